@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+// command is an aws-monitor subcommand. Run receives the arguments after the
+// command name and returns the process exit code.
+type command struct {
+	Name    string
+	Summary string
+	Run     func(args []string) int
+}
+
+// commands returns the aws-monitor subcommands in the order they are listed
+// in the usage message
+func commands() []command {
+	return []command{
+		{Name: "run", Summary: "Collect metrics until interrupted (the default)", Run: runRunCommand},
+		{Name: "validate", Summary: "Validate the configuration and exit", Run: runValidateCommand},
+		{Name: "version", Summary: "Show version information", Run: runVersionCommand},
+		{Name: "collect", Summary: "Collect metrics once and print them", Run: runCollectCommand},
+		{Name: "collectors", Summary: "List or describe the collectors, configured or of a running instance", Run: runCollectorsCommand},
+		{Name: "health", Summary: "Query the health endpoint of the local instance", Run: runHealthCommand},
+		{Name: "doctor", Summary: "Diagnose the configuration, connectivity, credentials and clock", Run: runDoctorCommand},
+		{Name: "dashboards", Summary: "Generate Grafana dashboards for the metrics of the enabled collectors", Run: runDashboardsCommand},
+		{Name: "config", Summary: "Generate configuration files: init, schema, policy or default", Run: func(args []string) int {
+			if err := runConfigCommand(args); err != nil {
+				fmt.Fprintf(os.Stderr, "config: %v\n", err)
+				return 1
+			}
+			return 0
+		}},
+	}
+}
+
+// runCLI runs the command named by the first argument. Without a command,
+// the flags of earlier releases are accepted: they run the daemon unless
+// -version, -validate, -check-permissions or -print-default-config select
+// another command.
+func runCLI(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runLegacy(args)
+	}
+	if args[0] == "help" {
+		usage(os.Stdout, nil)
+		return 0
+	}
+
+	for _, cmd := range commands() {
+		if cmd.Name == args[0] {
+			return cmd.Run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+	usage(os.Stderr, nil)
+	return 2
+}
+
+// usage writes the commands, and the flags accepted without a command, to w
+func usage(w io.Writer, legacy *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage of aws-monitor:\n  aws-monitor <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-12s%s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintf(w, "\nRun `aws-monitor <command> -help` for the flags of a command.\n")
+	if legacy != nil {
+		fmt.Fprintf(w, "\nFlags without a command:\n")
+		legacy.SetOutput(w)
+		legacy.PrintDefaults()
+	}
+}
+
+// runLegacy accepts the flags of earlier releases and runs the command they
+// select
+func runLegacy(args []string) int {
+	fs := flag.NewFlagSet("aws-monitor", flag.ContinueOnError)
+	var flags configFlags
+	flags.register(fs)
+	var (
+		showVersion  = fs.Bool("version", false, "Show version information")
+		validateOnly = fs.Bool("validate", false, "Validate configuration and exit")
+		printDefault = fs.Bool("print-default-config", false, "Print the default configuration and exit")
+		checkPerms   = fs.Bool("check-permissions", false, "Report the IAM permissions missing for the enabled collectors and exit")
+		format       = fs.String("format", "text", "Output format of -validate: text, or json for a report of the errors, warnings and defaulted settings")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			usage(os.Stdout, fs)
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		usage(os.Stderr, fs)
+		return 2
+	}
+
+	switch {
+	case *printDefault:
+		fmt.Print(string(config.DefaultYAML()))
+		return 0
+	case *showVersion:
+		printVersion(os.Stdout)
+		return 0
+	case *validateOnly:
+		return runValidation(&flags, false, *format)
+	case *checkPerms:
+		return runValidation(&flags, true, *format)
+	default:
+		return runDaemonWithFlags(&flags, runOptions{})
+	}
+}
+
+// configFlags are the flags shared by the commands that load a configuration
+// file
+type configFlags struct {
+	path      string
+	strict    bool
+	overrides config.Overrides
+}
+
+func (f *configFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.path, "config", "", "Path to configuration file, or an s3:// or ssm:// location")
+	fs.BoolVar(&f.strict, "strict", false, "Reject configuration files with unknown keys")
+
+	// Overrides of the configuration file, which take precedence over the
+	// AWS_MONITOR_* environment variables
+	fs.StringVar(&f.overrides.LogLevel, "log-level", "", "Override global.log_level (env "+config.EnvLogLevel+")")
+	fs.Func("regions", "Override enabled_regions with a comma-separated list (env "+config.EnvRegions+")", func(value string) error {
+		f.overrides.Regions = config.SplitList(value)
+		return nil
+	})
+	fs.StringVar(&f.overrides.OTELEndpoint, "otel-endpoint", "", "Override otel.collector_endpoint (env "+config.EnvOTELEndpoint+")")
+	fs.IntVar(&f.overrides.HealthPort, "health-port", 0, "Override global.health_check_port (env "+config.EnvHealthPort+")")
+}
+
+func (f *configFlags) loadOptions() config.LoadOptions {
+	return config.LoadOptions{Strict: f.strict, Overrides: f.overrides}
+}
+
+// load loads the configuration file, reporting failures on stderr
+func (f *configFlags) load() (*config.Config, bool) {
+	cfg, err := config.LoadWithOptions(f.path, f.loadOptions())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return nil, false
+	}
+	return cfg, true
+}
+
+// newCommandFlags returns the flag set of a command, which reports parse
+// errors and -help on stderr
+func newCommandFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("aws-monitor "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseExitCode returns the exit code for a flag parsing error: 0 for -help
+func parseExitCode(err error) int {
+	if err == flag.ErrHelp {
+		return 0
+	}
+	return 2
+}
+
+// startLogging initializes the global logger and logs the startup and
+// configuration details. The returned function flushes the logger.
+func startLogging(cfg *config.Config, flags *configFlags, loggerConfig logger.Config) (*logger.Logger, func(), bool) {
+	if err := logger.InitializeGlobal(loggerConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		return nil, nil, false
+	}
+
+	// Ensure logs are flushed, and exported logs delivered, on exit
+	flush := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := logger.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to flush logger: %v\n", err)
+		}
+	}
+
+	mainLogger := logger.WithComponent("main")
+	mainLogger.LogStartup(version, buildTime, gitCommit)
+
+	// Log configuration details
+	mainLogger.LogConfigLoad(flags.path, cfg.EnabledRegions)
+	for _, deprecation := range cfg.Deprecations() {
+		mainLogger.Warn("Deprecated configuration key", logger.String("deprecation", deprecation.String()))
+	}
+	for _, key := range cfg.UnknownKeys() {
+		mainLogger.Warn("Unknown configuration key ignored", logger.String("key", key.String()))
+	}
+	for _, key := range cfg.OverriddenKeys() {
+		mainLogger.Info("Configuration setting overridden", logger.String("key", key))
+	}
+	mainLogger.Info("OpenTelemetry configuration",
+		logger.String("endpoint", cfg.OTEL.CollectorEndpoint),
+		logger.String("service_name", cfg.OTEL.ServiceName),
+		logger.Bool("insecure", cfg.OTEL.Insecure),
+		logger.Bool("export_logs", cfg.OTEL.ExportLogs),
+		logger.Bool("export_traces", cfg.OTEL.ExportTraces),
+	)
+	for name, collectorCfg := range metricsConfigs(cfg) {
+		mainLogger.LogCollectorStatus(name, collectorCfg.Enabled, time.Duration(collectorCfg.CollectionInterval))
+	}
+	return mainLogger, flush, true
+}
+
+// metricsConfigs returns the collector configurations of the metrics section
+// by name
+func metricsConfigs(cfg *config.Config) map[string]config.CollectorConfig {
+	return map[string]config.CollectorConfig{
+		"ec2":    cfg.Metrics.EC2,
+		"rds":    cfg.Metrics.RDS,
+		"s3":     cfg.Metrics.S3,
+		"lambda": cfg.Metrics.Lambda,
+		"ebs":    cfg.Metrics.EBS,
+		"elb":    cfg.Metrics.ELB,
+		"vpc":    cfg.Metrics.VPC,
+	}
+}
+
+// runOptions are the flags of `aws-monitor run` that do not apply to other
+// commands
+type runOptions struct {
+	// dryRun writes metrics to dryRunOutput, stdout when empty, instead of
+	// exporting them
+	dryRun       bool
+	dryRunOutput string
+	// pidFile is written with the process ID while the daemon runs
+	pidFile string
+	// background detaches the daemon from the terminal
+	background bool
+}
+
+// runRunCommand implements `aws-monitor run`
+func runRunCommand(args []string) int {
+	fs := newCommandFlags("run")
+	var flags configFlags
+	flags.register(fs)
+	var opts runOptions
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Write metrics to stdout, or -dry-run-output, instead of exporting them")
+	fs.StringVar(&opts.dryRunOutput, "dry-run-output", "", "File to write dry run metrics to, as JSON lines")
+	fs.StringVar(&opts.pidFile, "pid-file", "", "Write the process ID to this file while running")
+	fs.BoolVar(&opts.background, "background", false, "Detach from the terminal and run in the background (default: run in the foreground)")
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if opts.dryRunOutput != "" {
+		opts.dryRun = true
+	}
+	if opts.background {
+		return runInBackground(&flags, opts, args)
+	}
+	return runDaemonWithFlags(&flags, opts)
+}
+
+// runInBackground validates the configuration, so that errors are reported
+// on the terminal, and starts `aws-monitor run` with args, without
+// -background, detached from it. With a PID file, it waits until the daemon
+// has written it.
+func runInBackground(flags *configFlags, opts runOptions, args []string) int {
+	if _, ok := flags.load(); !ok {
+		return 1
+	}
+
+	daemonArgs := []string{"run"}
+	for _, arg := range args {
+		switch strings.TrimLeft(arg, "-") {
+		case "background", "background=true":
+			continue
+		}
+		daemonArgs = append(daemonArgs, arg)
+	}
+	pid, err := startBackground(daemonArgs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start in the background: %v\n", err)
+		return 1
+	}
+	if opts.pidFile != "" && !waitForPIDFile(opts.pidFile, pid, 10*time.Second) {
+		fmt.Fprintf(os.Stderr, "aws-monitor (PID %d) did not write %s; check its logs\n", pid, opts.pidFile)
+		return 1
+	}
+	fmt.Printf("aws-monitor started in the background with PID %d\n", pid)
+	return 0
+}
+
+func runDaemonWithFlags(flags *configFlags, opts runOptions) int {
+	cfg, ok := flags.load()
+	if !ok {
+		return 1
+	}
+	if opts.pidFile != "" {
+		removePIDFile, err := writePIDFile(opts.pidFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write PID file: %v\n", err)
+			return 1
+		}
+		defer removePIDFile()
+	}
+	loggerConfig := newLoggerConfig(cfg)
+	if opts.dryRun {
+		// Nothing is sent to backends in a dry run, nor saved to the state
+		// file, and logs must not be mixed with metrics written to stdout
+		cfg.OTEL.ExportLogs = false
+		cfg.OTEL.ExportTraces = false
+		cfg.Alerting.Enabled = false
+		cfg.Events.Enabled = false
+		cfg.Global.ErrorTracking.Enabled = false
+		cfg.Global.State.Path = ""
+		loggerConfig = newLoggerConfig(cfg)
+		if opts.dryRunOutput == "" && (loggerConfig.OutputPath == "" || loggerConfig.OutputPath == "stdout") {
+			loggerConfig.OutputPath = "stderr"
+		}
+	}
+	host := detectPlatform(cfg.Global.Platform)
+	loggerConfig.OTLP.ResourceAttributes = host.MergeAttributes(loggerConfig.OTLP.ResourceAttributes)
+	mainLogger, flush, ok := startLogging(cfg, flags, loggerConfig)
+	if !ok {
+		return 1
+	}
+	defer flush()
+	return runDaemon(cfg, flags, opts, host, mainLogger)
+}
+
+// openDryRunOutput opens the file dry run metrics are appended to, or stdout
+// when path is empty
+func openDryRunOutput(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func dryRunOutputName(path string) string {
+	if path == "" {
+		return "stdout"
+	}
+	return path
+}
+
+// runVersionCommand implements `aws-monitor version`
+func runVersionCommand(args []string) int {
+	fs := newCommandFlags("version")
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	printVersion(os.Stdout)
+	return 0
+}
+
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "AWS Monitor %s\n", version)
+	fmt.Fprintf(w, "Build Time: %s\n", buildTime)
+	fmt.Fprintf(w, "Git Commit: %s\n", gitCommit)
+}
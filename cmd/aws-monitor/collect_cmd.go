@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+)
+
+// runCollectCommand implements `aws-monitor collect`, which runs the enabled
+// collectors, or the one named by -collector, once in each of their regions,
+// prints the metrics and exits. Logs are written to stderr unless the
+// configuration sends them to a file.
+func runCollectCommand(args []string) int {
+	fs := newCommandFlags("collect")
+	var flags configFlags
+	flags.register(fs)
+	name := fs.String("collector", "", "Collect only with the named collector, even if it is disabled")
+	region := fs.String("region", "", "Collect only in this region")
+	format := fs.String("format", "table", "Output format: table or json")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Maximum time to spend collecting")
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "collect: unknown format %q\n", *format)
+		return 2
+	}
+
+	cfg, ok := flags.load()
+	if !ok {
+		return 1
+	}
+	plugins, err := selectPlugins(cfg, *name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "collect: %v\n", err)
+		return 1
+	}
+	if *region != "" && !containsRegion(cfg.EnabledRegions, *region) {
+		fmt.Fprintf(os.Stderr, "collect: region %s is not in enabled_regions\n", *region)
+		return 1
+	}
+
+	loggerConfig := newLoggerConfig(cfg)
+	if loggerConfig.OutputPath == "" || loggerConfig.OutputPath == "stdout" {
+		loggerConfig.OutputPath = "stderr"
+	}
+	mainLogger, flush, ok := startLogging(cfg, &flags, loggerConfig)
+	if !ok {
+		return 1
+	}
+	defer flush()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	provider := aws.NewClientProvider(cfg, mainLogger)
+	defer provider.Close()
+	enabled, err := collectors.LoadPluginCollectors(plugins, collectors.CollectorDependencies{
+		Config:      cfg,
+		AWSProvider: provider,
+		Inventory:   aws.NewInventoryCache(provider, time.Duration(cfg.AWS.InventoryTTL), mainLogger),
+		Account:     resolveAccountInfo(ctx, provider, cfg.AWS.DefaultRegion, mainLogger),
+		Logger:      mainLogger,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load plugin collectors: %v\n", err)
+		return 1
+	}
+
+	results, failed := collectOnce(ctx, enabled, *region, os.Stderr)
+	if *format == "json" {
+		err = writeResultsJSON(os.Stdout, results)
+	} else {
+		err = writeResultsTable(os.Stdout, results)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "collect: %v\n", err)
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// selectPlugins returns the plugin configurations to collect with: all of
+// them, of which only the enabled ones are used, or the one named name,
+// enabled
+func selectPlugins(cfg *config.Config, name string) ([]config.PluginConfig, error) {
+	if name == "" {
+		return cfg.Plugins, nil
+	}
+	for _, plugin := range cfg.Plugins {
+		if plugin.Name == name {
+			plugin.Enabled = true
+			return []config.PluginConfig{plugin}, nil
+		}
+	}
+	if _, ok := metricsConfigs(cfg)[name]; ok {
+		return nil, fmt.Errorf("metrics.%s has no collector in this build; only plugin collectors can be run", name)
+	}
+	return nil, fmt.Errorf("no collector named %q", name)
+}
+
+// collectOnce starts each collector, collects in each of its regions, or only
+// in region when set, and stops it. It returns the results in collector and
+// region order, and whether any collection failed. Start and stop failures
+// are reported to stderr.
+func collectOnce(ctx context.Context, enabled []collectors.MetricCollector, region string, stderr io.Writer) ([]*collectors.CollectionResult, bool) {
+	sort.Slice(enabled, func(i, j int) bool { return enabled[i].Name() < enabled[j].Name() })
+
+	var results []*collectors.CollectionResult
+	failed := false
+	for _, collector := range enabled {
+		regions := collector.Info().EnabledRegions
+		if region != "" {
+			if !containsRegion(regions, region) {
+				continue
+			}
+			regions = []string{region}
+		}
+
+		if err := collector.Start(ctx); err != nil {
+			fmt.Fprintf(stderr, "Failed to start collector %s: %v\n", collector.Name(), err)
+			failed = true
+			continue
+		}
+		for _, r := range regions {
+			result := collector.Collect(ctx, r)
+			if result == nil {
+				continue
+			}
+			if result.Error != nil {
+				failed = true
+			}
+			results = append(results, result)
+		}
+		if err := collector.Stop(ctx); err != nil {
+			fmt.Fprintf(stderr, "Failed to stop collector %s: %v\n", collector.Name(), err)
+		}
+	}
+	return results, failed
+}
+
+func containsRegion(regions []string, region string) bool {
+	for _, r := range regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+func writeResultsJSON(w io.Writer, results []*collectors.CollectionResult) error {
+	if results == nil {
+		results = []*collectors.CollectionResult{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+// writeResultsTable writes a row per metric, and per failed collection, of
+// the results
+func writeResultsTable(w io.Writer, results []*collectors.CollectionResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTOR\tREGION\tMETRIC\tVALUE\tUNIT\tLABELS")
+	for _, result := range results {
+		if result.Error != nil {
+			fmt.Fprintf(tw, "%s\t%s\terror\t-\t-\t%s\n", result.CollectorName, result.Region, result.Error.Error())
+		}
+		for _, metric := range result.Metrics {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%g\t%s\t%s\n", result.CollectorName, result.Region,
+				metric.Name, metric.Value, metric.Unit, formatLabels(metric.Labels))
+		}
+	}
+	return tw.Flush()
+}
+
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"aws-monitoring/internal/admin"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+)
+
+// runCollectorsCommand implements `aws-monitor collectors list` and
+// `aws-monitor collectors describe`. With -url they query the admin API of a
+// running instance; otherwise they describe the collectors of the
+// configuration file. Without a subcommand, the collectors are listed.
+func runCollectorsCommand(args []string) int {
+	subcommand := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcommand, args = args[0], args[1:]
+	}
+
+	var err error
+	switch subcommand {
+	case "list":
+		err = runCollectorsList(args, os.Stdout)
+	case "describe":
+		err = runCollectorsDescribe(args, os.Stdout)
+	default:
+		err = fmt.Errorf("unknown command %q; usage: aws-monitor collectors list|describe [flags]", subcommand)
+	}
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "collectors: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// collectorsFlags are the flags of the collectors subcommands
+type collectorsFlags struct {
+	configFlags
+	url     string
+	timeout time.Duration
+}
+
+func newCollectorsFlags(name string) (*flag.FlagSet, *collectorsFlags) {
+	fs := newCommandFlags("collectors " + name)
+	var flags collectorsFlags
+	flags.register(fs)
+	fs.StringVar(&flags.url, "url", "", "Query the admin API of the instance at this URL, e.g. http://localhost:8080, using the admin credentials of the configuration")
+	fs.DurationVar(&flags.timeout, "timeout", 10*time.Second, "Maximum time to wait for the admin API")
+	return fs, &flags
+}
+
+// client returns the admin API client of the instance at the -url flag
+func (f *collectorsFlags) client(cfg *config.Config) *adminClient {
+	return &adminClient{
+		baseURL:     strings.TrimSuffix(f.url, "/"),
+		credentials: cfg.Admin,
+		http:        &http.Client{Timeout: f.timeout},
+	}
+}
+
+// runCollectorsList implements `aws-monitor collectors list`
+func runCollectorsList(args []string, stdout io.Writer) error {
+	fs, flags := newCollectorsFlags("list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.LoadWithOptions(flags.path, flags.loadOptions())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if flags.url == "" {
+		writeCollectors(stdout, cfg, collectors.RegisteredCollectorTypes())
+		return nil
+	}
+	var list adminCollectorList
+	if err := flags.client(cfg).get(context.Background(), "collectors", &list); err != nil {
+		return err
+	}
+	writeCollectorStatus(stdout, list)
+	return nil
+}
+
+// runCollectorsDescribe implements `aws-monitor collectors describe NAME`.
+// The name may come before or after the flags.
+func runCollectorsDescribe(args []string, stdout io.Writer) error {
+	fs, flags := newCollectorsFlags("describe")
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
+	}
+	if name == "" {
+		return fmt.Errorf("usage: aws-monitor collectors describe [flags] NAME")
+	}
+	cfg, err := config.LoadWithOptions(flags.path, flags.loadOptions())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if flags.url == "" {
+		return describeConfiguredCollector(stdout, cfg, name)
+	}
+	var detail admin.CollectorDetail
+	if err := flags.client(cfg).get(context.Background(), "collectors/"+url.PathEscape(name), &detail); err != nil {
+		return err
+	}
+	writeCollectorDetail(stdout, detail)
+	return nil
+}
+
+// writeCollectors writes a table of the metrics and plugin collectors of cfg,
+// followed by the registered collector types
+func writeCollectors(w io.Writer, cfg *config.Config, types []string) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tENABLED\tINTERVAL\tREGIONS")
+
+	metrics := metricsConfigs(cfg)
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		collectorCfg := metrics[name]
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\n", name, "metrics", collectorCfg.Enabled,
+			collectorInterval(collectorCfg.CollectionInterval, cfg), formatRegions(nil, cfg))
+	}
+	for _, plugin := range cfg.Plugins {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\n", plugin.Name, plugin.Type, plugin.Enabled,
+			collectorInterval(plugin.CollectionInterval, cfg), formatRegions(plugin.Regions, cfg))
+	}
+	tw.Flush()
+
+	if len(types) > 0 {
+		fmt.Fprintf(w, "\nRegistered collector types: %s\n", strings.Join(types, ", "))
+	}
+}
+
+// describeConfiguredCollector writes the effective configuration of the
+// named metrics section or plugin collector, with defaults applied
+func describeConfiguredCollector(w io.Writer, cfg *config.Config, name string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	defer tw.Flush()
+
+	if collectorCfg, ok := metricsConfigs(cfg)[name]; ok {
+		fmt.Fprintf(tw, "Name:\t%s\n", name)
+		fmt.Fprintf(tw, "Type:\tmetrics\n")
+		fmt.Fprintf(tw, "Enabled:\t%t\n", collectorCfg.Enabled)
+		fmt.Fprintf(tw, "Interval:\t%s\n", collectorInterval(collectorCfg.CollectionInterval, cfg))
+		fmt.Fprintf(tw, "Region intervals:\t%s\n", formatRegionIntervals(collectorCfg.RegionIntervals))
+		fmt.Fprintf(tw, "Regions:\t%s\n", formatRegions(nil, cfg))
+		fmt.Fprintf(tw, "Groups:\t%s\n", strings.Join(collectorCfg.Groups, ","))
+		fmt.Fprintf(tw, "Disabled metrics:\t%s\n", strings.Join(collectorCfg.DisabledMetrics, ","))
+		return nil
+	}
+
+	for _, plugin := range cfg.Plugins {
+		if plugin.Name != name {
+			continue
+		}
+		fmt.Fprintf(tw, "Name:\t%s\n", plugin.Name)
+		fmt.Fprintf(tw, "Type:\t%s\n", plugin.Type)
+		fmt.Fprintf(tw, "Enabled:\t%t\n", plugin.Enabled)
+		fmt.Fprintf(tw, "Interval:\t%s\n", collectorInterval(plugin.CollectionInterval, cfg))
+		fmt.Fprintf(tw, "Region intervals:\t%s\n", formatRegionIntervals(plugin.RegionIntervals))
+		fmt.Fprintf(tw, "Regions:\t%s\n", formatRegions(plugin.Regions, cfg))
+		fmt.Fprintf(tw, "Groups:\t%s\n", strings.Join(plugin.Groups, ","))
+		fmt.Fprintf(tw, "Disabled metrics:\t%s\n", strings.Join(plugin.DisabledMetrics, ","))
+		fmt.Fprintf(tw, "Settings:\t%s\n", formatLabels(plugin.Settings))
+		return nil
+	}
+	return fmt.Errorf("no collector named %q in the configuration", name)
+}
+
+// adminCollectorList is the response to GET /admin/collectors
+type adminCollectorList struct {
+	Collectors map[string]collectors.CollectorInfo `json:"collectors"`
+	Removed    []string                            `json:"removed"`
+}
+
+// writeCollectorStatus writes a table of the collectors of a running instance
+func writeCollectorStatus(w io.Writer, list adminCollectorList) {
+	names := make([]string, 0, len(list.Collectors))
+	for name := range list.Collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATUS\tINTERVAL\tREGIONS\tLAST RUN\tMETRICS\tERRORS")
+	for _, name := range names {
+		info := list.Collectors[name]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n", name, info.Status, info.Interval,
+			strings.Join(info.EnabledRegions, ","), formatTime(info.LastCollection), info.MetricsCollected, info.ErrorCount)
+	}
+	tw.Flush()
+
+	if len(list.Removed) > 0 {
+		sort.Strings(list.Removed)
+		fmt.Fprintf(w, "\nRemoved: %s\n", strings.Join(list.Removed, ", "))
+	}
+}
+
+// writeCollectorDetail writes the status and schedule of a collector of a
+// running instance
+func writeCollectorDetail(w io.Writer, detail admin.CollectorDetail) {
+	info := detail.Collector
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", info.Name)
+	fmt.Fprintf(tw, "Description:\t%s\n", info.Description)
+	fmt.Fprintf(tw, "Status:\t%s\n", info.Status)
+	fmt.Fprintf(tw, "Interval:\t%s\n", info.Interval)
+	fmt.Fprintf(tw, "Region intervals:\t%s\n", formatDurations(info.RegionIntervals))
+	fmt.Fprintf(tw, "Regions:\t%s\n", strings.Join(info.EnabledRegions, ","))
+	fmt.Fprintf(tw, "Groups:\t%s\n", strings.Join(info.Groups, ","))
+	fmt.Fprintf(tw, "Last run:\t%s\n", formatTime(info.LastCollection))
+	fmt.Fprintf(tw, "Metrics collected:\t%d\n", info.MetricsCollected)
+	fmt.Fprintf(tw, "Successful runs:\t%d\n", info.SuccessfulCollections)
+	fmt.Fprintf(tw, "Errors:\t%d\n", info.ErrorCount)
+	if info.LastError != nil {
+		fmt.Fprintf(tw, "Last error:\t%s\n", info.LastError.Error())
+	}
+	tw.Flush()
+
+	if len(detail.Jobs) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REGION\tINTERVAL\tLAST RUN\tNEXT RUN\tLAST METRICS")
+	for _, job := range detail.Jobs {
+		nextRun := job.NextRun
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", job.Region, job.Interval, formatTime(job.LastRun),
+			formatTime(&nextRun), job.LastMetricCount)
+	}
+	tw.Flush()
+}
+
+func collectorInterval(interval config.Duration, cfg *config.Config) string {
+	if interval == 0 {
+		interval = cfg.Global.DefaultInterval
+	}
+	return time.Duration(interval).String()
+}
+
+func formatRegions(regions []string, cfg *config.Config) string {
+	if len(regions) == 0 {
+		regions = cfg.EnabledRegions
+	}
+	return strings.Join(regions, ",")
+}
+
+func formatRegionIntervals(intervals map[string]config.Duration) string {
+	durations := make(map[string]time.Duration, len(intervals))
+	for region, interval := range intervals {
+		durations[region] = time.Duration(interval)
+	}
+	return formatDurations(durations)
+}
+
+func formatDurations(durations map[string]time.Duration) string {
+	pairs := make([]string, 0, len(durations))
+	for key, duration := range durations {
+		pairs = append(pairs, key+"="+duration.String())
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// adminClient queries the admin API of a running instance
+type adminClient struct {
+	baseURL     string
+	credentials config.AdminConfig
+	http        *http.Client
+}
+
+// get decodes the JSON response to GET /admin/<path> into v
+func (c *adminClient) get(ctx context.Context, path string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+admin.PathPrefix+path, nil)
+	if err != nil {
+		return err
+	}
+	authorize(request, c.credentials.Token, c.credentials.Username, c.credentials.Password)
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to query the admin API: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(response.Body).Decode(&body)
+		if body.Error == "" {
+			body.Error = response.Status
+		}
+		return fmt.Errorf("admin API returned %d: %s", response.StatusCode, body.Error)
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
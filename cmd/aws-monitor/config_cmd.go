@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+// runConfigCommand runs an `aws-monitor config` subcommand
+func runConfigCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: aws-monitor config init|schema|policy|default [flags]")
+	}
+
+	switch args[0] {
+	case "init":
+		return runConfigInit(args[1:], os.Stdin, os.Stdout, isTerminal(os.Stdin))
+	case "schema":
+		return runConfigSchema(args[1:], os.Stdout)
+	case "policy":
+		return runConfigPolicy(args[1:], os.Stdout)
+	case "default":
+		// The default configuration, as printed by --print-default-config
+		_, err := os.Stdout.Write(config.DefaultYAML())
+		return err
+	default:
+		return fmt.Errorf("unknown command %q; usage: aws-monitor config init|schema|policy|default [flags]", args[0])
+	}
+}
+
+// runConfigInit implements `aws-monitor config init`, which writes a
+// commented example configuration file. When interactive, it prompts for the
+// regions and OTEL endpoint unless they are given as flags.
+func runConfigInit(args []string, stdin io.Reader, stdout io.Writer, interactive bool) error {
+	fs := flag.NewFlagSet("config init", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	output := fs.String("output", "config.yaml", "Path to write the configuration file to")
+	minimal := fs.Bool("minimal", false, "Write only the required settings instead of every setting")
+	regions := fs.String("regions", "", "Comma-separated AWS regions to monitor (default "+config.DefaultExampleRegion+")")
+	endpoint := fs.String("otel-endpoint", "", "OpenTelemetry collector endpoint (default "+config.DefaultExampleEndpoint+")")
+	force := fs.Bool("force", false, "Overwrite the file if it exists")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	if interactive {
+		reader := bufio.NewReader(stdin)
+		if *regions == "" {
+			*regions = prompt(reader, stdout, "AWS regions to monitor, comma-separated", config.DefaultExampleRegion)
+		}
+		if *endpoint == "" {
+			*endpoint = prompt(reader, stdout, "OpenTelemetry collector endpoint", config.DefaultExampleEndpoint)
+		}
+	}
+
+	var buf bytes.Buffer
+	opts := config.ExampleOptions{
+		Regions:           splitList(*regions),
+		CollectorEndpoint: *endpoint,
+		Minimal:           *minimal,
+	}
+	if err := config.WriteExample(&buf, opts); err != nil {
+		return err
+	}
+
+	if err := writeNewFile(*output, buf.Bytes(), *force); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Wrote %s; check it with: aws-monitor -config %s -validate\n", *output, *output)
+	return nil
+}
+
+// runConfigSchema implements `aws-monitor config schema`, which writes the
+// JSON Schema of configuration files to stdout or a file
+func runConfigSchema(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("config schema", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	output := fs.String("output", "", "Path to write the schema to instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	schema, err := config.JSONSchema()
+	if err != nil {
+		return err
+	}
+	schema = append(schema, '\n')
+
+	if *output == "" {
+		_, err := stdout.Write(schema)
+		return err
+	}
+	if err := os.WriteFile(*output, schema, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", *output)
+	return nil
+}
+
+// runConfigPolicy implements `aws-monitor config policy`, which writes the
+// least-privilege IAM policy for the enabled collectors and regions of a
+// configuration file to stdout or a file
+func runConfigPolicy(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("config policy", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	configPath := fs.String("config", "", "Path to configuration file, or an s3:// or ssm:// location")
+	output := fs.String("output", "", "Path to write the policy to instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Collectors may log while they are created; keep stdout for the policy
+	log, err := logger.NewLogger(logger.Config{Level: "warn", Format: "text", OutputPath: "stderr", ErrorPath: "stderr"})
+	if err != nil {
+		return err
+	}
+	requirements, err := permissionRequirements(cfg, aws.NewClientProvider(cfg, log), log)
+	if err != nil {
+		return err
+	}
+
+	var permissions []aws.Permission
+	for _, requirement := range requirements {
+		permissions = append(permissions, requirement.Permissions...)
+	}
+	policy, err := json.MarshalIndent(aws.NewPolicy(permissions, cfg.EnabledRegions), "", "  ")
+	if err != nil {
+		return err
+	}
+	policy = append(policy, '\n')
+
+	if *output == "" {
+		_, err := stdout.Write(policy)
+		return err
+	}
+	if err := os.WriteFile(*output, policy, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", *output)
+	return nil
+}
+
+// prompt asks for a value, returning def when the answer is empty
+func prompt(reader *bufio.Reader, stdout io.Writer, question, def string) string {
+	fmt.Fprintf(stdout, "%s [%s]: ", question, def)
+	answer, _ := reader.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// writeNewFile writes data to path, which must not exist unless overwrite is
+// set. The file may hold credentials, so it is only readable by its owner.
+func writeNewFile(path string, data []byte, overwrite bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0600)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s already exists; use -force to overwrite it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/grafana"
+	"aws-monitoring/internal/telemetry"
+	"aws-monitoring/pkg/logger"
+)
+
+// runDashboardsCommand implements `aws-monitor dashboards generate`
+func runDashboardsCommand(args []string) int {
+	if len(args) == 0 || args[0] != "generate" {
+		fmt.Fprintln(os.Stderr, "usage: aws-monitor dashboards generate [flags]")
+		return 2
+	}
+
+	err := runDashboardsGenerate(args[1:], os.Stdout)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dashboards: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runDashboardsGenerate implements `aws-monitor dashboards generate`, which
+// writes a Grafana dashboard for the metrics of the enabled collectors to
+// stdout or a file
+func runDashboardsGenerate(args []string, stdout io.Writer) error {
+	fs := newCommandFlags("dashboards generate")
+	var flags configFlags
+	flags.register(fs)
+	backend := fs.String("backend", string(grafana.BackendPrometheus), "Metrics backend the dashboard queries: prometheus or cloudwatch")
+	namespace := fs.String("namespace", "", "CloudWatch namespace of the metrics (default otel.service_name)")
+	title := fs.String("title", "aws-monitor", "Dashboard title")
+	output := fs.String("output", "", "Path to write the dashboard to instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	selected, err := grafana.ParseBackend(*backend)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadWithOptions(flags.path, flags.loadOptions())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	// The collector's awsemf exporter publishes to a namespace named after
+	// the service unless configured otherwise
+	if *namespace == "" {
+		*namespace = cfg.OTEL.ServiceName
+	}
+
+	// Collectors may log while they are created; keep stdout for the dashboard
+	log, err := logger.NewLogger(logger.Config{Level: "warn", Format: "text", OutputPath: "stderr", ErrorPath: "stderr"})
+	if err != nil {
+		return err
+	}
+	sections, err := dashboardSections(cfg, aws.NewClientProvider(cfg, log), log)
+	if err != nil {
+		return err
+	}
+
+	dashboard, err := grafana.Generate(sections, grafana.Options{
+		Backend:   selected,
+		Title:     *title,
+		Regions:   cfg.EnabledRegions,
+		Namespace: *namespace,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err := stdout.Write(dashboard)
+		return err
+	}
+	if err := os.WriteFile(*output, dashboard, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	fmt.Fprintf(stdout, "Wrote %s; import it in Grafana under Dashboards > New > Import\n", *output)
+	return nil
+}
+
+// dashboardSections creates the enabled collectors, without starting them,
+// and returns the metrics each declares, by collector name. The
+// self-telemetry collector comes last.
+func dashboardSections(cfg *config.Config, provider aws.ClientProvider, log *logger.Logger) ([]grafana.Section, error) {
+	enabled, err := collectors.LoadPluginCollectors(cfg.Plugins, collectors.CollectorDependencies{
+		Config:      cfg,
+		AWSProvider: provider,
+		Logger:      log,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin collectors: %w", err)
+	}
+	sort.Slice(enabled, func(i, j int) bool { return enabled[i].Name() < enabled[j].Name() })
+	if cfg.Global.SelfMetrics.Enabled {
+		enabled = append(enabled, telemetry.NewCollector(cfg, telemetry.NewRegistry(), log))
+	}
+
+	var sections []grafana.Section
+	var undeclared []string
+	for _, collector := range enabled {
+		metrics := collectors.DescribeMetrics(collector)
+		if len(metrics) == 0 {
+			undeclared = append(undeclared, collector.Name())
+			continue
+		}
+		sections = append(sections, grafana.Section{Collector: collector.Name(), Metrics: metrics})
+	}
+	if len(undeclared) > 0 {
+		log.Warn("Collectors without declared metrics are left out of the dashboard",
+			logger.Strings("collectors", undeclared))
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("no enabled collector declares its metrics")
+	}
+	return sections, nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/discovery"
+	"aws-monitoring/pkg/logger"
+)
+
+// doctorStatus is the outcome of a doctor check
+type doctorStatus string
+
+const (
+	doctorPass doctorStatus = "PASS"
+	doctorWarn doctorStatus = "WARN"
+	doctorFail doctorStatus = "FAIL"
+)
+
+const (
+	// clockSkewWarning is the clock skew reported as a warning
+	clockSkewWarning = time.Minute
+	// clockSkewLimit is the clock skew beyond which AWS rejects signed
+	// requests
+	clockSkewLimit = 5 * time.Minute
+)
+
+// doctorResult is the outcome of a doctor check, with a hint at how to fix a
+// failure
+type doctorResult struct {
+	Check   string
+	Status  doctorStatus
+	Message string
+	Hint    string
+}
+
+// runDoctorCommand implements `aws-monitor doctor`, which diagnoses the
+// configuration and the environment aws-monitor runs in
+func runDoctorCommand(args []string) int {
+	fs := newCommandFlags("doctor")
+	var flags configFlags
+	flags.register(fs)
+	timeout := fs.Duration("timeout", 10*time.Second, "Maximum time for each network check")
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+
+	results := runDoctor(context.Background(), &flags, *timeout)
+	writeDoctorReport(os.Stdout, results)
+	for _, result := range results {
+		if result.Status == doctorFail {
+			return 1
+		}
+	}
+	return 0
+}
+
+// runDoctor runs the doctor checks. The checks after loading the
+// configuration are only run if it loads.
+func runDoctor(ctx context.Context, flags *configFlags, timeout time.Duration) []doctorResult {
+	cfg, result := checkConfiguration(flags)
+	results := []doctorResult{result}
+	if cfg == nil {
+		return results
+	}
+
+	// Only failures are logged, so the report stays readable
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json", OutputPath: "stderr"})
+	if err != nil {
+		return append(results, doctorResult{Check: "logger", Status: doctorFail, Message: err.Error()})
+	}
+	provider := aws.NewClientProvider(cfg, log)
+	defer provider.Close()
+
+	results = append(results, checkOTELEndpoint(ctx, cfg.OTEL.CollectorEndpoint, timeout))
+	for _, region := range cfg.EnabledRegions {
+		results = append(results, checkRegionCredentials(ctx, provider, region, timeout))
+	}
+	results = append(results, checkIAMPermissions(ctx, cfg, provider, log, timeout))
+	results = append(results, checkClockSkew(ctx, stsEndpoint(cfg.AWS.DefaultRegion), timeout))
+	return results
+}
+
+// checkConfiguration loads the configuration, returning nil if it is invalid
+func checkConfiguration(flags *configFlags) (*config.Config, doctorResult) {
+	result := doctorResult{Check: "configuration"}
+	cfg, err := config.LoadWithOptions(flags.path, flags.loadOptions())
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = err.Error()
+		result.Hint = "Fix the reported settings; `aws-monitor config schema` gives editors completion and inline errors"
+		return nil, result
+	}
+
+	path, _ := config.ResolvePath(flags.path)
+	result.Status = doctorPass
+	result.Message = "loaded from " + path
+	var notes []string
+	if n := len(cfg.UnknownKeys()); n > 0 {
+		notes = append(notes, fmt.Sprintf("%d unknown keys ignored", n))
+	}
+	if n := len(cfg.Deprecations()); n > 0 {
+		notes = append(notes, fmt.Sprintf("%d deprecated keys", n))
+	}
+	if len(notes) > 0 {
+		result.Status = doctorWarn
+		result.Message += "; " + strings.Join(notes, ", ")
+		result.Hint = "Run `aws-monitor validate` to list the keys and their replacements"
+	}
+	return cfg, result
+}
+
+// checkOTELEndpoint resolves the OTEL collector endpoint and connects to it
+func checkOTELEndpoint(ctx context.Context, endpoint string, timeout time.Duration) doctorResult {
+	if discovery.IsSRV(endpoint) {
+		return checkSRVEndpoint(ctx, endpoint, timeout)
+	}
+
+	result := doctorResult{Check: "otel endpoint"}
+	address, err := endpointAddress(endpoint)
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = err.Error()
+		result.Hint = "Set otel.collector_endpoint to a URL such as http://otel-collector:4317"
+		return result
+	}
+	host, port, _ := net.SplitHostPort(address)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("cannot resolve %s: %v", host, err)
+		result.Hint = "Check the host name in otel.collector_endpoint and the DNS configuration"
+		return result
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("cannot connect to %s: %v", address, err)
+		result.Hint = fmt.Sprintf("Check that the collector is running and that firewalls and security groups allow TCP port %s", port)
+		return result
+	}
+	_ = conn.Close()
+
+	result.Status = doctorPass
+	result.Message = fmt.Sprintf("%s reachable (%s)", address, strings.Join(addrs, ", "))
+	return result
+}
+
+// checkSRVEndpoint checks the SRV record of an srv:// collector endpoint has
+// targets and that one of them accepts TCP connections
+func checkSRVEndpoint(ctx context.Context, endpoint string, timeout time.Duration) doctorResult {
+	result := doctorResult{Check: "otel endpoint"}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	targets, err := discovery.Lookup(ctx, endpoint)
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("cannot resolve %s: %v", endpoint, err)
+		result.Hint = "Check the SRV record named in otel.collector_endpoint and the DNS configuration"
+		return result
+	}
+
+	var dialer net.Dialer
+	var errs []string
+	for _, target := range targets {
+		conn, err := dialer.DialContext(ctx, "tcp", target)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		_ = conn.Close()
+
+		result.Status = doctorPass
+		result.Message = fmt.Sprintf("%s reachable (targets %s)", target, strings.Join(targets, ", "))
+		return result
+	}
+
+	result.Status = doctorFail
+	result.Message = fmt.Sprintf("cannot connect to any target of %s: %s", endpoint, strings.Join(errs, "; "))
+	result.Hint = "Check that the collectors are running and that firewalls and security groups allow the ports of the SRV targets"
+	return result
+}
+
+// endpointAddress returns the host:port of an endpoint URL, defaulting the
+// port from the scheme
+func endpointAddress(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("endpoint %q has no host", endpoint)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// checkRegionCredentials checks the AWS credentials are valid in region
+func checkRegionCredentials(ctx context.Context, provider aws.ClientProvider, region string, timeout time.Duration) doctorResult {
+	client, err := provider.GetSTSClient(region)
+	if err != nil {
+		return doctorResult{
+			Check:   "credentials " + region,
+			Status:  doctorFail,
+			Message: err.Error(),
+			Hint:    "Check the aws section of the configuration",
+		}
+	}
+	return checkCredentials(ctx, client, region, timeout)
+}
+
+// checkCredentials calls sts:GetCallerIdentity, which any valid credentials
+// are allowed to
+func checkCredentials(ctx context.Context, client aws.STSClient, region string, timeout time.Duration) doctorResult {
+	result := doctorResult{Check: "credentials " + region}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	identity, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = err.Error()
+		result.Hint = "Check aws.access_key_id and aws.secret_access_key, and that STS is activated in the region (IAM account settings)"
+		return result
+	}
+	result.Status = doctorPass
+	result.Message = "valid for " + awssdk.ToString(identity.Arn)
+	return result
+}
+
+// checkIAMPermissions simulates the permissions of aws-monitor and the
+// enabled collectors
+func checkIAMPermissions(ctx context.Context, cfg *config.Config, provider aws.ClientProvider, log *logger.Logger, timeout time.Duration) doctorResult {
+	result := doctorResult{Check: "iam permissions"}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var report strings.Builder
+	allowed, err := runPermissionCheck(ctx, cfg, provider, &report, log)
+	switch {
+	case err != nil:
+		result.Status = doctorWarn
+		result.Message = "could not simulate policies: " + err.Error()
+		result.Hint = "Allow iam:SimulatePrincipalPolicy on the credentials' own identity to check permissions"
+	case !allowed:
+		result.Status = doctorFail
+		result.Message = missingPermissions(report.String())
+		result.Hint = "Attach the policy printed by `aws-monitor config policy`"
+	default:
+		result.Status = doctorPass
+		result.Message = "every permission of the enabled collectors is allowed"
+	}
+	return result
+}
+
+// missingPermissions returns the "missing" lines of a permission report
+func missingPermissions(report string) string {
+	var missing []string
+	for _, line := range strings.Split(report, "\n") {
+		if strings.Contains(line, ": missing ") {
+			missing = append(missing, line)
+		}
+	}
+	return strings.Join(missing, "; ")
+}
+
+// stsEndpoint returns the regional STS endpoint, whose Date header is used
+// to measure clock skew
+func stsEndpoint(region string) string {
+	return fmt.Sprintf("https://sts.%s.amazonaws.com/", region)
+}
+
+// checkClockSkew compares the local clock with the Date header of an AWS
+// endpoint; AWS rejects requests signed with a skewed clock
+func checkClockSkew(ctx context.Context, endpoint string, timeout time.Duration) doctorResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return doctorResult{Check: "clock skew", Status: doctorWarn, Message: err.Error()}
+	}
+	start := time.Now()
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return doctorResult{
+			Check:   "clock skew",
+			Status:  doctorWarn,
+			Message: "could not reach " + endpoint + ": " + err.Error(),
+			Hint:    "Check outbound HTTPS access to AWS",
+		}
+	}
+	_ = response.Body.Close()
+
+	serverTime, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return doctorResult{Check: "clock skew", Status: doctorWarn, Message: "no Date header in the response from " + endpoint}
+	}
+	// Compare with the middle of the request, as the server did
+	local := start.Add(time.Since(start) / 2)
+	return evaluateClockSkew(local.Sub(serverTime))
+}
+
+// evaluateClockSkew reports a clock skew as passing, a warning past
+// clockSkewWarning or a failure past clockSkewLimit. The Date header has a
+// resolution of a second.
+func evaluateClockSkew(skew time.Duration) doctorResult {
+	result := doctorResult{Check: "clock skew"}
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	result.Message = fmt.Sprintf("local clock %s %s AWS", abs.Round(time.Second), direction)
+
+	switch {
+	case abs > clockSkewLimit:
+		result.Status = doctorFail
+		result.Hint = "Synchronize the clock with NTP; AWS rejects requests signed with a clock skewed by more than 5m"
+	case abs > clockSkewWarning:
+		result.Status = doctorWarn
+		result.Hint = "Synchronize the clock with NTP"
+	default:
+		result.Status = doctorPass
+	}
+	return result
+}
+
+// writeDoctorReport writes a line per check, followed by its hint when it did
+// not pass, and a summary
+func writeDoctorReport(w io.Writer, results []doctorResult) {
+	counts := make(map[doctorStatus]int)
+	for _, result := range results {
+		counts[result.Status]++
+		fmt.Fprintf(w, "[%s] %s: %s\n", result.Status, result.Check, result.Message)
+		if result.Status != doctorPass && result.Hint != "" {
+			fmt.Fprintf(w, "       hint: %s\n", result.Hint)
+		}
+	}
+	fmt.Fprintf(w, "%d passed, %d warnings, %d failed\n", counts[doctorPass], counts[doctorWarn], counts[doctorFail])
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/health"
+)
+
+// runHealthCommand implements `aws-monitor health`, which queries the health
+// endpoint of the local instance and exits with status 1 unless it is healthy
+// or degraded, for wrapper scripts, container HEALTHCHECK and systemd checks
+func runHealthCommand(args []string) int {
+	fs := newCommandFlags("health")
+	var flags configFlags
+	flags.register(fs)
+	baseURL := fs.String("url", "", "Base URL of the health endpoints (default from health_check_port and health_check_tls)")
+	detailed := fs.Bool("detailed", false, "Query /health/detailed and list every check")
+	insecure := fs.Bool("insecure", false, "Do not verify the TLS certificate of the health endpoints")
+	timeout := fs.Duration("timeout", 5*time.Second, "Maximum time to wait for the health endpoint")
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+
+	// The configuration gives the port and credentials; it is only optional
+	// when the URL is given
+	cfg := &config.Config{}
+	if *baseURL == "" || flags.path != "" {
+		loaded, ok := flags.load()
+		if !ok {
+			return 1
+		}
+		cfg = loaded
+	}
+	if *baseURL == "" {
+		*baseURL = healthBaseURL(cfg)
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	if *insecure {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	status, err := checkHealth(client, strings.TrimSuffix(*baseURL, "/"), cfg.Global.HealthCheckAuth, *detailed, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "health: %v\n", err)
+		return 1
+	}
+	if status != health.StatusHealthy && status != health.StatusDegraded {
+		return 1
+	}
+	return 0
+}
+
+// healthBaseURL returns the URL of the local instance's health check port
+func healthBaseURL(cfg *config.Config) string {
+	scheme := "http"
+	if cfg.Global.HealthCheckTLS.Enabled {
+		scheme = "https"
+	}
+	port := cfg.Global.HealthCheckPort
+	if port == 0 {
+		port = 8080
+	}
+	return fmt.Sprintf("%s://localhost:%d", scheme, port)
+}
+
+// checkHealth queries /health, or /health/detailed when detailed is set,
+// writes the status to w and returns it
+func checkHealth(client *http.Client, baseURL string, auth config.HealthCheckAuthConfig, detailed bool, w io.Writer) (health.Status, error) {
+	path, credentials := "/health", auth.Probes
+	if detailed {
+		path, credentials = "/health/detailed", auth.Detailed
+	}
+	request, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	authorize(request, credentials.Token, credentials.Username, credentials.Password)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to query %s: %w", request.URL, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return "", fmt.Errorf("%s returned %s; check global.health_check_auth", request.URL, response.Status)
+	}
+
+	// Unhealthy responses carry the same body, with status 503
+	var overall health.OverallHealth
+	if err := json.NewDecoder(response.Body).Decode(&overall); err != nil {
+		return "", fmt.Errorf("%s returned %s and an unexpected body: %w", request.URL, response.Status, err)
+	}
+	if overall.Status == "" {
+		return "", fmt.Errorf("%s returned %s without a status", request.URL, response.Status)
+	}
+
+	if !detailed {
+		fmt.Fprintf(w, "%s\n", overall.Status)
+		return overall.Status, nil
+	}
+	writeHealthDetail(w, overall)
+	return overall.Status, nil
+}
+
+// writeHealthDetail writes the overall status and a row per check
+func writeHealthDetail(w io.Writer, overall health.OverallHealth) {
+	fmt.Fprintf(w, "%s", overall.Status)
+	if overall.Summary != "" {
+		fmt.Fprintf(w, ": %s", overall.Summary)
+	}
+	fmt.Fprintln(w)
+
+	names := make([]string, 0, len(overall.Checks))
+	for name := range overall.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tMESSAGE")
+	for _, name := range names {
+		check := overall.Checks[name]
+		message := check.Message
+		if check.Error != "" {
+			message = check.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, check.Status, message)
+	}
+	tw.Flush()
+}
+
+// authorize adds a bearer token or, without one, basic auth credentials to
+// a request
+func authorize(request *http.Request, token, username, password string) {
+	switch {
+	case token != "":
+		request.Header.Set("Authorization", "Bearer "+token)
+	case username != "":
+		request.SetBasicAuth(username, password)
+	}
+}
@@ -3,16 +3,36 @@ package main
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"aws-monitoring/internal/admin"
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/internal/anomaly"
+	"aws-monitoring/internal/api"
+	"aws-monitoring/internal/audit"
 	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
 	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/control"
+	"aws-monitoring/internal/dashboard"
+	"aws-monitoring/internal/events"
 	"aws-monitoring/internal/health"
+	"aws-monitoring/internal/memory"
+	"aws-monitoring/internal/platform"
+	"aws-monitoring/internal/reload"
+	"aws-monitoring/internal/rules"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/internal/state"
+	"aws-monitoring/internal/systemd"
+	"aws-monitoring/internal/telemetry"
+	"aws-monitoring/internal/tracing"
+	"aws-monitoring/internal/triggers"
 	"aws-monitoring/pkg/logger"
 )
 
@@ -23,136 +43,536 @@ var (
 )
 
 func main() {
-	// Parse command line flags
-	var (
-		configPath   = flag.String("config", "", "Path to configuration file")
-		showVersion  = flag.Bool("version", false, "Show version information")
-		validateOnly = flag.Bool("validate", false, "Validate configuration and exit")
-	)
-	flag.Parse()
+	os.Exit(runCLI(os.Args[1:]))
+}
 
-	// Show version information
-	if *showVersion {
-		fmt.Printf("AWS Monitor %s\n", version)
-		fmt.Printf("Build Time: %s\n", buildTime)
-		fmt.Printf("Git Commit: %s\n", gitCommit)
-		os.Exit(0)
+// changeLogLevel raises the log level on SIGUSR1 and lowers it on SIGUSR2,
+// recording the change to the audit log
+func changeLogLevel(sig os.Signal, log *logger.Logger, auditLog *audit.Log) {
+	previous := log.Level()
+	actor := "signal:SIGUSR2"
+	if isIncreaseVerbositySignal(sig) {
+		actor = "signal:SIGUSR1"
+		log.IncreaseVerbosity()
+	} else {
+		log.DecreaseVerbosity()
 	}
 
-	// Load configuration first (needed for logger setup)
-	cfg, err := config.Load(*configPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
-		os.Exit(1)
+	details := map[string]string{"from": previous, "to": log.Level()}
+	if err := auditLog.Record(audit.WithActor(context.Background(), actor), audit.ActionLogLevelChange, "", details); err != nil {
+		log.Error("Failed to record audit log entry", logger.String("error", err.Error()))
 	}
+}
 
-	// Initialize logger with configuration
-	loggerConfig := logger.Config{
-		Level:  cfg.Global.LogLevel,
-		Format: cfg.Global.LogFormat,
-	}
+// runDaemon implements `aws-monitor run`: it collects metrics and serves the
+// health check endpoints until it receives SIGINT or SIGTERM. It returns the
+// process exit code.
+func runDaemon(cfg *config.Config, flags *configFlags, opts runOptions, host platform.Metadata, mainLogger *logger.Logger) int {
+	// Setup graceful shutdown
+	shutdownChan := make(chan os.Signal, 1)
+	signal.Notify(shutdownChan, syscall.SIGINT, syscall.SIGTERM)
 
-	err = logger.InitializeGlobal(loggerConfig)
+	// Components register how to stop them as they start; they are stopped
+	// in reverse order on shutdown or when startup fails
+	shutdown := newShutdownSequence(mainLogger)
+	defer shutdown.run()
+
+	// Changes made at runtime are recorded to the audit log, which is kept in
+	// memory only unless a file is configured
+	auditLog, err := audit.Open(cfg.Admin.AuditLog, audit.DefaultRetained)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		mainLogger.Error("Failed to open audit log", logger.String("error", err.Error()))
+		return 1
 	}
+	shutdown.add("audit log", 0, func(context.Context) error { return auditLog.Close() })
 
-	// Ensure logs are flushed on exit
-	defer func() {
-		if err := logger.Sync(); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to sync logger: %v\n", err)
+	// SIGUSR1/SIGUSR2 raise/lower the log level without a restart
+	logLevelChan := make(chan os.Signal, 1)
+	notifyLogLevelSignals(logLevelChan)
+	go func() {
+		for sig := range logLevelChan {
+			changeLogLevel(sig, mainLogger, auditLog)
 		}
 	}()
 
-	// Create main logger
-	mainLogger := logger.WithComponent("main")
-
-	// Log application startup
-	mainLogger.LogStartup(version, buildTime, gitCommit)
-
-	// Log configuration details
-	mainLogger.LogConfigLoad(*configPath, cfg.EnabledRegions)
-	mainLogger.Info("OpenTelemetry configuration",
-		logger.String("endpoint", cfg.OTEL.CollectorEndpoint),
-		logger.String("service_name", cfg.OTEL.ServiceName),
-		logger.Bool("insecure", cfg.OTEL.Insecure),
-	)
-
-	// Log collector configurations
-	collectors := map[string]config.CollectorConfig{
-		"ec2":    cfg.Metrics.EC2,
-		"rds":    cfg.Metrics.RDS,
-		"s3":     cfg.Metrics.S3,
-		"lambda": cfg.Metrics.Lambda,
-		"ebs":    cfg.Metrics.EBS,
-		"elb":    cfg.Metrics.ELB,
-		"vpc":    cfg.Metrics.VPC,
-	}
-
-	for name, collectorCfg := range collectors {
-		mainLogger.LogCollectorStatus(name, collectorCfg.Enabled, time.Duration(collectorCfg.CollectionInterval))
-	}
-
-	// If validate-only mode, exit after successful validation
-	if *validateOnly {
-		mainLogger.Info("Configuration validation successful")
-		os.Exit(0)
-	}
-
-	// Setup graceful shutdown
-	shutdownChan := make(chan os.Signal, 1)
-	signal.Notify(shutdownChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// Start application components (placeholder)
 	mainLogger.Info("Starting application components",
 		logger.Int("max_workers", cfg.Global.MaxConcurrentWorkers),
 		logger.Int("health_port", cfg.Global.HealthCheckPort),
 	)
+	if host.Name != "" {
+		mainLogger.Info("Platform detected",
+			logger.String("platform", host.Name),
+			logger.Any("attributes", host.Attributes))
+	}
+	
+	// Exported telemetry identifies where aws-monitor runs
+	otelConfig := exportOTELConfig(cfg.OTEL, host)
 
 	// Initialize AWS clients
 	awsProvider := aws.NewClientProvider(cfg, mainLogger)
-	defer func() {
-		if err := awsProvider.Close(); err != nil {
-			mainLogger.Error("Failed to close AWS provider", logger.String("error", err.Error()))
-		}
-	}()
+	shutdown.add("aws clients", 0, func(context.Context) error { return awsProvider.Close() })
 
 	// Initialize health check system
 	healthManager := health.NewManager("aws-monitor", version, mainLogger)
+	healthManager.SetHistorySize(cfg.Global.HealthChecks.HistorySize)
+	for name, checker := range cfg.Global.HealthChecks.Checkers {
+		healthManager.SetCheckerConfig(name, health.CheckerConfig{
+			Enabled:    checker.Enabled,
+			Interval:   time.Duration(checker.Interval),
+			Timeout:    time.Duration(checker.Timeout),
+			Retries:    checker.Retries,
+			RetryDelay: time.Duration(checker.RetryDelay),
+		})
+	}
 	
 	// Register health checkers
 	healthManager.RegisterChecker(health.NewBasicChecker("aws-monitor", version))
 	healthManager.RegisterChecker(health.NewConfigChecker(cfg, mainLogger))
 	healthManager.RegisterChecker(health.NewAWSChecker(awsProvider, cfg, mainLogger))
+	healthManager.RegisterChecker(health.NewGoroutineChecker(cfg.Global.HealthChecks.MaxGoroutines))
+	healthManager.RegisterChecker(health.NewHeapChecker(uint64(cfg.Global.HealthChecks.HeapLimitMB) << 20))
+	healthManager.RegisterChecker(health.NewGCChecker(time.Duration(cfg.Global.HealthChecks.GCPauseThreshold)))
+	if diskPaths := healthCheckDiskPaths(cfg); len(diskPaths) > 0 {
+		healthManager.RegisterChecker(health.NewDiskChecker(diskPaths,
+			uint64(cfg.Global.HealthChecks.MinFreeDiskMB)<<20))
+	}
+	if cfg.OTEL.ExportLogs {
+		healthManager.RegisterChecker(health.NewExporterChecker(mainLogger, time.Duration(cfg.OTEL.ExportFailureThreshold)))
+	}
 	
 	// Start health check manager
 	healthManager.Start(30 * time.Second)
-	defer healthManager.Stop()
+	shutdown.add("health manager", 0, func(context.Context) error {
+		healthManager.Stop()
+		return nil
+	})
 	
 	// Start health check HTTP server
 	healthServer := health.NewServer(healthManager, cfg.Global.HealthCheckPort, mainLogger)
+
+	// The startup probe succeeds once configuration is loaded, AWS credentials
+	// are valid and, when logs are exported, the first export has succeeded
+	startupSteps := []string{health.StartupConfigLoaded, health.StartupAWSCredentials}
+	if cfg.OTEL.ExportLogs {
+		startupSteps = append(startupSteps, health.StartupExporterConnected)
+	}
+	startup := health.NewStartup(startupSteps...)
+	startup.Complete(health.StartupConfigLoaded)
+	healthServer.SetStartup(startup)
+	healthAuth := cfg.Global.HealthCheckAuth
+	healthServer.SetAuth(health.GroupProbes, endpointCredentials(healthAuth.Probes))
+	healthServer.SetAuth(health.GroupDetailed, endpointCredentials(healthAuth.Detailed))
+	healthServer.SetRateLimiter(health.NewRateLimiter(cfg.Global.HealthCheckRateLimit.RequestsPerSecond,
+		cfg.Global.HealthCheckRateLimit.Burst))
+	healthServer.SetCacheTTL(time.Duration(cfg.Global.HealthCheckCacheTTL))
+	var grpcHealthServer *health.GRPCServer
+	if cfg.Global.GRPCHealthPort > 0 {
+		grpcHealthServer = health.NewGRPCServer(healthManager, cfg.Global.GRPCHealthPort, mainLogger)
+	}
+	if tlsCfg := cfg.Global.HealthCheckTLS; tlsCfg.Enabled {
+		tlsConfig, err := health.NewTLSConfig(tlsCfg.CertFile, tlsCfg.KeyFile, tlsCfg.ClientCAFile)
+		if err != nil {
+			mainLogger.Error("Failed to configure health check TLS", logger.String("error", err.Error()))
+			return 1
+		}
+		healthServer.SetTLSConfig(tlsConfig)
+		if grpcHealthServer != nil {
+			grpcHealthServer.SetTLSConfig(tlsConfig)
+		}
+	}
 	if err := healthServer.Start(); err != nil {
 		mainLogger.Error("Failed to start health check server", logger.String("error", err.Error()))
-		os.Exit(1)
+		return 1
 	}
-	defer func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := healthServer.Stop(ctx); err != nil {
-			mainLogger.Error("Failed to stop health check server", logger.String("error", err.Error()))
-		}
-	}()
+	shutdown.add("health server", 5*time.Second, healthServer.Stop)
 
 	mainLogger.Info("Health check server started", logger.Int("port", cfg.Global.HealthCheckPort))
 
-	// TODO: Initialize and start remaining application components
-	// - Metric collectors
-	// - OpenTelemetry exporter
-	// - Scheduler
+	if grpcHealthServer != nil {
+		if err := grpcHealthServer.Start(); err != nil {
+			mainLogger.Error("Failed to start gRPC health server", logger.String("error", err.Error()))
+			return 1
+		}
+		shutdown.add("grpc health server", 0, func(context.Context) error {
+			grpcHealthServer.Stop()
+			return nil
+		})
+	}
+
+	// Buffered log entries are written and pending log batches exported once
+	// collection has stopped, while the health endpoints still answer
+	shutdown.add("log flush", 5*time.Second, mainLogger.Flush)
+
+	// Collection jobs are traced once a tracer provider is installed; pending
+	// spans are exported once collection has stopped
+	if cfg.OTEL.ExportTraces {
+		tracerProvider, err := tracing.NewProvider(otelConfig, version)
+		if err != nil {
+			mainLogger.Error("Failed to configure tracing", logger.String("error", err.Error()))
+			return 1
+		}
+		shutdown.add("trace export", 5*time.Second, tracerProvider.Shutdown)
+		mainLogger.Info("Tracing enabled",
+			logger.String("endpoint", cfg.OTEL.CollectorEndpoint),
+			logger.Float64("sample_ratio", cfg.OTEL.TraceSampleRatio))
+	}
+
+	appCtx, appCancel := context.WithCancel(context.Background())
+	shutdown.add("background tasks", 0, func(context.Context) error {
+		appCancel()
+		return nil
+	})
+
+	// Job run times, collector cursors and counters survive restarts; the
+	// state is saved once more after collection has stopped
+	stateStore, err := state.Open(cfg.Global.State.Path)
+	if err != nil {
+		mainLogger.Error("Failed to open state store", logger.String("error", err.Error()))
+		return 1
+	}
+	if stateStore.Path() != "" {
+		go stateStore.Run(appCtx, time.Duration(cfg.Global.State.FlushInterval), func(err error) {
+			mainLogger.Warn("Failed to save state", logger.String("error", err.Error()))
+		})
+		mainLogger.Info("Persistent state enabled", logger.String("path", stateStore.Path()))
+	}
+	shutdown.add("state", 0, func(context.Context) error { return stateStore.Close() })
+
+	// Under memory pressure fewer jobs run at once and low priority jobs are
+	// postponed, instead of the process being OOM-killed
+	memoryMonitor := memory.NewMonitor(memory.Config{
+		SoftLimit:     int64(cfg.Global.Memory.SoftLimitMB) << 20,
+		HighRatio:     cfg.Global.Memory.HighRatio,
+		CriticalRatio: cfg.Global.Memory.CriticalRatio,
+	})
+	memoryMonitor.ApplyRuntimeLimit()
+	if memoryMonitor.Limit() > 0 {
+		mainLogger.Info("Memory limit enabled", logger.Int64("limit_bytes", memoryMonitor.Limit()))
+	}
+
+	// Resolving the account validates the AWS credentials
+	account := resolveAccountInfo(appCtx, awsProvider, cfg.AWS.DefaultRegion, mainLogger)
+	if account.ID != "" {
+		startup.Complete(health.StartupAWSCredentials)
+	} else {
+		go awaitAWSCredentials(appCtx, awsProvider, cfg.AWS.DefaultRegion, startup, mainLogger)
+	}
+	if cfg.OTEL.ExportLogs {
+		go awaitFirstExport(appCtx, mainLogger, startup)
+	}
+
+	// Initialize collectors
+	inventory := aws.NewInventoryCache(awsProvider, time.Duration(cfg.AWS.InventoryTTL), mainLogger)
+	collectorDeps := collectors.CollectorDependencies{
+		Config:         cfg,
+		AWSProvider:    awsProvider,
+		Inventory:      inventory,
+		Account:        account,
+		State:          stateStore,
+		Memory:         memoryMonitor,
+		PlatformLabels: host.Labels,
+		Logger:         mainLogger,
+	}
+	var alertNotifier *alerting.Notifier
+	var alertSilencer *alerting.Silencer
+	if cfg.Alerting.Enabled {
+		notifier, err := newAlertNotifier(cfg.Alerting, awsProvider, mainLogger)
+		if err != nil {
+			mainLogger.Error("Failed to configure alerting", logger.String("error", err.Error()))
+			return 1
+		}
+		alertSilencer, err = alerting.NewSilencer(cfg.Alerting.StateFile)
+		if err != nil {
+			mainLogger.Error("Failed to load alert silences", logger.String("error", err.Error()))
+			return 1
+		}
+		notifier.SetSilencer(alertSilencer)
+		shutdown.add("alerts", 0, func(context.Context) error {
+			notifier.Wait()
+			return nil
+		})
+		collectorDeps.Alerts = notifier
+		alertNotifier = notifier
+		if cfg.Alerting.HealthChanges.Enabled {
+			statusNotifier := health.NewStatusNotifier(notifier, time.Duration(cfg.Alerting.HealthChanges.Debounce), mainLogger)
+			healthManager.AddListener(statusNotifier.Observe)
+		}
+	}
+	var eventPipeline *events.Pipeline
+	if cfg.Events.Enabled {
+		pipeline, err := newEventPipeline(cfg, otelConfig, mainLogger)
+		if err != nil {
+			mainLogger.Error("Failed to configure events", logger.String("error", err.Error()))
+			return 1
+		}
+		// Collectors are stopped first, so their last events are delivered
+		shutdown.add("events", 5*time.Second, pipeline.Close)
+		collectorDeps.Events = pipeline
+		eventPipeline = pipeline
+	}
+	if tracking := cfg.Global.ErrorTracking; tracking.Enabled {
+		release := tracking.Release
+		if release == "" {
+			release = version
+		}
+		reporter, err := alerting.NewSentryReporter(tracking.DSN, tracking.Environment, release, mainLogger)
+		if err != nil {
+			mainLogger.Error("Failed to configure error tracking", logger.String("error", err.Error()))
+			return 1
+		}
+		reporter.SetRateLimit(time.Duration(cfg.Alerting.Cooldown), cfg.Alerting.MaxPerHour)
+		shutdown.add("error reports", 0, func(context.Context) error {
+			reporter.Wait()
+			return nil
+		})
+		collectorDeps.Reporter = reporter
+		mainLogger.Info("Error tracking enabled", logger.String("environment", tracking.Environment))
+	}
+	registry := collectors.NewCollectorRegistry(mainLogger)
+	healthManager.RegisterChecker(health.NewCollectorChecker(registry))
+
+	pluginCollectors, err := collectors.LoadPluginCollectors(cfg.Plugins, collectorDeps)
+	if err != nil {
+		mainLogger.Error("Failed to load plugin collectors", logger.String("error", err.Error()))
+		return 1
+	}
+	for _, collector := range pluginCollectors {
+		if err := registry.Register(collector); err != nil {
+			mainLogger.Error("Failed to register collector", logger.String("error", err.Error()))
+			return 1
+		}
+	}
+
+	// Report aws-monitor's own metrics through a collector, so they are
+	// exported with the AWS metrics
+	selfMetrics := telemetry.NewRegistry()
+	selfMetrics.SetLogStats(mainLogger)
+	selfMetrics.SetHealth(healthManager)
+	if err := selfMetrics.SetStateStore(stateStore); err != nil {
+		mainLogger.Warn("Failed to restore collection counters", logger.String("error", err.Error()))
+	}
+	if cfg.Global.SelfMetrics.Enabled {
+		if err := registry.Register(telemetry.NewCollector(cfg, selfMetrics, mainLogger)); err != nil {
+			mainLogger.Error("Failed to register collector", logger.String("error", err.Error()))
+			return 1
+		}
+	}
+
+	if err := registry.Start(appCtx); err != nil {
+		mainLogger.Error("Failed to start collectors", logger.String("error", err.Error()))
+	}
+
+	// Report each collector's health separately so failures are attributable
+	for _, collector := range registry.List() {
+		healthManager.RegisterChecker(health.NewCollectorHealthChecker(collector))
+	}
+	shutdown.add("collectors", 10*time.Second, registry.Stop)
+
+	// Initialize scheduler
+	schedulerConfig := scheduler.DefaultConfig()
+	schedulerConfig.MaxConcurrentJobs = cfg.Global.MaxConcurrentWorkers
+	schedulerConfig.JobTimeout = time.Duration(cfg.Global.WorkerTimeout)
+	schedulerConfig.EnabledRegions = cfg.EnabledRegions
+	schedulerConfig.WarmupWindow = time.Duration(cfg.Global.WarmupWindow)
+	schedulerConfig.State = stateStore
+	schedulerConfig.Memory = memoryMonitor
+	schedulerConfig.RegionBatchThreshold = cfg.Global.RegionBatching.Threshold
+	schedulerConfig.RegionBatchSize = cfg.Global.RegionBatching.Size
+
+	// A dry run writes metrics out instead of exporting them
+	var processor scheduler.JobProcessor
+	if opts.dryRun {
+		output, err := openDryRunOutput(opts.dryRunOutput)
+		if err != nil {
+			mainLogger.Error("Failed to open dry run output", logger.String("error", err.Error()))
+			return 1
+		}
+		dryRun := scheduler.NewDryRunProcessor(output, mainLogger)
+		processor = dryRun
+		shutdown.add("dry run output", 0, func(context.Context) error {
+			summary := dryRun.Summary()
+			mainLogger.Info("Dry run summary",
+				logger.Int("results", summary.Results),
+				logger.Int("metrics", summary.Metrics),
+				logger.Int("series", summary.Series))
+			return output.Close()
+		})
+		mainLogger.Info("Dry run: metrics are written out instead of exported; alerts, error reports and logs are not sent",
+			logger.String("output", dryRunOutputName(opts.dryRunOutput)))
+	}
+
+	if cfg.Global.SelfMetrics.Enabled {
+		if processor == nil {
+			processor = scheduler.NewDefaultJobProcessor(mainLogger)
+		}
+		processor = telemetry.NewProcessor(processor, selfMetrics)
+	}
+
+	// Alert rules are evaluated over every collection run; without alerting
+	// their alerts are only logged
+	var evaluator *rules.Evaluator
+	if len(cfg.Alerts) > 0 {
+		if processor == nil {
+			processor = scheduler.NewDefaultJobProcessor(mainLogger)
+		}
+		evaluator = rules.NewEvaluator(cfg.Alerts, mainLogger)
+		if alertNotifier != nil {
+			evaluator.SetNotifier(alertNotifier)
+		}
+		if evaluator.HasDigestRules() {
+			go evaluator.RunDigests(appCtx, time.Duration(cfg.Alerting.DigestInterval))
+			// The alerts collected since the last digest are sent on shutdown
+			shutdown.add("alert digest", 0, func(context.Context) error {
+				evaluator.FlushDigest()
+				return nil
+			})
+		}
+		processor = rules.NewProcessor(processor, evaluator)
+	}
+
+	// Anomalies are reported as events; without events they are only logged
+	if cfg.Anomalies.Enabled {
+		if processor == nil {
+			processor = scheduler.NewDefaultJobProcessor(mainLogger)
+		}
+		detector := anomaly.NewDetector(cfg.Anomalies, mainLogger)
+		if eventPipeline != nil {
+			detector.SetEmitter(eventPipeline)
+		}
+		processor = anomaly.NewProcessor(processor, detector)
+	}
+
+	metricScheduler := scheduler.NewMetricScheduler(schedulerConfig, registry, processor, mainLogger)
+	selfMetrics.SetScheduler(metricScheduler)
+	for _, collector := range registry.List() {
+		info := collector.Info()
+		if err := scheduler.ScheduleCollectorInfo(metricScheduler, info); err != nil {
+			mainLogger.Error("Failed to schedule collector",
+				logger.String("collector", info.Name),
+				logger.String("error", err.Error()))
+		}
+	}
+
+	if err := metricScheduler.Start(appCtx); err != nil {
+		mainLogger.Error("Failed to start scheduler", logger.String("error", err.Error()))
+		return 1
+	}
+	// In-flight jobs are given global.shutdown_timeout to finish
+	shutdown.add("scheduler", time.Duration(cfg.Global.ShutdownTimeout), metricScheduler.Stop)
+	
+	// Collect as soon as EventBridge reports a relevant change. The listener
+	// is stopped before the scheduler, so no collection is triggered while
+	// in-flight jobs drain.
+	if cfg.EventTriggers.Enabled {
+		listener, err := newEventTriggerListener(cfg.EventTriggers, awsProvider, metricScheduler, mainLogger)
+		if err != nil {
+			mainLogger.Error("Failed to create event trigger listener", logger.String("error", err.Error()))
+			return 1
+		}
+		listener.Start(appCtx)
+		shutdown.add("event triggers", 5*time.Second, listener.Stop)
+	}
+
+	// Expose the read-only API on the health check server
+	apiHandler := api.NewHandler(registry, mainLogger)
+	apiHandler.SetScheduler(metricScheduler)
+	apiHandler.SetExportStats(mainLogger)
+	apiHandler.SetConfig(func() *config.Config { return cfg })
+	healthServer.Handle(api.PathPrefix, health.RequireAuth(endpointCredentials(healthAuth.API),
+		apiHandler, mainLogger.WithComponent("api")))
+
+	// The dashboard polls the API, so it shares its credentials
+	healthServer.Handle(dashboard.PathPrefix, health.RequireAuth(endpointCredentials(healthAuth.API),
+		dashboard.NewHandler(), mainLogger.WithComponent("dashboard")))
+
+	// Expose the admin API on the health check server
+	var adminHandler *admin.Handler
+	if cfg.Admin.Enabled {
+		adminCredentials := health.Credentials{
+			Token:    cfg.Admin.Token,
+			Username: cfg.Admin.Username,
+			Password: cfg.Admin.Password,
+		}
+		adminHandler = admin.NewHandler(registry, metricScheduler, collectorDeps, adminCredentials, mainLogger)
+		adminHandler.SetHealthManager(healthManager)
+		adminHandler.SetContext(appCtx)
+		adminHandler.SetAuditLog(auditLog)
+		if alertSilencer != nil {
+			if evaluator != nil {
+				adminHandler.SetAlerts(alertSilencer, evaluator)
+			} else {
+				adminHandler.SetAlerts(alertSilencer, nil)
+			}
+		}
+		healthServer.Handle(admin.PathPrefix, adminHandler)
+		mainLogger.Info("Admin API enabled", logger.String("path", admin.PathPrefix))
+	}
+
+	// Reload the configuration on SIGHUP and, if enabled, when the file changes
+	var reloadConfig func(ctx context.Context) error
+	if configFile, err := config.ResolvePath(flags.path); err != nil {
+		mainLogger.Warn("Configuration reload disabled", logger.String("error", err.Error()))
+	} else {
+		reloader := reload.NewReloader(configFile, cfg, registry, metricScheduler, collectorDeps, mainLogger)
+		reloader.SetHealthManager(healthManager)
+		reloader.SetLoadOptions(flags.loadOptions())
+		reloader.SetAuditLog(auditLog)
+		apiHandler.SetConfig(reloader.Current)
+		if adminHandler != nil {
+			adminHandler.SetConfig(reloader.Current)
+		}
+		reloadConfig = func(ctx context.Context) error {
+			_, err := reloader.Reload(ctx)
+			return err
+		}
+
+		reloadChan := make(chan os.Signal, 1)
+		notifyReloadSignal(reloadChan)
+		go func() {
+			for range reloadChan {
+				_, _ = reloader.Reload(audit.WithActor(appCtx, "signal:SIGHUP"))
+			}
+		}()
+		if cfg.Global.ConfigReload.Watch {
+			go reloader.Watch(appCtx, time.Duration(cfg.Global.ConfigReload.Interval))
+		}
+	}
+	
+	// Accept commands from the control queue, so a fleet can be operated
+	// without reaching the admin API of each instance
+	if cfg.Control.Enabled {
+		sqsClient, err := awsProvider.GetSQSClient(cfg.Control.Region)
+		if err != nil {
+			mainLogger.Error("Failed to create control channel", logger.String("error", err.Error()))
+			return 1
+		}
+		controlChannel := control.NewChannel(sqsClient, cfg.Control.QueueURL, metricScheduler, mainLogger)
+		controlChannel.SetWaitTime(time.Duration(cfg.Control.WaitTime))
+		controlChannel.SetAuditLog(auditLog)
+		if reloadConfig != nil {
+			controlChannel.SetReloader(reloadConfig)
+		}
+		controlChannel.Start(appCtx)
+		shutdown.add("control channel", 5*time.Second, controlChannel.Stop)
+	}
+
+	// Collected metrics are not exported over OTLP yet: the metric exporter,
+	// flushed in its own shutdown stage, is still missing
 
 	mainLogger.Info("Application startup complete")
 
+	// Tell systemd the service is ready and, if its watchdog is enabled, keep
+	// it fed while the scheduler is ticking
+	if notified, err := systemd.Notify(systemd.Ready); err != nil {
+		mainLogger.Warn("Failed to notify systemd", logger.String("error", err.Error()))
+	} else if notified {
+		mainLogger.Info("Notified systemd of readiness")
+	}
+	if timeout, ok := systemd.WatchdogTimeout(); ok {
+		mainLogger.Info("systemd watchdog enabled", logger.Duration("timeout", timeout))
+		go systemd.RunWatchdog(appCtx, timeout, metricScheduler.Health, mainLogger)
+	}
+
 	// Wait for shutdown signal
 	sig := <-shutdownChan
 	shutdownStart := time.Now()
@@ -160,12 +580,253 @@ func main() {
 	mainLogger.Info("Received shutdown signal",
 		logger.String("signal", sig.String()),
 	)
+	_, _ = systemd.Notify(systemd.Stopping)
 
-	// TODO: Implement graceful shutdown
-	// - Stop scheduler
-	// - Drain worker pools
-	// - Flush remaining metrics
-	// - Close connections
+	// Stop scheduling and drain in-flight jobs, stop the collectors, flush
+	// logs and traces, then stop the health endpoints and AWS clients
+	shutdown.run()
+
+	if stats, ok := mainLogger.AsyncStats(); ok && stats.Dropped > 0 {
+		mainLogger.Warn("Log entries were dropped because the async log queue was full",
+			logger.Int64("dropped", int64(stats.Dropped)),
+			logger.Int("queue_size", stats.QueueSize))
+	}
 
 	mainLogger.LogShutdown(sig.String(), time.Since(shutdownStart))
+	return 0
+}
+
+// resolveAccountInfo looks up the account ID and alias used to label metrics.
+// Failures are logged and metrics are then exported without account labels.
+func resolveAccountInfo(ctx context.Context, provider aws.ClientProvider, region string, log *logger.Logger) aws.AccountInfo {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	stsClient, err := provider.GetSTSClient(region)
+	if err != nil {
+		log.Warn("Failed to create STS client, metrics will not carry account labels", logger.String("error", err.Error()))
+		return aws.AccountInfo{}
+	}
+
+	iamClient, err := provider.GetIAMClient(region)
+	if err != nil {
+		log.Warn("Failed to create IAM client, metrics will not carry an account alias", logger.String("error", err.Error()))
+	}
+
+	account, err := aws.ResolveAccountInfo(ctx, stsClient, iamClient, log)
+	if err != nil {
+		log.Warn("Failed to resolve AWS account, metrics will not carry account labels", logger.String("error", err.Error()))
+		return aws.AccountInfo{}
+	}
+
+	return account
+}
+
+// awaitAWSCredentials retries validating the AWS credentials with STS until
+// they are valid, then completes the startup step
+func awaitAWSCredentials(ctx context.Context, provider aws.ClientProvider, region string, startup *health.Startup, log *logger.Logger) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stsClient, err := provider.GetSTSClient(region)
+		if err != nil {
+			continue
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		_, err = stsClient.GetCallerIdentity(checkCtx, &sts.GetCallerIdentityInput{})
+		cancel()
+		if err != nil {
+			log.Warn("AWS credentials are not valid yet", logger.String("error", err.Error()))
+			continue
+		}
+
+		log.Info("AWS credentials validated")
+		startup.Complete(health.StartupAWSCredentials)
+		return
+	}
+}
+
+// awaitFirstExport completes the exporter startup step once log export has
+// succeeded for the first time
+func awaitFirstExport(ctx context.Context, log *logger.Logger, startup *health.Startup) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if stats, ok := log.ExportStats(); !ok || !stats.LastSuccess.IsZero() {
+			startup.Complete(health.StartupExporterConnected)
+			return
+		}
+	}
+}
+
+// newAlertNotifier creates the notifier for high-severity collector errors from
+// the configured Slack, SNS, PagerDuty, email and webhook sinks
+func newAlertNotifier(cfg config.AlertingConfig, provider aws.ClientProvider, log *logger.Logger) (*alerting.Notifier, error) {
+	var sinks []alerting.AlertSink
+
+	if cfg.Slack.WebhookURL != "" {
+		sinks = append(sinks, alerting.NewSlackSink(cfg.Slack.WebhookURL))
+	}
+	if cfg.SNS.TopicARN != "" {
+		snsClient, err := provider.GetSNSClient(cfg.SNS.Region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SNS client: %w", err)
+		}
+		sinks = append(sinks, alerting.NewSNSSink(snsClient, cfg.SNS.TopicARN))
+	}
+	if cfg.PagerDuty.RoutingKey != "" {
+		sinks = append(sinks, alerting.NewPagerDutySink(cfg.PagerDuty.URL, cfg.PagerDuty.RoutingKey))
+	}
+	if email := cfg.Email; email.SMTPHost != "" {
+		sinks = append(sinks, alerting.NewEmailSink(email.SMTPHost, email.SMTPPort, email.Username, email.Password, email.From, email.To))
+	}
+	for _, webhook := range cfg.Webhooks {
+		sinks = append(sinks, alerting.NewWebhookSink(webhook.URL, webhook.Headers))
+	}
+
+	log.Info("Alerting enabled",
+		logger.Int("sinks", len(sinks)),
+		logger.Duration("cooldown", time.Duration(cfg.Cooldown)),
+		logger.Int("max_per_hour", cfg.MaxPerHour))
+
+	notifier := alerting.NewNotifier(sinks, time.Duration(cfg.Cooldown), cfg.MaxPerHour, log)
+	if cfg.Templates.Title != "" || cfg.Templates.Text != "" {
+		templates, err := alerting.NewTemplates(cfg.Templates.Title, cfg.Templates.Text)
+		if err != nil {
+			return nil, err
+		}
+		notifier.SetTemplates(templates)
+	}
+	return notifier, nil
+}
+
+// newEventTriggerListener creates the listener of the event triggers queue,
+// with the default rules unless rules are configured
+func newEventTriggerListener(cfg config.EventTriggersConfig, provider aws.ClientProvider, trigger triggers.Trigger, log *logger.Logger) (*triggers.Listener, error) {
+	sqsClient, err := provider.GetSQSClient(cfg.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SQS client: %w", err)
+	}
+
+	rules := triggers.DefaultRules()
+	if len(cfg.Rules) > 0 {
+		rules = make([]triggers.Rule, 0, len(cfg.Rules))
+		for _, rule := range cfg.Rules {
+			rules = append(rules, triggers.Rule{
+				Source:      rule.Source,
+				DetailTypes: rule.DetailTypes,
+				Collectors:  rule.Collectors,
+			})
+		}
+	}
+
+	listener := triggers.NewListener(sqsClient, cfg.QueueURL, rules, trigger, log)
+	listener.SetWaitTime(time.Duration(cfg.WaitTime))
+	return listener, nil
+}
+
+// newEventPipeline creates the events pipeline delivering to OTLP, with the
+// given OTEL configuration, and the configured webhooks
+func newEventPipeline(cfg *config.Config, otelConfig config.OTELConfig, log *logger.Logger) (*events.Pipeline, error) {
+	minSeverity, err := events.ParseSeverity(cfg.Events.MinSeverity)
+	if err != nil {
+		return nil, err
+	}
+
+	var sinks []events.Sink
+	if cfg.Events.ExportOTLP {
+		sink, err := events.NewOTLPSink(otelConfig, version)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	for _, webhook := range cfg.Events.Webhooks {
+		sinks = append(sinks, events.NewWebhookSink(webhook.URL, webhook.Headers))
+	}
+
+	log.Info("Events enabled",
+		logger.Int("sinks", len(sinks)),
+		logger.Int("buffer_size", cfg.Events.BufferSize),
+		logger.String("min_severity", string(minSeverity)))
+
+	return events.NewPipeline(sinks, cfg.Events.BufferSize, minSeverity, log), nil
+}
+
+// endpointCredentials converts the credentials of an endpoint group
+func endpointCredentials(auth config.EndpointAuthConfig) health.Credentials {
+	return health.Credentials{Token: auth.Token, Username: auth.Username, Password: auth.Password}
+}
+
+// healthCheckDiskPaths returns the paths the disk health check verifies: the
+// log file, when logging to one, and any configured directories
+func healthCheckDiskPaths(cfg *config.Config) []string {
+	var paths []string
+	if logger.IsFilePath(cfg.Global.LogOutputPath) {
+		paths = append(paths, cfg.Global.LogOutputPath)
+	}
+	return append(paths, cfg.Global.HealthChecks.DiskPaths...)
+}
+
+// newLoggerConfig builds the logger configuration from the global settings,
+// exporting logs to the metrics collector when enabled
+func newLoggerConfig(cfg *config.Config) logger.Config {
+	global := cfg.Global
+	loggerConfig := logger.Config{
+		Level:      global.LogLevel,
+		Format:     global.LogFormat,
+		OutputPath: global.LogOutputPath,
+		MaxSize:    global.LogMaxSize,
+		MaxAge:     global.LogMaxAge,
+		MaxBackups: global.LogMaxBackups,
+		Compress:   global.LogCompress,
+		Sampling: logger.SamplingConfig{
+			Initial:    global.LogSampling.Initial,
+			Thereafter: global.LogSampling.Thereafter,
+			Tick:       time.Duration(global.LogSampling.Tick),
+		},
+		OTLP: logger.OTLPConfig{
+			Enabled:            cfg.OTEL.ExportLogs,
+			Endpoint:           cfg.OTEL.CollectorEndpoint,
+			SRVRefreshInterval: time.Duration(cfg.OTEL.SRVRefreshInterval),
+			Insecure:           cfg.OTEL.Insecure,
+			Headers:            cfg.OTEL.Headers,
+			ServiceName:        cfg.OTEL.ServiceName,
+			ServiceVersion:     version,
+			ResourceAttributes: cfg.OTEL.ResourceAttributes,
+			BatchTimeout:       time.Duration(cfg.OTEL.BatchTimeout),
+			BatchSize:          cfg.OTEL.BatchSize,
+		},
+		Async: logger.AsyncConfig{
+			Enabled:   global.LogAsync.Enabled,
+			QueueSize: global.LogAsync.QueueSize,
+		},
+		RedactFields: global.LogRedactFields,
+	}
+
+	if len(global.LogSampling.Levels) > 0 {
+		loggerConfig.Sampling.Levels = make(map[string]logger.LevelSamplingConfig, len(global.LogSampling.Levels))
+		for level, limits := range global.LogSampling.Levels {
+			loggerConfig.Sampling.Levels[level] = logger.LevelSamplingConfig{
+				Initial:    limits.Initial,
+				Thereafter: limits.Thereafter,
+			}
+		}
+	}
+
+	return loggerConfig
 }
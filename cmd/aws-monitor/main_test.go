@@ -1,12 +1,32 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/grafana"
+	"aws-monitoring/internal/health"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
 )
 
 // TestMain tests the main function behavior
@@ -34,6 +54,18 @@ func TestMain(t *testing.T) {
 			wantExit: 0,
 			wantOut:  "Usage of",
 		},
+		{
+			name:     "version command",
+			args:     []string{"version"},
+			wantExit: 0,
+			wantOut:  "AWS Monitor",
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"colect"},
+			wantExit: 2,
+			wantOut:  "unknown command \"colect\"",
+		},
 	}
 
 	for _, tt := range tests {
@@ -307,4 +339,746 @@ metrics:
 		}
 		t.Fatal("Main function with --validate flag took too long (>10s)")
 	}
-}
\ No newline at end of file
+}
+func TestRunConfigInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		contains []string
+	}{
+		{
+			name:     "full from prompts",
+			input:    "eu-west-1, eu-central-1\nhttps://otel.example.com:4317\n",
+			contains: []string{"  - eu-west-1\n  - eu-central-1\n", "default_region: eu-west-1", `"https://otel.example.com:4317"`, "insecure: false", "# alerting:"},
+		},
+		{
+			name:     "minimal with defaults",
+			args:     []string{"-minimal"},
+			input:    "\n\n",
+			contains: []string{"  - us-east-1\n", `"http://localhost:4317"`, "insecure: true"},
+		},
+		{
+			name:     "flags skip prompts",
+			args:     []string{"-regions", "ap-southeast-2", "-otel-endpoint", "http://collector:4317"},
+			contains: []string{"  - ap-southeast-2\n", `"http://collector:4317"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			var stdout strings.Builder
+			args := append([]string{"-output", path}, tt.args...)
+			if err := runConfigInit(args, strings.NewReader(tt.input), &stdout, true); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read generated config: %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("Expected generated config to contain %q", s)
+				}
+			}
+
+			// The generated file must load and validate
+			t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+			if _, err := config.Load(path); err != nil {
+				t.Errorf("Generated config failed to load: %v", err)
+			}
+
+			// An existing file is not overwritten without -force
+			if err := runConfigInit(args, strings.NewReader(tt.input), &stdout, false); err == nil {
+				t.Errorf("Expected error when the file exists")
+			}
+			if err := runConfigInit(append(args, "-force"), strings.NewReader(tt.input), &stdout, false); err != nil {
+				t.Errorf("Unexpected error with -force: %v", err)
+			}
+		})
+	}
+
+	if err := runConfigInit([]string{"-output", filepath.Join(t.TempDir(), "c.yaml"), "-regions", "not a region"}, strings.NewReader(""), io.Discard, false); err == nil {
+		t.Errorf("Expected error for an invalid region")
+	}
+}
+
+func TestRunConfigSchema(t *testing.T) {
+	var stdout strings.Builder
+	if err := runConfigSchema(nil, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"$schema"`) {
+		t.Errorf("Expected a JSON Schema on stdout, got %q", stdout.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := runConfigSchema([]string{"-output", path}, io.Discard); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), `"enabled_regions"`) {
+		t.Errorf("Expected schema written to %s, got %v", path, err)
+	}
+}
+
+// fakeCaller is an STS client and policy simulator for a role that is allowed
+// the actions in allowed
+type fakeCaller struct {
+	allowed map[string]bool
+}
+
+func (f *fakeCaller) GetCallerIdentity(_ context.Context, _ *sts.GetCallerIdentityInput, _ ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Arn: awssdk.String("arn:aws:iam::123456789012:role/aws-monitor")}, nil
+}
+
+func (f *fakeCaller) SimulatePrincipalPolicy(_ context.Context, params *iam.SimulatePrincipalPolicyInput, _ ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	output := &iam.SimulatePrincipalPolicyOutput{}
+	for _, action := range params.ActionNames {
+		decision := iamtypes.PolicyEvaluationDecisionTypeImplicitDeny
+		if f.allowed[action] {
+			decision = iamtypes.PolicyEvaluationDecisionTypeAllowed
+		}
+		output.EvaluationResults = append(output.EvaluationResults, iamtypes.EvaluationResult{
+			EvalActionName: awssdk.String(action),
+			EvalDecision:   decision,
+		})
+	}
+	return output, nil
+}
+
+func TestCheckPermissions(t *testing.T) {
+	caller := &fakeCaller{allowed: map[string]bool{
+		"sts:GetCallerIdentity":  true,
+		"iam:ListAccountAliases": true,
+		"ec2:DescribeInstances":  true,
+	}}
+	requirements := []permissionRequirement{
+		{Name: "aws-monitor", Permissions: []aws.Permission{{Action: "sts:GetCallerIdentity"}, {Action: "ec2:DescribeInstances"}}},
+		{Name: "billing", Permissions: []aws.Permission{{Action: "ce:GetCostAndUsage"}, {Action: "iam:ListAccountAliases"}}},
+		{Name: "queue-depth"},
+	}
+
+	var out strings.Builder
+	ok, err := checkPermissions(context.Background(), caller, caller, requirements, &out)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("Expected missing permissions to be reported")
+	}
+
+	expected := "Checked permissions of arn:aws:iam::123456789012:role/aws-monitor\n" +
+		"aws-monitor: ok\n" +
+		"billing: missing ce:GetCostAndUsage\n" +
+		"queue-depth: no AWS permissions declared\n"
+	if out.String() != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestRunConfigPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `
+enabled_regions: [us-east-1, eu-west-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+alerting:
+  enabled: true
+  sns:
+    topic_arn: arn:aws:sns:us-east-1:123456789012:alerts
+`
+	if err := os.WriteFile(path, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	var stdout strings.Builder
+	if err := runConfigPolicy([]string{"-config", path}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var policy aws.PolicyDocument
+	if err := json.Unmarshal([]byte(stdout.String()), &policy); err != nil {
+		t.Fatalf("Expected a JSON policy on stdout, got %q: %v", stdout.String(), err)
+	}
+	if len(policy.Statement) != 3 {
+		t.Fatalf("Expected global, regional and SNS topic statements, got %+v", policy.Statement)
+	}
+	if regions := policy.Statement[1].Condition["StringEquals"]["aws:RequestedRegion"]; len(regions) != 2 {
+		t.Errorf("Expected regional actions limited to the enabled regions, got %v", regions)
+	}
+	if topic := policy.Statement[2]; topic.Resource != "arn:aws:sns:us-east-1:123456789012:alerts" || topic.Action[0] != "sns:Publish" {
+		t.Errorf("Expected sns:Publish on the alert topic, got %+v", topic)
+	}
+}
+
+func TestQueueARN(t *testing.T) {
+	tests := map[string]string{
+		"https://sqs.eu-west-1.amazonaws.com/123456789012/events": "arn:aws:sqs:eu-west-1:123456789012:events",
+		"https://sqs.eu-west-1.amazonaws.com/":                    "*",
+		"http://localhost:4566/000000000000/events/extra":         "*",
+	}
+	for queueURL, expected := range tests {
+		if arn := queueARN(queueURL, "eu-west-1"); arn != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, queueURL, arn)
+		}
+	}
+	if arn := queueARN("https://sqs.cn-north-1.amazonaws.com.cn/123456789012/events", "cn-north-1"); arn != "arn:aws-cn:sqs:cn-north-1:123456789012:events" {
+		t.Errorf("Expected an aws-cn queue ARN, got %s", arn)
+	}
+}
+
+func TestWriteCollectors(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1", "eu-west-1"},
+		Global:         config.GlobalConfig{DefaultInterval: config.Duration(5 * time.Minute)},
+		Plugins: []config.PluginConfig{{
+			Name:               "queue-depth",
+			Type:               "exec",
+			Enabled:            true,
+			CollectionInterval: config.Duration(time.Minute),
+			Regions:            []string{"eu-west-1"},
+		}},
+	}
+	cfg.Metrics.EC2 = config.CollectorConfig{Enabled: true}
+
+	var out strings.Builder
+	writeCollectors(&out, cfg, []string{"exec"})
+
+	lines := strings.Split(out.String(), "\n")
+	expected := map[string][]string{
+		"ec2":         {"ec2", "metrics", "true", "5m0s", "us-east-1,eu-west-1"},
+		"rds":         {"rds", "metrics", "false", "5m0s", "us-east-1,eu-west-1"},
+		"queue-depth": {"queue-depth", "exec", "true", "1m0s", "eu-west-1"},
+	}
+	for name, fields := range expected {
+		found := false
+		for _, line := range lines {
+			if got := strings.Fields(line); len(got) > 0 && got[0] == name {
+				found = true
+				if strings.Join(got, " ") != strings.Join(fields, " ") {
+					t.Errorf("Expected %s row %v, got %v", name, fields, got)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected a row for %s, got:\n%s", name, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "Registered collector types: exec") {
+		t.Errorf("Expected the registered collector types, got:\n%s", out.String())
+	}
+}
+
+// fakeCollector returns a metric per region, failing in failRegion
+type fakeCollector struct {
+	name       string
+	regions    []string
+	failRegion string
+	started    bool
+	stopped    bool
+}
+
+func (f *fakeCollector) Name() string        { return f.name }
+func (f *fakeCollector) Description() string { return "fake collector" }
+func (f *fakeCollector) Health() error       { return nil }
+
+func (f *fakeCollector) Collect(_ context.Context, region string) *collectors.CollectionResult {
+	result := &collectors.CollectionResult{CollectorName: f.name, Region: region}
+	if region == f.failRegion {
+		result.Error = errors.New(errors.ErrorTypeNetwork, "UNREACHABLE", "endpoint unreachable")
+		return result
+	}
+	result.Metrics = []collectors.MetricData{{
+		Name:   "queue_depth",
+		Value:  42,
+		Unit:   "Count",
+		Labels: map[string]string{"queue": "jobs", "region": region},
+	}}
+	return result
+}
+
+func (f *fakeCollector) Start(_ context.Context) error {
+	f.started = true
+	return nil
+}
+
+func (f *fakeCollector) Stop(_ context.Context) error {
+	f.stopped = true
+	return nil
+}
+
+func (f *fakeCollector) Info() collectors.CollectorInfo {
+	return collectors.CollectorInfo{Name: f.name, EnabledRegions: f.regions}
+}
+
+func TestCollectOnce(t *testing.T) {
+	queue := &fakeCollector{name: "queue-depth", regions: []string{"us-east-1", "eu-west-1"}}
+	billing := &fakeCollector{name: "billing", regions: []string{"us-east-1"}, failRegion: "us-east-1"}
+
+	var stderr strings.Builder
+	results, failed := collectOnce(context.Background(), []collectors.MetricCollector{queue, billing}, "", &stderr)
+	if !failed {
+		t.Error("Expected the billing failure to be reported")
+	}
+	if !queue.started || !queue.stopped || !billing.started || !billing.stopped {
+		t.Error("Expected every collector to be started and stopped")
+	}
+
+	var got []string
+	for _, result := range results {
+		got = append(got, result.CollectorName+"/"+result.Region)
+	}
+	expected := "billing/us-east-1 queue-depth/us-east-1 queue-depth/eu-west-1"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("Expected results %s, got %v", expected, got)
+	}
+
+	var out strings.Builder
+	if err := writeResultsTable(&out, results); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"endpoint unreachable", "queue_depth", "queue=jobs,region=eu-west-1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected table to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	// Only the collectors enabled in the region collect there
+	queue.started = false
+	results, failed = collectOnce(context.Background(), []collectors.MetricCollector{queue, billing}, "eu-west-1", &stderr)
+	if failed || len(results) != 1 || results[0].CollectorName != "queue-depth" {
+		t.Errorf("Expected a single queue-depth result, got %+v (failed %t)", results, failed)
+	}
+}
+
+func TestSelectPlugins(t *testing.T) {
+	cfg := &config.Config{Plugins: []config.PluginConfig{
+		{Name: "queue-depth", Type: "exec", Enabled: true},
+		{Name: "billing", Type: "exec"},
+	}}
+
+	plugins, err := selectPlugins(cfg, "")
+	if err != nil || len(plugins) != 2 {
+		t.Errorf("Expected every plugin without a name, got %+v (%v)", plugins, err)
+	}
+
+	plugins, err = selectPlugins(cfg, "billing")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Name != "billing" || !plugins[0].Enabled {
+		t.Errorf("Expected the named plugin, enabled, got %+v", plugins)
+	}
+	if cfg.Plugins[1].Enabled {
+		t.Error("Expected the configuration to be left unchanged")
+	}
+
+	if _, err := selectPlugins(cfg, "ec2"); err == nil || !strings.Contains(err.Error(), "metrics.ec2") {
+		t.Errorf("Expected an error for a metrics section without a collector, got %v", err)
+	}
+	if _, err := selectPlugins(cfg, "missing"); err == nil {
+		t.Error("Expected an error for an unknown collector")
+	}
+}
+
+func TestDoctorChecks(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+	if result := checkOTELEndpoint(ctx, server.URL, time.Second); result.Status != doctorPass {
+		t.Errorf("Expected a reachable endpoint to pass, got %+v", result)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closed := "http://" + listener.Addr().String()
+	_ = listener.Close()
+	if result := checkOTELEndpoint(ctx, closed, time.Second); result.Status != doctorFail || result.Hint == "" {
+		t.Errorf("Expected a closed port to fail with a hint, got %+v", result)
+	}
+
+	if result := checkOTELEndpoint(ctx, "srv://_otlp._tcp.collector.internal:4317", time.Second); result.Status != doctorFail || result.Hint == "" {
+		t.Errorf("Expected an invalid SRV endpoint to fail with a hint, got %+v", result)
+	}
+	if result := checkClockSkew(ctx, server.URL, time.Second); result.Status != doctorPass {
+		t.Errorf("Expected no clock skew against a local server, got %+v", result)
+	}
+
+	if result := checkCredentials(ctx, &fakeCaller{}, "us-east-1", time.Second); result.Status != doctorPass ||
+		!strings.Contains(result.Message, "role/aws-monitor") {
+		t.Errorf("Expected valid credentials to pass, got %+v", result)
+	}
+}
+
+func TestEvaluateClockSkew(t *testing.T) {
+	tests := []struct {
+		skew    time.Duration
+		status  doctorStatus
+		message string
+	}{
+		{skew: 2 * time.Second, status: doctorPass, message: "local clock 2s ahead of AWS"},
+		{skew: -90 * time.Second, status: doctorWarn, message: "local clock 1m30s behind AWS"},
+		{skew: 10 * time.Minute, status: doctorFail, message: "local clock 10m0s ahead of AWS"},
+	}
+	for _, tt := range tests {
+		result := evaluateClockSkew(tt.skew)
+		if result.Status != tt.status || result.Message != tt.message {
+			t.Errorf("Expected %s %q for skew %s, got %s %q", tt.status, tt.message, tt.skew, result.Status, result.Message)
+		}
+	}
+}
+
+func TestWriteDoctorReport(t *testing.T) {
+	var out strings.Builder
+	writeDoctorReport(&out, []doctorResult{
+		{Check: "configuration", Status: doctorPass, Message: "loaded from config.yaml", Hint: "unused"},
+		{Check: "clock skew", Status: doctorFail, Message: "local clock 10m0s ahead of AWS", Hint: "Synchronize the clock with NTP"},
+	})
+
+	expected := "[PASS] configuration: loaded from config.yaml\n" +
+		"[FAIL] clock skew: local clock 10m0s ahead of AWS\n" +
+		"       hint: Synchronize the clock with NTP\n" +
+		"1 passed, 0 warnings, 1 failed\n"
+	if out.String() != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestCollectorsCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `
+enabled_regions: [us-east-1, eu-west-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+admin:
+  enabled: true
+  token: secret
+plugins:
+  - name: queue-depth
+    type: exec
+    enabled: true
+    collection_interval: 1m
+    region_intervals:
+      eu-west-1: 5m
+    settings:
+      command: /opt/check.sh
+`
+	if err := os.WriteFile(path, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	var out strings.Builder
+	if err := runCollectorsDescribe([]string{"queue-depth", "-config", path}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"Type:             exec", "Interval:         1m0s", "Region intervals: eu-west-1=5m0s", "Settings:         command=/opt/check.sh"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the description to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	lastRun := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error": "unauthorized"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/admin/collectors":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"collectors": map[string]collectors.CollectorInfo{"queue-depth": {
+					Name:             "queue-depth",
+					Status:           collectors.StatusRunning,
+					Interval:         time.Minute,
+					EnabledRegions:   []string{"us-east-1"},
+					LastCollection:   &lastRun,
+					MetricsCollected: 120,
+					ErrorCount:       3,
+				}},
+				"removed": []string{"billing"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error": "collector missing not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	out.Reset()
+	if err := runCollectorsList([]string{"-config", path, "-url", server.URL}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fields := strings.Fields(strings.Split(out.String(), "\n")[1])
+	if len(fields) != 7 || fields[0] != "queue-depth" || fields[1] != "running" || fields[5] != "120" || fields[6] != "3" {
+		t.Errorf("Unexpected collector row %v in:\n%s", fields, out.String())
+	}
+	if !strings.Contains(out.String(), "Removed: billing") {
+		t.Errorf("Expected the removed collectors, got:\n%s", out.String())
+	}
+
+	err := runCollectorsDescribe([]string{"-config", path, "-url", server.URL, "missing"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "collector missing not found") {
+		t.Errorf("Expected the admin API error, got %v", err)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	status := health.StatusHealthy
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer probe-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		overall := health.OverallHealth{Status: status}
+		if r.URL.Path == "/health/detailed" {
+			overall.Checks = map[string]health.CheckResult{
+				"aws":    {Status: health.StatusUnhealthy, Error: "credentials expired"},
+				"config": {Status: health.StatusHealthy, Message: "configuration valid"},
+			}
+		}
+		if status != health.StatusHealthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(overall)
+	}))
+	defer server.Close()
+
+	auth := config.HealthCheckAuthConfig{
+		Probes:   config.EndpointAuthConfig{Token: "probe-token"},
+		Detailed: config.EndpointAuthConfig{Token: "probe-token"},
+	}
+	var out strings.Builder
+	got, err := checkHealth(server.Client(), server.URL, auth, false, &out)
+	if err != nil || got != health.StatusHealthy || out.String() != "healthy\n" {
+		t.Errorf("Expected healthy, got %s %q (%v)", got, out.String(), err)
+	}
+
+	status = health.StatusUnhealthy
+	out.Reset()
+	got, err = checkHealth(server.Client(), server.URL, auth, true, &out)
+	if err != nil || got != health.StatusUnhealthy {
+		t.Fatalf("Expected unhealthy from a 503 response, got %s (%v)", got, err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 5 || !strings.HasPrefix(lines[3], "aws ") || !strings.Contains(lines[3], "credentials expired") {
+		t.Errorf("Expected a row per check, sorted, got:\n%s", out.String())
+	}
+
+	if _, err := checkHealth(server.Client(), server.URL, config.HealthCheckAuthConfig{}, false, &out); err == nil ||
+		!strings.Contains(err.Error(), "health_check_auth") {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+}
+
+func TestWritePIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aws-monitor.pid")
+
+	// A running process holds the file
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getppid())+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write PID file: %v", err)
+	}
+	if _, err := writePIDFile(path); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("Expected the running process to be reported, got %v", err)
+	}
+
+	// A stale file is replaced
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatalf("Failed to run a process: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(exited.Process.Pid)), 0644); err != nil {
+		t.Fatalf("Failed to write PID file: %v", err)
+	}
+	remove, err := writePIDFile(path)
+	if err != nil {
+		t.Fatalf("Expected a stale PID file to be replaced, got %v", err)
+	}
+	if pid, err := readPIDFile(path); err != nil || pid != os.Getpid() {
+		t.Errorf("Expected PID %d in the file, got %d (%v)", os.Getpid(), pid, err)
+	}
+
+	remove()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the PID file to be removed, got %v", err)
+	}
+}
+
+func TestShutdownSequence(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json", OutputPath: "stderr"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	var order []string
+	stage := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			order = append(order, name)
+			if _, ok := ctx.Deadline(); ok != (name == "scheduler") {
+				t.Errorf("Expected only the scheduler stage to have a deadline, %s has one: %t", name, ok)
+			}
+			return nil
+		}
+	}
+
+	shutdown := newShutdownSequence(log)
+	shutdown.add("aws clients", 0, stage("aws clients"))
+	shutdown.add("health server", 0, stage("health server"))
+	shutdown.add("collectors", 0, func(context.Context) error {
+		order = append(order, "collectors")
+		return errors.NewTimeoutError("collectors-stop", time.Second)
+	})
+	shutdown.add("scheduler", time.Minute, stage("scheduler"))
+	shutdown.run()
+
+	// Stages run in reverse order, past failures
+	expected := "scheduler,collectors,health server,aws clients"
+	if got := strings.Join(order, ","); got != expected {
+		t.Errorf("Expected stages %s, got %s", expected, got)
+	}
+
+	shutdown.run()
+	if len(order) != 4 {
+		t.Errorf("Expected a second run to do nothing, got %v", order)
+	}
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	validYAML := `
+enabled_regions: [us-east-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+`
+	tests := []struct {
+		name     string
+		content  string
+		exitCode int
+		valid    bool
+		errors   int
+		warnings int
+	}{
+		{name: "valid", content: validYAML, exitCode: 0, valid: true},
+		{name: "warnings", content: validYAML + "global:\n  log_levle: debug\n", exitCode: validateExitWarnings, valid: true, warnings: 1},
+		{name: "invalid", content: strings.Replace(validYAML, "http://localhost:4317", "", 1), exitCode: validateExitInvalid, errors: 1},
+		{name: "unparseable", content: "enabled_regions: [us-east-1\n", exitCode: validateExitUnreadable, errors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, report, _ := validateConfiguration(&configFlags{path: path})
+			if report.ExitCode != tt.exitCode || report.Valid != tt.valid || (cfg != nil) != tt.valid {
+				t.Errorf("Expected exit code %d and valid %t, got %+v", tt.exitCode, tt.valid, report)
+			}
+			if len(report.Errors) != tt.errors || len(report.Warnings) != tt.warnings {
+				t.Errorf("Expected %d errors and %d warnings, got %q and %q", tt.errors, tt.warnings, report.Errors, report.Warnings)
+			}
+			if tt.valid && report.Defaults["global.health_check_port"] != "8080" {
+				t.Errorf("Expected the defaulted health check port in the report, got %v", report.Defaults)
+			}
+
+			// The report is JSON with empty lists rather than null
+			var out strings.Builder
+			if err := writeValidationReport(&out, report); err != nil {
+				t.Fatalf("Failed to write report: %v", err)
+			}
+			var decoded map[string]interface{}
+			if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+				t.Fatalf("Expected JSON report, got %q: %v", out.String(), err)
+			}
+			if _, ok := decoded["errors"].([]interface{}); !ok {
+				t.Errorf("Expected an errors list, got %v", decoded["errors"])
+			}
+
+			// The flags of earlier releases exit with the same codes
+			if code := runLegacy([]string{"-validate", "-format", "json", "-config", path}); code != tt.exitCode {
+				t.Errorf("Expected -validate to exit %d, got %d", tt.exitCode, code)
+			}
+		})
+	}
+}
+
+func TestRunDashboardsGenerate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `
+enabled_regions: [us-east-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+global:
+  self_metrics:
+    enabled: true
+plugins:
+  - name: queue-depth
+    type: exec
+    enabled: true
+    settings:
+      command: /bin/true
+      metrics: queue_depth
+`
+	if err := os.WriteFile(path, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := runDashboardsGenerate([]string{"-config", path, "-backend", "influxdb"}, io.Discard); err == nil {
+		t.Error("Expected error for unsupported backend")
+	}
+
+	var stdout strings.Builder
+	if err := runDashboardsGenerate([]string{"-config", path, "--backend", "cloudwatch"}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var dashboard grafana.Dashboard
+	if err := json.Unmarshal([]byte(stdout.String()), &dashboard); err != nil {
+		t.Fatalf("Expected a JSON dashboard on stdout, got %q: %v", stdout.String(), err)
+	}
+
+	var rows []string
+	queueDepth := false
+	for _, panel := range dashboard.Panels {
+		if panel.Type == "row" {
+			rows = append(rows, panel.Title)
+		}
+		for _, target := range panel.Targets {
+			if target.MetricName == "queue_depth" && target.Namespace == "aws-monitor" {
+				queueDepth = true
+			}
+		}
+	}
+	if strings.Join(rows, ",") != "queue-depth,self" {
+		t.Errorf("Expected a row for the exec and self-telemetry collectors, got %v", rows)
+	}
+	if !queueDepth {
+		t.Error("Expected a queue_depth panel in the service name namespace")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+// permissionRequirement is the set of permissions needed by aws-monitor itself
+// or by one collector
+type permissionRequirement struct {
+	Name        string
+	Permissions []aws.Permission
+}
+
+// corePermissions returns the permissions aws-monitor needs whichever
+// collectors are enabled
+func corePermissions(cfg *config.Config) []aws.Permission {
+	permissions := []aws.Permission{
+		// Account resolution and credential validation
+		{Action: "sts:GetCallerIdentity"},
+		{Action: "iam:ListAccountAliases"},
+		// AWS connectivity health check and the resource inventory cache
+		{Action: "ec2:DescribeInstances"},
+	}
+	if cfg.Alerting.Enabled && cfg.Alerting.SNS.TopicARN != "" {
+		permissions = append(permissions, aws.Permission{Action: "sns:Publish", Resource: cfg.Alerting.SNS.TopicARN})
+	}
+	if cfg.EventTriggers.Enabled && cfg.EventTriggers.QueueURL != "" {
+		queue := queueARN(cfg.EventTriggers.QueueURL, cfg.EventTriggers.Region)
+		permissions = append(permissions,
+			aws.Permission{Action: "sqs:ReceiveMessage", Resource: queue},
+			aws.Permission{Action: "sqs:DeleteMessage", Resource: queue})
+	}
+	if cfg.Control.Enabled && cfg.Control.QueueURL != "" {
+		queue := queueARN(cfg.Control.QueueURL, cfg.Control.Region)
+		permissions = append(permissions,
+			aws.Permission{Action: "sqs:ReceiveMessage", Resource: queue},
+			aws.Permission{Action: "sqs:DeleteMessage", Resource: queue})
+	}
+	return permissions
+}
+
+// queueARN returns the ARN of the SQS queue at queueURL, whose path is the
+// account ID and the queue name, or "*" when the URL has another form
+func queueARN(queueURL, region string) string {
+	parsed, err := url.Parse(queueURL)
+	if err != nil {
+		return "*"
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "*"
+	}
+	partition, ok := config.RegionPartition(region)
+	if !ok {
+		partition = config.PartitionAWS
+	}
+	return fmt.Sprintf("arn:%s:sqs:%s:%s:%s", partition, region, parts[0], parts[1])
+}
+
+// permissionRequirements creates the enabled collectors, without starting
+// them, and returns the permissions of aws-monitor followed by those of each
+// collector, by name
+func permissionRequirements(cfg *config.Config, provider aws.ClientProvider, log *logger.Logger) ([]permissionRequirement, error) {
+	enabled, err := collectors.LoadPluginCollectors(cfg.Plugins, collectors.CollectorDependencies{
+		Config:      cfg,
+		AWSProvider: provider,
+		Logger:      log,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin collectors: %w", err)
+	}
+	sort.Slice(enabled, func(i, j int) bool { return enabled[i].Name() < enabled[j].Name() })
+
+	requirements := []permissionRequirement{{Name: "aws-monitor", Permissions: corePermissions(cfg)}}
+	for _, collector := range enabled {
+		requirements = append(requirements, permissionRequirement{
+			Name:        collector.Name(),
+			Permissions: collectors.RequiredPermissions(collector),
+		})
+	}
+	return requirements, nil
+}
+
+// runPermissionCheck reports the permissions aws-monitor and the enabled
+// collectors are missing to w. It returns whether every permission is
+// allowed.
+func runPermissionCheck(ctx context.Context, cfg *config.Config, provider aws.ClientProvider, w io.Writer, log *logger.Logger) (bool, error) {
+	requirements, err := permissionRequirements(cfg, provider, log)
+	if err != nil {
+		return false, err
+	}
+
+	stsClient, err := provider.GetSTSClient(cfg.AWS.DefaultRegion)
+	if err != nil {
+		return false, fmt.Errorf("failed to create STS client: %w", err)
+	}
+	iamClient, err := provider.GetIAMClient(cfg.AWS.DefaultRegion)
+	if err != nil {
+		return false, fmt.Errorf("failed to create IAM client: %w", err)
+	}
+	simulator, ok := iamClient.(aws.PolicySimulator)
+	if !ok {
+		return false, fmt.Errorf("IAM client does not support policy simulation")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return checkPermissions(ctx, stsClient, simulator, requirements, w)
+}
+
+// checkPermissions simulates the caller's policies for every requirement and
+// writes a line per requirement listing its missing permissions
+func checkPermissions(ctx context.Context, stsClient aws.STSClient, simulator aws.PolicySimulator, requirements []permissionRequirement, w io.Writer) (bool, error) {
+	var all []aws.Permission
+	for _, requirement := range requirements {
+		all = append(all, requirement.Permissions...)
+	}
+
+	report, err := aws.CheckPermissions(ctx, stsClient, simulator, all)
+	if err != nil {
+		return false, err
+	}
+	missing := make(map[aws.Permission]bool, len(report.Missing))
+	for _, permission := range report.Missing {
+		missing[permission] = true
+	}
+
+	fmt.Fprintf(w, "Checked permissions of %s\n", report.Principal)
+	for _, requirement := range requirements {
+		if len(requirement.Permissions) == 0 {
+			fmt.Fprintf(w, "%s: no AWS permissions declared\n", requirement.Name)
+			continue
+		}
+
+		var names []string
+		for _, permission := range requirement.Permissions {
+			if permission.Resource == "" {
+				permission.Resource = "*"
+			}
+			if missing[permission] {
+				names = append(names, permission.String())
+			}
+		}
+		if len(names) == 0 {
+			fmt.Fprintf(w, "%s: ok\n", requirement.Name)
+		} else {
+			fmt.Fprintf(w, "%s: missing %s\n", requirement.Name, strings.Join(names, ", "))
+		}
+	}
+	return len(report.Missing) == 0, nil
+}
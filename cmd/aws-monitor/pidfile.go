@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writePIDFile writes the process ID to path, refusing to if the file names
+// another running process. A file left behind by a process that is no longer
+// running is replaced. The returned function removes the file if it still
+// holds this process's ID.
+func writePIDFile(path string) (func(), error) {
+	pid := os.Getpid()
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			existing, readErr := readPIDFile(path)
+			if readErr == nil && existing != pid && processAlive(existing) {
+				return nil, fmt.Errorf("%s: aws-monitor is already running with PID %d", path, existing)
+			}
+			// Stale or unreadable: replace it
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = fmt.Fprintf(file, "%d\n", pid)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			return nil, err
+		}
+		return func() {
+			if existing, err := readPIDFile(path); err == nil && existing == pid {
+				_ = os.Remove(path)
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("%s: the PID file keeps being recreated by another process", path)
+}
+
+// readPIDFile returns the process ID in a PID file
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("%s: invalid PID %q", path, strings.TrimSpace(string(data)))
+	}
+	return pid, nil
+}
+
+// waitForPIDFile waits until path holds pid, or until timeout
+func waitForPIDFile(path string, pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if existing, err := readPIDFile(path); err == nil && existing == pid {
+			return true
+		}
+		if !processAlive(pid) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/platform"
+)
+
+// detectPlatform detects the platform aws-monitor runs on, unless detection
+// is disabled. The metadata is kept out of the configuration, so a reloaded
+// configuration still compares equal to the one loaded at startup.
+func detectPlatform(cfg config.PlatformConfig) platform.Metadata {
+	if cfg.DisableDetection {
+		return platform.Metadata{}
+	}
+	host := platform.Detect(platform.Options{ClusterName: cfg.ClusterName})
+	if cfg.DisableLabels {
+		host.Labels = nil
+	}
+	return host
+}
+
+// exportOTELConfig returns the OTEL configuration telemetry is exported with:
+// the configured one with the platform metadata added to its resource
+// attributes
+func exportOTELConfig(cfg config.OTELConfig, host platform.Metadata) config.OTELConfig {
+	cfg.ResourceAttributes = host.MergeAttributes(cfg.ResourceAttributes)
+	return cfg
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// processAlive reports whether a process with the ID exists; on Windows,
+// finding a process opens it, which fails if it does not exist
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
+
+// startBackground is not supported on Windows; install aws-monitor as a
+// service instead
+func startBackground(args []string) (int, error) {
+	return 0, fmt.Errorf("running in the background is not supported on Windows; run aws-monitor as a service instead")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// shutdownStage stops a component of the application
+type shutdownStage struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// shutdownSequence stops the components of the application in the reverse
+// order they were started, so each is stopped before the components it
+// depends on, logging how long each stage took
+type shutdownSequence struct {
+	stages []shutdownStage
+	log    *logger.Logger
+}
+
+func newShutdownSequence(log *logger.Logger) *shutdownSequence {
+	return &shutdownSequence{log: log}
+}
+
+// add registers a stage; its context is cancelled after timeout, unless
+// timeout is 0
+func (s *shutdownSequence) add(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	s.stages = append(s.stages, shutdownStage{name: name, timeout: timeout, stop: stop})
+}
+
+// run runs the stages, continuing past failures. Later calls do nothing.
+func (s *shutdownSequence) run() {
+	for i := len(s.stages) - 1; i >= 0; i-- {
+		s.runStage(s.stages[i])
+	}
+	s.stages = nil
+}
+
+func (s *shutdownSequence) runStage(stage shutdownStage) {
+	ctx := context.Background()
+	if stage.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, stage.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := stage.stop(ctx)
+	duration := time.Since(start)
+	if err != nil {
+		s.log.Error("Shutdown stage failed",
+			logger.String("stage", stage.name),
+			logger.Duration("duration", duration),
+			logger.String("error", err.Error()))
+		return
+	}
+	s.log.Info("Shutdown stage completed",
+		logger.String("stage", stage.name),
+		logger.Duration("duration", duration))
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyLogLevelSignals relays SIGUSR1 (more verbose) and SIGUSR2 (less
+// verbose) to c
+func notifyLogLevelSignals(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+}
+
+// notifyReloadSignal relays SIGHUP, which reloads the configuration, to c
+func notifyReloadSignal(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGHUP)
+}
+
+// isIncreaseVerbositySignal reports whether sig asks for more verbose logging
+func isIncreaseVerbositySignal(sig os.Signal) bool {
+	return sig == syscall.SIGUSR1
+}
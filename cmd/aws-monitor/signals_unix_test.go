@@ -0,0 +1,43 @@
+//go:build !windows
+
+package main
+
+import (
+	"syscall"
+	"testing"
+
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestChangeLogLevelIsAudited(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	auditLog, err := audit.Open("", 0)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+
+	changeLogLevel(syscall.SIGUSR1, log, auditLog)
+	if log.Level() != "debug" {
+		t.Errorf("Expected SIGUSR1 to raise the log level to debug, got %s", log.Level())
+	}
+	changeLogLevel(syscall.SIGUSR2, log, auditLog)
+	changeLogLevel(syscall.SIGUSR2, log, auditLog)
+	if log.Level() != "warn" {
+		t.Errorf("Expected SIGUSR2 to lower the log level to warn, got %s", log.Level())
+	}
+
+	entries := auditLog.Entries(audit.Filter{Action: audit.ActionLogLevelChange})
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 audited log level changes, got %+v", entries)
+	}
+	if entries[0].Actor != "signal:SIGUSR2" || entries[0].Details["from"] != "info" || entries[0].Details["to"] != "warn" {
+		t.Errorf("Expected the latest change by SIGUSR2 from info to warn, got %+v", entries[0])
+	}
+	if entries[2].Actor != "signal:SIGUSR1" || entries[2].Details["from"] != "info" || entries[2].Details["to"] != "debug" {
+		t.Errorf("Expected the first change by SIGUSR1 from info to debug, got %+v", entries[2])
+	}
+}
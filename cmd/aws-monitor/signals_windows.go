@@ -0,0 +1,18 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// notifyLogLevelSignals is a no-op on Windows, which has no SIGUSR1/SIGUSR2;
+// use the admin API to change the log level instead
+func notifyLogLevelSignals(c chan<- os.Signal) {}
+
+// notifyReloadSignal is a no-op on Windows, which has no SIGHUP; enable
+// global.config_reload.watch to reload the configuration instead
+func notifyReloadSignal(c chan<- os.Signal) {}
+
+// isIncreaseVerbositySignal always reports false on Windows
+func isIncreaseVerbositySignal(sig os.Signal) bool {
+	return false
+}
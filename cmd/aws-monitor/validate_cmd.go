@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/config"
+)
+
+// Exit codes of the validate command, for CI pipelines that gate on
+// configuration changes; 2 is a usage error, as for every command
+const (
+	// validateExitInvalid is returned for invalid settings or missing IAM
+	// permissions
+	validateExitInvalid = 1
+	// validateExitUnreadable is returned when the configuration file
+	// cannot be found, read or parsed
+	validateExitUnreadable = 3
+	// validateExitWarnings is returned for a valid configuration with
+	// deprecated or unknown keys
+	validateExitWarnings = 4
+)
+
+// validationReport is the output of `aws-monitor validate -format json`
+type validationReport struct {
+	File     string   `json:"file,omitempty"`
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+	// Defaults are the settings the file leaves to their default values
+	Defaults map[string]string `json:"defaults,omitempty"`
+	ExitCode int               `json:"exit_code"`
+}
+
+// runValidateCommand implements `aws-monitor validate`
+func runValidateCommand(args []string) int {
+	fs := newCommandFlags("validate")
+	var flags configFlags
+	flags.register(fs)
+	permissions := fs.Bool("permissions", false, "Also report the IAM permissions missing for the enabled collectors")
+	format := fs.String("format", "text", "Output format: text, or json for a report of the errors, warnings and defaulted settings")
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	return runValidation(&flags, *permissions, *format)
+}
+
+// runValidation validates the configuration in the output format of the
+// validate command and returns one of the validate exit codes; the
+// -validate and -check-permissions flags run it too
+func runValidation(flags *configFlags, permissions bool, format string) int {
+	switch {
+	case format != "text" && format != "json":
+		fmt.Fprintf(os.Stderr, "validate: unknown format %q\n", format)
+		return 2
+	case format == "json" && permissions:
+		fmt.Fprintln(os.Stderr, "validate: checking permissions is not supported with -format json")
+		return 2
+	case format == "json":
+		_, report, _ := validateConfiguration(flags)
+		if err := writeValidationReport(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+			return validateExitInvalid
+		}
+		return report.ExitCode
+	}
+	return validate(flags, permissions)
+}
+
+// validateConfiguration loads the configuration and reports its errors,
+// warnings and defaulted settings, returning the configuration if it is
+// valid and the load error if not
+func validateConfiguration(flags *configFlags) (*config.Config, validationReport, error) {
+	report := validationReport{Errors: []string{}, Warnings: []string{}}
+	report.File, _ = config.ResolvePath(flags.path)
+
+	cfg, defaulted, err := config.LoadWithDefaults(flags.path, flags.loadOptions())
+	var validationErr *config.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		report.Errors = validationErr.Problems
+		report.ExitCode = validateExitInvalid
+		return nil, report, err
+	case err != nil:
+		report.Errors = []string{err.Error()}
+		report.ExitCode = validateExitUnreadable
+		return nil, report, err
+	}
+
+	report.Valid = true
+	for _, deprecation := range cfg.Deprecations() {
+		report.Warnings = append(report.Warnings, deprecation.String())
+	}
+	for _, key := range cfg.UnknownKeys() {
+		report.Warnings = append(report.Warnings, key.String())
+	}
+	report.Defaults = defaulted
+	if len(report.Warnings) > 0 {
+		report.ExitCode = validateExitWarnings
+	}
+	return cfg, report, nil
+}
+
+func writeValidationReport(w io.Writer, report validationReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// validate loads the configuration, logging its warnings, and, when
+// permissions is set, reports the IAM permissions that are missing. It
+// returns one of the validate exit codes.
+func validate(flags *configFlags, permissions bool) int {
+	cfg, report, err := validateConfiguration(flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return report.ExitCode
+	}
+	mainLogger, flush, ok := startLogging(cfg, flags, newLoggerConfig(cfg))
+	if !ok {
+		return validateExitInvalid
+	}
+	defer flush()
+	mainLogger.Info("Configuration validation successful")
+	if !permissions {
+		return report.ExitCode
+	}
+
+	// Preflight: report missing IAM permissions before collecting
+	provider := aws.NewClientProvider(cfg, mainLogger)
+	defer provider.Close()
+	allowed, err := runPermissionCheck(context.Background(), cfg, provider, os.Stdout, mainLogger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to check permissions: %v\n", err)
+		return validateExitInvalid
+	}
+	if !allowed {
+		return validateExitInvalid
+	}
+	return report.ExitCode
+}
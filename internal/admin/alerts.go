@@ -0,0 +1,193 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/pkg/logger"
+)
+
+// FiringAlerts lists the alerts that are firing, e.g. *rules.Evaluator
+type FiringAlerts interface {
+	FiringAlerts() []alerting.Alert
+}
+
+// AlertStatus is a firing alert with how it is muted, if it is
+type AlertStatus struct {
+	Alert          alerting.Alert           `json:"alert"`
+	Acknowledgment *alerting.Acknowledgment `json:"acknowledgment,omitempty"`
+	// SilencedBy is the ID of a silence matching the alert
+	SilencedBy string `json:"silenced_by,omitempty"`
+}
+
+// AcknowledgeRequest is the body of a request to acknowledge a firing alert,
+// identified by its rule and the labels of its series
+type AcknowledgeRequest struct {
+	Rule    string            `json:"rule"`
+	Labels  map[string]string `json:"labels"`
+	Comment string            `json:"comment,omitempty"`
+	By      string            `json:"by,omitempty"`
+}
+
+// SilenceRequest is the body of a request to silence alerts
+type SilenceRequest struct {
+	// Matchers are the label values of the alerts to silence
+	Matchers map[string]string `json:"matchers"`
+	// Duration is how long the silence lasts (e.g. "2h")
+	Duration  string `json:"duration"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+// SetAlerts serves the firing alerts of firing, which may be nil, and the
+// silences and acknowledgments of silencer
+func (h *Handler) SetAlerts(silencer *alerting.Silencer, firing FiringAlerts) {
+	h.silencer = silencer
+	h.firing = firing
+}
+
+// handleListAlerts returns the firing alerts and whether they are muted
+func (h *Handler) handleListAlerts(w http.ResponseWriter, _ *http.Request) {
+	if h.silencer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "alerting is not enabled")
+		return
+	}
+
+	statuses := []AlertStatus{}
+	for _, alert := range h.firingAlerts() {
+		status := AlertStatus{Alert: alert}
+		if ack, ok := h.silencer.Acknowledged(alert); ok {
+			status.Acknowledgment = &ack
+		}
+		status.SilencedBy, _ = h.silencer.Silenced(alert)
+		statuses = append(statuses, status)
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": statuses})
+}
+
+// handleAcknowledgeAlert acknowledges a firing alert, muting it until it
+// resolves
+func (h *Handler) handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	if h.silencer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "alerting is not enabled")
+		return
+	}
+
+	var req AcknowledgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.Rule == "" {
+		h.writeError(w, http.StatusBadRequest, "rule is required")
+		return
+	}
+
+	series := alerting.SeriesKey(req.Labels)
+	for _, alert := range h.firingAlerts() {
+		if alert.Rule != req.Rule || alerting.SeriesKey(alert.Labels) != series {
+			continue
+		}
+
+		ack, err := h.silencer.Acknowledge(alert, req.Comment, req.By)
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.logger.Info("Alert acknowledged via admin API",
+			logger.String("rule", req.Rule),
+			logger.String("series", series),
+			logger.String("by", req.By))
+		h.record(r, audit.ActionAlertAcknowledge, req.Rule, map[string]string{
+			"series":  series,
+			"by":      req.By,
+			"comment": req.Comment,
+		})
+		h.writeJSON(w, http.StatusOK, ack)
+		return
+	}
+
+	h.writeError(w, http.StatusNotFound, fmt.Sprintf("alert %s is not firing for %s", req.Rule, series))
+}
+
+// handleListSilences returns the active silences
+func (h *Handler) handleListSilences(w http.ResponseWriter, _ *http.Request) {
+	if h.silencer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "alerting is not enabled")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"silences": h.silencer.Silences()})
+}
+
+// handleAddSilence silences the alerts matching the request's matchers
+func (h *Handler) handleAddSilence(w http.ResponseWriter, r *http.Request) {
+	if h.silencer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "alerting is not enabled")
+		return
+	}
+
+	var req SilenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration %q", req.Duration))
+		return
+	}
+
+	silence, err := h.silencer.AddSilence(req.Matchers, duration, req.Comment, req.CreatedBy)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.logger.Info("Alerts silenced via admin API",
+		logger.String("silence", silence.ID),
+		logger.String("matchers", alerting.SeriesKey(silence.Matchers)),
+		logger.Duration("duration", duration))
+	h.record(r, audit.ActionSilenceCreate, silence.ID, map[string]string{
+		"matchers":   alerting.SeriesKey(silence.Matchers),
+		"duration":   duration.String(),
+		"created_by": silence.CreatedBy,
+		"comment":    silence.Comment,
+	})
+	h.writeJSON(w, http.StatusCreated, silence)
+}
+
+// handleExpireSilence ends a silence
+func (h *Handler) handleExpireSilence(w http.ResponseWriter, r *http.Request) {
+	if h.silencer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "alerting is not enabled")
+		return
+	}
+
+	id := r.PathValue("id")
+	expired, err := h.silencer.ExpireSilence(id)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !expired {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("silence %s not found", id))
+		return
+	}
+
+	h.logger.Info("Silence expired via admin API", logger.String("silence", id))
+	h.record(r, audit.ActionSilenceExpire, id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// firingAlerts returns the firing alerts, if alert rules are evaluated
+func (h *Handler) firingAlerts() []alerting.Alert {
+	if h.firing == nil {
+		return nil
+	}
+	return h.firing.FiringAlerts()
+}
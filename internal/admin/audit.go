@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/pkg/logger"
+)
+
+// SetAuditLog records the changes made through the API to log and serves it
+func (h *Handler) SetAuditLog(log *audit.Log) {
+	h.audit = log
+}
+
+// handleListAudit returns the latest audit log entries, newest first,
+// optionally filtered by the action, since and limit query parameters
+func (h *Handler) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "audit log is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{Action: query.Get("action")}
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid since %q, expected RFC 3339", since))
+			return
+		}
+		filter.Since = t
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", limit))
+			return
+		}
+		filter.Limit = n
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"entries": h.audit.Entries(filter)})
+}
+
+// record adds a change made by the request to the audit log, if any, with
+// the non-empty details and the client address
+func (h *Handler) record(r *http.Request, action, target string, details map[string]string) {
+	if h.audit == nil {
+		return
+	}
+	recorded := map[string]string{"remote_addr": r.RemoteAddr}
+	for key, value := range details {
+		if value != "" {
+			recorded[key] = value
+		}
+	}
+	if err := h.audit.Record(r.Context(), action, target, recorded); err != nil {
+		h.logger.Error("Failed to record audit log entry",
+			logger.String("action", action),
+			logger.String("error", err.Error()))
+	}
+}
+
+// requestActor identifies who made an authorized request: the basic auth
+// username, or "token" for bearer token requests
+func requestActor(r *http.Request) string {
+	if username, _, ok := r.BasicAuth(); ok {
+		return "admin:" + username
+	}
+	return "admin:token"
+}
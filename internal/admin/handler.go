@@ -0,0 +1,446 @@
+// Package admin provides the authenticated runtime administration API.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/health"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/logger"
+)
+
+// PathPrefix is the path under which the admin API is served
+const PathPrefix = "/admin/"
+
+// Handler serves the admin API. It allows collectors to be added and removed
+// at runtime, e.g. to disable a collector that is causing throttling.
+//
+//	GET    /admin/collectors         list collectors and their status
+//	GET    /admin/collectors/{name}  show a collector and its schedule
+//	POST   /admin/collectors         add a configured or removed collector (body: name)
+//	DELETE /admin/collectors/{name}  unschedule and remove a collector
+//	GET    /admin/log-level          show the current log level
+//	PUT    /admin/log-level          change the log level (body: {"level": "debug"})
+//	GET    /admin/alerts             list firing alerts and whether they are muted
+//	POST   /admin/alerts/acknowledge acknowledge a firing alert (body: rule and labels)
+//	GET    /admin/silences           list active silences
+//	POST   /admin/silences           silence alerts (body: matchers and duration)
+//	DELETE /admin/silences/{id}      expire a silence
+//	GET    /admin/audit              list the changes made at runtime, newest first
+//
+// Only a removed collector or a plugin collector declared in the
+// configuration, usually disabled, can be added: the API never creates
+// collectors of a type and settings of its own, which for the exec type would
+// run any command on the host.
+type Handler struct {
+	registry    collectors.Registry
+	scheduler   scheduler.Scheduler
+	deps        collectors.CollectorDependencies
+	config      func() *config.Config
+	credentials health.Credentials
+	// ctx is the context added collectors are started with, which outlives
+	// the request adding them
+	ctx    context.Context
+	logger *logger.Logger
+	mux    *http.ServeMux
+
+	mu      sync.Mutex
+	removed map[string]collectors.MetricCollector
+
+	// checkers, if set, tracks a health checker per collector
+	checkers CheckerRegistry
+
+	// silencer and firing, if set, serve the alert endpoints
+	silencer *alerting.Silencer
+	firing   FiringAlerts
+
+	// audit, if set, records the changes made through the API
+	audit *audit.Log
+}
+
+// CheckerRegistry registers the health checkers of collectors added or
+// removed at runtime
+type CheckerRegistry interface {
+	RegisterChecker(checker health.Checker)
+	UnregisterChecker(name string)
+}
+
+// CollectorRequest is the body of a request to add a collector
+type CollectorRequest struct {
+	// Name is the name of a removed collector, or of a plugin collector
+	// declared in the configuration
+	Name string `json:"name"`
+	// Interval overrides the configured collection interval (e.g. "5m") of
+	// a plugin collector
+	Interval string `json:"interval,omitempty"`
+	// Regions overrides the configured regions of a plugin collector
+	Regions []string `json:"regions,omitempty"`
+	// Groups overrides the configured groups of a plugin collector
+	Groups []string `json:"groups,omitempty"`
+}
+
+// CollectorDetail is the response to a request for a single collector
+type CollectorDetail struct {
+	// Collector is the status of the collector
+	Collector collectors.CollectorInfo `json:"collector"`
+	// Jobs are the collector's scheduled jobs, by region
+	Jobs []JobStatus `json:"jobs"`
+}
+
+// JobStatus is the schedule of a collector in one region
+type JobStatus struct {
+	Region   string        `json:"region"`
+	Interval time.Duration `json:"interval"`
+	NextRun  time.Time     `json:"next_run"`
+	LastRun  *time.Time    `json:"last_run,omitempty"`
+	// LastMetricCount is the number of metrics collected by the last run
+	LastMetricCount int `json:"last_metric_count"`
+}
+
+// LogLevelRequest is the body of a request to change the log level
+type LogLevelRequest struct {
+	// Level is the new log level: debug, info, warn or error
+	Level string `json:"level"`
+}
+
+// NewHandler creates a new admin API handler. Requests must carry the
+// credentials' bearer token or basic auth; without credentials every request
+// is rejected.
+func NewHandler(
+	registry collectors.Registry,
+	sched scheduler.Scheduler,
+	deps collectors.CollectorDependencies,
+	credentials health.Credentials,
+	log *logger.Logger,
+) *Handler {
+	h := &Handler{
+		registry:    registry,
+		scheduler:   sched,
+		deps:        deps,
+		config:      func() *config.Config { return deps.Config },
+		credentials: credentials,
+		ctx:         context.Background(),
+		logger:      log.WithComponent("admin-api"),
+		mux:         http.NewServeMux(),
+		removed:     make(map[string]collectors.MetricCollector),
+	}
+
+	h.mux.HandleFunc("GET /admin/collectors", h.handleListCollectors)
+	h.mux.HandleFunc("GET /admin/collectors/{name}", h.handleGetCollector)
+	h.mux.HandleFunc("POST /admin/collectors", h.handleAddCollector)
+	h.mux.HandleFunc("DELETE /admin/collectors/{name}", h.handleRemoveCollector)
+	h.mux.HandleFunc("GET /admin/log-level", h.handleGetLogLevel)
+	h.mux.HandleFunc("PUT /admin/log-level", h.handleSetLogLevel)
+	h.mux.HandleFunc("GET /admin/alerts", h.handleListAlerts)
+	h.mux.HandleFunc("POST /admin/alerts/acknowledge", h.handleAcknowledgeAlert)
+	h.mux.HandleFunc("GET /admin/silences", h.handleListSilences)
+	h.mux.HandleFunc("POST /admin/silences", h.handleAddSilence)
+	h.mux.HandleFunc("DELETE /admin/silences/{id}", h.handleExpireSilence)
+	h.mux.HandleFunc("GET /admin/audit", h.handleListAudit)
+
+	return h
+}
+
+// ServeHTTP authenticates the request and dispatches it to the admin endpoints
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		h.logger.Warn("Unauthorized admin request",
+			logger.String("method", r.Method),
+			logger.String("path", r.URL.Path),
+			logger.String("remote_addr", r.RemoteAddr))
+		h.credentials.Challenge(w)
+		h.writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	h.mux.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), requestActor(r))))
+}
+
+// handleListCollectors returns the status of all registered collectors
+func (h *Handler) handleListCollectors(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	removed := make([]string, 0, len(h.removed))
+	for name := range h.removed {
+		removed = append(removed, name)
+	}
+	h.mu.Unlock()
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collectors": h.registry.Status(),
+		"removed":    removed,
+	})
+}
+
+// handleGetCollector returns the status and scheduled jobs of a collector
+func (h *Handler) handleGetCollector(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	collector, exists := h.registry.Get(name)
+	if !exists {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("collector %s not found", name))
+		return
+	}
+
+	detail := CollectorDetail{Collector: collector.Info(), Jobs: []JobStatus{}}
+	for _, job := range h.scheduler.GetScheduledJobs() {
+		if job.CollectorName != name {
+			continue
+		}
+		detail.Jobs = append(detail.Jobs, JobStatus{
+			Region:          job.Region,
+			Interval:        job.Interval,
+			NextRun:         job.NextRun,
+			LastRun:         job.LastRun,
+			LastMetricCount: job.LastMetricCount,
+		})
+	}
+	sort.Slice(detail.Jobs, func(i, j int) bool { return detail.Jobs[i].Region < detail.Jobs[j].Region })
+
+	h.writeJSON(w, http.StatusOK, detail)
+}
+
+// SetHealthManager registers a health checker for each collector added
+// through the API and unregisters it when the collector is removed
+func (h *Handler) SetHealthManager(registry CheckerRegistry) {
+	h.checkers = registry
+}
+
+// SetConfig sets where the configuration the collectors are added from is
+// read, which is deps.Config unless set. current is called on every request,
+// so reloads are reflected.
+func (h *Handler) SetConfig(current func() *config.Config) {
+	h.config = current
+}
+
+// SetContext sets the application context collectors added through the API
+// are started with, which is context.Background() unless set
+func (h *Handler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// handleAddCollector creates, registers, starts and schedules a collector
+func (h *Handler) handleAddCollector(w http.ResponseWriter, r *http.Request) {
+	var req CollectorRequest
+	// Unknown fields, such as a type or settings, are rejected rather than
+	// ignored
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.Name == "" {
+		h.writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if _, exists := h.registry.Get(req.Name); exists {
+		h.writeError(w, http.StatusConflict, fmt.Sprintf("collector %s already registered", req.Name))
+		return
+	}
+
+	collector, status, err := h.buildCollector(req)
+	if err != nil {
+		h.writeError(w, status, err.Error())
+		return
+	}
+
+	if err := h.registry.Register(collector); err != nil {
+		h.writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if err := collector.Start(h.ctx); err != nil {
+		_ = h.registry.Unregister(req.Name)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to start collector: %v", err))
+		return
+	}
+
+	info := collector.Info()
+	if err := scheduler.ScheduleCollectorInfo(h.scheduler, info); err != nil {
+		_ = h.registry.Unregister(req.Name)
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to schedule collector: %v", err))
+		return
+	}
+
+	h.mu.Lock()
+	delete(h.removed, req.Name)
+	h.mu.Unlock()
+
+	if h.checkers != nil {
+		h.checkers.RegisterChecker(health.NewCollectorHealthChecker(collector))
+	}
+
+	h.logger.Info("Collector added via admin API",
+		logger.String("collector", info.Name),
+		logger.Strings("regions", info.EnabledRegions),
+		logger.Duration("interval", info.Interval))
+	h.record(r, audit.ActionCollectorAdd, info.Name, map[string]string{
+		"regions":  strings.Join(info.EnabledRegions, ","),
+		"interval": info.Interval.String(),
+	})
+
+	h.writeJSON(w, http.StatusCreated, collector.Info())
+}
+
+// handleRemoveCollector unschedules and unregisters a collector
+func (h *Handler) handleRemoveCollector(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	collector, exists := h.registry.Get(name)
+	if !exists {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("collector %s not found", name))
+		return
+	}
+
+	for _, job := range h.scheduler.GetScheduledJobs() {
+		if job.CollectorName != name {
+			continue
+		}
+		if err := h.scheduler.UnscheduleCollector(name, job.Region); err != nil {
+			h.logger.Warn("Failed to unschedule collector job",
+				logger.String("collector", name),
+				logger.String("region", job.Region),
+				logger.String("error", err.Error()))
+		}
+	}
+
+	if err := collector.Stop(r.Context()); err != nil {
+		h.logger.Warn("Failed to stop collector",
+			logger.String("collector", name),
+			logger.String("error", err.Error()))
+	}
+	if err := h.registry.Unregister(name); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	h.removed[name] = collector
+	h.mu.Unlock()
+
+	if h.checkers != nil {
+		h.checkers.UnregisterChecker(health.CollectorCheckerName(name))
+	}
+
+	h.logger.Info("Collector removed via admin API", logger.String("collector", name))
+	h.record(r, audit.ActionCollectorRemove, name, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetLogLevel returns the current log level
+func (h *Handler) handleGetLogLevel(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusOK, LogLevelRequest{Level: h.logger.Level()})
+}
+
+// handleSetLogLevel changes the log level of the whole application
+func (h *Handler) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	previous := h.logger.Level()
+	if err := h.logger.SetLevel(req.Level); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.record(r, audit.ActionLogLevelChange, "", map[string]string{"from": previous, "to": h.logger.Level()})
+
+	h.writeJSON(w, http.StatusOK, LogLevelRequest{Level: h.logger.Level()})
+}
+
+// buildCollector creates the plugin collector the configuration declares
+// under the requested name, with the request's overrides, or returns a
+// previously removed collector of another kind as it was. The returned status
+// code applies when err is non-nil.
+func (h *Handler) buildCollector(req CollectorRequest) (collectors.MetricCollector, int, error) {
+	cfg := h.config()
+	pluginCfg, declared := declaredPlugin(cfg, req.Name)
+	if !declared {
+		h.mu.Lock()
+		collector, removed := h.removed[req.Name]
+		h.mu.Unlock()
+		if !removed {
+			return nil, http.StatusNotFound, fmt.Errorf("collector %s was not removed and is not a plugin declared in the configuration", req.Name)
+		}
+		// Only plugin collectors are built from a configuration the
+		// overrides can be applied to
+		if req.Interval != "" || req.Regions != nil || req.Groups != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("collector %s is not a plugin collector: interval, regions and groups cannot be overridden", req.Name)
+		}
+		return collector, 0, nil
+	}
+	pluginCfg.Enabled = true
+	if pluginCfg.CollectionInterval == 0 && cfg != nil {
+		pluginCfg.CollectionInterval = cfg.Global.DefaultInterval
+	}
+	if req.Regions != nil {
+		pluginCfg.Regions = req.Regions
+	}
+	if req.Groups != nil {
+		pluginCfg.Groups = req.Groups
+	}
+	if req.Interval != "" {
+		interval, err := time.ParseDuration(req.Interval)
+		if err != nil || interval <= 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid interval %q", req.Interval)
+		}
+		pluginCfg.CollectionInterval = config.Duration(interval)
+	}
+
+	// Collectors fall back to the enabled regions of the current
+	// configuration
+	deps := h.deps
+	deps.Config = cfg
+	collector, err := collectors.NewPluginCollector(pluginCfg, deps)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	return collector, 0, nil
+}
+
+// declaredPlugin returns the configuration of the plugin collector named name
+func declaredPlugin(cfg *config.Config, name string) (config.PluginConfig, bool) {
+	if cfg == nil {
+		return config.PluginConfig{}, false
+	}
+	for _, plugin := range cfg.Plugins {
+		if plugin.Name == name {
+			return plugin, true
+		}
+	}
+	return config.PluginConfig{}, false
+}
+
+// authorized checks the request's credentials; the admin API is never
+// served unauthenticated
+func (h *Handler) authorized(r *http.Request) bool {
+	return h.credentials.Enabled() && h.credentials.Authorized(r)
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error("Failed to encode admin response", logger.String("error", err.Error()))
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, status int, message string) {
+	h.writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,515 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/health"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// stubCollector is a minimal collector used to exercise the admin API
+type stubCollector struct {
+	name    string
+	cfg     collectors.CollectorConfig
+	running bool
+	stopped int
+	ctx     context.Context
+}
+
+func (c *stubCollector) Name() string        { return c.name }
+func (c *stubCollector) Description() string { return "stub collector" }
+func (c *stubCollector) Health() error       { return nil }
+
+func (c *stubCollector) Collect(_ context.Context, region string) *collectors.CollectionResult {
+	return &collectors.CollectionResult{CollectorName: c.name, Region: region}
+}
+
+func (c *stubCollector) Start(ctx context.Context) error {
+	c.running = true
+	c.ctx = ctx
+	return nil
+}
+
+func (c *stubCollector) Stop(_ context.Context) error {
+	if !c.running {
+		return nil
+	}
+	c.running = false
+	c.stopped++
+	return nil
+}
+
+func (c *stubCollector) Info() collectors.CollectorInfo {
+	return collectors.CollectorInfo{
+		Name:           c.name,
+		EnabledRegions: c.cfg.EnabledRegions,
+		Interval:       c.cfg.Interval,
+		Groups:         c.cfg.Groups,
+	}
+}
+
+func init() {
+	err := collectors.RegisterCollectorType("admin-test-stub", func(name string, cfg collectors.CollectorConfig, _ collectors.CollectorDependencies) (collectors.MetricCollector, error) {
+		return &stubCollector{name: name, cfg: cfg}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newTestHandler(t *testing.T) (*Handler, collectors.Registry, scheduler.Scheduler) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	cfg := &config.Config{EnabledRegions: []string{"us-east-1", "us-west-2"}}
+	cfg.Global.DefaultInterval = config.Duration(5 * time.Minute)
+	cfg.Plugins = []config.PluginConfig{{Name: "queue", Type: "admin-test-stub"}}
+
+	registry := collectors.NewCollectorRegistry(log)
+	sched := scheduler.NewMetricScheduler(scheduler.DefaultConfig(), registry, nil, log)
+	deps := collectors.CollectorDependencies{Config: cfg, Logger: log}
+
+	return NewHandler(registry, sched, deps, health.Credentials{Token: "secret"}, log), registry, sched
+}
+
+func doRequest(h *Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerAuthentication(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	if w := doRequest(h, http.MethodGet, "/admin/collectors", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", w.Code)
+	}
+
+	if w := doRequest(h, http.MethodGet, "/admin/collectors", "wrong", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with wrong token, got %d", w.Code)
+	}
+
+	if w := doRequest(h, http.MethodGet, "/admin/collectors", "secret", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with valid token, got %d", w.Code)
+	}
+
+	h.credentials = health.Credentials{Username: "ops", Password: "hunter2"}
+	req := httptest.NewRequest(http.MethodGet, "/admin/collectors", nil)
+	req.SetBasicAuth("ops", "hunter2")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with valid basic auth, got %d", w.Code)
+	}
+
+	h.credentials = health.Credentials{}
+	if w := doRequest(h, http.MethodGet, "/admin/collectors", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without configured credentials, got %d", w.Code)
+	}
+}
+
+func TestHandlerAddRemoveCollector(t *testing.T) {
+	h, registry, sched := newTestHandler(t)
+
+	w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{
+		Name:     "queue",
+		Interval: "1m",
+		Regions:  []string{"us-east-1"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	collector, exists := registry.Get("queue")
+	if !exists {
+		t.Fatal("Expected collector to be registered")
+	}
+	if !collector.(*stubCollector).running {
+		t.Error("Expected collector to be started")
+	}
+	if jobs := sched.GetScheduledJobs(); len(jobs) != 1 || jobs[0].Interval != time.Minute {
+		t.Errorf("Expected 1 job with 1m interval, got %+v", jobs)
+	}
+
+	if w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{Name: "queue"}); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for duplicate collector, got %d", w.Code)
+	}
+
+	if w := doRequest(h, http.MethodDelete, "/admin/collectors/queue", "secret", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if _, exists := registry.Get("queue"); exists {
+		t.Error("Expected collector to be unregistered")
+	}
+	if collector.(*stubCollector).stopped != 1 {
+		t.Error("Expected removed collector to be stopped")
+	}
+	if jobs := sched.GetScheduledJobs(); len(jobs) != 0 {
+		t.Errorf("Expected no scheduled jobs after removal, got %d", len(jobs))
+	}
+
+	if w := doRequest(h, http.MethodDelete, "/admin/collectors/queue", "secret", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 removing unknown collector, got %d", w.Code)
+	}
+
+	// A removed plugin collector is built again, with the new overrides
+	w = doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{
+		Name:     "queue",
+		Interval: "2m",
+		Regions:  []string{"us-east-1", "us-west-2"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 restoring collector, got %d: %s", w.Code, w.Body.String())
+	}
+	if jobs := sched.GetScheduledJobs(); len(jobs) != 2 || jobs[0].Interval != 2*time.Minute {
+		t.Errorf("Expected 2 jobs with 2m interval, got %+v", jobs)
+	}
+}
+
+type appContextKey struct{}
+
+func TestHandlerRestoresRemovedCollector(t *testing.T) {
+	h, registry, sched := newTestHandler(t)
+	appCtx := context.WithValue(context.Background(), appContextKey{}, "app")
+	h.SetContext(appCtx)
+
+	// A collector that isn't a declared plugin, e.g. a built-in one
+	builtin := &stubCollector{name: "ec2", cfg: collectors.CollectorConfig{
+		Interval:       time.Minute,
+		EnabledRegions: []string{"us-east-1"},
+	}}
+	if err := registry.Register(builtin); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	_ = builtin.Start(context.Background())
+
+	if w := doRequest(h, http.MethodDelete, "/admin/collectors/ec2", "secret", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if builtin.running || builtin.stopped != 1 {
+		t.Errorf("Expected removed collector to be stopped once, got %d stops", builtin.stopped)
+	}
+
+	// Its settings don't come from a plugin declaration, so they cannot be
+	// overridden
+	for _, req := range []CollectorRequest{
+		{Name: "ec2", Interval: "5m"},
+		{Name: "ec2", Regions: []string{"us-west-2"}},
+		{Name: "ec2", Groups: []string{"prod"}},
+	} {
+		if w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", req); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 overriding a removed collector with %+v, got %d", req, w.Code)
+		}
+	}
+
+	if w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{Name: "ec2"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 restoring collector, got %d: %s", w.Code, w.Body.String())
+	}
+	if restored, _ := registry.Get("ec2"); restored != builtin {
+		t.Error("Expected the removed collector instance to be restored")
+	}
+	if builtin.ctx != appCtx {
+		t.Error("Expected the collector to be started with the application context")
+	}
+	if jobs := sched.GetScheduledJobs(); len(jobs) != 1 || jobs[0].Interval != time.Minute {
+		t.Errorf("Expected 1 job with 1m interval, got %+v", jobs)
+	}
+}
+
+func TestHandlerGetCollector(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{
+		Name:     "queue",
+		Interval: "1m",
+		Regions:  []string{"us-west-2", "us-east-1"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(h, http.MethodGet, "/admin/collectors/queue", "secret", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var detail CollectorDetail
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if detail.Collector.Name != "queue" || detail.Collector.Interval != time.Minute {
+		t.Errorf("Expected the collector's status, got %+v", detail.Collector)
+	}
+	if len(detail.Jobs) != 2 || detail.Jobs[0].Region != "us-east-1" || detail.Jobs[1].Region != "us-west-2" {
+		t.Errorf("Expected a job per region, sorted, got %+v", detail.Jobs)
+	}
+
+	if w := doRequest(h, http.MethodGet, "/admin/collectors/missing", "secret", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown collector, got %d", w.Code)
+	}
+}
+
+// stubCheckerRegistry records the health checkers registered by the handler
+type stubCheckerRegistry map[string]health.Checker
+
+func (r stubCheckerRegistry) RegisterChecker(checker health.Checker) { r[checker.Name()] = checker }
+func (r stubCheckerRegistry) UnregisterChecker(name string)          { delete(r, name) }
+
+func TestHandlerCollectorHealthCheckers(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	checkers := stubCheckerRegistry{}
+	h.SetHealthManager(checkers)
+
+	w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{Name: "queue"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, exists := checkers["collector:queue"]; !exists {
+		t.Error("Expected a health checker to be registered for the added collector")
+	}
+
+	if w := doRequest(h, http.MethodDelete, "/admin/collectors/queue", "secret", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if _, exists := checkers["collector:queue"]; exists {
+		t.Error("Expected the health checker to be unregistered with the collector")
+	}
+}
+
+func TestHandlerAddCollectorValidation(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	tests := []struct {
+		name string
+		req  CollectorRequest
+		code int
+	}{
+		{name: "missing name", req: CollectorRequest{}, code: http.StatusBadRequest},
+		{name: "undeclared collector", req: CollectorRequest{Name: "x"}, code: http.StatusNotFound},
+		{name: "invalid interval", req: CollectorRequest{Name: "queue", Interval: "soon"}, code: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", tt.req); w.Code != tt.code {
+				t.Errorf("Expected status %d, got %d", tt.code, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandlerRejectsCollectorDefinitions(t *testing.T) {
+	h, registry, _ := newTestHandler(t)
+
+	// The type and settings of a collector only come from the configuration,
+	// so the API cannot be used to run commands through an exec collector
+	for _, body := range []string{
+		`{"name": "shell", "type": "exec", "settings": {"command": "/bin/sh -c id"}}`,
+		`{"name": "queue", "settings": {"command": "/bin/sh -c id"}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/collectors", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+	if len(registry.List()) != 0 {
+		t.Errorf("Expected no collector added, got %d", len(registry.List()))
+	}
+}
+
+func TestHandlerAddsCollectorOfReloadedConfig(t *testing.T) {
+	h, registry, _ := newTestHandler(t)
+
+	reloaded := &config.Config{EnabledRegions: []string{"eu-west-1"}}
+	reloaded.Global.DefaultInterval = config.Duration(2 * time.Minute)
+	reloaded.Plugins = []config.PluginConfig{{Name: "jobs", Type: "admin-test-stub"}}
+	h.SetConfig(func() *config.Config { return reloaded })
+
+	if w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{Name: "queue"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a plugin no longer declared, got %d", w.Code)
+	}
+	w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{Name: "jobs"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for a plugin of the reloaded config, got %d: %s", w.Code, w.Body.String())
+	}
+
+	collector, exists := registry.Get("jobs")
+	if !exists {
+		t.Fatal("Expected collector to be registered")
+	}
+	if info := collector.Info(); info.Interval != 2*time.Minute {
+		t.Errorf("Expected the default interval of the reloaded config, got %v", info.Interval)
+	}
+}
+
+func TestHandlerLogLevel(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	var level LogLevelRequest
+	w := doRequest(h, http.MethodGet, "/admin/log-level", "secret", nil)
+	if err := json.NewDecoder(w.Body).Decode(&level); err != nil || level.Level != "debug" {
+		t.Fatalf("Expected current level debug, got %q (%v)", level.Level, err)
+	}
+
+	w = doRequest(h, http.MethodPut, "/admin/log-level", "secret", LogLevelRequest{Level: "warn"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if h.logger.Level() != "warn" {
+		t.Errorf("Expected log level warn, got %s", h.logger.Level())
+	}
+
+	if w := doRequest(h, http.MethodPut, "/admin/log-level", "secret", LogLevelRequest{Level: "loud"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown level, got %d", w.Code)
+	}
+	if w := doRequest(h, http.MethodPut, "/admin/log-level", "", LogLevelRequest{Level: "debug"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", w.Code)
+	}
+}
+
+// staticFiringAlerts returns fixed firing alerts
+type staticFiringAlerts []alerting.Alert
+
+func (a staticFiringAlerts) FiringAlerts() []alerting.Alert { return a }
+
+func TestHandlerAlerts(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	if w := doRequest(h, http.MethodGet, "/admin/alerts", "secret", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without alerting, got %d", w.Code)
+	}
+
+	silencer, err := alerting.NewSilencer("")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	labels := map[string]string{"collector": "sqs", "region": "us-east-1", "queue": "orders"}
+	firing := alerting.NewRuleAlert("queue-backlog", alerting.RuleFiring, errors.SeverityHigh, labels, 1200, "backlog")
+	h.SetAlerts(silencer, staticFiringAlerts{firing})
+
+	w := doRequest(h, http.MethodPost, "/admin/alerts/acknowledge", "secret",
+		AcknowledgeRequest{Rule: "queue-backlog", Labels: map[string]string{"queue": "payments"}})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an alert that isn't firing, got %d", w.Code)
+	}
+	w = doRequest(h, http.MethodPost, "/admin/alerts/acknowledge", "secret",
+		AcknowledgeRequest{Rule: "queue-backlog", Labels: labels, Comment: "scaling consumers", By: "ops"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(h, http.MethodPost, "/admin/silences", "secret",
+		SilenceRequest{Matchers: map[string]string{"queue": "orders"}, Duration: "2h", Comment: "migration"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var silence alerting.Silence
+	if err := json.NewDecoder(w.Body).Decode(&silence); err != nil || silence.ID == "" {
+		t.Fatalf("Expected the created silence, got %v", err)
+	}
+	if w := doRequest(h, http.MethodPost, "/admin/silences", "secret", SilenceRequest{Matchers: labels, Duration: "soon"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid duration, got %d", w.Code)
+	}
+
+	w = doRequest(h, http.MethodGet, "/admin/alerts", "secret", nil)
+	var listed struct {
+		Alerts []AlertStatus `json:"alerts"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode alerts: %v", err)
+	}
+	if len(listed.Alerts) != 1 || listed.Alerts[0].Acknowledgment == nil || listed.Alerts[0].SilencedBy != silence.ID {
+		t.Errorf("Expected the alert acknowledged and silenced, got %+v", listed.Alerts)
+	}
+
+	if w := doRequest(h, http.MethodDelete, "/admin/silences/"+silence.ID, "secret", nil); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if w := doRequest(h, http.MethodDelete, "/admin/silences/"+silence.ID, "secret", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an expired silence, got %d", w.Code)
+	}
+}
+
+func TestHandlerAuditLog(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	if w := doRequest(h, http.MethodGet, "/admin/audit", "secret", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without an audit log, got %d", w.Code)
+	}
+
+	log, err := audit.Open("", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	h.SetAuditLog(log)
+
+	w := doRequest(h, http.MethodPost, "/admin/collectors", "secret", CollectorRequest{
+		Name: "queue", Regions: []string{"us-east-1"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(h, http.MethodPut, "/admin/log-level", "secret", LogLevelRequest{Level: "warn"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	// Rejected requests change nothing and are not recorded
+	if w := doRequest(h, http.MethodPut, "/admin/log-level", "secret", LogLevelRequest{Level: "loud"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+
+	w = doRequest(h, http.MethodGet, "/admin/audit", "secret", nil)
+	var listed struct {
+		Entries []audit.Entry `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode audit log: %v", err)
+	}
+	if len(listed.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", listed.Entries)
+	}
+	change := listed.Entries[0]
+	if change.Action != audit.ActionLogLevelChange || change.Details["from"] != "debug" || change.Details["to"] != "warn" {
+		t.Errorf("Expected the log level change first, got %+v", change)
+	}
+	if change.Actor != "admin:token" || change.Details["remote_addr"] == "" {
+		t.Errorf("Expected who made the change and from where, got %+v", change)
+	}
+	if added := listed.Entries[1]; added.Action != audit.ActionCollectorAdd || added.Target != "queue" {
+		t.Errorf("Expected the collector addition, got %+v", added)
+	}
+
+	w = doRequest(h, http.MethodGet, "/admin/audit?action=collector.add&limit=5", "secret", nil)
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil || len(listed.Entries) != 1 {
+		t.Errorf("Expected the entries to be filtered by action, got %+v, %v", listed.Entries, err)
+	}
+	if w := doRequest(h, http.MethodGet, "/admin/audit?since=yesterday", "secret", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid since, got %d", w.Code)
+	}
+}
@@ -0,0 +1,276 @@
+// Package alerting delivers notifications about high-severity collector errors
+// and health status changes to external sinks such as Slack, SNS or generic
+// webhooks, and reports critical errors to Sentry-compatible error trackers.
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"aws-monitoring/pkg/errors"
+)
+
+// healthAlertPrefix prefixes the codes of health status alerts, followed by
+// the new status, e.g. HEALTH_UNHEALTHY
+const healthAlertPrefix = "HEALTH_"
+
+// ruleAlertPrefix prefixes the codes of alert rule alerts, followed by the
+// rule state, e.g. RULE_FIRING
+const ruleAlertPrefix = "RULE_"
+
+// digestAlertCode is the code of alert digests
+const digestAlertCode = ruleAlertPrefix + "DIGEST"
+
+// States of an alert rule alert
+const (
+	RuleFiring   = "firing"
+	RuleResolved = "resolved"
+)
+
+// Alert describes a collector error or health status change worth notifying
+// someone about
+type Alert struct {
+	// Collector is the name of the collector that failed; empty for health
+	// status alerts
+	Collector string `json:"collector,omitempty"`
+	// Severity is the severity of the error
+	Severity errors.Severity `json:"severity"`
+	// Type is the error category
+	Type errors.ErrorType `json:"type"`
+	// Code is the error code
+	Code string `json:"code"`
+	// Message is the human-readable error message
+	Message string `json:"message"`
+	// Region is the AWS region the error occurred in, if any
+	Region string `json:"region,omitempty"`
+	// Operation is the operation that failed, if known
+	Operation string `json:"operation,omitempty"`
+	// Time is when the alert was raised
+	Time time.Time `json:"time"`
+	// Suppressed is the number of similar alerts dropped by rate limiting
+	// since the last one was sent
+	Suppressed int `json:"suppressed,omitempty"`
+	// PreviousStatus and Status are the overall health before and after a
+	// health status change
+	PreviousStatus string `json:"previous_status,omitempty"`
+	Status         string `json:"status,omitempty"`
+	// Checks are the checks that are not healthy after a health status change
+	Checks []string `json:"checks,omitempty"`
+	// Rule is the name of the alert rule that fired or resolved, and State
+	// whether it did
+	Rule  string `json:"rule,omitempty"`
+	State string `json:"state,omitempty"`
+	// Labels identify the series an alert rule fired for, and Value is its
+	// latest value
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value,omitempty"`
+	// Digest are the alert rule alerts collected into a digest
+	Digest []Alert `json:"digest,omitempty"`
+
+	// title and text are rendered from the configured templates, if any
+	title string
+	text  string
+}
+
+// NewAlert creates an alert for an error reported by a collector
+func NewAlert(collectorName string, err *errors.Error) Alert {
+	return Alert{
+		Collector: collectorName,
+		Severity:  err.Severity,
+		Type:      err.Type,
+		Code:      err.Code,
+		Message:   err.Message,
+		Region:    err.Region,
+		Operation: err.Operation,
+		Time:      time.Now(),
+	}
+}
+
+// NewHealthAlert creates an alert for a change of the overall health status.
+// failing lists the checks that are not healthy. Transitions to the same
+// status share a rate limit key, so a flapping status is suppressed by the
+// cooldown.
+func NewHealthAlert(previous, current string, failing []string) Alert {
+	severity := errors.SeverityLow
+	switch current {
+	case "unhealthy":
+		severity = errors.SeverityHigh
+	case "degraded":
+		severity = errors.SeverityMedium
+	}
+
+	message := fmt.Sprintf("Health changed from %s to %s", previous, current)
+	if len(failing) > 0 {
+		message += "; failing checks: " + strings.Join(failing, ", ")
+	}
+
+	return Alert{
+		Severity:       severity,
+		Type:           errors.ErrorTypeInternal,
+		Code:           healthAlertPrefix + strings.ToUpper(current),
+		Message:        message,
+		Time:           time.Now(),
+		PreviousStatus: previous,
+		Status:         current,
+		Checks:         failing,
+	}
+}
+
+// NewRuleAlert creates an alert for an alert rule that started firing or
+// resolved for the series with the given labels. Resolved alerts have low
+// severity.
+func NewRuleAlert(rule, state string, severity errors.Severity, labels map[string]string, value float64, message string) Alert {
+	if state == RuleResolved {
+		severity = errors.SeverityLow
+	}
+	return Alert{
+		Collector: labels["collector"],
+		Severity:  severity,
+		Type:      errors.ErrorTypeValidation,
+		Code:      ruleAlertPrefix + strings.ToUpper(state),
+		Message:   message,
+		Region:    labels["region"],
+		Time:      time.Now(),
+		Rule:      rule,
+		State:     state,
+		Labels:    labels,
+		Value:     value,
+	}
+}
+
+// NewDigestAlert creates an alert summarizing alert rule alerts collected
+// over a period. Its severity is the highest of the alerts.
+func NewDigestAlert(alerts []Alert) Alert {
+	severity := errors.SeverityLow
+	var message strings.Builder
+	for i, alert := range alerts {
+		if severityRanks[alert.Severity] > severityRanks[severity] {
+			severity = alert.Severity
+		}
+		if i > 0 {
+			message.WriteByte('\n')
+		}
+		fmt.Fprintf(&message, "%s is %s: %s", alert.Rule, alert.State, alert.Message)
+	}
+
+	return Alert{
+		Severity: severity,
+		Type:     errors.ErrorTypeValidation,
+		Code:     digestAlertCode,
+		Message:  message.String(),
+		Time:     time.Now(),
+		Digest:   alerts,
+	}
+}
+
+// severityRanks orders the severities
+var severityRanks = map[errors.Severity]int{
+	errors.SeverityLow:      0,
+	errors.SeverityMedium:   1,
+	errors.SeverityHigh:     2,
+	errors.SeverityCritical: 3,
+}
+
+// Key identifies alerts that are rate limited together. Alert rule alerts are
+// limited per rule, series and state, so a resolution is not suppressed by
+// the alert that it resolves.
+func (a Alert) Key() string {
+	if a.Rule != "" {
+		return a.Rule + "/" + SeriesKey(a.Labels) + "/" + a.Code
+	}
+	return a.Collector + "/" + a.Region + "/" + a.Code
+}
+
+// SeriesKey identifies a series by its labels, sorted by name
+func SeriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%s", name, labels[name])
+	}
+	return b.String()
+}
+
+// Title returns a one-line summary of the alert
+func (a Alert) Title() string {
+	if a.title != "" {
+		return a.title
+	}
+	if a.Status != "" {
+		return fmt.Sprintf("[%s] aws-monitor health is %s", strings.ToUpper(string(a.Severity)), a.Status)
+	}
+	if a.Rule != "" {
+		return fmt.Sprintf("[%s] aws-monitor alert %s is %s", strings.ToUpper(string(a.Severity)), a.Rule, a.State)
+	}
+	if a.Code == digestAlertCode {
+		firing := 0
+		for _, alert := range a.Digest {
+			if alert.State == RuleFiring {
+				firing++
+			}
+		}
+		return fmt.Sprintf("[%s] aws-monitor alert digest: %d alerts, %d firing", strings.ToUpper(string(a.Severity)), len(a.Digest), firing)
+	}
+
+	title := fmt.Sprintf("[%s] aws-monitor collector %s", strings.ToUpper(string(a.Severity)), a.Collector)
+	if a.Region != "" {
+		title += " in " + a.Region
+	}
+	return title + " failed"
+}
+
+// Text returns the alert as plain text
+func (a Alert) Text() string {
+	if a.text != "" {
+		return a.text
+	}
+	var b strings.Builder
+	b.WriteString(a.Title())
+	fmt.Fprintf(&b, "\n%s: %s", a.Code, a.Message)
+	if a.Operation != "" {
+		fmt.Fprintf(&b, "\nOperation: %s", a.Operation)
+	}
+	if a.Suppressed > 0 {
+		fmt.Fprintf(&b, "\n(%d similar alerts suppressed)", a.Suppressed)
+	}
+	return b.String()
+}
+
+// IncidentKey identifies the problem an alert reports or resolves: the rule
+// and series of alert rule alerts, the overall health, or otherwise the rate
+// limit key
+func (a Alert) IncidentKey() string {
+	switch {
+	case a.Rule != "":
+		return "rule/" + a.Rule + "/" + SeriesKey(a.Labels)
+	case a.Status != "":
+		return "health"
+	default:
+		return a.Key()
+	}
+}
+
+// Resolves reports whether the alert ends a problem reported earlier: an
+// alert rule that resolved or the health status recovering
+func (a Alert) Resolves() bool {
+	return a.State == RuleResolved || a.Status == "healthy"
+}
+
+// AlertSink delivers alerts to an external system
+type AlertSink interface {
+	// Name identifies the sink in logs
+	Name() string
+	// Send delivers a single alert
+	Send(ctx context.Context, alert Alert) error
+}
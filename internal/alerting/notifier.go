@@ -0,0 +1,156 @@
+package alerting
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// Notifier fans alerts out to sinks with rate limiting to avoid alert storms.
+// Alerts with the same key are sent at most once per cooldown, and no more
+// than maxPerHour alerts are sent in any hour. Suppressed alerts are counted
+// and reported on the next alert sent for the same key.
+type Notifier struct {
+	sinks     []AlertSink
+	timeout   time.Duration
+	templates *Templates
+	silencer  *Silencer
+	logger    *logger.Logger
+	now       func() time.Time
+	limiter   *rateLimiter
+
+	wg sync.WaitGroup
+}
+
+// rateLimiter sends something with a given key at most once per cooldown,
+// and no more than maxPerHour things in any hour. A zero cooldown or
+// maxPerHour disables that limit.
+type rateLimiter struct {
+	cooldown   time.Duration
+	maxPerHour int
+
+	mu         sync.Mutex
+	lastSent   map[string]time.Time
+	suppressed map[string]int
+	sent       []time.Time
+}
+
+func newRateLimiter(cooldown time.Duration, maxPerHour int) *rateLimiter {
+	return &rateLimiter{
+		cooldown:   cooldown,
+		maxPerHour: maxPerHour,
+		lastSent:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+// NewNotifier creates a notifier delivering to sinks. A zero cooldown or
+// maxPerHour disables that limit.
+func NewNotifier(sinks []AlertSink, cooldown time.Duration, maxPerHour int, log *logger.Logger) *Notifier {
+	return &Notifier{
+		sinks:   sinks,
+		timeout: 30 * time.Second,
+		logger:  log.WithComponent("alerting"),
+		now:     time.Now,
+		limiter: newRateLimiter(cooldown, maxPerHour),
+	}
+}
+
+// SetTemplates sets the templates rendering the title and text of alerts
+func (n *Notifier) SetTemplates(templates *Templates) {
+	n.templates = templates
+}
+
+// SetSilencer sets the silences and acknowledgments that mute alerts
+func (n *Notifier) SetSilencer(silencer *Silencer) {
+	n.silencer = silencer
+}
+
+// Notify sends the alert to every sink in the background unless it is muted
+// by a silence or acknowledgment, or rate limited. It reports whether the
+// alert was sent.
+func (n *Notifier) Notify(alert Alert) bool {
+	if n.silencer != nil {
+		if reason, muted := n.silencer.Mutes(alert); muted {
+			n.logger.Info("Alert muted",
+				logger.String("collector", alert.Collector),
+				logger.String("rule", alert.Rule),
+				logger.String("error_code", alert.Code),
+				logger.String("reason", reason))
+			return false
+		}
+	}
+	suppressed, allowed := n.limiter.allow(alert.Key(), n.now())
+	if !allowed {
+		n.logger.Debug("Alert suppressed by rate limit",
+			logger.String("collector", alert.Collector),
+			logger.String("error_code", alert.Code))
+		return false
+	}
+	alert.Suppressed = suppressed
+
+	if n.templates != nil {
+		rendered, err := n.templates.apply(alert)
+		if err != nil {
+			n.logger.Error("Failed to render alert templates, using the built-in ones",
+				logger.String("collector", alert.Collector),
+				logger.String("error", err.Error()))
+		} else {
+			alert = rendered
+		}
+	}
+
+	for _, sink := range n.sinks {
+		n.wg.Add(1)
+		go func(sink AlertSink) {
+			defer n.wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+			defer cancel()
+
+			if err := sink.Send(ctx, alert); err != nil {
+				n.logger.Error("Failed to send alert",
+					logger.String("sink", sink.Name()),
+					logger.String("collector", alert.Collector),
+					logger.String("error", err.Error()))
+			}
+		}(sink)
+	}
+
+	return true
+}
+
+// Wait blocks until all alerts in flight have been delivered
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// allow applies the per-key cooldown and the hourly limit at now, recording
+// the key as sent or suppressed. When allowed, it returns the number of times
+// the key was suppressed since it was last sent.
+func (l *rateLimiter) allow(key string, now time.Time) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hourAgo := now.Add(-time.Hour)
+	for len(l.sent) > 0 && !l.sent[0].After(hourAgo) {
+		l.sent = l.sent[1:]
+	}
+
+	if last, exists := l.lastSent[key]; exists && l.cooldown > 0 && now.Sub(last) < l.cooldown {
+		l.suppressed[key]++
+		return 0, false
+	}
+	if l.maxPerHour > 0 && len(l.sent) >= l.maxPerHour {
+		l.suppressed[key]++
+		return 0, false
+	}
+
+	suppressed := l.suppressed[key]
+	delete(l.suppressed, key)
+	l.lastSent[key] = now
+	l.sent = append(l.sent, now)
+	return suppressed, true
+}
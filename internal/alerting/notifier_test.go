@@ -0,0 +1,180 @@
+package alerting
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// recordingSink records the alerts it receives
+type recordingSink struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(_ context.Context, alert Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func newTestNotifier(t *testing.T, sink AlertSink, cooldown time.Duration, maxPerHour int) (*Notifier, *time.Time) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	notifier := NewNotifier([]AlertSink{sink}, cooldown, maxPerHour, log)
+	notifier.now = func() time.Time { return now }
+	return notifier, &now
+}
+
+func TestNotifierCooldown(t *testing.T) {
+	sink := &recordingSink{}
+	notifier, now := newTestNotifier(t, sink, 15*time.Minute, 0)
+
+	alert := NewAlert("ec2", errors.WithRegion(errors.NewPermissionError("describe", "ec2:instances"), "us-east-1"))
+
+	if !notifier.Notify(alert) {
+		t.Error("Expected first alert to be sent")
+	}
+	for i := 0; i < 3; i++ {
+		if notifier.Notify(alert) {
+			t.Error("Expected repeated alert to be suppressed within the cooldown")
+		}
+	}
+
+	other := alert
+	other.Region = "us-west-2"
+	if !notifier.Notify(other) {
+		t.Error("Expected alert for another region to be sent")
+	}
+
+	*now = now.Add(16 * time.Minute)
+	if !notifier.Notify(alert) {
+		t.Error("Expected alert to be sent after the cooldown")
+	}
+	notifier.Wait()
+
+	if len(sink.alerts) != 3 {
+		t.Fatalf("Expected 3 alerts delivered, got %d", len(sink.alerts))
+	}
+	// Alerts are delivered concurrently, so look for the one reporting suppressions
+	suppressed := 0
+	for _, delivered := range sink.alerts {
+		suppressed += delivered.Suppressed
+	}
+	if suppressed != 3 {
+		t.Errorf("Expected 3 suppressed alerts to be reported, got %d", suppressed)
+	}
+}
+
+func TestNotifierHourlyLimit(t *testing.T) {
+	sink := &recordingSink{}
+	notifier, now := newTestNotifier(t, sink, 0, 2)
+
+	for i, code := range []string{"A", "B", "C"} {
+		sent := notifier.Notify(Alert{Collector: "ec2", Code: code})
+		if sent != (i < 2) {
+			t.Errorf("Alert %s: expected sent=%v, got %v", code, i < 2, sent)
+		}
+	}
+
+	*now = now.Add(time.Hour)
+	if !notifier.Notify(Alert{Collector: "ec2", Code: "C"}) {
+		t.Error("Expected alert to be sent once the hour has passed")
+	}
+	notifier.Wait()
+
+	if len(sink.alerts) != 3 {
+		t.Errorf("Expected 3 alerts delivered, got %d", len(sink.alerts))
+	}
+}
+
+func TestHealthAlert(t *testing.T) {
+	alert := NewHealthAlert("healthy", "unhealthy", []string{"aws", "disk"})
+
+	if alert.Severity != errors.SeverityHigh {
+		t.Errorf("Expected severity high, got %s", alert.Severity)
+	}
+	if alert.Code != "HEALTH_UNHEALTHY" {
+		t.Errorf("Expected code HEALTH_UNHEALTHY, got %s", alert.Code)
+	}
+	if title := alert.Title(); title != "[HIGH] aws-monitor health is unhealthy" {
+		t.Errorf("Expected health title, got %q", title)
+	}
+	if !strings.Contains(alert.Text(), "failing checks: aws, disk") {
+		t.Errorf("Expected text to list failing checks, got %q", alert.Text())
+	}
+
+	recovered := NewHealthAlert("unhealthy", "healthy", nil)
+	if recovered.Severity != errors.SeverityLow {
+		t.Errorf("Expected severity low for recovery, got %s", recovered.Severity)
+	}
+	if recovered.Key() == alert.Key() {
+		t.Error("Expected recovery and failure alerts to be rate limited separately")
+	}
+}
+
+func TestRuleAlert(t *testing.T) {
+	labels := map[string]string{"collector": "sqs", "region": "us-east-1", "queue": "orders"}
+	alert := NewRuleAlert("queue-backlog", RuleFiring, errors.SeverityCritical, labels, 1200, "backlog")
+
+	if alert.Collector != "sqs" || alert.Region != "us-east-1" {
+		t.Errorf("Expected collector and region from the labels, got %s, %s", alert.Collector, alert.Region)
+	}
+	if title := alert.Title(); title != "[CRITICAL] aws-monitor alert queue-backlog is firing" {
+		t.Errorf("Expected rule title, got %q", title)
+	}
+
+	other := NewRuleAlert("queue-backlog", RuleFiring, errors.SeverityCritical,
+		map[string]string{"collector": "sqs", "region": "us-east-1", "queue": "payments"}, 1500, "backlog")
+	if other.Key() == alert.Key() {
+		t.Error("Expected alerts for different series to be rate limited separately")
+	}
+
+	resolved := NewRuleAlert("queue-backlog", RuleResolved, errors.SeverityCritical, labels, 10, "backlog cleared")
+	if resolved.Severity != errors.SeverityLow {
+		t.Errorf("Expected severity low for resolution, got %s", resolved.Severity)
+	}
+	if resolved.Key() == alert.Key() {
+		t.Error("Expected resolution and firing alerts to be rate limited separately")
+	}
+}
+
+func TestNotifierTemplates(t *testing.T) {
+	sink := &recordingSink{}
+	notifier, _ := newTestNotifier(t, sink, 0, 0)
+	templates, err := NewTemplates("{{.Severity}}: {{.Collector}}\n{{.Code}}", "{{.Title}} - {{.Message}}")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	notifier.SetTemplates(templates)
+
+	notifier.Notify(Alert{Collector: "ec2", Severity: errors.SeverityCritical, Code: "PERMISSION_DENIED", Message: "access denied"})
+	notifier.Wait()
+
+	if len(sink.alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(sink.alerts))
+	}
+	alert := sink.alerts[0]
+	if title := alert.Title(); title != "critical: ec2 PERMISSION_DENIED" {
+		t.Errorf("Expected the rendered title on one line, got %q", title)
+	}
+	if text := alert.Text(); text != "critical: ec2 PERMISSION_DENIED - access denied" {
+		t.Errorf("Expected the rendered text, got %q", text)
+	}
+
+	if _, err := NewTemplates("{{.Severity", ""); err == nil {
+		t.Error("Expected error for an invalid template")
+	}
+}
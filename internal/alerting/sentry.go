@@ -0,0 +1,270 @@
+package alerting
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// sentryQueueSize is the number of reports waiting to be sent before new
+// ones are dropped
+const sentryQueueSize = 100
+
+// SentryReporter forwards critical collector errors, with their stack trace
+// and metadata, to Sentry or any service accepting Sentry store API events.
+// Reports are sent one at a time from a bounded queue, and are rate limited
+// like alerts, so that an error storm doesn't flood the tracker.
+type SentryReporter struct {
+	storeURL    string
+	authHeader  string
+	environment string
+	release     string
+	client      *http.Client
+	logger      *logger.Logger
+	now         func() time.Time
+	limiter     *rateLimiter
+	queue       chan sentryReport
+
+	wg sync.WaitGroup
+}
+
+// sentryReport is a queued event with what is logged if sending it fails
+type sentryReport struct {
+	collector string
+	code      string
+	event     sentryEvent
+}
+
+// sentryEvent is the subset of the Sentry event payload the reporter sends
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	Platform    string                 `json:"platform"`
+	Message     string                 `json:"message"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Fingerprint []string               `json:"fingerprint"`
+	Tags        map[string]string      `json:"tags"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   sentryExceptions       `json:"exception"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+// NewSentryReporter creates a reporter from a Sentry DSN of the form
+// https://<public_key>@<host>/<project_id>
+func NewSentryReporter(dsn, environment, release string, log *logger.Logger) (*SentryReporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry DSN: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid sentry DSN: scheme must be http or https")
+	}
+	if parsed.User == nil || parsed.User.Username() == "" {
+		return nil, fmt.Errorf("invalid sentry DSN: missing public key")
+	}
+
+	path := strings.Trim(parsed.Path, "/")
+	projectID := path
+	prefix := ""
+	if idx := strings.LastIndex(path, "/"); idx >= 0 {
+		prefix = "/" + path[:idx]
+		projectID = path[idx+1:]
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid sentry DSN: missing project ID")
+	}
+
+	r := &SentryReporter{
+		storeURL: fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, prefix, projectID),
+		authHeader: fmt.Sprintf("Sentry sentry_version=7, sentry_client=aws-monitor/1.0, sentry_key=%s",
+			parsed.User.Username()),
+		environment: environment,
+		release:     release,
+		client:      &http.Client{Timeout: defaultHTTPTimeout},
+		logger:      log.WithComponent("sentry-reporter"),
+		now:         time.Now,
+		limiter:     newRateLimiter(0, 0),
+		queue:       make(chan sentryReport, sentryQueueSize),
+	}
+	go r.run()
+	return r, nil
+}
+
+// SetRateLimit reports an error of the same collector and code at most once
+// per cooldown, and no more than maxPerHour errors in any hour. A zero
+// cooldown or maxPerHour disables that limit.
+func (r *SentryReporter) SetRateLimit(cooldown time.Duration, maxPerHour int) {
+	r.limiter = newRateLimiter(cooldown, maxPerHour)
+}
+
+// Report queues critical errors to be sent to Sentry in the background;
+// errors of any other severity are ignored. Errors are dropped when rate
+// limited or when the queue is full. It reports whether an event was queued.
+func (r *SentryReporter) Report(collectorName string, err *errors.Error) bool {
+	if err == nil || err.Severity != errors.SeverityCritical {
+		return false
+	}
+
+	suppressed, allowed := r.limiter.allow(collectorName+"/"+err.Code, r.now())
+	if !allowed {
+		r.logger.Debug("Error report suppressed by rate limit",
+			logger.String("collector", collectorName),
+			logger.String("error_code", err.Code))
+		return false
+	}
+
+	event := r.newEvent(collectorName, err)
+	if suppressed > 0 {
+		if event.Extra == nil {
+			event.Extra = make(map[string]interface{}, 1)
+		}
+		event.Extra["suppressed_reports"] = suppressed
+	}
+
+	r.wg.Add(1)
+	select {
+	case r.queue <- sentryReport{collector: collectorName, code: err.Code, event: event}:
+		return true
+	default:
+		r.wg.Done()
+		r.logger.Warn("Error report dropped, the queue is full",
+			logger.String("collector", collectorName),
+			logger.String("error_code", err.Code))
+		return false
+	}
+}
+
+// Wait blocks until all queued events have been delivered
+func (r *SentryReporter) Wait() {
+	r.wg.Wait()
+}
+
+// run sends the queued events one at a time
+func (r *SentryReporter) run() {
+	for report := range r.queue {
+		r.send(report)
+		r.wg.Done()
+	}
+}
+
+// send posts a queued event to the store API
+func (r *SentryReporter) send(report sentryReport) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout)
+	defer cancel()
+
+	headers := map[string]string{"X-Sentry-Auth": r.authHeader}
+	if err := postJSON(ctx, r.client, r.storeURL, headers, report.event); err != nil {
+		r.logger.Error("Failed to report error to Sentry",
+			logger.String("collector", report.collector),
+			logger.String("error_code", report.code),
+			logger.String("error", err.Error()))
+	}
+}
+
+// newEvent builds the Sentry event for a collector error
+func (r *SentryReporter) newEvent(collectorName string, err *errors.Error) sentryEvent {
+	tags := map[string]string{
+		"collector":  collectorName,
+		"error_type": string(err.Type),
+		"error_code": err.Code,
+	}
+	if err.Region != "" {
+		tags["region"] = err.Region
+	}
+	if err.Service != "" {
+		tags["service"] = err.Service
+	}
+	if err.Operation != "" {
+		tags["operation"] = err.Operation
+	}
+
+	var extra map[string]interface{}
+	if len(err.Metadata) > 0 || err.Cause != nil {
+		extra = make(map[string]interface{}, len(err.Metadata)+1)
+		for key, value := range err.Metadata {
+			extra[key] = value
+		}
+		if err.Cause != nil {
+			extra["cause"] = err.Cause.Error()
+		}
+	}
+
+	exception := sentryException{Type: err.Code, Value: err.Message}
+	if frames := sentryFrames(err.StackTrace); len(frames) > 0 {
+		exception.Stacktrace = &sentryStacktrace{Frames: frames}
+	}
+
+	timestamp := err.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   timestamp.UTC().Format(time.RFC3339),
+		Level:       "fatal",
+		Logger:      "aws-monitor",
+		Platform:    "go",
+		Message:     err.Error(),
+		Environment: r.environment,
+		Release:     r.release,
+		Fingerprint: []string{collectorName, err.Code},
+		Tags:        tags,
+		Extra:       extra,
+		Exception:   sentryExceptions{Values: []sentryException{exception}},
+	}
+}
+
+// sentryFrames converts a "file:line" stack trace, innermost call first, into
+// Sentry frames, which are ordered outermost call first
+func sentryFrames(stackTrace []string) []sentryFrame {
+	frames := make([]sentryFrame, 0, len(stackTrace))
+	for i := len(stackTrace) - 1; i >= 0; i-- {
+		frame := sentryFrame{Filename: stackTrace[i]}
+		if idx := strings.LastIndex(stackTrace[i], ":"); idx >= 0 {
+			if line, err := strconv.Atoi(stackTrace[i][idx+1:]); err == nil {
+				frame = sentryFrame{Filename: stackTrace[i][:idx], Lineno: line}
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// newEventID returns a random 32 character hex event ID
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
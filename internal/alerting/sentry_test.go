@@ -0,0 +1,200 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestNewSentryReporterDSN(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	reporter, err := NewSentryReporter("https://public@sentry.example.com/sentry/42", "", "", log)
+	if err != nil {
+		t.Fatalf("Expected valid DSN, got: %v", err)
+	}
+	if reporter.storeURL != "https://sentry.example.com/sentry/api/42/store/" {
+		t.Errorf("Unexpected store URL: %s", reporter.storeURL)
+	}
+	if !strings.Contains(reporter.authHeader, "sentry_key=public") {
+		t.Errorf("Expected auth header to carry the public key, got %s", reporter.authHeader)
+	}
+
+	for _, dsn := range []string{"sentry.example.com/42", "https://sentry.example.com/42", "https://public@sentry.example.com/"} {
+		if _, err := NewSentryReporter(dsn, "", "", log); err == nil {
+			t.Errorf("Expected error for invalid DSN %q", dsn)
+		}
+	}
+}
+
+func TestSentryReporterReport(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	var events []sentryEvent
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Sentry-Auth")
+		var event sentryEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/7"
+	reporter, err := NewSentryReporter(dsn, "production", "v1.2.3", log)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+
+	highErr := errors.WithSeverity(errors.New(errors.ErrorTypeAWS, "THROTTLED", "slow down"), errors.SeverityHigh)
+	if reporter.Report("ec2", highErr) {
+		t.Error("Expected high severity errors not to be reported")
+	}
+
+	criticalErr := errors.New(errors.ErrorTypeInternal, "COLLECTOR_PANIC", "collector panicked").
+		WithMetadata("instance_count", 12)
+	criticalErr = errors.WithSeverity(errors.WithRegion(criticalErr, "us-east-1"), errors.SeverityCritical)
+	if !reporter.Report("ec2", criticalErr) {
+		t.Fatal("Expected critical error to be reported")
+	}
+	reporter.Wait()
+
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if !strings.Contains(auth, "sentry_key=public") {
+		t.Errorf("Expected Sentry auth header, got %q", auth)
+	}
+
+	event := events[0]
+	if event.Level != "fatal" || event.Environment != "production" || event.Release != "v1.2.3" {
+		t.Errorf("Unexpected event attributes: %+v", event)
+	}
+	if event.Tags["collector"] != "ec2" || event.Tags["region"] != "us-east-1" || event.Tags["error_code"] != "COLLECTOR_PANIC" {
+		t.Errorf("Unexpected event tags: %v", event.Tags)
+	}
+	if event.Extra["instance_count"] != float64(12) {
+		t.Errorf("Expected error metadata in event extra, got %v", event.Extra)
+	}
+	if len(event.Exception.Values) != 1 || event.Exception.Values[0].Stacktrace == nil ||
+		len(event.Exception.Values[0].Stacktrace.Frames) == 0 {
+		t.Fatalf("Expected exception with stack trace, got %+v", event.Exception)
+	}
+	frames := event.Exception.Values[0].Stacktrace.Frames
+	if innermost := frames[len(frames)-1]; innermost.Filename != "sentry_test.go" || innermost.Lineno == 0 {
+		t.Errorf("Expected innermost frame in sentry_test.go, got %+v", innermost)
+	}
+}
+
+func TestSentryReporterRateLimit(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	var mu sync.Mutex
+	var events []sentryEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event sentryEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reporter, err := NewSentryReporter(strings.Replace(server.URL, "http://", "http://public@", 1)+"/7", "", "", log)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	reporter.SetRateLimit(time.Minute, 2)
+	now := time.Now()
+	reporter.now = func() time.Time { return now }
+
+	panicErr := errors.WithSeverity(errors.New(errors.ErrorTypeInternal, "COLLECTOR_PANIC", "collector panicked"), errors.SeverityCritical)
+	if !reporter.Report("ec2", panicErr) {
+		t.Fatal("Expected the first error to be reported")
+	}
+	for i := 0; i < 3; i++ {
+		if reporter.Report("ec2", panicErr) {
+			t.Error("Expected the repeated error to be suppressed within the cooldown")
+		}
+	}
+	if !reporter.Report("rds", panicErr) {
+		t.Error("Expected an error of another collector to be reported")
+	}
+	if reporter.Report("s3", panicErr) {
+		t.Error("Expected the hourly limit to suppress the error")
+	}
+
+	now = now.Add(time.Hour)
+	if !reporter.Report("ec2", panicErr) {
+		t.Error("Expected the error to be reported again after the cooldown")
+	}
+	reporter.Wait()
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	if events[2].Extra["suppressed_reports"] != float64(3) {
+		t.Errorf("Expected the suppressed reports counted in the next event, got %v", events[2].Extra)
+	}
+}
+
+func TestSentryReporterDropsWhenQueueFull(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		received++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reporter, err := NewSentryReporter(strings.Replace(server.URL, "http://", "http://public@", 1)+"/7", "", "", log)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+
+	queued := 0
+	for i := 0; i < sentryQueueSize+10; i++ {
+		codeErr := errors.New(errors.ErrorTypeInternal, fmt.Sprintf("ERR_%d", i), "failed")
+		if reporter.Report("ec2", errors.WithSeverity(codeErr, errors.SeverityCritical)) {
+			queued++
+		}
+	}
+	close(release)
+	reporter.Wait()
+
+	// The worker may hold one report while the queue is full
+	if queued < sentryQueueSize || queued > sentryQueueSize+1 {
+		t.Errorf("Expected reports beyond the queue size to be dropped, %d were queued", queued)
+	}
+	if received != queued {
+		t.Errorf("Expected every queued report to be sent, got %d of %d", received, queued)
+	}
+}
@@ -0,0 +1,314 @@
+package alerting
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Silence mutes the alerts whose labels match all its matchers until it ends
+type Silence struct {
+	ID string `json:"id"`
+	// Matchers are label values the alert must have. Besides the labels of
+	// alert rule alerts, alerts have the labels collector, region, rule, code
+	// and severity.
+	Matchers  map[string]string `json:"matchers"`
+	Comment   string            `json:"comment,omitempty"`
+	CreatedBy string            `json:"created_by,omitempty"`
+	StartsAt  time.Time         `json:"starts_at"`
+	EndsAt    time.Time         `json:"ends_at"`
+}
+
+// matches reports whether the alert has every label of the silence
+func (s Silence) matches(alert Alert) bool {
+	labels := alertLabels(alert)
+	for name, value := range s.Matchers {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Acknowledgment records that someone is handling a firing alert. Further
+// alerts for the same incident are muted until it resolves.
+type Acknowledgment struct {
+	// Incident is the incident key of the acknowledged alert
+	Incident string            `json:"incident"`
+	Rule     string            `json:"rule,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Comment  string            `json:"comment,omitempty"`
+	By       string            `json:"by,omitempty"`
+	Time     time.Time         `json:"time"`
+}
+
+// silencerState is the persisted state of a silencer
+type silencerState struct {
+	Silences        []Silence        `json:"silences"`
+	Acknowledgments []Acknowledgment `json:"acknowledgments"`
+}
+
+// Silencer holds the silences and acknowledgments of alerts. With a state
+// file, they are saved on every change and loaded on creation, so they
+// survive restarts.
+type Silencer struct {
+	path string
+	now  func() time.Time
+
+	mu       sync.Mutex
+	silences map[string]Silence
+	acks     map[string]Acknowledgment
+}
+
+// NewSilencer creates a silencer saving its state to path, loading the state
+// saved there before. Without a path the state is kept in memory only.
+func NewSilencer(path string) (*Silencer, error) {
+	s := &Silencer{
+		path:     path,
+		now:      time.Now,
+		silences: make(map[string]Silence),
+		acks:     make(map[string]Acknowledgment),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert state: %w", err)
+	}
+	var state silencerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse alert state %s: %w", path, err)
+	}
+	for _, silence := range state.Silences {
+		s.silences[silence.ID] = silence
+	}
+	for _, ack := range state.Acknowledgments {
+		s.acks[ack.Incident] = ack
+	}
+	return s, nil
+}
+
+// AddSilence mutes the alerts matching matchers for duration
+func (s *Silencer) AddSilence(matchers map[string]string, duration time.Duration, comment, createdBy string) (Silence, error) {
+	if len(matchers) == 0 {
+		return Silence{}, fmt.Errorf("a silence needs at least one matcher")
+	}
+	if duration <= 0 {
+		return Silence{}, fmt.Errorf("a silence needs a positive duration")
+	}
+	id, err := newSilenceID()
+	if err != nil {
+		return Silence{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	silence := Silence{
+		ID:        id,
+		Matchers:  matchers,
+		Comment:   comment,
+		CreatedBy: createdBy,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+	}
+	s.silences[id] = silence
+	return silence, s.save()
+}
+
+// ExpireSilence ends a silence now. It reports false if there is no active
+// silence with the ID.
+func (s *Silencer) ExpireSilence(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.silences[id]; !exists {
+		return false, nil
+	}
+	delete(s.silences, id)
+	return true, s.save()
+}
+
+// Silences returns the active silences, those ending first first
+func (s *Silencer) Silences() []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	silences := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		silences = append(silences, silence)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		if !silences[i].EndsAt.Equal(silences[j].EndsAt) {
+			return silences[i].EndsAt.Before(silences[j].EndsAt)
+		}
+		return silences[i].ID < silences[j].ID
+	})
+	return silences
+}
+
+// Acknowledge records that someone is handling the firing alert
+func (s *Silencer) Acknowledge(alert Alert, comment, by string) (Acknowledgment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ack := Acknowledgment{
+		Incident: alert.IncidentKey(),
+		Rule:     alert.Rule,
+		Labels:   alert.Labels,
+		Comment:  comment,
+		By:       by,
+		Time:     s.now(),
+	}
+	s.acks[ack.Incident] = ack
+	return ack, s.save()
+}
+
+// Acknowledged returns the acknowledgment of the alert's incident, if any
+func (s *Silencer) Acknowledged(alert Alert) (Acknowledgment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ack, ok := s.acks[alert.IncidentKey()]
+	return ack, ok
+}
+
+// Silenced returns the ID of an active silence matching the alert, if any
+func (s *Silencer) Silenced(alert Alert) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.silenced(alert)
+}
+
+// Mutes reports why the alert must not be sent, if it must not: an active
+// silence matches it, or its incident is acknowledged. An alert resolving an
+// acknowledged incident ends the acknowledgment and is sent unless silenced.
+func (s *Silencer) Mutes(alert Alert) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident := alert.IncidentKey()
+	if _, acked := s.acks[incident]; acked {
+		if !alert.Resolves() {
+			return "acknowledged", true
+		}
+		delete(s.acks, incident)
+		// The acknowledgment has ended either way; a failed save only
+		// means it may be restored after a restart
+		_ = s.save()
+	}
+	if id, ok := s.silenced(alert); ok {
+		return "silence " + id, true
+	}
+	return "", false
+}
+
+// silenced returns the ID of an active silence matching the alert. The caller
+// must hold mu.
+func (s *Silencer) silenced(alert Alert) (string, bool) {
+	now := s.now()
+	ids := make([]string, 0, len(s.silences))
+	for id, silence := range s.silences {
+		if now.Before(silence.EndsAt) && silence.matches(alert) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", false
+	}
+	sort.Strings(ids)
+	return ids[0], true
+}
+
+// prune drops the silences that have ended. The caller must hold mu.
+func (s *Silencer) prune() {
+	now := s.now()
+	for id, silence := range s.silences {
+		if !now.Before(silence.EndsAt) {
+			delete(s.silences, id)
+		}
+	}
+}
+
+// save writes the state to the state file, if any, replacing it atomically.
+// The caller must hold mu.
+func (s *Silencer) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.prune()
+	state := silencerState{
+		Silences:        make([]Silence, 0, len(s.silences)),
+		Acknowledgments: make([]Acknowledgment, 0, len(s.acks)),
+	}
+	for _, silence := range s.silences {
+		state.Silences = append(state.Silences, silence)
+	}
+	for _, ack := range s.acks {
+		state.Acknowledgments = append(state.Acknowledgments, ack)
+	}
+	sort.Slice(state.Silences, func(i, j int) bool { return state.Silences[i].ID < state.Silences[j].ID })
+	sort.Slice(state.Acknowledgments, func(i, j int) bool {
+		return state.Acknowledgments[i].Incident < state.Acknowledgments[j].Incident
+	})
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode alert state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create alert state directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write alert state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write alert state: %w", err)
+	}
+	return nil
+}
+
+// alertLabels returns the labels silences match an alert on
+func alertLabels(alert Alert) map[string]string {
+	labels := make(map[string]string, len(alert.Labels)+5)
+	for name, value := range alert.Labels {
+		labels[name] = value
+	}
+	for name, value := range map[string]string{
+		"collector": alert.Collector,
+		"region":    alert.Region,
+		"rule":      alert.Rule,
+		"code":      alert.Code,
+		"severity":  string(alert.Severity),
+	} {
+		if value != "" {
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+// newSilenceID returns a random silence ID
+func newSilenceID() (string, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("failed to generate silence ID: %w", err)
+	}
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,117 @@
+package alerting
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aws-monitoring/pkg/errors"
+)
+
+func TestSilencerSilences(t *testing.T) {
+	silencer, err := NewSilencer("")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	silencer.now = func() time.Time { return now }
+
+	if _, err := silencer.AddSilence(nil, time.Hour, "", ""); err == nil {
+		t.Error("Expected error for a silence without matchers")
+	}
+	silence, err := silencer.AddSilence(map[string]string{"collector": "ec2", "region": "us-east-1"}, time.Hour, "maintenance", "ops")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if id, muted := silencer.Mutes(testAlert()); !muted || id != "silence "+silence.ID {
+		t.Errorf("Expected the alert to be muted by the silence, got %q, %v", id, muted)
+	}
+	other := testAlert()
+	other.Region = "eu-west-1"
+	if _, muted := silencer.Mutes(other); muted {
+		t.Error("Expected an alert in another region not to be muted")
+	}
+
+	now = now.Add(time.Hour)
+	if _, muted := silencer.Mutes(testAlert()); muted {
+		t.Error("Expected the silence to end after its duration")
+	}
+	if silences := silencer.Silences(); len(silences) != 0 {
+		t.Errorf("Expected ended silences not to be listed, got %d", len(silences))
+	}
+}
+
+func TestSilencerAcknowledgments(t *testing.T) {
+	silencer, _ := NewSilencer("")
+	labels := map[string]string{"collector": "sqs", "region": "us-east-1", "queue": "orders"}
+	firing := NewRuleAlert("queue-backlog", RuleFiring, errors.SeverityHigh, labels, 1200, "backlog")
+
+	if _, err := silencer.Acknowledge(firing, "looking into it", "ops"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if reason, muted := silencer.Mutes(firing); !muted || reason != "acknowledged" {
+		t.Errorf("Expected the acknowledged alert to be muted, got %q, %v", reason, muted)
+	}
+
+	resolved := NewRuleAlert("queue-backlog", RuleResolved, errors.SeverityHigh, labels, 10, "backlog cleared")
+	if _, muted := silencer.Mutes(resolved); muted {
+		t.Error("Expected the resolution to be sent")
+	}
+	if _, acked := silencer.Acknowledged(firing); acked {
+		t.Error("Expected the resolution to end the acknowledgment")
+	}
+}
+
+func TestSilencerPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "alerts.json")
+	silencer, err := NewSilencer(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	silence, err := silencer.AddSilence(map[string]string{"rule": "queue-backlog"}, time.Hour, "", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	firing := NewRuleAlert("queue-backlog", RuleFiring, errors.SeverityHigh, map[string]string{"queue": "orders"}, 1200, "")
+	if _, err := silencer.Acknowledge(firing, "", "ops"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	restored, err := NewSilencer(path)
+	if err != nil {
+		t.Fatalf("Expected no error loading the state, got: %v", err)
+	}
+	if silences := restored.Silences(); len(silences) != 1 || silences[0].ID != silence.ID {
+		t.Errorf("Expected the silence to survive a restart, got %+v", silences)
+	}
+	if ack, acked := restored.Acknowledged(firing); !acked || ack.By != "ops" {
+		t.Errorf("Expected the acknowledgment to survive a restart, got %+v, %v", ack, acked)
+	}
+
+	if expired, err := restored.ExpireSilence(silence.ID); err != nil || !expired {
+		t.Fatalf("Expected the silence to expire, got %v, %v", expired, err)
+	}
+	if reloaded, _ := NewSilencer(path); len(reloaded.Silences()) != 0 {
+		t.Error("Expected the expired silence not to be restored")
+	}
+}
+
+func TestNotifierSilencer(t *testing.T) {
+	sink := &recordingSink{}
+	notifier, _ := newTestNotifier(t, sink, 0, 0)
+	silencer, _ := NewSilencer("")
+	notifier.SetSilencer(silencer)
+
+	if _, err := silencer.AddSilence(map[string]string{"code": "PERMISSION_DENIED"}, time.Hour, "", ""); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if notifier.Notify(testAlert()) {
+		t.Error("Expected the silenced alert not to be sent")
+	}
+	notifier.Wait()
+	if len(sink.alerts) != 0 {
+		t.Errorf("Expected no alerts delivered, got %d", len(sink.alerts))
+	}
+}
@@ -0,0 +1,288 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/pkg/errors"
+)
+
+// defaultHTTPTimeout bounds a single webhook delivery
+const defaultHTTPTimeout = 10 * time.Second
+
+// SlackSink posts alerts to a Slack incoming webhook
+type SlackSink struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackSink creates a sink for a Slack incoming webhook URL
+func NewSlackSink(webhookURL string) *SlackSink {
+	return &SlackSink{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// Name identifies the sink in logs
+func (s *SlackSink) Name() string {
+	return "slack"
+}
+
+// Send posts the alert as a Slack message
+func (s *SlackSink) Send(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, s.client, s.webhookURL, nil, map[string]string{"text": alert.Text()})
+}
+
+// WebhookSink posts alerts as JSON to a generic HTTP endpoint
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookSink creates a sink that posts alerts to url with extra headers
+func NewWebhookSink(url string, headers map[string]string) *WebhookSink {
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// Name identifies the sink in logs
+func (s *WebhookSink) Name() string {
+	return "webhook:" + s.url
+}
+
+// Send posts the alert as JSON
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, s.client, s.url, s.headers, alert)
+}
+
+// SNSSink publishes alerts to an SNS topic
+type SNSSink struct {
+	client   aws.SNSClient
+	topicARN string
+}
+
+// NewSNSSink creates a sink that publishes to topicARN
+func NewSNSSink(client aws.SNSClient, topicARN string) *SNSSink {
+	return &SNSSink{
+		client:   client,
+		topicARN: topicARN,
+	}
+}
+
+// Name identifies the sink in logs
+func (s *SNSSink) Name() string {
+	return "sns:" + s.topicARN
+}
+
+// Send publishes the alert to the topic
+func (s *SNSSink) Send(ctx context.Context, alert Alert) error {
+	subject := alert.Title()
+	// SNS subjects are limited to 100 characters
+	if len(subject) > 100 {
+		subject = subject[:100]
+	}
+	message := alert.Text()
+
+	_, err := s.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: &s.topicARN,
+		Subject:  &subject,
+		Message:  &message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", s.topicARN, err)
+	}
+	return nil
+}
+
+// PagerDutySink triggers and resolves PagerDuty incidents through the Events
+// API v2. Alerts that resolve an earlier one, such as a resolved alert rule,
+// resolve its incident.
+type PagerDutySink struct {
+	url        string
+	routingKey string
+	client     *http.Client
+}
+
+// NewPagerDutySink creates a sink sending events to url, the Events API
+// endpoint, for the service integration with routingKey
+func NewPagerDutySink(url, routingKey string) *PagerDutySink {
+	return &PagerDutySink{
+		url:        url,
+		routingKey: routingKey,
+		client:     &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// pagerDutyEvent is an Events API v2 event
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+// pagerDutyPayload describes the incident of a triggered event
+type pagerDutyPayload struct {
+	Summary       string `json:"summary"`
+	Source        string `json:"source"`
+	Severity      string `json:"severity"`
+	Timestamp     string `json:"timestamp"`
+	Component     string `json:"component,omitempty"`
+	Group         string `json:"group,omitempty"`
+	Class         string `json:"class,omitempty"`
+	CustomDetails Alert  `json:"custom_details"`
+}
+
+// Name identifies the sink in logs
+func (s *PagerDutySink) Name() string {
+	return "pagerduty"
+}
+
+// Send triggers an incident for the alert, or resolves the incident of the
+// alert it resolves
+func (s *PagerDutySink) Send(ctx context.Context, alert Alert) error {
+	event := pagerDutyEvent{
+		RoutingKey:  s.routingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.IncidentKey(),
+	}
+	if alert.Resolves() {
+		event.EventAction = "resolve"
+	} else {
+		summary := alert.Title()
+		// PagerDuty summaries are limited to 1024 characters
+		if len(summary) > 1024 {
+			summary = summary[:1024]
+		}
+		event.Payload = &pagerDutyPayload{
+			Summary:       summary,
+			Source:        "aws-monitor",
+			Severity:      pagerDutySeverity(alert.Severity),
+			Timestamp:     alert.Time.Format(time.RFC3339),
+			Component:     alert.Collector,
+			Group:         alert.Region,
+			Class:         string(alert.Type),
+			CustomDetails: alert,
+		}
+	}
+	return postJSON(ctx, s.client, s.url, nil, event)
+}
+
+// pagerDutySeverity returns the PagerDuty severity of an alert severity
+func pagerDutySeverity(severity errors.Severity) string {
+	switch severity {
+	case errors.SeverityCritical:
+		return "critical"
+	case errors.SeverityHigh:
+		return "error"
+	case errors.SeverityMedium:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// sendMailFunc sends an email, as smtp.SendMail does
+type sendMailFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailSink sends alerts as plain text emails through an SMTP server. The
+// server is contacted on every alert, and STARTTLS is used when it supports
+// it.
+type EmailSink struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail sendMailFunc
+}
+
+// NewEmailSink creates a sink sending from the from address to the to
+// addresses through the SMTP server at host and port. Without a username the
+// server is used without authentication.
+func NewEmailSink(host string, port int, username, password, from string, to []string) *EmailSink {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailSink{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:     auth,
+		from:     from,
+		to:       to,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Name identifies the sink in logs
+func (s *EmailSink) Name() string {
+	return "email:" + s.addr
+}
+
+// Send emails the alert with its title as the subject. The SMTP exchange
+// can't be cancelled, so ctx is only checked before it starts.
+func (s *EmailSink) Send(ctx context.Context, alert Alert) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.Join(strings.Fields(alert.Title()), " "))
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(alert.Text(), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	if err := s.sendMail(s.addr, s.auth, s.from, s.to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email through %s: %w", s.addr, err)
+	}
+	return nil
+}
+
+// postJSON posts body as JSON and treats any non-2xx response as an error
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+
+	"aws-monitoring/pkg/errors"
+)
+
+// mockSNSClient records published messages
+type mockSNSClient struct {
+	inputs []*sns.PublishInput
+}
+
+func (m *mockSNSClient) Publish(_ context.Context, params *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	m.inputs = append(m.inputs, params)
+	return &sns.PublishOutput{}, nil
+}
+
+func testAlert() Alert {
+	return Alert{Collector: "ec2", Severity: "critical", Code: "PERMISSION_DENIED", Message: "access denied", Region: "us-east-1"}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received Alert
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, map[string]string{"Authorization": "Bearer token"})
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if received.Collector != "ec2" || received.Code != "PERMISSION_DENIED" {
+		t.Errorf("Unexpected alert received: %+v", received)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Expected configured headers to be sent, got %q", auth)
+	}
+}
+
+func TestSlackSinkErrorStatus(t *testing.T) {
+	var text string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		text = body["text"]
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewSlackSink(server.URL).Send(context.Background(), testAlert()); err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+	if !strings.Contains(text, "[CRITICAL]") || !strings.Contains(text, "access denied") {
+		t.Errorf("Unexpected Slack message: %q", text)
+	}
+}
+
+func TestSNSSink(t *testing.T) {
+	client := &mockSNSClient{}
+	sink := NewSNSSink(client, "arn:aws:sns:us-east-1:123456789012:alerts")
+
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(client.inputs) != 1 {
+		t.Fatalf("Expected 1 message published, got %d", len(client.inputs))
+	}
+	input := client.inputs[0]
+	if *input.TopicArn != "arn:aws:sns:us-east-1:123456789012:alerts" || !strings.Contains(*input.Message, "PERMISSION_DENIED") {
+		t.Errorf("Unexpected publish input: %+v", input)
+	}
+}
+
+func TestPagerDutySink(t *testing.T) {
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewPagerDutySink(server.URL, "routing-key")
+	labels := map[string]string{"collector": "sqs", "region": "us-east-1", "queue": "orders"}
+	firing := NewRuleAlert("queue-backlog", RuleFiring, errors.SeverityHigh, labels, 1200, "backlog")
+	resolved := NewRuleAlert("queue-backlog", RuleResolved, errors.SeverityHigh, labels, 10, "backlog cleared")
+	for _, alert := range []Alert{firing, resolved} {
+		if err := sink.Send(context.Background(), alert); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	trigger, resolve := events[0], events[1]
+	if trigger.RoutingKey != "routing-key" || trigger.EventAction != "trigger" || trigger.Payload == nil {
+		t.Fatalf("Expected a trigger event with a payload, got %+v", trigger)
+	}
+	if trigger.Payload.Severity != "error" || trigger.Payload.Summary != firing.Title() {
+		t.Errorf("Expected severity error and the alert title, got %q, %q", trigger.Payload.Severity, trigger.Payload.Summary)
+	}
+	if resolve.EventAction != "resolve" || resolve.Payload != nil {
+		t.Errorf("Expected a resolve event without payload, got %+v", resolve)
+	}
+	if resolve.DedupKey != trigger.DedupKey {
+		t.Errorf("Expected the resolution to share the incident key %q, got %q", trigger.DedupKey, resolve.DedupKey)
+	}
+}
+
+func TestEmailSink(t *testing.T) {
+	sink := NewEmailSink("smtp.example.com", 587, "user", "secret", "aws-monitor@example.com",
+		[]string{"oncall@example.com", "ops@example.com"})
+	var addr, from string
+	var to []string
+	var msg []byte
+	sink.sendMail = func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if addr != "smtp.example.com:587" || from != "aws-monitor@example.com" || len(to) != 2 {
+		t.Errorf("Unexpected envelope: %s, %s, %v", addr, from, to)
+	}
+	for _, want := range []string{
+		"To: oncall@example.com, ops@example.com\r\n",
+		"Subject: [CRITICAL] aws-monitor collector ec2 in us-east-1 failed\r\n",
+		"\r\n\r\n[CRITICAL] aws-monitor collector ec2 in us-east-1 failed\r\nPERMISSION_DENIED: access denied",
+	} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("Expected message to contain %q, got %q", want, msg)
+		}
+	}
+
+	sink.sendMail = func(string, smtp.Auth, string, []string, []byte) error {
+		return fmt.Errorf("connection refused")
+	}
+	if err := sink.Send(context.Background(), testAlert()); err == nil {
+		t.Error("Expected error when the SMTP server fails")
+	}
+}
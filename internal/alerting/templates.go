@@ -0,0 +1,64 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Templates render the title and text of alerts from Go templates executed on
+// the alert, replacing the built-in ones. Sinks that format alerts, such as
+// Slack, SNS, PagerDuty and email, use the rendered title and text; webhooks
+// receive the alert as JSON either way.
+type Templates struct {
+	title *template.Template
+	text  *template.Template
+}
+
+// NewTemplates parses the title and text templates. An empty template keeps
+// the built-in one.
+func NewTemplates(title, text string) (*Templates, error) {
+	t := &Templates{}
+	var err error
+	if title != "" {
+		if t.title, err = template.New("title").Parse(title); err != nil {
+			return nil, fmt.Errorf("invalid title template: %w", err)
+		}
+	}
+	if text != "" {
+		if t.text, err = template.New("text").Parse(text); err != nil {
+			return nil, fmt.Errorf("invalid text template: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// apply renders the templates for the alert. The title is rendered first, so
+// the text template sees it as .Title.
+func (t *Templates) apply(alert Alert) (Alert, error) {
+	if t.title != nil {
+		title, err := execute(t.title, alert)
+		if err != nil {
+			return alert, err
+		}
+		// The title is used as a subject, which must be a single line
+		alert.title = strings.Join(strings.Fields(title), " ")
+	}
+	if t.text != nil {
+		text, err := execute(t.text, alert)
+		if err != nil {
+			return alert, err
+		}
+		alert.text = text
+	}
+	return alert, nil
+}
+
+// execute renders a template for the alert
+func execute(tmpl *template.Template, alert Alert) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, alert); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,331 @@
+// Package anomaly flags sudden deviations in selected series of the collected
+// metrics without hand-tuned thresholds. Each series learns a baseline of its
+// recent values, and a value further from the baseline than a number of
+// standard deviations is reported as an anomaly event.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/events"
+	"aws-monitoring/pkg/logger"
+)
+
+// EventType is the type of the events reported for anomalies
+const EventType = "metric.anomaly"
+
+// Methods of computing the baseline of a series
+const (
+	// MethodEWMA weighs recent values more, with the exponentially weighted
+	// moving average and variance of the values
+	MethodEWMA = "ewma"
+	// MethodRolling weighs the last values equally, with their mean and
+	// standard deviation
+	MethodRolling = "rolling"
+)
+
+// Emitter receives anomaly events, e.g. *events.Pipeline
+type Emitter interface {
+	Emit(event events.Event) bool
+}
+
+// selector selects the series checked for anomalies
+type selector struct {
+	metric string
+	labels map[string]string
+}
+
+// matches reports whether the metric is a selected series
+func (s selector) matches(metric collectors.MetricData) bool {
+	if metric.Name != s.metric {
+		return false
+	}
+	for name, value := range s.labels {
+		if metric.Labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// baseline is what a series' values are expected to be
+type baseline interface {
+	// stats returns the mean and standard deviation of the values so far
+	stats() (mean, stddev float64)
+	// add learns a value
+	add(value float64)
+}
+
+// ewma is a baseline of the exponentially weighted moving average and
+// variance of the values
+type ewma struct {
+	alpha    float64
+	mean     float64
+	variance float64
+	samples  int
+}
+
+func (e *ewma) stats() (float64, float64) {
+	return e.mean, math.Sqrt(e.variance)
+}
+
+func (e *ewma) add(value float64) {
+	e.samples++
+	if e.samples == 1 {
+		e.mean = value
+		return
+	}
+	diff := value - e.mean
+	e.mean += e.alpha * diff
+	e.variance = (1 - e.alpha) * (e.variance + e.alpha*diff*diff)
+}
+
+// rolling is a baseline of the mean and standard deviation of the last
+// values
+type rolling struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func (r *rolling) stats() (float64, float64) {
+	n := r.next
+	if r.full {
+		n = len(r.values)
+	}
+	if n == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, value := range r.values[:n] {
+		sum += value
+	}
+	mean := sum / float64(n)
+	var squares float64
+	for _, value := range r.values[:n] {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(n))
+}
+
+func (r *rolling) add(value float64) {
+	r.values[r.next] = value
+	r.next = (r.next + 1) % len(r.values)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// series is the state of a selected series
+type series struct {
+	baseline baseline
+	samples  int
+	// source is the collector and region whose runs report the series
+	source string
+	// anomalous is set while the series deviates, so that a lasting
+	// deviation is reported once
+	anomalous bool
+}
+
+// Anomaly is a value deviating from the baseline of its series
+type Anomaly struct {
+	// Collector and Region are those of the collection run reporting the
+	// value
+	Collector string
+	Region    string
+	Metric    string
+	Labels    map[string]string
+	Value     float64
+	Mean      float64
+	StdDev    float64
+	// Score is the number of standard deviations the value is from the
+	// mean; infinite when the series had not varied before
+	Score float64
+}
+
+// Detector checks the selected series of each collection run against their
+// baselines. A series is checked once its baseline has learned MinSamples
+// values, and every value, anomalous or not, is then learned, so that a
+// lasting change becomes the new normal. Anomalies are logged, and sent as
+// events if an emitter is set.
+type Detector struct {
+	selectors  []selector
+	method     string
+	alpha      float64
+	window     int
+	threshold  float64
+	minSamples int
+	severity   events.Severity
+	emitter    Emitter
+	logger     *logger.Logger
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewDetector creates a detector for the configured series
+func NewDetector(cfg config.AnomalyConfig, log *logger.Logger) *Detector {
+	d := &Detector{
+		method:     cfg.Method,
+		alpha:      cfg.Alpha,
+		window:     cfg.Window,
+		threshold:  cfg.Threshold,
+		minSamples: cfg.MinSamples,
+		severity:   events.Severity(cfg.Severity),
+		logger:     log.WithComponent("anomalies"),
+		series:     make(map[string]*series),
+	}
+	for _, s := range cfg.Series {
+		d.selectors = append(d.selectors, selector{metric: s.Metric, labels: s.Labels})
+	}
+	return d
+}
+
+// SetEmitter sets where anomaly events are sent
+func (d *Detector) SetEmitter(emitter Emitter) {
+	d.emitter = emitter
+}
+
+// Observe checks the selected series among the metrics of a collection run
+// of a collector in a region and learns their values. The series the run no
+// longer reports are forgotten. It returns the anomalies found.
+func (d *Detector) Observe(collectorName, region string, metrics []collectors.MetricData) []Anomaly {
+	source := collectorName + "/" + region
+	var anomalies []Anomaly
+
+	d.mu.Lock()
+	seen := make(map[string]bool)
+	for _, metric := range metrics {
+		if !d.selected(metric) {
+			continue
+		}
+
+		key := metric.Name + "{" + alerting.SeriesKey(metric.Labels) + "}"
+		seen[key] = true
+		state := d.series[key]
+		if state == nil {
+			state = &series{baseline: d.newBaseline(), source: source}
+			d.series[key] = state
+		}
+
+		if state.samples >= d.minSamples {
+			mean, stddev := state.baseline.stats()
+			score := deviation(metric.Value, mean, stddev)
+			switch {
+			case score > d.threshold && !state.anomalous:
+				state.anomalous = true
+				anomalies = append(anomalies, Anomaly{
+					Collector: collectorName,
+					Region:    region,
+					Metric:    metric.Name,
+					Labels:    collectors.CloneLabels(metric.Labels),
+					Value:     metric.Value,
+					Mean:      mean,
+					StdDev:    stddev,
+					Score:     score,
+				})
+			case score <= d.threshold:
+				state.anomalous = false
+			}
+		}
+		state.baseline.add(metric.Value)
+		state.samples++
+	}
+
+	for key, state := range d.series {
+		if state.source == source && !seen[key] {
+			delete(d.series, key)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, anomaly := range anomalies {
+		d.report(anomaly)
+	}
+	return anomalies
+}
+
+// selected reports whether the metric is a series checked for anomalies
+func (d *Detector) selected(metric collectors.MetricData) bool {
+	for _, s := range d.selectors {
+		if s.matches(metric) {
+			return true
+		}
+	}
+	return false
+}
+
+// newBaseline returns an empty baseline of the configured method
+func (d *Detector) newBaseline() baseline {
+	if d.method == MethodRolling {
+		return &rolling{values: make([]float64, d.window)}
+	}
+	return &ewma{alpha: d.alpha}
+}
+
+// deviation returns the number of standard deviations value is from mean. A
+// series that has not varied deviates infinitely by any change.
+func deviation(value, mean, stddev float64) float64 {
+	diff := math.Abs(value - mean)
+	if stddev == 0 {
+		if diff == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return diff / stddev
+}
+
+// report logs the anomaly and sends it as an event
+func (d *Detector) report(anomaly Anomaly) {
+	emitted := false
+	if d.emitter != nil {
+		emitted = d.emitter.Emit(d.event(anomaly))
+	}
+
+	d.logger.Warn("Anomaly detected",
+		logger.String("collector", anomaly.Collector),
+		logger.String("region", anomaly.Region),
+		logger.String("metric", anomaly.Metric),
+		logger.String("series", alerting.SeriesKey(anomaly.Labels)),
+		logger.Float64("value", anomaly.Value),
+		logger.Float64("mean", anomaly.Mean),
+		logger.Float64("stddev", anomaly.StdDev),
+		logger.Bool("emitted", emitted))
+}
+
+// event returns the event reporting an anomaly
+func (d *Detector) event(anomaly Anomaly) events.Event {
+	series := fmt.Sprintf("%s{%s}", anomaly.Metric, alerting.SeriesKey(anomaly.Labels))
+	message := fmt.Sprintf("%s is %g, %.1f standard deviations from its mean of %g", series, anomaly.Value, anomaly.Score, anomaly.Mean)
+	if math.IsInf(anomaly.Score, 1) {
+		message = fmt.Sprintf("%s is %g, after being %g in every collection", series, anomaly.Value, anomaly.Mean)
+	}
+
+	attributes := map[string]string{
+		"metric": anomaly.Metric,
+		"value":  strconv.FormatFloat(anomaly.Value, 'g', -1, 64),
+		"mean":   strconv.FormatFloat(anomaly.Mean, 'g', -1, 64),
+		"stddev": strconv.FormatFloat(anomaly.StdDev, 'g', -1, 64),
+		"score":  strconv.FormatFloat(anomaly.Score, 'g', 3, 64),
+	}
+	for name, value := range anomaly.Labels {
+		attributes["label."+name] = value
+	}
+
+	return events.Event{
+		Type:       EventType,
+		Severity:   d.severity,
+		Message:    message,
+		Collector:  anomaly.Collector,
+		Region:     anomaly.Region,
+		Attributes: attributes,
+	}
+}
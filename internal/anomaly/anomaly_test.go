@@ -0,0 +1,227 @@
+package anomaly
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/events"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// recordingEmitter records the events it is given
+type recordingEmitter struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (e *recordingEmitter) Emit(event events.Event) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+	return true
+}
+
+// countingProcessor counts the results and errors passed on to it
+type countingProcessor struct {
+	results int
+	errors  int
+}
+
+func (p *countingProcessor) ProcessResult(context.Context, *scheduler.ScheduledJob, *collectors.CollectionResult) error {
+	p.results++
+	return nil
+}
+
+func (p *countingProcessor) ProcessError(context.Context, *scheduler.ScheduledJob, *errors.Error) error {
+	p.errors++
+	return nil
+}
+
+func newTestDetector(t *testing.T, cfg config.AnomalyConfig) (*Detector, *recordingEmitter) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	if cfg.Alpha == 0 {
+		cfg.Alpha = 0.3
+	}
+	if cfg.Window == 0 {
+		cfg.Window = 30
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = 3
+	}
+	if cfg.MinSamples == 0 {
+		cfg.MinSamples = 5
+	}
+	if cfg.Severity == "" {
+		cfg.Severity = "warning"
+	}
+	if cfg.Series == nil {
+		cfg.Series = []config.AnomalySeriesConfig{{Metric: "aws_natgateway_bytes_out"}}
+	}
+
+	emitter := &recordingEmitter{}
+	detector := NewDetector(cfg, log)
+	detector.SetEmitter(emitter)
+	return detector, emitter
+}
+
+func natBytes(gateway string, value float64) collectors.MetricData {
+	return collectors.MetricData{
+		Name:   "aws_natgateway_bytes_out",
+		Value:  value,
+		Labels: map[string]string{"nat_gateway_id": gateway},
+	}
+}
+
+// learn observes a run per value of the gateway's series
+func learn(d *Detector, gateway string, values ...float64) []Anomaly {
+	var anomalies []Anomaly
+	for _, value := range values {
+		anomalies = append(anomalies, d.Observe("natgateway", "us-east-1", []collectors.MetricData{natBytes(gateway, value)})...)
+	}
+	return anomalies
+}
+
+func TestDetectorFlagsDeviation(t *testing.T) {
+	for _, method := range []string{MethodEWMA, MethodRolling} {
+		t.Run(method, func(t *testing.T) {
+			detector, emitter := newTestDetector(t, config.AnomalyConfig{Method: method})
+
+			if anomalies := learn(detector, "nat-1", 100, 104, 98, 101, 97, 103, 99, 102); len(anomalies) != 0 {
+				t.Fatalf("Expected no anomaly within the usual variation, got %+v", anomalies)
+			}
+
+			anomalies := learn(detector, "nat-1", 400)
+			if len(anomalies) != 1 {
+				t.Fatalf("Expected 1 anomaly for a spike, got %d", len(anomalies))
+			}
+			if anomalies[0].Value != 400 || anomalies[0].Score <= 3 || anomalies[0].Mean < 97 || anomalies[0].Mean > 104 {
+				t.Errorf("Expected the spike measured against the baseline, got %+v", anomalies[0])
+			}
+
+			if len(emitter.events) != 1 {
+				t.Fatalf("Expected 1 event, got %d", len(emitter.events))
+			}
+			event := emitter.events[0]
+			if event.Type != EventType || event.Severity != events.SeverityWarning ||
+				event.Collector != "natgateway" || event.Region != "us-east-1" {
+				t.Errorf("Expected a warning anomaly event of the run's collector and region, got %+v", event)
+			}
+			if event.Attributes["label.nat_gateway_id"] != "nat-1" || event.Attributes["value"] != "400" ||
+				!strings.Contains(event.Message, "standard deviations") {
+				t.Errorf("Expected the series and deviation in the event, got %+v", event)
+			}
+		})
+	}
+}
+
+func TestDetectorWaitsForMinSamples(t *testing.T) {
+	detector, _ := newTestDetector(t, config.AnomalyConfig{MinSamples: 5})
+
+	if anomalies := learn(detector, "nat-1", 100, 101, 99, 5000); len(anomalies) != 0 {
+		t.Errorf("Expected no anomaly before min_samples values, got %+v", anomalies)
+	}
+}
+
+func TestDetectorReportsLastingDeviationOnce(t *testing.T) {
+	detector, emitter := newTestDetector(t, config.AnomalyConfig{Method: MethodRolling, Window: 5})
+
+	learn(detector, "nat-1", 100, 102, 98, 101, 99)
+	learn(detector, "nat-1", 500, 510)
+	if len(emitter.events) != 1 {
+		t.Fatalf("Expected a lasting deviation reported once, got %d events", len(emitter.events))
+	}
+
+	// Once the window has learned the new level, it is normal again
+	if anomalies := learn(detector, "nat-1", 505, 495, 500, 502, 498, 501); len(anomalies) != 0 {
+		t.Errorf("Expected the new level to become the baseline, got %+v", anomalies)
+	}
+	if anomalies := learn(detector, "nat-1", 100); len(anomalies) != 1 {
+		t.Errorf("Expected a new deviation to be reported, got %d anomalies", len(anomalies))
+	}
+}
+
+func TestDetectorFlagsChangeOfConstantSeries(t *testing.T) {
+	detector, emitter := newTestDetector(t, config.AnomalyConfig{
+		Series: []config.AnomalySeriesConfig{{Metric: "aws_lambda_errors"}},
+	})
+
+	errorsMetric := func(value float64) []collectors.MetricData {
+		return []collectors.MetricData{{Name: "aws_lambda_errors", Value: value, Labels: map[string]string{"function_name": "orders"}}}
+	}
+	for i := 0; i < 6; i++ {
+		detector.Observe("lambda", "us-east-1", errorsMetric(0))
+	}
+	anomalies := detector.Observe("lambda", "us-east-1", errorsMetric(3))
+	if len(anomalies) != 1 || !math.IsInf(anomalies[0].Score, 1) {
+		t.Fatalf("Expected an anomaly for a change of a constant series, got %+v", anomalies)
+	}
+	if !strings.Contains(emitter.events[0].Message, "after being 0 in every collection") {
+		t.Errorf("Expected the constant value in the message, got %q", emitter.events[0].Message)
+	}
+}
+
+func TestDetectorSelectsSeries(t *testing.T) {
+	detector, _ := newTestDetector(t, config.AnomalyConfig{
+		Series: []config.AnomalySeriesConfig{{Metric: "aws_natgateway_bytes_out", Labels: map[string]string{"nat_gateway_id": "nat-1"}}},
+	})
+
+	learn(detector, "nat-2", 100, 102, 98, 101, 99)
+	if anomalies := learn(detector, "nat-2", 5000); len(anomalies) != 0 {
+		t.Errorf("Expected series not matching the labels to be ignored, got %+v", anomalies)
+	}
+	if len(detector.series) != 0 {
+		t.Errorf("Expected no baseline for unselected series, got %d", len(detector.series))
+	}
+}
+
+func TestDetectorForgetsSeriesNoLongerReported(t *testing.T) {
+	detector, _ := newTestDetector(t, config.AnomalyConfig{})
+
+	learn(detector, "nat-1", 100, 102, 98)
+	detector.Observe("natgateway", "eu-west-1", []collectors.MetricData{natBytes("nat-9", 10)})
+	detector.Observe("natgateway", "us-east-1", []collectors.MetricData{natBytes("nat-2", 10)})
+
+	if len(detector.series) != 2 {
+		t.Fatalf("Expected the series of other regions kept and nat-1 forgotten, got %d series", len(detector.series))
+	}
+	if _, ok := detector.series["aws_natgateway_bytes_out{nat_gateway_id=nat-1}"]; ok {
+		t.Error("Expected nat-1 to be forgotten")
+	}
+}
+
+func TestProcessor(t *testing.T) {
+	detector, emitter := newTestDetector(t, config.AnomalyConfig{})
+	next := &countingProcessor{}
+	p := NewProcessor(next, detector)
+	ctx := context.Background()
+	job := &scheduler.ScheduledJob{CollectorName: "natgateway", Region: "us-east-1"}
+
+	for _, value := range []float64{100, 102, 98, 101, 99, 900} {
+		_ = p.ProcessResult(ctx, job, &collectors.CollectionResult{Metrics: []collectors.MetricData{natBytes("nat-1", value)}})
+	}
+	// A failed run doesn't forget the series it didn't report
+	_ = p.ProcessResult(ctx, job, &collectors.CollectionResult{Error: errors.NewTimeoutError("collect", time.Second)})
+	_ = p.ProcessError(ctx, job, errors.NewTimeoutError("collect", time.Second))
+
+	if next.results != 7 || next.errors != 1 {
+		t.Errorf("Expected every result and error passed on, got %d and %d", next.results, next.errors)
+	}
+	if len(emitter.events) != 1 {
+		t.Errorf("Expected 1 anomaly event, got %d", len(emitter.events))
+	}
+	if len(detector.series) != 1 {
+		t.Errorf("Expected the series kept after a failed run, got %d", len(detector.series))
+	}
+}
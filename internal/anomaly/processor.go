@@ -0,0 +1,35 @@
+package anomaly
+
+import (
+	"context"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+)
+
+// Processor implements scheduler.JobProcessor by checking the metrics of
+// every successful collection run for anomalies before passing it on to the
+// next processor. Failed runs leave the baselines unchanged.
+type Processor struct {
+	next     scheduler.JobProcessor
+	detector *Detector
+}
+
+// NewProcessor creates a processor checking runs with detector
+func NewProcessor(next scheduler.JobProcessor, detector *Detector) *Processor {
+	return &Processor{next: next, detector: detector}
+}
+
+// ProcessResult checks the metrics of a successful run for anomalies
+func (p *Processor) ProcessResult(ctx context.Context, job *scheduler.ScheduledJob, result *collectors.CollectionResult) error {
+	if result.Error == nil {
+		p.detector.Observe(job.CollectorName, job.Region, result.Metrics)
+	}
+	return p.next.ProcessResult(ctx, job, result)
+}
+
+// ProcessError passes the error on to the next processor
+func (p *Processor) ProcessError(ctx context.Context, job *scheduler.ScheduledJob, err *errors.Error) error {
+	return p.next.ProcessError(ctx, job, err)
+}
@@ -0,0 +1,284 @@
+// Package api provides the read-only JSON API served alongside the health checks.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// PathPrefix is the path under which the API is served
+const PathPrefix = "/api/v1/"
+
+// Handler serves the API:
+//
+//	GET /api/v1/collectors             status of every collector
+//	GET /api/v1/collectors/{name}      status of a single collector
+//	GET /api/v1/registry               registered collector types and collectors
+//	GET /api/v1/scheduler              scheduler status and job counters
+//	GET /api/v1/jobs                   scheduled jobs with their next and last run (?collector= to filter)
+//	GET /api/v1/export                 log export counters
+//	GET /api/v1/config                 effective configuration, with secrets redacted
+//	GET /api/v1/errors/catalog         list every known error code
+//	GET /api/v1/errors/catalog/{code}  describe a single error code
+//	GET /api/v1/errors/recent          recent errors per collector (?collector= to filter)
+type Handler struct {
+	registry  collectors.Registry
+	scheduler scheduler.Scheduler
+	exporter  ExportStatsProvider
+	config    func() *config.Config
+	logger    *logger.Logger
+	mux       *http.ServeMux
+}
+
+// ExportStatsProvider reports the log export counters; ok is false when log
+// export is disabled
+type ExportStatsProvider interface {
+	ExportStats() (stats logger.ExportStats, ok bool)
+}
+
+// Job is the schedule and last outcome of a collection job
+type Job struct {
+	ID        string        `json:"id"`
+	Collector string        `json:"collector"`
+	Region    string        `json:"region"`
+	Interval  time.Duration `json:"interval"`
+	Enabled   bool          `json:"enabled"`
+	NextRun   time.Time     `json:"next_run"`
+	LastRun   *time.Time    `json:"last_run,omitempty"`
+	// LastDuration is how long the last run took
+	LastDuration time.Duration `json:"last_duration,omitempty"`
+	// LastMetricCount is the number of metrics collected by the last run
+	LastMetricCount int `json:"last_metric_count"`
+	// LastError is the error of the last run, if it failed
+	LastError *errors.Error `json:"last_error,omitempty"`
+}
+
+// NewHandler creates a new API handler
+func NewHandler(registry collectors.Registry, log *logger.Logger) *Handler {
+	h := &Handler{
+		registry: registry,
+		logger:   log.WithComponent("api"),
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /api/v1/collectors", h.handleListCollectors)
+	h.mux.HandleFunc("GET /api/v1/collectors/{name}", h.handleGetCollector)
+	h.mux.HandleFunc("GET /api/v1/registry", h.handleRegistry)
+	h.mux.HandleFunc("GET /api/v1/scheduler", h.handleScheduler)
+	h.mux.HandleFunc("GET /api/v1/jobs", h.handleJobs)
+	h.mux.HandleFunc("GET /api/v1/export", h.handleExport)
+	h.mux.HandleFunc("GET /api/v1/config", h.handleConfig)
+	h.mux.HandleFunc("GET /api/v1/errors/catalog", h.handleErrorCatalog)
+	h.mux.HandleFunc("GET /api/v1/errors/catalog/{code}", h.handleErrorCode)
+	h.mux.HandleFunc("GET /api/v1/errors/recent", h.handleRecentErrors)
+
+	return h
+}
+
+// SetScheduler enables the scheduler and job endpoints, which respond 503
+// until it is set
+func (h *Handler) SetScheduler(s scheduler.Scheduler) {
+	h.scheduler = s
+}
+
+// SetExportStats enables the export endpoint, which responds 503 until it is
+// set or while log export is disabled
+func (h *Handler) SetExportStats(provider ExportStatsProvider) {
+	h.exporter = provider
+}
+
+// SetConfig enables the configuration endpoint, which responds 503 until it
+// is set. current is called on every request, so reloads are reflected.
+func (h *Handler) SetConfig(current func() *config.Config) {
+	h.config = current
+}
+
+// ServeHTTP dispatches the request to the API endpoints
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleListCollectors returns the status of every registered collector
+func (h *Handler) handleListCollectors(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collectors": h.registry.Status(),
+	})
+}
+
+// handleGetCollector returns the status of a single collector
+func (h *Handler) handleGetCollector(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	collector, exists := h.registry.Get(name)
+	if !exists {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("collector %s not found", name))
+		return
+	}
+	h.writeJSON(w, http.StatusOK, collector.Info())
+}
+
+// handleRegistry returns the collector types plugins can use and the names
+// of the registered collectors
+func (h *Handler) handleRegistry(w http.ResponseWriter, _ *http.Request) {
+	list := h.registry.List()
+	names := make([]string, 0, len(list))
+	for _, collector := range list {
+		names = append(names, collector.Name())
+	}
+	sort.Strings(names)
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collector_types": collectors.RegisteredCollectorTypes(),
+		"collectors":      names,
+	})
+}
+
+// handleScheduler returns the scheduler status and job counters
+func (h *Handler) handleScheduler(w http.ResponseWriter, _ *http.Request) {
+	if h.scheduler == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "scheduler not available")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.scheduler.GetInfo())
+}
+
+// handleJobs returns the scheduled jobs, sorted by collector and region, or
+// those of the collector named by the collector query parameter
+func (h *Handler) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if h.scheduler == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "scheduler not available")
+		return
+	}
+	name := r.URL.Query().Get("collector")
+
+	jobs := []Job{}
+	for _, scheduled := range h.scheduler.GetScheduledJobs() {
+		if name != "" && scheduled.CollectorName != name {
+			continue
+		}
+		job := Job{
+			ID:        scheduled.ID,
+			Collector: scheduled.CollectorName,
+			Region:    scheduled.Region,
+			Interval:  scheduled.Interval,
+			Enabled:   scheduled.Enabled,
+			NextRun:   scheduled.NextRun,
+			LastRun:   scheduled.LastRun,
+		}
+		if result := scheduled.LastResult; result != nil {
+			job.LastDuration = result.Duration
+			job.LastMetricCount = scheduled.LastMetricCount
+			job.LastError = result.Error
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Collector != jobs[j].Collector {
+			return jobs[i].Collector < jobs[j].Collector
+		}
+		return jobs[i].Region < jobs[j].Region
+	})
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs": jobs,
+	})
+}
+
+// handleExport returns the log export counters
+func (h *Handler) handleExport(w http.ResponseWriter, _ *http.Request) {
+	if h.exporter == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "log export not enabled")
+		return
+	}
+	stats, ok := h.exporter.ExportStats()
+	if !ok {
+		h.writeError(w, http.StatusServiceUnavailable, "log export not enabled")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, stats)
+}
+
+// handleConfig returns the configuration the process is running with, after
+// defaults, includes and overrides, with the values of sensitive settings
+// redacted
+func (h *Handler) handleConfig(w http.ResponseWriter, _ *http.Request) {
+	if h.config == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "configuration not available")
+		return
+	}
+	doc, err := h.config().Redacted()
+	if err != nil {
+		h.logger.Error("Failed to encode configuration", logger.String("error", err.Error()))
+		h.writeError(w, http.StatusInternalServerError, "failed to encode configuration")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, doc)
+}
+
+// handleErrorCatalog returns the descriptions of all known error codes
+func (h *Handler) handleErrorCatalog(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"codes": errors.Catalog(),
+	})
+}
+
+// handleErrorCode returns the description of a single error code
+func (h *Handler) handleErrorCode(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+
+	info, exists := errors.LookupCode(code)
+	if !exists {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("error code %s not found", code))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, info)
+}
+
+// handleRecentErrors returns the most recent errors of every collector, or of
+// the collector named by the collector query parameter
+func (h *Handler) handleRecentErrors(w http.ResponseWriter, r *http.Request) {
+	var list []collectors.MetricCollector
+	if name := r.URL.Query().Get("collector"); name != "" {
+		collector, exists := h.registry.Get(name)
+		if !exists {
+			h.writeError(w, http.StatusNotFound, fmt.Sprintf("collector %s not found", name))
+			return
+		}
+		list = append(list, collector)
+	} else {
+		list = h.registry.List()
+	}
+
+	recent := make(map[string][]collectors.RecentError, len(list))
+	for _, collector := range list {
+		if c, ok := collector.(collectors.RecentErrorsProvider); ok {
+			recent[collector.Name()] = c.RecentErrors()
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collectors": recent,
+	})
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error("Failed to encode API response", logger.String("error", err.Error()))
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, status int, message string) {
+	h.writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// stubCollector is a minimal collector with a fixed list of recent errors
+type stubCollector struct {
+	name   string
+	recent []collectors.RecentError
+}
+
+func (c *stubCollector) Name() string                  { return c.name }
+func (c *stubCollector) Description() string           { return "stub collector" }
+func (c *stubCollector) Health() error                 { return nil }
+func (c *stubCollector) Start(_ context.Context) error { return nil }
+func (c *stubCollector) Stop(_ context.Context) error  { return nil }
+func (c *stubCollector) Info() collectors.CollectorInfo {
+	return collectors.CollectorInfo{Name: c.name}
+}
+
+func (c *stubCollector) Collect(_ context.Context, region string) *collectors.CollectionResult {
+	return &collectors.CollectionResult{CollectorName: c.name, Region: region}
+}
+
+func (c *stubCollector) RecentErrors() []collectors.RecentError {
+	return c.recent
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	registry := collectors.NewCollectorRegistry(log)
+	recent := []collectors.RecentError{
+		collectors.NewRecentError(errors.WithRegion(errors.NewPermissionError("describe", "ec2:instances"), "us-east-1")),
+	}
+	for _, collector := range []*stubCollector{{name: "ec2", recent: recent}, {name: "vpc"}} {
+		if err := registry.Register(collector); err != nil {
+			t.Fatalf("Failed to register collector: %v", err)
+		}
+	}
+
+	return NewHandler(registry, log)
+}
+
+func doRequest(h *Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestHandlerErrorCatalog(t *testing.T) {
+	h := newTestHandler(t)
+
+	w := doRequest(h, http.MethodGet, "/api/v1/errors/catalog")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var body struct {
+		Codes []errors.CodeInfo `json:"codes"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	found := false
+	for _, info := range body.Codes {
+		if info.Code == errors.CodeHighErrorRate {
+			found = info.Description != "" && info.Remediation != ""
+		}
+	}
+	if !found {
+		t.Errorf("Expected %s with description and remediation in catalog", errors.CodeHighErrorRate)
+	}
+}
+
+func TestHandlerErrorCode(t *testing.T) {
+	h := newTestHandler(t)
+
+	w := doRequest(h, http.MethodGet, "/api/v1/errors/catalog/COLLECTOR_NOT_FOUND")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var info errors.CodeInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.Code != errors.CodeCollectorNotFound || info.Type != errors.ErrorTypeValidation {
+		t.Errorf("Unexpected code info: %+v", info)
+	}
+
+	if w := doRequest(h, http.MethodGet, "/api/v1/errors/catalog/NOPE"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown code, got %d", w.Code)
+	}
+
+	if w := doRequest(h, http.MethodPost, "/api/v1/errors/catalog"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", w.Code)
+	}
+}
+
+func TestHandlerRecentErrors(t *testing.T) {
+	h := newTestHandler(t)
+
+	w := doRequest(h, http.MethodGet, "/api/v1/errors/recent")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var body struct {
+		Collectors map[string][]collectors.RecentError `json:"collectors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Collectors) != 2 {
+		t.Errorf("Expected recent errors for 2 collectors, got %d", len(body.Collectors))
+	}
+	if recent := body.Collectors["ec2"]; len(recent) != 1 || recent[0].Code != errors.CodeAccessDenied || recent[0].Region != "us-east-1" {
+		t.Errorf("Unexpected recent errors for ec2: %+v", recent)
+	}
+
+	w = doRequest(h, http.MethodGet, "/api/v1/errors/recent?collector=vpc")
+	body.Collectors = nil
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if _, exists := body.Collectors["vpc"]; !exists || len(body.Collectors) != 1 {
+		t.Errorf("Expected only vpc in filtered response, got %v", body.Collectors)
+	}
+
+	if w := doRequest(h, http.MethodGet, "/api/v1/errors/recent?collector=missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown collector, got %d", w.Code)
+	}
+}
+
+func TestHandlerCollectors(t *testing.T) {
+	h := newTestHandler(t)
+
+	w := doRequest(h, http.MethodGet, "/api/v1/collectors")
+	var list struct {
+		Collectors map[string]collectors.CollectorInfo `json:"collectors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Collectors) != 2 || list.Collectors["ec2"].Name != "ec2" {
+		t.Errorf("Expected the status of ec2 and vpc, got %+v", list.Collectors)
+	}
+
+	w = doRequest(h, http.MethodGet, "/api/v1/collectors/vpc")
+	var info collectors.CollectorInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if w.Code != http.StatusOK || info.Name != "vpc" {
+		t.Errorf("Expected vpc's status, got %d %+v", w.Code, info)
+	}
+	if w := doRequest(h, http.MethodGet, "/api/v1/collectors/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown collector, got %d", w.Code)
+	}
+
+	w = doRequest(h, http.MethodGet, "/api/v1/registry")
+	var registry struct {
+		CollectorTypes []string `json:"collector_types"`
+		Collectors     []string `json:"collectors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&registry); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(registry.Collectors) != 2 || registry.Collectors[0] != "ec2" || registry.Collectors[1] != "vpc" {
+		t.Errorf("Expected sorted collector names, got %v", registry.Collectors)
+	}
+}
+
+func TestHandlerSchedulerAndJobs(t *testing.T) {
+	h := newTestHandler(t)
+
+	// Without a scheduler, the endpoints are unavailable
+	if w := doRequest(h, http.MethodGet, "/api/v1/jobs"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without a scheduler, got %d", w.Code)
+	}
+
+	log, _ := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	s := scheduler.NewMetricScheduler(scheduler.DefaultConfig(), h.registry, nil, log)
+	if err := s.ScheduleCollector("vpc", []string{"us-east-1"}, time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	if err := s.ScheduleCollector("ec2", []string{"us-west-2", "us-east-1"}, 5*time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	h.SetScheduler(s)
+
+	w := doRequest(h, http.MethodGet, "/api/v1/scheduler")
+	var info scheduler.Info
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.Status != scheduler.StatusStopped || info.JobCount != 3 {
+		t.Errorf("Expected a stopped scheduler with 3 jobs, got %+v", info)
+	}
+
+	w = doRequest(h, http.MethodGet, "/api/v1/jobs")
+	var body struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Jobs) != 3 {
+		t.Fatalf("Expected 3 jobs, got %+v", body.Jobs)
+	}
+	first := body.Jobs[0]
+	if first.Collector != "ec2" || first.Region != "us-east-1" || first.Interval != 5*time.Minute || first.NextRun.IsZero() {
+		t.Errorf("Expected ec2's us-east-1 job first, got %+v", first)
+	}
+	if first.LastRun != nil {
+		t.Errorf("Expected no last run, got %v", first.LastRun)
+	}
+
+	w = doRequest(h, http.MethodGet, "/api/v1/jobs?collector=vpc")
+	body.Jobs = nil
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Jobs) != 1 || body.Jobs[0].Collector != "vpc" {
+		t.Errorf("Expected only vpc's job, got %+v", body.Jobs)
+	}
+}
+
+// stubExporter reports fixed log export counters
+type stubExporter struct {
+	stats   logger.ExportStats
+	enabled bool
+}
+
+func (e stubExporter) ExportStats() (logger.ExportStats, bool) {
+	return e.stats, e.enabled
+}
+
+func TestHandlerExport(t *testing.T) {
+	h := newTestHandler(t)
+
+	if w := doRequest(h, http.MethodGet, "/api/v1/export"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without export stats, got %d", w.Code)
+	}
+
+	h.SetExportStats(stubExporter{})
+	if w := doRequest(h, http.MethodGet, "/api/v1/export"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while log export is disabled, got %d", w.Code)
+	}
+
+	h.SetExportStats(stubExporter{stats: logger.ExportStats{Exported: 42, Failed: 1}, enabled: true})
+	w := doRequest(h, http.MethodGet, "/api/v1/export")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var stats logger.ExportStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if stats.Exported != 42 || stats.Failed != 1 {
+		t.Errorf("Unexpected export stats: %+v", stats)
+	}
+}
+
+func TestHandlerConfig(t *testing.T) {
+	h := newTestHandler(t)
+
+	if w := doRequest(h, http.MethodGet, "/api/v1/config"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without a configuration, got %d", w.Code)
+	}
+
+	cfg := &config.Config{}
+	cfg.AWS.SecretAccessKey = "wJalrXUtnFEMI"
+	cfg.AWS.DefaultRegion = "us-east-1"
+	cfg.Global.LogRedactFields = []string{"Default_Region"}
+	h.SetConfig(func() *config.Config { return cfg })
+
+	w := doRequest(h, http.MethodGet, "/api/v1/config")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var body struct {
+		AWS map[string]interface{} `json:"aws"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.AWS["secret_access_key"] != config.RedactedValue {
+		t.Errorf("Expected secret_access_key to be redacted, got %v", body.AWS["secret_access_key"])
+	}
+	if body.AWS["default_region"] != config.RedactedValue {
+		t.Errorf("Expected log_redact_fields to be redacted, got %v", body.AWS["default_region"])
+	}
+}
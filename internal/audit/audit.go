@@ -0,0 +1,206 @@
+// Package audit records the changes made to the running application, such as
+// configuration reloads and collectors added through the admin API, to an
+// append-only log.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionConfigReload     = "config.reload"
+	ActionCollectorAdd     = "collector.add"
+	ActionCollectorRemove  = "collector.remove"
+	ActionLogLevelChange   = "log_level.change"
+	ActionSilenceCreate    = "silence.create"
+	ActionSilenceExpire    = "silence.expire"
+	ActionAlertAcknowledge = "alert.acknowledge"
+	ActionCollectorRun     = "collector.run"
+	ActionRegionPause      = "region.pause"
+	ActionRegionResume     = "region.resume"
+)
+
+// DefaultRetained is the number of latest entries kept in memory for
+// retrieval
+const DefaultRetained = 1000
+
+// systemActor is the actor of changes made without a known actor
+const systemActor = "system"
+
+// Entry is a change made to the running application
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor is who made the change, e.g. "admin:alice" or "signal:SIGHUP"
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	// Target is what was changed, e.g. the collector name
+	Target  string            `json:"target,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Filter selects audit log entries; zero fields select every entry
+type Filter struct {
+	Action string
+	Since  time.Time
+	// Limit is the maximum number of entries returned
+	Limit int
+}
+
+// Log appends entries to a file, one JSON object per line, and keeps the
+// latest entries in memory for retrieval. Entries are never modified or
+// removed from the file. A log without a file keeps its entries in memory
+// only.
+type Log struct {
+	retained int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	entries []Entry
+}
+
+// Open opens the audit log appending to path, keeping the latest retained
+// entries, including those already in the file, for retrieval. An empty path
+// opens a log kept in memory only.
+func Open(path string, retained int) (*Log, error) {
+	if retained <= 0 {
+		retained = DefaultRetained
+	}
+	l := &Log{retained: retained, now: time.Now}
+	if path == "" {
+		return l, nil
+	}
+
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// load reads the latest entries of the file at path, if it exists
+func (l *Log) load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("failed to parse audit log %s, line %d: %w", path, line, err)
+		}
+		l.retain(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+// Record appends an entry for a change made by the actor of ctx
+func (l *Log) Record(ctx context.Context, action, target string, details map[string]string) error {
+	entry := Entry{
+		Time:    l.now().UTC(),
+		Actor:   ActorFrom(ctx),
+		Action:  action,
+		Target:  target,
+		Details: details,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.retain(entry)
+	if l.file == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return l.file.Sync()
+}
+
+// retain keeps entry in memory, dropping the oldest entry beyond the limit
+func (l *Log) retain(entry Entry) {
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.retained {
+		l.entries = append(l.entries[:0], l.entries[len(l.entries)-l.retained:]...)
+	}
+}
+
+// Entries returns the retained entries selected by filter, newest first
+func (l *Log) Entries(filter Filter) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []Entry{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		entry := l.entries[i]
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+			continue
+		}
+		entries = append(entries, entry)
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+	}
+	return entries
+}
+
+// Close closes the file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// actorKey is the context key of the actor making changes
+type actorKey struct{}
+
+// WithActor returns a context for changes made by actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor of ctx; "system" if none is set
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return systemActor
+}
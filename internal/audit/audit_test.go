@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogAppendsAndRestores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	log, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Expected no error opening a new audit log, got: %v", err)
+	}
+
+	ctx := WithActor(context.Background(), "admin:alice")
+	if err := log.Record(ctx, ActionCollectorRemove, "queue-depth", nil); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := log.Record(context.Background(), ActionConfigReload, "", map[string]string{"added": "s3"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Expected no error closing, got: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("Expected one line per entry, got %d", len(lines))
+	}
+
+	// Reopening appends to the entries already recorded
+	reopened, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got: %v", err)
+	}
+	defer reopened.Close()
+	if err := reopened.Record(ctx, ActionLogLevelChange, "", map[string]string{"level": "debug"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	entries := reopened.Entries(Filter{})
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Action != ActionLogLevelChange || entries[2].Action != ActionCollectorRemove {
+		t.Errorf("Expected entries newest first, got %+v", entries)
+	}
+	if entries[1].Actor != "system" || entries[2].Actor != "admin:alice" {
+		t.Errorf("Expected the actors of the changes, got %q and %q", entries[1].Actor, entries[2].Actor)
+	}
+}
+
+func TestLogEntriesFilter(t *testing.T) {
+	log, err := Open("", 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	log.now = func() time.Time { return now }
+
+	for _, action := range []string{ActionSilenceCreate, ActionSilenceExpire, ActionSilenceCreate} {
+		if err := log.Record(context.Background(), action, "", nil); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		now = now.Add(time.Minute)
+	}
+
+	if entries := log.Entries(Filter{}); len(entries) != 2 {
+		t.Errorf("Expected only the 2 latest entries to be retained, got %d", len(entries))
+	}
+	if entries := log.Entries(Filter{Action: ActionSilenceCreate}); len(entries) != 1 {
+		t.Errorf("Expected 1 retained silence.create entry, got %d", len(entries))
+	}
+	since := time.Date(2024, 5, 1, 12, 2, 0, 0, time.UTC)
+	if entries := log.Entries(Filter{Since: since}); len(entries) != 1 || !entries[0].Time.Equal(since) {
+		t.Errorf("Expected the entry at %v, got %+v", since, entries)
+	}
+	if entries := log.Entries(Filter{Limit: 1}); len(entries) != 1 {
+		t.Errorf("Expected the limit to apply, got %d entries", len(entries))
+	}
+}
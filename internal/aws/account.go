@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// STSClient interface defines STS operations needed to identify the account
+type STSClient interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// IAMClient interface defines IAM operations needed to identify the account
+type IAMClient interface {
+	ListAccountAliases(ctx context.Context, params *iam.ListAccountAliasesInput, optFns ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error)
+}
+
+// AccountInfo identifies the AWS account being monitored
+type AccountInfo struct {
+	// ID is the 12-digit AWS account ID
+	ID string `json:"id"`
+	// Alias is the IAM account alias, empty if none is set or it can't be read
+	Alias string `json:"alias,omitempty"`
+}
+
+// ResolveAccountInfo looks up the account ID with STS and the account alias
+// with IAM. The alias is optional: if it cannot be read (e.g. missing
+// iam:ListAccountAliases permission) a warning is logged and it is left empty.
+func ResolveAccountInfo(ctx context.Context, stsClient STSClient, iamClient IAMClient, log *logger.Logger) (AccountInfo, error) {
+	log = log.WithComponent("aws-account")
+
+	identity, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return AccountInfo{}, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	if identity.Account == nil || *identity.Account == "" {
+		return AccountInfo{}, fmt.Errorf("caller identity did not include an account ID")
+	}
+
+	info := AccountInfo{ID: *identity.Account}
+
+	if iamClient != nil {
+		aliases, err := iamClient.ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+		if err != nil {
+			log.Warn("Failed to list account aliases, continuing without alias",
+				logger.String("account_id", info.ID),
+				logger.String("error", err.Error()))
+		} else if len(aliases.AccountAliases) > 0 {
+			// An account can have at most one alias
+			info.Alias = aliases.AccountAliases[0]
+		}
+	}
+
+	log.Info("AWS account resolved",
+		logger.String("account_id", info.ID),
+		logger.String("account_alias", info.Alias))
+
+	return info, nil
+}
@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+type mockSTSClient struct {
+	account *string
+	arn     *string
+	err     error
+}
+
+func (m *mockSTSClient) GetCallerIdentity(_ context.Context, _ *sts.GetCallerIdentityInput, _ ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sts.GetCallerIdentityOutput{Account: m.account, Arn: m.arn}, nil
+}
+
+type mockIAMClient struct {
+	aliases []string
+	err     error
+}
+
+func (m *mockIAMClient) ListAccountAliases(_ context.Context, _ *iam.ListAccountAliasesInput, _ ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.ListAccountAliasesOutput{AccountAliases: m.aliases}, nil
+}
+
+func TestResolveAccountInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		sts         *mockSTSClient
+		iam         IAMClient
+		expected    AccountInfo
+		expectError bool
+	}{
+		{
+			name:     "account with alias",
+			sts:      &mockSTSClient{account: awssdk.String("123456789012")},
+			iam:      &mockIAMClient{aliases: []string{"acme-prod"}},
+			expected: AccountInfo{ID: "123456789012", Alias: "acme-prod"},
+		},
+		{
+			name:     "account without alias",
+			sts:      &mockSTSClient{account: awssdk.String("123456789012")},
+			iam:      &mockIAMClient{},
+			expected: AccountInfo{ID: "123456789012"},
+		},
+		{
+			name:     "alias lookup denied",
+			sts:      &mockSTSClient{account: awssdk.String("123456789012")},
+			iam:      &mockIAMClient{err: fmt.Errorf("AccessDenied")},
+			expected: AccountInfo{ID: "123456789012"},
+		},
+		{
+			name:     "no IAM client",
+			sts:      &mockSTSClient{account: awssdk.String("123456789012")},
+			expected: AccountInfo{ID: "123456789012"},
+		},
+		{
+			name:        "caller identity fails",
+			sts:         &mockSTSClient{err: fmt.Errorf("expired token")},
+			iam:         &mockIAMClient{},
+			expectError: true,
+		},
+		{
+			name:        "missing account",
+			sts:         &mockSTSClient{},
+			iam:         &mockIAMClient{},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ResolveAccountInfo(context.Background(), tt.sts, tt.iam, newInventoryTestLogger(t))
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if info != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, info)
+			}
+		})
+	}
+}
@@ -5,12 +5,17 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	appConfig "aws-monitoring/internal/config"
 	"aws-monitoring/pkg/logger"
@@ -22,9 +27,24 @@ type EC2Client interface {
 	DescribeInstanceStatus(ctx context.Context, params *ec2.DescribeInstanceStatusInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error)
 }
 
+// SNSClient interface defines SNS operations needed to publish alerts
+type SNSClient interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SQSClient interface defines SQS operations needed to consume event triggers
+type SQSClient interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
 // ClientProvider interface for creating AWS service clients
 type ClientProvider interface {
 	GetEC2Client(region string) (EC2Client, error)
+	GetSTSClient(region string) (STSClient, error)
+	GetIAMClient(region string) (IAMClient, error)
+	GetSNSClient(region string) (SNSClient, error)
+	GetSQSClient(region string) (SQSClient, error)
 	Close() error
 }
 
@@ -32,16 +52,33 @@ type ClientProvider interface {
 type clientProvider struct {
 	config     *appConfig.Config
 	logger     *logger.Logger
+	// loadConfig loads the AWS config of a region
+	loadConfig func(region string) (aws.Config, error)
+
+	mu         sync.Mutex
 	awsConfigs map[string]aws.Config
+	// loading are the config loads in progress, by region
+	loading    map[string]*configLoad
+}
+
+// configLoad is a load of the AWS config of a region in progress, which
+// concurrent requests for the region wait for instead of loading it again
+type configLoad struct {
+	done chan struct{}
+	cfg  aws.Config
+	err  error
 }
 
 // NewClientProvider creates a new AWS client provider
 func NewClientProvider(cfg *appConfig.Config, log *logger.Logger) ClientProvider {
-	return &clientProvider{
+	cp := &clientProvider{
 		config:     cfg,
 		logger:     log.WithComponent("aws-client"),
 		awsConfigs: make(map[string]aws.Config),
+		loading:    make(map[string]*configLoad),
 	}
+	cp.loadConfig = cp.loadAWSConfig
+	return cp
 }
 
 // GetEC2Client returns an EC2 client for the specified region
@@ -57,13 +94,95 @@ func (cp *clientProvider) GetEC2Client(region string) (EC2Client, error) {
 	return client, nil
 }
 
-// getAWSConfig returns AWS config for the specified region, creating it if needed
+// GetSTSClient returns an STS client for the specified region
+func (cp *clientProvider) GetSTSClient(region string) (STSClient, error) {
+	awsCfg, err := cp.getAWSConfig(region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS config for region %s: %w", region, err)
+	}
+
+	client := sts.NewFromConfig(awsCfg)
+	cp.logger.Debug("Created STS client", logger.String("region", region))
+
+	return client, nil
+}
+
+// GetIAMClient returns an IAM client. IAM is a global service; the region
+// only selects which AWS configuration is used to sign requests.
+func (cp *clientProvider) GetIAMClient(region string) (IAMClient, error) {
+	awsCfg, err := cp.getAWSConfig(region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS config for region %s: %w", region, err)
+	}
+
+	client := iam.NewFromConfig(awsCfg)
+	cp.logger.Debug("Created IAM client", logger.String("region", region))
+
+	return client, nil
+}
+
+// GetSNSClient returns an SNS client for the specified region
+func (cp *clientProvider) GetSNSClient(region string) (SNSClient, error) {
+	awsCfg, err := cp.getAWSConfig(region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS config for region %s: %w", region, err)
+	}
+
+	client := sns.NewFromConfig(awsCfg)
+	cp.logger.Debug("Created SNS client", logger.String("region", region))
+
+	return client, nil
+}
+
+// GetSQSClient returns an SQS client for the specified region
+func (cp *clientProvider) GetSQSClient(region string) (SQSClient, error) {
+	awsCfg, err := cp.getAWSConfig(region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS config for region %s: %w", region, err)
+	}
+
+	client := sqs.NewFromConfig(awsCfg)
+	cp.logger.Debug("Created SQS client", logger.String("region", region))
+
+	return client, nil
+}
+
+// getAWSConfig returns AWS config for the specified region, creating it if
+// needed. Concurrent first requests for a region share a single load, since
+// loading can take IMDS and STS round trips. A failed load isn't cached, so
+// the next request tries again.
 func (cp *clientProvider) getAWSConfig(region string) (aws.Config, error) {
+	cp.mu.Lock()
 	// Check if we already have a config for this region
 	if cfg, exists := cp.awsConfigs[region]; exists {
+		cp.mu.Unlock()
 		return cfg, nil
 	}
+	if load, loading := cp.loading[region]; loading {
+		cp.mu.Unlock()
+		<-load.done
+		return load.cfg, load.err
+	}
+	load := &configLoad{done: make(chan struct{})}
+	cp.loading[region] = load
+	cp.mu.Unlock()
 
+	load.cfg, load.err = cp.loadConfig(region)
+
+	cp.mu.Lock()
+	delete(cp.loading, region)
+	if load.err == nil {
+		// Store the config for reuse
+		cp.awsConfigs[region] = load.cfg
+	}
+	cp.mu.Unlock()
+	close(load.done)
+
+	return load.cfg, load.err
+}
+
+// loadAWSConfig loads the AWS config of a region
+func (cp *clientProvider) loadAWSConfig(region string) (aws.Config, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -98,9 +217,14 @@ func (cp *clientProvider) getAWSConfig(region string) (aws.Config, error) {
 	awsCfg.HTTPClient = &http.Client{
 		Timeout: time.Duration(cp.config.AWS.Timeout),
 	}
+	
+	// Send requests to the endpoint override, such as LocalStack, if set
+	if cp.config.AWS.EndpointURL != "" {
+		awsCfg.BaseEndpoint = aws.String(cp.config.AWS.EndpointURL)
+	}
 
-	// Store the config for reuse
-	cp.awsConfigs[region] = awsCfg
+	// Trace API calls made by traced collections
+	awsCfg.APIOptions = append(awsCfg.APIOptions, addTracing)
 
 	cp.logger.Info("AWS config loaded",
 		logger.String("region", region),
@@ -115,7 +239,9 @@ func (cp *clientProvider) getAWSConfig(region string) (aws.Config, error) {
 func (cp *clientProvider) Close() error {
 	cp.logger.Debug("Closing AWS client provider")
 	// Clear cached configs
+	cp.mu.Lock()
 	cp.awsConfigs = make(map[string]aws.Config)
+	cp.mu.Unlock()
 	return nil
 }
 
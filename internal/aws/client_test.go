@@ -2,9 +2,13 @@ package aws
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 
 	"aws-monitoring/internal/config"
@@ -228,6 +232,41 @@ func TestClientProvider_ConfigCaching(t *testing.T) {
 	}
 }
 
+func TestClientProvider_EndpointURL(t *testing.T) {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{
+			AccessKeyID:     "test-key",
+			SecretAccessKey: "test-secret",
+			DefaultRegion:   "us-east-1",
+			MaxRetries:      3,
+			Timeout:         config.Duration(30 * time.Second),
+			EndpointURL:     "http://localhost:4566",
+		},
+	}
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	cp := NewClientProvider(cfg, log).(*clientProvider)
+	
+	awsCfg, err := cp.getAWSConfig("us-east-1")
+	if err != nil {
+		t.Fatalf("Failed to load AWS config: %v", err)
+	}
+	if awsCfg.BaseEndpoint == nil || *awsCfg.BaseEndpoint != "http://localhost:4566" {
+		t.Errorf("Expected requests sent to the endpoint override, got %v", awsCfg.BaseEndpoint)
+	}
+	
+	cfg.AWS.EndpointURL = ""
+	awsCfg, err = cp.getAWSConfig("us-west-2")
+	if err != nil {
+		t.Fatalf("Failed to load AWS config: %v", err)
+	}
+	if awsCfg.BaseEndpoint != nil {
+		t.Errorf("Expected the AWS endpoints without an override, got %s", *awsCfg.BaseEndpoint)
+	}
+}
+
 func TestEC2ClientInterface(t *testing.T) {
 	// Test that our mock implements the interface
 	var client EC2Client = &mockEC2Client{}
@@ -286,4 +325,62 @@ func TestClientProvider_MultipleRegions(t *testing.T) {
 	if len(cp.awsConfigs) != len(cfg.EnabledRegions) {
 		t.Errorf("Expected %d cached configs, got %d", len(cfg.EnabledRegions), len(cp.awsConfigs))
 	}
-}
\ No newline at end of file
+}
+func TestClientProvider_ConcurrentConfigLoads(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	cp := NewClientProvider(&config.Config{}, log).(*clientProvider)
+
+	var loads atomic.Int32
+	failing := atomic.Bool{}
+	failing.Store(true)
+	release := make(chan struct{})
+	cp.loadConfig = func(region string) (aws.Config, error) {
+		loads.Add(1)
+		<-release
+		if failing.Load() {
+			return aws.Config{}, errors.New("imds unavailable")
+		}
+		return aws.Config{Region: region}, nil
+	}
+
+	// Concurrent first requests for a region share one load, and its error
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cp.getAWSConfig("us-east-1")
+			errs <- err
+		}()
+	}
+	for loads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("Expected a single config load, got %d", n)
+	}
+	for err := range errs {
+		if err == nil {
+			t.Error("Expected every waiting request to get the load error")
+		}
+	}
+
+	// A failed load isn't cached
+	failing.Store(false)
+	cfg, err := cp.getAWSConfig("us-east-1")
+	if err != nil || cfg.Region != "us-east-1" {
+		t.Errorf("Expected the config to load on the next request, got %v, %v", cfg.Region, err)
+	}
+	if _, err := cp.getAWSConfig("us-east-1"); err != nil || loads.Load() != 2 {
+		t.Errorf("Expected the loaded config to be reused, got %d loads, err %v", loads.Load(), err)
+	}
+}
@@ -0,0 +1,173 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// DefaultInventoryTTL is used when no TTL is configured for the inventory cache
+const DefaultInventoryTTL = 60 * time.Second
+
+// InventoryCache provides a TTL-based cache of described AWS resources that is
+// shared across collectors, so that resources are described once per cycle
+// instead of once per collector.
+type InventoryCache struct {
+	provider ClientProvider
+	ttl      time.Duration
+	logger   *logger.Logger
+
+	mu      sync.Mutex
+	entries map[string]*inventoryEntry
+	hits    int64
+	misses  int64
+}
+
+// inventoryEntry holds the cached inventory for a single region
+type inventoryEntry struct {
+	// mu serializes refreshes so concurrent callers share a single describe call
+	mu        sync.Mutex
+	instances []types.Instance
+	fetchedAt time.Time
+}
+
+// InventoryStats provides information about cache usage
+type InventoryStats struct {
+	// Regions is the number of regions with cached inventory
+	Regions int `json:"regions"`
+	// Hits is the number of lookups served from the cache
+	Hits int64 `json:"hits"`
+	// Misses is the number of lookups that required an AWS call
+	Misses int64 `json:"misses"`
+}
+
+// NewInventoryCache creates a new shared inventory cache
+func NewInventoryCache(provider ClientProvider, ttl time.Duration, log *logger.Logger) *InventoryCache {
+	if ttl <= 0 {
+		ttl = DefaultInventoryTTL
+	}
+
+	return &InventoryCache{
+		provider: provider,
+		ttl:      ttl,
+		logger:   log.WithComponent("aws-inventory"),
+		entries:  make(map[string]*inventoryEntry),
+	}
+}
+
+// GetInstances returns all EC2 instances in the region, describing them only
+// when the cached copy is missing or older than the TTL
+func (ic *InventoryCache) GetInstances(ctx context.Context, region string) ([]types.Instance, error) {
+	entry := ic.getEntry(region)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !entry.fetchedAt.IsZero() && time.Since(entry.fetchedAt) < ic.ttl {
+		ic.recordLookup(true)
+		return entry.instances, nil
+	}
+	ic.recordLookup(false)
+
+	instances, err := ic.describeInstances(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.instances = instances
+	entry.fetchedAt = time.Now()
+
+	ic.logger.Debug("Inventory refreshed",
+		logger.String("region", region),
+		logger.Int("instance_count", len(instances)))
+
+	return instances, nil
+}
+
+// Invalidate drops the cached inventory for a region
+func (ic *InventoryCache) Invalidate(region string) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	delete(ic.entries, region)
+}
+
+// Clear drops all cached inventory
+func (ic *InventoryCache) Clear() {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	ic.entries = make(map[string]*inventoryEntry)
+}
+
+// Stats returns cache usage statistics
+func (ic *InventoryCache) Stats() InventoryStats {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	return InventoryStats{
+		Regions: len(ic.entries),
+		Hits:    ic.hits,
+		Misses:  ic.misses,
+	}
+}
+
+// TTL returns how long cached inventory is considered fresh
+func (ic *InventoryCache) TTL() time.Duration {
+	return ic.ttl
+}
+
+func (ic *InventoryCache) getEntry(region string) *inventoryEntry {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	entry, exists := ic.entries[region]
+	if !exists {
+		entry = &inventoryEntry{}
+		ic.entries[region] = entry
+	}
+	return entry
+}
+
+func (ic *InventoryCache) recordLookup(hit bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if hit {
+		ic.hits++
+	} else {
+		ic.misses++
+	}
+}
+
+// describeInstances pages through DescribeInstances for the region
+func (ic *InventoryCache) describeInstances(ctx context.Context, region string) ([]types.Instance, error) {
+	client, err := ic.provider.GetEC2Client(region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EC2 client for region %s: %w", region, err)
+	}
+
+	var instances []types.Instance
+	input := &ec2.DescribeInstancesInput{}
+
+	for {
+		output, err := client.DescribeInstances(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances in region %s: %w", region, err)
+		}
+
+		for _, reservation := range output.Reservations {
+			instances = append(instances, reservation.Instances...)
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return instances, nil
+}
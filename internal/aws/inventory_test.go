@@ -0,0 +1,163 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// mockInventoryProvider returns the same mock EC2 client for every region
+type mockInventoryProvider struct {
+	client EC2Client
+}
+
+func (m *mockInventoryProvider) GetEC2Client(_ string) (EC2Client, error) {
+	return m.client, nil
+}
+
+func (m *mockInventoryProvider) GetSTSClient(_ string) (STSClient, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockInventoryProvider) GetIAMClient(_ string) (IAMClient, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockInventoryProvider) GetSNSClient(_ string) (SNSClient, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockInventoryProvider) GetSQSClient(_ string) (SQSClient, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockInventoryProvider) Close() error {
+	return nil
+}
+
+func newInventoryTestLogger(t *testing.T) *logger.Logger {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return log
+}
+
+func TestInventoryCache_GetInstancesCachesWithinTTL(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+
+	client := &mockEC2Client{
+		describeInstancesFunc: func(_ context.Context, _ *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			return &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{
+					{Instances: []types.Instance{{InstanceId: awssdk.String("i-1")}, {InstanceId: awssdk.String("i-2")}}},
+				},
+			}, nil
+		},
+	}
+
+	cache := NewInventoryCache(&mockInventoryProvider{client: client}, time.Minute, newInventoryTestLogger(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instances, err := cache.GetInstances(context.Background(), "us-east-1")
+			if err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if len(instances) != 2 {
+				t.Errorf("Expected 2 instances, got %d", len(instances))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected 1 DescribeInstances call, got %d", calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Misses != 1 || stats.Hits != 4 {
+		t.Errorf("Expected 1 miss and 4 hits, got %d misses and %d hits", stats.Misses, stats.Hits)
+	}
+
+	cache.Invalidate("us-east-1")
+	if _, err := cache.GetInstances(context.Background(), "us-east-1"); err != nil {
+		t.Errorf("Expected no error after invalidate, got: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected invalidate to force a refresh, got %d calls", calls)
+	}
+}
+
+func TestInventoryCache_Pagination(t *testing.T) {
+	client := &mockEC2Client{
+		describeInstancesFunc: func(_ context.Context, params *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+			if params.NextToken == nil {
+				return &ec2.DescribeInstancesOutput{
+					Reservations: []types.Reservation{{Instances: []types.Instance{{InstanceId: awssdk.String("i-1")}}}},
+					NextToken:    awssdk.String("page-2"),
+				}, nil
+			}
+			return &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{{Instances: []types.Instance{{InstanceId: awssdk.String("i-2")}}}},
+			}, nil
+		},
+	}
+
+	cache := NewInventoryCache(&mockInventoryProvider{client: client}, 0, newInventoryTestLogger(t))
+
+	if cache.TTL() != DefaultInventoryTTL {
+		t.Errorf("Expected default TTL %v, got %v", DefaultInventoryTTL, cache.TTL())
+	}
+
+	instances, err := cache.GetInstances(context.Background(), "us-west-2")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Errorf("Expected 2 instances across pages, got %d", len(instances))
+	}
+}
+
+func TestInventoryCache_ErrorNotCached(t *testing.T) {
+	fail := true
+	client := &mockEC2Client{
+		describeInstancesFunc: func(_ context.Context, _ *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+			if fail {
+				return nil, fmt.Errorf("throttled")
+			}
+			return &ec2.DescribeInstancesOutput{}, nil
+		},
+	}
+
+	cache := NewInventoryCache(&mockInventoryProvider{client: client}, time.Minute, newInventoryTestLogger(t))
+
+	if _, err := cache.GetInstances(context.Background(), "us-east-1"); err == nil {
+		t.Error("Expected error from failing describe call")
+	}
+
+	fail = false
+	if _, err := cache.GetInstances(context.Background(), "us-east-1"); err != nil {
+		t.Errorf("Expected failed lookup not to be cached, got: %v", err)
+	}
+
+	cache.Clear()
+	if cache.Stats().Regions != 0 {
+		t.Error("Expected no cached regions after clear")
+	}
+}
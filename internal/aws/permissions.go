@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Permission is an IAM action needed on a resource. An empty Resource means
+// any resource ("*").
+type Permission struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource,omitempty"`
+}
+
+// String formats the permission as the action, followed by the resource if
+// it is not "*"
+func (p Permission) String() string {
+	if p.Resource == "" || p.Resource == "*" {
+		return p.Action
+	}
+	return p.Action + " on " + p.Resource
+}
+
+// PolicySimulator evaluates the IAM policies of a principal without calling
+// the actions themselves
+type PolicySimulator interface {
+	SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error)
+}
+
+// PermissionReport is the result of checking permissions of the caller
+type PermissionReport struct {
+	// Principal is the ARN of the IAM user or role whose policies were simulated
+	Principal string `json:"principal"`
+	// Missing are the permissions the principal's policies do not allow
+	Missing []Permission `json:"missing,omitempty"`
+}
+
+// CheckPermissions simulates the IAM policies of the caller for each of the
+// permissions and reports those that are not allowed. The caller needs
+// iam:SimulatePrincipalPolicy on itself; the root user cannot be simulated.
+func CheckPermissions(ctx context.Context, stsClient STSClient, simulator PolicySimulator, permissions []Permission) (PermissionReport, error) {
+	identity, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return PermissionReport{}, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	if identity.Arn == nil || *identity.Arn == "" {
+		return PermissionReport{}, fmt.Errorf("caller identity did not include an ARN")
+	}
+
+	report := PermissionReport{Principal: principalARN(*identity.Arn)}
+
+	// Simulate the actions needed on each resource together
+	actionsByResource := make(map[string][]string)
+	for _, permission := range permissions {
+		resource := permission.Resource
+		if resource == "" {
+			resource = "*"
+		}
+		if !containsString(actionsByResource[resource], permission.Action) {
+			actionsByResource[resource] = append(actionsByResource[resource], permission.Action)
+		}
+	}
+	resources := make([]string, 0, len(actionsByResource))
+	for resource := range actionsByResource {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	for _, resource := range resources {
+		decisions, err := simulate(ctx, simulator, report.Principal, resource, actionsByResource[resource])
+		if err != nil {
+			return report, err
+		}
+		for _, action := range actionsByResource[resource] {
+			if decisions[action] != types.PolicyEvaluationDecisionTypeAllowed {
+				report.Missing = append(report.Missing, Permission{Action: action, Resource: resource})
+			}
+		}
+	}
+	return report, nil
+}
+
+// simulate returns the policy decision for each action on a resource
+func simulate(ctx context.Context, simulator PolicySimulator, principal, resource string, actions []string) (map[string]types.PolicyEvaluationDecisionType, error) {
+	decisions := make(map[string]types.PolicyEvaluationDecisionType, len(actions))
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principal),
+		ActionNames:     actions,
+		ResourceArns:    []string{resource},
+	}
+
+	for {
+		output, err := simulator.SimulatePrincipalPolicy(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate policies of %s: %w", principal, err)
+		}
+		for _, result := range output.EvaluationResults {
+			if result.EvalActionName != nil {
+				decisions[*result.EvalActionName] = result.EvalDecision
+			}
+		}
+		if !output.IsTruncated || output.Marker == nil {
+			return decisions, nil
+		}
+		input.Marker = output.Marker
+	}
+}
+
+// principalARN returns the ARN of the IAM user or role behind a caller
+// identity. Assumed role sessions (arn:aws:sts::123456789012:assumed-role/name/session)
+// are simulated as their role; roles with a path are not supported, since the
+// path is not part of the session ARN.
+func principalARN(callerARN string) string {
+	parts := strings.SplitN(callerARN, ":", 6)
+	if len(parts) != 6 || parts[2] != "sts" || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return callerARN
+	}
+
+	role := strings.SplitN(strings.TrimPrefix(parts[5], "assumed-role/"), "/", 2)[0]
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", parts[1], parts[4], role)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// mockPolicySimulator allows the actions in allowed, on any resource, and
+// returns one evaluation result per page
+type mockPolicySimulator struct {
+	allowed map[string]bool
+	err     error
+	inputs  []iam.SimulatePrincipalPolicyInput
+}
+
+func (m *mockPolicySimulator) SimulatePrincipalPolicy(_ context.Context, params *iam.SimulatePrincipalPolicyInput, _ ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.inputs = append(m.inputs, *params)
+
+	page := 0
+	if params.Marker != nil {
+		fmt.Sscanf(*params.Marker, "%d", &page)
+	}
+	action := params.ActionNames[page]
+	decision := types.PolicyEvaluationDecisionTypeImplicitDeny
+	if m.allowed[action] {
+		decision = types.PolicyEvaluationDecisionTypeAllowed
+	}
+
+	output := &iam.SimulatePrincipalPolicyOutput{
+		EvaluationResults: []types.EvaluationResult{{EvalActionName: awssdk.String(action), EvalDecision: decision}},
+	}
+	if page+1 < len(params.ActionNames) {
+		output.IsTruncated = true
+		output.Marker = awssdk.String(fmt.Sprint(page + 1))
+	}
+	return output, nil
+}
+
+func TestCheckPermissions(t *testing.T) {
+	stsClient := &mockSTSClient{arn: awssdk.String("arn:aws:sts::123456789012:assumed-role/aws-monitor/i-0abc")}
+	simulator := &mockPolicySimulator{allowed: map[string]bool{
+		"sts:GetCallerIdentity": true,
+		"ec2:DescribeInstances": true,
+	}}
+
+	report, err := CheckPermissions(context.Background(), stsClient, simulator, []Permission{
+		{Action: "sts:GetCallerIdentity"},
+		{Action: "ec2:DescribeInstances"},
+		{Action: "cloudwatch:GetMetricData"},
+		{Action: "ec2:DescribeInstances"},
+		{Action: "sns:Publish", Resource: "arn:aws:sns:us-east-1:123456789012:alerts"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if report.Principal != "arn:aws:iam::123456789012:role/aws-monitor" {
+		t.Errorf("Expected the role of the session to be simulated, got %s", report.Principal)
+	}
+	expected := []Permission{
+		{Action: "cloudwatch:GetMetricData", Resource: "*"},
+		{Action: "sns:Publish", Resource: "arn:aws:sns:us-east-1:123456789012:alerts"},
+	}
+	if !reflect.DeepEqual(report.Missing, expected) {
+		t.Errorf("Expected missing %v, got %v", expected, report.Missing)
+	}
+
+	// Actions are simulated together per resource, without duplicates
+	if len(simulator.inputs) != 4 {
+		t.Errorf("Expected 4 simulation pages, got %d", len(simulator.inputs))
+	}
+	if actions := simulator.inputs[0].ActionNames; len(actions) != 3 {
+		t.Errorf("Expected 3 distinct actions on *, got %v", actions)
+	}
+
+	simulator.err = fmt.Errorf("AccessDenied")
+	if _, err := CheckPermissions(context.Background(), stsClient, simulator, expected); err == nil {
+		t.Error("Expected an error when simulation is denied")
+	}
+}
+
+func TestPrincipalARN(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:iam::123456789012:user/alice":                        "arn:aws:iam::123456789012:user/alice",
+		"arn:aws:sts::123456789012:assumed-role/monitor/session":      "arn:aws:iam::123456789012:role/monitor",
+		"arn:aws-cn:sts::123456789012:assumed-role/monitor/i-0abc123": "arn:aws-cn:iam::123456789012:role/monitor",
+		"arn:aws:sts::123456789012:federated-user/bob":                "arn:aws:sts::123456789012:federated-user/bob",
+	}
+	for caller, expected := range tests {
+		if got := principalARN(caller); got != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, caller, got)
+		}
+	}
+}
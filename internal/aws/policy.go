@@ -0,0 +1,111 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// globalServices are the IAM service prefixes whose APIs are not called in a
+// monitored region, so their actions are not restricted with
+// aws:RequestedRegion
+var globalServices = map[string]bool{
+	"cloudfront":    true,
+	"iam":           true,
+	"organizations": true,
+	"route53":       true,
+	"sts":           true,
+	"support":       true,
+}
+
+// PolicyDocument is an IAM policy document
+type PolicyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// PolicyStatement is a statement of an IAM policy document
+type PolicyStatement struct {
+	Sid       string                         `json:"Sid,omitempty"`
+	Effect    string                         `json:"Effect"`
+	Action    []string                       `json:"Action"`
+	Resource  string                         `json:"Resource"`
+	Condition map[string]map[string][]string `json:"Condition,omitempty"`
+}
+
+// NewPolicy returns the least-privilege IAM policy allowing the permissions.
+// Actions of regional services on any resource are only allowed in regions;
+// actions on specific resources are allowed on those resources only.
+func NewPolicy(permissions []Permission, regions []string) PolicyDocument {
+	var global, regional []string
+	byResource := make(map[string][]string)
+	for _, permission := range permissions {
+		switch {
+		case permission.Resource != "" && permission.Resource != "*":
+			byResource[permission.Resource] = appendUnique(byResource[permission.Resource], permission.Action)
+		case globalServices[actionService(permission.Action)]:
+			global = appendUnique(global, permission.Action)
+		default:
+			regional = appendUnique(regional, permission.Action)
+		}
+	}
+
+	policy := PolicyDocument{Version: "2012-10-17"}
+	if len(global) > 0 {
+		sort.Strings(global)
+		policy.Statement = append(policy.Statement, PolicyStatement{
+			Sid:      "Global",
+			Effect:   "Allow",
+			Action:   global,
+			Resource: "*",
+		})
+	}
+	if len(regional) > 0 {
+		sort.Strings(regional)
+		statement := PolicyStatement{
+			Sid:      "Regional",
+			Effect:   "Allow",
+			Action:   regional,
+			Resource: "*",
+		}
+		if len(regions) > 0 {
+			sorted := append([]string(nil), regions...)
+			sort.Strings(sorted)
+			statement.Condition = map[string]map[string][]string{
+				"StringEquals": {"aws:RequestedRegion": sorted},
+			}
+		}
+		policy.Statement = append(policy.Statement, statement)
+	}
+
+	resources := make([]string, 0, len(byResource))
+	for resource := range byResource {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for i, resource := range resources {
+		actions := byResource[resource]
+		sort.Strings(actions)
+		policy.Statement = append(policy.Statement, PolicyStatement{
+			Sid:      fmt.Sprintf("Resource%d", i+1),
+			Effect:   "Allow",
+			Action:   actions,
+			Resource: resource,
+		})
+	}
+	return policy
+}
+
+// actionService returns the service prefix of an IAM action, e.g. "ec2" for
+// "ec2:DescribeInstances"
+func actionService(action string) string {
+	service, _, _ := strings.Cut(action, ":")
+	return strings.ToLower(service)
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
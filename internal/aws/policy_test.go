@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewPolicy(t *testing.T) {
+	policy := NewPolicy([]Permission{
+		{Action: "sts:GetCallerIdentity"},
+		{Action: "ec2:DescribeInstances"},
+		{Action: "cloudwatch:GetMetricData"},
+		{Action: "ec2:DescribeInstances"},
+		{Action: "iam:ListAccountAliases"},
+		{Action: "sns:Publish", Resource: "arn:aws:sns:us-east-1:123456789012:alerts"},
+	}, []string{"us-west-2", "us-east-1"})
+
+	expected := []PolicyStatement{
+		{
+			Sid:      "Global",
+			Effect:   "Allow",
+			Action:   []string{"iam:ListAccountAliases", "sts:GetCallerIdentity"},
+			Resource: "*",
+		},
+		{
+			Sid:      "Regional",
+			Effect:   "Allow",
+			Action:   []string{"cloudwatch:GetMetricData", "ec2:DescribeInstances"},
+			Resource: "*",
+			Condition: map[string]map[string][]string{
+				"StringEquals": {"aws:RequestedRegion": {"us-east-1", "us-west-2"}},
+			},
+		},
+		{
+			Sid:      "Resource1",
+			Effect:   "Allow",
+			Action:   []string{"sns:Publish"},
+			Resource: "arn:aws:sns:us-east-1:123456789012:alerts",
+		},
+	}
+	if policy.Version != "2012-10-17" {
+		t.Errorf("Expected policy version 2012-10-17, got %s", policy.Version)
+	}
+	if !reflect.DeepEqual(policy.Statement, expected) {
+		t.Errorf("Expected statements %+v, got %+v", expected, policy.Statement)
+	}
+
+	if empty := NewPolicy(nil, []string{"us-east-1"}); len(empty.Statement) != 0 {
+		t.Errorf("Expected no statements without permissions, got %+v", empty.Statement)
+	}
+}
@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// DefaultQueueWaitTime is how long a receive waits for messages, the longest
+// SQS allows
+const DefaultQueueWaitTime = 20 * time.Second
+
+// queueRetryDelay is how long a consumer waits after a failed receive
+const queueRetryDelay = 5 * time.Second
+
+// queueMaxMessages is the number of messages received at once, the most SQS
+// allows
+const queueMaxMessages = 10
+
+// MessageHandler handles the body of a message received from a queue
+type MessageHandler func(ctx context.Context, messageID, body string)
+
+// QueueConsumer long-polls an SQS queue and hands each message to a handler.
+// Messages are deleted once handled, whatever the outcome, so a message the
+// handler cannot use is not received again.
+type QueueConsumer struct {
+	client   SQSClient
+	queueURL string
+	handle   MessageHandler
+	waitTime time.Duration
+	// retryDelay is how long the consumer waits after a failed receive
+	retryDelay time.Duration
+	logger     *logger.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewQueueConsumer creates a consumer of the queue at queueURL. Start starts
+// it.
+func NewQueueConsumer(client SQSClient, queueURL string, handle MessageHandler, log *logger.Logger) *QueueConsumer {
+	return &QueueConsumer{
+		client:     client,
+		queueURL:   queueURL,
+		handle:     handle,
+		waitTime:   DefaultQueueWaitTime,
+		retryDelay: queueRetryDelay,
+		logger:     log,
+	}
+}
+
+// SetWaitTime sets how long a receive waits for messages, up to 20s
+func (c *QueueConsumer) SetWaitTime(waitTime time.Duration) {
+	c.waitTime = waitTime
+}
+
+// Start consumes the queue in the background until Stop is called or ctx is
+// done. It reports whether the consumer was started, false if it already
+// runs.
+func (c *QueueConsumer) Start(ctx context.Context) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done != nil {
+		return false
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.run(ctx, c.done)
+	return true
+}
+
+// Stop stops consuming the queue and waits until ctx is done for the receive
+// in progress to return
+func (c *QueueConsumer) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *QueueConsumer) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for ctx.Err() == nil {
+		if err := c.poll(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Warn("Failed to receive queue messages",
+				logger.String("queue_url", c.queueURL),
+				logger.String("error", err.Error()))
+			select {
+			case <-ctx.Done():
+			case <-time.After(c.retryDelay):
+			}
+		}
+	}
+}
+
+// poll receives the messages waiting in the queue and handles them
+func (c *QueueConsumer) poll(ctx context.Context) error {
+	output, err := c.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            awssdk.String(c.queueURL),
+		MaxNumberOfMessages: queueMaxMessages,
+		WaitTimeSeconds:     int32(c.waitTime / time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, message := range output.Messages {
+		messageID := awssdk.ToString(message.MessageId)
+
+		// What a message starts outlives the receive, so stopping the
+		// consumer does not cancel it
+		c.handle(context.WithoutCancel(ctx), messageID, awssdk.ToString(message.Body))
+
+		if _, err := c.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      awssdk.String(c.queueURL),
+			ReceiptHandle: message.ReceiptHandle,
+		}); err != nil {
+			c.logger.Warn("Failed to delete queue message",
+				logger.String("queue_url", c.queueURL),
+				logger.String("message_id", messageID),
+				logger.String("error", err.Error()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// mockSQSClient fails the first receive, then returns the queued messages,
+// then waits for the context like an empty long poll
+type mockSQSClient struct {
+	mu       sync.Mutex
+	receives int
+	messages []types.Message
+	deleted  []string
+}
+
+func (m *mockSQSClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	m.mu.Lock()
+	m.receives++
+	receives, messages := m.receives, m.messages
+	if receives > 1 {
+		m.messages = nil
+	}
+	m.mu.Unlock()
+
+	switch {
+	case receives == 1:
+		return nil, fmt.Errorf("throttled")
+	case len(messages) > 0:
+		if params.MaxNumberOfMessages != 10 || params.WaitTimeSeconds != 1 {
+			return nil, fmt.Errorf("unexpected receive %d messages waiting %ds", params.MaxNumberOfMessages, params.WaitTimeSeconds)
+		}
+		return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+	default:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+}
+
+func (m *mockSQSClient) DeleteMessage(_ context.Context, params *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, awssdk.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestQueueConsumer(t *testing.T) {
+	client := &mockSQSClient{messages: []types.Message{
+		{MessageId: awssdk.String("1"), ReceiptHandle: awssdk.String("receipt-1"), Body: awssdk.String("first")},
+		{MessageId: awssdk.String("2"), ReceiptHandle: awssdk.String("receipt-2"), Body: awssdk.String("second")},
+	}}
+	log, _ := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+
+	var mu sync.Mutex
+	var handled []string
+	consumer := NewQueueConsumer(client, "queue", func(_ context.Context, messageID, body string) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, messageID+":"+body)
+	}, log)
+	consumer.SetWaitTime(time.Second)
+	consumer.retryDelay = 10 * time.Millisecond
+
+	if !consumer.Start(context.Background()) {
+		t.Fatal("Expected the consumer to start")
+	}
+	if consumer.Start(context.Background()) {
+		t.Error("Expected a second start to do nothing")
+	}
+
+	// The failed receive is retried after the retry delay
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		client.mu.Lock()
+		deleted := len(client.deleted)
+		client.mu.Unlock()
+		if deleted == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := consumer.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop consumer: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 2 || handled[0] != "1:first" || handled[1] != "2:second" {
+		t.Errorf("Expected both messages to be handled in order, got %v", handled)
+	}
+	if len(client.deleted) != 2 {
+		t.Errorf("Expected both messages to be deleted, got %v", client.deleted)
+	}
+}
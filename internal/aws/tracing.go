@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"context"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the AWS API call spans
+const tracerName = "aws-monitoring/internal/aws"
+
+// addTracing traces the API calls of a client made within a traced
+// operation, such as a collection job
+func addTracing(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AWSMonitorTracing", traceAPICall), middleware.After)
+}
+
+// traceAPICall records an API call, including its retries, as a client span
+// named after the service and operation, e.g. EC2.DescribeInstances
+func traceAPICall(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	// Calls outside a traced operation, such as health checks, would each
+	// start a trace of their own
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return next.HandleInitialize(ctx, in)
+	}
+
+	service := awsmiddleware.GetServiceID(ctx)
+	operation := awsmiddleware.GetOperationName(ctx)
+	ctx, span := otel.Tracer(tracerName).Start(ctx, service+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("aws-api"),
+			semconv.RPCService(service),
+			semconv.RPCMethod(operation),
+			semconv.CloudRegion(awsmiddleware.GetRegion(ctx))))
+	defer span.End()
+
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		span.SetAttributes(semconv.AWSRequestID(requestID))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return out, metadata, err
+}
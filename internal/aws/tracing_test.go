@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// callStack returns a handler running the tracing middleware after the
+// service metadata of an EC2 DescribeInstances call is registered, and
+// failing with err
+func callStack(t *testing.T, err error) middleware.Handler {
+	stack := middleware.NewStack("DescribeInstances", func() interface{} { return nil })
+	metadata := &awsmiddleware.RegisterServiceMetadata{ServiceID: "EC2", Region: "us-east-1", OperationName: "DescribeInstances"}
+	if err := stack.Initialize.Add(metadata, middleware.Before); err != nil {
+		t.Fatalf("Failed to add service metadata: %v", err)
+	}
+	if err := addTracing(stack); err != nil {
+		t.Fatalf("Failed to add tracing: %v", err)
+	}
+	return middleware.DecorateHandler(middleware.HandlerFunc(func(context.Context, interface{}) (interface{}, middleware.Metadata, error) {
+		return nil, middleware.Metadata{}, err
+	}), stack)
+}
+
+func TestTraceAPICall(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	// Calls outside a traced operation are not traced
+	if _, _, err := callStack(t, nil).Handle(context.Background(), nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Fatalf("Expected no span without a parent, got %d", len(spans))
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "collect")
+	if _, _, err := callStack(t, errors.New("throttled")).Handle(ctx, nil); err == nil {
+		t.Fatal("Expected the call's error")
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected an API call and a parent span, got %d spans", len(spans))
+	}
+	call := spans[0]
+	if call.Name != "EC2.DescribeInstances" {
+		t.Errorf("Expected span EC2.DescribeInstances, got %s", call.Name)
+	}
+	if call.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the API call span to be a child of the collection span")
+	}
+	if call.Status.Code != codes.Error {
+		t.Errorf("Expected an error status, got %v", call.Status)
+	}
+	attributes := make(map[string]string)
+	for _, attr := range call.Attributes {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attributes["rpc.service"] != "EC2" || attributes["cloud.region"] != "us-east-1" {
+		t.Errorf("Unexpected API call span attributes: %v", attributes)
+	}
+}
@@ -0,0 +1,140 @@
+package awsfake
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/logger"
+)
+
+// TestEndToEndCollection runs an exec collector, enriched from the EC2
+// inventory, through the scheduler to the dry run exporter, with the AWS
+// APIs served by the fake
+func TestEndToEndCollection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell script test on windows")
+	}
+
+	server := NewServer()
+	defer server.Close()
+	server.SetAccount("210987654321", "monitoring-prod")
+	server.SetInstances(Instance{ID: "i-0123456789abcdef0", Type: "m6i.large", Tags: map[string]string{"team": "payments"}})
+
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+	cfg.AWS.AccessKeyID = "test-key"
+	cfg.AWS.SecretAccessKey = "test-secret"
+	cfg.AWS.EndpointURL = server.URL
+	cfg.EnabledRegions = []string{"us-east-1"}
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	provider := aws.NewClientProvider(cfg, log)
+	defer provider.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stsClient, err := provider.GetSTSClient("us-east-1")
+	if err != nil {
+		t.Fatalf("Failed to create STS client: %v", err)
+	}
+	iamClient, err := provider.GetIAMClient("us-east-1")
+	if err != nil {
+		t.Fatalf("Failed to create IAM client: %v", err)
+	}
+	account, err := aws.ResolveAccountInfo(ctx, stsClient, iamClient, log)
+	if err != nil {
+		t.Fatalf("Failed to resolve account: %v", err)
+	}
+
+	script := filepath.Join(t.TempDir(), "queue-depth.sh")
+	content := "#!/bin/sh\necho '[{\"name\":\"queue_depth\",\"value\":42,\"unit\":\"Count\",\"labels\":{\"instance_id\":\"i-0123456789abcdef0\"}}]'\n"
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	collector, err := collectors.NewPluginCollector(config.PluginConfig{
+		Name:       "queue-depth",
+		Type:       collectors.ExecCollectorType,
+		Enabled:    true,
+		Settings:   map[string]string{"command": script},
+		Enrichment: config.EnrichmentConfig{Enabled: true, Tags: []string{"team"}},
+	}, collectors.CollectorDependencies{
+		Config:      cfg,
+		AWSProvider: provider,
+		Inventory:   aws.NewInventoryCache(provider, time.Minute, log),
+		Account:     account,
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create collector: %v", err)
+	}
+	registry := collectors.NewCollectorRegistry(log)
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+
+	var exported bytes.Buffer
+	schedulerConfig := scheduler.DefaultConfig()
+	schedulerConfig.TickInterval = 50 * time.Millisecond
+	metricScheduler := scheduler.NewMetricScheduler(schedulerConfig, registry, scheduler.NewDryRunProcessor(&exported, log), log)
+	if err := metricScheduler.ScheduleCollector("queue-depth", cfg.EnabledRegions, time.Hour); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	if err := metricScheduler.Start(ctx); err != nil {
+		t.Fatalf("Failed to start scheduler: %v", err)
+	}
+	for metricScheduler.GetInfo().CompletedJobs == 0 && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := metricScheduler.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop scheduler: %v", err)
+	}
+
+	// Stopping waited for the job, so the exporter is done writing
+	var metrics []scheduler.DryRunMetric
+	scanner := bufio.NewScanner(&exported)
+	for scanner.Scan() {
+		var metric scheduler.DryRunMetric
+		if err := json.Unmarshal(scanner.Bytes(), &metric); err != nil {
+			t.Fatalf("Failed to decode exported metric: %v", err)
+		}
+		metrics = append(metrics, metric)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("Expected 1 exported metric, got %d", len(metrics))
+	}
+	metric := metrics[0]
+	if metric.Name != "queue_depth" || metric.Value != 42 || metric.Region != "us-east-1" {
+		t.Errorf("Expected queue_depth=42 in us-east-1, got %+v", metric)
+	}
+	expected := map[string]string{
+		"account_id":        "210987654321",
+		"account_alias":     "monitoring-prod",
+		"instance_type":     "m6i.large",
+		"availability_zone": AvailabilityZone,
+		"resource_arn":      "arn:aws:ec2:us-east-1:210987654321:instance/i-0123456789abcdef0",
+		"tag_team":          "payments",
+	}
+	for k, v := range expected {
+		if metric.Labels[k] != v {
+			t.Errorf("Expected label %s=%s, got %q", k, v, metric.Labels[k])
+		}
+	}
+	if requests := server.Requests("DescribeInstances"); len(requests) != 1 {
+		t.Errorf("Expected the inventory to describe instances once, got %d", len(requests))
+	}
+}
@@ -0,0 +1,210 @@
+// Package awsfake serves canned AWS API responses over HTTP, so aws-monitor
+// can run end to end in tests, from the scheduler through collectors to the
+// exporter, without AWS credentials or network access. Tests point
+// aws.endpoint_url at the server's URL.
+//
+// The server speaks the query protocol of EC2, STS, IAM, SNS and RDS:
+// requests are routed by their Action parameter. It answers the calls
+// aws-monitor makes out of the box; Handle serves any other action, such as
+// DescribeDBInstances, with a canned response.
+package awsfake
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// ec2Version is the API version of EC2 requests
+const ec2Version = "2016-11-15"
+
+// requestID is the ID of every response
+const requestID = "00000000-0000-0000-0000-000000000000"
+
+const (
+	// AccountID is the account the server reports until SetAccount is called
+	AccountID = "123456789012"
+	// AvailabilityZone is where instances are placed unless they say otherwise
+	AvailabilityZone = "us-east-1a"
+)
+
+// Instance is an EC2 instance returned by DescribeInstances
+type Instance struct {
+	ID string
+	// Type defaults to t3.micro
+	Type string
+	// State defaults to running
+	State string
+	// AvailabilityZone defaults to us-east-1a
+	AvailabilityZone string
+	Tags             map[string]string
+}
+
+// Server is a fake AWS endpoint for all services
+type Server struct {
+	// URL is the endpoint to send AWS API requests to
+	URL string
+
+	server *httptest.Server
+
+	mu sync.Mutex
+	// responses are the canned response bodies, by action
+	responses map[string]string
+	// requests are the parameters of the requests received, by action
+	requests map[string][]url.Values
+}
+
+// NewServer starts a fake AWS endpoint with an account and no resources
+func NewServer() *Server {
+	s := &Server{
+		responses: make(map[string]string),
+		requests:  make(map[string][]url.Values),
+	}
+	s.SetAccount(AccountID, "")
+	s.SetInstances()
+	s.Handle("DescribeInstanceStatus", ec2Response("DescribeInstanceStatus", "<instanceStatusSet/>"))
+	s.Handle("Publish", queryResponse("Publish", "http://sns.amazonaws.com/doc/2010-03-31/",
+		"<MessageId>"+requestID+"</MessageId>"))
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Handle serves body, a complete XML response document, to the requests for
+// action, replacing any previous response
+func (s *Server) Handle(action, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[action] = body
+}
+
+// SetAccount sets the account reported by GetCallerIdentity and its alias,
+// reported by ListAccountAliases if not empty
+func (s *Server) SetAccount(id, alias string) {
+	s.Handle("GetCallerIdentity", queryResponse("GetCallerIdentity", "https://sts.amazonaws.com/doc/2011-06-15/",
+		fmt.Sprintf("<Arn>arn:aws:iam::%s:root</Arn><UserId>%s</UserId><Account>%s</Account>", id, id, id)))
+	aliases := "<AccountAliases/>"
+	if alias != "" {
+		aliases = "<AccountAliases><member>" + escape(alias) + "</member></AccountAliases>"
+	}
+	s.Handle("ListAccountAliases", queryResponse("ListAccountAliases", "https://iam.amazonaws.com/doc/2010-05-08/",
+		aliases+"<IsTruncated>false</IsTruncated>"))
+}
+
+// SetInstances sets the instances DescribeInstances returns, in one
+// reservation
+func (s *Server) SetInstances(instances ...Instance) {
+	var b bytes.Buffer
+	b.WriteString("<reservationSet>")
+	if len(instances) > 0 {
+		b.WriteString("<item><reservationId>r-00000000000000000</reservationId><instancesSet>")
+		for _, instance := range instances {
+			writeInstance(&b, instance)
+		}
+		b.WriteString("</instancesSet></item>")
+	}
+	b.WriteString("</reservationSet>")
+	s.Handle("DescribeInstances", ec2Response("DescribeInstances", b.String()))
+}
+
+// Requests returns the parameters of the requests received for action, in
+// the order they were received
+func (s *Server) Requests(action string) []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.requests[action]...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := r.PostForm.Get("Action")
+
+	s.mu.Lock()
+	s.requests[action] = append(s.requests[action], r.PostForm)
+	body, exists := s.responses[action]
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/xml")
+	if !exists {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, errorResponse(r.PostForm.Get("Version"), "InvalidAction", action+" is not served by the fake"))
+		return
+	}
+	fmt.Fprint(w, body)
+}
+
+// errorResponse returns an error document in the shape the service of the
+// API version parses: EC2 has its own
+func errorResponse(version, code, message string) string {
+	if version == ec2Version {
+		return fmt.Sprintf("<Response><Errors><Error><Code>%s</Code><Message>%s</Message></Error></Errors><RequestID>%s</RequestID></Response>",
+			code, escape(message), requestID)
+	}
+	return fmt.Sprintf("<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error><RequestId>%s</RequestId></ErrorResponse>",
+		code, escape(message), requestID)
+}
+
+// ec2Response returns an EC2 response document with the given content
+func ec2Response(action, content string) string {
+	return fmt.Sprintf(`<%sResponse xmlns="http://ec2.amazonaws.com/doc/%s/"><requestId>%s</requestId>%s</%sResponse>`,
+		action, ec2Version, requestID, content, action)
+}
+
+// queryResponse returns the response document of the STS, IAM, SNS and RDS
+// query protocol, with the given result content
+func queryResponse(action, namespace, result string) string {
+	return fmt.Sprintf(`<%sResponse xmlns="%s"><%sResult>%s</%sResult><ResponseMetadata><RequestId>%s</RequestId></ResponseMetadata></%sResponse>`,
+		action, namespace, action, result, action, requestID, action)
+}
+
+// writeInstance writes the item describing an instance
+func writeInstance(b *bytes.Buffer, instance Instance) {
+	instanceType, state, zone := instance.Type, instance.State, instance.AvailabilityZone
+	if instanceType == "" {
+		instanceType = "t3.micro"
+	}
+	if state == "" {
+		state = "running"
+	}
+	if zone == "" {
+		zone = AvailabilityZone
+	}
+
+	fmt.Fprintf(b, "<item><instanceId>%s</instanceId><instanceType>%s</instanceType>", escape(instance.ID), escape(instanceType))
+	fmt.Fprintf(b, "<instanceState><name>%s</name></instanceState>", escape(state))
+	fmt.Fprintf(b, "<placement><availabilityZone>%s</availabilityZone></placement>", escape(zone))
+	if len(instance.Tags) > 0 {
+		keys := make([]string, 0, len(instance.Tags))
+		for key := range instance.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("<tagSet>")
+		for _, key := range keys {
+			fmt.Fprintf(b, "<item><key>%s</key><value>%s</value></item>", escape(key), escape(instance.Tags[key]))
+		}
+		b.WriteString("</tagSet>")
+	}
+	b.WriteString("</item>")
+}
+
+// escape escapes text for XML
+func escape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package awsfake
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+// newProvider returns a client provider sending requests to the server
+func newProvider(t *testing.T, server *Server) aws.ClientProvider {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{
+			AccessKeyID:     "test-key",
+			SecretAccessKey: "test-secret",
+			DefaultRegion:   "us-east-1",
+			MaxRetries:      1,
+			Timeout:         config.Duration(10 * time.Second),
+			EndpointURL:     server.URL,
+		},
+	}
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return aws.NewClientProvider(cfg, log)
+}
+
+func TestServerDescribeInstances(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	provider := newProvider(t, server)
+
+	client, err := provider.GetEC2Client("us-east-1")
+	if err != nil {
+		t.Fatalf("Failed to create EC2 client: %v", err)
+	}
+	out, err := client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(out.Reservations) != 0 {
+		t.Errorf("Expected no instances by default, got %d reservations", len(out.Reservations))
+	}
+
+	server.SetInstances(
+		Instance{ID: "i-0123456789abcdef0", Tags: map[string]string{"team": "payments & billing"}},
+		Instance{ID: "i-0fedcba9876543210", Type: "m6i.large", State: "stopped", AvailabilityZone: "us-east-1b"},
+	)
+	out, err = client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(out.Reservations) != 1 || len(out.Reservations[0].Instances) != 2 {
+		t.Fatalf("Expected 2 instances in 1 reservation, got %+v", out.Reservations)
+	}
+	first, second := out.Reservations[0].Instances[0], out.Reservations[0].Instances[1]
+	if first.InstanceType != "t3.micro" || awssdk.ToString(first.Placement.AvailabilityZone) != AvailabilityZone {
+		t.Errorf("Expected the default type and zone, got %s in %s", first.InstanceType, awssdk.ToString(first.Placement.AvailabilityZone))
+	}
+	if len(first.Tags) != 1 || awssdk.ToString(first.Tags[0].Value) != "payments & billing" {
+		t.Errorf("Expected the escaped tag to round-trip, got %+v", first.Tags)
+	}
+	if second.State == nil || second.State.Name != "stopped" || second.InstanceType != "m6i.large" {
+		t.Errorf("Expected the stopped m6i.large instance, got %+v", second)
+	}
+	if requests := server.Requests("DescribeInstances"); len(requests) != 2 {
+		t.Errorf("Expected 2 DescribeInstances requests, got %d", len(requests))
+	}
+}
+
+func TestServerAccount(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetAccount("210987654321", "monitoring-prod")
+	provider := newProvider(t, server)
+
+	stsClient, err := provider.GetSTSClient("us-east-1")
+	if err != nil {
+		t.Fatalf("Failed to create STS client: %v", err)
+	}
+	iamClient, err := provider.GetIAMClient("us-east-1")
+	if err != nil {
+		t.Fatalf("Failed to create IAM client: %v", err)
+	}
+	log, _ := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	account, err := aws.ResolveAccountInfo(context.Background(), stsClient, iamClient, log)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if account.ID != "210987654321" || account.Alias != "monitoring-prod" {
+		t.Errorf("Expected the configured account, got %+v", account)
+	}
+}
+
+func TestServerUnknownAction(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: awssdk.String(server.URL),
+		Credentials:  awssdk.AnonymousCredentials{},
+	})
+	if _, err := client.GetSessionToken(context.Background(), &sts.GetSessionTokenInput{}); err == nil || !strings.Contains(err.Error(), "InvalidAction") {
+		t.Errorf("Expected an InvalidAction error for an action not served, got %v", err)
+	}
+
+	// Any action can be served with a canned response
+	server.Handle("DescribeDBInstances", queryResponse("DescribeDBInstances", "http://rds.amazonaws.com/doc/2014-10-31/", "<DBInstances/>"))
+	resp, err := http.PostForm(server.URL, url.Values{"Action": {"DescribeDBInstances"}, "Version": {"2014-10-31"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the canned response, got status %d", resp.StatusCode)
+	}
+	if requests := server.Requests("DescribeDBInstances"); len(requests) != 1 || requests[0].Get("Version") != "2014-10-31" {
+		t.Errorf("Expected the request parameters to be recorded, got %v", requests)
+	}
+}
@@ -2,15 +2,25 @@ package collectors
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"aws-monitoring/internal/aws"
 	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/tracing"
 	"aws-monitoring/pkg/errors"
 	"aws-monitoring/pkg/logger"
 )
 
+// tracerName is the instrumentation scope of the collection spans
+const tracerName = "aws-monitoring/internal/collectors"
+
 // BaseCollector provides common functionality for all metric collectors
 type BaseCollector struct {
 	// name is the unique identifier for this collector
@@ -23,10 +33,35 @@ type BaseCollector struct {
 	collectorConfig CollectorConfig
 	// awsProvider provides AWS client access
 	awsProvider aws.ClientProvider
+	// inventory is the shared resource inventory cache, if configured
+	inventory *aws.InventoryCache
+	// account identifies the monitored AWS account, if resolved
+	account aws.AccountInfo
 	// logger for structured logging
 	logger *logger.Logger
 	// errorHandler handles and processes errors
 	errorHandler ErrorHandler
+	// slo tracks collection success against the SLO target, if configured
+	slo *SLOTracker
+	// regionSLO tracks collection success in each region the collector
+	// collected, when the SLO is configured
+	regionSLO map[string]*SLOTracker
+	// recentErrors keeps the latest collection errors for the recent errors API
+	recentErrors *recentErrors
+	// events receives the events the collector emits, if set
+	events EventEmitter
+	// state persists the collector's state across restarts, if set
+	state StateStore
+	// checkpoints tracks the last exported datapoint of each metric
+	checkpoints bool
+	// memory scales batch sizes to the memory pressure, if set
+	memory BatchScaler
+	// platformLabels identify the platform aws-monitor runs on, such as its
+	// Kubernetes pod, if detected
+	platformLabels map[string]string
+	// commonLabels are the labels added to every metric, built once rather
+	// than for every metric created
+	commonLabels map[string]string
 	
 	// State management
 	mu                    sync.RWMutex
@@ -53,7 +88,12 @@ func NewBaseCollector(
 ) *BaseCollector {
 	ctx, cancel := context.WithCancel(context.Background())
 	
-	return &BaseCollector{
+	recentErrorLimit := DefaultRecentErrorLimit
+	if config != nil && config.Global.RecentErrorLimit > 0 {
+		recentErrorLimit = config.Global.RecentErrorLimit
+	}
+	
+	bc := &BaseCollector{
 		name:            name,
 		description:     description,
 		config:          config,
@@ -63,8 +103,12 @@ func NewBaseCollector(
 		status:          StatusStopped,
 		ctx:             ctx,
 		cancel:          cancel,
-		errorHandler:    NewDefaultErrorHandler(logger),
+		errorHandler:    NewCircuitBreakerErrorHandler(logger),
+		slo:             NewSLOTracker(collectorConfig.SLO),
+		recentErrors:    newRecentErrors(recentErrorLimit),
 	}
+	bc.commonLabels = bc.buildCommonLabels()
+	return bc
 }
 
 // Name returns the collector name
@@ -143,12 +187,65 @@ func (bc *BaseCollector) Info() CollectorInfo {
 		Status:                bc.status,
 		EnabledRegions:        bc.getEnabledRegions(),
 		Interval:              bc.collectorConfig.Interval,
+		RegionIntervals:       bc.collectorConfig.RegionIntervals,
+		Groups:                bc.collectorConfig.Groups,
+		Priority:              bc.collectorConfig.Priority,
 		LastCollection:        bc.lastCollection,
 		LastError:             bc.lastError,
 		MetricsCollected:      bc.metricsCollected,
 		ErrorCount:            bc.errorCount,
 		SuccessfulCollections: bc.successfulCollections,
+		CircuitBreakers:       bc.circuitBreakerStates(),
+		SLO:                   bc.sloInfo(),
+	}
+}
+
+// errorMetrics returns a counter metric for every error type and code the error
+// handler has seen for this collector in a region
+func (bc *BaseCollector) errorMetrics(region string) []MetricData {
+	counter, ok := bc.errorHandler.(ErrorCounter)
+	if !ok {
+		return nil
+	}
+
+	counts := counter.ErrorCounts(bc.name)
+	metrics := make([]MetricData, 0, len(counts))
+	for _, count := range counts {
+		if count.Region != region {
+			continue
+		}
+		metrics = append(metrics, bc.CreateMetricWithDescription(ErrorsTotalMetric, float64(count.Count), "Count",
+			"Total number of collector errors", map[string]string{
+				"region":     count.Region,
+				"error_type": string(count.Type),
+				"error_code": count.Code,
+			}))
+	}
+
+	return metrics
+}
+
+// sloInfo returns the collector's SLO status, or nil if SLO tracking is disabled
+func (bc *BaseCollector) sloInfo() *SLOInfo {
+	if bc.slo == nil {
+		return nil
 	}
+	return bc.slo.Info()
+}
+
+// circuitBreakerStates returns the collector's circuit breaker status by
+// region, or nil if the error handler has no circuit breakers
+func (bc *BaseCollector) circuitBreakerStates() map[string]CircuitBreakerStatus {
+	breaker, ok := bc.errorHandler.(CircuitBreaker)
+	if !ok {
+		return nil
+	}
+
+	states := breaker.States(bc.name)
+	if len(states) == 0 {
+		return nil
+	}
+	return states
 }
 
 // Health returns the health status of the collector
@@ -158,15 +255,20 @@ func (bc *BaseCollector) Health() error {
 	
 	switch bc.status {
 	case StatusRunning:
+		// Check if the error budget is burning too fast
+		if bc.slo != nil && bc.slo.Burning() {
+			return errors.NewValidationError(errors.CodeSLOBurnRateHigh,
+				"collector is burning its error budget faster than the threshold")
+		}
 		// Check if we've had successful collections recently
-		if bc.lastCollection != nil && time.Since(*bc.lastCollection) < 2*bc.collectorConfig.Interval {
+		if bc.lastCollection != nil && time.Since(*bc.lastCollection) < 2*bc.longestInterval() {
 			return nil
 		}
 		// Check error rate
 		if bc.successfulCollections > 0 {
 			errorRate := float64(bc.errorCount) / float64(bc.successfulCollections+bc.errorCount)
 			if errorRate > 0.5 { // More than 50% error rate
-				return errors.NewValidationError("HIGH_ERROR_RATE", 
+				return errors.NewValidationError(errors.CodeHighErrorRate, 
 					"collector has high error rate")
 			}
 		}
@@ -174,19 +276,40 @@ func (bc *BaseCollector) Health() error {
 	case StatusError:
 		return bc.lastError
 	case StatusStopped:
-		return errors.NewValidationError("COLLECTOR_STOPPED", "collector is stopped")
+		return errors.NewValidationError(errors.CodeCollectorStopped, "collector is stopped")
 	default:
-		return errors.NewValidationError("COLLECTOR_NOT_READY", "collector is not ready")
+		return errors.NewValidationError(errors.CodeCollectorNotReady, "collector is not ready")
 	}
 }
 
-// CreateMetric creates a standardized metric data point
+// CreateMetric creates a standardized metric data point stamped with the current time
 func (bc *BaseCollector) CreateMetric(name string, value float64, unit string, labels map[string]string) MetricData {
-	// Add common labels
+	return bc.CreateMetricAt(name, value, unit, time.Time{}, labels)
+}
+
+// CreateMetricAt creates a standardized metric data point stamped with the
+// time the measurement was taken upstream (e.g. a CloudWatch datapoint
+// timestamp), so exported series line up with when the value was observed
+// rather than when it was collected. A zero timestamp means the current time.
+// The metric takes over labels, which must not be shared with another metric;
+// collectors can take them from AcquireLabels. The name, unit and label values
+// are interned, so the many metrics of a collection share their copies.
+func (bc *BaseCollector) CreateMetricAt(name string, value float64, unit string, timestamp time.Time, labels map[string]string) MetricData {
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	// Add common labels, which are interned once
 	commonLabels := bc.getCommonLabels()
 	if labels == nil {
-		labels = commonLabels
+		// Every metric owns its labels, since enrichment adds to them and
+		// they are reused once the collection is processed
+		labels = AcquireLabels(len(commonLabels))
+		for k, v := range commonLabels {
+			labels[k] = v
+		}
 	} else {
+		InternLabels(labels)
 		// Merge labels (specific labels override common ones)
 		for k, v := range commonLabels {
 			if _, exists := labels[k]; !exists {
@@ -196,10 +319,10 @@ func (bc *BaseCollector) CreateMetric(name string, value float64, unit string, l
 	}
 	
 	return MetricData{
-		Name:      name,
+		Name:      Intern(name),
 		Value:     value,
-		Unit:      unit,
-		Timestamp: time.Now(),
+		Unit:      Intern(unit),
+		Timestamp: timestamp,
 		Labels:    labels,
 	}
 }
@@ -223,12 +346,40 @@ func (bc *BaseCollector) CollectWithRetry(ctx context.Context, region string, co
 		Metadata:       make(map[string]interface{}),
 	}
 	
+	// The AWS API calls of the collection are traced as children of this span
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "collect",
+		trace.WithAttributes(
+			tracing.AttrCollector.String(bc.name),
+			tracing.AttrRegion.String(region)))
+	defer func() {
+		span.SetAttributes(tracing.AttrMetricCount.Int(len(result.Metrics)))
+		if result.Error != nil {
+			span.RecordError(result.Error)
+			span.SetStatus(codes.Error, result.Error.Error())
+		}
+		span.End()
+	}()
+	
+	// Skip the collection while the circuit breaker for this region is open
+	breaker, hasBreaker := bc.errorHandler.(CircuitBreaker)
+	if hasBreaker && !breaker.Allow(bc.name, region) {
+		result.Error = errors.WithSeverity(errors.WithRegion(errors.New(errors.ErrorTypeInternal, errors.CodeCircuitOpen,
+			"circuit breaker open, skipping collection"), region), errors.SeverityLow)
+		result.Metadata["circuit_breaker"] = CircuitBreakerOpen
+		result.Duration = time.Since(start)
+		return result
+	}
+	
 	var lastErr *errors.Error
+	// succeeded is set once an attempt succeeds, even with no metrics, so
+	// that the failures of earlier attempts aren't recorded
+	succeeded := false
+	log := bc.logger.ForContext(ctx)
 	
 	for attempt := 0; attempt < bc.collectorConfig.Retries+1; attempt++ {
 		// Check if context is cancelled
 		if ctx.Err() != nil {
-			result.Error = errors.Wrap(ctx.Err(), errors.ErrorTypeInternal, "CONTEXT_CANCELLED", "collection cancelled")
+			result.Error = errors.Wrap(ctx.Err(), errors.ErrorTypeInternal, errors.CodeContextCancelled, "collection cancelled")
 			break
 		}
 		
@@ -240,19 +391,32 @@ func (bc *BaseCollector) CollectWithRetry(ctx context.Context, region string, co
 		
 		if err == nil {
 			// Success
-			result.Metrics = metrics
-			bc.recordSuccess()
+			enriched, warning := bc.enrichMetrics(ctx, region, metrics)
+			if warning != nil {
+				result.Warnings = append(result.Warnings, warning)
+			}
+			result.Metrics = enriched
+			succeeded = true
+			bc.recordSuccess(region)
+			if hasBreaker {
+				breaker.RecordSuccess(bc.name, region)
+			}
 			break
 		}
 		
 		// Handle error
 		if e, ok := err.(*errors.Error); ok {
 			lastErr = e
+		} else if classified := errors.ClassifyAWSError(err); classified != nil {
+			lastErr = classified
 		} else {
-			lastErr = errors.Wrap(err, errors.ErrorTypeInternal, "COLLECTION_ERROR", "collection failed")
+			lastErr = errors.Wrap(err, errors.ErrorTypeInternal, errors.CodeCollectionError, "collection failed")
 		}
 		
 		lastErr = errors.WithRegion(errors.WithOperation(lastErr, "collect"), region)
+		span.AddEvent("attempt failed", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.String("error", lastErr.Error())))
 		
 		// Check if we should retry
 		if !bc.errorHandler.ShouldRetry(lastErr, attempt) {
@@ -262,7 +426,19 @@ func (bc *BaseCollector) CollectWithRetry(ctx context.Context, region string, co
 		// Wait before retry (unless it's the last attempt)
 		if attempt < bc.collectorConfig.Retries {
 			retryDelay := bc.errorHandler.GetRetryDelay(lastErr, attempt)
-			bc.logger.Warn("Collection failed, retrying",
+			
+			// A server-suggested delay can outlast the collection; report the
+			// error now rather than waiting to be cancelled
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < retryDelay {
+				log.Warn("Retry delay exceeds collection deadline, not retrying",
+					logger.String("collector", bc.name),
+					logger.String("region", region),
+					logger.Duration("retry_delay", retryDelay),
+					logger.String("error", lastErr.Error()))
+				break
+			}
+			
+			log.Warn("Collection failed, retrying",
 				logger.String("collector", bc.name),
 				logger.String("region", region),
 				logger.Int("attempt", attempt+1),
@@ -273,22 +449,25 @@ func (bc *BaseCollector) CollectWithRetry(ctx context.Context, region string, co
 			case <-time.After(retryDelay):
 				// Continue to retry
 			case <-ctx.Done():
-				result.Error = errors.Wrap(ctx.Err(), errors.ErrorTypeInternal, "CONTEXT_CANCELLED", "collection cancelled during retry")
-				bc.recordError(result.Error)
+				result.Error = errors.Wrap(ctx.Err(), errors.ErrorTypeInternal, errors.CodeContextCancelled, "collection cancelled during retry")
+				bc.recordError(region, result.Error)
 				result.Duration = time.Since(start)
 				return result
 			}
 		}
 	}
 	
-	if lastErr != nil && len(result.Metrics) == 0 {
+	if !succeeded && lastErr != nil {
 		result.Error = lastErr
-		bc.recordError(lastErr)
+		bc.recordError(region, lastErr)
 		bc.errorHandler.HandleError(bc.name, lastErr)
 	}
 	
 	result.Duration = time.Since(start)
 	bc.recordCollection()
+	result.Metrics = append(result.Metrics, bc.sloMetrics(region)...)
+	result.Metrics = append(result.Metrics, bc.errorMetrics(region)...)
+	result.Metrics = bc.filterMetrics(result.Metrics)
 	
 	// Add collection metadata
 	result.Metadata["attempts"] = len(result.Warnings) + 1
@@ -300,23 +479,172 @@ func (bc *BaseCollector) CollectWithRetry(ctx context.Context, region string, co
 	return result
 }
 
+// CollectWithBudget performs collection with retry logic, handing collectFunc a
+// Budget that splits the attempt timeout across its phases. Phases that run out
+// of time are reported as warnings on the result alongside the partial metrics.
+func (bc *BaseCollector) CollectWithBudget(ctx context.Context, region string, collectFunc func(ctx context.Context, region string, budget *Budget) ([]MetricData, error)) *CollectionResult {
+	var budget *Budget
+
+	result := bc.CollectWithRetry(ctx, region, func(ctx context.Context, region string) ([]MetricData, error) {
+		budget = NewBudget(ctx)
+		return collectFunc(ctx, region, budget)
+	})
+
+	if budget != nil {
+		for _, warning := range budget.Warnings() {
+			result.Warnings = append(result.Warnings, errors.WithRegion(warning, region))
+		}
+		result.Metadata["phases"] = budget.Phases()
+		if len(budget.Warnings()) > 0 {
+			result.Metadata["partial"] = true
+			bc.logger.ForContext(ctx).Warn("Collection budget exhausted, returning partial results",
+				logger.String("collector", bc.name),
+				logger.String("region", region),
+				logger.Int("metric_count", len(result.Metrics)))
+		}
+	}
+
+	return result
+}
+
+// CollectAllRegions runs collectFunc across all enabled regions, at most
+// MaxConcurrentRegions at a time, and merges the per-region results. Regions
+// that fail are reported as warnings; the merged result only carries an error
+// when every region failed.
+func (bc *BaseCollector) CollectAllRegions(ctx context.Context, collectFunc func(ctx context.Context, region string) ([]MetricData, error)) *CollectionResult {
+	start := time.Now()
+	regions := bc.getEnabledRegions()
+
+	limit := bc.collectorConfig.MaxConcurrentRegions
+	if limit <= 0 || limit > len(regions) {
+		limit = len(regions)
+	}
+
+	results := make([]*CollectionResult, len(regions))
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				results[i] = &CollectionResult{
+					CollectorName:  bc.name,
+					Region:         region,
+					CollectionTime: time.Now(),
+					Error: errors.WithRegion(errors.Wrap(ctx.Err(), errors.ErrorTypeInternal,
+						errors.CodeContextCancelled, "collection cancelled before start"), region),
+				}
+				return
+			}
+
+			results[i] = bc.CollectWithRetry(ctx, region, collectFunc)
+		}(i, region)
+	}
+
+	wg.Wait()
+
+	return bc.mergeRegionResults(start, results)
+}
+
+// mergeRegionResults combines per-region collection results into a single result
+func (bc *BaseCollector) mergeRegionResults(start time.Time, results []*CollectionResult) *CollectionResult {
+	merged := &CollectionResult{
+		CollectorName:  bc.name,
+		Region:         AllRegions,
+		CollectionTime: start,
+		Metrics:        []MetricData{},
+		Warnings:       []*errors.Error{},
+		Metadata:       make(map[string]interface{}),
+	}
+
+	failedRegions := []string{}
+	regionErrors := errors.NewMultiError()
+
+	for _, result := range results {
+		merged.Metrics = append(merged.Metrics, result.Metrics...)
+		merged.Warnings = append(merged.Warnings, result.Warnings...)
+
+		if result.Error != nil {
+			failedRegions = append(failedRegions, result.Region)
+			regionErrors.Add(result.Error)
+			merged.Warnings = append(merged.Warnings, result.Error)
+		}
+	}
+
+	if len(results) > 0 && len(failedRegions) == len(results) {
+		merged.Error = errors.Wrap(regionErrors, errors.ErrorTypeInternal, errors.CodeAllRegionsFailed,
+			fmt.Sprintf("collection failed in all %d regions", len(results)))
+	}
+
+	merged.Duration = time.Since(start)
+	merged.Metadata["regions"] = len(results)
+	merged.Metadata["failed_regions"] = failedRegions
+	merged.Metadata["metric_count"] = len(merged.Metrics)
+
+	return merged
+}
+
+// ResourceCap applies the MaxResources cap to total resources found in a region.
+// It returns how many resources should be processed and, when the list is
+// truncated, a warning metric that the caller should include in its results.
+func (bc *BaseCollector) ResourceCap(region string, total int) (int, *MetricData) {
+	limit := bc.collectorConfig.MaxResources
+	if limit <= 0 || total <= limit {
+		return total, nil
+	}
+
+	skipped := total - limit
+	bc.logger.Warn("Resource cap reached, truncating collection",
+		logger.String("collector", bc.name),
+		logger.String("region", region),
+		logger.Int("resources", total),
+		logger.Int("max_resources", limit))
+
+	metric := bc.CreateMetricWithDescription(ResourcesTruncatedMetric, float64(skipped), "Count",
+		"Resources skipped because the collector reached its max_resources cap",
+		map[string]string{
+			"region":        region,
+			"max_resources": fmt.Sprintf("%d", limit),
+		})
+
+	return limit, &metric
+}
+
 // Helper methods
 
+// longestInterval returns the longest interval the collector runs at in any
+// region
+func (bc *BaseCollector) longestInterval() time.Duration {
+	longest := bc.collectorConfig.Interval
+	for _, interval := range bc.collectorConfig.RegionIntervals {
+		if interval > longest {
+			longest = interval
+		}
+	}
+	return longest
+}
+
 func (bc *BaseCollector) validateConfig() *errors.Error {
 	if bc.collectorConfig.Interval <= 0 {
-		return errors.NewConfigError("INVALID_INTERVAL", "collection interval must be positive")
+		return errors.NewConfigError(errors.CodeInvalidInterval, "collection interval must be positive")
 	}
 	
 	if bc.collectorConfig.Timeout <= 0 {
-		return errors.NewConfigError("INVALID_TIMEOUT", "collection timeout must be positive")
+		return errors.NewConfigError(errors.CodeInvalidTimeout, "collection timeout must be positive")
 	}
 	
 	if bc.collectorConfig.Retries < 0 {
-		return errors.NewConfigError("INVALID_RETRIES", "retries must be non-negative")
+		return errors.NewConfigError(errors.CodeInvalidRetries, "retries must be non-negative")
 	}
 	
 	if len(bc.getEnabledRegions()) == 0 {
-		return errors.NewConfigError("NO_REGIONS", "no regions enabled for collection")
+		return errors.NewConfigError(errors.CodeNoRegions, "no regions enabled for collection")
 	}
 	
 	return nil
@@ -329,31 +657,76 @@ func (bc *BaseCollector) getEnabledRegions() []string {
 	return bc.config.EnabledRegions
 }
 
+// getCommonLabels returns the labels added to every metric. The map is shared
+// and must not be modified.
 func (bc *BaseCollector) getCommonLabels() map[string]string {
+	if bc.commonLabels != nil {
+		return bc.commonLabels
+	}
+	return bc.buildCommonLabels()
+}
+
+func (bc *BaseCollector) buildCommonLabels() map[string]string {
 	labels := map[string]string{
 		"collector": bc.name,
 		"service":   "aws-monitor",
 	}
 	
+	// Add the account identity so multi-account dashboards can group by it
+	if bc.account.ID != "" {
+		labels["account_id"] = bc.account.ID
+	}
+	if bc.account.Alias != "" {
+		labels["account_alias"] = bc.account.Alias
+	}
+	
+	// Add where aws-monitor runs; custom tags can override it
+	for k, v := range bc.platformLabels {
+		labels[k] = v
+	}
+	
 	// Add custom tags from configuration
 	for k, v := range bc.collectorConfig.CustomTags {
 		labels[k] = v
 	}
 	
+	InternLabels(labels)
 	return labels
 }
 
-func (bc *BaseCollector) recordSuccess() {
+func (bc *BaseCollector) recordSuccess(region string) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 	bc.successfulCollections++
+	bc.recordSLO(region, true)
 }
 
-func (bc *BaseCollector) recordError(err *errors.Error) {
+func (bc *BaseCollector) recordError(region string, err *errors.Error) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 	bc.errorCount++
 	bc.lastError = err
+	bc.recentErrors.add(NewRecentError(err))
+	bc.recordSLO(region, false)
+}
+
+// recordSLO records the outcome of a collection in region against the
+// collector's SLO and the region's. bc.mu must be held.
+func (bc *BaseCollector) recordSLO(region string, success bool) {
+	if bc.slo == nil {
+		return
+	}
+	bc.slo.Record(success)
+	
+	tracker, exists := bc.regionSLO[region]
+	if !exists {
+		if bc.regionSLO == nil {
+			bc.regionSLO = make(map[string]*SLOTracker)
+		}
+		tracker = NewSLOTracker(bc.collectorConfig.SLO)
+		bc.regionSLO[region] = tracker
+	}
+	tracker.Record(success)
 }
 
 func (bc *BaseCollector) recordCollection() {
@@ -364,16 +737,67 @@ func (bc *BaseCollector) recordCollection() {
 	bc.metricsCollected++
 }
 
+// RecentErrors returns the collector's most recent errors, newest first
+func (bc *BaseCollector) RecentErrors() []RecentError {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.recentErrors.list()
+}
+
 // SetErrorHandler allows customizing error handling
 func (bc *BaseCollector) SetErrorHandler(handler ErrorHandler) {
 	bc.errorHandler = handler
 }
 
+// SetAlertNotifier sets where the error handler sends alerts for high and
+// critical severity errors
+func (bc *BaseCollector) SetAlertNotifier(alerts AlertNotifier) {
+	if handler, ok := bc.errorHandler.(interface{ SetAlertNotifier(AlertNotifier) }); ok {
+		handler.SetAlertNotifier(alerts)
+	}
+}
+
+// SetErrorReporter sets the error tracker the error handler reports critical
+// errors to
+func (bc *BaseCollector) SetErrorReporter(reporter ErrorReporter) {
+	if handler, ok := bc.errorHandler.(interface{ SetErrorReporter(ErrorReporter) }); ok {
+		handler.SetErrorReporter(reporter)
+	}
+}
+
 // GetAWSProvider returns the AWS provider for subclasses
 func (bc *BaseCollector) GetAWSProvider() aws.ClientProvider {
 	return bc.awsProvider
 }
 
+// SetInventoryCache sets the shared resource inventory cache
+func (bc *BaseCollector) SetInventoryCache(inventory *aws.InventoryCache) {
+	bc.inventory = inventory
+}
+
+// GetInventoryCache returns the shared resource inventory cache, or nil if none is set
+func (bc *BaseCollector) GetInventoryCache() *aws.InventoryCache {
+	return bc.inventory
+}
+
+// SetAccountInfo sets the AWS account identity added to every metric's labels
+func (bc *BaseCollector) SetAccountInfo(account aws.AccountInfo) {
+	bc.account = account
+	bc.commonLabels = bc.buildCommonLabels()
+}
+
+// SetPlatformLabels sets the labels identifying the platform aws-monitor runs
+// on, added to every metric's labels
+func (bc *BaseCollector) SetPlatformLabels(labels map[string]string) {
+	bc.platformLabels = labels
+	bc.commonLabels = bc.buildCommonLabels()
+}
+
+// GetAccountInfo returns the AWS account identity, empty if not resolved
+func (bc *BaseCollector) GetAccountInfo() aws.AccountInfo {
+	return bc.account
+}
+
 // GetConfig returns the application configuration
 func (bc *BaseCollector) GetConfig() *config.Config {
 	return bc.config
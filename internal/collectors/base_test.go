@@ -2,13 +2,17 @@ package collectors
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 
+	"aws-monitoring/internal/alerting"
 	"aws-monitoring/internal/aws"
 	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/state"
 	"aws-monitoring/pkg/errors"
 	"aws-monitoring/pkg/logger"
 )
@@ -20,6 +24,22 @@ func (m *mockAWSProvider) GetEC2Client(_ string) (aws.EC2Client, error) {
 	return &mockCollectorEC2Client{}, nil
 }
 
+func (m *mockAWSProvider) GetSTSClient(_ string) (aws.STSClient, error) {
+	return nil, errors.NewValidationError("NOT_IMPLEMENTED", "not implemented")
+}
+
+func (m *mockAWSProvider) GetIAMClient(_ string) (aws.IAMClient, error) {
+	return nil, errors.NewValidationError("NOT_IMPLEMENTED", "not implemented")
+}
+
+func (m *mockAWSProvider) GetSNSClient(_ string) (aws.SNSClient, error) {
+	return nil, errors.NewValidationError("NOT_IMPLEMENTED", "not implemented")
+}
+
+func (m *mockAWSProvider) GetSQSClient(_ string) (aws.SQSClient, error) {
+	return nil, errors.NewValidationError("NOT_IMPLEMENTED", "not implemented")
+}
+
 func (m *mockAWSProvider) Close() error {
 	return nil
 }
@@ -296,11 +316,72 @@ func TestBaseCollectorCreateMetric(t *testing.T) {
 		}
 	}
 	
+	// Account labels are added once the account is resolved
+	bc.SetAccountInfo(aws.AccountInfo{ID: "123456789012", Alias: "acme-prod"})
+	accountMetric := bc.CreateMetric("test_metric", 1, "Count", nil)
+	if accountMetric.Labels["account_id"] != "123456789012" || accountMetric.Labels["account_alias"] != "acme-prod" {
+		t.Errorf("Expected account labels, got %v", accountMetric.Labels)
+	}
+	
 	// Test metric with description
 	metricWithDesc := bc.CreateMetricWithDescription("test_metric_desc", 100, "Bytes", "Test metric with description", nil)
 	if metricWithDesc.Description != "Test metric with description" {
 		t.Errorf("Expected description 'Test metric with description', got %s", metricWithDesc.Description)
 	}
+	
+	// Test metric with an upstream timestamp
+	observed := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	timedMetric := bc.CreateMetricAt("test_metric_at", 1, "Count", observed, nil)
+	if !timedMetric.Timestamp.Equal(observed) {
+		t.Errorf("Expected timestamp %v, got %v", observed, timedMetric.Timestamp)
+	}
+	if timedMetric.Labels["collector"] != "test-collector" {
+		t.Errorf("Expected common labels on timestamped metric, got %v", timedMetric.Labels)
+	}
+	
+	if bc.CreateMetricAt("test_metric_now", 1, "Count", time.Time{}, nil).Timestamp.IsZero() {
+		t.Error("Expected zero timestamp to default to the current time")
+	}
+}
+
+func TestBaseCollectorResourceCap(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1"},
+	}
+
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	collectorConfig := DefaultCollectorConfig()
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	// No cap configured
+	if keep, truncated := bc.ResourceCap("us-east-1", 1000); keep != 1000 || truncated != nil {
+		t.Errorf("Expected no truncation without cap, got keep=%d truncated=%v", keep, truncated)
+	}
+
+	collectorConfig.MaxResources = 100
+	bc = NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	if keep, truncated := bc.ResourceCap("us-east-1", 50); keep != 50 || truncated != nil {
+		t.Errorf("Expected no truncation below cap, got keep=%d truncated=%v", keep, truncated)
+	}
+
+	keep, truncated := bc.ResourceCap("us-east-1", 250)
+	if keep != 100 {
+		t.Errorf("Expected 100 resources to be kept, got %d", keep)
+	}
+	if truncated == nil {
+		t.Fatal("Expected truncation metric")
+	}
+	if truncated.Name != ResourcesTruncatedMetric || truncated.Value != 150 {
+		t.Errorf("Expected %s with value 150, got %s=%f", ResourcesTruncatedMetric, truncated.Name, truncated.Value)
+	}
+	if truncated.Labels["region"] != "us-east-1" || truncated.Labels["max_resources"] != "100" {
+		t.Errorf("Unexpected truncation metric labels: %v", truncated.Labels)
+	}
 }
 
 func TestBaseCollectorCollectWithRetry(t *testing.T) {
@@ -386,8 +467,333 @@ func TestBaseCollectorCollectWithRetry(t *testing.T) {
 		t.Error("Expected error for non-retryable failure")
 	}
 	
-	if len(result.Metrics) != 0 {
-		t.Errorf("Expected no metrics for failed collection, got %d", len(result.Metrics))
+	// Only the error counter is reported for a failed collection
+	if len(result.Metrics) != 1 || result.Metrics[0].Name != ErrorsTotalMetric {
+		t.Fatalf("Expected only the error counter for failed collection, got %+v", result.Metrics)
+	}
+	
+	counter := result.Metrics[0]
+	if counter.Value != 1 {
+		t.Errorf("Expected error count 1, got %v", counter.Value)
+	}
+	if counter.Labels["region"] != "us-east-1" || counter.Labels["error_type"] != string(errors.ErrorTypePermission) ||
+		counter.Labels["error_code"] != "ACCESS_DENIED" {
+		t.Errorf("Expected error counter labels for the permission error, got %v", counter.Labels)
+	}
+	
+	result = bc.CollectWithRetry(ctx, "us-east-1", nonRetryableErrorFunc)
+	if len(result.Metrics) != 1 || result.Metrics[0].Value != 2 {
+		t.Errorf("Expected error counter to reach 2, got %+v", result.Metrics)
+	}
+}
+
+func TestBaseCollectorRetryAfter(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1"},
+	}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 2
+
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	attempts := 0
+	throttledFunc := func(_ context.Context, _ string) ([]MetricData, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.NewRateLimitError(50 * time.Millisecond)
+		}
+		return []MetricData{bc.CreateMetric("test_metric", 1, "Count", nil)}, nil
+	}
+
+	// The server-suggested delay is used instead of exponential backoff
+	start := time.Now()
+	result := bc.CollectWithRetry(context.Background(), "us-east-1", throttledFunc)
+	if result.Error != nil || attempts != 2 {
+		t.Fatalf("Expected success on the second attempt, got %d attempts and error %v", attempts, result.Error)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected to wait about the suggested 50ms, waited %v", elapsed)
+	}
+
+	// A suggested delay longer than the deadline returns the throttling error
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	attempts = 0
+	longDelayFunc := func(_ context.Context, _ string) ([]MetricData, error) {
+		attempts++
+		return nil, errors.NewRateLimitError(time.Hour)
+	}
+
+	start = time.Now()
+	result = bc.CollectWithRetry(ctx, "us-east-1", longDelayFunc)
+	if result.Error == nil || result.Error.Type != errors.ErrorTypeRateLimit {
+		t.Errorf("Expected rate limit error, got: %v", result.Error)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retries past the deadline, got %d attempts", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected to give up without waiting, took %v", elapsed)
+	}
+}
+
+func TestBaseCollectorCircuitBreaker(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1", "us-west-2"},
+	}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 0
+
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	calls := 0
+	failing := func(_ context.Context, _ string) ([]MetricData, error) {
+		calls++
+		return nil, errors.NewPermissionError("describe", "ec2:instances")
+	}
+
+	for i := 0; i < 5; i++ {
+		bc.CollectWithRetry(context.Background(), "us-east-1", failing)
+	}
+
+	result := bc.CollectWithRetry(context.Background(), "us-east-1", failing)
+	if calls != 5 {
+		t.Errorf("Expected collection to be skipped once the breaker opened, got %d calls", calls)
+	}
+	if result.Error == nil || result.Error.Code != "CIRCUIT_OPEN" {
+		t.Errorf("Expected CIRCUIT_OPEN error, got %v", result.Error)
+	}
+
+	// Other regions have their own breaker
+	result = bc.CollectWithRetry(context.Background(), "us-west-2", func(_ context.Context, _ string) ([]MetricData, error) {
+		return []MetricData{bc.CreateMetric("ok", 1, "Count", nil)}, nil
+	})
+	if result.Error != nil {
+		t.Errorf("Expected us-west-2 collection to run, got %v", result.Error)
+	}
+
+	breakers := bc.Info().CircuitBreakers
+	if breakers["us-east-1"].State != CircuitBreakerOpen || breakers["us-east-1"].FailureCount != 5 {
+		t.Errorf("Expected open breaker with 5 failures for us-east-1, got %+v", breakers["us-east-1"])
+	}
+	if _, exists := breakers["us-west-2"]; exists {
+		t.Error("Expected no breaker for a region that never failed")
+	}
+}
+
+func TestBaseCollectorRetrySucceedsWithoutMetrics(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1"},
+	}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 1
+	collectorConfig.RetryDelay = time.Millisecond
+
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	attempts := 0
+	result := bc.CollectWithRetry(context.Background(), "us-east-1", func(_ context.Context, _ string) ([]MetricData, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.NewTimeoutError("describe", time.Second)
+		}
+		// Nothing to report is a successful collection
+		return nil, nil
+	})
+
+	if attempts != 2 {
+		t.Fatalf("Expected a retry, got %d attempts", attempts)
+	}
+	if result.Error != nil {
+		t.Errorf("Expected the successful retry to leave no error, got %v", result.Error)
+	}
+	info := bc.Info()
+	if info.ErrorCount != 0 || info.LastError != nil {
+		t.Errorf("Expected no error recorded for a successful retry, got %d (%v)", info.ErrorCount, info.LastError)
+	}
+	if breaker, exists := info.CircuitBreakers["us-east-1"]; exists && breaker.FailureCount != 0 {
+		t.Errorf("Expected no breaker failure for a successful retry, got %+v", breaker)
+	}
+}
+
+func TestCircuitBreakerErrorHandlerRecovery(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	handler := NewCircuitBreakerErrorHandler(log).(*CircuitBreakerErrorHandler)
+	handler.timeout = 0
+
+	failure := errors.WithRegion(errors.NewPermissionError("describe", "ec2:instances"), "us-east-1")
+	for i := 0; i < handler.failureThreshold; i++ {
+		handler.HandleError("ec2", failure)
+	}
+	if state := handler.States("ec2")["us-east-1"].State; state != CircuitBreakerOpen {
+		t.Fatalf("Expected open breaker, got %s", state)
+	}
+
+	// Once the timeout passes the breaker lets collections through half-open
+	if !handler.Allow("ec2", "us-east-1") {
+		t.Fatal("Expected half-open breaker to allow a collection")
+	}
+	for i := 0; i < handler.recoveryThreshold; i++ {
+		handler.RecordSuccess("ec2", "us-east-1")
+	}
+	if state := handler.States("ec2")["us-east-1"].State; state != CircuitBreakerClosed {
+		t.Errorf("Expected breaker to close after recovery, got %s", state)
+	}
+}
+
+// recordingNotifier records the alerts it is notified of
+type recordingNotifier struct {
+	alerts []alerting.Alert
+}
+
+func (n *recordingNotifier) Notify(alert alerting.Alert) bool {
+	n.alerts = append(n.alerts, alert)
+	return true
+}
+
+func TestErrorHandlerAlerts(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", &config.Config{}, DefaultCollectorConfig(), &mockAWSProvider{}, log)
+	notifier := &recordingNotifier{}
+	bc.SetAlertNotifier(notifier)
+
+	bc.errorHandler.HandleError("test-collector", errors.NewValidationError("INVALID", "medium severity"))
+	bc.errorHandler.HandleError("test-collector", errors.WithRegion(errors.NewPermissionError("describe", "ec2:instances"), "us-east-1"))
+
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected only the high severity error to alert, got %d alerts", len(notifier.alerts))
+	}
+	if notifier.alerts[0].Collector != "test-collector" || notifier.alerts[0].Region != "us-east-1" {
+		t.Errorf("Unexpected alert: %+v", notifier.alerts[0])
+	}
+}
+
+// recordingReporter records the errors it is asked to report
+type recordingReporter struct {
+	errors []*errors.Error
+}
+
+func (r *recordingReporter) Report(_ string, err *errors.Error) bool {
+	r.errors = append(r.errors, err)
+	return true
+}
+
+func TestErrorHandlerReporter(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", &config.Config{}, DefaultCollectorConfig(), &mockAWSProvider{}, log)
+	reporter := &recordingReporter{}
+	bc.SetErrorReporter(reporter)
+
+	bc.errorHandler.HandleError("test-collector", errors.NewPermissionError("describe", "ec2:instances"))
+	bc.errorHandler.HandleError("test-collector",
+		errors.WithSeverity(errors.New(errors.ErrorTypeInternal, "COLLECTOR_PANIC", "panic"), errors.SeverityCritical))
+
+	if len(reporter.errors) != 1 || reporter.errors[0].Code != "COLLECTOR_PANIC" {
+		t.Errorf("Expected only the critical error to be reported, got %v", reporter.errors)
+	}
+}
+
+func TestBaseCollectorCollectAllRegions(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1", "us-west-2", "eu-west-1"},
+	}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 0
+	collectorConfig.MaxConcurrentRegions = 2
+
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	collectFunc := func(_ context.Context, region string) ([]MetricData, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if region == "eu-west-1" {
+			return nil, errors.NewPermissionError("describe", "ec2:instances")
+		}
+		return []MetricData{bc.CreateMetric("region_metric", 1, "Count", map[string]string{"region": region})}, nil
+	}
+
+	result := bc.CollectAllRegions(context.Background(), collectFunc)
+
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 concurrent regions, got %d", maxRunning)
+	}
+
+	if result.Error != nil {
+		t.Errorf("Expected no error when some regions succeed, got: %v", result.Error)
+	}
+
+	if result.Region != AllRegions {
+		t.Errorf("Expected region %s, got %s", AllRegions, result.Region)
+	}
+
+	// Two region metrics plus the error counter for eu-west-1
+	if len(result.Metrics) != 3 {
+		t.Errorf("Expected 3 metrics, got %d", len(result.Metrics))
+	}
+
+	if len(result.Warnings) != 1 || result.Warnings[0].Region != "eu-west-1" {
+		t.Errorf("Expected a single warning for eu-west-1, got %v", result.Warnings)
+	}
+
+	// All regions failing should surface an error
+	failFunc := func(_ context.Context, _ string) ([]MetricData, error) {
+		return nil, errors.NewPermissionError("describe", "ec2:instances")
+	}
+
+	result = bc.CollectAllRegions(context.Background(), failFunc)
+	if result.Error == nil || result.Error.Code != "ALL_REGIONS_FAILED" {
+		t.Errorf("Expected ALL_REGIONS_FAILED error, got: %v", result.Error)
 	}
 }
 
@@ -508,4 +914,128 @@ func TestDefaultCollectorConfig(t *testing.T) {
 	if config.CustomTags == nil {
 		t.Error("Expected custom tags map to be initialized")
 	}
-}
\ No newline at end of file
+}
+func TestBaseCollectorRecentErrors(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1"},
+	}
+	cfg.Global.RecentErrorLimit = 3
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 0
+
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	if recent := bc.RecentErrors(); len(recent) != 0 {
+		t.Errorf("Expected no recent errors, got %d", len(recent))
+	}
+
+	for i := 0; i < 5; i++ {
+		code := fmt.Sprintf("FAILURE_%d", i)
+		bc.CollectWithRetry(context.Background(), "us-east-1", func(_ context.Context, _ string) ([]MetricData, error) {
+			return nil, errors.Wrap(fmt.Errorf("cause %d", i), errors.ErrorTypeAWS, code, "collection failed")
+		})
+	}
+
+	recent := bc.RecentErrors()
+	if len(recent) != 3 {
+		t.Fatalf("Expected the last 3 errors, got %d", len(recent))
+	}
+	for i, want := range []string{"FAILURE_4", "FAILURE_3", "FAILURE_2"} {
+		if recent[i].Code != want {
+			t.Errorf("Expected error %d to be %s, got %s", i, want, recent[i].Code)
+		}
+	}
+	if recent[0].Region != "us-east-1" || recent[0].Cause != "cause 4" || recent[0].Operation != "collect" {
+		t.Errorf("Unexpected recent error: %+v", recent[0])
+	}
+}
+
+func TestBaseCollectorDisabledMetrics(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.DisabledMetrics = []string{"s3_object_*", ErrorsTotalMetric}
+	bc := NewBaseCollector("s3", "test", &config.Config{EnabledRegions: []string{"us-east-1"}}, collectorConfig, &mockAWSProvider{}, log)
+
+	result := bc.CollectWithRetry(context.Background(), "us-east-1", func(_ context.Context, _ string) ([]MetricData, error) {
+		return []MetricData{
+			bc.CreateMetric("s3_bucket_count", 3, "Count", nil),
+			bc.CreateMetric("s3_object_count", 1000, "Count", map[string]string{"bucket": "logs"}),
+			bc.CreateMetric("s3_object_size_bytes", 42, "Bytes", map[string]string{"bucket": "logs"}),
+		}, nil
+	})
+
+	for _, metric := range result.Metrics {
+		if MetricDisabled(collectorConfig.DisabledMetrics, metric.Name) {
+			t.Errorf("Expected disabled metric %s to be dropped", metric.Name)
+		}
+	}
+	if len(result.Metrics) == 0 || result.Metrics[0].Name != "s3_bucket_count" {
+		t.Errorf("Expected s3_bucket_count to be kept, got %v", result.Metrics)
+	}
+	if result.Metadata["metric_count"] != len(result.Metrics) {
+		t.Errorf("Expected metric_count %d, got %v", len(result.Metrics), result.Metadata["metric_count"])
+	}
+}
+
+func TestBaseCollectorState(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	bc := NewBaseCollector("cloudwatch", "test", &config.Config{EnabledRegions: []string{"us-east-1"}}, DefaultCollectorConfig(), &mockAWSProvider{}, log)
+
+	var cursor time.Time
+	if found, err := bc.LoadState("us-east-1", &cursor); found || err != nil {
+		t.Errorf("Expected no state without a store, got found=%v, err=%v", found, err)
+	}
+	if err := bc.SaveState("us-east-1", time.Now()); err != nil {
+		t.Errorf("Expected state to be discarded without a store, got: %v", err)
+	}
+
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatalf("Failed to open state store: %v", err)
+	}
+	bc.SetStateStore(store)
+	saved := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := bc.SaveState("us-east-1", saved); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if found, err := bc.LoadState("us-east-1", &cursor); !found || err != nil || !cursor.Equal(saved) {
+		t.Errorf("Expected %v, got %v, found=%v, err=%v", saved, cursor, found, err)
+	}
+	if keys := store.Keys("collector:cloudwatch"); len(keys) != 1 {
+		t.Errorf("Expected the state in the collector's bucket, got %v", keys)
+	}
+}
+
+// halvingScaler halves batch sizes, as under high memory pressure
+type halvingScaler struct{}
+
+func (halvingScaler) ScaleBatch(size int) int { return size / 2 }
+
+func TestBaseCollectorBatchSize(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	bc := NewBaseCollector("test-collector", "Test collector", &config.Config{}, DefaultCollectorConfig(), &mockAWSProvider{}, log)
+	
+	if size := bc.BatchSize(100); size != 100 {
+		t.Errorf("Expected the batch size unchanged without a memory monitor, got %d", size)
+	}
+	bc.SetMemoryMonitor(halvingScaler{})
+	if size := bc.BatchSize(100); size != 50 {
+		t.Errorf("Expected the scaled batch size 50, got %d", size)
+	}
+}
@@ -0,0 +1,145 @@
+package collectors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"aws-monitoring/pkg/errors"
+)
+
+// Budget splits the deadline of a collection attempt across the phases of a
+// collector that makes several downstream calls (e.g. list buckets, then fetch
+// per-bucket statistics). When a phase runs out of time the budget records a
+// warning so the collector can stop and return partial results instead of
+// failing the whole collection with a blanket timeout.
+type Budget struct {
+	ctx      context.Context
+	deadline time.Time
+	// hasDeadline is false when the parent context has no deadline
+	hasDeadline bool
+
+	mu       sync.Mutex
+	phases   []PhaseSummary
+	warnings []*errors.Error
+}
+
+// PhaseSummary records how a single budget phase went
+type PhaseSummary struct {
+	// Name identifies the phase
+	Name string `json:"name"`
+	// Allotted is the time the phase was given (zero if unlimited)
+	Allotted time.Duration `json:"allotted"`
+	// Elapsed is how long the phase took
+	Elapsed time.Duration `json:"elapsed"`
+	// TimedOut indicates the phase ran out of time
+	TimedOut bool `json:"timed_out"`
+}
+
+// NewBudget creates a budget from the deadline of ctx
+func NewBudget(ctx context.Context) *Budget {
+	deadline, hasDeadline := ctx.Deadline()
+	return &Budget{
+		ctx:         ctx,
+		deadline:    deadline,
+		hasDeadline: hasDeadline,
+	}
+}
+
+// Remaining returns the time left before the overall deadline, or -1 if the
+// budget has no deadline
+func (b *Budget) Remaining() time.Duration {
+	if !b.hasDeadline {
+		return -1
+	}
+	if remaining := time.Until(b.deadline); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Exhausted reports whether the overall budget is used up or cancelled
+func (b *Budget) Exhausted() bool {
+	return b.ctx.Err() != nil || b.Remaining() == 0
+}
+
+// Phase runs fn with a deadline of share (0 < share <= 1) of the remaining
+// budget; time a phase does not use is left for later phases. It returns false
+// and records a warning when the phase runs out of time, in which case the
+// caller should stop and return the results gathered so far. Errors other than
+// the phase timing out are returned unchanged.
+func (b *Budget) Phase(name string, share float64, fn func(ctx context.Context) error) (bool, error) {
+	if share <= 0 || share > 1 {
+		share = 1
+	}
+
+	if b.Exhausted() {
+		b.recordTimeout(PhaseSummary{Name: name, TimedOut: true})
+		return false, nil
+	}
+
+	phaseCtx := b.ctx
+	cancel := context.CancelFunc(func() {})
+	var allotted time.Duration
+	if b.hasDeadline {
+		allotted = time.Duration(float64(b.Remaining()) * share)
+		phaseCtx, cancel = context.WithTimeout(b.ctx, allotted)
+	}
+	defer cancel()
+
+	start := time.Now()
+	err := fn(phaseCtx)
+	summary := PhaseSummary{Name: name, Allotted: allotted, Elapsed: time.Since(start)}
+
+	if phaseCtx.Err() != nil && (err == nil || isContextError(err)) {
+		summary.TimedOut = true
+		b.recordTimeout(summary)
+		return false, nil
+	}
+
+	b.mu.Lock()
+	b.phases = append(b.phases, summary)
+	b.mu.Unlock()
+
+	return true, err
+}
+
+// Warnings returns the warnings recorded for phases that ran out of time
+func (b *Budget) Warnings() []*errors.Error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	warnings := make([]*errors.Error, len(b.warnings))
+	copy(warnings, b.warnings)
+	return warnings
+}
+
+// Phases returns a summary of every phase run so far
+func (b *Budget) Phases() []PhaseSummary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	phases := make([]PhaseSummary, len(b.phases))
+	copy(phases, b.phases)
+	return phases
+}
+
+func (b *Budget) recordTimeout(summary PhaseSummary) {
+	warning := errors.New(errors.ErrorTypeTimeout, errors.CodeBudgetPhaseTimeout,
+		fmt.Sprintf("phase %s ran out of time, returning partial results", summary.Name)).
+		WithMetadata("phase", summary.Name).
+		WithMetadata("allotted", summary.Allotted.String())
+	warning = errors.WithSeverity(errors.WithOperation(warning, summary.Name), errors.SeverityLow)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.phases = append(b.phases, summary)
+	b.warnings = append(b.warnings, warning)
+}
+
+// isContextError reports whether err was caused by a cancelled or expired context
+func isContextError(err error) bool {
+	return stderrors.Is(err, context.DeadlineExceeded) || stderrors.Is(err, context.Canceled)
+}
@@ -0,0 +1,132 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestBudgetPhases(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	budget := NewBudget(ctx)
+
+	// A fast phase completes and leaves its unused time for later phases
+	completed, err := budget.Phase("list", 0.25, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected phase context to have a deadline")
+		}
+		return nil
+	})
+	if !completed || err != nil {
+		t.Fatalf("Expected first phase to complete, got completed=%v err=%v", completed, err)
+	}
+
+	// Non-timeout errors are returned unchanged
+	completed, err = budget.Phase("describe", 0.5, func(_ context.Context) error {
+		return fmt.Errorf("access denied")
+	})
+	if !completed || err == nil {
+		t.Errorf("Expected phase error to be returned, got completed=%v err=%v", completed, err)
+	}
+
+	// A slow phase runs out of its share and is reported as a warning
+	start := time.Now()
+	completed, err = budget.Phase("stats", 0.5, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if completed || err != nil {
+		t.Errorf("Expected phase to time out without error, got completed=%v err=%v", completed, err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected phase to be limited to its share of the budget, took %v", elapsed)
+	}
+
+	warnings := budget.Warnings()
+	if len(warnings) != 1 || warnings[0].Code != "BUDGET_PHASE_TIMEOUT" {
+		t.Fatalf("Expected 1 BUDGET_PHASE_TIMEOUT warning, got %v", warnings)
+	}
+
+	phases := budget.Phases()
+	if len(phases) != 3 || !phases[2].TimedOut || phases[0].TimedOut {
+		t.Errorf("Unexpected phase summaries: %+v", phases)
+	}
+
+	if budget.Exhausted() {
+		t.Error("Expected budget not to be exhausted yet")
+	}
+}
+
+func TestBudgetWithoutDeadline(t *testing.T) {
+	budget := NewBudget(context.Background())
+
+	if budget.Remaining() != -1 {
+		t.Errorf("Expected unlimited budget, got %v", budget.Remaining())
+	}
+
+	completed, err := budget.Phase("list", 0.5, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("Expected no deadline without a budget deadline")
+		}
+		return nil
+	})
+	if !completed || err != nil {
+		t.Errorf("Expected phase to complete, got completed=%v err=%v", completed, err)
+	}
+}
+
+func TestBaseCollectorCollectWithBudget(t *testing.T) {
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1"},
+	}
+
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Timeout = 100 * time.Millisecond
+	collectorConfig.Retries = 0
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+
+	result := bc.CollectWithBudget(context.Background(), "us-east-1", func(_ context.Context, _ string, budget *Budget) ([]MetricData, error) {
+		metrics := []MetricData{}
+
+		if _, err := budget.Phase("list", 0.3, func(_ context.Context) error {
+			metrics = append(metrics, bc.CreateMetric("bucket_count", 2, "Count", nil))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		// The stats phase never finishes; the metrics gathered so far are returned
+		if _, err := budget.Phase("stats", 1, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}); err != nil {
+			return nil, err
+		}
+
+		return metrics, nil
+	})
+
+	if result.Error != nil {
+		t.Fatalf("Expected partial results without error, got: %v", result.Error)
+	}
+	if len(result.Metrics) != 1 {
+		t.Errorf("Expected 1 partial metric, got %d", len(result.Metrics))
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Region != "us-east-1" {
+		t.Errorf("Expected 1 regional warning, got %v", result.Warnings)
+	}
+	if result.Metadata["partial"] != true {
+		t.Error("Expected result to be marked partial")
+	}
+}
@@ -0,0 +1,112 @@
+package collectors
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// checkpointKeyPrefix prefixes the state key of the checkpoints of a region
+const checkpointKeyPrefix = "checkpoints:"
+
+// Checkpointer is implemented by collectors that resume fetching datapoints,
+// such as CloudWatch datapoints, from the last exported one. The scheduler
+// commits the checkpoints of a collection once its metrics were processed
+// successfully, so a failed export is fetched again instead of leaving a gap.
+type Checkpointer interface {
+	CommitCheckpoints(region string, metrics []MetricData) error
+}
+
+// EnableCheckpoints makes the collector track the timestamp of the last
+// exported datapoint of each series, per region. The checkpoints are kept in
+// the state store, so they survive restarts.
+func (bc *BaseCollector) EnableCheckpoints() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.checkpoints = true
+}
+
+// Checkpoints returns the timestamp of the last exported datapoint of each
+// series in region, by checkpointKey
+func (bc *BaseCollector) Checkpoints(region string) (map[string]time.Time, error) {
+	checkpoints := make(map[string]time.Time)
+	if _, err := bc.LoadState(checkpointKeyPrefix+region, &checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+// OldestCheckpoint returns the earliest checkpoint in region, from which
+// datapoints must be fetched so no series has a gap. It reports false if no
+// datapoint was exported in region yet.
+func (bc *BaseCollector) OldestCheckpoint(region string) (time.Time, bool, error) {
+	checkpoints, err := bc.Checkpoints(region)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var oldest time.Time
+	for _, checkpoint := range checkpoints {
+		if oldest.IsZero() || checkpoint.Before(oldest) {
+			oldest = checkpoint
+		}
+	}
+	return oldest, !oldest.IsZero(), nil
+}
+
+// SkipCheckpointed drops the datapoints of region at or before the checkpoint
+// of their series, which were exported before. It reuses the slice of metrics.
+func (bc *BaseCollector) SkipCheckpointed(region string, metrics []MetricData) ([]MetricData, error) {
+	checkpoints, err := bc.Checkpoints(region)
+	if err != nil || len(checkpoints) == 0 {
+		return metrics, err
+	}
+
+	kept := metrics[:0]
+	for _, metric := range metrics {
+		if checkpoint, ok := checkpoints[checkpointKey(metric)]; ok && !metric.Timestamp.After(checkpoint) {
+			continue
+		}
+		kept = append(kept, metric)
+	}
+	return kept, nil
+}
+
+// CommitCheckpoints advances the checkpoint of each series in region to its
+// latest exported datapoint. It does nothing unless checkpoints are enabled.
+func (bc *BaseCollector) CommitCheckpoints(region string, metrics []MetricData) error {
+	bc.mu.RLock()
+	enabled := bc.checkpoints
+	bc.mu.RUnlock()
+	if !enabled || len(metrics) == 0 {
+		return nil
+	}
+
+	checkpoints, err := bc.Checkpoints(region)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, metric := range metrics {
+		key := checkpointKey(metric)
+		if metric.Timestamp.After(checkpoints[key]) {
+			checkpoints[key] = metric.Timestamp
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return bc.SaveState(checkpointKeyPrefix+region, checkpoints)
+}
+
+// checkpointKey identifies the series of a metric by its name and sorted
+// labels, so series of a metric keep their own checkpoints
+func checkpointKey(metric MetricData) string {
+	labels := make([]string, 0, len(metric.Labels))
+	for key, value := range metric.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	return metric.Name + "{" + strings.Join(labels, ",") + "}"
+}
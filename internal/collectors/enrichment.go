@@ -0,0 +1,126 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"aws-monitoring/pkg/errors"
+)
+
+// DefaultEnrichmentResourceLabel is the label used to find the resource a metric describes
+const DefaultEnrichmentResourceLabel = "instance_id"
+
+// EnrichmentConfig configures resource metadata enrichment for a collector
+type EnrichmentConfig struct {
+	// Enabled turns enrichment on for the collector
+	Enabled bool `json:"enabled"`
+	// ResourceLabel is the metric label holding the resource ID (default "instance_id")
+	ResourceLabel string `json:"resource_label,omitempty"`
+	// Tags are the resource tag keys copied to "tag_<key>" labels
+	Tags []string `json:"tags,omitempty"`
+}
+
+// enrichMetrics attaches stable resource metadata (instance type, availability
+// zone, ARN and selected tags) from the shared inventory cache to metrics that
+// identify a resource. Existing labels are never overwritten. Enrichment is
+// best-effort: if the inventory can't be read the metrics are returned as is
+// along with a warning.
+func (bc *BaseCollector) enrichMetrics(ctx context.Context, region string, metrics []MetricData) ([]MetricData, *errors.Error) {
+	enrichment := bc.collectorConfig.Enrichment
+	if !enrichment.Enabled || bc.inventory == nil || len(metrics) == 0 {
+		return metrics, nil
+	}
+
+	resourceLabel := enrichment.ResourceLabel
+	if resourceLabel == "" {
+		resourceLabel = DefaultEnrichmentResourceLabel
+	}
+
+	instances, err := bc.inventory.GetInstances(ctx, region)
+	if err != nil {
+		return metrics, errors.WithRegion(errors.Wrap(err, errors.ErrorTypeAWS, errors.CodeEnrichmentFailed,
+			"failed to read inventory for metadata enrichment"), region)
+	}
+
+	byID := make(map[string]types.Instance, len(instances))
+	for _, instance := range instances {
+		if instance.InstanceId != nil {
+			byID[*instance.InstanceId] = instance
+		}
+	}
+
+	// Many metrics identify the same instance; build its labels once
+	metadataByID := make(map[string]map[string]string)
+	for i := range metrics {
+		resourceID, exists := metrics[i].Labels[resourceLabel]
+		if !exists {
+			continue
+		}
+
+		metadata, found := metadataByID[resourceID]
+		if !found {
+			instance, exists := byID[resourceID]
+			if !exists {
+				continue
+			}
+			metadata = bc.instanceMetadata(region, instance, enrichment.Tags)
+			metadataByID[resourceID] = metadata
+		}
+
+		for key, value := range metadata {
+			if _, exists := metrics[i].Labels[key]; !exists {
+				metrics[i].Labels[key] = value
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+// instanceMetadata returns the enrichment labels for an instance
+func (bc *BaseCollector) instanceMetadata(region string, instance types.Instance, tagKeys []string) map[string]string {
+	metadata := make(map[string]string)
+
+	if instance.InstanceType != "" {
+		metadata["instance_type"] = string(instance.InstanceType)
+	}
+	if instance.Placement != nil && instance.Placement.AvailabilityZone != nil {
+		metadata["availability_zone"] = *instance.Placement.AvailabilityZone
+	}
+	if instance.InstanceId != nil && bc.account.ID != "" {
+		metadata["resource_arn"] = fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", region, bc.account.ID, *instance.InstanceId)
+	}
+
+	if len(tagKeys) > 0 {
+		tags := make(map[string]string, len(instance.Tags))
+		for _, tag := range instance.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+		for _, key := range tagKeys {
+			if value, exists := tags[key]; exists {
+				metadata[tagLabelName(key)] = value
+			}
+		}
+	}
+
+	return metadata
+}
+
+// tagLabelName converts a resource tag key into a label name, e.g. "Cost-Center" -> "tag_cost_center"
+func tagLabelName(key string) string {
+	var b strings.Builder
+	b.WriteString("tag_")
+	for _, r := range strings.ToLower(key) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
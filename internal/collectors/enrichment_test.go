@@ -0,0 +1,112 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+// inventoryEC2Client returns a fixed set of instances
+type inventoryEC2Client struct {
+	mockCollectorEC2Client
+	instances []types.Instance
+}
+
+func (m *inventoryEC2Client) DescribeInstances(_ context.Context, _ *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: m.instances}},
+	}, nil
+}
+
+type inventoryProvider struct {
+	mockAWSProvider
+	client *inventoryEC2Client
+}
+
+func (m *inventoryProvider) GetEC2Client(_ string) (aws.EC2Client, error) {
+	return m.client, nil
+}
+
+func TestBaseCollectorEnrichment(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	provider := &inventoryProvider{client: &inventoryEC2Client{instances: []types.Instance{
+		{
+			InstanceId:   awssdk.String("i-1"),
+			InstanceType: types.InstanceType("m5.large"),
+			Placement:    &types.Placement{AvailabilityZone: awssdk.String("us-east-1a")},
+			Tags: []types.Tag{
+				{Key: awssdk.String("Cost-Center"), Value: awssdk.String("1234")},
+				{Key: awssdk.String("Secret"), Value: awssdk.String("hidden")},
+			},
+		},
+	}}}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 0
+	collectorConfig.Enrichment = EnrichmentConfig{Enabled: true, Tags: []string{"Cost-Center"}}
+
+	cfg := &config.Config{EnabledRegions: []string{"us-east-1"}}
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, provider, log)
+	bc.SetInventoryCache(aws.NewInventoryCache(provider, 0, log))
+	bc.SetAccountInfo(aws.AccountInfo{ID: "123456789012"})
+
+	result := bc.CollectWithRetry(context.Background(), "us-east-1", func(_ context.Context, _ string) ([]MetricData, error) {
+		return []MetricData{
+			bc.CreateMetric("cpu", 10, "Percent", map[string]string{"instance_id": "i-1", "instance_type": "override"}),
+			bc.CreateMetric("cpu", 20, "Percent", map[string]string{"instance_id": "i-unknown"}),
+			bc.CreateMetric("total", 2, "Count", nil),
+		}, nil
+	})
+
+	if result.Error != nil || len(result.Warnings) != 0 {
+		t.Fatalf("Expected no error or warnings, got %v / %v", result.Error, result.Warnings)
+	}
+
+	enriched := result.Metrics[0].Labels
+	expected := map[string]string{
+		"availability_zone": "us-east-1a",
+		"resource_arn":      "arn:aws:ec2:us-east-1:123456789012:instance/i-1",
+		"tag_cost_center":   "1234",
+		"instance_type":     "override",
+	}
+	for key, value := range expected {
+		if enriched[key] != value {
+			t.Errorf("Expected label %s=%s, got %q", key, value, enriched[key])
+		}
+	}
+	if _, exists := enriched["tag_secret"]; exists {
+		t.Error("Expected only selected tags to be copied")
+	}
+
+	if _, exists := result.Metrics[1].Labels["availability_zone"]; exists {
+		t.Error("Expected unknown resource not to be enriched")
+	}
+	if _, exists := result.Metrics[2].Labels["availability_zone"]; exists {
+		t.Error("Expected metric without resource label not to be enriched")
+	}
+}
+
+func TestTagLabelName(t *testing.T) {
+	tests := map[string]string{
+		"Name":                     "tag_name",
+		"Cost-Center":              "tag_cost_center",
+		"aws:cloudformation:stack": "tag_aws_cloudformation_stack",
+	}
+
+	for key, expected := range tests {
+		if actual := tagLabelName(key); actual != expected {
+			t.Errorf("Expected %s, got %s", expected, actual)
+		}
+	}
+}
@@ -2,18 +2,64 @@ package collectors
 
 import (
 	"math"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"aws-monitoring/internal/alerting"
 	"aws-monitoring/pkg/errors"
 	"aws-monitoring/pkg/logger"
 )
 
+// ErrorsTotalMetric counts handled collector errors by region, error type and code
+const ErrorsTotalMetric = "aws_monitor_errors_total"
+
+// ErrorCount is the number of errors handled for a collector, region, error type and code
+type ErrorCount struct {
+	Collector string
+	Region    string
+	Type      errors.ErrorType
+	Code      string
+	Count     int64
+}
+
+// ErrorCounter is implemented by error handlers that count the errors they handle
+type ErrorCounter interface {
+	// ErrorCounts returns the error counts for a collector
+	ErrorCounts(collectorName string) []ErrorCount
+}
+
+// errorCountKey identifies an error counter series
+type errorCountKey struct {
+	collector string
+	region    string
+	errorType errors.ErrorType
+	code      string
+}
+
+// AlertNotifier receives alerts for high and critical severity collector errors
+type AlertNotifier interface {
+	Notify(alert alerting.Alert) bool
+}
+
+// ErrorReporter forwards collector errors to an external error tracker such as Sentry
+type ErrorReporter interface {
+	Report(collectorName string, err *errors.Error) bool
+}
+
 // DefaultErrorHandler provides a default implementation of ErrorHandler
 type DefaultErrorHandler struct {
 	logger     *logger.Logger
 	maxRetries int
 	baseDelay  time.Duration
+	// alerts is notified of high and critical severity errors, if set
+	alerts AlertNotifier
+	// reporter receives critical errors for error tracking, if set
+	reporter ErrorReporter
+
+	countsMu sync.Mutex
+	counts   map[errorCountKey]int64
 }
 
 // NewDefaultErrorHandler creates a new default error handler
@@ -70,11 +116,78 @@ func (eh *DefaultErrorHandler) HandleError(collectorName string, err *errors.Err
 			logger.Any("stack_trace", err.StackTrace))
 	}
 	
+	eh.countError(collectorName, err)
+	
+	// Send alerts for errors that need attention
+	if eh.alerts != nil && (err.Severity == errors.SeverityHigh || err.Severity == errors.SeverityCritical) {
+		eh.alerts.Notify(alerting.NewAlert(collectorName, err))
+	}
+	
+	if eh.reporter != nil && err.Severity == errors.SeverityCritical {
+		eh.reporter.Report(collectorName, err)
+	}
+	
 	// TODO: Here you could add additional error handling like:
-	// - Sending alerts for critical errors
-	// - Recording error metrics
 	// - Updating health status
-	// - Implementing circuit breaker logic
+}
+
+// ErrorCounts returns the cumulative error counts for a collector, sorted by
+// region, error type and code
+func (eh *DefaultErrorHandler) ErrorCounts(collectorName string) []ErrorCount {
+	eh.countsMu.Lock()
+	defer eh.countsMu.Unlock()
+
+	var counts []ErrorCount
+	for key, count := range eh.counts {
+		if key.collector != collectorName {
+			continue
+		}
+		counts = append(counts, ErrorCount{
+			Collector: key.collector,
+			Region:    key.region,
+			Type:      key.errorType,
+			Code:      key.code,
+			Count:     count,
+		})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Region != counts[j].Region {
+			return counts[i].Region < counts[j].Region
+		}
+		if counts[i].Type != counts[j].Type {
+			return counts[i].Type < counts[j].Type
+		}
+		return counts[i].Code < counts[j].Code
+	})
+
+	return counts
+}
+
+// countError increments the counter for the error's collector, region, type and code
+func (eh *DefaultErrorHandler) countError(collectorName string, err *errors.Error) {
+	eh.countsMu.Lock()
+	defer eh.countsMu.Unlock()
+
+	if eh.counts == nil {
+		eh.counts = make(map[errorCountKey]int64)
+	}
+	eh.counts[errorCountKey{
+		collector: collectorName,
+		region:    err.Region,
+		errorType: err.Type,
+		code:      err.Code,
+	}]++
+}
+
+// SetAlertNotifier sets where alerts for high and critical severity errors are sent
+func (eh *DefaultErrorHandler) SetAlertNotifier(alerts AlertNotifier) {
+	eh.alerts = alerts
+}
+
+// SetErrorReporter sets the error tracker critical errors are reported to
+func (eh *DefaultErrorHandler) SetErrorReporter(reporter ErrorReporter) {
+	eh.reporter = reporter
 }
 
 // ShouldRetry determines if an operation should be retried
@@ -205,10 +318,10 @@ func (eh *DefaultErrorHandler) shouldRetryAWSError(err *errors.Error) bool {
 func (eh *DefaultErrorHandler) shouldRetryInternalError(err *errors.Error) bool {
 	// Internal errors that might be retryable
 	retryableInternalCodes := []string{
-		"CONTEXT_CANCELLED",
-		"TIMEOUT",
-		"NETWORK_ERROR",
-		"TEMPORARY_FAILURE",
+		errors.CodeContextCancelled,
+		errors.CodeTimeout,
+		errors.CodeNetworkError,
+		errors.CodeTemporaryFailure,
 	}
 	
 	for _, code := range retryableInternalCodes {
@@ -220,18 +333,20 @@ func (eh *DefaultErrorHandler) shouldRetryInternalError(err *errors.Error) bool
 	return false
 }
 
-// CircuitBreakerErrorHandler implements circuit breaker pattern for error handling
+// CircuitBreakerErrorHandler implements the circuit breaker pattern on top of
+// the default error handling. It keeps an independent breaker for every
+// collector and region, so a region that keeps failing is skipped without
+// blocking collection from the other regions.
 type CircuitBreakerErrorHandler struct {
 	*DefaultErrorHandler
-	
-	// Circuit breaker state
-	state          CircuitBreakerState
-	failureCount   int
-	lastFailure    time.Time
-	successCount   int
-	failureThreshold int
-	timeout        time.Duration
+
+	failureThreshold  int
+	timeout           time.Duration
 	recoveryThreshold int
+
+	mu sync.Mutex
+	// breakers holds the breaker state by collector name and region
+	breakers map[string]map[string]*circuitBreaker
 }
 
 // CircuitBreakerState represents the state of a circuit breaker
@@ -246,6 +361,35 @@ const (
 	CircuitBreakerHalfOpen CircuitBreakerState = "half_open"
 )
 
+// CircuitBreaker is implemented by error handlers that gate collections per
+// collector and region
+type CircuitBreaker interface {
+	// Allow reports whether a collection for the collector and region may run
+	Allow(collectorName, region string) bool
+	// RecordSuccess records a successful collection for the collector and region
+	RecordSuccess(collectorName, region string)
+	// States returns the breaker status of a collector by region
+	States(collectorName string) map[string]CircuitBreakerStatus
+}
+
+// CircuitBreakerStatus reports the state of a single circuit breaker
+type CircuitBreakerStatus struct {
+	// State is the current breaker state
+	State CircuitBreakerState `json:"state"`
+	// FailureCount is the number of consecutive failed collections
+	FailureCount int `json:"failure_count"`
+	// LastFailure is when the last failure was recorded
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+}
+
+// circuitBreaker is the state of the breaker for one collector and region
+type circuitBreaker struct {
+	state        CircuitBreakerState
+	failureCount int
+	successCount int
+	lastFailure  time.Time
+}
+
 // NewCircuitBreakerErrorHandler creates a circuit breaker error handler
 func NewCircuitBreakerErrorHandler(log *logger.Logger) ErrorHandler {
 	return &CircuitBreakerErrorHandler{
@@ -254,94 +398,139 @@ func NewCircuitBreakerErrorHandler(log *logger.Logger) ErrorHandler {
 			maxRetries: 3,
 			baseDelay:  time.Second,
 		},
-		state:             CircuitBreakerClosed,
-		failureThreshold:  5,  // Open circuit after 5 failures
+		failureThreshold:  5,                // Open circuit after 5 failures
 		timeout:           60 * time.Second, // Stay open for 60 seconds
-		recoveryThreshold: 3,  // Need 3 successes to close circuit
+		recoveryThreshold: 3,                // Need 3 successes to close circuit
+		breakers:          make(map[string]map[string]*circuitBreaker),
 	}
 }
 
-// ShouldRetry implements circuit breaker logic
-func (cb *CircuitBreakerErrorHandler) ShouldRetry(err *errors.Error, attempt int) bool {
-	// If circuit is open, don't retry
-	if cb.isCircuitOpen() {
-		return false
-	}
-	
-	// Use default retry logic if circuit is closed or half-open
-	return cb.DefaultErrorHandler.ShouldRetry(err, attempt)
-}
-
 // HandleError implements circuit breaker error handling
 func (cb *CircuitBreakerErrorHandler) HandleError(collectorName string, err *errors.Error) {
+	if err == nil {
+		return
+	}
+
 	// Call default error handling first
 	cb.DefaultErrorHandler.HandleError(collectorName, err)
-	
+
 	// Update circuit breaker state
-	cb.recordFailure()
+	cb.recordFailure(collectorName, err.Region)
+}
+
+// Allow reports whether a collection may run. An open breaker lets a
+// collection through again (half-open) once its timeout has passed.
+func (cb *CircuitBreakerErrorHandler) Allow(collectorName, region string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	breaker := cb.breakers[collectorName][region]
+	if breaker == nil || breaker.state != CircuitBreakerOpen {
+		return true
+	}
+
+	if time.Since(breaker.lastFailure) > cb.timeout {
+		breaker.state = CircuitBreakerHalfOpen
+		breaker.successCount = 0
+		cb.logger.Info("Circuit breaker half-open",
+			logger.String("collector", collectorName),
+			logger.String("region", region))
+		return true
+	}
+
+	return false
 }
 
-// RecordSuccess should be called when an operation succeeds
-func (cb *CircuitBreakerErrorHandler) RecordSuccess() {
-	switch cb.state {
+// RecordSuccess should be called when a collection succeeds
+func (cb *CircuitBreakerErrorHandler) RecordSuccess(collectorName, region string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	breaker := cb.breakers[collectorName][region]
+	if breaker == nil {
+		return
+	}
+
+	switch breaker.state {
 	case CircuitBreakerHalfOpen:
-		cb.successCount++
-		if cb.successCount >= cb.recoveryThreshold {
-			cb.closeCircuit()
+		breaker.successCount++
+		if breaker.successCount >= cb.recoveryThreshold {
+			cb.closeCircuit(breaker, collectorName, region)
 		}
 	case CircuitBreakerOpen:
 		// Success while open (shouldn't happen)
-		cb.closeCircuit()
+		cb.closeCircuit(breaker, collectorName, region)
 	case CircuitBreakerClosed:
 		// Reset failure count on success
-		cb.failureCount = 0
+		breaker.failureCount = 0
 	}
 }
 
-func (cb *CircuitBreakerErrorHandler) recordFailure() {
-	cb.lastFailure = time.Now()
-	
-	switch cb.state {
-	case CircuitBreakerClosed:
-		cb.failureCount++
-		if cb.failureCount >= cb.failureThreshold {
-			cb.openCircuit()
+// States returns the breaker status of a collector by region
+func (cb *CircuitBreakerErrorHandler) States(collectorName string) map[string]CircuitBreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	states := make(map[string]CircuitBreakerStatus, len(cb.breakers[collectorName]))
+	for region, breaker := range cb.breakers[collectorName] {
+		status := CircuitBreakerStatus{
+			State:        breaker.state,
+			FailureCount: breaker.failureCount,
 		}
-	case CircuitBreakerHalfOpen:
-		cb.openCircuit()
-	case CircuitBreakerOpen:
-		// Already open, just update timestamp
+		if !breaker.lastFailure.IsZero() {
+			lastFailure := breaker.lastFailure
+			status.LastFailure = &lastFailure
+		}
+		states[region] = status
 	}
+
+	return states
 }
 
-func (cb *CircuitBreakerErrorHandler) isCircuitOpen() bool {
-	if cb.state == CircuitBreakerOpen {
-		// Check if timeout has passed
-		if time.Since(cb.lastFailure) > cb.timeout {
-			cb.transitionToHalfOpen()
-			return false
+func (cb *CircuitBreakerErrorHandler) recordFailure(collectorName, region string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	regions, exists := cb.breakers[collectorName]
+	if !exists {
+		regions = make(map[string]*circuitBreaker)
+		cb.breakers[collectorName] = regions
+	}
+	breaker, exists := regions[region]
+	if !exists {
+		breaker = &circuitBreaker{state: CircuitBreakerClosed}
+		regions[region] = breaker
+	}
+
+	breaker.lastFailure = time.Now()
+	breaker.failureCount++
+
+	switch breaker.state {
+	case CircuitBreakerClosed:
+		if breaker.failureCount >= cb.failureThreshold {
+			cb.openCircuit(breaker, collectorName, region)
 		}
-		return true
+	case CircuitBreakerHalfOpen:
+		cb.openCircuit(breaker, collectorName, region)
+	case CircuitBreakerOpen:
+		// Already open, just update timestamp
 	}
-	return false
 }
 
-func (cb *CircuitBreakerErrorHandler) openCircuit() {
-	cb.state = CircuitBreakerOpen
-	cb.logger.Warn("Circuit breaker opened", 
-		logger.Int("failure_count", cb.failureCount),
+func (cb *CircuitBreakerErrorHandler) openCircuit(breaker *circuitBreaker, collectorName, region string) {
+	breaker.state = CircuitBreakerOpen
+	cb.logger.Warn("Circuit breaker opened",
+		logger.String("collector", collectorName),
+		logger.String("region", region),
+		logger.Int("failure_count", breaker.failureCount),
 		logger.Duration("timeout", cb.timeout))
 }
 
-func (cb *CircuitBreakerErrorHandler) closeCircuit() {
-	cb.state = CircuitBreakerClosed
-	cb.failureCount = 0
-	cb.successCount = 0
-	cb.logger.Info("Circuit breaker closed")
+func (cb *CircuitBreakerErrorHandler) closeCircuit(breaker *circuitBreaker, collectorName, region string) {
+	breaker.state = CircuitBreakerClosed
+	breaker.failureCount = 0
+	breaker.successCount = 0
+	cb.logger.Info("Circuit breaker closed",
+		logger.String("collector", collectorName),
+		logger.String("region", region))
 }
-
-func (cb *CircuitBreakerErrorHandler) transitionToHalfOpen() {
-	cb.state = CircuitBreakerHalfOpen
-	cb.successCount = 0
-	cb.logger.Info("Circuit breaker half-open")
-}
\ No newline at end of file
@@ -0,0 +1,33 @@
+package collectors
+
+import (
+	"aws-monitoring/internal/events"
+)
+
+// EventEmitter receives the events collectors report, such as an instance
+// state change or an expiring certificate
+type EventEmitter interface {
+	Emit(event events.Event) bool
+}
+
+// SetEventEmitter sets where the collector's events are sent
+func (bc *BaseCollector) SetEventEmitter(emitter EventEmitter) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.events = emitter
+}
+
+// EmitEvent sends an event, attributed to the collector, to the events
+// pipeline. It reports whether the event was queued, which it never is while
+// events are disabled.
+func (bc *BaseCollector) EmitEvent(event events.Event) bool {
+	bc.mu.RLock()
+	emitter := bc.events
+	bc.mu.RUnlock()
+	if emitter == nil {
+		return false
+	}
+
+	event.Collector = bc.name
+	return emitter.Emit(event)
+}
@@ -0,0 +1,436 @@
+package collectors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"aws-monitoring/internal/events"
+	"aws-monitoring/pkg/errors"
+)
+
+// ExecCollectorType is the registered collector type for the exec collector
+const ExecCollectorType = "exec"
+
+// Output formats supported by the exec collector
+const (
+	// ExecFormatJSON expects a JSON array of metrics or an object with a "metrics" array
+	ExecFormatJSON = "json"
+	// ExecFormatPrometheus expects the Prometheus text exposition format
+	ExecFormatPrometheus = "prometheus"
+)
+
+func init() {
+	if err := RegisterCollectorType(ExecCollectorType, NewExecCollector); err != nil {
+		panic(err)
+	}
+}
+
+// ExecCollector runs an external command and parses its stdout into metrics.
+// It is configured through plugin settings:
+//
+//	command: path of the executable (required)
+//	args:    whitespace-separated arguments
+//	format:  "json" (default) or "prometheus"
+//	metrics: whitespace-separated names of the gauges the command outputs,
+//	         declared for generated dashboards
+//	checkpoint: "true" to resume from the last exported datapoints
+//
+// Metrics may carry the time they were measured: a "timestamp" (RFC 3339) in
+// JSON output or a millisecond timestamp on Prometheus samples. JSON output
+// in the object form may also report events in an "events" array, which are
+// sent to the events pipeline.
+//
+// The command receives AWS_REGION and AWS_MONITOR_COLLECTOR in its environment.
+//
+// Commands that fetch datapoints, such as CloudWatch datapoints, set
+// checkpoint so each datapoint is exported once. The timestamp of the last
+// exported datapoint of each metric is kept per region, across restarts.
+// The command receives the oldest of them as AWS_MONITOR_SINCE (RFC 3339),
+// once a datapoint was exported in the region, and fetches datapoints from
+// there; datapoints at or before their metric's checkpoint are dropped.
+type ExecCollector struct {
+	*BaseCollector
+
+	command string
+	args    []string
+	format  string
+	metrics []string
+	// checkpoint resumes from the last exported datapoints
+	checkpoint bool
+}
+
+// execMetric is the JSON representation of a metric produced by a command
+type execMetric struct {
+	Name        string            `json:"name"`
+	Value       float64           `json:"value"`
+	Unit        string            `json:"unit"`
+	Labels      map[string]string `json:"labels"`
+	Description string            `json:"description"`
+	// Timestamp is when the value was measured; zero means collection time
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// execEvent is the JSON representation of an event reported by a command
+type execEvent struct {
+	Type       string            `json:"type"`
+	Severity   string            `json:"severity"`
+	Message    string            `json:"message"`
+	Region     string            `json:"region"`
+	Resource   string            `json:"resource"`
+	Attributes map[string]string `json:"attributes"`
+	// Timestamp is when the event occurred; zero means collection time
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewExecCollector creates an exec collector from its configuration
+func NewExecCollector(name string, cfg CollectorConfig, deps CollectorDependencies) (MetricCollector, error) {
+	command := cfg.Settings["command"]
+	if command == "" {
+		return nil, fmt.Errorf("exec collector %s: setting 'command' is required", name)
+	}
+
+	format := strings.ToLower(cfg.Settings["format"])
+	if format == "" {
+		format = ExecFormatJSON
+	}
+	if format != ExecFormatJSON && format != ExecFormatPrometheus {
+		return nil, fmt.Errorf("exec collector %s: unsupported format %s", name, format)
+	}
+
+	checkpoint := false
+	if value := cfg.Settings["checkpoint"]; value != "" {
+		var err error
+		if checkpoint, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("exec collector %s: invalid checkpoint setting %q", name, value)
+		}
+	}
+
+	collector := &ExecCollector{
+		BaseCollector: NewBaseCollector(name, fmt.Sprintf("Runs %s and collects its output", command),
+			deps.Config, cfg, deps.AWSProvider, deps.Logger),
+		command:    command,
+		args:       strings.Fields(cfg.Settings["args"]),
+		format:     format,
+		metrics:    strings.Fields(cfg.Settings["metrics"]),
+		checkpoint: checkpoint,
+	}
+	if checkpoint {
+		collector.EnableCheckpoints()
+	}
+	return collector, nil
+}
+
+// DescribeMetrics returns the gauges named by the metrics setting, after the
+// metrics every collector may produce
+func (ec *ExecCollector) DescribeMetrics() []MetricDescriptor {
+	metrics := ec.BaseCollector.DescribeMetrics()
+	for _, name := range ec.metrics {
+		metrics = append(metrics, MetricDescriptor{
+			Name:        name,
+			Kind:        KindGauge,
+			Description: fmt.Sprintf("Output of %s", ec.command),
+		})
+	}
+	return metrics
+}
+
+// Collect runs the configured command for the region
+func (ec *ExecCollector) Collect(ctx context.Context, region string) *CollectionResult {
+	return ec.CollectWithRetry(ctx, region, ec.run)
+}
+
+// run executes the command and converts its output into metrics
+func (ec *ExecCollector) run(ctx context.Context, region string) ([]MetricData, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, ec.command, ec.args...)
+	cmd.Env = append(os.Environ(),
+		"AWS_REGION="+region,
+		"AWS_MONITOR_COLLECTOR="+ec.Name())
+	if ec.checkpoint {
+		since, ok, err := ec.OldestCheckpoint(region)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeInternal, errors.CodeExecFailed,
+				"failed to load checkpoints")
+		}
+		if ok {
+			cmd.Env = append(cmd.Env, "AWS_MONITOR_SINCE="+since.UTC().Format(time.RFC3339))
+		}
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.NewTimeoutError("exec", ec.GetCollectorConfig().Timeout)
+		}
+		return nil, errors.Wrap(err, errors.ErrorTypeInternal, errors.CodeExecFailed,
+			fmt.Sprintf("command %s failed: %s", ec.command, strings.TrimSpace(stderr.String())))
+	}
+
+	var parsed []execMetric
+	var reported []events.Event
+	var err error
+	switch ec.format {
+	case ExecFormatPrometheus:
+		parsed, err = parsePrometheusText(stdout.Bytes())
+	default:
+		parsed, err = parseExecJSON(stdout.Bytes())
+		if err == nil {
+			reported, err = parseExecEvents(stdout.Bytes())
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeValidation, errors.CodeExecOutputInvalid,
+			fmt.Sprintf("failed to parse %s output of %s", ec.format, ec.command))
+	}
+
+	for _, event := range reported {
+		if event.Region == "" {
+			event.Region = region
+		}
+		ec.EmitEvent(event)
+	}
+
+	metrics := AcquireMetrics(len(parsed) + 1)
+	for _, m := range parsed {
+		labels := m.Labels
+		if labels == nil {
+			labels = AcquireLabels(1)
+		}
+		if _, exists := labels["region"]; !exists {
+			labels["region"] = region
+		}
+		metric := ec.CreateMetricAt(m.Name, m.Value, m.Unit, m.Timestamp, labels)
+		metric.Description = m.Description
+		metrics = append(metrics, metric)
+	}
+	if ec.checkpoint {
+		if metrics, err = ec.SkipCheckpointed(region, metrics); err != nil {
+			return nil, errors.Wrap(err, errors.ErrorTypeInternal, errors.CodeExecFailed,
+				"failed to load checkpoints")
+		}
+	}
+
+	keep, truncated := ec.ResourceCap(region, len(metrics))
+	metrics = metrics[:keep]
+	if truncated != nil {
+		metrics = append(metrics, *truncated)
+	}
+
+	return metrics, nil
+}
+
+// parseExecJSON parses either a JSON array of metrics or an object with a "metrics" array
+func parseExecJSON(data []byte) ([]execMetric, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var metrics []execMetric
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &metrics); err != nil {
+			return nil, err
+		}
+	} else {
+		var wrapper struct {
+			Metrics []execMetric `json:"metrics"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, err
+		}
+		metrics = wrapper.Metrics
+	}
+
+	for i := range metrics {
+		if metrics[i].Name == "" {
+			return nil, fmt.Errorf("metric %d has no name", i)
+		}
+	}
+
+	return metrics, nil
+}
+
+// parseExecEvents parses the "events" array of JSON output in the object
+// form. The array form carries no events.
+func parseExecEvents(data []byte) ([]events.Event, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, nil
+	}
+
+	var wrapper struct {
+		Events []execEvent `json:"events"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+
+	reported := make([]events.Event, 0, len(wrapper.Events))
+	for i, e := range wrapper.Events {
+		if e.Type == "" {
+			return nil, fmt.Errorf("event %d has no type", i)
+		}
+		severity := events.SeverityInfo
+		if e.Severity != "" {
+			var err error
+			if severity, err = events.ParseSeverity(e.Severity); err != nil {
+				return nil, fmt.Errorf("event %d: %w", i, err)
+			}
+		}
+		reported = append(reported, events.Event{
+			Type:       e.Type,
+			Severity:   severity,
+			Message:    e.Message,
+			Region:     e.Region,
+			Resource:   e.Resource,
+			Attributes: e.Attributes,
+			Time:       e.Timestamp,
+		})
+	}
+	return reported, nil
+}
+
+// parsePrometheusText parses the Prometheus text exposition format. HELP
+// comments become metric descriptions and sample timestamps are kept; TYPE
+// comments are ignored.
+func parsePrometheusText(data []byte) ([]execMetric, error) {
+	var metrics []execMetric
+	help := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(line, " ", 4)
+			if len(fields) == 4 && fields[1] == "HELP" {
+				help[fields[2]] = fields[3]
+			}
+			continue
+		}
+
+		metric, err := parsePrometheusSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		metric.Description = help[metric.Name]
+		metrics = append(metrics, metric)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// parsePrometheusSample parses a single `name{labels} value [timestamp]` line
+func parsePrometheusSample(line string) (execMetric, error) {
+	metric := execMetric{Labels: make(map[string]string)}
+
+	rest := line
+	if idx := strings.IndexByte(line, '{'); idx >= 0 {
+		end := strings.LastIndexByte(line, '}')
+		if end < idx {
+			return metric, fmt.Errorf("unterminated label set")
+		}
+		metric.Name = Intern(line[:idx])
+		labels, err := parsePrometheusLabels(line[idx+1 : end])
+		if err != nil {
+			return metric, err
+		}
+		metric.Labels = labels
+		rest = line[end+1:]
+	} else {
+		fields := strings.Fields(line)
+		metric.Name = Intern(fields[0])
+		rest = strings.TrimPrefix(line, fields[0])
+	}
+
+	fields := strings.Fields(rest)
+	if metric.Name == "" || len(fields) == 0 {
+		return metric, fmt.Errorf("invalid sample %q", line)
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return metric, fmt.Errorf("invalid value for %s: %w", metric.Name, err)
+	}
+	metric.Value = value
+
+	if len(fields) > 1 {
+		millis, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return metric, fmt.Errorf("invalid timestamp for %s: %w", metric.Name, err)
+		}
+		metric.Timestamp = time.UnixMilli(millis)
+	}
+
+	return metric, nil
+}
+
+// parsePrometheusLabels parses the inside of a `{key="value",...}` label set.
+// Keys and values are interned, so they don't keep the whole line alive.
+func parsePrometheusLabels(s string) (map[string]string, error) {
+	labels := AcquireLabels(strings.Count(s, "=") + 1)
+
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			return nil, fmt.Errorf("invalid label set near %q", s)
+		}
+		key := Intern(strings.TrimSpace(s[:eq]))
+
+		// Find the closing quote, honoring escapes. Values without escapes,
+		// the usual case, are interned without building a copy.
+		var value strings.Builder
+		escaped := false
+		i := eq + 2
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				if !escaped {
+					value.WriteString(s[eq+2 : i])
+					escaped = true
+				}
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			if escaped {
+				value.WriteByte(s[i])
+			}
+		}
+		if i >= len(s) {
+			return nil, fmt.Errorf("unterminated value for label %s", key)
+		}
+
+		if escaped {
+			labels[key] = Intern(value.String())
+		} else {
+			labels[key] = Intern(s[eq+2 : i])
+		}
+		s = strings.TrimPrefix(strings.TrimSpace(s[i+1:]), ",")
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,357 @@
+package collectors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/events"
+	"aws-monitoring/internal/state"
+)
+
+func TestParseExecJSON(t *testing.T) {
+	arrayOutput := `[{"name":"queue_depth","value":12,"unit":"Count","labels":{"queue":"orders"}}]`
+	metrics, err := parseExecJSON([]byte(arrayOutput))
+	if err != nil {
+		t.Fatalf("Expected no error parsing array, got: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].Name != "queue_depth" || metrics[0].Value != 12 {
+		t.Errorf("Unexpected metrics parsed from array: %+v", metrics)
+	}
+
+	objectOutput := `{"metrics":[{"name":"a","value":1},{"name":"b","value":2}]}`
+	metrics, err = parseExecJSON([]byte(objectOutput))
+	if err != nil {
+		t.Fatalf("Expected no error parsing object, got: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Errorf("Expected 2 metrics from object, got %d", len(metrics))
+	}
+
+	if _, err := parseExecJSON([]byte(`[{"value":1}]`)); err == nil {
+		t.Error("Expected error for metric without a name")
+	}
+
+	if _, err := parseExecJSON([]byte(`not json`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestParsePrometheusText(t *testing.T) {
+	output := `# HELP http_requests_total Total HTTP requests
+# TYPE http_requests_total counter
+http_requests_total{method="get",path="/a,b"} 1027 1395066363000
+http_requests_total{method="post"} 3
+temperature 21.5
+`
+	metrics, err := parsePrometheusText([]byte(output))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(metrics) != 3 {
+		t.Fatalf("Expected 3 metrics, got %d", len(metrics))
+	}
+
+	if metrics[0].Labels["path"] != "/a,b" || metrics[0].Labels["method"] != "get" {
+		t.Errorf("Unexpected labels: %v", metrics[0].Labels)
+	}
+
+	if metrics[0].Value != 1027 {
+		t.Errorf("Expected value 1027, got %v", metrics[0].Value)
+	}
+
+	if !metrics[0].Timestamp.Equal(time.UnixMilli(1395066363000)) {
+		t.Errorf("Expected sample timestamp to be kept, got %v", metrics[0].Timestamp)
+	}
+
+	if !metrics[1].Timestamp.IsZero() {
+		t.Errorf("Expected zero timestamp for sample without one, got %v", metrics[1].Timestamp)
+	}
+
+	if metrics[0].Description != "Total HTTP requests" {
+		t.Errorf("Expected HELP text as description, got %q", metrics[0].Description)
+	}
+
+	if metrics[2].Name != "temperature" || metrics[2].Value != 21.5 {
+		t.Errorf("Unexpected unlabelled metric: %+v", metrics[2])
+	}
+
+	if _, err := parsePrometheusText([]byte(`broken{label="x" 1`)); err == nil {
+		t.Error("Expected error for unterminated label set")
+	}
+
+	if _, err := parsePrometheusText([]byte(`sample 1 yesterday`)); err == nil {
+		t.Error("Expected error for invalid timestamp")
+	}
+}
+
+func TestExecCollector(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell script test on windows")
+	}
+
+	script := filepath.Join(t.TempDir(), "check.sh")
+	content := "#!/bin/sh\necho '[{\"name\":\"script_metric\",\"value\":42,\"unit\":\"Count\",\"labels\":{\"arg\":\"'$1'\",\"env_region\":\"'$AWS_REGION'\"}}]'\n"
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	cfg := DefaultCollectorConfig()
+	cfg.Retries = 0
+	cfg.Settings = map[string]string{"command": script, "args": "hello"}
+
+	collector, err := NewExecCollector("script", cfg, newTestPluginDeps(t))
+	if err != nil {
+		t.Fatalf("Expected no error creating exec collector, got: %v", err)
+	}
+
+	result := collector.Collect(context.Background(), "us-east-1")
+	if result.Error != nil {
+		t.Fatalf("Expected no error collecting, got: %v", result.Error)
+	}
+
+	if len(result.Metrics) != 1 {
+		t.Fatalf("Expected 1 metric, got %d", len(result.Metrics))
+	}
+
+	metric := result.Metrics[0]
+	if metric.Value != 42 || metric.Labels["arg"] != "hello" {
+		t.Errorf("Unexpected metric: %+v", metric)
+	}
+	if metric.Labels["env_region"] != "us-east-1" || metric.Labels["region"] != "us-east-1" {
+		t.Errorf("Expected region to be passed to the command and labelled, got %v", metric.Labels)
+	}
+	if metric.Labels["collector"] != "script" {
+		t.Errorf("Expected common labels to be applied, got %v", metric.Labels)
+	}
+
+	// A failing command surfaces an error
+	cfg.Settings = map[string]string{"command": filepath.Join(t.TempDir(), "missing.sh")}
+	collector, err = NewExecCollector("missing", cfg, newTestPluginDeps(t))
+	if err != nil {
+		t.Fatalf("Expected no error creating exec collector, got: %v", err)
+	}
+	if result := collector.Collect(context.Background(), "us-east-1"); result.Error == nil {
+		t.Error("Expected error for missing command")
+	}
+}
+
+func TestNewExecCollectorValidation(t *testing.T) {
+	cfg := DefaultCollectorConfig()
+
+	if _, err := NewExecCollector("no-command", cfg, newTestPluginDeps(t)); err == nil {
+		t.Error("Expected error when command is missing")
+	}
+
+	cfg.Settings = map[string]string{"command": "true", "format": "xml"}
+	if _, err := NewExecCollector("bad-format", cfg, newTestPluginDeps(t)); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+
+	cfg.Settings = map[string]string{"command": "true", "checkpoint": "sometimes"}
+	if _, err := NewExecCollector("bad-checkpoint", cfg, newTestPluginDeps(t)); err == nil {
+		t.Error("Expected error for an invalid checkpoint setting")
+	}
+
+	found := false
+	for _, typeName := range RegisteredCollectorTypes() {
+		if typeName == ExecCollectorType {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected exec collector type to be registered")
+	}
+}
+
+func TestExecCollectorDescribeMetrics(t *testing.T) {
+	cfg := DefaultCollectorConfig()
+	cfg.MaxResources = 10
+	cfg.Settings = map[string]string{"command": "true", "metrics": "queue_depth  queue_age"}
+
+	collector, err := NewExecCollector("queues", cfg, newTestPluginDeps(t))
+	if err != nil {
+		t.Fatalf("Expected no error creating exec collector, got: %v", err)
+	}
+
+	var names []string
+	for _, metric := range DescribeMetrics(collector) {
+		names = append(names, metric.Name)
+	}
+	expected := []string{ErrorsTotalMetric, ResourcesTruncatedMetric, "queue_depth", "queue_age"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected metrics %v, got %v", expected, names)
+	}
+}
+
+func TestExecCollectorCheckpoints(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell script test on windows")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "cpu.sh")
+	sinceFile := filepath.Join(dir, "since")
+	content := `#!/bin/sh
+printf '%s' "$AWS_MONITOR_SINCE" > ` + sinceFile + `
+echo '[{"name":"cpu_utilization","value":40,"timestamp":"2024-05-01T12:00:00Z"},{"name":"cpu_utilization","value":55,"timestamp":"2024-05-01T12:05:00Z"}]'
+`
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatalf("Failed to open state store: %v", err)
+	}
+	deps := newTestPluginDeps(t)
+	deps.State = store
+	newCollector := func() MetricCollector {
+		collector, err := NewPluginCollector(config.PluginConfig{
+			Name:     "cpu",
+			Type:     ExecCollectorType,
+			Enabled:  true,
+			Settings: map[string]string{"command": script, "checkpoint": "true"},
+		}, deps)
+		if err != nil {
+			t.Fatalf("Expected no error creating exec collector, got: %v", err)
+		}
+		return collector
+	}
+
+	// Until the datapoints are committed, they are fetched again
+	collector := newCollector()
+	for i := 0; i < 2; i++ {
+		result := collector.Collect(context.Background(), "us-east-1")
+		if result.Error != nil || len(result.Metrics) != 2 {
+			t.Fatalf("Expected 2 datapoints and no error, got %d, %v", len(result.Metrics), result.Error)
+		}
+		if i == 1 {
+			if err := collector.(Checkpointer).CommitCheckpoints("us-east-1", result.Metrics); err != nil {
+				t.Fatalf("Expected no error committing checkpoints, got: %v", err)
+			}
+		}
+	}
+
+	// After a restart, the command fetches from the checkpoint and the
+	// exported datapoints are dropped
+	collector = newCollector()
+	result := collector.Collect(context.Background(), "us-east-1")
+	if result.Error != nil || len(result.Metrics) != 0 {
+		t.Errorf("Expected the exported datapoints to be dropped, got %d, %v", len(result.Metrics), result.Error)
+	}
+	since, err := os.ReadFile(sinceFile)
+	if err != nil {
+		t.Fatalf("Failed to read AWS_MONITOR_SINCE: %v", err)
+	}
+	if string(since) != "2024-05-01T12:05:00Z" {
+		t.Errorf("Expected AWS_MONITOR_SINCE to be the last exported datapoint, got %q", since)
+	}
+}
+
+func TestCheckpointsPerSeries(t *testing.T) {
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatalf("Failed to open state store: %v", err)
+	}
+	deps := newTestPluginDeps(t)
+	bc := NewBaseCollector("cpu", "CPU", deps.Config, DefaultCollectorConfig(), deps.AWSProvider, deps.Logger)
+	bc.SetStateStore(store)
+	bc.EnableCheckpoints()
+
+	datapoint := func(instance string, minute int) MetricData {
+		return MetricData{
+			Name:      "cpu_utilization",
+			Labels:    map[string]string{"instance_id": instance},
+			Timestamp: time.Date(2024, 5, 1, 12, minute, 0, 0, time.UTC),
+		}
+	}
+	if err := bc.CommitCheckpoints("us-east-1", []MetricData{datapoint("i-a", 5), datapoint("i-b", 0)}); err != nil {
+		t.Fatalf("Expected no error committing checkpoints, got: %v", err)
+	}
+
+	// A datapoint of i-b newer than its own checkpoint is kept, though i-a
+	// was exported up to a later time
+	kept, err := bc.SkipCheckpointed("us-east-1", []MetricData{datapoint("i-a", 5), datapoint("i-b", 0), datapoint("i-b", 3)})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(kept) != 1 || kept[0].Labels["instance_id"] != "i-b" || kept[0].Timestamp.Minute() != 3 {
+		t.Errorf("Expected only the new datapoint of i-b, got %+v", kept)
+	}
+
+	oldest, ok, err := bc.OldestCheckpoint("us-east-1")
+	if err != nil || !ok || oldest.Minute() != 0 {
+		t.Errorf("Expected the checkpoint of i-b as the oldest, got %v, %t, %v", oldest, ok, err)
+	}
+}
+
+// recordingEmitter keeps the events it receives
+type recordingEmitter struct {
+	events []events.Event
+}
+
+func (e *recordingEmitter) Emit(event events.Event) bool {
+	e.events = append(e.events, event)
+	return true
+}
+
+func TestExecCollectorEvents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell script test on windows")
+	}
+
+	script := filepath.Join(t.TempDir(), "certs.sh")
+	content := `#!/bin/sh
+echo '{"metrics":[{"name":"certificates","value":3}],"events":[{"type":"acm.certificate.expiring","severity":"warning","message":"expires in 14 days","resource":"arn:aws:acm:us-east-1:123456789012:certificate/abc"}]}'
+`
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	emitter := &recordingEmitter{}
+	deps := newTestPluginDeps(t)
+	deps.Events = emitter
+
+	collector, err := NewPluginCollector(config.PluginConfig{
+		Name:     "certs",
+		Type:     ExecCollectorType,
+		Enabled:  true,
+		Settings: map[string]string{"command": script},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Expected no error creating exec collector, got: %v", err)
+	}
+	if result := collector.Collect(context.Background(), "us-east-1"); result.Error != nil || len(result.Metrics) != 1 {
+		t.Fatalf("Expected 1 metric and no error, got %d metrics, %v", len(result.Metrics), result.Error)
+	}
+
+	if len(emitter.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(emitter.events))
+	}
+	event := emitter.events[0]
+	if event.Type != "acm.certificate.expiring" || event.Severity != events.SeverityWarning {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if event.Collector != "certs" || event.Region != "us-east-1" {
+		t.Errorf("Expected the event attributed to the collector and region, got %+v", event)
+	}
+}
+
+func TestParseExecEvents(t *testing.T) {
+	if reported, err := parseExecEvents([]byte(`[{"name":"a","value":1}]`)); err != nil || len(reported) != 0 {
+		t.Errorf("Expected no events from the array form, got %v, %v", reported, err)
+	}
+	if _, err := parseExecEvents([]byte(`{"events":[{"message":"no type"}]}`)); err == nil {
+		t.Error("Expected error for an event without a type")
+	}
+	if _, err := parseExecEvents([]byte(`{"events":[{"type":"a","severity":"urgent"}]}`)); err == nil {
+		t.Error("Expected error for an unknown severity")
+	}
+}
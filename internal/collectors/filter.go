@@ -0,0 +1,31 @@
+package collectors
+
+import "path"
+
+// filterMetrics drops the metrics whose names match one of the collector's
+// disabled metric patterns
+func (bc *BaseCollector) filterMetrics(metrics []MetricData) []MetricData {
+	patterns := bc.collectorConfig.DisabledMetrics
+	if len(patterns) == 0 {
+		return metrics
+	}
+
+	kept := metrics[:0]
+	for _, metric := range metrics {
+		if !MetricDisabled(patterns, metric.Name) {
+			kept = append(kept, metric)
+		}
+	}
+	return kept
+}
+
+// MetricDisabled reports whether name matches one of the glob patterns of
+// disabled metrics, e.g. "s3_object_*"
+func MetricDisabled(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
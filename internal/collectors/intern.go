@@ -0,0 +1,22 @@
+package collectors
+
+import (
+	"unique"
+)
+
+// Intern returns the canonical copy of s. Label names and values repeat
+// across the datapoints of every collection (regions, resource IDs, units),
+// so interning them makes the memory they hold scale with the number of
+// distinct values rather than with the number of datapoints. Interned
+// strings are released once no longer used.
+func Intern(s string) string {
+	return unique.Make(s).Value()
+}
+
+// InternLabels replaces the values of labels with their canonical copies.
+// The keys are kept, since a map key can't be replaced in place.
+func InternLabels(labels map[string]string) {
+	for key, value := range labels {
+		labels[key] = Intern(value)
+	}
+}
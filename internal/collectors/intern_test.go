@@ -0,0 +1,127 @@
+package collectors
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestInternLabels(t *testing.T) {
+	first := map[string]string{"region": string([]byte("us-east-1"))}
+	second := map[string]string{"region": string([]byte("us-east-1"))}
+	InternLabels(first)
+	InternLabels(second)
+
+	if first["region"] != "us-east-1" {
+		t.Errorf("Expected the value unchanged, got %q", first["region"])
+	}
+	if unsafe.StringData(first["region"]) != unsafe.StringData(second["region"]) {
+		t.Error("Expected equal values to share one copy")
+	}
+}
+
+func TestParsePrometheusLabelsAllocations(t *testing.T) {
+	set := `instance="i-1234567890abcdef0",queue="orders",team="platform",env="prod"`
+	if _, err := parsePrometheusLabels(set); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Once the values were seen, only the label map is allocated, however
+	// many labels there are
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = parsePrometheusLabels(set)
+	})
+	if allocs > 2 {
+		t.Errorf("Expected at most 2 allocations per label set, got %v", allocs)
+	}
+}
+
+func TestCreateMetricInterns(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	bc := NewBaseCollector("test", "Test collector", &config.Config{}, DefaultCollectorConfig(), &mockAWSProvider{}, log)
+
+	// Values decoded from two API responses are distinct copies
+	first := bc.CreateMetric(string([]byte("cpu_utilization")), 1, string([]byte("Percent")),
+		map[string]string{"instance_id": string([]byte("i-0123456789abcdef0"))})
+	second := bc.CreateMetric(string([]byte("cpu_utilization")), 2, string([]byte("Percent")),
+		map[string]string{"instance_id": string([]byte("i-0123456789abcdef0"))})
+
+	if unsafe.StringData(first.Name) != unsafe.StringData(second.Name) {
+		t.Error("Expected equal names to share one copy")
+	}
+	if unsafe.StringData(first.Unit) != unsafe.StringData(second.Unit) {
+		t.Error("Expected equal units to share one copy")
+	}
+	if unsafe.StringData(first.Labels["instance_id"]) != unsafe.StringData(second.Labels["instance_id"]) {
+		t.Error("Expected equal label values to share one copy")
+	}
+}
+
+// BenchmarkCreateMetricRetained measures the memory held by the metrics of
+// 10 collections of 1000 datapoints kept for processing, e.g. by windows of
+// recent values, whose names and label values are decoded afresh from every
+// API response
+func BenchmarkCreateMetricRetained(b *testing.B) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		b.Fatalf("Failed to create logger: %v", err)
+	}
+	bc := NewBaseCollector("bench", "Benchmark collector", &config.Config{}, DefaultCollectorConfig(), &mockAWSProvider{}, log)
+
+	const collections, datapoints = 10, 1000
+	decoded := func(s string) string { return string([]byte(s)) }
+	collect := func(create func(name, unit string, labels map[string]string) MetricData) []MetricData {
+		metrics := make([]MetricData, 0, datapoints)
+		for i := 0; i < datapoints; i++ {
+			labels := map[string]string{
+				"instance_id":   decoded(fmt.Sprintf("i-%017d", i)),
+				"instance_type": decoded("m5.large"),
+				"region":        decoded("us-east-1"),
+			}
+			metrics = append(metrics, create(decoded("cpu_utilization"), decoded("Percent"), labels))
+		}
+		return metrics
+	}
+
+	run := func(b *testing.B, create func(name, unit string, labels map[string]string) MetricData) {
+		b.ReportAllocs()
+		var retained uint64
+		for i := 0; i < b.N; i++ {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			kept := make([][]MetricData, collections)
+			for c := range kept {
+				kept[c] = collect(create)
+			}
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			runtime.KeepAlive(kept)
+			if after.HeapAlloc > before.HeapAlloc {
+				retained += after.HeapAlloc - before.HeapAlloc
+			}
+		}
+		b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+	}
+
+	b.Run("raw", func(b *testing.B) {
+		run(b, func(name, unit string, labels map[string]string) MetricData {
+			// The common labels CreateMetric adds
+			labels["collector"] = "bench"
+			labels["service"] = "aws-monitor"
+			return MetricData{Name: name, Unit: unit, Labels: labels}
+		})
+	})
+	b.Run("created", func(b *testing.B) {
+		run(b, func(name, unit string, labels map[string]string) MetricData {
+			return bc.CreateMetric(name, 0, unit, labels)
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package collectors
+
+// Collector priorities, deciding which jobs are postponed first under memory
+// pressure
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// BatchScaler shrinks batch sizes while memory is under pressure
+type BatchScaler interface {
+	ScaleBatch(size int) int
+}
+
+// SetMemoryMonitor sets what scales the collector's batch sizes to the
+// memory pressure
+func (bc *BaseCollector) SetMemoryMonitor(memory BatchScaler) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.memory = memory
+}
+
+// BatchSize returns how many items, such as the results of one page of an
+// AWS API call, the collector should fetch at once when it would normally
+// fetch size. It is smaller while memory is under pressure.
+func (bc *BaseCollector) BatchSize(size int) int {
+	bc.mu.RLock()
+	memory := bc.memory
+	bc.mu.RUnlock()
+	if memory == nil {
+		return size
+	}
+	return memory.ScaleBatch(size)
+}
@@ -0,0 +1,73 @@
+package collectors
+
+// MetricKind is how the values of a metric behave over time
+type MetricKind string
+
+const (
+	// KindGauge is a value that goes up and down, e.g. a resource count
+	KindGauge MetricKind = "gauge"
+	// KindCounter is a cumulative total that only goes up
+	KindCounter MetricKind = "counter"
+	// KindHistogram is reported as cumulative _bucket series with an le
+	// label, and _sum and _count series
+	KindHistogram MetricKind = "histogram"
+)
+
+// MetricDescriptor describes a metric a collector produces, without a value
+type MetricDescriptor struct {
+	Name        string     `json:"name"`
+	Kind        MetricKind `json:"kind"`
+	Unit        string     `json:"unit"`
+	Description string     `json:"description"`
+	// Labels are the labels that distinguish the series of the metric,
+	// besides the labels every collector adds
+	Labels []string `json:"labels,omitempty"`
+}
+
+// MetricDescriber is implemented by collectors that declare the metrics they
+// produce, so that dashboards can be generated for them
+type MetricDescriber interface {
+	DescribeMetrics() []MetricDescriptor
+}
+
+// DescribeMetrics returns the metrics a collector declares, or nil if it does
+// not declare any
+func DescribeMetrics(collector MetricCollector) []MetricDescriptor {
+	if describer, ok := collector.(MetricDescriber); ok {
+		return describer.DescribeMetrics()
+	}
+	return nil
+}
+
+// DescribeMetrics returns the metrics every collector may produce: error
+// counts, and the resource cap and SLO metrics when they are configured.
+// Collectors embedding BaseCollector declare their own metrics by appending
+// to these.
+func (bc *BaseCollector) DescribeMetrics() []MetricDescriptor {
+	metrics := []MetricDescriptor{{
+		Name:        ErrorsTotalMetric,
+		Kind:        KindCounter,
+		Unit:        "Count",
+		Description: "Total number of collector errors",
+		Labels:      []string{"region", "error_type", "error_code"},
+	}}
+	if bc.collectorConfig.MaxResources > 0 {
+		metrics = append(metrics, MetricDescriptor{
+			Name:        ResourcesTruncatedMetric,
+			Kind:        KindGauge,
+			Unit:        "Count",
+			Description: "Resources skipped because the collector reached its max_resources cap",
+			Labels:      []string{"region"},
+		})
+	}
+	if bc.slo != nil {
+		metrics = append(metrics,
+			MetricDescriptor{Name: SLOBudgetRemainingMetric, Kind: KindGauge, Unit: "Ratio",
+				Description: "Fraction of the collection error budget remaining", Labels: []string{"region", "window"}},
+			MetricDescriptor{Name: SLOBurnRateMetric, Kind: KindGauge, Unit: "Ratio",
+				Description: "Rate at which the collection error budget is being spent", Labels: []string{"region", "window"}},
+			MetricDescriptor{Name: SLOSuccessRateMetric, Kind: KindGauge, Unit: "Ratio",
+				Description: "Fraction of successful collections", Labels: []string{"region", "window"}})
+	}
+	return metrics
+}
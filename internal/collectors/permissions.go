@@ -0,0 +1,22 @@
+package collectors
+
+import (
+	"aws-monitoring/internal/aws"
+)
+
+// PermissionRequirer is implemented by collectors that call AWS APIs, so that
+// missing IAM permissions can be reported before collection starts
+type PermissionRequirer interface {
+	// RequiredPermissions returns the IAM actions, and optionally resources,
+	// the collector calls
+	RequiredPermissions() []aws.Permission
+}
+
+// RequiredPermissions returns the permissions a collector needs, or nil if it
+// does not declare any
+func RequiredPermissions(collector MetricCollector) []aws.Permission {
+	if requirer, ok := collector.(PermissionRequirer); ok {
+		return requirer.RequiredPermissions()
+	}
+	return nil
+}
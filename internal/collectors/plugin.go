@@ -0,0 +1,239 @@
+package collectors
+
+import (
+	"fmt"
+	"plugin"
+	"sort"
+	"sync"
+	"time"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+// PluginRegisterSymbol is the optional function a Go plugin can export to
+// register its collector types. Plugins may also register from init().
+const PluginRegisterSymbol = "RegisterCollectors"
+
+// CollectorDependencies are the shared dependencies handed to collector constructors
+type CollectorDependencies struct {
+	// Config is the application configuration
+	Config *config.Config
+	// AWSProvider provides AWS client access
+	AWSProvider aws.ClientProvider
+	// Inventory is the shared resource inventory cache
+	Inventory *aws.InventoryCache
+	// Account identifies the monitored AWS account, empty if not resolved
+	Account aws.AccountInfo
+	// Alerts is notified of high and critical severity collector errors, if set
+	Alerts AlertNotifier
+	// Reporter receives critical collector errors for error tracking, if set
+	Reporter ErrorReporter
+	// Events receives the events collectors emit, if set
+	Events EventEmitter
+	// State persists collector state across restarts, if set
+	State StateStore
+	// Memory scales collector batch sizes to the memory pressure, if set
+	Memory BatchScaler
+	// PlatformLabels identify the platform aws-monitor runs on, such as its
+	// Kubernetes pod, and are added to every metric, if set
+	PlatformLabels map[string]string
+	// Logger is the parent logger for the collector
+	Logger *logger.Logger
+}
+
+// CollectorConstructor creates a collector instance with the given name and configuration
+type CollectorConstructor func(name string, cfg CollectorConfig, deps CollectorDependencies) (MetricCollector, error)
+
+var (
+	collectorTypesMu sync.RWMutex
+	collectorTypes   = make(map[string]CollectorConstructor)
+)
+
+// RegisterCollectorType makes a collector type available to the collector factory
+// and to plugin configuration. External modules typically call it from init().
+func RegisterCollectorType(typeName string, constructor CollectorConstructor) error {
+	if typeName == "" {
+		return fmt.Errorf("collector type cannot be empty")
+	}
+	if constructor == nil {
+		return fmt.Errorf("constructor for collector type %s cannot be nil", typeName)
+	}
+
+	collectorTypesMu.Lock()
+	defer collectorTypesMu.Unlock()
+
+	if _, exists := collectorTypes[typeName]; exists {
+		return fmt.Errorf("collector type %s already registered", typeName)
+	}
+
+	collectorTypes[typeName] = constructor
+	return nil
+}
+
+// RegisteredCollectorTypes returns the names of all registered collector types
+func RegisteredCollectorTypes() []string {
+	collectorTypesMu.RLock()
+	defer collectorTypesMu.RUnlock()
+
+	types := make([]string, 0, len(collectorTypes))
+	for typeName := range collectorTypes {
+		types = append(types, typeName)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// lookupCollectorType returns the constructor for a registered collector type
+func lookupCollectorType(typeName string) (CollectorConstructor, bool) {
+	collectorTypesMu.RLock()
+	defer collectorTypesMu.RUnlock()
+
+	constructor, exists := collectorTypes[typeName]
+	return constructor, exists
+}
+
+// LoadPlugin opens a Go plugin (.so) so that its collector types are registered.
+// If the plugin exports RegisterCollectors, it is called after the plugin is opened.
+func LoadPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open plugin %s: %w", path, err)
+	}
+
+	symbol, err := p.Lookup(PluginRegisterSymbol)
+	if err != nil {
+		// Plugins without the symbol register their types from init()
+		return nil
+	}
+
+	register, ok := symbol.(func() error)
+	if !ok {
+		return fmt.Errorf("plugin %s: %s has type %T, expected func() error", path, PluginRegisterSymbol, symbol)
+	}
+
+	if err := register(); err != nil {
+		return fmt.Errorf("plugin %s: failed to register collectors: %w", path, err)
+	}
+
+	return nil
+}
+
+// LoadPluginCollectors loads any configured plugin files and creates a collector
+// for every enabled plugin entry
+func LoadPluginCollectors(pluginConfigs []config.PluginConfig, deps CollectorDependencies) ([]MetricCollector, error) {
+	log := deps.Logger.WithComponent("collector-plugins")
+	loaded := make(map[string]bool)
+	result := make([]MetricCollector, 0, len(pluginConfigs))
+
+	for _, pluginCfg := range pluginConfigs {
+		if !pluginCfg.Enabled {
+			log.Info("Plugin collector disabled", logger.String("collector", pluginCfg.Name))
+			continue
+		}
+
+		if pluginCfg.Path != "" && !loaded[pluginCfg.Path] {
+			if err := LoadPlugin(pluginCfg.Path); err != nil {
+				return nil, err
+			}
+			loaded[pluginCfg.Path] = true
+			log.Info("Plugin loaded", logger.String("path", pluginCfg.Path))
+		}
+
+		collector, err := NewPluginCollector(pluginCfg, deps)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, collector)
+		log.Info("Plugin collector created",
+			logger.String("collector", pluginCfg.Name),
+			logger.String("type", pluginCfg.Type))
+	}
+
+	return result, nil
+}
+
+// NewPluginCollector creates a collector of a registered type from a plugin
+// configuration entry. The plugin file, if any, must already be loaded.
+func NewPluginCollector(pluginCfg config.PluginConfig, deps CollectorDependencies) (MetricCollector, error) {
+	constructor, exists := lookupCollectorType(pluginCfg.Type)
+	if !exists {
+		return nil, fmt.Errorf("collector %s: unknown collector type %s (registered: %v)",
+			pluginCfg.Name, pluginCfg.Type, RegisteredCollectorTypes())
+	}
+
+	collector, err := constructor(pluginCfg.Name, pluginCollectorConfig(pluginCfg), deps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collector %s of type %s: %w", pluginCfg.Name, pluginCfg.Type, err)
+	}
+
+	applyDependencies(collector, deps)
+	return collector, nil
+}
+
+// applyDependencies hands shared dependencies to collectors that accept them,
+// typically through an embedded BaseCollector
+func applyDependencies(collector MetricCollector, deps CollectorDependencies) {
+	if c, ok := collector.(interface{ SetInventoryCache(*aws.InventoryCache) }); ok && deps.Inventory != nil {
+		c.SetInventoryCache(deps.Inventory)
+	}
+	if c, ok := collector.(interface{ SetAccountInfo(aws.AccountInfo) }); ok && deps.Account.ID != "" {
+		c.SetAccountInfo(deps.Account)
+	}
+	if c, ok := collector.(interface{ SetAlertNotifier(AlertNotifier) }); ok && deps.Alerts != nil {
+		c.SetAlertNotifier(deps.Alerts)
+	}
+	if c, ok := collector.(interface{ SetErrorReporter(ErrorReporter) }); ok && deps.Reporter != nil {
+		c.SetErrorReporter(deps.Reporter)
+	}
+	if c, ok := collector.(interface{ SetEventEmitter(EventEmitter) }); ok && deps.Events != nil {
+		c.SetEventEmitter(deps.Events)
+	}
+	if c, ok := collector.(interface{ SetStateStore(StateStore) }); ok && deps.State != nil {
+		c.SetStateStore(deps.State)
+	}
+	if c, ok := collector.(interface{ SetMemoryMonitor(BatchScaler) }); ok && deps.Memory != nil {
+		c.SetMemoryMonitor(deps.Memory)
+	}
+	if c, ok := collector.(interface{ SetPlatformLabels(map[string]string) }); ok && len(deps.PlatformLabels) > 0 {
+		c.SetPlatformLabels(deps.PlatformLabels)
+	}
+}
+
+// pluginCollectorConfig builds a CollectorConfig from a plugin configuration entry
+func pluginCollectorConfig(pluginCfg config.PluginConfig) CollectorConfig {
+	cfg := DefaultCollectorConfig()
+	cfg.Enabled = pluginCfg.Enabled
+	if pluginCfg.CollectionInterval > 0 {
+		cfg.Interval = time.Duration(pluginCfg.CollectionInterval)
+	}
+	cfg.EnabledRegions = pluginCfg.Regions
+	cfg.Groups = pluginCfg.Groups
+	cfg.MaxResources = pluginCfg.MaxResources
+	cfg.DisabledMetrics = pluginCfg.DisabledMetrics
+	cfg.Priority = pluginCfg.Priority
+	if len(pluginCfg.RegionIntervals) > 0 {
+		cfg.RegionIntervals = make(map[string]time.Duration, len(pluginCfg.RegionIntervals))
+		for region, interval := range pluginCfg.RegionIntervals {
+			cfg.RegionIntervals[region] = time.Duration(interval)
+		}
+	}
+	cfg.Enrichment = EnrichmentConfig{
+		Enabled:       pluginCfg.Enrichment.Enabled,
+		ResourceLabel: pluginCfg.Enrichment.ResourceLabel,
+		Tags:          pluginCfg.Enrichment.Tags,
+	}
+	cfg.SLO = SLOConfig{
+		Target:            pluginCfg.SLO.Target,
+		BurnRateThreshold: pluginCfg.SLO.BurnRateThreshold,
+	}
+	for _, window := range pluginCfg.SLO.Windows {
+		cfg.SLO.Windows = append(cfg.SLO.Windows, time.Duration(window))
+	}
+	cfg.Settings = pluginCfg.Settings
+
+	return cfg
+}
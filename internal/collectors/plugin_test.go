@@ -0,0 +1,144 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+func newTestPluginDeps(t *testing.T) CollectorDependencies {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	return CollectorDependencies{
+		Config:      &config.Config{EnabledRegions: []string{"us-east-1"}},
+		AWSProvider: &mockAWSProvider{},
+		Logger:      log,
+	}
+}
+
+// testPluginCollector is a minimal collector built on BaseCollector
+type testPluginCollector struct {
+	*BaseCollector
+}
+
+func (c *testPluginCollector) Collect(ctx context.Context, region string) *CollectionResult {
+	return c.CollectWithRetry(ctx, region, func(_ context.Context, _ string) ([]MetricData, error) {
+		return []MetricData{c.CreateMetric("plugin_metric", 1, "Count", nil)}, nil
+	})
+}
+
+func testPluginConstructor(name string, cfg CollectorConfig, deps CollectorDependencies) (MetricCollector, error) {
+	if cfg.Settings["fail"] == "true" {
+		return nil, fmt.Errorf("constructor failure")
+	}
+	return &testPluginCollector{
+		BaseCollector: NewBaseCollector(name, "plugin collector", deps.Config, cfg, deps.AWSProvider, deps.Logger),
+	}, nil
+}
+
+func TestRegisterCollectorType(t *testing.T) {
+	if err := RegisterCollectorType("test-register-type", testPluginConstructor); err != nil {
+		t.Fatalf("Expected no error registering type, got: %v", err)
+	}
+
+	if err := RegisterCollectorType("test-register-type", testPluginConstructor); err == nil {
+		t.Error("Expected error registering duplicate type")
+	}
+
+	if err := RegisterCollectorType("", testPluginConstructor); err == nil {
+		t.Error("Expected error registering empty type")
+	}
+
+	if err := RegisterCollectorType("test-nil-constructor", nil); err == nil {
+		t.Error("Expected error registering nil constructor")
+	}
+
+	found := false
+	for _, typeName := range RegisteredCollectorTypes() {
+		if typeName == "test-register-type" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected registered type to be listed")
+	}
+}
+
+func TestDefaultCollectorFactory(t *testing.T) {
+	if err := RegisterCollectorType("test-factory-type", testPluginConstructor); err != nil {
+		t.Fatalf("Expected no error registering type, got: %v", err)
+	}
+
+	factory := NewDefaultCollectorFactory(newTestPluginDeps(t))
+
+	collector, err := factory.Create("test-factory-type", DefaultCollectorConfig())
+	if err != nil {
+		t.Fatalf("Expected no error creating collector, got: %v", err)
+	}
+	if collector.Name() != "test-factory-type" {
+		t.Errorf("Expected collector name 'test-factory-type', got %s", collector.Name())
+	}
+
+	if _, err := factory.Create("unknown-type", DefaultCollectorConfig()); err == nil {
+		t.Error("Expected error creating unknown collector type")
+	}
+}
+
+func TestLoadPluginCollectors(t *testing.T) {
+	if err := RegisterCollectorType("test-load-type", testPluginConstructor); err != nil {
+		t.Fatalf("Expected no error registering type, got: %v", err)
+	}
+
+	deps := newTestPluginDeps(t)
+
+	collectors, err := LoadPluginCollectors([]config.PluginConfig{
+		{
+			Name:               "billing",
+			Type:               "test-load-type",
+			Enabled:            true,
+			CollectionInterval: config.Duration(2 * time.Minute),
+			Settings:           map[string]string{"team": "finance"},
+		},
+		{Name: "disabled", Type: "test-load-type", Enabled: false},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Expected no error loading plugin collectors, got: %v", err)
+	}
+
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 collector, got %d", len(collectors))
+	}
+
+	info := collectors[0].Info()
+	if info.Name != "billing" {
+		t.Errorf("Expected collector name 'billing', got %s", info.Name)
+	}
+	if info.Interval != 2*time.Minute {
+		t.Errorf("Expected interval 2m, got %v", info.Interval)
+	}
+
+	if _, err := LoadPluginCollectors([]config.PluginConfig{
+		{Name: "missing", Type: "not-registered", Enabled: true},
+	}, deps); err == nil {
+		t.Error("Expected error for unregistered collector type")
+	}
+
+	if _, err := LoadPluginCollectors([]config.PluginConfig{
+		{Name: "broken", Type: "test-load-type", Enabled: true, Settings: map[string]string{"fail": "true"}},
+	}, deps); err == nil {
+		t.Error("Expected error when constructor fails")
+	}
+
+	if _, err := LoadPluginCollectors([]config.PluginConfig{
+		{Name: "so", Type: "test-load-type", Path: "/nonexistent/plugin.so", Enabled: true},
+	}, deps); err == nil {
+		t.Error("Expected error for missing plugin file")
+	}
+}
@@ -0,0 +1,100 @@
+package collectors
+
+import (
+	"sync"
+)
+
+const (
+	// defaultMetricsCapacity is the capacity of the metric slices the pool
+	// allocates
+	defaultMetricsCapacity = 64
+	// maxPooledMetrics is the capacity beyond which a metric slice is left
+	// to the garbage collector instead of being pooled, so one unusually
+	// large collection doesn't keep its memory for good
+	maxPooledMetrics = 16384
+	// maxPooledLabels is the size beyond which a label map is left to the
+	// garbage collector, since a cleared map keeps its buckets
+	maxPooledLabels = 64
+)
+
+// The metric slices and label maps of a collection are scratch buffers owned
+// by its collection pass: the scheduler releases them once the result was
+// processed, and later collections reuse them instead of allocating and
+// discarding thousands of them every cycle in large accounts.
+var (
+	metricsPool = sync.Pool{
+		New: func() interface{} {
+			metrics := make([]MetricData, 0, defaultMetricsCapacity)
+			return &metrics
+		},
+	}
+	labelsPool = sync.Pool{}
+)
+
+// AcquireMetrics returns an empty metric slice with room for at least
+// capacity metrics, reusing a released slice if possible. Collectors that
+// know roughly how many metrics they produce should pass that estimate.
+func AcquireMetrics(capacity int) []MetricData {
+	metrics := *metricsPool.Get().(*[]MetricData)
+	if cap(metrics) < capacity {
+		// Too small for this collection; let the garbage collector have it
+		return make([]MetricData, 0, capacity)
+	}
+	return metrics[:0]
+}
+
+// AcquireLabels returns an empty label map for a metric, reusing a released
+// one if possible. size is the number of labels expected.
+func AcquireLabels(size int) map[string]string {
+	if labels, ok := labelsPool.Get().(map[string]string); ok {
+		return labels
+	}
+	return make(map[string]string, size)
+}
+
+// ReleaseMetrics returns the slice of metrics and their label maps to the
+// pools once the collection was processed. Neither may be used after they
+// are released, so processors that keep metrics or labels must copy them,
+// e.g. with CloneMetrics or CloneLabels. Every metric must own its label map,
+// as CreateMetric ensures.
+func ReleaseMetrics(metrics []MetricData) {
+	for i := range metrics {
+		if labels := metrics[i].Labels; labels != nil && len(labels) <= maxPooledLabels {
+			clear(labels)
+			labelsPool.Put(labels)
+		}
+	}
+	if cap(metrics) == 0 || cap(metrics) > maxPooledMetrics {
+		return
+	}
+	// Drop the references to the label maps, including those of metrics
+	// dropped from the slice, which may have been moved
+	metrics = metrics[:cap(metrics)]
+	clear(metrics)
+	metrics = metrics[:0]
+	metricsPool.Put(&metrics)
+}
+
+// CloneMetrics returns a copy of metrics with their own label maps, which
+// can be kept after the metrics are released
+func CloneMetrics(metrics []MetricData) []MetricData {
+	clones := make([]MetricData, len(metrics))
+	for i, metric := range metrics {
+		clones[i] = metric
+		clones[i].Labels = CloneLabels(metric.Labels)
+	}
+	return clones
+}
+
+// CloneLabels returns a copy of labels, which can be kept after the metric
+// they belong to is released
+func CloneLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(labels))
+	for k, v := range labels {
+		clone[k] = v
+	}
+	return clone
+}
@@ -0,0 +1,80 @@
+package collectors
+
+import (
+	"fmt"
+	"testing"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestReleaseMetrics(t *testing.T) {
+	metrics := AcquireMetrics(100)
+	if len(metrics) != 0 || cap(metrics) < 100 {
+		t.Errorf("Expected an empty slice with room for 100 metrics, got len %d, cap %d", len(metrics), cap(metrics))
+	}
+
+	labels := AcquireLabels(1)
+	labels["region"] = "us-east-1"
+	metrics = append(metrics, MetricData{Name: "test_metric", Labels: labels})
+	clones := CloneMetrics(metrics)
+	ReleaseMetrics(metrics)
+	if metrics[0].Labels != nil || metrics[0].Name != "" {
+		t.Errorf("Expected released metrics to be cleared, got %+v", metrics[0])
+	}
+	if len(labels) != 0 {
+		t.Errorf("Expected released labels to be cleared, got %v", labels)
+	}
+	if clones[0].Name != "test_metric" || clones[0].Labels["region"] != "us-east-1" {
+		t.Errorf("Expected the clones to keep the metrics, got %+v", clones[0])
+	}
+
+	// Slices too large to keep are left to the garbage collector
+	ReleaseMetrics(make([]MetricData, 0, maxPooledMetrics+1))
+	ReleaseMetrics(nil)
+
+	if reused := AcquireMetrics(0); len(reused) != 0 {
+		t.Errorf("Expected an empty slice, got %d metrics", len(reused))
+	}
+	if reused := AcquireLabels(1); len(reused) != 0 {
+		t.Errorf("Expected empty labels, got %v", reused)
+	}
+}
+
+// BenchmarkCollectionPass measures the allocations of a collection of 1000
+// datapoints, with and without releasing them once processed
+func BenchmarkCollectionPass(b *testing.B) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		b.Fatalf("Failed to create logger: %v", err)
+	}
+	bc := NewBaseCollector("bench", "Benchmark collector", &config.Config{}, DefaultCollectorConfig(), &mockAWSProvider{}, log)
+
+	instances := make([]string, 1000)
+	for i := range instances {
+		instances[i] = fmt.Sprintf("i-%017d", i)
+	}
+	collect := func() []MetricData {
+		metrics := AcquireMetrics(len(instances))
+		for _, instance := range instances {
+			labels := AcquireLabels(4)
+			labels["instance_id"] = instance
+			labels["region"] = "us-east-1"
+			metrics = append(metrics, bc.CreateMetric("cpu_utilization", 42, "Percent", labels))
+		}
+		return metrics
+	}
+
+	b.Run("allocated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = collect()
+		}
+	})
+	b.Run("released", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ReleaseMetrics(collect())
+		}
+	})
+}
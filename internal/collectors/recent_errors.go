@@ -0,0 +1,102 @@
+package collectors
+
+import (
+	"time"
+
+	"aws-monitoring/pkg/errors"
+)
+
+// DefaultRecentErrorLimit is how many recent errors are kept per collector
+// when global.recent_error_limit is not set
+const DefaultRecentErrorLimit = 20
+
+// RecentError summarizes a classified collector error for operators
+type RecentError struct {
+	// Time is when the error occurred
+	Time time.Time `json:"time"`
+	// Type is the error category
+	Type errors.ErrorType `json:"type"`
+	// Code is the error code
+	Code string `json:"code"`
+	// Severity is the error severity
+	Severity errors.Severity `json:"severity"`
+	// Message is the full error message including its context
+	Message string `json:"message"`
+	// Region is the AWS region the error occurred in, if any
+	Region string `json:"region,omitempty"`
+	// Operation is the operation that failed, if known
+	Operation string `json:"operation,omitempty"`
+	// Service is the AWS service that returned the error, if any
+	Service string `json:"service,omitempty"`
+	// Retryable indicates the error could be retried
+	Retryable bool `json:"retryable"`
+	// Cause is the underlying error message, if any
+	Cause string `json:"cause,omitempty"`
+	// Metadata is additional error context
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// RecentErrorsProvider is implemented by collectors that keep their most recent
+// errors, typically through an embedded BaseCollector
+type RecentErrorsProvider interface {
+	// RecentErrors returns the most recent errors, newest first
+	RecentErrors() []RecentError
+}
+
+// NewRecentError creates a recent error entry from a classified error
+func NewRecentError(err *errors.Error) RecentError {
+	recent := RecentError{
+		Time:      err.Timestamp,
+		Type:      err.Type,
+		Code:      err.Code,
+		Severity:  err.Severity,
+		Message:   err.Error(),
+		Region:    err.Region,
+		Operation: err.Operation,
+		Service:   err.Service,
+		Retryable: err.Retryable,
+		Metadata:  err.Metadata,
+	}
+	if err.Cause != nil {
+		recent.Cause = err.Cause.Error()
+	}
+	return recent
+}
+
+// recentErrors is a fixed-size ring buffer of the most recent errors. It is
+// not safe for concurrent use; BaseCollector guards it with its mutex.
+type recentErrors struct {
+	entries []RecentError
+	next    int
+	full    bool
+}
+
+func newRecentErrors(limit int) *recentErrors {
+	if limit <= 0 {
+		limit = DefaultRecentErrorLimit
+	}
+	return &recentErrors{entries: make([]RecentError, limit)}
+}
+
+// add records an error, overwriting the oldest one when the buffer is full
+func (r *recentErrors) add(entry RecentError) {
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the recorded errors, newest first
+func (r *recentErrors) list() []RecentError {
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+
+	list := make([]RecentError, 0, count)
+	for i := 1; i <= count; i++ {
+		list = append(list, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	return list
+}
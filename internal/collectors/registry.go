@@ -3,6 +3,7 @@ package collectors
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"aws-monitoring/pkg/logger"
@@ -163,6 +164,25 @@ func (r *CollectorRegistry) Status() map[string]CollectorInfo {
 	return status
 }
 
+// Groups returns the sorted names of registered collectors in each collector group
+func (r *CollectorRegistry) Groups() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := make(map[string][]string)
+	for name, collector := range r.collectors {
+		for _, group := range collector.Info().Groups {
+			groups[group] = append(groups[group], name)
+		}
+	}
+
+	for group := range groups {
+		sort.Strings(groups[group])
+	}
+
+	return groups
+}
+
 // MetricProcessorRegistry manages metric processors
 type MetricProcessorRegistry struct {
 	processors []MetricProcessor
@@ -252,27 +272,38 @@ func (r *MetricProcessorRegistry) Process(ctx context.Context, result *Collectio
 	return nil
 }
 
-// DefaultCollectorFactory provides a basic implementation of CollectorFactory
+// DefaultCollectorFactory creates collectors from the registered collector types
 type DefaultCollectorFactory struct {
+	deps   CollectorDependencies
 	logger *logger.Logger
 }
 
 // NewDefaultCollectorFactory creates a new default collector factory
-func NewDefaultCollectorFactory(log *logger.Logger) CollectorFactory {
+func NewDefaultCollectorFactory(deps CollectorDependencies) CollectorFactory {
 	return &DefaultCollectorFactory{
-		logger: log.WithComponent("collector-factory"),
+		deps:   deps,
+		logger: deps.Logger.WithComponent("collector-factory"),
 	}
 }
 
-// Create creates a new collector instance based on the name and configuration
-func (f *DefaultCollectorFactory) Create(name string, _ CollectorConfig) (MetricCollector, error) {
-	// This is a placeholder implementation
-	// In a real implementation, you would create specific collector types based on the name
-	return nil, fmt.Errorf("collector type %s not supported by default factory", name)
+// Create creates a new collector instance of the registered type with the given name
+func (f *DefaultCollectorFactory) Create(name string, config CollectorConfig) (MetricCollector, error) {
+	constructor, exists := lookupCollectorType(name)
+	if !exists {
+		return nil, fmt.Errorf("collector type %s not supported by default factory", name)
+	}
+
+	collector, err := constructor(name, config, f.deps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collector %s: %w", name, err)
+	}
+	applyDependencies(collector, f.deps)
+
+	f.logger.Debug("Collector created", logger.String("collector", name))
+	return collector, nil
 }
 
 // SupportedTypes returns the types of collectors this factory can create
 func (f *DefaultCollectorFactory) SupportedTypes() []string {
-	// This would return the actual supported types in a real implementation
-	return []string{}
-}
\ No newline at end of file
+	return RegisteredCollectorTypes()
+}
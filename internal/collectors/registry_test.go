@@ -0,0 +1,41 @@
+package collectors
+
+import (
+	"testing"
+)
+
+func TestCollectorRegistryGroups(t *testing.T) {
+	deps := newTestPluginDeps(t)
+	registry := NewCollectorRegistry(deps.Logger).(*CollectorRegistry)
+
+	computeCfg := DefaultCollectorConfig()
+	computeCfg.Groups = []string{"compute"}
+	sharedCfg := DefaultCollectorConfig()
+	sharedCfg.Groups = []string{"compute", "security"}
+
+	for name, cfg := range map[string]CollectorConfig{"ec2": computeCfg, "vpc": sharedCfg, "plain": DefaultCollectorConfig()} {
+		collector, err := testPluginConstructor(name, cfg, deps)
+		if err != nil {
+			t.Fatalf("Failed to create collector: %v", err)
+		}
+		if err := registry.Register(collector); err != nil {
+			t.Fatalf("Failed to register collector: %v", err)
+		}
+	}
+
+	groups := registry.Groups()
+	if len(groups) != 2 {
+		t.Errorf("Expected 2 groups, got %d", len(groups))
+	}
+	if len(groups["compute"]) != 2 || groups["compute"][0] != "ec2" || groups["compute"][1] != "vpc" {
+		t.Errorf("Expected compute group [ec2 vpc], got %v", groups["compute"])
+	}
+	if len(groups["security"]) != 1 || groups["security"][0] != "vpc" {
+		t.Errorf("Expected security group [vpc], got %v", groups["security"])
+	}
+
+	status := registry.Status()
+	if len(status["vpc"].Groups) != 2 {
+		t.Errorf("Expected group membership in status, got %v", status["vpc"].Groups)
+	}
+}
@@ -0,0 +1,252 @@
+package collectors
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SLO metric names
+const (
+	// SLOBudgetRemainingMetric is the fraction of the error budget left in a window
+	SLOBudgetRemainingMetric = "collector_slo_error_budget_remaining"
+	// SLOBurnRateMetric is how fast the error budget is being spent in a window
+	// (1 means exactly on budget)
+	SLOBurnRateMetric = "collector_slo_burn_rate"
+	// SLOSuccessRateMetric is the collection success rate in a window
+	SLOSuccessRateMetric = "collector_slo_success_rate"
+)
+
+// Defaults applied to an SLOConfig with a target set
+var (
+	// DefaultSLOWindows are the rolling windows tracked when none are configured
+	DefaultSLOWindows = []time.Duration{time.Hour, 24 * time.Hour}
+	// DefaultSLOBurnRateThreshold is the burn rate above which the collector is degraded
+	DefaultSLOBurnRateThreshold = 2.0
+)
+
+// sloBucketSize is the granularity collection outcomes are aggregated at
+const sloBucketSize = time.Minute
+
+// SLOConfig configures success-rate objective tracking for a collector
+type SLOConfig struct {
+	// Target is the objective success rate (e.g. 0.99); zero disables tracking
+	Target float64 `json:"target"`
+	// Windows are the rolling windows the success rate is measured over
+	Windows []time.Duration `json:"windows,omitempty"`
+	// BurnRateThreshold is the burn rate that marks the collector degraded
+	BurnRateThreshold float64 `json:"burn_rate_threshold,omitempty"`
+}
+
+// SLOWindowStatus reports how a collector is doing against its SLO in one window
+type SLOWindowStatus struct {
+	// Window is the length of the rolling window
+	Window time.Duration `json:"window"`
+	// Total is the number of collections in the window
+	Total int `json:"total"`
+	// Failures is the number of failed collections in the window
+	Failures int `json:"failures"`
+	// SuccessRate is the fraction of successful collections (1 with no data)
+	SuccessRate float64 `json:"success_rate"`
+	// BurnRate is the error rate divided by the error budget
+	BurnRate float64 `json:"burn_rate"`
+	// BudgetRemaining is the fraction of the error budget left, down to 0
+	BudgetRemaining float64 `json:"budget_remaining"`
+}
+
+// SLOInfo summarizes a collector's SLO status
+type SLOInfo struct {
+	// Target is the objective success rate
+	Target float64 `json:"target"`
+	// Burning indicates the burn rate exceeds the threshold in every window
+	Burning bool `json:"burning"`
+	// Windows is the status of each rolling window
+	Windows []SLOWindowStatus `json:"windows"`
+}
+
+// SLOTracker keeps rolling success-rate windows for a collector and computes
+// error budget burn against the configured target. Outcomes are aggregated per
+// minute, so memory is bounded by the longest window.
+type SLOTracker struct {
+	target    float64
+	windows   []time.Duration
+	threshold float64
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets []sloBucket
+}
+
+// sloBucket counts collection outcomes in one bucket interval
+type sloBucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// NewSLOTracker creates a tracker for cfg, or returns nil if no target is set
+func NewSLOTracker(cfg SLOConfig) *SLOTracker {
+	if cfg.Target <= 0 || cfg.Target >= 1 {
+		return nil
+	}
+
+	windows := cfg.Windows
+	if len(windows) == 0 {
+		windows = DefaultSLOWindows
+	}
+	windows = append([]time.Duration(nil), windows...)
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+
+	threshold := cfg.BurnRateThreshold
+	if threshold <= 0 {
+		threshold = DefaultSLOBurnRateThreshold
+	}
+
+	return &SLOTracker{
+		target:    cfg.Target,
+		windows:   windows,
+		threshold: threshold,
+		now:       time.Now,
+	}
+}
+
+// Record records the outcome of a collection
+func (t *SLOTracker) Record(success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	start := now.Truncate(sloBucketSize)
+
+	if n := len(t.buckets); n == 0 || t.buckets[n-1].start.Before(start) {
+		t.buckets = append(t.buckets, sloBucket{start: start})
+	}
+
+	bucket := &t.buckets[len(t.buckets)-1]
+	bucket.total++
+	if !success {
+		bucket.failures++
+	}
+
+	t.prune(now)
+}
+
+// Status returns the SLO status for every window, shortest first
+func (t *SLOTracker) Status() []SLOWindowStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+
+	budget := 1 - t.target
+	statuses := make([]SLOWindowStatus, 0, len(t.windows))
+	for _, window := range t.windows {
+		status := SLOWindowStatus{Window: window, SuccessRate: 1, BudgetRemaining: 1}
+
+		cutoff := now.Add(-window)
+		for _, bucket := range t.buckets {
+			if bucket.start.Add(sloBucketSize).After(cutoff) {
+				status.Total += bucket.total
+				status.Failures += bucket.failures
+			}
+		}
+
+		if status.Total > 0 {
+			errorRate := float64(status.Failures) / float64(status.Total)
+			status.SuccessRate = 1 - errorRate
+			status.BurnRate = errorRate / budget
+			status.BudgetRemaining = 1 - status.BurnRate
+			if status.BudgetRemaining < 0 {
+				status.BudgetRemaining = 0
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// Info returns the target, burn state and status of every window
+func (t *SLOTracker) Info() *SLOInfo {
+	statuses := t.Status()
+	return &SLOInfo{
+		Target:  t.target,
+		Burning: t.burning(statuses),
+		Windows: statuses,
+	}
+}
+
+// Burning reports whether the error budget is burning faster than the
+// threshold in every window. Requiring all windows keeps a short burst from
+// flipping the collector while the short window stops it staying degraded
+// long after the collector has recovered.
+func (t *SLOTracker) Burning() bool {
+	return t.burning(t.Status())
+}
+
+func (t *SLOTracker) burning(statuses []SLOWindowStatus) bool {
+	for _, status := range statuses {
+		if status.Total == 0 || status.BurnRate <= t.threshold {
+			return false
+		}
+	}
+	return len(statuses) > 0
+}
+
+// prune drops buckets older than the longest window
+func (t *SLOTracker) prune(now time.Time) {
+	cutoff := now.Add(-t.windows[len(t.windows)-1])
+
+	drop := 0
+	for drop < len(t.buckets) && !t.buckets[drop].start.Add(sloBucketSize).After(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		t.buckets = append(t.buckets[:0], t.buckets[drop:]...)
+	}
+}
+
+// sloMetrics returns the error budget metrics of region for every SLO window,
+// or nil if SLO tracking is disabled or no outcome was recorded in region
+func (bc *BaseCollector) sloMetrics(region string) []MetricData {
+	bc.mu.RLock()
+	tracker := bc.regionSLO[region]
+	bc.mu.RUnlock()
+	if tracker == nil {
+		return nil
+	}
+
+	statuses := tracker.Status()
+	metrics := make([]MetricData, 0, 3*len(statuses))
+	labels := func(window string) map[string]string {
+		return map[string]string{"region": region, "window": window}
+	}
+	for _, status := range statuses {
+		window := formatSLOWindow(status.Window)
+		metrics = append(metrics,
+			bc.CreateMetricWithDescription(SLOBudgetRemainingMetric, status.BudgetRemaining, "Ratio",
+				"Fraction of the collection error budget remaining", labels(window)),
+			bc.CreateMetricWithDescription(SLOBurnRateMetric, status.BurnRate, "Ratio",
+				"Rate at which the collection error budget is being spent", labels(window)),
+			bc.CreateMetricWithDescription(SLOSuccessRateMetric, status.SuccessRate, "Ratio",
+				"Fraction of successful collections", labels(window)),
+		)
+	}
+
+	return metrics
+}
+
+// formatSLOWindow formats a window as a compact label value, e.g. "1h" or "30m"
+func formatSLOWindow(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	default:
+		return window.String()
+	}
+}
@@ -0,0 +1,161 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestNewSLOTracker(t *testing.T) {
+	if NewSLOTracker(SLOConfig{}) != nil {
+		t.Error("Expected no tracker without a target")
+	}
+	if NewSLOTracker(SLOConfig{Target: 1}) != nil {
+		t.Error("Expected no tracker for a target with no error budget")
+	}
+
+	tracker := NewSLOTracker(SLOConfig{Target: 0.99, Windows: []time.Duration{24 * time.Hour, time.Hour}})
+	if tracker == nil {
+		t.Fatal("Expected tracker for a valid target")
+	}
+	if tracker.windows[0] != time.Hour || tracker.threshold != DefaultSLOBurnRateThreshold {
+		t.Errorf("Expected sorted windows and default threshold, got %v / %v", tracker.windows, tracker.threshold)
+	}
+}
+
+func TestSLOTrackerWindows(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewSLOTracker(SLOConfig{Target: 0.9})
+	tracker.now = func() time.Time { return now }
+
+	// 2 hours ago: 10 successes
+	now = now.Add(-2 * time.Hour)
+	for i := 0; i < 10; i++ {
+		tracker.Record(true)
+	}
+
+	// Within the last hour: 5 successes, 5 failures
+	now = now.Add(2 * time.Hour)
+	for i := 0; i < 5; i++ {
+		tracker.Record(true)
+		tracker.Record(false)
+	}
+
+	statuses := tracker.Status()
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(statuses))
+	}
+
+	hour, day := statuses[0], statuses[1]
+	if hour.Total != 10 || hour.Failures != 5 || hour.SuccessRate != 0.5 {
+		t.Errorf("Unexpected 1h window: %+v", hour)
+	}
+	if hour.BudgetRemaining != 0 {
+		t.Errorf("Expected exhausted budget in 1h window, got %v", hour.BudgetRemaining)
+	}
+	if day.Total != 20 || day.Failures != 5 {
+		t.Errorf("Unexpected 24h window: %+v", day)
+	}
+	if burn := day.BurnRate; burn < 2.49 || burn > 2.51 {
+		t.Errorf("Expected 24h burn rate 2.5, got %v", burn)
+	}
+	if !tracker.Burning() {
+		t.Error("Expected tracker to be burning in both windows")
+	}
+
+	// A day later the old outcomes age out
+	now = now.Add(25 * time.Hour)
+	tracker.Record(true)
+	if statuses := tracker.Status(); statuses[1].Total != 1 || tracker.Burning() {
+		t.Errorf("Expected old outcomes to be pruned, got %+v", statuses)
+	}
+}
+
+func TestBaseCollectorSLO(t *testing.T) {
+	cfg := &config.Config{EnabledRegions: []string{"us-east-1"}}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 0
+	collectorConfig.SLO = SLOConfig{Target: 0.99}
+
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+	if err := bc.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start collector: %v", err)
+	}
+
+	result := bc.CollectWithRetry(context.Background(), "us-east-1", func(_ context.Context, _ string) ([]MetricData, error) {
+		return nil, errors.NewPermissionError("describe", "ec2:instances")
+	})
+
+	found := 0
+	for _, metric := range result.Metrics {
+		if metric.Name == SLOBudgetRemainingMetric {
+			found++
+			if metric.Value != 0 {
+				t.Errorf("Expected exhausted budget, got %v", metric.Value)
+			}
+		}
+	}
+	if found != 2 {
+		t.Errorf("Expected budget metric for 2 windows, got %d", found)
+	}
+
+	info := bc.Info()
+	if info.SLO == nil || !info.SLO.Burning {
+		t.Errorf("Expected SLO to be burning, got %+v", info.SLO)
+	}
+
+	if err := bc.Health(); err == nil {
+		t.Error("Expected collector to be unhealthy while burning its error budget")
+	}
+}
+
+func TestBaseCollectorSLOMetricsPerRegion(t *testing.T) {
+	cfg := &config.Config{EnabledRegions: []string{"us-east-1", "us-west-2", "eu-west-1"}}
+
+	collectorConfig := DefaultCollectorConfig()
+	collectorConfig.Retries = 0
+	collectorConfig.SLO = SLOConfig{Target: 0.99}
+
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	bc := NewBaseCollector("test-collector", "test", cfg, collectorConfig, &mockAWSProvider{}, log)
+	result := bc.CollectAllRegions(context.Background(), func(_ context.Context, region string) ([]MetricData, error) {
+		if region == "us-west-2" {
+			return nil, errors.NewPermissionError("describe", "ec2:instances")
+		}
+		return nil, nil
+	})
+
+	budgets := make(map[string]float64)
+	found := 0
+	for _, metric := range result.Metrics {
+		if metric.Name == SLOBudgetRemainingMetric {
+			found++
+			if metric.Labels["window"] == "1h" {
+				budgets[metric.Labels["region"]] = metric.Value
+			}
+		}
+	}
+	if found != 6 {
+		t.Errorf("Expected budget metric for 2 windows in each of 3 regions, got %d", found)
+	}
+	expected := map[string]float64{"us-east-1": 1, "us-west-2": 0, "eu-west-1": 1}
+	for region, budget := range expected {
+		if got, exists := budgets[region]; !exists || got != budget {
+			t.Errorf("Expected budget %v in %s, got %v (reported: %t)", budget, region, got, exists)
+		}
+	}
+}
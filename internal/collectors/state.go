@@ -0,0 +1,44 @@
+package collectors
+
+// stateBucketPrefix prefixes the state store bucket of each collector
+const stateBucketPrefix = "collector:"
+
+// StateStore persists state across restarts, such as the cursors collectors
+// resume from
+type StateStore interface {
+	Get(bucket, key string, value interface{}) (bool, error)
+	Put(bucket, key string, value interface{}) error
+}
+
+// SetStateStore sets where the collector's state is persisted
+func (bc *BaseCollector) SetStateStore(store StateStore) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.state = store
+}
+
+// LoadState decodes the collector's state saved under key into value. It
+// reports whether the state was found, which it never is without a store.
+func (bc *BaseCollector) LoadState(key string, value interface{}) (bool, error) {
+	bc.mu.RLock()
+	store := bc.state
+	bc.mu.RUnlock()
+	if store == nil {
+		return false, nil
+	}
+
+	return store.Get(stateBucketPrefix+bc.name, key, value)
+}
+
+// SaveState saves value as the collector's state under key. Without a store
+// the state is discarded.
+func (bc *BaseCollector) SaveState(key string, value interface{}) error {
+	bc.mu.RLock()
+	store := bc.state
+	bc.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+
+	return store.Put(stateBucketPrefix+bc.name, key, value)
+}
@@ -16,7 +16,8 @@ type MetricData struct {
 	Value float64 `json:"value"`
 	// Unit is the metric unit (e.g., "Count", "Bytes", "Percent")
 	Unit string `json:"unit"`
-	// Timestamp when the metric was collected
+	// Timestamp when the measurement was taken; the upstream datapoint time
+	// when the source provides one, otherwise the collection time
 	Timestamp time.Time `json:"timestamp"`
 	// Labels are key-value pairs that identify the metric
 	Labels map[string]string `json:"labels"`
@@ -44,6 +45,13 @@ type CollectionResult struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// ResourcesTruncatedMetric reports how many resources were skipped because a
+// collector hit its MaxResources cap
+const ResourcesTruncatedMetric = "collector_resources_truncated"
+
+// AllRegions is the region reported on results merged from several regions
+const AllRegions = "all"
+
 // CollectorStatus represents the current status of a collector
 type CollectorStatus string
 
@@ -72,6 +80,12 @@ type CollectorInfo struct {
 	EnabledRegions []string `json:"enabled_regions"`
 	// Interval is how often this collector runs
 	Interval time.Duration `json:"interval"`
+	// RegionIntervals overrides Interval in specific regions
+	RegionIntervals map[string]time.Duration `json:"region_intervals,omitempty"`
+	// Groups are the collector groups this collector belongs to
+	Groups []string `json:"groups,omitempty"`
+	// Priority decides which jobs are postponed first under memory pressure
+	Priority string `json:"priority,omitempty"`
 	// LastCollection is when this collector last ran
 	LastCollection *time.Time `json:"last_collection,omitempty"`
 	// LastError is the most recent error encountered
@@ -82,6 +96,18 @@ type CollectorInfo struct {
 	ErrorCount int64 `json:"error_count"`
 	// SuccessfulCollections is the number of successful collection runs
 	SuccessfulCollections int64 `json:"successful_collections"`
+	// CircuitBreakers is the circuit breaker status by region
+	CircuitBreakers map[string]CircuitBreakerStatus `json:"circuit_breakers,omitempty"`
+	// SLO is the success-rate objective status, if SLO tracking is enabled
+	SLO *SLOInfo `json:"slo,omitempty"`
+}
+
+// IntervalFor returns how often the collector runs in a region
+func (i CollectorInfo) IntervalFor(region string) time.Duration {
+	if interval, ok := i.RegionIntervals[region]; ok && interval > 0 {
+		return interval
+	}
+	return i.Interval
 }
 
 // MetricCollector defines the interface that all metric collectors must implement
@@ -94,6 +120,7 @@ type MetricCollector interface {
 	
 	// Collect performs metric collection for the specified region
 	// It should be safe to call concurrently from multiple goroutines
+	// The caller owns the metrics returned, which may be released for reuse
 	Collect(ctx context.Context, region string) *CollectionResult
 	
 	// Start initializes the collector and prepares it for collection
@@ -123,21 +150,41 @@ type CollectorConfig struct {
 	RetryDelay time.Duration `json:"retry_delay"`
 	// EnabledRegions restricts collection to specific regions
 	EnabledRegions []string `json:"enabled_regions,omitempty"`
+	// Groups are the collector groups (e.g., "compute", "security") this collector belongs to
+	Groups []string `json:"groups,omitempty"`
+	// MaxConcurrentRegions limits how many regions CollectAllRegions collects in parallel
+	MaxConcurrentRegions int `json:"max_concurrent_regions"`
+	// MaxResources caps how many resources are enumerated per region per cycle (0 = unlimited)
+	MaxResources int `json:"max_resources,omitempty"`
+	// Enrichment attaches resource metadata from the inventory cache to metrics
+	Enrichment EnrichmentConfig `json:"enrichment"`
+	// SLO configures success-rate objective and error budget tracking
+	SLO SLOConfig `json:"slo"`
 	// MetricFilters allow filtering which metrics to collect
 	MetricFilters []string `json:"metric_filters,omitempty"`
+	// DisabledMetrics are glob patterns of metric names that are dropped from
+	// collection results
+	DisabledMetrics []string `json:"disabled_metrics,omitempty"`
+	// RegionIntervals overrides Interval in specific regions
+	RegionIntervals map[string]time.Duration `json:"region_intervals,omitempty"`
+	// Priority is low, normal or high; empty means normal
+	Priority string `json:"priority,omitempty"`
 	// CustomTags are additional tags to add to all metrics
 	CustomTags map[string]string `json:"custom_tags,omitempty"`
+	// Settings are collector-specific options, mainly used by plugin collectors
+	Settings map[string]string `json:"settings,omitempty"`
 }
 
 // DefaultCollectorConfig returns sensible defaults for collector configuration
 func DefaultCollectorConfig() CollectorConfig {
 	return CollectorConfig{
-		Enabled:    true,
-		Interval:   5 * time.Minute,
-		Timeout:    30 * time.Second,
-		Retries:    3,
-		RetryDelay: 10 * time.Second,
-		CustomTags: make(map[string]string),
+		Enabled:              true,
+		Interval:             5 * time.Minute,
+		Timeout:              30 * time.Second,
+		Retries:              3,
+		RetryDelay:           10 * time.Second,
+		MaxConcurrentRegions: 5,
+		CustomTags:           make(map[string]string),
 	}
 }
 
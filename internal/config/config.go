@@ -4,11 +4,16 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"text/template"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"gopkg.in/yaml.v3"
+
+	"aws-monitoring/pkg/discovery"
 )
 
 // Duration is a custom type for handling time.Duration in YAML
@@ -42,11 +47,44 @@ func (d Duration) String() string {
 
 // Config represents the complete application configuration
 type Config struct {
-	EnabledRegions []string      `yaml:"enabled_regions" validate:"required,min=1"`
-	AWS            AWSConfig     `yaml:"aws" validate:"required"`
-	OTEL           OTELConfig    `yaml:"otel" validate:"required"`
-	Metrics        MetricsConfig `yaml:"metrics" validate:"required"`
-	Global         GlobalConfig  `yaml:"global"`
+	EnabledRegions []string            `yaml:"enabled_regions" validate:"required,min=1"`
+	AWS            AWSConfig           `yaml:"aws" validate:"required"`
+	OTEL           OTELConfig          `yaml:"otel" validate:"required"`
+	Metrics        MetricsConfig       `yaml:"metrics" validate:"required"`
+	Plugins        []PluginConfig      `yaml:"plugins" validate:"dive"`
+	Admin          AdminConfig         `yaml:"admin"`
+	Alerting       AlertingConfig      `yaml:"alerting"`
+	Alerts         []AlertRuleConfig   `yaml:"alerts" validate:"dive"`
+	Anomalies      AnomalyConfig       `yaml:"anomalies"`
+	Events         EventsConfig        `yaml:"events"`
+	EventTriggers  EventTriggersConfig `yaml:"event_triggers"`
+	Control        ControlConfig       `yaml:"control"`
+	Global         GlobalConfig        `yaml:"global"`
+
+	// deprecations are the deprecated keys Load accepted
+	deprecations []Deprecation
+	// unknownKeys are the keys Load ignored
+	unknownKeys []UnknownKey
+	// overridden are the keys replaced by flags or environment variables
+	overridden []string
+}
+
+// Deprecations returns the deprecated keys found when the configuration was
+// loaded, which should be reported so they are migrated
+func (c *Config) Deprecations() []Deprecation {
+	return c.deprecations
+}
+
+// UnknownKeys returns the keys found when the configuration was loaded that
+// no setting uses and were ignored; strict mode rejects them instead
+func (c *Config) UnknownKeys() []UnknownKey {
+	return c.unknownKeys
+}
+
+// OverriddenKeys returns the settings of the configuration file replaced by
+// command-line flags or AWS_MONITOR_* environment variables
+func (c *Config) OverriddenKeys() []string {
+	return c.overridden
 }
 
 // AWSConfig holds AWS-specific configuration
@@ -56,6 +94,10 @@ type AWSConfig struct {
 	DefaultRegion   string   `yaml:"default_region" validate:"required"`
 	MaxRetries      int      `yaml:"max_retries" validate:"min=1,max=10"`
 	Timeout         Duration `yaml:"timeout"`
+	InventoryTTL    Duration `yaml:"inventory_ttl"`
+	// EndpointURL replaces the AWS endpoints of every service, e.g. with a
+	// LocalStack endpoint for testing
+	EndpointURL string `yaml:"endpoint_url" validate:"omitempty,url"`
 }
 
 // OTELConfig holds OpenTelemetry configuration
@@ -66,6 +108,20 @@ type OTELConfig struct {
 	Insecure          bool              `yaml:"insecure"`
 	BatchTimeout      Duration          `yaml:"batch_timeout"`
 	BatchSize         int               `yaml:"batch_size" validate:"min=1,max=10000"`
+	// ExportLogs also ships log records to the collector over OTLP
+	ExportLogs bool `yaml:"export_logs"`
+	// ExportTraces ships a trace of every collection job to the collector
+	ExportTraces bool `yaml:"export_traces"`
+	// TraceSampleRatio is the fraction of collection jobs traced
+	TraceSampleRatio float64 `yaml:"trace_sample_ratio" validate:"min=0,max=1"`
+	// ResourceAttributes are added to the resource of exported telemetry
+	ResourceAttributes map[string]string `yaml:"resource_attributes"`
+	// ExportFailureThreshold is how long exports may fail before health is
+	// reported as degraded
+	ExportFailureThreshold Duration `yaml:"export_failure_threshold"`
+	// SRVRefreshInterval is how often the SRV record of an srv://
+	// collector_endpoint is resolved again
+	SRVRefreshInterval Duration `yaml:"srv_refresh_interval"`
 }
 
 // MetricsConfig holds configuration for all metric collectors
@@ -77,69 +133,573 @@ type MetricsConfig struct {
 	EBS    CollectorConfig `yaml:"ebs"`
 	ELB    CollectorConfig `yaml:"elb"`
 	VPC    CollectorConfig `yaml:"vpc"`
+	// Groups enables or disables every collector in a group (e.g. "compute": false)
+	Groups map[string]bool `yaml:"groups"`
 }
 
 // CollectorConfig holds configuration for individual collectors
 type CollectorConfig struct {
-	Enabled            bool     `yaml:"enabled"`
-	CollectionInterval Duration `yaml:"collection_interval"`
+	Enabled            bool             `yaml:"enabled"`
+	CollectionInterval Duration         `yaml:"collection_interval"`
+	Groups             []string         `yaml:"groups"`
+	MaxResources       int              `yaml:"max_resources" validate:"min=0"`
+	Enrichment         EnrichmentConfig `yaml:"enrichment"`
+	SLO                SLOConfig        `yaml:"slo"`
+	// DisabledMetrics are glob patterns of metric names not to export, e.g.
+	// "s3_object_*", to cut cost and cardinality
+	DisabledMetrics []string `yaml:"disabled_metrics"`
+	// RegionIntervals overrides the collection interval in specific regions
+	RegionIntervals map[string]Duration `yaml:"region_intervals"`
+	// Priority decides which jobs are postponed first under memory pressure
+	Priority string `yaml:"priority" validate:"omitempty,oneof=low normal high"`
+}
+
+// EnrichmentConfig configures attaching resource metadata (instance type,
+// availability zone, ARN and selected tags) from the inventory cache to metrics
+type EnrichmentConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	ResourceLabel string   `yaml:"resource_label"`
+	Tags          []string `yaml:"tags"`
+}
+
+// SLOConfig configures collection success-rate objectives. Rolling windows
+// (default 1h and 24h) are tracked against the target; the collector is
+// degraded while the error budget burns faster than the threshold (default 2)
+// in every window.
+type SLOConfig struct {
+	Target            float64    `yaml:"target" validate:"omitempty,gt=0,lt=1"`
+	Windows           []Duration `yaml:"windows"`
+	BurnRateThreshold float64    `yaml:"burn_rate_threshold" validate:"min=0"`
+}
+
+// DefaultCollectorGroups are the groups built-in collectors belong to unless
+// overridden with the collector's groups setting
+var DefaultCollectorGroups = map[string][]string{
+	"ec2":    {"compute"},
+	"lambda": {"compute"},
+	"rds":    {"database"},
+	"s3":     {"storage"},
+	"ebs":    {"storage"},
+	"elb":    {"network"},
+	"vpc":    {"network", "security"},
+}
+
+// PluginConfig holds configuration for a collector provided by a plugin or an
+// externally registered collector type
+type PluginConfig struct {
+	Name               string              `yaml:"name" validate:"required"`
+	Type               string              `yaml:"type" validate:"required"`
+	Path               string              `yaml:"path"`
+	Enabled            bool                `yaml:"enabled"`
+	CollectionInterval Duration            `yaml:"collection_interval"`
+	Regions            []string            `yaml:"regions"`
+	Groups             []string            `yaml:"groups"`
+	MaxResources       int                 `yaml:"max_resources" validate:"min=0"`
+	Enrichment         EnrichmentConfig    `yaml:"enrichment"`
+	SLO                SLOConfig           `yaml:"slo"`
+	DisabledMetrics    []string            `yaml:"disabled_metrics"`
+	RegionIntervals    map[string]Duration `yaml:"region_intervals"`
+	Priority           string              `yaml:"priority" validate:"omitempty,oneof=low normal high"`
+	Settings           map[string]string   `yaml:"settings"`
+}
+
+// AdminConfig holds configuration for the runtime admin API, served on the
+// health check port under /admin/. Requests must carry the bearer token or
+// the basic auth username and password; at least one is required.
+type AdminConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Token    string `yaml:"token"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// AuditLog is the file the changes made at runtime are appended to; the
+	// latest changes are also served by GET /admin/audit
+	AuditLog string `yaml:"audit_log"`
+}
+
+// AlertingConfig configures notifications for high and critical severity
+// collector errors. Alerts for the same collector, region and error code are
+// sent at most once per cooldown, and at most MaxPerHour alerts are sent in
+// total per hour; suppressed alerts are counted in the next one sent. The
+// alerts of digest alert rules are sent together every DigestInterval.
+type AlertingConfig struct {
+	Enabled        bool                 `yaml:"enabled"`
+	Cooldown       Duration             `yaml:"cooldown"`
+	MaxPerHour     int                  `yaml:"max_per_hour" validate:"min=0"`
+	DigestInterval Duration             `yaml:"digest_interval"`
+	Slack          SlackAlertConfig     `yaml:"slack"`
+	SNS            SNSAlertConfig       `yaml:"sns"`
+	PagerDuty      PagerDutyAlertConfig `yaml:"pagerduty"`
+	Email          EmailAlertConfig     `yaml:"email"`
+	Webhooks       []WebhookAlertConfig `yaml:"webhooks" validate:"dive"`
+	Templates      AlertTemplateConfig  `yaml:"templates"`
+	// StateFile keeps the silences and acknowledgments of alerts across
+	// restarts; without it they are lost on restart
+	StateFile string `yaml:"state_file"`
+
+	HealthChanges HealthChangeAlertConfig `yaml:"health_changes"`
+}
+
+// HealthChangeAlertConfig configures alerts for changes of the overall health
+// status
+type HealthChangeAlertConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Debounce Duration `yaml:"debounce"`
+}
+
+// AlertRuleConfig is a threshold rule over collected metrics. It fires for a
+// series of Metric whose labels match Labels once its value has compared to
+// Threshold with Operator for the For duration, and resolves when the
+// comparison no longer holds.
+//
+// With Change set, the percentage change of the value over that period is
+// compared instead, e.g. the day-over-day change of a daily spend with 24h.
+// Digest rules collect their alerts into a digest sent every
+// alerting.digest_interval instead of sending them as they happen.
+type AlertRuleConfig struct {
+	Name      string            `yaml:"name" validate:"required"`
+	Metric    string            `yaml:"metric" validate:"required"`
+	Labels    map[string]string `yaml:"labels"`
+	Operator  string            `yaml:"operator" validate:"required,oneof=> >= < <= == !="`
+	Threshold float64           `yaml:"threshold"`
+	For       Duration          `yaml:"for"`
+	Severity  string            `yaml:"severity" validate:"omitempty,oneof=low medium high critical"`
+	Change    Duration          `yaml:"change"`
+	Digest    bool              `yaml:"digest"`
+}
+
+// AnomalyConfig configures the detection of sudden deviations in selected
+// series. Each series learns a baseline of its values, and a value more than
+// Threshold standard deviations from the baseline is an anomaly, reported as
+// an event.
+type AnomalyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Method computes the baseline: ewma, the exponentially weighted moving
+	// average and variance, or rolling, the mean and standard deviation of
+	// the last Window values
+	Method string `yaml:"method" validate:"omitempty,oneof=ewma rolling"`
+	// Alpha is the weight of the latest value in an ewma baseline
+	Alpha float64 `yaml:"alpha" validate:"min=0,max=1"`
+	// Window is the number of values of a rolling baseline
+	Window int `yaml:"window" validate:"min=0"`
+	// Threshold is the number of standard deviations from the baseline at
+	// which a value is anomalous
+	Threshold float64 `yaml:"threshold" validate:"min=0"`
+	// MinSamples is the number of values a series learns before it is
+	// checked
+	MinSamples int `yaml:"min_samples" validate:"min=0"`
+	// Severity is the severity of anomaly events
+	Severity string                `yaml:"severity" validate:"omitempty,oneof=info warning critical"`
+	Series   []AnomalySeriesConfig `yaml:"series" validate:"dive"`
+}
+
+// AnomalySeriesConfig selects the series of Metric whose labels match Labels
+// for anomaly detection
+type AnomalySeriesConfig struct {
+	Metric string            `yaml:"metric" validate:"required"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// SlackAlertConfig configures delivery of alerts to a Slack incoming webhook
+type SlackAlertConfig struct {
+	WebhookURL string `yaml:"webhook_url" validate:"omitempty,url"`
+}
+
+// SNSAlertConfig configures delivery of alerts to an SNS topic
+type SNSAlertConfig struct {
+	TopicARN string `yaml:"topic_arn"`
+	Region   string `yaml:"region"`
+}
+
+// PagerDutyAlertConfig configures delivery of alerts to a PagerDuty service
+// through the Events API v2
+type PagerDutyAlertConfig struct {
+	RoutingKey string `yaml:"routing_key"`
+	// URL is the Events API endpoint
+	URL string `yaml:"url" validate:"omitempty,url"`
+}
+
+// EmailAlertConfig configures delivery of alerts by email through an SMTP
+// server. STARTTLS is used when the server supports it.
+type EmailAlertConfig struct {
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port" validate:"min=0,max=65535"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from" validate:"omitempty,email"`
+	To       []string `yaml:"to" validate:"dive,email"`
+}
+
+// AlertTemplateConfig replaces the title and text of alerts, such as the Slack
+// message and the email subject and body, with Go templates executed on the
+// alert
+type AlertTemplateConfig struct {
+	Title string `yaml:"title"`
+	Text  string `yaml:"text"`
+}
+
+// WebhookAlertConfig configures delivery of alerts as JSON to a generic webhook
+type WebhookAlertConfig struct {
+	URL     string            `yaml:"url" validate:"required,url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// EventsConfig configures the events pipeline, which delivers discrete
+// occurrences reported by collectors, such as an instance state change or an
+// expiring certificate, separately from metrics. Events are queued and
+// delivered in the background; they are dropped while the queue is full.
+type EventsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// BufferSize is the number of events queued for delivery
+	BufferSize int `yaml:"buffer_size" validate:"min=0"`
+	// MinSeverity is the lowest severity delivered: info, warning or critical
+	MinSeverity string `yaml:"min_severity" validate:"omitempty,oneof=info warning critical"`
+	// ExportOTLP exports events as log records to otel.collector_endpoint
+	ExportOTLP bool                 `yaml:"export_otlp"`
+	Webhooks   []EventWebhookConfig `yaml:"webhooks" validate:"dive"`
+}
+
+// EventWebhookConfig configures delivery of events as JSON to a webhook
+type EventWebhookConfig struct {
+	URL     string            `yaml:"url" validate:"required,url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// EventTriggersConfig configures collections triggered by AWS events.
+// EventBridge rules deliver the events to the SQS queue at QueueURL, and each
+// event matching one of Rules runs the rule's collectors in the event's region
+// right away instead of at their next interval. Without rules, EC2 instance
+// state changes trigger the ec2 collector and RDS instance and cluster events
+// the rds collector.
+type EventTriggersConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	QueueURL string `yaml:"queue_url" validate:"omitempty,url"`
+	// Region is the region of the queue; defaults to aws.default_region
+	Region string `yaml:"region"`
+	// WaitTime is how long a receive waits for messages, up to 20s
+	WaitTime Duration                 `yaml:"wait_time"`
+	Rules    []EventTriggerRuleConfig `yaml:"rules" validate:"dive"`
+}
+
+// EventTriggerRuleConfig selects the collectors triggered by events of a
+// source and, when DetailTypes is set, of one of those detail types
+type EventTriggerRuleConfig struct {
+	Source      string   `yaml:"source" validate:"required"`
+	DetailTypes []string `yaml:"detail_types"`
+	Collectors  []string `yaml:"collectors" validate:"required,min=1"`
+}
+
+// ControlConfig configures the control channel, an SQS queue aws-monitor
+// consumes commands from, such as running a collector now, pausing a region
+// or reloading the configuration
+type ControlConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	QueueURL string `yaml:"queue_url" validate:"omitempty,url"`
+	// Region is the region of the queue; defaults to aws.default_region
+	Region string `yaml:"region"`
+	// WaitTime is how long a receive waits for messages, up to 20s
+	WaitTime Duration `yaml:"wait_time"`
 }
 
 // GlobalConfig holds global application settings
 type GlobalConfig struct {
-	LogLevel             string   `yaml:"log_level" validate:"oneof=debug info warn error"`
-	LogFormat            string   `yaml:"log_format" validate:"oneof=json text"`
-	HealthCheckPort      int      `yaml:"health_check_port" validate:"min=1,max=65535"`
-	HealthCheckPath      string   `yaml:"health_check_path"`
-	DefaultInterval      Duration `yaml:"default_collection_interval"`
-	MaxConcurrentWorkers int      `yaml:"max_concurrent_workers" validate:"min=1,max=100"`
-	WorkerTimeout        Duration `yaml:"worker_timeout"`
-	MaxErrorCount        int      `yaml:"max_error_count" validate:"min=1"`
-	ErrorResetInterval   Duration `yaml:"error_reset_interval"`
-	MetricBufferSize     int      `yaml:"metric_buffer_size" validate:"min=1"`
-	ExportTimeout        Duration `yaml:"export_timeout"`
-}
-
-// Load loads configuration from the specified file path
-func Load(configPath string) (*Config, error) {
-	// Try to find config file if path is empty
-	if configPath == "" {
-		var err error
-		configPath, err = findConfigFile()
-		if err != nil {
-			return nil, fmt.Errorf("config file not found: %w", err)
-		}
+	LogLevel             string                     `yaml:"log_level" validate:"oneof=debug info warn error"`
+	LogFormat            string                     `yaml:"log_format" validate:"oneof=json text"`
+	LogOutputPath        string                     `yaml:"log_output_path"`
+	LogMaxSize           int                        `yaml:"log_max_size" validate:"min=0"`
+	LogMaxAge            int                        `yaml:"log_max_age" validate:"min=0"`
+	LogMaxBackups        int                        `yaml:"log_max_backups" validate:"min=0"`
+	LogCompress          bool                       `yaml:"log_compress"`
+	LogSampling          LogSamplingConfig          `yaml:"log_sampling"`
+	LogRedactFields      []string                   `yaml:"log_redact_fields"`
+	LogAsync             LogAsyncConfig             `yaml:"log_async"`
+	HealthCheckPort      int                        `yaml:"health_check_port" validate:"min=1,max=65535"`
+	HealthCheckPath      string                     `yaml:"health_check_path"`
+	HealthCheckTLS       HealthCheckTLSConfig       `yaml:"health_check_tls"`
+	HealthCheckAuth      HealthCheckAuthConfig      `yaml:"health_check_auth"`
+	HealthCheckRateLimit HealthCheckRateLimitConfig `yaml:"health_check_rate_limit"`
+	HealthCheckCacheTTL  Duration                   `yaml:"health_check_cache_ttl"`
+	GRPCHealthPort       int                        `yaml:"grpc_health_port" validate:"min=0,max=65535"`
+	DefaultInterval      Duration                   `yaml:"default_collection_interval"`
+	MaxConcurrentWorkers int                        `yaml:"max_concurrent_workers" validate:"min=1,max=100"`
+	WorkerTimeout        Duration                   `yaml:"worker_timeout"`
+	ShutdownTimeout      Duration                   `yaml:"shutdown_timeout"`
+	MaxErrorCount        int                        `yaml:"max_error_count" validate:"min=1"`
+	ErrorResetInterval   Duration                   `yaml:"error_reset_interval"`
+	MetricBufferSize     int                        `yaml:"metric_buffer_size" validate:"min=1"`
+	ExportTimeout        Duration                   `yaml:"export_timeout"`
+	WarmupWindow         Duration                   `yaml:"warmup_window"`
+	RecentErrorLimit     int                        `yaml:"recent_error_limit" validate:"min=0"`
+	ErrorTracking        ErrorTrackingConfig        `yaml:"error_tracking"`
+	HealthChecks         HealthChecksConfig         `yaml:"health_checks"`
+	ConfigReload         ConfigReloadConfig         `yaml:"config_reload"`
+	SelfMetrics          SelfMetricsConfig          `yaml:"self_metrics"`
+	State                StateConfig                `yaml:"state"`
+	Memory               MemoryConfig               `yaml:"memory"`
+	RegionBatching       RegionBatchingConfig       `yaml:"region_batching"`
+	Platform             PlatformConfig             `yaml:"platform"`
+	// StrictConfig rejects configuration files with unknown keys instead of
+	// ignoring them
+	StrictConfig bool `yaml:"strict_config"`
+}
+
+// SelfMetricsConfig configures reporting aws-monitor's own metrics
+// (collection rate and latency, export results, buffer sizes, goroutines)
+// with the AWS metrics
+type SelfMetricsConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Interval Duration `yaml:"interval"`
+}
+
+// StateConfig configures the store keeping the state aws-monitor needs
+// across restarts: the last run of each collection job, collector cursors
+// and cumulative counters. Without a path the state is kept in memory only.
+type StateConfig struct {
+	Path string `yaml:"path"`
+	// FlushInterval is how often changes are saved to the file; changes
+	// since the last flush are lost if the process crashes
+	FlushInterval Duration `yaml:"flush_interval"`
+}
+
+// MemoryConfig configures the soft memory limit. As the memory in use
+// approaches it, fewer collection jobs run at once and low priority jobs are
+// postponed. Without a limit GOMEMLIMIT is used, if set.
+type MemoryConfig struct {
+	SoftLimitMB int `yaml:"soft_limit_mb" validate:"min=0"`
+	// HighRatio and CriticalRatio are the shares of the limit at which
+	// memory pressure becomes high and critical
+	HighRatio     float64 `yaml:"high_ratio" validate:"min=0,max=1"`
+	CriticalRatio float64 `yaml:"critical_ratio" validate:"min=0,max=1"`
+}
+
+// RegionBatchingConfig configures running a collector scheduled in many
+// regions as one job, collecting its regions in parallel batches, instead of
+// as one job per region competing for max_concurrent_workers
+type RegionBatchingConfig struct {
+	// Threshold is the number of regions from which a collector's regions
+	// are batched
+	Threshold int `yaml:"threshold" validate:"min=0"`
+	// Size is the number of regions of a batch collected in parallel
+	Size int `yaml:"size" validate:"min=0"`
+}
+
+// PlatformConfig configures detecting the platform aws-monitor runs on,
+// Kubernetes, ECS or EC2, and adding its metadata to the resource of exported
+// telemetry and, for Kubernetes, to the labels of every metric. Detection is
+// on unless disabled.
+type PlatformConfig struct {
+	DisableDetection bool `yaml:"disable_detection"`
+	// DisableLabels keeps the metadata on the resource only, out of metric
+	// labels
+	DisableLabels bool `yaml:"disable_labels"`
+	// ClusterName names the Kubernetes cluster, which pods cannot discover;
+	// K8S_CLUSTER_NAME is used when empty
+	ClusterName string `yaml:"cluster_name"`
+}
+
+// ConfigReloadConfig configures reloading the configuration file when it
+// changes. SIGHUP always triggers a reload.
+type ConfigReloadConfig struct {
+	// Watch reloads the file when it changes. Local files are watched for
+	// file system notifications; remote documents are fetched every Interval
+	Watch    bool     `yaml:"watch"`
+	Interval Duration `yaml:"interval"`
+}
+
+// HealthCheckAuthConfig protects groups of health check port endpoints. Each
+// group is unauthenticated unless a token or username is set.
+type HealthCheckAuthConfig struct {
+	// Probes covers /health, /health/live, /health/ready, /health/startup
+	// and /metrics
+	Probes EndpointAuthConfig `yaml:"probes"`
+	// Detailed covers /health/detailed and /health/history
+	Detailed EndpointAuthConfig `yaml:"detailed"`
+	// API covers the read-only API under /api/v1/
+	API EndpointAuthConfig `yaml:"api"`
+}
+
+// EndpointAuthConfig holds the credentials accepted by a group of endpoints:
+// a bearer token, basic auth or both
+type EndpointAuthConfig struct {
+	Token    string `yaml:"token"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// HealthCheckRateLimitConfig limits the requests each client may make to the
+// health endpoints, so aggressive uptime checkers are answered with 429
+type HealthCheckRateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second" validate:"min=0"`
+	Burst             int     `yaml:"burst" validate:"min=0"`
+}
+
+// HealthCheckTLSConfig serves the health check port, and with it the admin
+// API, over HTTPS. When ClientCAFile is set, clients must present a
+// certificate signed by one of its CAs.
+type HealthCheckTLSConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+}
+
+// HealthChecksConfig holds the thresholds of the built-in runtime and disk
+// health checks, which report degraded status before the process runs out of
+// memory or disk space
+type HealthChecksConfig struct {
+	// MaxGoroutines is the goroutine count above which health is degraded
+	MaxGoroutines int `yaml:"max_goroutines" validate:"min=0"`
+	// HeapLimitMB is the heap size limit in megabytes; when unset, the
+	// GOMEMLIMIT soft memory limit is used if one is set
+	HeapLimitMB int `yaml:"heap_limit_mb" validate:"min=0"`
+	// GCPauseThreshold is the average recent GC pause above which health is
+	// degraded
+	GCPauseThreshold Duration `yaml:"gc_pause_threshold"`
+	// MinFreeDiskMB is the free space in megabytes below which the log file's
+	// directory and DiskPaths are reported as degraded
+	MinFreeDiskMB int `yaml:"min_free_disk_mb" validate:"min=0"`
+	// DiskPaths are additional directories that must stay writable, such as
+	// a persistent buffer directory
+	DiskPaths []string `yaml:"disk_paths"`
+	// HistorySize is the number of results kept per check for /health/history
+	HistorySize int `yaml:"history_size" validate:"min=0"`
+	// Checkers configures individual checks by name, e.g. aws_connectivity
+	// or collector:ec2, so expensive checks can run less often
+	Checkers map[string]HealthCheckerConfig `yaml:"checkers" validate:"dive"`
+}
+
+// HealthCheckerConfig configures how a health check is run. A zero Interval
+// runs the check on every round of checks, every 30 seconds.
+type HealthCheckerConfig struct {
+	Enabled    bool     `yaml:"enabled"`
+	Interval   Duration `yaml:"interval"`
+	Timeout    Duration `yaml:"timeout"`
+	Retries    int      `yaml:"retries" validate:"min=0"`
+	RetryDelay Duration `yaml:"retry_delay"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for HealthCheckerConfig; a
+// configured check is enabled unless enabled is set to false
+func (c *HealthCheckerConfig) UnmarshalYAML(value *yaml.Node) error {
+	type plain HealthCheckerConfig
+	config := plain{Enabled: true}
+	if err := value.Decode(&config); err != nil {
+		return err
 	}
 
-	// Read the config file
-	data, err := os.ReadFile(configPath)
+	*c = HealthCheckerConfig(config)
+	return nil
+}
+
+// LogSamplingConfig limits repeated log entries. Within each tick the first
+// Initial entries with the same level and message are logged, then every
+// Thereafter-th one. Levels overrides the limits per level (debug, info, warn,
+// error). Sampling is disabled when no limits are set.
+type LogSamplingConfig struct {
+	Initial    int                               `yaml:"initial" validate:"min=0"`
+	Thereafter int                               `yaml:"thereafter" validate:"min=0"`
+	Tick       Duration                          `yaml:"tick"`
+	Levels     map[string]LogLevelSamplingConfig `yaml:"levels" validate:"dive"`
+}
+
+// LogLevelSamplingConfig holds the sampling limits for a single log level
+type LogLevelSamplingConfig struct {
+	Initial    int `yaml:"initial" validate:"min=0"`
+	Thereafter int `yaml:"thereafter" validate:"min=0"`
+}
+
+// LogAsyncConfig enables asynchronous log writing. Entries below error level
+// are queued, up to QueueSize, and written in the background; entries that
+// don't fit in the queue are dropped and counted.
+type LogAsyncConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	QueueSize int  `yaml:"queue_size" validate:"min=0"`
+}
+
+// ErrorTrackingConfig configures reporting of critical collector errors, with
+// their stack trace and metadata, to Sentry or a Sentry-compatible endpoint
+type ErrorTrackingConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	DSN         string `yaml:"dsn" validate:"omitempty,url"`
+	Environment string `yaml:"environment"`
+	Release     string `yaml:"release"`
+}
+
+// Load loads configuration from the specified file path, or from an S3
+// object (s3://bucket/key) or SSM parameter (ssm://name)
+func Load(configPath string) (*Config, error) {
+	return LoadWithOptions(configPath, LoadOptions{})
+}
+
+// LoadWithOptions loads configuration like Load. In strict mode, set by opts
+// or global.strict_config, unknown keys are rejected.
+func LoadWithOptions(configPath string, opts LoadOptions) (*Config, error) {
+	config, _, err := LoadWithDefaults(configPath, opts)
+	return config, err
+}
+
+// LoadWithDefaults loads configuration like LoadWithOptions, also returning
+// the settings the file left unset, or set to zero, that were set to their
+// default values, by dotted key
+func LoadWithDefaults(configPath string, opts LoadOptions) (*Config, map[string]string, error) {
+	// Try to find config file if path is empty
+	configPath, err := ResolvePath(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+		return nil, nil, err
 	}
 
-	// Parse YAML
+	// Read and parse the config file and the files it includes, by extension
+	// as YAML, JSON or TOML, substituting ${VAR} and ${VAR:-default}
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	if err := decodeFiles(configPath, &config); err != nil {
+		return nil, nil, err
+	}
+	if (opts.Strict || config.Global.StrictConfig) && len(config.unknownKeys) > 0 {
+		return nil, nil, unknownKeysError(config.unknownKeys)
+	}
+
+	// Flags take precedence over the environment, which takes precedence
+	// over the file
+	overrides, err := EnvOverrides(os.Getenv)
+	if err != nil {
+		return nil, nil, &ValidationError{Problems: []string{err.Error()}, err: err}
 	}
+	overrides.Merge(opts.Overrides).apply(&config)
 
-	// Set defaults
+	// Set defaults, recording the settings they fill in
+	loaded := settingValues(&config)
 	setDefaults(&config)
+	defaulted := defaultedSettings(loaded, settingValues(&config))
 
 	// Validate configuration
 	if err := validate(&config); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+		return nil, nil, newValidationError(err)
 	}
 
-	return &config, nil
+	return &config, defaulted, nil
+}
+
+// ResolvePath returns the configuration file Load reads for configPath: the
+// path itself or, when it is empty, the first file found in the standard
+// locations
+func ResolvePath(configPath string) (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+
+	path, err := findConfigFile()
+	if err != nil {
+		return "", fmt.Errorf("config file not found: %w", err)
+	}
+	return path, nil
 }
 
 // findConfigFile searches for config file in standard locations
 func findConfigFile() (string, error) {
-	possiblePaths := []string{
-		"./config.yaml",
-		"./configs/config.yaml",
-		"/etc/aws-monitor/config.yaml",
+	var possiblePaths []string
+	for _, dir := range []string{".", "./configs", "/etc/aws-monitor"} {
+		for _, name := range []string{"config.yaml", "config.json", "config.toml"} {
+			possiblePaths = append(possiblePaths, dir+"/"+name)
+		}
 	}
 
 	for _, path := range possiblePaths {
@@ -160,6 +720,9 @@ func setDefaults(config *Config) {
 	if config.AWS.Timeout == 0 {
 		config.AWS.Timeout = Duration(30 * time.Second)
 	}
+	if config.AWS.InventoryTTL == 0 {
+		config.AWS.InventoryTTL = Duration(60 * time.Second)
+	}
 
 	// OTEL defaults
 	if config.OTEL.BatchTimeout == 0 {
@@ -171,6 +734,15 @@ func setDefaults(config *Config) {
 	if config.OTEL.Headers == nil {
 		config.OTEL.Headers = make(map[string]string)
 	}
+	if config.OTEL.ExportFailureThreshold == 0 {
+		config.OTEL.ExportFailureThreshold = Duration(2 * time.Minute)
+	}
+	if config.OTEL.SRVRefreshInterval == 0 {
+		config.OTEL.SRVRefreshInterval = Duration(30 * time.Second)
+	}
+	if config.OTEL.TraceSampleRatio == 0 {
+		config.OTEL.TraceSampleRatio = 1
+	}
 
 	// Global defaults
 	if config.Global.LogLevel == "" {
@@ -179,6 +751,12 @@ func setDefaults(config *Config) {
 	if config.Global.LogFormat == "" {
 		config.Global.LogFormat = "json"
 	}
+	if config.Global.LogMaxSize == 0 {
+		config.Global.LogMaxSize = 100 // megabytes, only used for log files
+	}
+	if config.Global.LogMaxBackups == 0 {
+		config.Global.LogMaxBackups = 5
+	}
 	if config.Global.HealthCheckPort == 0 {
 		config.Global.HealthCheckPort = 8080
 	}
@@ -194,6 +772,9 @@ func setDefaults(config *Config) {
 	if config.Global.WorkerTimeout == 0 {
 		config.Global.WorkerTimeout = Duration(60 * time.Second)
 	}
+	if config.Global.ShutdownTimeout == 0 {
+		config.Global.ShutdownTimeout = Duration(30 * time.Second)
+	}
 	if config.Global.MaxErrorCount == 0 {
 		config.Global.MaxErrorCount = 5
 	}
@@ -206,6 +787,125 @@ func setDefaults(config *Config) {
 	if config.Global.ExportTimeout == 0 {
 		config.Global.ExportTimeout = Duration(30 * time.Second)
 	}
+	if config.Global.RecentErrorLimit == 0 {
+		config.Global.RecentErrorLimit = 20
+	}
+	if config.Global.HealthChecks.MaxGoroutines == 0 {
+		config.Global.HealthChecks.MaxGoroutines = 10000
+	}
+	if config.Global.HealthChecks.GCPauseThreshold == 0 {
+		config.Global.HealthChecks.GCPauseThreshold = Duration(100 * time.Millisecond)
+	}
+	if config.Global.HealthChecks.MinFreeDiskMB == 0 {
+		config.Global.HealthChecks.MinFreeDiskMB = 100
+	}
+	if config.Global.HealthCheckRateLimit.RequestsPerSecond == 0 {
+		config.Global.HealthCheckRateLimit.RequestsPerSecond = 10
+	}
+	if config.Global.HealthCheckRateLimit.Burst == 0 {
+		config.Global.HealthCheckRateLimit.Burst = 20
+	}
+	if config.Global.HealthCheckCacheTTL == 0 {
+		config.Global.HealthCheckCacheTTL = Duration(time.Second)
+	}
+	if config.Global.ConfigReload.Interval == 0 {
+		config.Global.ConfigReload.Interval = Duration(10 * time.Second)
+	}
+	if config.Global.HealthChecks.HistorySize == 0 {
+		config.Global.HealthChecks.HistorySize = 20
+	}
+	if config.Global.SelfMetrics.Interval == 0 {
+		config.Global.SelfMetrics.Interval = Duration(time.Minute)
+	}
+	if config.Global.State.FlushInterval == 0 {
+		config.Global.State.FlushInterval = Duration(10 * time.Second)
+	}
+	if config.Global.RegionBatching.Threshold == 0 {
+		config.Global.RegionBatching.Threshold = 15
+	}
+	if config.Global.RegionBatching.Size == 0 {
+		config.Global.RegionBatching.Size = 5
+	}
+	if config.Global.Memory.HighRatio == 0 {
+		config.Global.Memory.HighRatio = 0.8
+	}
+	if config.Global.Memory.CriticalRatio == 0 {
+		config.Global.Memory.CriticalRatio = 0.9
+	}
+
+	// Alerting defaults
+	if config.Alerting.Cooldown == 0 {
+		config.Alerting.Cooldown = Duration(15 * time.Minute)
+	}
+	if config.Alerting.MaxPerHour == 0 {
+		config.Alerting.MaxPerHour = 20
+	}
+	if config.Alerting.HealthChanges.Debounce == 0 {
+		config.Alerting.HealthChanges.Debounce = Duration(time.Minute)
+	}
+	if config.Alerting.SNS.Region == "" {
+		config.Alerting.SNS.Region = config.AWS.DefaultRegion
+	}
+	if config.Alerting.PagerDuty.URL == "" {
+		config.Alerting.PagerDuty.URL = "https://events.pagerduty.com/v2/enqueue"
+	}
+	if config.Alerting.Email.SMTPPort == 0 {
+		config.Alerting.Email.SMTPPort = 587
+	}
+	if config.Alerting.DigestInterval == 0 {
+		config.Alerting.DigestInterval = Duration(24 * time.Hour)
+	}
+
+	// Alert rule defaults
+	for i := range config.Alerts {
+		if config.Alerts[i].Severity == "" {
+			config.Alerts[i].Severity = "high"
+		}
+	}
+
+	// Anomaly detection defaults
+	if config.Anomalies.Method == "" {
+		config.Anomalies.Method = "ewma"
+	}
+	if config.Anomalies.Alpha == 0 {
+		config.Anomalies.Alpha = 0.3
+	}
+	if config.Anomalies.Window == 0 {
+		config.Anomalies.Window = 30
+	}
+	if config.Anomalies.Threshold == 0 {
+		config.Anomalies.Threshold = 3
+	}
+	if config.Anomalies.MinSamples == 0 {
+		config.Anomalies.MinSamples = 10
+	}
+	if config.Anomalies.Severity == "" {
+		config.Anomalies.Severity = "warning"
+	}
+
+	// Events defaults
+	if config.Events.BufferSize == 0 {
+		config.Events.BufferSize = 1000
+	}
+	if config.Events.MinSeverity == "" {
+		config.Events.MinSeverity = "info"
+	}
+
+	// Event triggers defaults
+	if config.EventTriggers.Region == "" {
+		config.EventTriggers.Region = config.AWS.DefaultRegion
+	}
+	if config.EventTriggers.WaitTime == 0 {
+		config.EventTriggers.WaitTime = Duration(20 * time.Second)
+	}
+
+	// Control channel defaults
+	if config.Control.Region == "" {
+		config.Control.Region = config.AWS.DefaultRegion
+	}
+	if config.Control.WaitTime == 0 {
+		config.Control.WaitTime = Duration(20 * time.Second)
+	}
 
 	// Set default collection intervals for collectors
 	defaultInterval := config.Global.DefaultInterval
@@ -216,6 +916,55 @@ func setDefaults(config *Config) {
 	setCollectorDefaults(&config.Metrics.EBS, defaultInterval)
 	setCollectorDefaults(&config.Metrics.ELB, defaultInterval)
 	setCollectorDefaults(&config.Metrics.VPC, Duration(600*time.Second)) // 10 minutes for VPC
+
+	for i := range config.Plugins {
+		if config.Plugins[i].CollectionInterval == 0 {
+			config.Plugins[i].CollectionInterval = defaultInterval
+		}
+	}
+
+	// Default group membership, then apply group toggles
+	for name, collector := range config.builtinCollectorConfigs() {
+		if len(collector.Groups) == 0 {
+			collector.Groups = DefaultCollectorGroups[name]
+		}
+	}
+	applyGroupToggles(config)
+}
+
+// applyGroupToggles resolves the enabled state of collectors that belong to a
+// toggled group. A disabled group takes precedence over an enabled one, and an
+// enabled group turns on all of its members.
+func applyGroupToggles(config *Config) {
+	if len(config.Metrics.Groups) == 0 {
+		return
+	}
+
+	for _, collector := range config.builtinCollectorConfigs() {
+		if enabled, toggled := groupToggle(collector.Groups, config.Metrics.Groups); toggled {
+			collector.Enabled = enabled
+		}
+	}
+	for i := range config.Plugins {
+		if enabled, toggled := groupToggle(config.Plugins[i].Groups, config.Metrics.Groups); toggled {
+			config.Plugins[i].Enabled = enabled
+		}
+	}
+}
+
+// groupToggle reports whether any of the groups is toggled and the resulting state
+func groupToggle(groups []string, toggles map[string]bool) (enabled, toggled bool) {
+	for _, group := range groups {
+		groupEnabled, exists := toggles[group]
+		if !exists {
+			continue
+		}
+		if !groupEnabled {
+			return false, true
+		}
+		enabled, toggled = true, true
+	}
+	return enabled, toggled
 }
 
 // setCollectorDefaults sets default values for a collector
@@ -225,6 +974,37 @@ func setCollectorDefaults(collector *CollectorConfig, defaultInterval Duration)
 	}
 }
 
+// ValidationError is returned by Load when the configuration was read and
+// parsed but its settings are invalid, as opposed to a file that cannot be
+// read or parsed
+type ValidationError struct {
+	// Problems describes each invalid setting
+	Problems []string
+	err      error
+}
+
+func (e *ValidationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// newValidationError describes a validation failure, with a problem per
+// field failing struct tag validation
+func newValidationError(err error) *ValidationError {
+	problems := []string{err.Error()}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		problems = problems[:0]
+		for _, fieldError := range validationErrors {
+			problems = append(problems, fmt.Sprintf("field '%s' %s", fieldError.Field(), getValidationMessage(fieldError)))
+		}
+		err = fmt.Errorf("validation failed: %v", problems)
+	}
+	return &ValidationError{Problems: problems, err: fmt.Errorf("config validation failed: %w", err)}
+}
+
 // validate validates the configuration using struct tags
 func validate(config *Config) error {
 	validator := validator.New()
@@ -233,7 +1013,7 @@ func validate(config *Config) error {
 	registerCustomValidations(validator)
 
 	if err := validator.Struct(config); err != nil {
-		return formatValidationError(err)
+		return err
 	}
 
 	// Custom validation logic
@@ -245,6 +1025,71 @@ func registerCustomValidations(_ *validator.Validate) {
 	// Add custom validation for duration fields if needed
 }
 
+// validateMetricPatterns checks the disabled metric patterns of a collector
+// are valid globs
+func validateMetricPatterns(collector string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid disabled_metrics pattern %q for collector %s: %w", pattern, collector, err)
+		}
+	}
+	return nil
+}
+
+// validateRegionIntervals checks that the per-region interval overrides of a
+// collector name known regions and are positive
+func validateRegionIntervals(collector string, intervals map[string]Duration) error {
+	regions := make([]string, 0, len(intervals))
+	for region := range intervals {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	for _, region := range regions {
+		if err := validateRegion(fmt.Sprintf("region_intervals of collector %s", collector), region); err != nil {
+			return err
+		}
+		if intervals[region] <= 0 {
+			return fmt.Errorf("region_intervals of collector %s: interval for %s must be positive", collector, region)
+		}
+	}
+	return nil
+}
+
+// validateQueue checks a section consuming an SQS queue has a queue when
+// enabled and a wait time SQS accepts
+func validateQueue(section string, enabled bool, queueURL string, waitTime Duration) error {
+	if enabled && queueURL == "" {
+		return fmt.Errorf("%s is enabled but no queue_url is configured", section)
+	}
+	if waitTime < 0 || time.Duration(waitTime) > 20*time.Second {
+		return fmt.Errorf("%s wait_time must be at most 20s", section)
+	}
+	return nil
+}
+
+// validateEventTriggers checks event triggers have a queue, a wait time SQS
+// accepts and rules naming known collectors
+func validateEventTriggers(config *Config) error {
+	triggers := config.EventTriggers
+	if err := validateQueue("event_triggers", triggers.Enabled, triggers.QueueURL, triggers.WaitTime); err != nil {
+		return err
+	}
+
+	plugins := make(map[string]bool)
+	for _, plugin := range config.Plugins {
+		plugins[plugin.Name] = true
+	}
+	for _, rule := range triggers.Rules {
+		for _, name := range rule.Collectors {
+			if _, err := config.GetCollectorConfig(name); err != nil && !plugins[name] {
+				return fmt.Errorf("event_triggers rule for %s: unknown collector %s", rule.Source, name)
+			}
+		}
+	}
+	return nil
+}
+
 // validateCustomRules performs custom validation logic
 func validateCustomRules(config *Config) error {
 	// Validate enabled regions
@@ -252,11 +1097,9 @@ func validateCustomRules(config *Config) error {
 		return fmt.Errorf("at least one region must be enabled")
 	}
 
-	// Validate AWS region format (basic check)
-	for _, region := range config.EnabledRegions {
-		if len(region) < 3 {
-			return fmt.Errorf("invalid AWS region format: %s", region)
-		}
+	// Validate regions against the known AWS regions
+	if err := validateRegions(config); err != nil {
+		return err
 	}
 
 	// Validate default region is in enabled regions
@@ -271,20 +1114,151 @@ func validateCustomRules(config *Config) error {
 		return fmt.Errorf("default region %s must be in enabled regions", config.AWS.DefaultRegion)
 	}
 
-	return nil
-}
+	// Validate an srv:// collector endpoint names an SRV record only
+	if discovery.IsSRV(config.OTEL.CollectorEndpoint) {
+		if _, err := discovery.RecordName(config.OTEL.CollectorEndpoint); err != nil {
+			return fmt.Errorf("otel collector_endpoint: %w", err)
+		}
+	}
 
-// formatValidationError formats validation errors into user-friendly messages
-func formatValidationError(err error) error {
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
-		var messages []string
-		for _, fieldError := range validationErrors {
-			message := fmt.Sprintf("field '%s' %s", fieldError.Field(), getValidationMessage(fieldError))
-			messages = append(messages, message)
+	// Validate plugin collector names are unique and don't shadow built-in collectors
+	pluginNames := make(map[string]bool)
+	for _, plugin := range config.Plugins {
+		if _, err := config.GetCollectorConfig(plugin.Name); err == nil {
+			return fmt.Errorf("plugin collector name %s conflicts with a built-in collector", plugin.Name)
+		}
+		if pluginNames[plugin.Name] {
+			return fmt.Errorf("duplicate plugin collector name: %s", plugin.Name)
+		}
+		pluginNames[plugin.Name] = true
+	}
+
+	// Validate disabled metric patterns
+	for name, collector := range config.builtinCollectorConfigs() {
+		if err := validateMetricPatterns(name, collector.DisabledMetrics); err != nil {
+			return err
+		}
+	}
+	for _, plugin := range config.Plugins {
+		if err := validateMetricPatterns(plugin.Name, plugin.DisabledMetrics); err != nil {
+			return err
+		}
+	}
+
+	// Validate per-region interval overrides
+	for name, collector := range config.builtinCollectorConfigs() {
+		if err := validateRegionIntervals(name, collector.RegionIntervals); err != nil {
+			return err
+		}
+	}
+	for _, plugin := range config.Plugins {
+		if err := validateRegionIntervals(plugin.Name, plugin.RegionIntervals); err != nil {
+			return err
+		}
+	}
+
+	// Validate group toggles refer to groups that have members
+	knownGroups := make(map[string]bool)
+	for _, collector := range config.builtinCollectorConfigs() {
+		for _, group := range collector.Groups {
+			knownGroups[group] = true
+		}
+	}
+	for _, plugin := range config.Plugins {
+		for _, group := range plugin.Groups {
+			knownGroups[group] = true
+		}
+	}
+	for group := range config.Metrics.Groups {
+		if !knownGroups[group] {
+			return fmt.Errorf("unknown collector group: %s", group)
+		}
+	}
+
+	// Validate alerting has somewhere to send alerts
+	alerting := config.Alerting
+	if alerting.Enabled && alerting.Slack.WebhookURL == "" && alerting.SNS.TopicARN == "" &&
+		alerting.PagerDuty.RoutingKey == "" && alerting.Email.SMTPHost == "" && len(alerting.Webhooks) == 0 {
+		return fmt.Errorf("alerting is enabled but no slack, sns, pagerduty, email or webhook sink is configured")
+	}
+	if alerting.Email.SMTPHost != "" && (alerting.Email.From == "" || len(alerting.Email.To) == 0) {
+		return fmt.Errorf("alerting email needs a from address and at least one to address")
+	}
+	if _, err := template.New("title").Parse(alerting.Templates.Title); err != nil {
+		return fmt.Errorf("invalid alerting title template: %w", err)
+	}
+	if _, err := template.New("text").Parse(alerting.Templates.Text); err != nil {
+		return fmt.Errorf("invalid alerting text template: %w", err)
+	}
+
+	ruleNames := make(map[string]bool)
+	for _, rule := range config.Alerts {
+		if ruleNames[rule.Name] {
+			return fmt.Errorf("duplicate alert rule name: %s", rule.Name)
+		}
+		ruleNames[rule.Name] = true
+	}
+
+	if anomalies := config.Anomalies; anomalies.Enabled {
+		if len(anomalies.Series) == 0 {
+			return fmt.Errorf("anomaly detection is enabled but no series is configured")
+		}
+		if anomalies.Window < 2 || anomalies.MinSamples < 2 {
+			return fmt.Errorf("anomalies window and min_samples must be at least 2")
 		}
-		return fmt.Errorf("validation failed: %v", messages)
 	}
-	return err
+
+	if config.Events.Enabled && !config.Events.ExportOTLP && len(config.Events.Webhooks) == 0 {
+		return fmt.Errorf("events are enabled but neither otlp export nor a webhook is configured")
+	}
+
+	if err := validateEventTriggers(config); err != nil {
+		return err
+	}
+	if err := validateQueue("control", config.Control.Enabled, config.Control.QueueURL, config.Control.WaitTime); err != nil {
+		return err
+	}
+
+	for level := range config.Global.LogSampling.Levels {
+		switch level {
+		case "debug", "info", "warn", "error":
+		default:
+			return fmt.Errorf("invalid log sampling level: %s", level)
+		}
+	}
+
+	if memory := config.Global.Memory; memory.CriticalRatio > 0 && memory.HighRatio >= memory.CriticalRatio {
+		return fmt.Errorf("memory high_ratio must be below critical_ratio")
+	}
+
+	if config.Global.ErrorTracking.Enabled && config.Global.ErrorTracking.DSN == "" {
+		return fmt.Errorf("error tracking is enabled but no dsn is configured")
+	}
+
+	admin := config.Admin
+	if admin.Enabled && admin.Token == "" && admin.Username == "" {
+		return fmt.Errorf("admin API is enabled but no token or username is configured")
+	}
+	if (admin.Username == "") != (admin.Password == "") {
+		return fmt.Errorf("admin username and password must be set together")
+	}
+
+	healthAuth := config.Global.HealthCheckAuth
+	for group, auth := range map[string]EndpointAuthConfig{
+		"probes":   healthAuth.Probes,
+		"detailed": healthAuth.Detailed,
+		"api":      healthAuth.API,
+	} {
+		if (auth.Username == "") != (auth.Password == "") {
+			return fmt.Errorf("health check auth for %s: username and password must be set together", group)
+		}
+	}
+
+	if tlsConfig := config.Global.HealthCheckTLS; tlsConfig.Enabled && (tlsConfig.CertFile == "" || tlsConfig.KeyFile == "") {
+		return fmt.Errorf("health check TLS is enabled but cert_file or key_file is not configured")
+	}
+
+	return nil
 }
 
 // getValidationMessage returns a user-friendly validation message
@@ -296,10 +1270,16 @@ func getValidationMessage(fieldError validator.FieldError) string {
 		return fmt.Sprintf("must be at least %s", fieldError.Param())
 	case "max":
 		return fmt.Sprintf("must be at most %s", fieldError.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldError.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fieldError.Param())
 	case "url":
 		return "must be a valid URL"
 	case "oneof":
 		return fmt.Sprintf("must be one of: %s", fieldError.Param())
+	case "required_if":
+		return fmt.Sprintf("is required when %s", fieldError.Param())
 	default:
 		return fmt.Sprintf("failed validation: %s", fieldError.Tag())
 	}
@@ -327,7 +1307,42 @@ func (c *Config) GetCollectorConfig(collectorName string) (CollectorConfig, erro
 	}
 }
 
-// Save saves the configuration to a file
+// GetCollectorGroups returns the names of collectors, built-in and plugin,
+// that belong to each group
+func (c *Config) GetCollectorGroups() map[string][]string {
+	groups := make(map[string][]string)
+	for name, collector := range c.builtinCollectorConfigs() {
+		for _, group := range collector.Groups {
+			groups[group] = append(groups[group], name)
+		}
+	}
+	for _, plugin := range c.Plugins {
+		for _, group := range plugin.Groups {
+			groups[group] = append(groups[group], plugin.Name)
+		}
+	}
+
+	for group := range groups {
+		sort.Strings(groups[group])
+	}
+
+	return groups
+}
+
+// builtinCollectorConfigs returns the built-in collector configurations by name
+func (c *Config) builtinCollectorConfigs() map[string]*CollectorConfig {
+	return map[string]*CollectorConfig{
+		"ec2":    &c.Metrics.EC2,
+		"rds":    &c.Metrics.RDS,
+		"s3":     &c.Metrics.S3,
+		"lambda": &c.Metrics.Lambda,
+		"ebs":    &c.Metrics.EBS,
+		"elb":    &c.Metrics.ELB,
+		"vpc":    &c.Metrics.VPC,
+	}
+}
+
+// Save saves the configuration to a file as YAML
 func (c *Config) Save(configPath string) error {
 	data, err := yaml.Marshal(c)
 	if err != nil {
@@ -1,11 +1,22 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"gopkg.in/yaml.v3"
 )
 
@@ -145,41 +156,1672 @@ otel:
 `,
 			expectError: true,
 		},
+		{
+			name: "SRV endpoint",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "srv://_otlp._tcp.collector.internal"
+  service_name: "aws-monitor"
+  srv_refresh_interval: 1m
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.OTEL.SRVRefreshInterval == Duration(time.Minute)
+			},
+		},
+		{
+			name: "SRV endpoint with a port",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "srv://_otlp._tcp.collector.internal:4317"
+  service_name: "aws-monitor"
+`,
+			expectError: true,
+		},
+		{
+			name: "plugin collectors",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+plugins:
+  - name: billing
+    type: acme-billing
+    path: /opt/plugins/billing.so
+    enabled: true
+    settings:
+      team: finance
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return len(c.Plugins) == 1 &&
+					c.Plugins[0].Type == "acme-billing" &&
+					c.Plugins[0].Settings["team"] == "finance" &&
+					c.Plugins[0].CollectionInterval == c.Global.DefaultInterval
+			},
+		},
+		{
+			name: "plugin name conflicts with built-in collector",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+plugins:
+  - name: ec2
+    type: custom-ec2
+`,
+			expectError: true,
+		},
+		{
+			name: "plugin missing type",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+plugins:
+  - name: billing
+`,
+			expectError: true,
+		},
+		{
+			name: "memory high ratio above critical ratio",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  memory:
+    soft_limit_mb: 512
+    high_ratio: 0.95
+`,
+			expectError: true,
+		},
+		{
+			name: "invalid plugin priority",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+plugins:
+  - name: billing
+    type: acme-billing
+    priority: urgent
+`,
+			expectError: true,
+		},
+		{
+			name: "collector group toggles",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+metrics:
+  groups:
+    compute: false
+    storage: true
+    cost: true
+  ec2:
+    enabled: true
+  lambda:
+    enabled: true
+  s3:
+    enabled: false
+  rds:
+    enabled: true
+plugins:
+  - name: billing
+    type: acme-billing
+    groups: [cost]
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				groups := c.GetCollectorGroups()
+				return !c.Metrics.EC2.Enabled &&
+					!c.Metrics.Lambda.Enabled &&
+					c.Metrics.S3.Enabled &&
+					c.Metrics.EBS.Enabled &&
+					c.Metrics.RDS.Enabled &&
+					c.Plugins[0].Enabled &&
+					len(groups["compute"]) == 2 &&
+					len(groups["cost"]) == 1 && groups["cost"][0] == "billing"
+			},
+		},
+		{
+			name: "admin API without token",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+admin:
+  enabled: true
+`,
+			expectError: true,
+		},
+		{
+			name: "admin API and detailed health with basic auth",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+admin:
+  enabled: true
+  username: ops
+  password: hunter2
+global:
+  health_check_auth:
+    detailed:
+      token: detailed-secret
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.Admin.Username == "ops" &&
+					c.Global.HealthCheckAuth.Detailed.Token == "detailed-secret" &&
+					c.Global.HealthCheckAuth.Probes.Token == ""
+			},
+		},
+		{
+			name: "health check auth without password",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  health_check_auth:
+    detailed:
+      username: ops
+`,
+			expectError: true,
+		},
+		{
+			name: "health check TLS without key",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  health_check_tls:
+    enabled: true
+    cert_file: /etc/aws-monitor/tls.crt
+`,
+			expectError: true,
+		},
+		{
+			name: "unknown collector group",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+metrics:
+  groups:
+    computee: false
+`,
+			expectError: true,
+		},
+		{
+			name: "health checker settings",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  health_checks:
+    checkers:
+      aws_connectivity:
+        interval: 5m
+        timeout: 30s
+        retries: 2
+      gc_pauses:
+        enabled: false
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				checkers := c.Global.HealthChecks.Checkers
+				return checkers["aws_connectivity"].Enabled &&
+					checkers["aws_connectivity"].Interval == Duration(5*time.Minute) &&
+					checkers["aws_connectivity"].Retries == 2 &&
+					!checkers["gc_pauses"].Enabled
+			},
+		},
+		{
+			name: "alerting sinks",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerting:
+  enabled: true
+  sns:
+    topic_arn: "arn:aws:sns:us-east-1:123456789012:alerts"
+  webhooks:
+    - url: "https://hooks.example.com/alerts"
+      headers:
+        Authorization: "Bearer token"
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.Alerting.SNS.Region == "us-east-1" &&
+					c.Alerting.Cooldown == Duration(15*time.Minute) &&
+					c.Alerting.MaxPerHour == 20 &&
+					c.Alerting.HealthChanges.Debounce == Duration(time.Minute) &&
+					len(c.Alerting.Webhooks) == 1
+			},
+		},
+		{
+			name: "alerting without sinks",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerting:
+  enabled: true
+`,
+			expectError: true,
+		},
+		{
+			name: "alerting pagerduty and email",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerting:
+  enabled: true
+  pagerduty:
+    routing_key: "routing-key"
+  email:
+    smtp_host: smtp.example.com
+    from: aws-monitor@example.com
+    to: [oncall@example.com]
+  templates:
+    title: "{{.Severity}} {{.Collector}}"
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.Alerting.PagerDuty.URL == "https://events.pagerduty.com/v2/enqueue" &&
+					c.Alerting.Email.SMTPPort == 587 &&
+					len(c.Alerting.Email.To) == 1
+			},
+		},
+		{
+			name: "alerting email without recipients",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerting:
+  enabled: true
+  email:
+    smtp_host: smtp.example.com
+    from: aws-monitor@example.com
+`,
+			expectError: true,
+		},
+		{
+			name: "alerting with invalid template",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerting:
+  enabled: true
+  webhooks:
+    - url: "https://hooks.example.com/alerts"
+  templates:
+    text: "{{.Message"
+`,
+			expectError: true,
+		},
+		{
+			name: "alert rules",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerts:
+  - name: queue-backlog
+    metric: aws_sqs_queue_depth
+    labels:
+      queue: orders
+    operator: ">"
+    threshold: 1000
+    for: 5m
+  - name: ec2-cost-spike
+    metric: aws_cost_daily_amount
+    operator: ">"
+    threshold: 30
+    change: 24h
+    digest: true
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return len(c.Alerts) == 2 &&
+					c.Alerts[1].Change == Duration(24*time.Hour) &&
+					c.Alerts[1].Digest &&
+					c.Alerting.DigestInterval == Duration(24*time.Hour) &&
+					c.Alerts[0].Operator == ">" &&
+					c.Alerts[0].Threshold == 1000 &&
+					c.Alerts[0].For == Duration(5*time.Minute) &&
+					c.Alerts[0].Labels["queue"] == "orders" &&
+					c.Alerts[0].Severity == "high"
+			},
+		},
+		{
+			name: "alert rule with invalid operator",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerts:
+  - name: queue-backlog
+    metric: aws_sqs_queue_depth
+    operator: "=>"
+    threshold: 1000
+`,
+			expectError: true,
+		},
+		{
+			name: "duplicate alert rule names",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+alerts:
+  - name: queue-backlog
+    metric: aws_sqs_queue_depth
+    operator: ">"
+    threshold: 1000
+  - name: queue-backlog
+    metric: aws_sqs_oldest_message_age
+    operator: ">"
+    threshold: 600
+`,
+			expectError: true,
+		},
+		{
+			name: "anomaly detection",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+anomalies:
+  enabled: true
+  method: rolling
+  series:
+    - metric: aws_natgateway_bytes_out_to_destination
+    - metric: aws_lambda_errors
+      labels:
+        function_name: orders
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.Anomalies.Enabled &&
+					c.Anomalies.Method == "rolling" &&
+					c.Anomalies.Window == 30 &&
+					c.Anomalies.Threshold == 3 &&
+					len(c.Anomalies.Series) == 2 &&
+					c.Anomalies.Series[1].Labels["function_name"] == "orders"
+			},
+		},
+		{
+			name: "anomaly detection without series",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+anomalies:
+  enabled: true
+`,
+			expectError: true,
+		},
+		{
+			name: "anomaly detection with unknown method",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+anomalies:
+  enabled: true
+  method: median
+  series:
+    - metric: aws_lambda_errors
+`,
+			expectError: true,
+		},
+		{
+			name: "events",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+events:
+  enabled: true
+  min_severity: warning
+  webhooks:
+    - url: "https://hooks.example.com/events"
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.Events.BufferSize == 1000 &&
+					c.Events.MinSeverity == "warning" &&
+					len(c.Events.Webhooks) == 1
+			},
+		},
+		{
+			name: "events without sinks",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+events:
+  enabled: true
+`,
+			expectError: true,
+		},
+		{
+			name: "event triggers",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+event_triggers:
+  enabled: true
+  queue_url: "https://sqs.us-east-1.amazonaws.com/123456789012/aws-monitor-events"
+  rules:
+    - source: aws.autoscaling
+      detail_types: ["EC2 Instance Launch Successful"]
+      collectors: [ec2, ebs]
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.EventTriggers.Region == "us-east-1" &&
+					c.EventTriggers.WaitTime == Duration(20*time.Second) &&
+					len(c.EventTriggers.Rules) == 1 &&
+					len(c.EventTriggers.Rules[0].Collectors) == 2
+			},
+		},
+		{
+			name: "event triggers without queue",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+event_triggers:
+  enabled: true
+`,
+			expectError: true,
+		},
+		{
+			name: "event triggers with unknown collector",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+event_triggers:
+  enabled: true
+  queue_url: "https://sqs.us-east-1.amazonaws.com/123456789012/aws-monitor-events"
+  rules:
+    - source: aws.ec2
+      collectors: [ec3]
+`,
+			expectError: true,
+		},
+		{
+			name: "control without queue",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+control:
+  enabled: true
+`,
+			expectError: true,
+		},
+		{
+			name: "control with long wait time",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+control:
+  enabled: true
+  queue_url: "https://sqs.us-east-1.amazonaws.com/123456789012/aws-monitor-control"
+  wait_time: 30s
+`,
+			expectError: true,
+		},
+		{
+			name: "events with invalid severity",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+events:
+  min_severity: debug
+`,
+			expectError: true,
+		},
+		{
+			name: "error tracking",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  error_tracking:
+    enabled: true
+    dsn: "https://public@sentry.example.com/42"
+    environment: production
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				return c.Global.ErrorTracking.Enabled &&
+					c.Global.ErrorTracking.DSN == "https://public@sentry.example.com/42" &&
+					c.Global.ErrorTracking.Environment == "production"
+			},
+		},
+		{
+			name: "log sampling",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  log_sampling:
+    initial: 100
+    thereafter: 100
+    tick: 1s
+    levels:
+      warn:
+        initial: 10
+        thereafter: 1000
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				sampling := c.Global.LogSampling
+				return sampling.Initial == 100 && time.Duration(sampling.Tick) == time.Second &&
+					sampling.Levels["warn"].Thereafter == 1000
+			},
+		},
+		{
+			name: "log sampling unknown level",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  log_sampling:
+    levels:
+      warning:
+        initial: 10
+`,
+			expectError: true,
+		},
+		{
+			name: "error tracking without dsn",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  error_tracking:
+    enabled: true
+`,
+			expectError: true,
+		},
+		{
+			name: "collector SLO",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+metrics:
+  ec2:
+    enabled: true
+    slo:
+      target: 0.99
+      windows: [1h, 6h]
+      burn_rate_threshold: 10
+`,
+			expectError: false,
+			validate: func(c *Config) bool {
+				slo := c.Metrics.EC2.SLO
+				return slo.Target == 0.99 &&
+					len(slo.Windows) == 2 && slo.Windows[1] == Duration(6*time.Hour) &&
+					slo.BurnRateThreshold == 10
+			},
+		},
+		{
+			name: "invalid SLO target",
+			configYAML: `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+metrics:
+  ec2:
+    slo:
+      target: 99
+`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temporary config file
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "config.yaml")
+			err := os.WriteFile(configPath, []byte(tt.configYAML), 0600)
+			if err != nil {
+				t.Fatalf("Failed to create test config file: %v", err)
+			}
+
+			// Load configuration
+			config, err := Load(configPath)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+
+			if tt.validate != nil && !tt.validate(config) {
+				t.Errorf("Config validation failed")
+			}
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	configYAML := `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+metrics:
+  ec2:
+    enabled: true
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configYAML), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Test AWS defaults
+	if config.AWS.MaxRetries != 3 {
+		t.Errorf("Expected AWS.MaxRetries to be 3, got %d", config.AWS.MaxRetries)
+	}
+	if time.Duration(config.AWS.Timeout) != 30*time.Second {
+		t.Errorf("Expected AWS.Timeout to be 30s, got %s", config.AWS.Timeout)
+	}
+
+	// Test OTEL defaults
+	if config.OTEL.BatchSize != 512 {
+		t.Errorf("Expected OTEL.BatchSize to be 512, got %d", config.OTEL.BatchSize)
+	}
+	if time.Duration(config.OTEL.BatchTimeout) != 5*time.Second {
+		t.Errorf("Expected OTEL.BatchTimeout to be 5s, got %s", config.OTEL.BatchTimeout)
+	}
+	if config.Global.HealthChecks.MaxGoroutines != 10000 {
+		t.Errorf("Expected Global.HealthChecks.MaxGoroutines to be 10000, got %d", config.Global.HealthChecks.MaxGoroutines)
+	}
+	if time.Duration(config.Global.HealthChecks.GCPauseThreshold) != 100*time.Millisecond {
+		t.Errorf("Expected Global.HealthChecks.GCPauseThreshold to be 100ms, got %s", config.Global.HealthChecks.GCPauseThreshold)
+	}
+	if config.Global.HealthChecks.MinFreeDiskMB != 100 {
+		t.Errorf("Expected Global.HealthChecks.MinFreeDiskMB to be 100, got %d", config.Global.HealthChecks.MinFreeDiskMB)
+	}
+	if config.Global.HealthChecks.HistorySize != 20 {
+		t.Errorf("Expected Global.HealthChecks.HistorySize to be 20, got %d", config.Global.HealthChecks.HistorySize)
+	}
+	if config.Global.HealthCheckRateLimit.RequestsPerSecond != 10 || config.Global.HealthCheckRateLimit.Burst != 20 {
+		t.Errorf("Expected Global.HealthCheckRateLimit to be 10/s with a burst of 20, got %+v", config.Global.HealthCheckRateLimit)
+	}
+	if config.Global.ConfigReload.Interval != Duration(10*time.Second) {
+		t.Errorf("Expected Global.ConfigReload.Interval to be 10s, got %v", config.Global.ConfigReload.Interval)
+	}
+	if config.Global.HealthCheckCacheTTL != Duration(time.Second) {
+		t.Errorf("Expected Global.HealthCheckCacheTTL to be 1s, got %v", config.Global.HealthCheckCacheTTL)
+	}
+	if config.Global.ShutdownTimeout != Duration(30*time.Second) {
+		t.Errorf("Expected Global.ShutdownTimeout to be 30s, got %v", config.Global.ShutdownTimeout)
+	}
+	if config.Global.SelfMetrics.Enabled || config.Global.SelfMetrics.Interval != Duration(time.Minute) {
+		t.Errorf("Expected Global.SelfMetrics to be disabled with a 1m interval, got %+v", config.Global.SelfMetrics)
+	}
+	if anomalies := config.Anomalies; anomalies.Enabled || anomalies.Method != "ewma" || anomalies.Alpha != 0.3 ||
+		anomalies.Window != 30 || anomalies.Threshold != 3 || anomalies.MinSamples != 10 || anomalies.Severity != "warning" {
+		t.Errorf("Expected Anomalies to be disabled with the ewma defaults, got %+v", anomalies)
+	}
+	if config.Events.Enabled || config.Events.BufferSize != 1000 || config.Events.MinSeverity != "info" {
+		t.Errorf("Expected Events to be disabled with a 1000 event buffer from info, got %+v", config.Events)
+	}
+	if config.EventTriggers.Enabled || config.EventTriggers.Region != "us-east-1" || config.EventTriggers.WaitTime != Duration(20*time.Second) {
+		t.Errorf("Expected EventTriggers to be disabled in us-east-1 with a 20s wait, got %+v", config.EventTriggers)
+	}
+	if config.Control.Enabled || config.Control.Region != "us-east-1" || config.Control.WaitTime != Duration(20*time.Second) {
+		t.Errorf("Expected Control to be disabled in us-east-1 with a 20s wait, got %+v", config.Control)
+	}
+	if time.Duration(config.OTEL.SRVRefreshInterval) != 30*time.Second {
+		t.Errorf("Expected OTEL.SRVRefreshInterval to be 30s, got %s", config.OTEL.SRVRefreshInterval)
+	}
+	if time.Duration(config.OTEL.ExportFailureThreshold) != 2*time.Minute {
+		t.Errorf("Expected OTEL.ExportFailureThreshold to be 2m, got %s", config.OTEL.ExportFailureThreshold)
+	}
+	if config.OTEL.TraceSampleRatio != 1 {
+		t.Errorf("Expected OTEL.TraceSampleRatio to be 1, got %g", config.OTEL.TraceSampleRatio)
+	}
+
+	// Test Global defaults
+	if config.Global.LogLevel != "info" {
+		t.Errorf("Expected Global.LogLevel to be 'info', got %s", config.Global.LogLevel)
+	}
+	if config.Global.LogFormat != "json" {
+		t.Errorf("Expected Global.LogFormat to be 'json', got %s", config.Global.LogFormat)
+	}
+	if config.Global.HealthCheckPort != 8080 {
+		t.Errorf("Expected Global.HealthCheckPort to be 8080, got %d", config.Global.HealthCheckPort)
+	}
+	if config.Global.MaxConcurrentWorkers != 10 {
+		t.Errorf("Expected Global.MaxConcurrentWorkers to be 10, got %d", config.Global.MaxConcurrentWorkers)
+	}
+	if config.Global.LogMaxSize != 100 || config.Global.LogMaxBackups != 5 {
+		t.Errorf("Expected log rotation defaults of 100MB and 5 backups, got %dMB and %d backups",
+			config.Global.LogMaxSize, config.Global.LogMaxBackups)
+	}
+
+	// Test collector defaults
+	if time.Duration(config.Metrics.EC2.CollectionInterval) != 300*time.Second {
+		t.Errorf("Expected EC2.CollectionInterval to be 300s, got %s", config.Metrics.EC2.CollectionInterval)
+	}
+	if time.Duration(config.Metrics.S3.CollectionInterval) != 600*time.Second {
+		t.Errorf("Expected S3.CollectionInterval to be 600s, got %s", config.Metrics.S3.CollectionInterval)
+	}
+}
+
+func TestGetCollectorConfig(t *testing.T) {
+	config := &Config{
+		Metrics: MetricsConfig{
+			EC2: CollectorConfig{
+				Enabled:            true,
+				CollectionInterval: Duration(300 * time.Second),
+			},
+			RDS: CollectorConfig{
+				Enabled:            false,
+				CollectionInterval: Duration(600 * time.Second),
+			},
+		},
+	}
+
+	// Test valid collector
+	ec2Config, err := config.GetCollectorConfig("ec2")
+	if err != nil {
+		t.Errorf("Unexpected error getting EC2 config: %v", err)
+	}
+	if !ec2Config.Enabled {
+		t.Errorf("Expected EC2 to be enabled")
+	}
+
+	// Test invalid collector
+	_, err = config.GetCollectorConfig("invalid")
+	if err == nil {
+		t.Errorf("Expected error for invalid collector")
+	}
+}
+
+func TestSave(t *testing.T) {
+	config := &Config{
+		EnabledRegions: []string{"us-east-1"},
+		AWS: AWSConfig{
+			AccessKeyID:     "test-key",
+			SecretAccessKey: "test-secret",
+			DefaultRegion:   "us-east-1",
+		},
+		OTEL: OTELConfig{
+			CollectorEndpoint: "http://localhost:4317",
+			ServiceName:       "aws-monitor",
+		},
+	}
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "saved-config.yaml")
+
+	err := config.Save(configPath)
+	if err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	// Verify file exists and has correct permissions
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("Config file not created: %v", err)
+	}
+
+	mode := info.Mode()
+	if mode != 0600 {
+		t.Errorf("Expected file permissions 0600, got %o", mode)
+	}
+
+	// Load the saved config and verify
+	loadedConfig, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+
+	if loadedConfig.AWS.AccessKeyID != config.AWS.AccessKeyID {
+		t.Errorf("Config not saved correctly")
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	// Test when config file doesn't exist
+	_, err := findConfigFile()
+	if err == nil {
+		t.Errorf("Expected error when config file doesn't exist")
+	}
+
+	// Create a config file in current directory
+	tmpConfig := "config.yaml"
+	err = os.WriteFile(tmpConfig, []byte("test"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+	defer func() { _ = os.Remove(tmpConfig) }()
+
+	path, err := findConfigFile()
+	if err != nil {
+		t.Errorf("Unexpected error finding config file: %v", err)
+	}
+	if path != "./config.yaml" {
+		t.Errorf("Expected './config.yaml', got %s", path)
+	}
+}
+
+func TestDurationUnmarshal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		hasError bool
+	}{
+		{"30s", 30 * time.Second, false},
+		{"5m", 5 * time.Minute, false},
+		{"1h", 1 * time.Hour, false},
+		{"invalid", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var d Duration
+			err := d.UnmarshalYAML(&yaml.Node{Value: tt.input, Kind: yaml.ScalarNode})
+
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("Expected error for input %s", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error for input %s: %v", tt.input, err)
+				return
+			}
+
+			if time.Duration(d) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, time.Duration(d))
+			}
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("AWS_MONITOR_TEST_KEY", "from-env")
+	t.Setenv("AWS_MONITOR_TEST_EMPTY", "")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"key: ${AWS_MONITOR_TEST_KEY}", "key: from-env"},
+		{"key: ${AWS_MONITOR_TEST_UNSET}", "key: "},
+		{"port: ${AWS_MONITOR_TEST_UNSET:-8080}", "port: 8080"},
+		{"port: ${AWS_MONITOR_TEST_EMPTY:-8080}", "port: 8080"},
+		{"key: ${AWS_MONITOR_TEST_KEY:-default}", "key: from-env"},
+		{"secret: pa$word", "secret: pa$word"},
+		{"secret: $${AWS_MONITOR_TEST_KEY}", "secret: ${AWS_MONITOR_TEST_KEY}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := string(expandEnv([]byte(tt.input))); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadExpandsEnv(t *testing.T) {
+	t.Setenv("AWS_MONITOR_TEST_SECRET", "secret-from-env")
+
+	configYAML := `
+enabled_regions:
+  - us-east-1
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "${AWS_MONITOR_TEST_SECRET}"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  health_check_port: ${AWS_MONITOR_TEST_PORT:-9090}
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.AWS.SecretAccessKey != "secret-from-env" {
+		t.Errorf("Expected secret from the environment, got %q", config.AWS.SecretAccessKey)
+	}
+	if config.Global.HealthCheckPort != 9090 {
+		t.Errorf("Expected default health check port 9090, got %d", config.Global.HealthCheckPort)
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "json",
+			file: "config.json",
+			content: `{
+	"enabled_regions": ["us-east-1", "us-west-2"],
+	"aws": {"access_key_id": "test-key", "secret_access_key": "test-secret", "default_region": "us-east-1"},
+	"otel": {"collector_endpoint": "http://localhost:4317", "service_name": "aws-monitor"},
+	"metrics": {"ec2": {"enabled": true, "collection_interval": "2m"}},
+	"plugins": [{"name": "custom", "type": "custom", "enabled": true}],
+	"global": {"health_check_port": 9091}
+}`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `
+enabled_regions = ["us-east-1", "us-west-2"]
+
+[aws]
+access_key_id = "test-key"
+secret_access_key = 'test-secret'
+default_region = "us-east-1"
+
+[otel]
+collector_endpoint = "http://localhost:4317"
+service_name = "aws-monitor" # trailing comment
+
+[metrics.ec2]
+enabled = true
+collection_interval = "2m"
+
+[[plugins]]
+name = "custom"
+type = "custom"
+enabled = true
+
+[global]
+health_check_port = 9_091
+`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create temporary config file
-			tmpDir := t.TempDir()
-			configPath := filepath.Join(tmpDir, "config.yaml")
-			err := os.WriteFile(configPath, []byte(tt.configYAML), 0600)
+			configPath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(configPath, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			config, err := Load(configPath)
 			if err != nil {
-				t.Fatalf("Failed to create test config file: %v", err)
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if len(config.EnabledRegions) != 2 || config.EnabledRegions[1] != "us-west-2" {
+				t.Errorf("Expected regions [us-east-1 us-west-2], got %v", config.EnabledRegions)
+			}
+			if config.AWS.SecretAccessKey != "test-secret" {
+				t.Errorf("Expected secret 'test-secret', got %q", config.AWS.SecretAccessKey)
+			}
+			if !config.Metrics.EC2.Enabled || time.Duration(config.Metrics.EC2.CollectionInterval) != 2*time.Minute {
+				t.Errorf("Expected EC2 enabled every 2m, got %+v", config.Metrics.EC2)
+			}
+			if len(config.Plugins) != 1 || config.Plugins[0].Name != "custom" {
+				t.Errorf("Expected plugin 'custom', got %+v", config.Plugins)
+			}
+			if config.Global.HealthCheckPort != 9091 {
+				t.Errorf("Expected health check port 9091, got %d", config.Global.HealthCheckPort)
+			}
+			// Defaults and validation apply to every format
+			if config.AWS.MaxRetries != 3 {
+				t.Errorf("Expected default AWS.MaxRetries 3, got %d", config.AWS.MaxRetries)
+			}
+		})
+	}
+}
+
+func TestLoadFormatErrors(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{file: "config.json", content: `{"enabled_regions": ["us-east-1"],}`},
+		{file: "config.toml", content: "[aws\naccess_key_id = \"key\"\n"},
+		{file: "config.toml", content: "enabled_regions = [\"us-east-1\"]\nenabled_regions = []\n"},
+		// Valid syntax, but fails validation
+		{file: "config.json", content: `{"enabled_regions": []}`},
+	}
+
+	for _, tt := range tests {
+		configPath := filepath.Join(t.TempDir(), tt.file)
+		if err := os.WriteFile(configPath, []byte(tt.content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("Expected error loading %s %q", tt.file, tt.content)
+		}
+	}
+}
+
+func TestDecodeTOML(t *testing.T) {
+	doc := `
+# comment
+title = "multi\tline \u00e9"
+literal = 'C:\path'
+int = -42
+hex = 0xff
+float = 1.5e3
+bool = false
+nested = [[1, 2], ["a"]]
+inline = { name = "x", port = 80 }
+"quoted key" = 1
+a.b.c = true
+text = """
+first \
+  second"""
+raw = '''
+keep \n'''
+
+[table.sub]
+key = "value"
+
+[[items]]
+id = 1
+
+[[items]]
+id = 2
+
+[items.extra]
+flag = true
+`
+	got, err := decodeDocument("config.toml", []byte(doc))
+	if err != nil {
+		t.Fatalf("Failed to parse TOML: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"title":      "multi\tline é",
+		"literal":    `C:\path`,
+		"int":        int64(-42),
+		"hex":        int64(255),
+		"float":      1500.0,
+		"bool":       false,
+		"nested":     []interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{"a"}},
+		"inline":     map[string]interface{}{"name": "x", "port": int64(80)},
+		"quoted key": int64(1),
+		"a":          map[string]interface{}{"b": map[string]interface{}{"c": true}},
+		"text":       "first second",
+		"raw":        `keep \n`,
+		"table":      map[string]interface{}{"sub": map[string]interface{}{"key": "value"}},
+		"items": []map[string]interface{}{
+			{"id": int64(1)},
+			{"id": int64(2), "extra": map[string]interface{}{"flag": true}},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestLoadIncludes(t *testing.T) {
+	dir := t.TempDir()
+	base := `
+include:
+  - overrides/regions.json
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+  headers:
+    team: platform
+metrics:
+  ec2:
+    enabled: true
+    collection_interval: 5m
+`
+	files := map[string]string{
+		"config.yaml":            base,
+		"overrides/regions.json": `{"enabled_regions": ["us-east-1"], "include": "env.toml"}`,
+		"overrides/env.toml":     "[otel.headers]\nenv = \"prod\"\n",
+		"conf.d/10-regions.yaml": "enabled_regions:\n  - us-east-1\n  - eu-west-1\n",
+		"conf.d/20-ec2.yaml":     "metrics:\n  ec2:\n    collection_interval: 1m\n",
+		"conf.d/README.md":       "not a config file",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(dir, "config.yaml")
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Lists are replaced, maps are merged key by key
+	if !reflect.DeepEqual(config.EnabledRegions, []string{"us-east-1", "eu-west-1"}) {
+		t.Errorf("Expected regions from conf.d, got %v", config.EnabledRegions)
+	}
+	if config.OTEL.Headers["team"] != "platform" || config.OTEL.Headers["env"] != "prod" {
+		t.Errorf("Expected merged headers, got %v", config.OTEL.Headers)
+	}
+	if !config.Metrics.EC2.Enabled || time.Duration(config.Metrics.EC2.CollectionInterval) != time.Minute {
+		t.Errorf("Expected EC2 enabled every 1m, got %+v", config.Metrics.EC2)
+	}
+
+	sources, err := SourceFiles(configPath)
+	if err != nil {
+		t.Fatalf("Failed to list source files: %v", err)
+	}
+	expected := []string{
+		configPath,
+		filepath.Join(dir, "overrides/regions.json"),
+		filepath.Join(dir, "overrides/env.toml"),
+		filepath.Join(dir, "conf.d/10-regions.yaml"),
+		filepath.Join(dir, "conf.d/20-ec2.yaml"),
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("Expected source files %v, got %v", expected, sources)
+	}
+}
+
+func TestLoadIncludeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		include string
+	}{
+		{name: "missing file", include: "include: missing.yaml"},
+		{name: "not a string", include: "include: [1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.include), 0600); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+			if _, err := Load(configPath); err == nil {
+				t.Errorf("Expected error for %s", tt.include)
+			}
+		})
+	}
+}
+
+// fakeRemote points the remote fetcher at handler for the duration of a test
+func fakeRemote(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	previous := remote
+	remote = &remoteFetcher{
+		loadAWSConfig: func(_ context.Context, region string) (aws.Config, error) {
+			if region == "" {
+				region = "us-west-2"
+			}
+			return aws.Config{
+				Region:       region,
+				BaseEndpoint: aws.String(server.URL),
+				Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+				}),
+			}, nil
+		},
+	}
+	t.Cleanup(func() { remote = previous })
+}
+
+func TestLoadRemote(t *testing.T) {
+	document := `{
+	"enabled_regions": ["eu-west-1"],
+	"aws": {"access_key_id": "test-key", "secret_access_key": "test-secret", "default_region": "eu-west-1"},
+	"otel": {"collector_endpoint": "http://localhost:4317", "service_name": "aws-monitor"}
+}`
+
+	var throttled atomic.Bool
+	fakeRemote(t, func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		switch {
+		case r.URL.Path == "/configs/throttled.json" && !throttled.Swap(true):
+			// Throttling is retried
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>"))
+		case r.Method == http.MethodGet && (r.URL.Path == "/configs/monitor/config.json" ||
+			r.URL.Path == "/configs.example.com/monitor/config.json" || r.URL.Path == "/configs/throttled.json"):
+			if !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+				t.Errorf("Expected S3 request signed for eu-west-1, got %q", auth)
 			}
+			_, _ = w.Write([]byte(document))
+		case r.Method == http.MethodPost && r.Header.Get("X-Amz-Target") == "AmazonSSM.GetParameter":
+			if !strings.Contains(auth, "/us-west-2/ssm/aws4_request") {
+				t.Errorf("Expected SSM request signed for us-west-2, got %q", auth)
+			}
+			body, _ := io.ReadAll(r.Body)
+			var input struct {
+				Name           string
+				WithDecryption bool
+			}
+			_ = json.Unmarshal(body, &input)
+			if input.Name != "/aws-monitor/config" || !input.WithDecryption {
+				t.Errorf("Expected decrypted parameter /aws-monitor/config, got %+v", input)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"Parameter": map[string]string{"Value": document},
+			})
+		default:
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>"))
+		}
+	})
+
+	for _, location := range []string{
+		"s3://configs/monitor/config.json?region=eu-west-1",
+		"s3://configs.example.com/monitor/config.json?region=eu-west-1",
+		"s3://configs/throttled.json?region=eu-west-1",
+		"ssm:///aws-monitor/config",
+	} {
+		config, err := Load(location)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", location, err)
+		}
+		if len(config.EnabledRegions) != 1 || config.EnabledRegions[0] != "eu-west-1" {
+			t.Errorf("Expected regions [eu-west-1] from %s, got %v", location, config.EnabledRegions)
+		}
+	}
+
+	if _, err := Load("s3://configs/missing.yaml"); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Expected access denied error, got %v", err)
+	}
+	if _, err := Load("s3://configs"); err == nil {
+		t.Errorf("Expected error for S3 location without a key")
+	}
+}
 
-			// Load configuration
-			config, err := Load(configPath)
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("Failed to generate schema: %v", err)
+	}
 
-			if tt.expectError {
-				if err == nil {
-					t.Errorf("Expected error but got none")
-				}
-				return
-			}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
 
-			if err != nil {
-				t.Errorf("Unexpected error: %v", err)
-				return
+	property := func(path ...string) map[string]interface{} {
+		t.Helper()
+		node := schema
+		for _, name := range path {
+			properties, _ := node["properties"].(map[string]interface{})
+			next, ok := properties[name].(map[string]interface{})
+			if !ok {
+				t.Fatalf("Expected schema property %s", strings.Join(path, "."))
 			}
+			node = next
+		}
+		return node
+	}
 
-			if tt.validate != nil && !tt.validate(config) {
-				t.Errorf("Config validation failed")
+	if !reflect.DeepEqual(schema["required"], []interface{}{"enabled_regions"}) {
+		t.Errorf("Expected enabled_regions to be required, got %v", schema["required"])
+	}
+	if regions := property("enabled_regions"); regions["minItems"] != 1.0 {
+		t.Errorf("Expected enabled_regions minItems 1, got %v", regions["minItems"])
+	}
+	if required := property("aws")["required"]; !reflect.DeepEqual(required, []interface{}{"access_key_id", "default_region", "secret_access_key"}) {
+		t.Errorf("Expected required AWS credentials, got %v", required)
+	}
+	if endpoint := property("otel", "collector_endpoint"); endpoint["format"] != "uri" {
+		t.Errorf("Expected collector_endpoint format uri, got %v", endpoint["format"])
+	}
+	if property("global")["additionalProperties"] != false {
+		t.Errorf("Expected unknown global keys to be rejected")
+	}
+
+	// Optional settings also accept their zero value, which selects the default
+	retries := property("aws", "max_retries")
+	expected := []interface{}{
+		map[string]interface{}{"const": 0.0},
+		map[string]interface{}{"minimum": 1.0, "maximum": 10.0},
+	}
+	if !reflect.DeepEqual(retries["anyOf"], expected) {
+		t.Errorf("Expected max_retries %v, got %v", expected, retries["anyOf"])
+	}
+
+	pattern := regexp.MustCompile(property("aws", "timeout")["pattern"].(string))
+	for _, duration := range []string{"30s", "1h30m", "0", "1.5m"} {
+		if !pattern.MatchString(duration) {
+			t.Errorf("Expected duration pattern to match %s", duration)
+		}
+	}
+	if pattern.MatchString("30") {
+		t.Errorf("Expected duration pattern not to match a bare number")
+	}
+
+	// Every key of the example configuration is in the schema
+	example, err := os.ReadFile("../../configs/config.example.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read example config: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(example, &doc); err != nil {
+		t.Fatalf("Failed to parse example config: %v", err)
+	}
+	var walk func(path string, value interface{}, node map[string]interface{})
+	walk = func(path string, value interface{}, node map[string]interface{}) {
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		properties, _ := node["properties"].(map[string]interface{})
+		for key, child := range values {
+			next, ok := properties[key].(map[string]interface{})
+			if !ok {
+				t.Errorf("Example key %s%s is not in the schema", path, key)
+				continue
 			}
-		})
+			walk(path+key+".", child, next)
+		}
 	}
+	walk("", doc, schema)
 }
 
-func TestDefaults(t *testing.T) {
+func TestLoadDeprecatedKeys(t *testing.T) {
 	configYAML := `
 enabled_regions:
   - us-east-1
@@ -188,194 +1830,414 @@ aws:
   secret_access_key: "test-secret"
   default_region: us-east-1
 otel:
-  collector_endpoint: "http://localhost:4317"
+  endpoint: "http://localhost:4317"
   service_name: "aws-monitor"
 metrics:
   ec2:
     enabled: true
+    interval: 2m
+plugins:
+  - name: custom
+    type: custom
+    interval: 30s
+global:
+  default_interval: 1m
+  default_collection_interval: 10m
 `
-
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yaml")
-	err := os.WriteFile(configPath, []byte(configYAML), 0600)
-	if err != nil {
-		t.Fatalf("Failed to create test config file: %v", err)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
 	}
 
 	config, err := Load(configPath)
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
-
-	// Test AWS defaults
-	if config.AWS.MaxRetries != 3 {
-		t.Errorf("Expected AWS.MaxRetries to be 3, got %d", config.AWS.MaxRetries)
+	if config.OTEL.CollectorEndpoint != "http://localhost:4317" {
+		t.Errorf("Expected endpoint moved to collector_endpoint, got %q", config.OTEL.CollectorEndpoint)
 	}
-	if time.Duration(config.AWS.Timeout) != 30*time.Second {
-		t.Errorf("Expected AWS.Timeout to be 30s, got %s", config.AWS.Timeout)
+	if time.Duration(config.Metrics.EC2.CollectionInterval) != 2*time.Minute {
+		t.Errorf("Expected EC2 interval 2m, got %s", config.Metrics.EC2.CollectionInterval)
 	}
-
-	// Test OTEL defaults
-	if config.OTEL.BatchSize != 512 {
-		t.Errorf("Expected OTEL.BatchSize to be 512, got %d", config.OTEL.BatchSize)
+	if time.Duration(config.Plugins[0].CollectionInterval) != 30*time.Second {
+		t.Errorf("Expected plugin interval 30s, got %s", config.Plugins[0].CollectionInterval)
 	}
-	if time.Duration(config.OTEL.BatchTimeout) != 5*time.Second {
-		t.Errorf("Expected OTEL.BatchTimeout to be 5s, got %s", config.OTEL.BatchTimeout)
+	// The replacement wins when both keys are set
+	if time.Duration(config.Global.DefaultInterval) != 10*time.Minute {
+		t.Errorf("Expected default interval 10m, got %s", config.Global.DefaultInterval)
 	}
 
-	// Test Global defaults
-	if config.Global.LogLevel != "info" {
-		t.Errorf("Expected Global.LogLevel to be 'info', got %s", config.Global.LogLevel)
+	var keys []string
+	for _, deprecation := range config.Deprecations() {
+		if deprecation.File != configPath {
+			t.Errorf("Expected deprecation in %s, got %s", configPath, deprecation.File)
+		}
+		keys = append(keys, deprecation.Key+"->"+deprecation.Replacement)
 	}
-	if config.Global.LogFormat != "json" {
-		t.Errorf("Expected Global.LogFormat to be 'json', got %s", config.Global.LogFormat)
+	expected := []string{
+		"otel.endpoint->otel.collector_endpoint",
+		"metrics.ec2.interval->metrics.ec2.collection_interval",
+		"plugins.0.interval->plugins.0.collection_interval",
+		"global.default_interval->global.default_collection_interval",
 	}
-	if config.Global.HealthCheckPort != 8080 {
-		t.Errorf("Expected Global.HealthCheckPort to be 8080, got %d", config.Global.HealthCheckPort)
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected deprecations %v, got %v", expected, keys)
 	}
-	if config.Global.MaxConcurrentWorkers != 10 {
-		t.Errorf("Expected Global.MaxConcurrentWorkers to be 10, got %d", config.Global.MaxConcurrentWorkers)
+}
+
+func TestMigrateDocumentRemovedKey(t *testing.T) {
+	doc := map[string]interface{}{
+		"global": map[string]interface{}{"legacy": true, "log_level": "info"},
 	}
+	deprecations := migrateDocument("config.yaml", doc, []keyMigration{{From: "global.legacy"}})
 
-	// Test collector defaults
-	if time.Duration(config.Metrics.EC2.CollectionInterval) != 300*time.Second {
-		t.Errorf("Expected EC2.CollectionInterval to be 300s, got %s", config.Metrics.EC2.CollectionInterval)
+	if _, exists := lookupKey(doc, []string{"global", "legacy"}); exists {
+		t.Errorf("Expected removed key to be dropped")
 	}
-	if time.Duration(config.Metrics.S3.CollectionInterval) != 600*time.Second {
-		t.Errorf("Expected S3.CollectionInterval to be 600s, got %s", config.Metrics.S3.CollectionInterval)
+	if len(deprecations) != 1 || deprecations[0].String() != "config.yaml: global.legacy is no longer used and was ignored" {
+		t.Errorf("Expected one removed-key deprecation, got %v", deprecations)
 	}
 }
 
-func TestGetCollectorConfig(t *testing.T) {
-	config := &Config{
-		Metrics: MetricsConfig{
-			EC2: CollectorConfig{
-				Enabled:            true,
-				CollectionInterval: Duration(300 * time.Second),
-			},
-			RDS: CollectorConfig{
-				Enabled:            false,
-				CollectionInterval: Duration(600 * time.Second),
-			},
-		},
+func TestValidateRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []string
+		plugin  []string
+		err     string
+	}{
+		{name: "known regions", regions: []string{"us-east-1", "ap-southeast-4", "il-central-1"}},
+		{name: "govcloud", regions: []string{"us-gov-west-1", "us-gov-east-1"}},
+		{name: "typo", regions: []string{"us-est-1"}, err: `invalid AWS region "us-est-1" in enabled_regions; did you mean us-east-1`},
+		{name: "no close match", regions: []string{"moon-base-1"}, err: `invalid AWS region "moon-base-1" in enabled_regions`},
+		{name: "mixed partitions", regions: []string{"us-east-1", "cn-north-1"}, err: "span the aws and aws-cn partitions"},
+		{name: "plugin region", regions: []string{"us-east-1"}, plugin: []string{"eu-wset-1"}, err: "in plugin custom regions; did you mean eu-west-1"},
 	}
 
-	// Test valid collector
-	ec2Config, err := config.GetCollectorConfig("ec2")
-	if err != nil {
-		t.Errorf("Unexpected error getting EC2 config: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				EnabledRegions: tt.regions,
+				Plugins:        []PluginConfig{{Name: "custom", Regions: tt.plugin}},
+			}
+
+			err := validateRegions(config)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
 	}
-	if !ec2Config.Enabled {
-		t.Errorf("Expected EC2 to be enabled")
+}
+
+func TestValidateDisabledMetrics(t *testing.T) {
+	config := &Config{
+		EnabledRegions: []string{"us-east-1"},
+		AWS:            AWSConfig{DefaultRegion: "us-east-1"},
+	}
+	config.Metrics.S3.DisabledMetrics = []string{"s3_object_*"}
+	if err := validateCustomRules(config); err != nil {
+		t.Errorf("Unexpected error: %v", err)
 	}
 
-	// Test invalid collector
-	_, err = config.GetCollectorConfig("invalid")
-	if err == nil {
-		t.Errorf("Expected error for invalid collector")
+	config.Plugins = []PluginConfig{{Name: "custom", DisabledMetrics: []string{"queue_[depth"}}}
+	if err := validateCustomRules(config); err == nil || !strings.Contains(err.Error(), "queue_[depth") {
+		t.Errorf("Expected invalid pattern error, got %v", err)
 	}
 }
 
-func TestSave(t *testing.T) {
+func TestValidateRegionIntervals(t *testing.T) {
 	config := &Config{
 		EnabledRegions: []string{"us-east-1"},
-		AWS: AWSConfig{
-			AccessKeyID:     "test-key",
-			SecretAccessKey: "test-secret",
-			DefaultRegion:   "us-east-1",
-		},
-		OTEL: OTELConfig{
-			CollectorEndpoint: "http://localhost:4317",
-			ServiceName:       "aws-monitor",
-		},
+		AWS:            AWSConfig{DefaultRegion: "us-east-1"},
+	}
+	config.Metrics.EC2.RegionIntervals = map[string]Duration{"us-east-1": Duration(time.Minute)}
+	if err := validateCustomRules(config); err != nil {
+		t.Errorf("Unexpected error: %v", err)
 	}
 
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "saved-config.yaml")
+	config.Plugins = []PluginConfig{{Name: "custom", RegionIntervals: map[string]Duration{"us-east-9": Duration(time.Minute)}}}
+	if err := validateCustomRules(config); err == nil || !strings.Contains(err.Error(), "us-east-9") {
+		t.Errorf("Expected invalid region error, got %v", err)
+	}
 
-	err := config.Save(configPath)
-	if err != nil {
-		t.Fatalf("Failed to save config: %v", err)
+	config.Plugins = []PluginConfig{{Name: "custom", RegionIntervals: map[string]Duration{"us-east-1": 0}}}
+	if err := validateCustomRules(config); err == nil || !strings.Contains(err.Error(), "must be positive") {
+		t.Errorf("Expected non-positive interval error, got %v", err)
 	}
+}
 
-	// Verify file exists and has correct permissions
-	info, err := os.Stat(configPath)
+func TestDefaultConfig(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+
+	defaults, err := Default()
 	if err != nil {
-		t.Fatalf("Config file not created: %v", err)
+		t.Fatalf("Failed to load default config: %v", err)
 	}
 
-	mode := info.Mode()
-	if mode != 0600 {
-		t.Errorf("Expected file permissions 0600, got %o", mode)
+	// The embedded file lists every default, so setDefaults changes nothing
+	var listed Config
+	if err := yaml.Unmarshal(expandEnv(DefaultYAML()), &listed); err != nil {
+		t.Fatalf("Failed to parse default config: %v", err)
+	}
+	if !reflect.DeepEqual(&listed, defaults) {
+		t.Errorf("Expected default.yaml to list every default\nlisted:   %+v\ndefaults: %+v", listed, *defaults)
 	}
 
-	// Load the saved config and verify
-	loadedConfig, err := Load(configPath)
+	// Printing the defaults to a file produces a valid configuration that
+	// matches one setting only the required values
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, DefaultYAML(), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	loaded, err := Load(path)
 	if err != nil {
-		t.Fatalf("Failed to load saved config: %v", err)
+		t.Fatalf("Expected the default config to be valid, got %v", err)
+	}
+	if !reflect.DeepEqual(loaded, defaults) {
+		t.Errorf("Expected loaded defaults to match Default()")
 	}
 
-	if loadedConfig.AWS.AccessKeyID != config.AWS.AccessKeyID {
-		t.Errorf("Config not saved correctly")
+	required := filepath.Join(t.TempDir(), "config.yaml")
+	requiredYAML := `
+enabled_regions: [us-east-1]
+aws:
+  access_key_id: "${AWS_ACCESS_KEY_ID}"
+  secret_access_key: "${AWS_SECRET_ACCESS_KEY}"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+`
+	if err := os.WriteFile(required, []byte(requiredYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	minimal, err := Load(required)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !reflect.DeepEqual(minimal, defaults) {
+		t.Errorf("Expected a config with only required settings to match Default()\nminimal:  %+v\ndefaults: %+v", *minimal, *defaults)
 	}
 }
 
-func TestFindConfigFile(t *testing.T) {
-	// Test when config file doesn't exist
-	_, err := findConfigFile()
-	if err == nil {
-		t.Errorf("Expected error when config file doesn't exist")
+func TestLoadStrict(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	configYAML := `
+enabled_regions: [us-east-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+metrics:
+  ec2:
+    enabled: true
+    collection_intervall: 60s
+plugins:
+  - name: billing
+    type: acme-billing
+    setings:
+      team: finance
+`
+	if err := os.WriteFile(path, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
 	}
 
-	// Create a config file in current directory
-	tmpConfig := "config.yaml"
-	err = os.WriteFile(tmpConfig, []byte("test"), 0600)
+	// Unknown keys are ignored, and reported, by default
+	config, err := Load(path)
 	if err != nil {
-		t.Fatalf("Failed to create test config: %v", err)
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []UnknownKey{
+		{File: path, Key: "metrics.ec2.collection_intervall", Suggestion: "metrics.ec2.collection_interval"},
+		{File: path, Key: "plugins[0].setings", Suggestion: "plugins[0].settings"},
+	}
+	if !reflect.DeepEqual(config.UnknownKeys(), expected) {
+		t.Errorf("Expected unknown keys %v, got %v", expected, config.UnknownKeys())
 	}
-	defer func() { _ = os.Remove(tmpConfig) }()
 
-	path, err := findConfigFile()
+	// Strict mode rejects them
+	_, err = LoadWithOptions(path, LoadOptions{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "did you mean metrics.ec2.collection_interval?") {
+		t.Errorf("Expected unknown key error with a suggestion, got %v", err)
+	}
+
+	// global.strict_config enables strict mode from the file, including for
+	// keys of included files
+	overlay := filepath.Join(dir, "overlay.yaml")
+	if err := os.WriteFile(overlay, []byte("global:\n  strict_config: true\n  log_levle: debug\n"), 0600); err != nil {
+		t.Fatalf("Failed to write overlay: %v", err)
+	}
+	strictYAML := strings.Replace(configYAML, "    collection_intervall: 60s\n", "", 1)
+	strictYAML = strings.Replace(strictYAML, "setings", "settings", 1) + "include: overlay.yaml\n"
+	if err := os.WriteFile(path, []byte(strictYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	_, err = Load(path)
+	if err == nil || !strings.Contains(err.Error(), overlay+": unknown key global.log_levle; did you mean global.log_level?") {
+		t.Errorf("Expected unknown key error for the overlay, got %v", err)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `
+enabled_regions: [us-east-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+global:
+  log_level: info
+`
+	if err := os.WriteFile(path, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// The environment overrides the file, and flags the environment
+	t.Setenv(EnvLogLevel, "warn")
+	t.Setenv(EnvRegions, "eu-west-1, eu-central-1")
+	t.Setenv(EnvHealthPort, "9090")
+	config, err := LoadWithOptions(path, LoadOptions{Overrides: Overrides{
+		LogLevel:     "debug",
+		OTELEndpoint: "http://collector:4317",
+	}})
 	if err != nil {
-		t.Errorf("Unexpected error finding config file: %v", err)
+		t.Fatalf("Failed to load config: %v", err)
 	}
-	if path != "./config.yaml" {
-		t.Errorf("Expected './config.yaml', got %s", path)
+	if config.Global.LogLevel != "debug" {
+		t.Errorf("Expected log level debug from the flag, got %s", config.Global.LogLevel)
+	}
+	if !reflect.DeepEqual(config.EnabledRegions, []string{"eu-west-1", "eu-central-1"}) {
+		t.Errorf("Expected regions from the environment, got %v", config.EnabledRegions)
+	}
+	if config.AWS.DefaultRegion != "eu-west-1" {
+		t.Errorf("Expected the first overridden region to become the default region, got %s", config.AWS.DefaultRegion)
+	}
+	if config.OTEL.CollectorEndpoint != "http://collector:4317" {
+		t.Errorf("Expected OTEL endpoint from the flag, got %s", config.OTEL.CollectorEndpoint)
+	}
+	if config.Global.HealthCheckPort != 9090 {
+		t.Errorf("Expected health check port 9090 from the environment, got %d", config.Global.HealthCheckPort)
+	}
+	expected := []string{"global.log_level", "enabled_regions", "aws.default_region", "otel.collector_endpoint", "global.health_check_port"}
+	if !reflect.DeepEqual(config.OverriddenKeys(), expected) {
+		t.Errorf("Expected overridden keys %v, got %v", expected, config.OverriddenKeys())
+	}
+
+	// Overridden values are validated like the file's
+	_, err = LoadWithOptions(path, LoadOptions{Overrides: Overrides{Regions: []string{"us-east-9"}}})
+	if err == nil || !strings.Contains(err.Error(), "us-east-9") {
+		t.Errorf("Expected an unknown region error, got %v", err)
+	}
+
+	t.Setenv(EnvHealthPort, "http")
+	_, err = Load(path)
+	if err == nil || !strings.Contains(err.Error(), EnvHealthPort) {
+		t.Errorf("Expected an invalid port error naming %s, got %v", EnvHealthPort, err)
 	}
 }
 
-func TestDurationUnmarshal(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected time.Duration
-		hasError bool
-	}{
-		{"30s", 30 * time.Second, false},
-		{"5m", 5 * time.Minute, false},
-		{"1h", 1 * time.Hour, false},
-		{"invalid", 0, true},
+func TestLoadErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		return path
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			var d Duration
-			err := d.UnmarshalYAML(&yaml.Node{Value: tt.input, Kind: yaml.ScalarNode})
+	// A file that cannot be parsed is not a validation error
+	_, err := Load(write("broken.yaml", "enabled_regions: [us-east-1\n"))
+	var validationErr *ValidationError
+	if err == nil || errors.As(err, &validationErr) {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
 
-			if tt.hasError {
-				if err == nil {
-					t.Errorf("Expected error for input %s", tt.input)
-				}
-				return
-			}
+	// Each invalid field is a problem of the validation error
+	_, err = Load(write("invalid.yaml", `
+enabled_regions: [us-east-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  service_name: aws-monitor
+global:
+  log_level: verbose
+`))
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	if len(validationErr.Problems) != 2 {
+		t.Errorf("Expected 2 problems, got %q", validationErr.Problems)
+	}
+	if !strings.HasPrefix(err.Error(), "config validation failed: ") {
+		t.Errorf("Expected the validation error message, got %q", err.Error())
+	}
 
-			if err != nil {
-				t.Errorf("Unexpected error for input %s: %v", tt.input, err)
-				return
-			}
+	// Unknown keys are validation errors in strict mode
+	_, err = LoadWithOptions(write("typo.yaml", `
+enabled_regions: [us-east-1]
+aws:
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+global:
+  log_levle: debug
+`), LoadOptions{Strict: true})
+	if !errors.As(err, &validationErr) || len(validationErr.Problems) != 1 {
+		t.Errorf("Expected a validation error for the unknown key, got %v", err)
+	}
+}
 
-			if time.Duration(d) != tt.expected {
-				t.Errorf("Expected %s, got %s", tt.expected, time.Duration(d))
-			}
-		})
+func TestLoadDefaultedSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `
+enabled_regions: [us-east-1]
+aws:
+  access_key_id: test-key
+  secret_access_key: test-secret
+  default_region: us-east-1
+otel:
+  collector_endpoint: http://localhost:4317
+  service_name: aws-monitor
+global:
+  log_level: debug
+  worker_timeout: 30s
+`
+	if err := os.WriteFile(path, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, defaulted, err := LoadWithDefaults(path, LoadOptions{})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if defaulted["global.health_check_port"] != "8080" {
+		t.Errorf("Expected defaulted health check port 8080, got %q", defaulted["global.health_check_port"])
+	}
+	if defaulted["global.shutdown_timeout"] != "30s" {
+		t.Errorf("Expected defaulted shutdown timeout 30s, got %q", defaulted["global.shutdown_timeout"])
+	}
+	for _, key := range []string{"global.log_level", "global.worker_timeout", "aws.default_region", "aws.secret_access_key"} {
+		if value, ok := defaulted[key]; ok {
+			t.Errorf("Expected %s from the file not to be reported as defaulted, got %q", key, value)
+		}
 	}
 }
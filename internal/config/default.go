@@ -0,0 +1,97 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfig is the canonical default configuration. It must list the
+// value setDefaults assigns to every setting it defaults.
+//
+//go:embed default.yaml
+var defaultConfig []byte
+
+// DefaultYAML returns the default configuration as a commented YAML file,
+// with placeholders for the required settings
+func DefaultYAML() []byte {
+	return append([]byte(nil), defaultConfig...)
+}
+
+// Default returns the default configuration, with ${VAR} placeholders
+// substituted from the environment. It is not validated, since the required
+// credentials may not be set.
+func Default() (*Config, error) {
+	var config Config
+	if err := yaml.Unmarshal(expandEnv(defaultConfig), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse default configuration: %w", err)
+	}
+
+	setDefaults(&config)
+	return &config, nil
+}
+
+// settingValues returns the value of every setting of config set to a
+// non-zero value, by dotted key
+func settingValues(config *Config) map[string]string {
+	values := make(map[string]string)
+	walkSettings("", reflect.ValueOf(config).Elem(), values)
+	return values
+}
+
+// walkSettings adds the non-zero settings of v at path to values
+func walkSettings(path string, v reflect.Value, values map[string]string) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			walkSettings(joinKey(path, name), v.Field(i), values)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			walkSettings(joinKey(path, fmt.Sprint(iter.Key().Interface())), iter.Value(), values)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkSettings(fmt.Sprintf("%s[%d]", path, i), v.Index(i), values)
+		}
+	default:
+		if !v.IsZero() {
+			values[path] = fmt.Sprint(v.Interface())
+		}
+	}
+}
+
+// defaultedSettings returns the settings whose value after setDefaults
+// differs from the value loaded from the file
+func defaultedSettings(loaded, effective map[string]string) map[string]string {
+	defaulted := make(map[string]string)
+	for key, value := range effective {
+		if loaded[key] != value {
+			defaulted[key] = value
+		}
+	}
+	return defaulted
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"regexp"
+)
+
+// envPattern matches ${VAR} and ${VAR:-default} references, and $$, which
+// escapes a literal dollar sign
+var envPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces ${VAR} with the value of the environment variable VAR,
+// or the empty string when it is unset. ${VAR:-default} uses default when VAR
+// is unset or empty. Bare $VAR is left alone, since passwords and tokens often
+// contain dollar signs; $$ produces a literal $.
+func expandEnv(data []byte) []byte {
+	return envPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		if string(match) == "$$" {
+			return []byte("$")
+		}
+
+		groups := envPattern.FindSubmatch(match)
+		if value := os.Getenv(string(groups[1])); value != "" {
+			return []byte(value)
+		}
+		return groups[2]
+	})
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// decode parses a configuration file into config. The format is selected by
+// the file's extension: .json and .toml files are converted to YAML first so
+// that every format is decoded through the same yaml struct tags; any other
+// extension is parsed as YAML.
+func decode(configPath string, data []byte, config *Config) error {
+	if !isJSONOrTOML(configPath) {
+		return yaml.Unmarshal(data, config)
+	}
+
+	doc, err := decodeDocument(configPath, data)
+	if err != nil {
+		return err
+	}
+	converted, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to convert configuration: %w", err)
+	}
+	return yaml.Unmarshal(converted, config)
+}
+
+// decodeDocument parses a configuration file of any format into nested maps
+func decodeDocument(configPath string, data []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	switch configExt(configPath) {
+	case ".json":
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %w", err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return doc, nil
+}
+
+func isJSONOrTOML(configPath string) bool {
+	ext := configExt(configPath)
+	return ext == ".json" || ext == ".toml"
+}
+
+// configExt returns the extension that selects the format of a configuration
+// file, ignoring the query of a remote location
+func configExt(configPath string) string {
+	if i := strings.IndexByte(configPath, '?'); i >= 0 && IsRemote(configPath) {
+		configPath = configPath[:i]
+	}
+	return strings.ToLower(filepath.Ext(configPath))
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Defaults used by WriteExample for options that are not set
+const (
+	DefaultExampleRegion   = "us-east-1"
+	DefaultExampleEndpoint = "http://localhost:4317"
+)
+
+//go:embed templates/*.yaml.tmpl
+var exampleTemplates embed.FS
+
+// ExampleOptions selects the contents of a generated configuration file
+type ExampleOptions struct {
+	// Regions are the enabled regions; the first is the default region
+	Regions []string
+	// CollectorEndpoint is the OTLP endpoint metrics are exported to
+	CollectorEndpoint string
+	// Minimal writes only the required settings instead of every setting
+	Minimal bool
+}
+
+// WriteExample writes a commented example configuration to w
+func WriteExample(w io.Writer, opts ExampleOptions) error {
+	if len(opts.Regions) == 0 {
+		opts.Regions = []string{DefaultExampleRegion}
+	}
+	for _, region := range opts.Regions {
+		if err := validateRegion("regions", region); err != nil {
+			return err
+		}
+	}
+	if opts.CollectorEndpoint == "" {
+		opts.CollectorEndpoint = DefaultExampleEndpoint
+	}
+
+	name := "full.yaml.tmpl"
+	if opts.Minimal {
+		name = "minimal.yaml.tmpl"
+	}
+	tmpl, err := template.New(name).
+		Funcs(template.FuncMap{"quote": strconv.Quote}).
+		ParseFS(exampleTemplates, "templates/"+name)
+	if err != nil {
+		return fmt.Errorf("failed to parse example template: %w", err)
+	}
+
+	return tmpl.Execute(w, struct {
+		Regions           []string
+		DefaultRegion     string
+		CollectorEndpoint string
+		Insecure          bool
+	}{
+		Regions:           opts.Regions,
+		DefaultRegion:     opts.Regions[0],
+		CollectorEndpoint: opts.CollectorEndpoint,
+		Insecure:          !strings.HasPrefix(opts.CollectorEndpoint, "https://"),
+	})
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// includeKey lists files, directories or glob patterns that are merged
+	// over the file containing it, relative to that file
+	includeKey = "include"
+	// confDir is the directory next to the configuration file whose files are
+	// merged over it, after its includes
+	confDir = "conf.d"
+)
+
+// configExtensions are the extensions of the files merged from a directory
+var configExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true, ".toml": true}
+
+// SourceFiles returns the files Load reads for the configuration file at
+// configPath in merge order: the file itself, the files it includes and the
+// files in its conf.d directory
+func SourceFiles(configPath string) ([]string, error) {
+	loader := newIncludeLoader()
+	if _, err := loader.loadAll(configPath); err != nil {
+		return nil, err
+	}
+	return loader.files, nil
+}
+
+// decodeFiles decodes the configuration file at configPath, with the files
+// it includes deep-merged over it, into config. Deprecated keys are moved to
+// their replacements in each file before merging, and unknown keys are
+// recorded.
+func decodeFiles(configPath string, config *Config) error {
+	loader := newIncludeLoader()
+	doc, err := loader.loadAll(configPath)
+	if err != nil {
+		return err
+	}
+
+	config.deprecations = loader.deprecations
+	config.unknownKeys = loader.unknown
+	if len(loader.files) == 1 && len(loader.deprecations) == 0 {
+		// Decode a lone file directly, so errors report its line numbers
+		if err := decode(configPath, loader.base, config); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+		}
+		return nil
+	}
+
+	merged, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to merge config files %v: %w", loader.files, err)
+	}
+	if err := yaml.Unmarshal(merged, config); err != nil {
+		return fmt.Errorf("failed to parse config files %v: %w", loader.files, err)
+	}
+	return nil
+}
+
+// includeLoader reads a configuration file and the files it includes
+type includeLoader struct {
+	// files are the files read, in merge order
+	files []string
+	// base is the contents of the first file read, after substitution
+	base []byte
+	// deprecations are the deprecated keys found in the files read
+	deprecations []Deprecation
+	// unknown are the keys found in the files read that no setting uses
+	unknown []UnknownKey
+	seen    map[string]bool
+}
+
+func newIncludeLoader() *includeLoader {
+	return &includeLoader{seen: make(map[string]bool)}
+}
+
+// loadAll loads the configuration file at configPath with its includes and
+// merges the files in its conf.d directory over it. Remote documents have
+// no conf.d directory.
+func (l *includeLoader) loadAll(configPath string) (map[string]interface{}, error) {
+	doc, err := l.load(configPath)
+	if err != nil || IsRemote(configPath) {
+		return doc, err
+	}
+
+	overlays, err := dirConfigFiles(filepath.Join(filepath.Dir(configPath), confDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s directory: %w", confDir, err)
+	}
+	for _, overlay := range overlays {
+		if err := l.merge(doc, overlay); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// load reads, substitutes and parses a configuration file or remote document
+// and merges the files it includes over it. Files already loaded are skipped, which also
+// breaks include cycles.
+func (l *includeLoader) load(path string) (map[string]interface{}, error) {
+	l.seen[absPath(path)] = true
+	l.files = append(l.files, path)
+
+	data, err := ReadSource(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	data = expandEnv(data)
+	if l.base == nil {
+		l.base = data
+	}
+
+	doc, err := decodeDocument(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if IsRemote(path) && doc[includeKey] != nil {
+		return nil, fmt.Errorf("%s is not supported in remote config %s", includeKey, path)
+	}
+	includes, err := includePaths(path, doc[includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in config file %s: %w", includeKey, path, err)
+	}
+	delete(doc, includeKey)
+	l.deprecations = append(l.deprecations, migrateDocument(path, doc, deprecatedKeys)...)
+	l.unknown = append(l.unknown, findUnknownKeys(path, doc)...)
+
+	for _, include := range includes {
+		if err := l.merge(doc, include); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// merge loads the file at path, unless it was already loaded, and merges it
+// over doc
+func (l *includeLoader) merge(doc map[string]interface{}, path string) error {
+	if l.seen[absPath(path)] {
+		return nil
+	}
+
+	overlay, err := l.load(path)
+	if err != nil {
+		return err
+	}
+	mergeDocuments(doc, overlay)
+	return nil
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// includePaths resolves the include entries of the file at path: a file, a
+// directory, whose configuration files are included in name order, or a
+// glob pattern. Relative entries are relative to the including file.
+func includePaths(path string, value interface{}) ([]string, error) {
+	var entries []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		entries = []string{v}
+	case []interface{}:
+		for _, entry := range v {
+			s, ok := entry.(string)
+			if !ok {
+				return nil, fmt.Errorf("entries must be strings, got %v", entry)
+			}
+			entries = append(entries, s)
+		}
+	default:
+		return nil, fmt.Errorf("must be a string or a list of strings")
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(filepath.Dir(path), entry)
+		}
+
+		if strings.ContainsAny(entry, "*?[") {
+			matches, err := filepath.Glob(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %w", entry, err)
+			}
+			sort.Strings(matches)
+			paths = append(paths, matches...)
+			continue
+		}
+
+		info, err := os.Stat(entry)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, entry)
+			continue
+		}
+		files, err := dirConfigFiles(entry)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, files...)
+	}
+	return paths, nil
+}
+
+// dirConfigFiles returns the YAML, JSON and TOML files in dir in name order
+func dirConfigFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && configExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// mergeDocuments deep-merges overlay into base: nested maps are merged key by
+// key, and any other value, including lists, replaces the base value
+func mergeDocuments(base, overlay map[string]interface{}) {
+	for key, value := range overlay {
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		overlayMap, overlayIsMap := value.(map[string]interface{})
+		if baseIsMap && overlayIsMap {
+			mergeDocuments(baseMap, overlayMap)
+			continue
+		}
+		base[key] = value
+	}
+}
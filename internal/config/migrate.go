@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyMigration maps a deprecated configuration key to the key replacing it.
+// Keys are dotted paths from the document root, where * matches every list
+// element or map key; the values matched by wildcards in From fill the
+// wildcards in To in order. An empty To marks a key that is no longer used.
+type keyMigration struct {
+	From string
+	To   string
+}
+
+// deprecatedKeys are the keys still accepted for configuration files written
+// for earlier versions
+var deprecatedKeys = []keyMigration{
+	{From: "otel.endpoint", To: "otel.collector_endpoint"},
+	{From: "metrics.*.interval", To: "metrics.*.collection_interval"},
+	{From: "plugins.*.interval", To: "plugins.*.collection_interval"},
+	{From: "global.default_interval", To: "global.default_collection_interval"},
+}
+
+// Deprecation reports a deprecated key found in a configuration file
+type Deprecation struct {
+	// File is the configuration file containing the key
+	File string `json:"file"`
+	// Key is the deprecated key
+	Key string `json:"key"`
+	// Replacement is the key its value was moved to; empty when the key is
+	// no longer used and was ignored
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// String describes the deprecation and how to resolve it
+func (d Deprecation) String() string {
+	if d.Replacement == "" {
+		return fmt.Sprintf("%s: %s is no longer used and was ignored", d.File, d.Key)
+	}
+	return fmt.Sprintf("%s: %s is deprecated, use %s instead", d.File, d.Key, d.Replacement)
+}
+
+// migrateDocument moves the values of deprecated keys in doc to their
+// replacements. When both keys are set, the replacement wins.
+func migrateDocument(file string, doc map[string]interface{}, migrations []keyMigration) []Deprecation {
+	var deprecations []Deprecation
+	for _, migration := range migrations {
+		for _, match := range matchKeys(doc, strings.Split(migration.From, "."), nil, nil) {
+			value, _ := removeKey(doc, match.path)
+			deprecation := Deprecation{File: file, Key: strings.Join(match.path, ".")}
+
+			if migration.To != "" {
+				target := fillWildcards(strings.Split(migration.To, "."), match.wildcards)
+				deprecation.Replacement = strings.Join(target, ".")
+				if _, exists := lookupKey(doc, target); !exists {
+					setKey(doc, target, value)
+				}
+			}
+			deprecations = append(deprecations, deprecation)
+		}
+	}
+	return deprecations
+}
+
+// keyMatch is a key matching a migration pattern
+type keyMatch struct {
+	path      []string
+	wildcards []string
+}
+
+// matchKeys returns the keys below node matching pattern, sorted by path
+func matchKeys(node interface{}, pattern, path, wildcards []string) []keyMatch {
+	if len(pattern) == 0 {
+		return []keyMatch{{path: path, wildcards: wildcards}}
+	}
+
+	var matches []keyMatch
+	for _, key := range childKeys(node) {
+		if pattern[0] != "*" && pattern[0] != key {
+			continue
+		}
+
+		child, _ := lookupKey(node, []string{key})
+		childWildcards := wildcards
+		if pattern[0] == "*" {
+			childWildcards = append(append([]string(nil), wildcards...), key)
+		}
+		matches = append(matches, matchKeys(child, pattern[1:], append(append([]string(nil), path...), key), childWildcards)...)
+	}
+	return matches
+}
+
+// childKeys returns the sorted keys of a map or the indexes of a list
+func childKeys(node interface{}) []string {
+	var keys []string
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	case []interface{}:
+		for i := range n {
+			keys = append(keys, strconv.Itoa(i))
+		}
+	}
+	return keys
+}
+
+func fillWildcards(pattern, wildcards []string) []string {
+	path := make([]string, len(pattern))
+	for i, key := range pattern {
+		if key == "*" && len(wildcards) > 0 {
+			key, wildcards = wildcards[0], wildcards[1:]
+		}
+		path[i] = key
+	}
+	return path
+}
+
+// lookupKey returns the value at path below node
+func lookupKey(node interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, ok := n[key]
+			if !ok {
+				return nil, false
+			}
+			node = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, false
+			}
+			node = n[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// removeKey deletes the map entry at path below doc and returns its value
+func removeKey(doc map[string]interface{}, path []string) (interface{}, bool) {
+	parent, ok := lookupKey(doc, path[:len(path)-1])
+	if !ok {
+		return nil, false
+	}
+	m, ok := parent.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := m[path[len(path)-1]]
+	delete(m, path[len(path)-1])
+	return value, ok
+}
+
+// setKey sets the value at path below doc, creating missing maps. Values in
+// the way that are not maps are left alone, since decoding reports them.
+func setKey(doc map[string]interface{}, path []string, value interface{}) {
+	var node interface{} = doc
+	for i, key := range path {
+		last := i == len(path)-1
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if last {
+				n[key] = value
+				return
+			}
+			if _, ok := n[key]; !ok {
+				n[key] = make(map[string]interface{})
+			}
+			node = n[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(n) {
+				return
+			}
+			if last {
+				n[index] = value
+				return
+			}
+			node = n[index]
+		default:
+			return
+		}
+	}
+}
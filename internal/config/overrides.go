@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Environment variables overriding settings of the configuration file
+const (
+	EnvLogLevel     = "AWS_MONITOR_LOG_LEVEL"
+	EnvRegions      = "AWS_MONITOR_REGIONS"
+	EnvOTELEndpoint = "AWS_MONITOR_OTEL_ENDPOINT"
+	EnvHealthPort   = "AWS_MONITOR_HEALTH_PORT"
+)
+
+// Overrides replace settings of the configuration file at load time, for
+// container deployments that template only a few values. Unset fields leave
+// the file's settings alone.
+type Overrides struct {
+	// LogLevel replaces global.log_level
+	LogLevel string
+	// Regions replaces enabled_regions. When aws.default_region is not
+	// among them, the first becomes the default region.
+	Regions []string
+	// OTELEndpoint replaces otel.collector_endpoint
+	OTELEndpoint string
+	// HealthPort replaces global.health_check_port
+	HealthPort int
+}
+
+// EnvOverrides reads the overrides set by the AWS_MONITOR_* environment
+// variables, using getenv to look them up
+func EnvOverrides(getenv func(string) string) (Overrides, error) {
+	overrides := Overrides{
+		LogLevel:     getenv(EnvLogLevel),
+		Regions:      SplitList(getenv(EnvRegions)),
+		OTELEndpoint: getenv(EnvOTELEndpoint),
+	}
+	if value := getenv(EnvHealthPort); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return Overrides{}, fmt.Errorf("%s: invalid port %q", EnvHealthPort, value)
+		}
+		overrides.HealthPort = port
+	}
+	return overrides, nil
+}
+
+// Merge returns o with the fields set in other replacing its own
+func (o Overrides) Merge(other Overrides) Overrides {
+	if other.LogLevel != "" {
+		o.LogLevel = other.LogLevel
+	}
+	if len(other.Regions) > 0 {
+		o.Regions = other.Regions
+	}
+	if other.OTELEndpoint != "" {
+		o.OTELEndpoint = other.OTELEndpoint
+	}
+	if other.HealthPort != 0 {
+		o.HealthPort = other.HealthPort
+	}
+	return o
+}
+
+// apply replaces the overridden settings of config, recording their keys
+func (o Overrides) apply(config *Config) {
+	if o.LogLevel != "" {
+		config.Global.LogLevel = o.LogLevel
+		config.overridden = append(config.overridden, "global.log_level")
+	}
+	if len(o.Regions) > 0 {
+		config.EnabledRegions = o.Regions
+		config.overridden = append(config.overridden, "enabled_regions")
+		if !containsString(o.Regions, config.AWS.DefaultRegion) {
+			config.AWS.DefaultRegion = o.Regions[0]
+			config.overridden = append(config.overridden, "aws.default_region")
+		}
+	}
+	if o.OTELEndpoint != "" {
+		config.OTEL.CollectorEndpoint = o.OTELEndpoint
+		config.overridden = append(config.overridden, "otel.collector_endpoint")
+	}
+	if o.HealthPort != 0 {
+		config.Global.HealthCheckPort = o.HealthPort
+		config.overridden = append(config.overridden, "global.health_check_port")
+	}
+}
+
+// SplitList splits a comma-separated list, dropping empty items and the
+// spaces around items
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces the values of sensitive settings
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeys are the configuration keys whose values are never shown, with
+// everything nested under them
+var sensitiveKeys = []string{
+	"access_key_id",
+	"secret_access_key",
+	"token",
+	"password",
+	"dsn",
+	"routing_key",
+	"webhook_url",
+	"headers",
+}
+
+// SensitiveKeys returns the lowercased keys whose values are masked: secrets,
+// credentials and the keys listed in global.log_redact_fields
+func (c *Config) SensitiveKeys() map[string]bool {
+	sensitive := make(map[string]bool, len(sensitiveKeys)+len(c.Global.LogRedactFields))
+	for _, key := range sensitiveKeys {
+		sensitive[key] = true
+	}
+	for _, key := range c.Global.LogRedactFields {
+		sensitive[strings.ToLower(key)] = true
+	}
+	return sensitive
+}
+
+// Document converts the configuration to the nested maps and lists of its
+// YAML form
+func (c *Config) Document() (map[string]interface{}, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %w", err)
+	}
+	return doc, nil
+}
+
+// Redacted returns the document of the configuration with the values of
+// sensitive settings masked
+func (c *Config) Redacted() (map[string]interface{}, error) {
+	doc, err := c.Document()
+	if err != nil {
+		return nil, err
+	}
+	return RedactValue(doc, false, c.SensitiveKeys()).(map[string]interface{}), nil
+}
+
+// RedactValue returns value, or a copy with the settings under sensitive keys
+// masked. The whole value is masked if redact is set.
+func RedactValue(value interface{}, redact bool, sensitive map[string]bool) interface{} {
+	if redact {
+		return RedactedValue
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			masked[key] = RedactValue(item, sensitive[strings.ToLower(key)], sensitive)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = RedactValue(item, false, sensitive)
+		}
+		return masked
+	default:
+		return value
+	}
+}
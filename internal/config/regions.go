@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Partitions are isolated groups of AWS regions with separate credentials
+const (
+	PartitionAWS      = "aws"
+	PartitionChina    = "aws-cn"
+	PartitionGovCloud = "aws-us-gov"
+	PartitionISO      = "aws-iso"
+	PartitionISOB     = "aws-iso-b"
+	PartitionISOE     = "aws-iso-e"
+	PartitionISOF     = "aws-iso-f"
+)
+
+// awsRegions maps every AWS region to its partition. Regions launched after
+// 2019 are opt-in: they must be enabled for the account before use.
+var awsRegions = map[string]string{
+	"us-east-1":      PartitionAWS,
+	"us-east-2":      PartitionAWS,
+	"us-west-1":      PartitionAWS,
+	"us-west-2":      PartitionAWS,
+	"af-south-1":     PartitionAWS, // opt-in
+	"ap-east-1":      PartitionAWS, // opt-in
+	"ap-east-2":      PartitionAWS, // opt-in
+	"ap-south-1":     PartitionAWS,
+	"ap-south-2":     PartitionAWS, // opt-in
+	"ap-northeast-1": PartitionAWS,
+	"ap-northeast-2": PartitionAWS,
+	"ap-northeast-3": PartitionAWS,
+	"ap-southeast-1": PartitionAWS,
+	"ap-southeast-2": PartitionAWS,
+	"ap-southeast-3": PartitionAWS, // opt-in
+	"ap-southeast-4": PartitionAWS, // opt-in
+	"ap-southeast-5": PartitionAWS, // opt-in
+	"ap-southeast-7": PartitionAWS, // opt-in
+	"ca-central-1":   PartitionAWS,
+	"ca-west-1":      PartitionAWS, // opt-in
+	"eu-central-1":   PartitionAWS,
+	"eu-central-2":   PartitionAWS, // opt-in
+	"eu-north-1":     PartitionAWS,
+	"eu-south-1":     PartitionAWS, // opt-in
+	"eu-south-2":     PartitionAWS, // opt-in
+	"eu-west-1":      PartitionAWS,
+	"eu-west-2":      PartitionAWS,
+	"eu-west-3":      PartitionAWS,
+	"il-central-1":   PartitionAWS, // opt-in
+	"me-central-1":   PartitionAWS, // opt-in
+	"me-south-1":     PartitionAWS, // opt-in
+	"mx-central-1":   PartitionAWS, // opt-in
+	"sa-east-1":      PartitionAWS,
+
+	"cn-north-1":     PartitionChina,
+	"cn-northwest-1": PartitionChina,
+
+	"us-gov-east-1": PartitionGovCloud,
+	"us-gov-west-1": PartitionGovCloud,
+
+	"us-iso-east-1":   PartitionISO,
+	"us-iso-west-1":   PartitionISO,
+	"us-isob-east-1":  PartitionISOB,
+	"eu-isoe-west-1":  PartitionISOE,
+	"us-isof-east-1":  PartitionISOF,
+	"us-isof-south-1": PartitionISOF,
+}
+
+// RegionPartition returns the partition of an AWS region and whether the
+// region is known
+func RegionPartition(region string) (string, bool) {
+	partition, ok := awsRegions[region]
+	return partition, ok
+}
+
+// validateRegion returns an error naming an unknown region, suggesting the
+// known regions with the closest names
+func validateRegion(setting, region string) error {
+	if _, ok := awsRegions[region]; ok {
+		return nil
+	}
+
+	err := fmt.Sprintf("invalid AWS region %q in %s", region, setting)
+	if suggestions := closestRegions(region); len(suggestions) > 0 {
+		err += fmt.Sprintf("; did you mean %s?", strings.Join(suggestions, " or "))
+	}
+	return fmt.Errorf("%s", err)
+}
+
+// closestRegions returns up to three known regions within an edit distance
+// of three of region, closest first
+func closestRegions(region string) []string {
+	const maxDistance, maxSuggestions = 3, 3
+
+	type candidate struct {
+		region   string
+		distance int
+	}
+	var candidates []candidate
+	lower := strings.ToLower(strings.TrimSpace(region))
+	for known := range awsRegions {
+		if d := editDistance(lower, known); d <= maxDistance {
+			candidates = append(candidates, candidate{region: known, distance: d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].region < candidates[j].region
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].region)
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// validateRegions checks every region setting names a known region and that
+// the enabled regions share one partition, since a single set of credentials
+// cannot access more than one
+func validateRegions(config *Config) error {
+	partitions := make(map[string]bool)
+	for _, region := range config.EnabledRegions {
+		if err := validateRegion("enabled_regions", region); err != nil {
+			return err
+		}
+		partitions[awsRegions[region]] = true
+	}
+	if len(partitions) > 1 {
+		var names []string
+		for partition := range partitions {
+			names = append(names, partition)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("enabled_regions span the %s partitions; regions of different partitions need separate configurations",
+			strings.Join(names, " and "))
+	}
+
+	for _, plugin := range config.Plugins {
+		for _, region := range plugin.Regions {
+			if err := validateRegion(fmt.Sprintf("plugin %s regions", plugin.Name), region); err != nil {
+				return err
+			}
+		}
+	}
+	if config.Alerting.SNS.TopicARN != "" {
+		if err := validateRegion("alerting.sns.region", config.Alerting.SNS.Region); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+const (
+	// remoteTimeout bounds fetching a remote configuration document
+	remoteTimeout = 30 * time.Second
+	// maxRemoteSize is the largest remote configuration document accepted
+	maxRemoteSize = 10 << 20
+)
+
+// IsRemote reports whether configPath refers to an S3 object
+// (s3://bucket/key) or an SSM parameter (ssm://name) rather than a local file
+func IsRemote(configPath string) bool {
+	return strings.HasPrefix(configPath, "s3://") || strings.HasPrefix(configPath, "ssm://")
+}
+
+// ReadSource returns the contents of a local configuration file or of a
+// remote configuration document
+func ReadSource(configPath string) ([]byte, error) {
+	if !IsRemote(configPath) {
+		return os.ReadFile(configPath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	return remote.fetch(ctx, configPath)
+}
+
+// remote fetches remote configuration documents for ReadSource
+var remote = &remoteFetcher{loadAWSConfig: loadDefaultAWSConfig}
+
+// remoteFetcher fetches configuration documents from S3 and SSM Parameter
+// Store. Requests use the default AWS credential chain and endpoint settings,
+// such as AWS_ENDPOINT_URL, since the credentials and aws.endpoint_url in the
+// configuration are not known until it has been fetched.
+type remoteFetcher struct {
+	// loadAWSConfig loads the AWS configuration for a region; an empty
+	// region uses the default region
+	loadAWSConfig func(ctx context.Context, region string) (aws.Config, error)
+}
+
+func loadDefaultAWSConfig(ctx context.Context, region string) (aws.Config, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	return awsconfig.LoadDefaultConfig(ctx, opts...)
+}
+
+// fetch returns the document at an s3:// or ssm:// location. A region query
+// parameter, e.g. s3://bucket/config.yaml?region=eu-west-1, overrides the
+// default region.
+func (f *remoteFetcher) fetch(ctx context.Context, location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote config location %s: %w", location, err)
+	}
+
+	awsCfg, err := f.loadAWSConfig(ctx, u.Query().Get("region"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region for %s: set AWS_REGION or add ?region= to the location", location)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		// Custom endpoints, such as emulators, serve buckets by path rather
+		// than by subdomain
+		client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+			o.UsePathStyle = awsCfg.BaseEndpoint != nil
+		})
+		return fetchS3(ctx, client, u)
+	default:
+		return fetchSSM(ctx, ssm.NewFromConfig(awsCfg), location)
+	}
+}
+
+// fetchS3 downloads an S3 object
+func fetchS3(ctx context.Context, client *s3.Client, u *url.URL) ([]byte, error) {
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 location %s: expected s3://bucket/key", u.Redacted())
+	}
+
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", bucket, key, err)
+	}
+	defer output.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(output.Body, maxRemoteSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %w", bucket, key, err)
+	}
+	if len(body) > maxRemoteSize {
+		return nil, fmt.Errorf("s3://%s/%s exceeds %d bytes", bucket, key, maxRemoteSize)
+	}
+	return body, nil
+}
+
+// fetchSSM reads an SSM parameter, decrypting SecureString parameters.
+// Hierarchical names keep their leading slash: ssm:///aws-monitor/config.
+func fetchSSM(ctx context.Context, client *ssm.Client, location string) ([]byte, error) {
+	name := strings.TrimPrefix(location, "ssm://")
+	if i := strings.IndexByte(name, '?'); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		return nil, fmt.Errorf("invalid SSM location %s: expected ssm://parameter-name", location)
+	}
+
+	output, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SSM parameter %s: %w", name, err)
+	}
+	if output.Parameter == nil {
+		return nil, fmt.Errorf("SSM parameter %s has no value", name)
+	}
+	return []byte(aws.ToString(output.Parameter.Value)), nil
+}
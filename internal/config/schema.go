@@ -0,0 +1,202 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// durationPattern matches the durations accepted by time.ParseDuration
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// JSONSchema returns a JSON Schema (draft 2020-12) for configuration files,
+// derived from the yaml and validate tags of Config. Unknown keys are
+// rejected, so that misspelled settings are caught. Checks that span fields,
+// such as the default region being enabled, are only done by Load.
+func JSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "AWS Monitor configuration"
+
+	properties := schema["properties"].(map[string]interface{})
+	properties[includeKey] = map[string]interface{}{
+		"description": "Files, directories or glob patterns merged over this file",
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema returns the schema of a configuration value of type t
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == durationType {
+		return map[string]interface{}{"type": "string", "pattern": durationPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the schema of a struct, with a property per field
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		schema := typeSchema(field.Type)
+		if applyValidateTag(schema, field.Type, field.Tag.Get("validate")) {
+			required = append(required, name)
+		}
+		properties[name] = schema
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applyValidateTag adds the constraints of a validate tag to the schema of a
+// value of type t and reports whether the value is required. Load replaces
+// zero values of optional settings with defaults, so the constraints of
+// optional values also accept the zero value.
+func applyValidateTag(schema map[string]interface{}, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		// Rules after dive apply to the elements of a slice or map
+		if rule == "dive" {
+			elem := schema["items"]
+			if t.Kind() == reflect.Map {
+				elem = schema["additionalProperties"]
+			}
+			applyValidateTag(elem.(map[string]interface{}), t.Elem(), strings.Join(rules[i+1:], ","))
+			rules = rules[:i]
+			break
+		}
+	}
+
+	required := false
+	constraints := make(map[string]interface{})
+	for _, rule := range rules {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			// The validator does not check required on structs
+			required = t.Kind() != reflect.Struct
+		case "min", "max":
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			constraints[boundKeyword(t, key)] = bound
+		case "gt":
+			if bound, err := strconv.ParseFloat(value, 64); err == nil {
+				constraints["exclusiveMinimum"] = bound
+			}
+		case "lt":
+			if bound, err := strconv.ParseFloat(value, 64); err == nil {
+				constraints["exclusiveMaximum"] = bound
+			}
+		case "oneof":
+			constraints["enum"] = strings.Fields(value)
+		case "url":
+			constraints["format"] = "uri"
+		}
+	}
+
+	if required || acceptsZero(constraints) {
+		for key, value := range constraints {
+			schema[key] = value
+		}
+	} else if len(constraints) > 0 {
+		schema["anyOf"] = []interface{}{
+			map[string]interface{}{"const": reflect.Zero(t).Interface()},
+			constraints,
+		}
+	}
+	return required
+}
+
+// boundKeyword returns the keyword of a min or max rule, which bounds the
+// length of strings, slices and maps and the value of numbers
+func boundKeyword(t reflect.Type, rule string) string {
+	switch t.Kind() {
+	case reflect.String:
+		return rule + "Length"
+	case reflect.Slice:
+		return rule + "Items"
+	case reflect.Map:
+		return rule + "Properties"
+	default:
+		return rule + "imum"
+	}
+}
+
+// acceptsZero reports whether the zero value of a number, string or list
+// satisfies constraints
+func acceptsZero(constraints map[string]interface{}) bool {
+	for key, value := range constraints {
+		switch key {
+		case "minimum", "maximum":
+			bound := value.(float64)
+			if key == "minimum" && bound > 0 || key == "maximum" && bound < 0 {
+				return false
+			}
+		case "exclusiveMinimum", "exclusiveMaximum":
+			bound := value.(float64)
+			if key == "exclusiveMinimum" && bound >= 0 || key == "exclusiveMaximum" && bound <= 0 {
+				return false
+			}
+		case "minLength", "minItems", "minProperties":
+			if value.(float64) > 0 {
+				return false
+			}
+		case "maxLength", "maxItems", "maxProperties":
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// LoadOptions changes how a configuration file is loaded
+type LoadOptions struct {
+	// Strict rejects unknown keys, as global.strict_config does
+	Strict bool
+	// Overrides replace settings of the file, and those set by the
+	// AWS_MONITOR_* environment variables
+	Overrides Overrides
+}
+
+// UnknownKey is a key in a configuration file that no setting uses, usually a
+// typo
+type UnknownKey struct {
+	// File is the configuration file containing the key
+	File string `json:"file"`
+	// Key is the dotted path of the key, e.g. metrics.ec2.collection_intervall
+	Key string `json:"key"`
+	// Suggestion is the known key with the closest name, if any is close
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// String describes the unknown key, suggesting the closest known key
+func (k UnknownKey) String() string {
+	if k.Suggestion == "" {
+		return fmt.Sprintf("%s: unknown key %s", k.File, k.Key)
+	}
+	return fmt.Sprintf("%s: unknown key %s; did you mean %s?", k.File, k.Key, k.Suggestion)
+}
+
+// unknownKeysError reports the unknown keys rejected in strict mode
+func unknownKeysError(keys []UnknownKey) error {
+	descriptions := make([]string, len(keys))
+	for i, key := range keys {
+		descriptions[i] = key.String()
+	}
+	return &ValidationError{
+		Problems: descriptions,
+		err:      fmt.Errorf("unknown configuration keys (strict mode):\n  %s", strings.Join(descriptions, "\n  ")),
+	}
+}
+
+// findUnknownKeys returns the keys of a configuration document that are not
+// settings of Config, sorted
+func findUnknownKeys(file string, doc map[string]interface{}) []UnknownKey {
+	var unknown []UnknownKey
+	walkUnknownKeys(file, "", doc, reflect.TypeOf(Config{}), &unknown)
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Key < unknown[j].Key })
+	return unknown
+}
+
+// walkUnknownKeys appends the keys of value at path that are not fields of t
+func walkUnknownKeys(file, path string, value interface{}, t reflect.Type, unknown *[]UnknownKey) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		known := yamlFields(t)
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field, ok := known[key]
+			if !ok {
+				*unknown = append(*unknown, UnknownKey{
+					File:       file,
+					Key:        joinKey(path, key),
+					Suggestion: closestKey(path, key, known),
+				})
+				continue
+			}
+			walkUnknownKeys(file, joinKey(path, key), fields[key], field, unknown)
+		}
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, entry := range entries {
+			walkUnknownKeys(file, joinKey(path, key), entry, t.Elem(), unknown)
+		}
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			walkUnknownKeys(file, fmt.Sprintf("%s[%d]", path, i), item, t.Elem(), unknown)
+		}
+	}
+}
+
+// yamlFields returns the types of the fields of a struct by their YAML key
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// closestKey returns the known key at path closest to key, or "" if none is
+// within an edit distance of three
+func closestKey(path, key string, known map[string]reflect.Type) string {
+	const maxDistance = 3
+
+	best, bestDistance := "", maxDistance+1
+	for name := range known {
+		distance := editDistance(key, name)
+		if distance < bestDistance || (distance == bestDistance && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return joinKey(path, best)
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
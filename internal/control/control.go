@@ -0,0 +1,232 @@
+// Package control operates aws-monitor through commands sent to an SQS queue,
+// such as running a collector now, pausing a region or reloading the
+// configuration, without network access to the admin API of each instance.
+//
+// An SQS message is received by one consumer only and is deleted once
+// handled, including commands for other hosts, so each monitor needs a queue
+// of its own. Publishing commands to an SNS topic every queue of a fleet is
+// subscribed to operates the whole fleet.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/logger"
+)
+
+// Commands understood by a Channel
+const (
+	// CommandCollect runs a collector now, in Region or, without one, in
+	// every region it is scheduled in
+	CommandCollect = "collect"
+	// CommandPauseRegion stops running the collectors of Region
+	CommandPauseRegion = "pause_region"
+	// CommandResumeRegion runs the collectors of a paused Region again
+	CommandResumeRegion = "resume_region"
+	// CommandReload reloads the configuration file
+	CommandReload = "reload"
+)
+
+// Command is a control message
+type Command struct {
+	Command   string `json:"command"`
+	Collector string `json:"collector,omitempty"`
+	Region    string `json:"region,omitempty"`
+	// Hosts restricts the command to the monitors running on these hosts;
+	// without hosts every monitor runs it
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// Scheduler runs and pauses the collector jobs
+type Scheduler interface {
+	TriggerCollector(ctx context.Context, collectorName, region string) error
+	PauseRegion(region string)
+	ResumeRegion(region string)
+	GetScheduledJobs() []scheduler.ScheduledJob
+}
+
+// Channel consumes an SQS queue of commands and runs them. Commands that
+// cannot be run are logged and discarded.
+type Channel struct {
+	consumer  *aws.QueueConsumer
+	queueURL  string
+	scheduler Scheduler
+	reload    func(ctx context.Context) error
+	audit     *audit.Log
+	hostname  string
+	logger    *logger.Logger
+}
+
+// NewChannel creates a channel consuming the queue at queueURL. Start starts
+// it.
+func NewChannel(client aws.SQSClient, queueURL string, sched Scheduler, log *logger.Logger) *Channel {
+	hostname, _ := os.Hostname()
+	c := &Channel{
+		queueURL:  queueURL,
+		scheduler: sched,
+		hostname:  hostname,
+		logger:    log.WithComponent("control"),
+	}
+	c.consumer = aws.NewQueueConsumer(client, queueURL, c.handle, c.logger)
+	return c
+}
+
+// SetReloader enables the reload command, which calls reload
+func (c *Channel) SetReloader(reload func(ctx context.Context) error) {
+	c.reload = reload
+}
+
+// SetAuditLog records the commands run to log
+func (c *Channel) SetAuditLog(log *audit.Log) {
+	c.audit = log
+}
+
+// SetWaitTime sets how long a receive waits for messages, up to 20s
+func (c *Channel) SetWaitTime(waitTime time.Duration) {
+	c.consumer.SetWaitTime(waitTime)
+}
+
+// Start consumes the queue in the background until Stop is called or ctx is
+// done
+func (c *Channel) Start(ctx context.Context) {
+	if c.consumer.Start(ctx) {
+		c.logger.Info("Control channel started", logger.String("queue_url", c.queueURL))
+	}
+}
+
+// Stop stops consuming the queue and waits until ctx is done for the receive
+// in progress to return
+func (c *Channel) Stop(ctx context.Context) error {
+	return c.consumer.Stop(ctx)
+}
+
+// handle runs the command in a message, unless it targets other hosts. The
+// audit log records the message ID as the actor.
+func (c *Channel) handle(ctx context.Context, messageID, body string) {
+	var command Command
+	if err := json.Unmarshal([]byte(body), &command); err != nil {
+		c.logger.Warn("Discarding control message",
+			logger.String("message_id", messageID),
+			logger.String("error", err.Error()))
+		return
+	}
+	if !c.targets(command) {
+		c.logger.Debug("Skipping control command for other hosts",
+			logger.String("message_id", messageID),
+			logger.String("command", command.Command))
+		return
+	}
+
+	ctx = audit.WithActor(ctx, "sqs:"+messageID)
+	if err := c.Execute(ctx, command); err != nil {
+		c.logger.Warn("Control command failed",
+			logger.String("message_id", messageID),
+			logger.String("command", command.Command),
+			logger.String("error", err.Error()))
+		return
+	}
+	c.logger.Info("Control command run",
+		logger.String("message_id", messageID),
+		logger.String("command", command.Command),
+		logger.String("collector", command.Collector),
+		logger.String("region", command.Region))
+}
+
+// targets reports whether the command is for this host
+func (c *Channel) targets(command Command) bool {
+	if len(command.Hosts) == 0 {
+		return true
+	}
+	for _, host := range command.Hosts {
+		if c.hostname != "" && strings.EqualFold(host, c.hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute runs a command
+func (c *Channel) Execute(ctx context.Context, command Command) error {
+	switch command.Command {
+	case CommandCollect:
+		return c.collect(ctx, command.Collector, command.Region)
+	case CommandPauseRegion, CommandResumeRegion:
+		if command.Region == "" {
+			return fmt.Errorf("%s needs a region", command.Command)
+		}
+		if command.Command == CommandPauseRegion {
+			c.scheduler.PauseRegion(command.Region)
+			c.record(ctx, audit.ActionRegionPause, command.Region, nil)
+		} else {
+			c.scheduler.ResumeRegion(command.Region)
+			c.record(ctx, audit.ActionRegionResume, command.Region, nil)
+		}
+		return nil
+	case CommandReload:
+		if c.reload == nil {
+			return fmt.Errorf("configuration reload is disabled")
+		}
+		return c.reload(ctx)
+	default:
+		return fmt.Errorf("unknown command %q", command.Command)
+	}
+}
+
+// collect triggers a collector in region or, without one, in every region it
+// is scheduled in
+func (c *Channel) collect(ctx context.Context, collectorName, region string) error {
+	if collectorName == "" {
+		return fmt.Errorf("%s needs a collector", CommandCollect)
+	}
+
+	regions := []string{region}
+	if region == "" {
+		regions = nil
+		for _, job := range c.scheduler.GetScheduledJobs() {
+			if job.CollectorName == collectorName {
+				regions = append(regions, job.Region)
+			}
+		}
+		if len(regions) == 0 {
+			return fmt.Errorf("collector %s is not scheduled", collectorName)
+		}
+	}
+
+	triggered := make([]string, 0, len(regions))
+	var errs []string
+	for _, r := range regions {
+		if err := c.scheduler.TriggerCollector(ctx, collectorName, r); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		triggered = append(triggered, r)
+	}
+	if len(triggered) > 0 {
+		c.record(ctx, audit.ActionCollectorRun, collectorName,
+			map[string]string{"regions": strings.Join(triggered, ",")})
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to run collector %s: %s", collectorName, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// record adds a command run to the audit log, if any
+func (c *Channel) record(ctx context.Context, action, target string, details map[string]string) {
+	if c.audit == nil {
+		return
+	}
+	if err := c.audit.Record(ctx, action, target, details); err != nil {
+		c.logger.Error("Failed to record audit log entry",
+			logger.String("action", action),
+			logger.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,156 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/logger"
+)
+
+// mockScheduler records the jobs triggered and the regions paused
+type mockScheduler struct {
+	jobs      []scheduler.ScheduledJob
+	triggered []string
+	paused    map[string]bool
+}
+
+func newMockScheduler() *mockScheduler {
+	return &mockScheduler{
+		jobs: []scheduler.ScheduledJob{
+			{ID: "ec2-us-east-1", CollectorName: "ec2", Region: "us-east-1"},
+			{ID: "ec2-eu-west-1", CollectorName: "ec2", Region: "eu-west-1"},
+			{ID: "rds-us-east-1", CollectorName: "rds", Region: "us-east-1"},
+		},
+		paused: make(map[string]bool),
+	}
+}
+
+func (m *mockScheduler) TriggerCollector(_ context.Context, collectorName, region string) error {
+	jobID := fmt.Sprintf("%s-%s", collectorName, region)
+	for _, job := range m.jobs {
+		if job.ID == jobID {
+			m.triggered = append(m.triggered, jobID)
+			return nil
+		}
+	}
+	return fmt.Errorf("job %s not found", jobID)
+}
+
+func (m *mockScheduler) PauseRegion(region string)  { m.paused[region] = true }
+func (m *mockScheduler) ResumeRegion(region string) { delete(m.paused, region) }
+
+func (m *mockScheduler) GetScheduledJobs() []scheduler.ScheduledJob { return m.jobs }
+
+func newTestChannel(t *testing.T) (*Channel, *mockScheduler, *audit.Log) {
+	t.Helper()
+	log, _ := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	auditLog, err := audit.Open("", 0)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+
+	sched := newMockScheduler()
+	channel := NewChannel(nil, "queue", sched, log)
+	channel.SetAuditLog(auditLog)
+	channel.hostname = "monitor-1"
+	return channel, sched, auditLog
+}
+
+func TestChannelCollect(t *testing.T) {
+	channel, sched, auditLog := newTestChannel(t)
+	ctx := context.Background()
+
+	channel.handle(ctx, "m1", `{"command": "collect", "collector": "ec2"}`)
+	channel.handle(ctx, "m2", `{"command": "collect", "collector": "rds", "region": "us-east-1"}`)
+
+	expected := []string{"ec2-us-east-1", "ec2-eu-west-1", "rds-us-east-1"}
+	if !reflect.DeepEqual(sched.triggered, expected) {
+		t.Errorf("Expected jobs %v to be triggered, got %v", expected, sched.triggered)
+	}
+
+	entries := auditLog.Entries(audit.Filter{Action: audit.ActionCollectorRun})
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %+v", entries)
+	}
+	// Entries are returned newest first
+	if entries[1].Actor != "sqs:m1" || entries[1].Target != "ec2" || entries[1].Details["regions"] != "us-east-1,eu-west-1" {
+		t.Errorf("Expected the ec2 run by sqs:m1 in both regions, got %+v", entries[1])
+	}
+
+	for _, command := range []Command{
+		{Command: CommandCollect},
+		{Command: CommandCollect, Collector: "s3"},
+		{Command: CommandCollect, Collector: "rds", Region: "eu-west-1"},
+	} {
+		if err := channel.Execute(ctx, command); err == nil {
+			t.Errorf("Expected error running %+v", command)
+		}
+	}
+}
+
+func TestChannelPauseRegion(t *testing.T) {
+	channel, sched, auditLog := newTestChannel(t)
+	ctx := context.Background()
+
+	channel.handle(ctx, "m1", `{"command": "pause_region", "region": "eu-west-1"}`)
+	if !sched.paused["eu-west-1"] {
+		t.Error("Expected eu-west-1 to be paused")
+	}
+	channel.handle(ctx, "m2", `{"command": "resume_region", "region": "eu-west-1"}`)
+	if sched.paused["eu-west-1"] {
+		t.Error("Expected eu-west-1 to be resumed")
+	}
+
+	if entries := auditLog.Entries(audit.Filter{}); len(entries) != 2 ||
+		entries[0].Action != audit.ActionRegionResume || entries[1].Action != audit.ActionRegionPause {
+		t.Errorf("Expected the pause and resume to be audited, got %+v", entries)
+	}
+
+	if err := channel.Execute(ctx, Command{Command: CommandPauseRegion}); err == nil {
+		t.Error("Expected error pausing without a region")
+	}
+}
+
+func TestChannelReload(t *testing.T) {
+	channel, _, _ := newTestChannel(t)
+	ctx := context.Background()
+
+	if err := channel.Execute(ctx, Command{Command: CommandReload}); err == nil {
+		t.Error("Expected error reloading without a reloader")
+	}
+
+	var actor string
+	channel.SetReloader(func(ctx context.Context) error {
+		actor = audit.ActorFrom(ctx)
+		return nil
+	})
+	channel.handle(ctx, "m1", `{"command": "reload"}`)
+	if actor != "sqs:m1" {
+		t.Errorf("Expected the reload to be made by sqs:m1, got %q", actor)
+	}
+
+	if err := channel.Execute(ctx, Command{Command: "restart"}); err == nil {
+		t.Error("Expected error running an unknown command")
+	}
+}
+
+func TestChannelHosts(t *testing.T) {
+	channel, sched, _ := newTestChannel(t)
+	ctx := context.Background()
+
+	channel.handle(ctx, "m1", `{"command": "pause_region", "region": "us-east-1", "hosts": ["monitor-2"]}`)
+	if sched.paused["us-east-1"] {
+		t.Error("Expected a command for another host to be skipped")
+	}
+	channel.handle(ctx, "m2", `{"command": "pause_region", "region": "us-east-1", "hosts": ["monitor-2", "MONITOR-1"]}`)
+	if !sched.paused["us-east-1"] {
+		t.Error("Expected a command for this host to be run")
+	}
+
+	// Invalid messages are discarded
+	channel.handle(ctx, "m3", "not json")
+}
@@ -0,0 +1,36 @@
+// Package dashboard serves the status web dashboard, a single page that polls
+// the read-only API for quick operational triage without external tooling.
+package dashboard
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// PathPrefix is the path under which the dashboard is served
+const PathPrefix = "/dashboard/"
+
+// contentSecurityPolicy limits the page to its inline script and styles and
+// to requests to its own origin
+const contentSecurityPolicy = "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'"
+
+//go:embed index.html
+var index []byte
+
+// NewHandler creates a handler serving the dashboard page at PathPrefix.
+// The page reads the API relative to its own path, so the API must be
+// served on the same server.
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+PathPrefix+"{$}", serveIndex)
+	return mux
+}
+
+func serveIndex(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	_, _ = w.Write(index)
+}
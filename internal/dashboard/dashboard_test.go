@@ -0,0 +1,43 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PathPrefix, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Expected an HTML content type, got %q", contentType)
+	}
+	if w.Header().Get("Content-Security-Policy") == "" {
+		t.Error("Expected a content security policy")
+	}
+	for _, path := range []string{"collectors", "scheduler", "jobs", "export", "errors/recent"} {
+		if !strings.Contains(w.Body.String(), `get("`+path+`")`) {
+			t.Errorf("Expected the page to poll %s", path)
+		}
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, PathPrefix + "index.js", http.StatusNotFound},
+		{http.MethodPost, PathPrefix, http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.code {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.path, tc.code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package events delivers discrete occurrences reported by collectors, such as
+// an instance state change, an expiring certificate or a new security finding,
+// separately from metrics: to the OpenTelemetry collector as log records and
+// to webhooks as JSON.
+package events
+
+import (
+	"fmt"
+	"time"
+)
+
+// Severity is how urgent an event is
+type Severity string
+
+const (
+	// SeverityInfo is a notable change that needs no action
+	SeverityInfo Severity = "info"
+	// SeverityWarning needs attention soon, e.g. a certificate expiring in
+	// weeks
+	SeverityWarning Severity = "warning"
+	// SeverityCritical needs immediate attention
+	SeverityCritical Severity = "critical"
+)
+
+// severityRanks orders the severities
+var severityRanks = map[Severity]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// ParseSeverity returns the severity with the given name
+func ParseSeverity(name string) (Severity, error) {
+	severity := Severity(name)
+	if _, ok := severityRanks[severity]; !ok {
+		return "", fmt.Errorf("unknown event severity %q; use info, warning or critical", name)
+	}
+	return severity, nil
+}
+
+// AtLeast reports whether the severity is min or more urgent. Unknown
+// severities rank as info.
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRanks[s] >= severityRanks[min]
+}
+
+// Event is a discrete occurrence reported by a collector
+type Event struct {
+	// Type identifies the kind of event, e.g. ec2.instance.state_change
+	Type string `json:"type"`
+	// Severity is how urgent the event is; info if empty
+	Severity Severity `json:"severity"`
+	// Message describes the event
+	Message string `json:"message"`
+	// Collector is the name of the collector that reported the event
+	Collector string `json:"collector,omitempty"`
+	// Region is the AWS region of the resource, if any
+	Region string `json:"region,omitempty"`
+	// Resource identifies the resource the event is about, e.g. an instance
+	// ID or ARN
+	Resource string `json:"resource,omitempty"`
+	// Attributes are further details of the event
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Time is when the event occurred
+	Time time.Time `json:"time"`
+}
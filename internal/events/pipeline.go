@@ -0,0 +1,156 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// Sink delivers events to a destination
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	// Send delivers a single event
+	Send(ctx context.Context, event Event) error
+}
+
+// Stats are the counters of a pipeline
+type Stats struct {
+	// Emitted is the number of events queued for delivery
+	Emitted int64 `json:"emitted"`
+	// Dropped is the number of events discarded because the queue was full
+	Dropped int64 `json:"dropped"`
+	// Delivered and Failed count deliveries to each sink
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`
+}
+
+// Pipeline queues the events emitted by collectors and delivers them to every
+// sink in the background, so that a slow sink never holds up a collection.
+// Events below the minimum severity are discarded, and events emitted while
+// the queue is full are dropped.
+type Pipeline struct {
+	sinks       []Sink
+	minSeverity Severity
+	timeout     time.Duration
+	logger      *logger.Logger
+
+	// mu guards closing the queue against concurrent Emit calls
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Event
+	done   chan struct{}
+
+	emitted   atomic.Int64
+	dropped   atomic.Int64
+	delivered atomic.Int64
+	failed    atomic.Int64
+}
+
+// NewPipeline creates a pipeline delivering to sinks and starts delivering.
+// Close stops it.
+func NewPipeline(sinks []Sink, bufferSize int, minSeverity Severity, log *logger.Logger) *Pipeline {
+	p := &Pipeline{
+		sinks:       sinks,
+		minSeverity: minSeverity,
+		timeout:     10 * time.Second,
+		logger:      log.WithComponent("events"),
+		queue:       make(chan Event, bufferSize),
+		done:        make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Emit queues an event for delivery, stamping it with the current time if it
+// has none. It reports whether the event was queued.
+func (p *Pipeline) Emit(event Event) bool {
+	if event.Severity == "" {
+		event.Severity = SeverityInfo
+	}
+	if !event.Severity.AtLeast(p.minSeverity) {
+		return false
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+	select {
+	case p.queue <- event:
+		p.emitted.Add(1)
+		return true
+	default:
+		p.dropped.Add(1)
+		p.logger.Warn("Event queue full, dropping event",
+			logger.String("type", event.Type),
+			logger.String("collector", event.Collector))
+		return false
+	}
+}
+
+// run delivers queued events until the queue is closed and drained
+func (p *Pipeline) run() {
+	defer close(p.done)
+	for event := range p.queue {
+		for _, sink := range p.sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+			err := sink.Send(ctx, event)
+			cancel()
+
+			if err != nil {
+				p.failed.Add(1)
+				p.logger.Error("Failed to deliver event",
+					logger.String("sink", sink.Name()),
+					logger.String("type", event.Type),
+					logger.String("collector", event.Collector),
+					logger.String("error", err.Error()))
+				continue
+			}
+			p.delivered.Add(1)
+		}
+	}
+}
+
+// Stats returns the pipeline counters
+func (p *Pipeline) Stats() Stats {
+	return Stats{
+		Emitted:   p.emitted.Load(),
+		Dropped:   p.dropped.Load(),
+		Delivered: p.delivered.Load(),
+		Failed:    p.failed.Load(),
+	}
+}
+
+// Close stops accepting events, delivers those queued until ctx is done and
+// then shuts down the sinks that need it, such as the OTLP exporter
+func (p *Pipeline) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for _, sink := range p.sinks {
+		if s, ok := sink.(interface{ Shutdown(context.Context) error }); ok {
+			if err := s.Shutdown(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
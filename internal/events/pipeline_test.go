@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// recordingSink records the events it receives, optionally failing, and
+// blocks until released if gate is set
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+	fail   bool
+	gate   chan struct{}
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(_ context.Context, event Event) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	if s.fail {
+		return errors.New("delivery failed")
+	}
+	return nil
+}
+
+func (s *recordingSink) received() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func newTestLogger(t *testing.T) *logger.Logger {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return log
+}
+
+func TestParseSeverity(t *testing.T) {
+	if severity, err := ParseSeverity("warning"); err != nil || severity != SeverityWarning {
+		t.Errorf("Expected warning, got %q, %v", severity, err)
+	}
+	if _, err := ParseSeverity("debug"); err == nil {
+		t.Error("Expected error for unknown severity")
+	}
+	if !SeverityCritical.AtLeast(SeverityWarning) || SeverityInfo.AtLeast(SeverityWarning) {
+		t.Error("Expected severities to be ordered info < warning < critical")
+	}
+}
+
+func TestPipelineDelivers(t *testing.T) {
+	ok, failing := &recordingSink{}, &recordingSink{fail: true}
+	p := NewPipeline([]Sink{ok, failing}, 10, SeverityWarning, newTestLogger(t))
+
+	if p.Emit(Event{Type: "ec2.instance.state_change", Message: "instance stopped"}) {
+		t.Error("Expected an info event to be discarded below the minimum severity")
+	}
+	if !p.Emit(Event{Type: "acm.certificate.expiring", Severity: SeverityWarning, Message: "expires in 14 days"}) {
+		t.Error("Expected a warning event to be queued")
+	}
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error closing, got: %v", err)
+	}
+	if p.Emit(Event{Type: "late", Severity: SeverityCritical}) {
+		t.Error("Expected events emitted after Close to be discarded")
+	}
+
+	received := ok.received()
+	if len(received) != 1 || received[0].Type != "acm.certificate.expiring" {
+		t.Fatalf("Expected the warning event to be delivered, got %+v", received)
+	}
+	if received[0].Time.IsZero() {
+		t.Error("Expected the event to be stamped with the current time")
+	}
+
+	stats := p.Stats()
+	if stats.Emitted != 1 || stats.Delivered != 1 || stats.Failed != 1 || stats.Dropped != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestPipelineDropsWhenFull(t *testing.T) {
+	sink := &recordingSink{gate: make(chan struct{})}
+	p := NewPipeline([]Sink{sink}, 1, SeverityInfo, newTestLogger(t))
+
+	// The first event is taken by the delivery goroutine, which blocks on
+	// the gate; the second fills the queue
+	p.Emit(Event{Type: "first"})
+	deadline := time.Now().Add(time.Second)
+	for len(p.queue) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !p.Emit(Event{Type: "second"}) {
+		t.Fatal("Expected the second event to be queued")
+	}
+	if p.Emit(Event{Type: "third"}) {
+		t.Error("Expected the third event to be dropped while the queue is full")
+	}
+
+	close(sink.gate)
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error closing, got: %v", err)
+	}
+	if stats := p.Stats(); stats.Dropped != 1 || stats.Delivered != 2 {
+		t.Errorf("Expected 1 dropped and 2 delivered events, got %+v", stats)
+	}
+}
@@ -0,0 +1,202 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+// scopeName is the instrumentation scope of exported events
+const scopeName = "aws-monitoring/internal/events"
+
+// Attributes of exported events, besides their own attributes
+const (
+	attrCollector = "aws_monitor.collector"
+	attrResource  = "aws_monitor.resource"
+)
+
+// WebhookSink posts events as JSON to an HTTP endpoint
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookSink creates a sink that posts events to url with extra headers
+func NewWebhookSink(url string, headers map[string]string) *WebhookSink {
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the sink in logs
+func (s *WebhookSink) Name() string {
+	return "webhook:" + s.url
+}
+
+// Send posts the event as JSON and treats any non-2xx response as an error
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OTLPSink exports events as OTLP log records named after the event type, in
+// batches, to the OpenTelemetry collector
+type OTLPSink struct {
+	provider *sdklog.LoggerProvider
+	logger   otellog.Logger
+}
+
+// NewOTLPSink creates a sink exporting over OTLP/gRPC to the configured
+// collector. Shutting it down exports the remaining events.
+func NewOTLPSink(cfg config.OTELConfig, version string) (*OTLPSink, error) {
+	// Events are log records, connected to the collector like the logs
+	opts, err := logger.OTLPExporterOptions(logger.OTLPConfig{
+		Endpoint:           cfg.CollectorEndpoint,
+		SRVRefreshInterval: time.Duration(cfg.SRVRefreshInterval),
+		Insecure:           cfg.Insecure,
+		Headers:            cfg.Headers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlploggrpc.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP event exporter: %w", err)
+	}
+	return newOTLPSink(cfg, version, exporter), nil
+}
+
+// newOTLPSink creates a sink batching events to exporter
+func newOTLPSink(cfg config.OTELConfig, version string, exporter sdklog.Exporter) *OTLPSink {
+	var batchOpts []sdklog.BatchProcessorOption
+	if cfg.BatchTimeout > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportInterval(time.Duration(cfg.BatchTimeout)))
+	}
+	if cfg.BatchSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportMaxBatchSize(cfg.BatchSize))
+	}
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(eventResource(cfg, version)),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, batchOpts...)),
+	)
+	return &OTLPSink{provider: provider, logger: provider.Logger(scopeName)}
+}
+
+// Name identifies the sink in logs
+func (s *OTLPSink) Name() string {
+	return "otlp"
+}
+
+// Send queues the event for export
+func (s *OTLPSink) Send(ctx context.Context, event Event) error {
+	var record otellog.Record
+	record.SetEventName(event.Type)
+	record.SetTimestamp(event.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(otelSeverity(event.Severity))
+	record.SetSeverityText(string(event.Severity))
+	record.SetBody(otellog.StringValue(event.Message))
+
+	attrs := make([]otellog.KeyValue, 0, len(event.Attributes)+3)
+	if event.Collector != "" {
+		attrs = append(attrs, otellog.String(attrCollector, event.Collector))
+	}
+	if event.Region != "" {
+		attrs = append(attrs, otellog.String(string(semconv.CloudRegionKey), event.Region))
+	}
+	if event.Resource != "" {
+		attrs = append(attrs, otellog.String(attrResource, event.Resource))
+	}
+	keys := make([]string, 0, len(event.Attributes))
+	for key := range event.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attrs = append(attrs, otellog.String(key, event.Attributes[key]))
+	}
+	record.AddAttributes(attrs...)
+
+	s.logger.Emit(ctx, record)
+	return nil
+}
+
+// Shutdown exports the remaining events and stops the exporter
+func (s *OTLPSink) Shutdown(ctx context.Context) error {
+	return s.provider.Shutdown(ctx)
+}
+
+// otelSeverity returns the log record severity of an event severity
+func otelSeverity(severity Severity) otellog.Severity {
+	switch severity {
+	case SeverityCritical:
+		return otellog.SeverityError
+	case SeverityWarning:
+		return otellog.SeverityWarn
+	default:
+		return otellog.SeverityInfo
+	}
+}
+
+// eventResource returns the resource describing the exporting service, as
+// for exported log records and traces
+func eventResource(cfg config.OTELConfig, version string) *resource.Resource {
+	keys := make([]string, 0, len(cfg.ResourceAttributes))
+	for key := range cfg.ResourceAttributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute.KeyValue, 0, len(keys)+2)
+	for _, key := range keys {
+		attrs = append(attrs, attribute.String(key, cfg.ResourceAttributes[key]))
+	}
+	attrs = append(attrs, semconv.ServiceName(cfg.ServiceName))
+	if version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(version))
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
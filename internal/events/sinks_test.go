@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+
+	"aws-monitoring/internal/config"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var received Event
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, map[string]string{"Authorization": "Bearer token"})
+	event := Event{Type: "ec2.instance.state_change", Severity: SeverityInfo, Message: "instance stopped",
+		Resource: "i-0123456789abcdef0", Time: time.Now()}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if received.Type != event.Type || received.Resource != event.Resource {
+		t.Errorf("Expected the event as JSON, got %+v", received)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Expected the configured headers, got Authorization %q", auth)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := NewWebhookSink(failing.URL, nil).Send(context.Background(), event); err == nil {
+		t.Error("Expected error for a non-2xx response")
+	}
+}
+
+// memoryExporter keeps the exported log records
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, record := range records {
+		e.records = append(e.records, record.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func TestOTLPSink(t *testing.T) {
+	exporter := &memoryExporter{}
+	sink := newOTLPSink(config.OTELConfig{ServiceName: "aws-monitor"}, "1.2.3", exporter)
+
+	event := Event{
+		Type:       "securityhub.finding.new",
+		Severity:   SeverityCritical,
+		Message:    "S3 bucket is public",
+		Collector:  "findings",
+		Region:     "us-east-1",
+		Resource:   "arn:aws:s3:::reports",
+		Attributes: map[string]string{"finding_id": "abc"},
+		Time:       time.Now(),
+	}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := sink.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error shutting down, got: %v", err)
+	}
+
+	if len(exporter.records) != 1 {
+		t.Fatalf("Expected 1 exported record, got %d", len(exporter.records))
+	}
+	record := exporter.records[0]
+	if record.EventName() != event.Type || record.Body().AsString() != event.Message {
+		t.Errorf("Expected the event name and message, got %q, %q", record.EventName(), record.Body().AsString())
+	}
+	if record.Severity() != otellog.SeverityError {
+		t.Errorf("Expected critical events exported as errors, got %v", record.Severity())
+	}
+
+	attrs := make(map[string]string)
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value.AsString()
+		return true
+	})
+	for key, want := range map[string]string{
+		attrCollector:  "findings",
+		"cloud.region": "us-east-1",
+		attrResource:   "arn:aws:s3:::reports",
+		"finding_id":   "abc",
+	} {
+		if attrs[key] != want {
+			t.Errorf("Expected attribute %s=%s, got %q", key, want, attrs[key])
+		}
+	}
+}
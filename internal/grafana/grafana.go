@@ -0,0 +1,431 @@
+// Package grafana generates Grafana dashboards for the metrics the collectors
+// declare, querying them from the backend the OpenTelemetry collector exports
+// them to.
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"aws-monitoring/internal/collectors"
+)
+
+// Backend is the metrics store a dashboard queries
+type Backend string
+
+const (
+	// BackendPrometheus queries Prometheus, or a compatible store, with PromQL
+	BackendPrometheus Backend = "prometheus"
+	// BackendCloudWatch queries CloudWatch metrics, as published by the
+	// collector's awsemf exporter
+	BackendCloudWatch Backend = "cloudwatch"
+)
+
+// Backends are the supported backends
+var Backends = []Backend{BackendPrometheus, BackendCloudWatch}
+
+// ParseBackend returns the backend with the given name
+func ParseBackend(name string) (Backend, error) {
+	for _, backend := range Backends {
+		if string(backend) == strings.ToLower(name) {
+			return backend, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported backend %q; use prometheus or cloudwatch", name)
+}
+
+// schemaVersion is the Grafana dashboard schema the dashboards are written in
+const schemaVersion = 39
+
+// Panel dimensions on Grafana's 24 column grid
+const (
+	panelWidth  = 12
+	panelHeight = 8
+	gridWidth   = 24
+)
+
+// Section is a row of the dashboard with a panel per metric of a collector
+type Section struct {
+	Collector string
+	Metrics   []collectors.MetricDescriptor
+}
+
+// Options configure a generated dashboard
+type Options struct {
+	Backend Backend
+	Title   string
+	// Regions are offered by the region variable
+	Regions []string
+	// Namespace is the CloudWatch namespace the metrics are published in
+	Namespace string
+}
+
+// Dashboard is a Grafana dashboard, as imported from JSON
+type Dashboard struct {
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	Timezone      string     `json:"timezone"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Version       int        `json:"version"`
+	Refresh       string     `json:"refresh"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+// TimeRange is the default time range of a dashboard
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Templating holds the variables of a dashboard
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+// Variable is a dashboard variable: the data source or the regions shown
+type Variable struct {
+	Name       string   `json:"name"`
+	Label      string   `json:"label"`
+	Type       string   `json:"type"`
+	Query      string   `json:"query"`
+	Multi      bool     `json:"multi,omitempty"`
+	IncludeAll bool     `json:"includeAll,omitempty"`
+	AllValue   string   `json:"allValue,omitempty"`
+	Current    *Current `json:"current,omitempty"`
+	Options    []Option `json:"options,omitempty"`
+}
+
+// Current is the selected value of a variable
+type Current struct {
+	Text  interface{} `json:"text"`
+	Value interface{} `json:"value"`
+}
+
+// Option is a value of a custom variable
+type Option struct {
+	Text     string `json:"text"`
+	Value    string `json:"value"`
+	Selected bool   `json:"selected"`
+}
+
+// DataSource references the data source of a panel or query
+type DataSource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// GridPos is the position and size of a panel
+type GridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Panel is a row, or a time series panel charting a metric
+type Panel struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	GridPos     GridPos      `json:"gridPos"`
+	Collapsed   *bool        `json:"collapsed,omitempty"`
+	DataSource  *DataSource  `json:"datasource,omitempty"`
+	FieldConfig *FieldConfig `json:"fieldConfig,omitempty"`
+	Targets     []Target     `json:"targets,omitempty"`
+}
+
+// FieldConfig holds the display settings of a panel's series
+type FieldConfig struct {
+	Defaults FieldDefaults `json:"defaults"`
+}
+
+// FieldDefaults are the display settings applied to every series
+type FieldDefaults struct {
+	Unit string `json:"unit"`
+}
+
+// Target is a query of a panel. The fields used depend on the backend.
+type Target struct {
+	RefID      string      `json:"refId"`
+	DataSource *DataSource `json:"datasource"`
+	Hide       bool        `json:"hide,omitempty"`
+
+	// Prometheus
+	Expr         string `json:"expr,omitempty"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+
+	// CloudWatch
+	QueryMode        string              `json:"queryMode,omitempty"`
+	MetricQueryType  *int                `json:"metricQueryType,omitempty"`
+	MetricEditorMode *int                `json:"metricEditorMode,omitempty"`
+	Namespace        string              `json:"namespace,omitempty"`
+	MetricName       string              `json:"metricName,omitempty"`
+	Dimensions       map[string][]string `json:"dimensions,omitempty"`
+	Statistic        string              `json:"statistic,omitempty"`
+	Region           string              `json:"region,omitempty"`
+	MatchExact       *bool               `json:"matchExact,omitempty"`
+	ID               string              `json:"id,omitempty"`
+	Expression       string              `json:"expression,omitempty"`
+	Label            string              `json:"label,omitempty"`
+}
+
+// dataSource is the data source variable every panel queries
+func (o Options) dataSource() *DataSource {
+	return &DataSource{Type: string(o.Backend), UID: "${datasource}"}
+}
+
+// Generate returns the dashboard, as indented JSON, with a row per section
+func Generate(sections []Section, opts Options) ([]byte, error) {
+	dashboard, err := New(sections, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// New creates the dashboard with a row per section
+func New(sections []Section, opts Options) (*Dashboard, error) {
+	if _, err := ParseBackend(string(opts.Backend)); err != nil {
+		return nil, err
+	}
+	if opts.Backend == BackendCloudWatch && opts.Namespace == "" {
+		return nil, fmt.Errorf("a CloudWatch namespace is required")
+	}
+	if opts.Title == "" {
+		opts.Title = "aws-monitor"
+	}
+
+	dashboard := &Dashboard{
+		UID:           "aws-monitor-" + string(opts.Backend),
+		Title:         opts.Title,
+		Tags:          []string{"aws-monitor", string(opts.Backend)},
+		Timezone:      "browser",
+		SchemaVersion: schemaVersion,
+		Version:       1,
+		Refresh:       "1m",
+		Time:          TimeRange{From: "now-6h", To: "now"},
+		Templating:    Templating{List: variables(opts)},
+		Panels:        []Panel{},
+	}
+
+	id, y := 1, 0
+	collapsed := false
+	for _, section := range sections {
+		dashboard.Panels = append(dashboard.Panels, Panel{
+			ID:        id,
+			Type:      "row",
+			Title:     section.Collector,
+			GridPos:   GridPos{X: 0, Y: y, W: gridWidth, H: 1},
+			Collapsed: &collapsed,
+		})
+		id++
+		y++
+
+		for i, metric := range section.Metrics {
+			panel := metricPanel(section.Collector, metric, opts)
+			panel.ID = id
+			panel.GridPos = GridPos{X: (i % 2) * panelWidth, Y: y + (i/2)*panelHeight, W: panelWidth, H: panelHeight}
+			dashboard.Panels = append(dashboard.Panels, panel)
+			id++
+		}
+		y += (len(section.Metrics) + 1) / 2 * panelHeight
+	}
+	return dashboard, nil
+}
+
+// variables returns the data source and region variables
+func variables(opts Options) []Variable {
+	region := Variable{
+		Name:       "region",
+		Label:      "Region",
+		Type:       "custom",
+		Query:      strings.Join(opts.Regions, ","),
+		Multi:      true,
+		IncludeAll: true,
+		Current:    &Current{Text: []string{"All"}, Value: []string{"$__all"}},
+		Options:    []Option{{Text: "All", Value: "$__all", Selected: true}},
+	}
+	if opts.Backend == BackendPrometheus {
+		region.AllValue = ".*"
+	}
+	for _, name := range opts.Regions {
+		region.Options = append(region.Options, Option{Text: name, Value: name})
+	}
+
+	return []Variable{
+		{Name: "datasource", Label: "Data source", Type: "datasource", Query: string(opts.Backend)},
+		region,
+	}
+}
+
+// metricPanel returns the time series panel charting a metric of a collector
+func metricPanel(collector string, metric collectors.MetricDescriptor, opts Options) Panel {
+	panel := Panel{
+		Type:        "timeseries",
+		Title:       metric.Name,
+		Description: metric.Description,
+		DataSource:  opts.dataSource(),
+		FieldConfig: &FieldConfig{Defaults: FieldDefaults{Unit: grafanaUnit(metric.Unit)}},
+	}
+
+	switch opts.Backend {
+	case BackendCloudWatch:
+		panel.Targets = cloudWatchTargets(collector, metric, opts)
+		if metric.Kind == collectors.KindHistogram {
+			panel.Title = metric.Name + " (average)"
+		}
+	default:
+		panel.Targets = []Target{prometheusTarget(collector, metric, opts)}
+		switch metric.Kind {
+		case collectors.KindCounter:
+			panel.Title = metric.Name + " (per second)"
+			panel.FieldConfig.Defaults.Unit = "short"
+		case collectors.KindHistogram:
+			panel.Title = metric.Name + " (p95)"
+		}
+	}
+	return panel
+}
+
+// invalidPromChars are the characters Prometheus doesn't allow in metric
+// names, which the collector's exporter replaces with underscores
+var invalidPromChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+// prometheusTarget returns the PromQL query of a metric: the rate of a
+// counter, the 95th percentile of a histogram or the value of a gauge, by the
+// metric's labels
+func prometheusTarget(collector string, metric collectors.MetricDescriptor, opts Options) Target {
+	name := invalidPromChars.ReplaceAllString(metric.Name, "_")
+	var matchers []string
+	if !hasLabel(metric, "collector") {
+		matchers = append(matchers, fmt.Sprintf(`collector="%s"`, collector))
+	}
+	if hasLabel(metric, "region") {
+		matchers = append(matchers, `region=~"$region"`)
+	}
+	selector := ""
+	if len(matchers) > 0 {
+		selector = "{" + strings.Join(matchers, ", ") + "}"
+	}
+
+	by := metric.Labels
+	legend := make([]string, 0, len(by))
+	for _, label := range by {
+		legend = append(legend, "{{"+label+"}}")
+	}
+	grouping := ""
+	if len(by) > 0 {
+		grouping = " by (" + strings.Join(by, ", ") + ")"
+	}
+
+	target := Target{RefID: "A", DataSource: opts.dataSource(), LegendFormat: strings.Join(legend, " ")}
+	if target.LegendFormat == "" {
+		target.LegendFormat = metric.Name
+	}
+	switch metric.Kind {
+	case collectors.KindCounter:
+		target.Expr = fmt.Sprintf("sum%s (rate(%s%s[$__rate_interval]))", grouping, name, selector)
+	case collectors.KindHistogram:
+		target.Expr = fmt.Sprintf("histogram_quantile(0.95, sum by (%s) (rate(%s_bucket%s[$__rate_interval])))",
+			strings.Join(append([]string{"le"}, by...), ", "), name, selector)
+	default:
+		target.Expr = fmt.Sprintf("sum%s (%s%s)", grouping, name, selector)
+	}
+	return target
+}
+
+// cloudWatchTargets returns the CloudWatch queries of a metric: its sum for
+// a counter, its maximum for a gauge, and for a histogram its average, the
+// _sum series divided by the _count series
+func cloudWatchTargets(collector string, metric collectors.MetricDescriptor, opts Options) []Target {
+	dimensions := map[string][]string{}
+	if !hasLabel(metric, "collector") {
+		dimensions["collector"] = []string{collector}
+	}
+	if hasLabel(metric, "region") {
+		dimensions["region"] = []string{"$region"}
+	}
+
+	query := func(refID, name, statistic string) Target {
+		builder, search := 0, 0
+		matchExact := false
+		return Target{
+			RefID:            refID,
+			DataSource:       opts.dataSource(),
+			QueryMode:        "Metrics",
+			MetricQueryType:  &search,
+			MetricEditorMode: &builder,
+			Namespace:        opts.Namespace,
+			MetricName:       name,
+			Dimensions:       dimensions,
+			Statistic:        statistic,
+			Region:           "default",
+			MatchExact:       &matchExact,
+		}
+	}
+
+	switch metric.Kind {
+	case collectors.KindCounter:
+		return []Target{query("A", metric.Name, "Sum")}
+	case collectors.KindHistogram:
+		sum := query("A", metric.Name+"_sum", "Sum")
+		sum.ID, sum.Hide = "total", true
+		count := query("B", metric.Name+"_count", "Sum")
+		count.ID, count.Hide = "runs", true
+		code, search := 1, 0
+		average := Target{
+			RefID:            "C",
+			DataSource:       opts.dataSource(),
+			QueryMode:        "Metrics",
+			MetricQueryType:  &search,
+			MetricEditorMode: &code,
+			Region:           "default",
+			ID:               "average",
+			Expression:       "total / runs",
+			Label:            "average",
+		}
+		return []Target{sum, count, average}
+	default:
+		return []Target{query("A", metric.Name, "Maximum")}
+	}
+}
+
+// hasLabel reports whether the series of a metric are distinguished by label.
+// A metric labelled with a collector, such as the self-telemetry collection
+// counts, describes that collector rather than the one producing it.
+func hasLabel(metric collectors.MetricDescriptor, label string) bool {
+	for _, l := range metric.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// grafanaUnit returns the Grafana unit of a metric unit
+func grafanaUnit(unit string) string {
+	switch unit {
+	case "Seconds":
+		return "s"
+	case "Milliseconds":
+		return "ms"
+	case "Bytes":
+		return "bytes"
+	case "Percent":
+		return "percent"
+	case "Ratio":
+		return "percentunit"
+	default:
+		return "short"
+	}
+}
@@ -0,0 +1,108 @@
+package grafana
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"aws-monitoring/internal/collectors"
+)
+
+// testSections are a collector with a gauge and a counter, and the
+// self-telemetry collector with a histogram
+var testSections = []Section{
+	{Collector: "queues", Metrics: []collectors.MetricDescriptor{
+		{Name: "queue_depth", Kind: collectors.KindGauge, Unit: "Count", Labels: []string{"queue"}},
+		{Name: collectors.ErrorsTotalMetric, Kind: collectors.KindCounter, Unit: "Count",
+			Labels: []string{"region", "error_type", "error_code"}},
+	}},
+	{Collector: "self", Metrics: []collectors.MetricDescriptor{
+		{Name: "aws_monitor_collection_duration_seconds", Kind: collectors.KindHistogram, Unit: "Seconds",
+			Labels: []string{"collector"}},
+	}},
+}
+
+func TestParseBackend(t *testing.T) {
+	if backend, err := ParseBackend("CloudWatch"); err != nil || backend != BackendCloudWatch {
+		t.Errorf("Expected cloudwatch backend, got %q, %v", backend, err)
+	}
+	if _, err := ParseBackend("influxdb"); err == nil {
+		t.Error("Expected error for unsupported backend")
+	}
+}
+
+func TestGeneratePrometheus(t *testing.T) {
+	data, err := Generate(testSections, Options{Backend: BackendPrometheus, Regions: []string{"us-east-1", "eu-west-1"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var dashboard Dashboard
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		t.Fatalf("Failed to decode dashboard: %v", err)
+	}
+	if len(dashboard.Panels) != 5 {
+		t.Fatalf("Expected 2 rows and 3 panels, got %d panels", len(dashboard.Panels))
+	}
+	if dashboard.Panels[0].Type != "row" || dashboard.Panels[0].Title != "queues" {
+		t.Errorf("Expected the queues row first, got %+v", dashboard.Panels[0])
+	}
+	if got := dashboard.Templating.List[1].Query; got != "us-east-1,eu-west-1" {
+		t.Errorf("Expected the region variable to offer the regions, got %q", got)
+	}
+
+	expected := map[string]string{
+		"queue_depth": `sum by (queue) (queue_depth{collector="queues"})`,
+		collectors.ErrorsTotalMetric + " (per second)":  `sum by (region, error_type, error_code) (rate(aws_monitor_errors_total{collector="queues", region=~"$region"}[$__rate_interval]))`,
+		"aws_monitor_collection_duration_seconds (p95)": `histogram_quantile(0.95, sum by (le, collector) (rate(aws_monitor_collection_duration_seconds_bucket[$__rate_interval])))`,
+	}
+	for _, panel := range dashboard.Panels {
+		if panel.Type == "row" {
+			continue
+		}
+		want, ok := expected[panel.Title]
+		if !ok {
+			t.Errorf("Unexpected panel %q", panel.Title)
+			continue
+		}
+		if len(panel.Targets) != 1 || panel.Targets[0].Expr != want {
+			t.Errorf("Expected query %s for %s, got %+v", want, panel.Title, panel.Targets)
+		}
+	}
+
+	// Panels are laid out two per line, below their row
+	if pos := dashboard.Panels[2].GridPos; pos.X != panelWidth || pos.Y != 1 {
+		t.Errorf("Expected the second panel beside the first, got %+v", pos)
+	}
+	if pos := dashboard.Panels[3].GridPos; pos.Y != 1+panelHeight {
+		t.Errorf("Expected the second row below the first row's panels, got %+v", pos)
+	}
+}
+
+func TestGenerateCloudWatch(t *testing.T) {
+	if _, err := Generate(testSections, Options{Backend: BackendCloudWatch}); err == nil {
+		t.Error("Expected error without a namespace")
+	}
+
+	dashboard, err := New(testSections, Options{Backend: BackendCloudWatch, Namespace: "aws-monitor"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	errorsPanel := dashboard.Panels[2]
+	target := errorsPanel.Targets[0]
+	if target.Namespace != "aws-monitor" || target.MetricName != collectors.ErrorsTotalMetric || target.Statistic != "Sum" {
+		t.Errorf("Unexpected errors query: %+v", target)
+	}
+	if strings.Join(target.Dimensions["collector"], ",") != "queues" || strings.Join(target.Dimensions["region"], ",") != "$region" {
+		t.Errorf("Expected collector and region dimensions, got %v", target.Dimensions)
+	}
+
+	durationPanel := dashboard.Panels[4]
+	if len(durationPanel.Targets) != 3 || durationPanel.Targets[2].Expression != "total / runs" {
+		t.Errorf("Expected the histogram average as metric math, got %+v", durationPanel.Targets)
+	}
+	if _, ok := durationPanel.Targets[0].Dimensions["collector"]; ok {
+		t.Error("Expected no collector dimension for a metric labelled with the collector it describes")
+	}
+}
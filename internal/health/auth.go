@@ -0,0 +1,96 @@
+package health
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// EndpointGroup identifies a group of health server endpoints that share
+// credentials
+type EndpointGroup string
+
+const (
+	// GroupProbes covers /health, /health/live, /health/ready and /health/startup
+	GroupProbes EndpointGroup = "probes"
+	// GroupDetailed covers /health/detailed and /health/history, which expose
+	// account and collector metadata
+	GroupDetailed EndpointGroup = "detailed"
+)
+
+// Credentials protect endpoints with a bearer token, basic auth or both. The
+// zero value allows unauthenticated access.
+type Credentials struct {
+	// Token is accepted as "Authorization: Bearer <token>"
+	Token string
+	// Username and Password are accepted as basic auth
+	Username string
+	Password string
+}
+
+// Enabled reports whether requests must be authenticated
+func (c Credentials) Enabled() bool {
+	return c.Token != "" || c.Username != ""
+}
+
+// Authorized reports whether r carries valid credentials, comparing them in
+// constant time. It is always true when authentication is disabled.
+func (c Credentials) Authorized(r *http.Request) bool {
+	if !c.Enabled() {
+		return true
+	}
+
+	if c.Token != "" {
+		if provided, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found &&
+			subtle.ConstantTimeCompare([]byte(provided), []byte(c.Token)) == 1 {
+			return true
+		}
+	}
+
+	if c.Username != "" {
+		if username, password, ok := r.BasicAuth(); ok {
+			usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(c.Username))
+			passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(c.Password))
+			return usernameMatch&passwordMatch == 1
+		}
+	}
+
+	return false
+}
+
+// Challenge sets the WWW-Authenticate headers for the accepted schemes
+func (c Credentials) Challenge(w http.ResponseWriter) {
+	if c.Token != "" {
+		w.Header().Add("WWW-Authenticate", "Bearer")
+	}
+	if c.Username != "" {
+		w.Header().Add("WWW-Authenticate", `Basic realm="aws-monitor"`)
+	}
+}
+
+// RequireAuth wraps next so requests without valid credentials are rejected
+// with 401 Unauthorized. Handlers are returned unwrapped when authentication
+// is disabled.
+func RequireAuth(credentials Credentials, next http.Handler, log *logger.Logger) http.Handler {
+	if !credentials.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !credentials.Authorized(r) {
+			log.Warn("Unauthorized request",
+				logger.String("method", r.Method),
+				logger.String("path", r.URL.Path),
+				logger.String("remote_addr", r.RemoteAddr))
+			credentials.Challenge(w)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aws-monitoring/pkg/logger"
+)
+
+func TestRequireAuth(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	credentials := Credentials{Token: "secret", Username: "ops", Password: "hunter2"}
+
+	tests := []struct {
+		name        string
+		credentials Credentials
+		setup       func(r *http.Request)
+		expected    int
+	}{
+		{name: "disabled", setup: func(*http.Request) {}, expected: http.StatusOK},
+		{name: "missing credentials", credentials: credentials, setup: func(*http.Request) {}, expected: http.StatusUnauthorized},
+		{
+			name:        "valid token",
+			credentials: credentials,
+			setup:       func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") },
+			expected:    http.StatusOK,
+		},
+		{
+			name:        "wrong token",
+			credentials: credentials,
+			setup:       func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") },
+			expected:    http.StatusUnauthorized,
+		},
+		{
+			name:        "valid basic auth",
+			credentials: credentials,
+			setup:       func(r *http.Request) { r.SetBasicAuth("ops", "hunter2") },
+			expected:    http.StatusOK,
+		},
+		{
+			name:        "wrong password",
+			credentials: credentials,
+			setup:       func(r *http.Request) { r.SetBasicAuth("ops", "wrong") },
+			expected:    http.StatusUnauthorized,
+		},
+		{
+			name:        "basic auth not configured",
+			credentials: Credentials{Token: "secret"},
+			setup:       func(r *http.Request) { r.SetBasicAuth("", "") },
+			expected:    http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/health/detailed", nil)
+			tt.setup(req)
+			w := httptest.NewRecorder()
+
+			RequireAuth(tt.credentials, ok, log).ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+			if w.Code == http.StatusUnauthorized && len(w.Header().Values("WWW-Authenticate")) == 0 {
+				t.Error("Expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
@@ -54,6 +54,22 @@ func (m *mockClientProvider) GetEC2Client(region string) (aws.EC2Client, error)
 	return client, nil
 }
 
+func (m *mockClientProvider) GetSTSClient(_ string) (aws.STSClient, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockClientProvider) GetIAMClient(_ string) (aws.IAMClient, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockClientProvider) GetSNSClient(_ string) (aws.SNSClient, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockClientProvider) GetSQSClient(_ string) (aws.SQSClient, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockClientProvider) Close() error {
 	return nil
 }
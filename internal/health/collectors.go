@@ -0,0 +1,163 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+)
+
+// CollectorStatusProvider reports the status of the registered collectors
+type CollectorStatusProvider interface {
+	Status() map[string]collectors.CollectorInfo
+}
+
+// CollectorChecker implements health checks for metric collectors. It reports
+// degraded while any circuit breaker is not closed or any collector is burning
+// its SLO error budget too fast.
+type CollectorChecker struct {
+	provider CollectorStatusProvider
+	name     string
+}
+
+// NewCollectorChecker creates a new collector health checker
+func NewCollectorChecker(provider CollectorStatusProvider) *CollectorChecker {
+	return &CollectorChecker{
+		provider: provider,
+		name:     "collectors",
+	}
+}
+
+// Name returns the unique identifier for this checker
+func (c *CollectorChecker) Name() string {
+	return c.name
+}
+
+// Check reports the circuit breaker and SLO state of every collector
+func (c *CollectorChecker) Check(_ context.Context) CheckResult {
+	start := time.Now()
+	result := CheckResult{
+		Name:        c.name,
+		LastChecked: start,
+		Metadata:    make(map[string]interface{}),
+	}
+
+	status := c.provider.Status()
+	breakers := make(map[string]map[string]collectors.CircuitBreakerStatus)
+	tripped := []string{}
+	burning := []string{}
+
+	for name, info := range status {
+		if info.SLO != nil && info.SLO.Burning {
+			burning = append(burning, name)
+		}
+		if len(info.CircuitBreakers) == 0 {
+			continue
+		}
+		breakers[name] = info.CircuitBreakers
+		for region, breaker := range info.CircuitBreakers {
+			if breaker.State != collectors.CircuitBreakerClosed {
+				tripped = append(tripped, fmt.Sprintf("%s/%s (%s)", name, region, breaker.State))
+			}
+		}
+	}
+	sort.Strings(tripped)
+	sort.Strings(burning)
+
+	result.Metadata["total_collectors"] = len(status)
+	result.Metadata["circuit_breakers"] = breakers
+	result.Duration = time.Since(start)
+
+	if len(tripped) > 0 || len(burning) > 0 {
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("%d circuit breakers not closed, %d collectors burning SLO error budget",
+			len(tripped), len(burning))
+		result.Metadata["tripped"] = tripped
+		result.Metadata["slo_burning"] = burning
+		return result
+	}
+
+	result.Status = StatusHealthy
+	result.Message = fmt.Sprintf("All %d collectors healthy", len(status))
+	return result
+}
+
+// CollectorHealthChecker reports the health of a single collector, so
+// /health/detailed shows which collector is failing. Collectors that are
+// running but burning their error budget or failing often are degraded; a
+// collector in the error state or stopped is unhealthy.
+type CollectorHealthChecker struct {
+	collector collectors.MetricCollector
+	name      string
+}
+
+// NewCollectorHealthChecker creates a health checker for collector
+func NewCollectorHealthChecker(collector collectors.MetricCollector) *CollectorHealthChecker {
+	return &CollectorHealthChecker{
+		collector: collector,
+		name:      CollectorCheckerName(collector.Name()),
+	}
+}
+
+// CollectorCheckerName returns the name of the health checker for a collector
+func CollectorCheckerName(collectorName string) string {
+	return "collector:" + collectorName
+}
+
+// Name returns the unique identifier for this checker
+func (c *CollectorHealthChecker) Name() string {
+	return c.name
+}
+
+// Critical returns false: a single failing collector, e.g. one lacking access
+// to Trusted Advisor, degrades the service rather than making it unhealthy
+func (c *CollectorHealthChecker) Critical() bool {
+	return false
+}
+
+// Check reports the collector's health along with its status and statistics
+func (c *CollectorHealthChecker) Check(_ context.Context) CheckResult {
+	start := time.Now()
+	info := c.collector.Info()
+	result := CheckResult{
+		Name:        c.name,
+		LastChecked: start,
+		Metadata: map[string]interface{}{
+			"collector":              info.Name,
+			"status":                 info.Status,
+			"enabled_regions":        info.EnabledRegions,
+			"metrics_collected":      info.MetricsCollected,
+			"error_count":            info.ErrorCount,
+			"successful_collections": info.SuccessfulCollections,
+		},
+	}
+	if info.LastCollection != nil {
+		result.Metadata["last_collection"] = *info.LastCollection
+	}
+	if info.LastError != nil {
+		result.Metadata["last_error_code"] = info.LastError.Code
+	}
+
+	err := c.collector.Health()
+	result.Duration = time.Since(start)
+	if err == nil {
+		result.Status = StatusHealthy
+		result.Message = fmt.Sprintf("Collector %s is healthy", info.Name)
+		return result
+	}
+
+	result.Error = err.Error()
+	result.Message = fmt.Sprintf("Collector %s: %s", info.Name, err.Error())
+	switch {
+	case info.Status == collectors.StatusError || info.Status == collectors.StatusStopped:
+		result.Status = StatusUnhealthy
+	case info.Status == collectors.StatusRunning:
+		result.Status = StatusDegraded
+	default:
+		// Starting or stopping
+		result.Status = StatusUnknown
+	}
+	return result
+}
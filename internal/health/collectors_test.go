@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"aws-monitoring/internal/collectors"
+)
+
+// stubCollectorStatus implements CollectorStatusProvider for testing
+type stubCollectorStatus map[string]collectors.CollectorInfo
+
+func (s stubCollectorStatus) Status() map[string]collectors.CollectorInfo {
+	return s
+}
+
+func TestCollectorCheckerHealthy(t *testing.T) {
+	checker := NewCollectorChecker(stubCollectorStatus{
+		"ec2": {Name: "ec2", CircuitBreakers: map[string]collectors.CircuitBreakerStatus{
+			"us-east-1": {State: collectors.CircuitBreakerClosed, FailureCount: 1},
+		}},
+		"rds": {Name: "rds"},
+	})
+
+	if checker.Name() != "collectors" {
+		t.Errorf("Expected name 'collectors', got %s", checker.Name())
+	}
+
+	result := checker.Check(context.Background())
+	if result.Status != StatusHealthy {
+		t.Errorf("Expected status healthy, got %s", result.Status)
+	}
+	if result.Metadata["total_collectors"] != 2 {
+		t.Errorf("Expected 2 collectors, got %v", result.Metadata["total_collectors"])
+	}
+}
+
+func TestCollectorCheckerOpenBreaker(t *testing.T) {
+	checker := NewCollectorChecker(stubCollectorStatus{
+		"ec2": {Name: "ec2", CircuitBreakers: map[string]collectors.CircuitBreakerStatus{
+			"us-east-1": {State: collectors.CircuitBreakerOpen, FailureCount: 5},
+			"us-west-2": {State: collectors.CircuitBreakerClosed},
+		}},
+	})
+
+	result := checker.Check(context.Background())
+	if result.Status != StatusDegraded {
+		t.Errorf("Expected status degraded, got %s", result.Status)
+	}
+
+	tripped, ok := result.Metadata["tripped"].([]string)
+	if !ok || len(tripped) != 1 || tripped[0] != "ec2/us-east-1 (open)" {
+		t.Errorf("Expected ec2/us-east-1 to be reported as tripped, got %v", result.Metadata["tripped"])
+	}
+
+	if burning, ok := result.Metadata["slo_burning"].([]string); !ok || len(burning) != 0 {
+		t.Errorf("Expected no collectors burning SLO budget, got %v", result.Metadata["slo_burning"])
+	}
+
+	breakers, ok := result.Metadata["circuit_breakers"].(map[string]map[string]collectors.CircuitBreakerStatus)
+	if !ok || breakers["ec2"]["us-east-1"].FailureCount != 5 {
+		t.Errorf("Expected breaker details in metadata, got %v", result.Metadata["circuit_breakers"])
+	}
+}
+
+func TestCollectorCheckerSLOBurning(t *testing.T) {
+	checker := NewCollectorChecker(stubCollectorStatus{
+		"ec2": {Name: "ec2", SLO: &collectors.SLOInfo{Target: 0.99, Burning: true}},
+		"rds": {Name: "rds", SLO: &collectors.SLOInfo{Target: 0.99}},
+	})
+
+	result := checker.Check(context.Background())
+	if result.Status != StatusDegraded {
+		t.Errorf("Expected status degraded, got %s", result.Status)
+	}
+
+	burning, ok := result.Metadata["slo_burning"].([]string)
+	if !ok || len(burning) != 1 || burning[0] != "ec2" {
+		t.Errorf("Expected ec2 to be reported as burning, got %v", result.Metadata["slo_burning"])
+	}
+}
+
+// stubMetricCollector is a collector with fixed info and health
+type stubMetricCollector struct {
+	info   collectors.CollectorInfo
+	health error
+}
+
+func (c *stubMetricCollector) Name() string                  { return c.info.Name }
+func (c *stubMetricCollector) Description() string           { return "stub collector" }
+func (c *stubMetricCollector) Health() error                 { return c.health }
+func (c *stubMetricCollector) Start(_ context.Context) error { return nil }
+func (c *stubMetricCollector) Stop(_ context.Context) error  { return nil }
+func (c *stubMetricCollector) Info() collectors.CollectorInfo {
+	return c.info
+}
+
+func (c *stubMetricCollector) Collect(_ context.Context, region string) *collectors.CollectionResult {
+	return &collectors.CollectionResult{CollectorName: c.info.Name, Region: region}
+}
+
+func TestCollectorHealthChecker(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   collectors.CollectorStatus
+		health   error
+		expected Status
+	}{
+		{name: "healthy", status: collectors.StatusRunning, expected: StatusHealthy},
+		{name: "failing while running", status: collectors.StatusRunning, health: errors.New("high error rate"), expected: StatusDegraded},
+		{name: "error state", status: collectors.StatusError, health: errors.New("collector is in error state"), expected: StatusUnhealthy},
+		{name: "stopped", status: collectors.StatusStopped, health: errors.New("collector is not running"), expected: StatusUnhealthy},
+		{name: "starting", status: collectors.StatusStarting, health: errors.New("collector is not running"), expected: StatusUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewCollectorHealthChecker(&stubMetricCollector{
+				info:   collectors.CollectorInfo{Name: "ec2", Status: tt.status, ErrorCount: 3},
+				health: tt.health,
+			})
+
+			if checker.Name() != "collector:ec2" {
+				t.Errorf("Expected name 'collector:ec2', got %s", checker.Name())
+			}
+
+			result := checker.Check(context.Background())
+			if result.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, result.Status)
+			}
+			if result.Metadata["error_count"] != int64(3) {
+				t.Errorf("Expected error_count 3 in metadata, got %v", result.Metadata["error_count"])
+			}
+			if tt.health != nil && result.Error != tt.health.Error() {
+				t.Errorf("Expected error %q, got %q", tt.health.Error(), result.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// errFreeSpaceUnsupported is returned by freeSpace on platforms where free
+// disk space can't be determined
+var errFreeSpaceUnsupported = errors.New("free space is not supported on this platform")
+
+// DiskChecker verifies that the directories the monitor writes to, such as
+// the log file's directory, are writable and have enough free space. An
+// unwritable directory is unhealthy; low free space is degraded.
+type DiskChecker struct {
+	paths        []string
+	minFreeBytes uint64
+	freeSpace    func(dir string) (uint64, error)
+	name         string
+}
+
+// NewDiskChecker creates a health checker for paths, which may be files or
+// directories. Files are checked through the directory containing them.
+func NewDiskChecker(paths []string, minFreeBytes uint64) *DiskChecker {
+	return &DiskChecker{
+		paths:        paths,
+		minFreeBytes: minFreeBytes,
+		freeSpace:    freeSpace,
+		name:         "disk",
+	}
+}
+
+// Name returns the unique identifier for this checker
+func (c *DiskChecker) Name() string {
+	return c.name
+}
+
+// Check verifies each path's directory is writable and has enough free space
+func (c *DiskChecker) Check(_ context.Context) CheckResult {
+	start := time.Now()
+	result := CheckResult{
+		Name:        c.name,
+		Status:      StatusHealthy,
+		LastChecked: start,
+		Metadata:    make(map[string]interface{}),
+	}
+
+	var unwritable, lowSpace []string
+	for _, path := range c.paths {
+		dir := checkedDir(path)
+		info := map[string]interface{}{"directory": dir}
+		result.Metadata[path] = info
+
+		if err := checkWritable(dir); err != nil {
+			info["writable"] = false
+			info["error"] = err.Error()
+			unwritable = append(unwritable, dir)
+			continue
+		}
+		info["writable"] = true
+
+		free, err := c.freeSpace(dir)
+		if err != nil {
+			if !errors.Is(err, errFreeSpaceUnsupported) {
+				info["error"] = err.Error()
+			}
+			continue
+		}
+		info["free_bytes"] = free
+		if free < c.minFreeBytes {
+			lowSpace = append(lowSpace, dir)
+		}
+	}
+
+	switch {
+	case len(unwritable) > 0:
+		result.Status = StatusUnhealthy
+		result.Message = fmt.Sprintf("Not writable: %s", strings.Join(unwritable, ", "))
+	case len(lowSpace) > 0:
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("Less than %d MB free: %s", c.minFreeBytes>>20, strings.Join(lowSpace, ", "))
+	default:
+		result.Message = fmt.Sprintf("%d paths writable", len(c.paths))
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// checkedDir returns path if it is a directory, otherwise its parent
+func checkedDir(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return filepath.Dir(path)
+}
+
+// checkWritable creates and removes a temporary file in dir
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".aws-monitor-health-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	closeErr := file.Close()
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package health
+
+// freeSpace is not supported on this platform; only writability is checked
+func freeSpace(string) (uint64, error) {
+	return 0, errFreeSpaceUnsupported
+}
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskChecker(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "aws-monitor.log")
+	missing := filepath.Join(dir, "missing", "aws-monitor.log")
+
+	tests := []struct {
+		name     string
+		paths    []string
+		free     uint64
+		expected Status
+	}{
+		{name: "writable", paths: []string{logFile, dir}, free: 1 << 30, expected: StatusHealthy},
+		{name: "low free space", paths: []string{logFile}, free: 1 << 20, expected: StatusDegraded},
+		{name: "not writable", paths: []string{logFile, missing}, free: 1 << 30, expected: StatusUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewDiskChecker(tt.paths, 100<<20)
+			checker.freeSpace = func(string) (uint64, error) { return tt.free, nil }
+
+			result := checker.Check(context.Background())
+			if result.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s: %s", tt.expected, result.Status, result.Message)
+			}
+
+			info, ok := result.Metadata[logFile].(map[string]interface{})
+			if !ok || info["directory"] != dir || info["writable"] != true {
+				t.Errorf("Expected %s to be checked through writable %s, got %v", logFile, dir, result.Metadata[logFile])
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected the writability check to clean up, found %d entries", len(entries))
+	}
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// Exporter connection states reported in health check metadata
+const (
+	ExporterConnected    = "connected"
+	ExporterDisconnected = "disconnected"
+	ExporterIdle         = "idle"
+)
+
+// ExportStatusProvider exposes the state of OTLP export, e.g. *logger.Logger
+type ExportStatusProvider interface {
+	ExportStats() (logger.ExportStats, bool)
+}
+
+// ExporterChecker reports the state of the OTLP exporter. It is degraded when
+// exports have been failing for longer than the failure threshold, since
+// telemetry is then being lost rather than delayed.
+type ExporterChecker struct {
+	provider         ExportStatusProvider
+	failureThreshold time.Duration
+	name             string
+}
+
+// NewExporterChecker creates a health checker for the OTLP exporter
+func NewExporterChecker(provider ExportStatusProvider, failureThreshold time.Duration) *ExporterChecker {
+	return &ExporterChecker{
+		provider:         provider,
+		failureThreshold: failureThreshold,
+		name:             "otlp_exporter",
+	}
+}
+
+// Name returns the unique identifier for this checker
+func (c *ExporterChecker) Name() string {
+	return c.name
+}
+
+// Check reports the exporter's connection state, last successful export and
+// pending queue size
+func (c *ExporterChecker) Check(_ context.Context) CheckResult {
+	start := time.Now()
+	result := CheckResult{
+		Name:        c.name,
+		LastChecked: start,
+		Metadata:    make(map[string]interface{}),
+	}
+
+	stats, ok := c.provider.ExportStats()
+	if !ok {
+		result.Status = StatusUnknown
+		result.Message = "OTLP export is disabled"
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	result.Metadata["exported"] = stats.Exported
+	result.Metadata["failed"] = stats.Failed
+	result.Metadata["pending"] = stats.Pending
+	if !stats.LastSuccess.IsZero() {
+		result.Metadata["last_success"] = stats.LastSuccess
+	}
+
+	switch {
+	case !stats.FailingSince.IsZero():
+		failingFor := start.Sub(stats.FailingSince)
+		result.Metadata["connection_state"] = ExporterDisconnected
+		result.Metadata["failing_since"] = stats.FailingSince
+		result.Error = stats.LastError
+		result.Message = fmt.Sprintf("Exports failing for %s", failingFor.Round(time.Second))
+		if failingFor > c.failureThreshold {
+			result.Status = StatusDegraded
+		} else {
+			result.Status = StatusHealthy
+		}
+	case stats.LastSuccess.IsZero():
+		result.Metadata["connection_state"] = ExporterIdle
+		result.Status = StatusHealthy
+		result.Message = "No records exported yet"
+	default:
+		result.Metadata["connection_state"] = ExporterConnected
+		result.Status = StatusHealthy
+		result.Message = "Exporter connected"
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// stubExportStatus implements ExportStatusProvider for testing
+type stubExportStatus struct {
+	stats   logger.ExportStats
+	enabled bool
+}
+
+func (s stubExportStatus) ExportStats() (logger.ExportStats, bool) {
+	return s.stats, s.enabled
+}
+
+func TestExporterChecker(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		provider stubExportStatus
+		status   Status
+		state    interface{}
+	}{
+		{
+			name:     "disabled",
+			provider: stubExportStatus{},
+			status:   StatusUnknown,
+		},
+		{
+			name:     "idle",
+			provider: stubExportStatus{enabled: true},
+			status:   StatusHealthy,
+			state:    ExporterIdle,
+		},
+		{
+			name:     "connected",
+			provider: stubExportStatus{enabled: true, stats: logger.ExportStats{Exported: 10, LastSuccess: now}},
+			status:   StatusHealthy,
+			state:    ExporterConnected,
+		},
+		{
+			name: "failing within threshold",
+			provider: stubExportStatus{enabled: true, stats: logger.ExportStats{
+				FailingSince: now.Add(-10 * time.Second), LastError: "connection refused",
+			}},
+			status: StatusHealthy,
+			state:  ExporterDisconnected,
+		},
+		{
+			name: "failing beyond threshold",
+			provider: stubExportStatus{enabled: true, stats: logger.ExportStats{
+				Pending: 42, FailingSince: now.Add(-5 * time.Minute), LastError: "connection refused",
+			}},
+			status: StatusDegraded,
+			state:  ExporterDisconnected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewExporterChecker(tt.provider, time.Minute)
+
+			result := checker.Check(context.Background())
+			if result.Status != tt.status {
+				t.Errorf("Expected status %s, got %s", tt.status, result.Status)
+			}
+			if result.Metadata["connection_state"] != tt.state {
+				t.Errorf("Expected connection state %v, got %v", tt.state, result.Metadata["connection_state"])
+			}
+			if tt.provider.enabled && result.Metadata["pending"] != tt.provider.stats.Pending {
+				t.Errorf("Expected pending %d, got %v", tt.provider.stats.Pending, result.Metadata["pending"])
+			}
+			if result.Error != tt.provider.stats.LastError {
+				t.Errorf("Expected error %q, got %q", tt.provider.stats.LastError, result.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// GRPCServer serves the standard grpc.health.v1.Health service for gRPC-native
+// load balancers and service meshes. The empty service name reports the
+// overall status; each health check is also a service, named after the check.
+// Healthy and degraded map to SERVING, anything else to NOT_SERVING.
+type GRPCServer struct {
+	manager  *Manager
+	health   *grpchealth.Server
+	server   *grpc.Server
+	listener net.Listener
+	port     int
+	tls      *tls.Config
+	logger   *logger.Logger
+
+	// mu guards services, the check names currently reported
+	mu       sync.Mutex
+	services map[string]bool
+}
+
+// NewGRPCServer creates a gRPC health server reporting the manager's status
+func NewGRPCServer(manager *Manager, port int, log *logger.Logger) *GRPCServer {
+	s := &GRPCServer{
+		manager:  manager,
+		health:   grpchealth.NewServer(),
+		port:     port,
+		logger:   log.WithComponent("grpc-health-server"),
+		services: make(map[string]bool),
+	}
+
+	// Not serving until the first checks have run
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	manager.AddListener(s.update)
+	return s
+}
+
+// SetTLSConfig serves the health service over TLS; it must be called before
+// Start
+func (s *GRPCServer) SetTLSConfig(tlsConfig *tls.Config) {
+	s.tls = tlsConfig
+}
+
+// Start listens on the configured port and serves the health service
+func (s *GRPCServer) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on gRPC health port %d: %w", s.port, err)
+	}
+	s.listener = listener
+
+	var opts []grpc.ServerOption
+	if s.tls != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tls)))
+	}
+	s.server = grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	s.update(s.manager.GetHealth())
+
+	s.logger.Info("Starting gRPC health server",
+		logger.Int("port", s.port),
+		logger.Bool("tls", s.tls != nil))
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			s.logger.Error("gRPC health server failed", logger.String("error", err.Error()))
+		}
+	}()
+
+	return nil
+}
+
+// Stop reports every service as NOT_SERVING and stops the server. Watch
+// streams never end on their own, so connections are closed immediately
+// rather than drained.
+func (s *GRPCServer) Stop() {
+	if s.server == nil {
+		return
+	}
+
+	s.logger.Info("Stopping gRPC health server")
+	s.health.Shutdown()
+	s.server.Stop()
+}
+
+// update sets the serving status of the overall service and of each check
+func (s *GRPCServer) update(health OverallHealth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.health.SetServingStatus("", servingStatus(health.Status))
+
+	current := make(map[string]bool, len(health.Checks))
+	for name, result := range health.Checks {
+		s.health.SetServingStatus(name, servingStatus(result.Status))
+		current[name] = true
+	}
+
+	// Checks that were unregistered are reported as unknown
+	for name := range s.services {
+		if !current[name] {
+			s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+		}
+	}
+	s.services = current
+}
+
+// servingStatus converts a health status to a gRPC serving status
+func servingStatus(status Status) healthpb.HealthCheckResponse_ServingStatus {
+	switch status {
+	case StatusHealthy, StatusDegraded:
+		return healthpb.HealthCheckResponse_SERVING
+	default:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+}
@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"aws-monitoring/pkg/logger"
+)
+
+func TestGRPCServer(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	server := NewGRPCServer(manager, 0, log)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start gRPC health server: %v", err)
+	}
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(server.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q) failed: %v", service, err)
+		}
+		return resp.Status
+	}
+
+	if status := check(""); status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING before checks have run, got %s", status)
+	}
+
+	checker := newMockChecker("aws_connectivity", StatusDegraded, "1 of 2 regions accessible")
+	manager.RegisterChecker(checker)
+	manager.RegisterChecker(newMockChecker("configuration", StatusHealthy, "ok"))
+	manager.RunChecks(context.Background())
+
+	if status := check(""); status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected SERVING when degraded, got %s", status)
+	}
+	if status := check("aws_connectivity"); status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected aws_connectivity to be SERVING, got %s", status)
+	}
+
+	checker.result.Status = StatusUnhealthy
+	manager.RunChecks(context.Background())
+	if status := check(""); status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING when unhealthy, got %s", status)
+	}
+
+	manager.UnregisterChecker("aws_connectivity")
+	manager.RunChecks(context.Background())
+	if status := check("aws_connectivity"); status != healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("Expected an unregistered check to be SERVICE_UNKNOWN, got %s", status)
+	}
+}
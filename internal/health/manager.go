@@ -9,33 +9,49 @@ import (
 	"aws-monitoring/pkg/logger"
 )
 
+// DefaultHistorySize is the number of results kept per checker by default
+const DefaultHistorySize = 20
+
+// defaultCheckTimeout is the timeout of checks without a configured timeout
+const defaultCheckTimeout = 10 * time.Second
+
 // Manager manages health checks and provides aggregated health status
 type Manager struct {
-	checkers   map[string]Checker
-	results    map[string]CheckResult
-	startTime  time.Time
-	version    string
-	service    string
-	logger     *logger.Logger
-	mu         sync.RWMutex
-	ctx        context.Context
-	cancel     context.CancelFunc
-	running    bool
+	checkers    map[string]Checker
+	results     map[string]CheckResult
+	history     map[string][]HistoryEntry
+	historySize int
+	listeners   []func(OverallHealth)
+	configs     map[string]CheckerConfig
+	lastRun     map[string]time.Time
+	tick        time.Duration
+	startTime   time.Time
+	version     string
+	service     string
+	logger      *logger.Logger
+	mu          sync.RWMutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+	running     bool
 }
 
 // NewManager creates a new health check manager
 func NewManager(service, version string, log *logger.Logger) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Manager{
-		checkers:  make(map[string]Checker),
-		results:   make(map[string]CheckResult),
-		startTime: time.Now(),
-		version:   version,
-		service:   service,
-		logger:    log.WithComponent("health"),
-		ctx:       ctx,
-		cancel:    cancel,
-		running:   false,
+		checkers:    make(map[string]Checker),
+		results:     make(map[string]CheckResult),
+		history:     make(map[string][]HistoryEntry),
+		historySize: DefaultHistorySize,
+		configs:     make(map[string]CheckerConfig),
+		lastRun:     make(map[string]time.Time),
+		startTime:   time.Now(),
+		version:     version,
+		service:     service,
+		logger:      log.WithComponent("health"),
+		ctx:         ctx,
+		cancel:      cancel,
+		running:     false,
 	}
 }
 
@@ -57,21 +73,72 @@ func (m *Manager) UnregisterChecker(name string) {
 	if _, exists := m.checkers[name]; exists {
 		delete(m.checkers, name)
 		delete(m.results, name)
+		delete(m.history, name)
+		delete(m.lastRun, name)
 		m.logger.Info("Health checker unregistered", logger.String("checker", name))
 	}
 }
 
-// RunChecks executes all registered health checks
+// SetCheckerConfig sets how the named checker is run. Checkers without a
+// configuration run on every round of checks with a 10 second timeout and no
+// retries. A zero Interval runs the checker on every round, and a zero Timeout
+// uses the default timeout.
+func (m *Manager) SetCheckerConfig(name string, config CheckerConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configs[name] = config
+	if !config.Enabled {
+		delete(m.results, name)
+		delete(m.history, name)
+	}
+}
+
+// checkerConfig returns the configuration of the named checker with defaults
+// applied; the caller must hold the lock
+func (m *Manager) checkerConfig(name string) CheckerConfig {
+	config, exists := m.configs[name]
+	if !exists {
+		return CheckerConfig{Enabled: true, Timeout: defaultCheckTimeout}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultCheckTimeout
+	}
+	if config.Retries > 0 && config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultCheckerConfig().RetryDelay
+	}
+	return config
+}
+
+// RunChecks executes all enabled health checks
 func (m *Manager) RunChecks(ctx context.Context) {
+	m.runChecks(ctx, time.Now(), false)
+}
+
+// runChecks executes the enabled health checks; with onlyDue set, checks
+// whose interval has not elapsed since their last run are skipped
+func (m *Manager) runChecks(ctx context.Context, now time.Time, onlyDue bool) {
 	m.mu.Lock()
 	checkers := make(map[string]Checker)
+	configs := make(map[string]CheckerConfig)
 	for name, checker := range m.checkers {
+		config := m.checkerConfig(name)
+		if !config.Enabled {
+			continue
+		}
+		// Ticks are not exact, so allow half a tick of slack
+		lastRun, ran := m.lastRun[name]
+		if onlyDue && ran && config.Interval > 0 && now.Sub(lastRun)+m.tick/2 < config.Interval {
+			continue
+		}
 		checkers[name] = checker
+		configs[name] = config
+		m.lastRun[name] = now
 	}
 	m.mu.Unlock()
 
 	if len(checkers) == 0 {
-		m.logger.Debug("No health checkers registered")
+		m.logger.Debug("No health checks to run")
 		return
 	}
 
@@ -79,25 +146,23 @@ func (m *Manager) RunChecks(ctx context.Context) {
 	resultsChan := make(chan CheckResult, len(checkers))
 
 	// Run all checks concurrently
-	for _, checker := range checkers {
+	for name, checker := range checkers {
 		wg.Add(1)
-		go func(c Checker) {
+		go func(c Checker, config CheckerConfig) {
 			defer wg.Done()
 			start := time.Now()
 			
-			checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-			defer cancel()
-			
-			result := c.Check(checkCtx)
+			result := m.runCheck(ctx, c, config)
 			result.Duration = time.Since(start)
 			result.LastChecked = start
+			result.Optional = !IsCritical(c)
 			
 			select {
 			case resultsChan <- result:
 			case <-ctx.Done():
 				return
 			}
-		}(checker)
+		}(checker, configs[name])
 	}
 
 	// Wait for all checks to complete
@@ -110,12 +175,111 @@ func (m *Manager) RunChecks(ctx context.Context) {
 	m.mu.Lock()
 	for result := range resultsChan {
 		m.results[result.Name] = result
+		m.recordHistory(result)
 		m.logger.Debug("Health check completed",
 			logger.String("checker", result.Name),
 			logger.String("status", string(result.Status)),
 			logger.Duration("duration", result.Duration))
 	}
+	listeners := m.listeners
 	m.mu.Unlock()
+
+	if len(listeners) > 0 {
+		health := m.GetHealth()
+		for _, listener := range listeners {
+			listener(health)
+		}
+	}
+}
+
+// runCheck runs a single check, retrying it while it is unhealthy up to the
+// configured number of retries
+func (m *Manager) runCheck(ctx context.Context, checker Checker, config CheckerConfig) CheckResult {
+	for attempt := 0; ; attempt++ {
+		checkCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+		result := checker.Check(checkCtx)
+		cancel()
+
+		if result.Status != StatusUnhealthy || attempt >= config.Retries {
+			return result
+		}
+
+		m.logger.Debug("Retrying unhealthy health check",
+			logger.String("checker", checker.Name()),
+			logger.Int("attempt", attempt+1))
+		select {
+		case <-time.After(config.RetryDelay):
+		case <-ctx.Done():
+			return result
+		}
+	}
+}
+
+// AddListener registers a function called with the overall health after
+// every round of checks
+func (m *Manager) AddListener(listener func(OverallHealth)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.listeners = append(m.listeners, listener)
+}
+
+// SetHistorySize sets the number of results kept per checker; zero disables
+// history
+func (m *Manager) SetHistorySize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.historySize = size
+	for name, entries := range m.history {
+		m.history[name] = trimHistory(entries, size)
+	}
+}
+
+// recordHistory appends result to its checker's history; m.mu must be held
+func (m *Manager) recordHistory(result CheckResult) {
+	if m.historySize <= 0 {
+		return
+	}
+
+	entry := HistoryEntry{
+		Status:    result.Status,
+		Message:   result.Message,
+		Error:     result.Error,
+		Timestamp: result.LastChecked,
+		Duration:  result.Duration,
+	}
+	m.history[result.Name] = trimHistory(append(m.history[result.Name], entry), m.historySize)
+}
+
+// trimHistory drops the oldest entries beyond size
+func trimHistory(entries []HistoryEntry, size int) []HistoryEntry {
+	if len(entries) <= size {
+		return entries
+	}
+	return entries[len(entries)-size:]
+}
+
+// GetHistory returns the recent results of each checker
+func (m *Manager) GetHistory() map[string]CheckHistory {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	history := make(map[string]CheckHistory, len(m.history))
+	for name, entries := range m.history {
+		results := make([]HistoryEntry, len(entries))
+		copy(results, entries)
+
+		transitions := 0
+		for i := 1; i < len(results); i++ {
+			if results[i].Status != results[i-1].Status {
+				transitions++
+			}
+		}
+
+		history[name] = CheckHistory{Name: name, Transitions: transitions, Results: results}
+	}
+	return history
 }
 
 // GetHealth returns the current overall health status
@@ -150,6 +314,7 @@ func (m *Manager) aggregateStatus(checks map[string]CheckResult) (Status, string
 
 	healthyCount := 0
 	unhealthyCount := 0
+	criticalCount := 0
 	degradedCount := 0
 	unknownCount := 0
 	totalChecks := len(checks)
@@ -160,6 +325,9 @@ func (m *Manager) aggregateStatus(checks map[string]CheckResult) (Status, string
 			healthyCount++
 		case StatusUnhealthy:
 			unhealthyCount++
+			if !result.Optional {
+				criticalCount++
+			}
 		case StatusDegraded:
 			degradedCount++
 		case StatusUnknown:
@@ -167,12 +335,13 @@ func (m *Manager) aggregateStatus(checks map[string]CheckResult) (Status, string
 		}
 	}
 
-	// Determine overall status based on individual check results
-	if unhealthyCount > 0 {
+	// Determine overall status based on individual check results; failing
+	// non-critical checks only degrade the service
+	if criticalCount > 0 {
 		return StatusUnhealthy, generateSummary(healthyCount, unhealthyCount, degradedCount, unknownCount, totalChecks)
 	}
 	
-	if degradedCount > 0 {
+	if unhealthyCount > 0 || degradedCount > 0 {
 		return StatusDegraded, generateSummary(healthyCount, unhealthyCount, degradedCount, unknownCount, totalChecks)
 	}
 	
@@ -235,6 +404,13 @@ func (m *Manager) Start(interval time.Duration) {
 		return
 	}
 	m.running = true
+	// Tick often enough for the checker with the shortest interval
+	for _, config := range m.configs {
+		if config.Enabled && config.Interval > 0 && config.Interval < interval {
+			interval = config.Interval
+		}
+	}
+	m.tick = interval
 	m.mu.Unlock()
 
 	m.logger.Info("Starting health check manager", logger.Duration("interval", interval))
@@ -248,8 +424,8 @@ func (m *Manager) Start(interval time.Duration) {
 
 		for {
 			select {
-			case <-ticker.C:
-				m.RunChecks(m.ctx)
+			case now := <-ticker.C:
+				m.runChecks(m.ctx, now, true)
 			case <-m.ctx.Done():
 				m.logger.Info("Health check manager stopped")
 				return
@@ -22,6 +22,15 @@ func (m *mockChecker) Check(_ context.Context) CheckResult {
 	return m.result
 }
 
+// optionalChecker is a mock checker that declares itself non-critical
+type optionalChecker struct {
+	*mockChecker
+}
+
+func (o optionalChecker) Critical() bool {
+	return false
+}
+
 func newMockChecker(name string, status Status, message string) *mockChecker {
 	return &mockChecker{
 		name: name,
@@ -157,6 +166,144 @@ func TestManagerRunChecks(t *testing.T) {
 	}
 }
 
+func TestManagerHistory(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	manager.SetHistorySize(3)
+
+	checker := newMockChecker("flapping", StatusHealthy, "ok")
+	manager.RegisterChecker(checker)
+
+	for _, status := range []Status{StatusHealthy, StatusUnhealthy, StatusHealthy, StatusUnhealthy} {
+		checker.result.Status = status
+		manager.RunChecks(context.Background())
+	}
+
+	history := manager.GetHistory()["flapping"]
+	if len(history.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(history.Results))
+	}
+	if history.Results[0].Status != StatusUnhealthy || history.Results[2].Status != StatusUnhealthy {
+		t.Errorf("Expected the oldest result to be dropped, got %+v", history.Results)
+	}
+	if history.Transitions != 2 {
+		t.Errorf("Expected 2 transitions, got %d", history.Transitions)
+	}
+
+	manager.UnregisterChecker("flapping")
+	if _, exists := manager.GetHistory()["flapping"]; exists {
+		t.Error("Expected history to be removed with the checker")
+	}
+}
+
+func TestManagerCriticality(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	critical := newMockChecker("credentials", StatusHealthy, "ok")
+	optional := optionalChecker{newMockChecker("trusted_advisor", StatusUnhealthy, "access denied")}
+	manager.RegisterChecker(critical)
+	manager.RegisterChecker(optional)
+
+	manager.RunChecks(context.Background())
+	health := manager.GetHealth()
+	if health.Status != StatusDegraded {
+		t.Errorf("Expected failing optional check to degrade the service, got %s", health.Status)
+	}
+	if !health.Checks["trusted_advisor"].Optional || health.Checks["credentials"].Optional {
+		t.Errorf("Expected only trusted_advisor to be optional, got %+v", health.Checks)
+	}
+
+	critical.result.Status = StatusUnhealthy
+	manager.RunChecks(context.Background())
+	if status := manager.GetHealth().Status; status != StatusUnhealthy {
+		t.Errorf("Expected failing critical check to make the service unhealthy, got %s", status)
+	}
+}
+
+func TestManagerCheckerConfig(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	manager.tick = 30 * time.Second
+	cheap := newMockChecker("cheap", StatusHealthy, "ok")
+	expensive := newMockChecker("expensive", StatusHealthy, "ok")
+	disabled := newMockChecker("disabled", StatusHealthy, "ok")
+	manager.RegisterChecker(cheap)
+	manager.RegisterChecker(expensive)
+	manager.RegisterChecker(disabled)
+	manager.SetCheckerConfig("expensive", CheckerConfig{Enabled: true, Interval: 5 * time.Minute})
+	manager.SetCheckerConfig("disabled", CheckerConfig{Enabled: false})
+
+	start := time.Now()
+	manager.runChecks(context.Background(), start, true)
+	if _, exists := manager.GetHealth().Checks["disabled"]; exists {
+		t.Error("Expected disabled checker not to run")
+	}
+
+	expensive.result.Status = StatusDegraded
+	cheap.result.Status = StatusDegraded
+	manager.runChecks(context.Background(), start.Add(30*time.Second), true)
+	checks := manager.GetHealth().Checks
+	if checks["cheap"].Status != StatusDegraded {
+		t.Errorf("Expected cheap checker to run every round, got %s", checks["cheap"].Status)
+	}
+	if checks["expensive"].Status != StatusHealthy {
+		t.Errorf("Expected expensive checker to be skipped before its interval, got %s", checks["expensive"].Status)
+	}
+
+	manager.runChecks(context.Background(), start.Add(5*time.Minute), true)
+	if status := manager.GetHealth().Checks["expensive"].Status; status != StatusDegraded {
+		t.Errorf("Expected expensive checker to run after its interval, got %s", status)
+	}
+}
+
+// flakyChecker is unhealthy for its first failures checks
+type flakyChecker struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyChecker) Name() string { return "flaky" }
+
+func (f *flakyChecker) Check(_ context.Context) CheckResult {
+	f.calls++
+	if f.calls <= f.failures {
+		return CheckResult{Name: "flaky", Status: StatusUnhealthy}
+	}
+	return CheckResult{Name: "flaky", Status: StatusHealthy}
+}
+
+func TestManagerCheckerRetries(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	checker := &flakyChecker{failures: 2}
+	manager.RegisterChecker(checker)
+	manager.SetCheckerConfig("flaky", CheckerConfig{Enabled: true, Retries: 2, RetryDelay: time.Millisecond})
+
+	manager.RunChecks(context.Background())
+	if status := manager.GetHealth().Checks["flaky"].Status; status != StatusHealthy {
+		t.Errorf("Expected check to succeed on its last retry, got %s", status)
+	}
+	if checker.calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", checker.calls)
+	}
+}
+
 func TestManagerGetHealth(t *testing.T) {
 	loggerConfig := logger.Config{
 		Level:  "debug",
@@ -241,6 +388,22 @@ func TestAggregateStatus(t *testing.T) {
 			},
 			expectedStatus: StatusUnhealthy,
 		},
+		{
+			name: "one optional unhealthy",
+			checks: map[string]CheckResult{
+				"check1": {Status: StatusHealthy},
+				"check2": {Status: StatusUnhealthy, Optional: true},
+			},
+			expectedStatus: StatusDegraded,
+		},
+		{
+			name: "optional and critical unhealthy",
+			checks: map[string]CheckResult{
+				"check1": {Status: StatusUnhealthy},
+				"check2": {Status: StatusUnhealthy, Optional: true},
+			},
+			expectedStatus: StatusUnhealthy,
+		},
 		{
 			name: "one degraded",
 			checks: map[string]CheckResult{
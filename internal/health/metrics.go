@@ -0,0 +1,164 @@
+package health
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Health check metric names. The status metrics are one-hot: for each check
+// the series of its current status is 1 and the other statuses are 0.
+const (
+	MetricCheckStatus   = "aws_monitor_healthcheck_status"
+	MetricCheckDuration = "aws_monitor_healthcheck_duration_seconds"
+	MetricStatus        = "aws_monitor_health_status"
+)
+
+// metricStatuses are the statuses reported by the status metrics
+var metricStatuses = []Status{StatusHealthy, StatusDegraded, StatusUnhealthy, StatusUnknown}
+
+// healthMetric describes a health check metric
+type healthMetric struct {
+	name        string
+	description string
+	unit        string
+}
+
+// healthMetrics are the exported metrics, in exposition order
+var healthMetrics = []healthMetric{
+	{name: MetricStatus, description: "Overall health status, 1 for the current status", unit: "Count"},
+	{name: MetricCheckStatus, description: "Health check status, 1 for the check's current status", unit: "Count"},
+	{name: MetricCheckDuration, description: "Duration of the latest run of the health check", unit: "Seconds"},
+}
+
+// metricSample is a single value of a health check metric
+type metricSample struct {
+	name   string
+	labels []attribute.KeyValue
+	value  float64
+}
+
+// healthSamples returns the metric values for health, ordered by metric and
+// check name
+func healthSamples(health OverallHealth) []metricSample {
+	names := make([]string, 0, len(health.Checks))
+	for name := range health.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	samples := make([]metricSample, 0, len(metricStatuses)*(len(names)+1)+len(names))
+	for _, status := range metricStatuses {
+		samples = append(samples, metricSample{
+			name:   MetricStatus,
+			labels: []attribute.KeyValue{attribute.String("status", string(status))},
+			value:  oneHot(health.Status == status),
+		})
+	}
+	for _, name := range names {
+		for _, status := range metricStatuses {
+			samples = append(samples, metricSample{
+				name: MetricCheckStatus,
+				labels: []attribute.KeyValue{
+					attribute.String("checker", name),
+					attribute.String("status", string(status)),
+				},
+				value: oneHot(health.Checks[name].Status == status),
+			})
+		}
+	}
+	for _, name := range names {
+		samples = append(samples, metricSample{
+			name:   MetricCheckDuration,
+			labels: []attribute.KeyValue{attribute.String("checker", name)},
+			value:  health.Checks[name].Duration.Seconds(),
+		})
+	}
+	return samples
+}
+
+func oneHot(set bool) float64 {
+	if set {
+		return 1
+	}
+	return 0
+}
+
+// MetricSample is a value of a health check metric
+type MetricSample struct {
+	Name   string
+	Unit   string
+	Labels map[string]string
+	Value  float64
+}
+
+// MetricDescription returns the description of a health check metric, or ""
+// for other metrics
+func MetricDescription(name string) string {
+	for _, m := range healthMetrics {
+		if m.name == name {
+			return m.description
+		}
+	}
+	return ""
+}
+
+// MetricSamples returns the values of the health check metrics for health,
+// so they can be exported with the application's own metrics
+func MetricSamples(health OverallHealth) []MetricSample {
+	units := make(map[string]string, len(healthMetrics))
+	for _, m := range healthMetrics {
+		units[m.name] = m.unit
+	}
+
+	samples := healthSamples(health)
+	metrics := make([]MetricSample, 0, len(samples))
+	for _, sample := range samples {
+		labels := make(map[string]string, len(sample.labels))
+		for _, label := range sample.labels {
+			labels[string(label.Key)] = label.Value.AsString()
+		}
+		metrics = append(metrics, MetricSample{
+			Name:   sample.name,
+			Unit:   units[sample.name],
+			Labels: labels,
+			Value:  sample.value,
+		})
+	}
+	return metrics
+}
+
+// writePrometheusMetrics writes the metric values for health in the
+// Prometheus text exposition format
+func writePrometheusMetrics(w io.Writer, health OverallHealth) error {
+	samples := healthSamples(health)
+	var b strings.Builder
+	for _, m := range healthMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.description, m.name)
+		for _, sample := range samples {
+			if sample.name != m.name {
+				continue
+			}
+			b.WriteString(sample.name)
+			b.WriteByte('{')
+			for i, label := range sample.labels {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=\"%s\"", label.Key, escapeLabelValue(label.Value.AsString()))
+			}
+			fmt.Fprintf(&b, "} %g\n", sample.value)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
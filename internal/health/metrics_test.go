@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aws-monitoring/pkg/logger"
+)
+
+func newMetricsManager(t *testing.T) *Manager {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	manager.RegisterChecker(newMockChecker("aws_connectivity", StatusHealthy, "ok"))
+	manager.RegisterChecker(newMockChecker("disk", StatusDegraded, "low on space"))
+	manager.RunChecks(context.Background())
+	return manager
+}
+
+func TestMetricSamples(t *testing.T) {
+	manager := newMetricsManager(t)
+
+	values := make(map[string]float64)
+	units := make(map[string]string)
+	for _, sample := range MetricSamples(manager.GetHealth()) {
+		key := sample.Name
+		if checker, ok := sample.Labels["checker"]; ok {
+			key += ",checker=" + checker
+		}
+		if status, ok := sample.Labels["status"]; ok {
+			key += ",status=" + status
+		}
+		values[key] = sample.Value
+		units[sample.Name] = sample.Unit
+	}
+
+	expected := map[string]float64{
+		MetricStatus + ",status=degraded":                              1,
+		MetricStatus + ",status=healthy":                               0,
+		MetricCheckStatus + ",checker=disk,status=degraded":            1,
+		MetricCheckStatus + ",checker=disk,status=healthy":             0,
+		MetricCheckStatus + ",checker=aws_connectivity,status=healthy": 1,
+	}
+	for key, value := range expected {
+		got, exists := values[key]
+		if !exists {
+			t.Errorf("Expected a sample for %s", key)
+			continue
+		}
+		if got != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, got)
+		}
+	}
+	if _, exists := values[MetricCheckDuration+",checker=disk"]; !exists {
+		t.Error("Expected the duration of the disk check to be reported")
+	}
+	if units[MetricCheckDuration] != "Seconds" || units[MetricStatus] != "Count" {
+		t.Errorf("Unexpected units: %v", units)
+	}
+	if MetricDescription(MetricCheckStatus) == "" || MetricDescription("other") != "" {
+		t.Error("Expected descriptions for the health check metrics only")
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	manager := newMetricsManager(t)
+	server := NewServer(manager, 8080, manager.logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.handleMetrics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Expected a text/plain content type, got %s", contentType)
+	}
+
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE aws_monitor_healthcheck_status gauge",
+		`aws_monitor_health_status{status="degraded"} 1`,
+		`aws_monitor_healthcheck_status{checker="disk",status="degraded"} 1`,
+		`aws_monitor_healthcheck_status{checker="disk",status="healthy"} 0`,
+		`aws_monitor_healthcheck_status{checker="aws_connectivity",status="healthy"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if got := escapeLabelValue("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("Expected escaped label value, got %s", got)
+	}
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/pkg/logger"
+)
+
+// AlertNotifier delivers alerts, e.g. *alerting.Notifier
+type AlertNotifier interface {
+	Notify(alert alerting.Alert) bool
+}
+
+// StatusNotifier sends an alert when the overall health status changes. A new
+// status must persist for the debounce period before it is reported, so a
+// status that flaps between rounds of checks does not flood the alert sinks.
+type StatusNotifier struct {
+	notifier AlertNotifier
+	debounce time.Duration
+	logger   *logger.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	reported Status
+	pending  Status
+	since    time.Time
+}
+
+// NewStatusNotifier creates a notifier for overall health status changes.
+// The status is assumed to start out healthy, so only problems and the
+// recovery from them are reported.
+func NewStatusNotifier(notifier AlertNotifier, debounce time.Duration, log *logger.Logger) *StatusNotifier {
+	return &StatusNotifier{
+		notifier: notifier,
+		debounce: debounce,
+		logger:   log.WithComponent("health-notifier"),
+		now:      time.Now,
+		reported: StatusHealthy,
+	}
+}
+
+// Observe records the overall health after a round of checks and sends an
+// alert once a changed status has persisted for the debounce period. It is
+// meant to be registered with Manager.AddListener.
+func (n *StatusNotifier) Observe(health OverallHealth) {
+	// Unknown means the checks have not run yet, not a change worth reporting
+	if health.Status == StatusUnknown {
+		return
+	}
+
+	n.mu.Lock()
+	now := n.now()
+	if health.Status == n.reported {
+		n.pending = ""
+		n.mu.Unlock()
+		return
+	}
+	if health.Status != n.pending {
+		n.pending = health.Status
+		n.since = now
+	}
+	if now.Sub(n.since) < n.debounce {
+		n.mu.Unlock()
+		return
+	}
+
+	previous := n.reported
+	n.reported = health.Status
+	n.pending = ""
+	n.mu.Unlock()
+
+	alert := alerting.NewHealthAlert(string(previous), string(health.Status), failingChecks(health))
+	n.logger.Info("Health status changed",
+		logger.String("previous_status", string(previous)),
+		logger.String("status", string(health.Status)),
+		logger.Bool("notified", n.notifier.Notify(alert)))
+}
+
+// failingChecks returns the sorted names of the checks that are degraded or
+// unhealthy
+func failingChecks(health OverallHealth) []string {
+	var failing []string
+	for name, result := range health.Checks {
+		if result.Status == StatusDegraded || result.Status == StatusUnhealthy {
+			failing = append(failing, name)
+		}
+	}
+	sort.Strings(failing)
+	return failing
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/pkg/logger"
+)
+
+// recordingNotifier records the alerts it is asked to send
+type recordingNotifier struct {
+	alerts []alerting.Alert
+}
+
+func (n *recordingNotifier) Notify(alert alerting.Alert) bool {
+	n.alerts = append(n.alerts, alert)
+	return true
+}
+
+func overallHealth(status Status, checks map[string]Status) OverallHealth {
+	health := OverallHealth{Status: status, Checks: make(map[string]CheckResult)}
+	for name, checkStatus := range checks {
+		health.Checks[name] = CheckResult{Name: name, Status: checkStatus}
+	}
+	return health
+}
+
+func TestStatusNotifier(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	recorder := &recordingNotifier{}
+	notifier := NewStatusNotifier(recorder, time.Minute, log)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	notifier.now = func() time.Time { return now }
+
+	degraded := overallHealth(StatusDegraded, map[string]Status{
+		"heap": StatusDegraded, "disk": StatusDegraded, "config": StatusHealthy,
+	})
+	healthy := overallHealth(StatusHealthy, map[string]Status{"heap": StatusHealthy})
+
+	notifier.Observe(overallHealth(StatusUnknown, nil))
+	notifier.Observe(healthy)
+	if len(recorder.alerts) != 0 {
+		t.Fatalf("Expected no alerts while healthy, got %d", len(recorder.alerts))
+	}
+
+	// A status that flaps back within the debounce period is not reported
+	notifier.Observe(degraded)
+	now = now.Add(30 * time.Second)
+	notifier.Observe(healthy)
+	now = now.Add(30 * time.Second)
+	notifier.Observe(degraded)
+	if len(recorder.alerts) != 0 {
+		t.Fatalf("Expected flapping status to be debounced, got %d alerts", len(recorder.alerts))
+	}
+
+	now = now.Add(time.Minute)
+	notifier.Observe(degraded)
+	if len(recorder.alerts) != 1 {
+		t.Fatalf("Expected 1 alert after the debounce period, got %d", len(recorder.alerts))
+	}
+	alert := recorder.alerts[0]
+	if alert.PreviousStatus != "healthy" || alert.Status != "degraded" {
+		t.Errorf("Expected change from healthy to degraded, got %s to %s", alert.PreviousStatus, alert.Status)
+	}
+	if len(alert.Checks) != 2 || alert.Checks[0] != "disk" || alert.Checks[1] != "heap" {
+		t.Errorf("Expected failing checks [disk heap], got %v", alert.Checks)
+	}
+
+	notifier.Observe(degraded)
+	if len(recorder.alerts) != 1 {
+		t.Errorf("Expected unchanged status not to be reported again, got %d alerts", len(recorder.alerts))
+	}
+
+	notifier.Observe(healthy)
+	now = now.Add(time.Minute)
+	notifier.Observe(healthy)
+	if len(recorder.alerts) != 2 {
+		t.Fatalf("Expected recovery to be reported, got %d alerts", len(recorder.alerts))
+	}
+	if recorder.alerts[1].Status != "healthy" {
+		t.Errorf("Expected recovery to healthy, got %s", recorder.alerts[1].Status)
+	}
+}
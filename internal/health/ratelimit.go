@@ -0,0 +1,117 @@
+package health
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// rateLimitPruneInterval is how often idle clients are dropped
+const rateLimitPruneInterval = time.Minute
+
+// RateLimiter limits the request rate of each client with a token bucket, so
+// an aggressive uptime checker cannot flood the health check port
+type RateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+// tokenBucket holds a client's remaining requests
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing each client perSecond requests per
+// second on average, with bursts of up to burst requests. perSecond must be
+// positive.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    perSecond,
+		burst:   float64(burst),
+		now:     time.Now,
+		clients: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow reports whether client may make a request now
+func (l *RateLimiter) Allow(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) >= rateLimitPruneInterval {
+		l.prune(now)
+	}
+
+	bucket, exists := l.clients[client]
+	if !exists {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.clients[client] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// prune drops the buckets of clients idle long enough to have refilled, which
+// behave exactly like new clients
+func (l *RateLimiter) prune(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for client, bucket := range l.clients {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.clients, client)
+		}
+	}
+	l.lastPrune = now
+}
+
+// RequireRateLimit wraps next so requests beyond the limiter's rate are
+// rejected with 429 Too Many Requests. Clients are identified by their IP
+// address. A nil limiter returns next unchanged.
+func RequireRateLimit(limiter *RateLimiter, next http.Handler, log *logger.Logger) http.Handler {
+	if limiter == nil {
+		return next
+	}
+
+	retryAfter := strconv.Itoa(int(1/limiter.rate) + 1)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+
+		if !limiter.Allow(client) {
+			// Logged at debug level: the point is to keep aggressive
+			// checkers from adding log noise
+			log.Debug("Health check request rate limited",
+				logger.String("client", client),
+				logger.String("path", r.URL.Path))
+			w.Header().Set("Retry-After", retryAfter)
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
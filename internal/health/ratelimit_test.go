@@ -0,0 +1,66 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(1, 2)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	if !limiter.Allow("10.0.0.1") || !limiter.Allow("10.0.0.1") {
+		t.Fatal("Expected the burst to be allowed")
+	}
+	if limiter.Allow("10.0.0.1") {
+		t.Error("Expected a request beyond the burst to be rejected")
+	}
+	if !limiter.Allow("10.0.0.2") {
+		t.Error("Expected another client to have its own limit")
+	}
+
+	now = now.Add(time.Second)
+	if !limiter.Allow("10.0.0.1") {
+		t.Error("Expected a request to be allowed after the bucket refilled")
+	}
+	if limiter.Allow("10.0.0.1") {
+		t.Error("Expected only one token to be refilled after one second")
+	}
+
+	now = now.Add(2 * rateLimitPruneInterval)
+	limiter.Allow("10.0.0.3")
+	if len(limiter.clients) != 1 {
+		t.Errorf("Expected idle clients to be pruned, got %d clients", len(limiter.clients))
+	}
+}
+
+func TestRequireRateLimit(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := RequireRateLimit(NewRateLimiter(1, 1), ok, log)
+
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.RemoteAddr = "192.0.2.1:12345"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Error("Expected a Retry-After header on rejected requests")
+		}
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("Expected status codes [200 429], got %v", codes)
+	}
+}
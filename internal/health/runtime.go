@@ -0,0 +1,225 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	// heapDegradedRatio is the fraction of the heap limit above which the
+	// heap checker reports degraded
+	heapDegradedRatio = 0.9
+	// gcPauseWindow is the number of recent GC pauses averaged by the GC
+	// checker; the trend compares them with the window before
+	gcPauseWindow = 8
+)
+
+// GC pause trends reported in health check metadata
+const (
+	TrendRising  = "rising"
+	TrendFalling = "falling"
+	TrendStable  = "stable"
+)
+
+// GoroutineChecker reports degraded when the number of goroutines exceeds a
+// limit, which usually means goroutines are leaking
+type GoroutineChecker struct {
+	maxGoroutines int
+	numGoroutine  func() int
+	name          string
+}
+
+// NewGoroutineChecker creates a goroutine count health checker
+func NewGoroutineChecker(maxGoroutines int) *GoroutineChecker {
+	return &GoroutineChecker{
+		maxGoroutines: maxGoroutines,
+		numGoroutine:  runtime.NumGoroutine,
+		name:          "goroutines",
+	}
+}
+
+// Name returns the unique identifier for this checker
+func (c *GoroutineChecker) Name() string {
+	return c.name
+}
+
+// Check compares the goroutine count with the limit
+func (c *GoroutineChecker) Check(_ context.Context) CheckResult {
+	start := time.Now()
+	count := c.numGoroutine()
+	result := CheckResult{
+		Name:        c.name,
+		Status:      StatusHealthy,
+		Message:     fmt.Sprintf("%d goroutines", count),
+		LastChecked: start,
+		Metadata: map[string]interface{}{
+			"goroutines": count,
+			"limit":      c.maxGoroutines,
+		},
+	}
+
+	if c.maxGoroutines > 0 && count > c.maxGoroutines {
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("%d goroutines exceed the limit of %d", count, c.maxGoroutines)
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// HeapChecker reports degraded when heap usage approaches a limit, before the
+// process is killed for running out of memory
+type HeapChecker struct {
+	limit        uint64
+	readMemStats func(*runtime.MemStats)
+	name         string
+}
+
+// NewHeapChecker creates a heap usage health checker. A limitBytes of zero
+// uses the GOMEMLIMIT soft memory limit, if one is set; otherwise heap usage
+// is only reported.
+func NewHeapChecker(limitBytes uint64) *HeapChecker {
+	if limitBytes == 0 {
+		if memLimit := debug.SetMemoryLimit(-1); memLimit > 0 && memLimit < math.MaxInt64 {
+			limitBytes = uint64(memLimit)
+		}
+	}
+	return &HeapChecker{
+		limit:        limitBytes,
+		readMemStats: runtime.ReadMemStats,
+		name:         "heap",
+	}
+}
+
+// Name returns the unique identifier for this checker
+func (c *HeapChecker) Name() string {
+	return c.name
+}
+
+// Check compares heap usage with the limit
+func (c *HeapChecker) Check(_ context.Context) CheckResult {
+	start := time.Now()
+	var stats runtime.MemStats
+	c.readMemStats(&stats)
+
+	result := CheckResult{
+		Name:        c.name,
+		Status:      StatusHealthy,
+		Message:     fmt.Sprintf("Heap in use: %d MB", stats.HeapAlloc>>20),
+		LastChecked: start,
+		Metadata: map[string]interface{}{
+			"heap_alloc_bytes": stats.HeapAlloc,
+			"heap_sys_bytes":   stats.HeapSys,
+			"sys_bytes":        stats.Sys,
+		},
+	}
+
+	if c.limit > 0 {
+		usage := float64(stats.HeapAlloc) / float64(c.limit)
+		result.Metadata["limit_bytes"] = c.limit
+		result.Metadata["usage_ratio"] = usage
+		result.Message = fmt.Sprintf("Heap in use: %d of %d MB (%.0f%%)", stats.HeapAlloc>>20, c.limit>>20, usage*100)
+		if usage >= heapDegradedRatio {
+			result.Status = StatusDegraded
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// GCChecker reports degraded when recent garbage collection pauses are long,
+// a sign the heap is under pressure
+type GCChecker struct {
+	threshold    time.Duration
+	readMemStats func(*runtime.MemStats)
+	name         string
+}
+
+// NewGCChecker creates a GC pause health checker
+func NewGCChecker(threshold time.Duration) *GCChecker {
+	return &GCChecker{
+		threshold:    threshold,
+		readMemStats: runtime.ReadMemStats,
+		name:         "gc_pauses",
+	}
+}
+
+// Name returns the unique identifier for this checker
+func (c *GCChecker) Name() string {
+	return c.name
+}
+
+// Check compares the average of the most recent GC pauses with the threshold
+// and reports whether pauses are getting longer
+func (c *GCChecker) Check(_ context.Context) CheckResult {
+	start := time.Now()
+	var stats runtime.MemStats
+	c.readMemStats(&stats)
+
+	result := CheckResult{
+		Name:        c.name,
+		Status:      StatusHealthy,
+		LastChecked: start,
+		Metadata: map[string]interface{}{
+			"num_gc":         stats.NumGC,
+			"gc_cpu_percent": stats.GCCPUFraction * 100,
+			"threshold":      c.threshold.String(),
+		},
+	}
+
+	recent := averagePause(&stats, 0)
+	previous := averagePause(&stats, gcPauseWindow)
+	trend := pauseTrend(recent, previous)
+	result.Metadata["recent_avg_pause"] = recent.String()
+	result.Metadata["trend"] = trend
+	result.Message = fmt.Sprintf("Average recent GC pause %s (%s)", recent, trend)
+
+	if c.threshold > 0 && recent > c.threshold {
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("Average recent GC pause %s exceeds %s (%s)", recent, c.threshold, trend)
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// averagePause averages up to gcPauseWindow pauses, starting skip collections
+// before the most recent one
+func averagePause(stats *runtime.MemStats, skip uint32) time.Duration {
+	if stats.NumGC <= skip {
+		return 0
+	}
+
+	count := stats.NumGC - skip
+	if count > gcPauseWindow {
+		count = gcPauseWindow
+	}
+
+	var total uint64
+	for i := uint32(0); i < count; i++ {
+		// PauseNs is a circular buffer; the most recent pause is at
+		// (NumGC+255)%256
+		total += stats.PauseNs[(stats.NumGC-skip-i+255)%uint32(len(stats.PauseNs))]
+	}
+	return time.Duration(total / uint64(count))
+}
+
+// pauseTrend compares the recent average pause with the previous one,
+// ignoring changes of less than 25%
+func pauseTrend(recent, previous time.Duration) string {
+	switch {
+	case previous == 0:
+		return TrendStable
+	case recent > previous+previous/4:
+		return TrendRising
+	case recent < previous-previous/4:
+		return TrendFalling
+	default:
+		return TrendStable
+	}
+}
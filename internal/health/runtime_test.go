@@ -0,0 +1,122 @@
+package health
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// memStats returns a readMemStats function reporting stats
+func memStats(stats runtime.MemStats) func(*runtime.MemStats) {
+	return func(m *runtime.MemStats) { *m = stats }
+}
+
+// withPauses returns stats whose most recent GC pauses are pauses, oldest first
+func withPauses(pauses ...time.Duration) runtime.MemStats {
+	var stats runtime.MemStats
+	for _, pause := range pauses {
+		stats.PauseNs[stats.NumGC%uint32(len(stats.PauseNs))] = uint64(pause)
+		stats.NumGC++
+	}
+	return stats
+}
+
+func TestGoroutineChecker(t *testing.T) {
+	checker := NewGoroutineChecker(100)
+	if checker.Name() != "goroutines" {
+		t.Errorf("Expected name 'goroutines', got %s", checker.Name())
+	}
+
+	checker.numGoroutine = func() int { return 50 }
+	if result := checker.Check(context.Background()); result.Status != StatusHealthy {
+		t.Errorf("Expected status healthy, got %s", result.Status)
+	}
+
+	checker.numGoroutine = func() int { return 150 }
+	result := checker.Check(context.Background())
+	if result.Status != StatusDegraded {
+		t.Errorf("Expected status degraded, got %s", result.Status)
+	}
+	if result.Metadata["goroutines"] != 150 {
+		t.Errorf("Expected 150 goroutines in metadata, got %v", result.Metadata["goroutines"])
+	}
+}
+
+func TestHeapChecker(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     uint64
+		heapAlloc uint64
+		expected  Status
+	}{
+		{name: "below limit", limit: 100 << 20, heapAlloc: 50 << 20, expected: StatusHealthy},
+		{name: "near limit", limit: 100 << 20, heapAlloc: 95 << 20, expected: StatusDegraded},
+		{name: "no limit", heapAlloc: 500 << 20, expected: StatusHealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &HeapChecker{
+				limit:        tt.limit,
+				readMemStats: memStats(runtime.MemStats{HeapAlloc: tt.heapAlloc}),
+				name:         "heap",
+			}
+
+			result := checker.Check(context.Background())
+			if result.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, result.Status)
+			}
+			if result.Metadata["heap_alloc_bytes"] != tt.heapAlloc {
+				t.Errorf("Expected heap_alloc_bytes %d, got %v", tt.heapAlloc, result.Metadata["heap_alloc_bytes"])
+			}
+		})
+	}
+}
+
+func TestGCChecker(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name     string
+		stats    runtime.MemStats
+		expected Status
+		trend    string
+	}{
+		{name: "no collections", stats: runtime.MemStats{}, expected: StatusHealthy, trend: TrendStable},
+		{
+			name:     "short pauses",
+			stats:    withPauses(ms, ms, ms, ms, ms, ms, ms, ms, ms, ms, ms, ms, ms, ms, ms, ms),
+			expected: StatusHealthy,
+			trend:    TrendStable,
+		},
+		{
+			name: "long rising pauses",
+			stats: withPauses(ms, ms, ms, ms, ms, ms, ms, ms,
+				200*ms, 200*ms, 200*ms, 200*ms, 200*ms, 200*ms, 200*ms, 200*ms),
+			expected: StatusDegraded,
+			trend:    TrendRising,
+		},
+		{
+			name: "falling pauses",
+			stats: withPauses(40*ms, 40*ms, 40*ms, 40*ms, 40*ms, 40*ms, 40*ms, 40*ms,
+				ms, ms, ms, ms, ms, ms, ms, ms),
+			expected: StatusHealthy,
+			trend:    TrendFalling,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewGCChecker(100 * ms)
+			checker.readMemStats = memStats(tt.stats)
+
+			result := checker.Check(context.Background())
+			if result.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, result.Status)
+			}
+			if result.Metadata["trend"] != tt.trend {
+				t.Errorf("Expected trend %s, got %v", tt.trend, result.Metadata["trend"])
+			}
+		})
+	}
+}
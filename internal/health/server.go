@@ -2,9 +2,11 @@ package health
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"aws-monitoring/pkg/logger"
@@ -15,7 +17,17 @@ type Server struct {
 	manager *Manager
 	logger  *logger.Logger
 	server  *http.Server
+	mux     *http.ServeMux
 	port    int
+	startup *Startup
+	tls     *tls.Config
+	auth    map[EndpointGroup]Credentials
+	limiter *RateLimiter
+
+	cacheTTL time.Duration
+	cacheMu  sync.Mutex
+	cached   OverallHealth
+	cachedAt time.Time
 }
 
 // NewServer creates a new health check HTTP server
@@ -23,19 +35,24 @@ func NewServer(manager *Manager, port int, log *logger.Logger) *Server {
 	return &Server{
 		manager: manager,
 		logger:  log.WithComponent("health-server"),
+		mux:     http.NewServeMux(),
 		port:    port,
+		auth:    make(map[EndpointGroup]Credentials),
 	}
 }
 
 // Start starts the health check HTTP server
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
+	mux := s.mux
 	
 	// Register health check endpoints
-	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/health/live", s.handleLiveness)
-	mux.HandleFunc("/health/ready", s.handleReadiness)
-	mux.HandleFunc("/health/detailed", s.handleDetailedHealth)
+	mux.Handle("/health", s.withAuth(GroupProbes, s.handleHealth))
+	mux.Handle("/health/live", s.withAuth(GroupProbes, s.handleLiveness))
+	mux.Handle("/health/ready", s.withAuth(GroupProbes, s.handleReadiness))
+	mux.Handle("/health/startup", s.withAuth(GroupProbes, s.handleStartup))
+	mux.Handle("/health/detailed", s.withAuth(GroupDetailed, s.handleDetailedHealth))
+	mux.Handle("/health/history", s.withAuth(GroupDetailed, s.handleHistory))
+	mux.Handle("/metrics", s.withAuth(GroupProbes, s.handleMetrics))
 
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.port),
@@ -43,12 +60,22 @@ func (s *Server) Start() error {
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		TLSConfig:    s.tls,
 	}
 
-	s.logger.Info("Starting health check server", logger.Int("port", s.port))
+	s.logger.Info("Starting health check server",
+		logger.Int("port", s.port),
+		logger.Bool("tls", s.tls != nil))
 
 	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.tls != nil {
+			// The certificates are already loaded into TLSConfig
+			err = s.server.ListenAndServeTLS("", "")
+		} else {
+			err = s.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			s.logger.Error("Health check server failed", logger.String("error", err.Error()))
 		}
 	}()
@@ -56,6 +83,67 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// Handle registers an additional handler, such as the admin API, on the server.
+// It may be called before or after Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// SetTLSConfig serves the health check port over HTTPS; it must be called
+// before Start
+func (s *Server) SetTLSConfig(tlsConfig *tls.Config) {
+	s.tls = tlsConfig
+}
+
+// SetAuth requires credentials for a group of endpoints; it must be called
+// before Start
+func (s *Server) SetAuth(group EndpointGroup, credentials Credentials) {
+	s.auth[group] = credentials
+}
+
+// SetRateLimiter limits the request rate of each client on the health
+// endpoints; it must be called before Start
+func (s *Server) SetRateLimiter(limiter *RateLimiter) {
+	s.limiter = limiter
+}
+
+// SetCacheTTL reuses the overall health for ttl between requests instead of
+// aggregating the check results on every request; zero disables caching
+func (s *Server) SetCacheTTL(ttl time.Duration) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.cacheTTL = ttl
+	s.cachedAt = time.Time{}
+}
+
+// withAuth wraps handler with the rate limiter and the credentials of group.
+// Rate limiting comes first so it also slows down credential guessing.
+func (s *Server) withAuth(group EndpointGroup, handler http.HandlerFunc) http.Handler {
+	return RequireRateLimit(s.limiter, RequireAuth(s.auth[group], handler, s.logger), s.logger)
+}
+
+// getHealth returns the overall health, cached for the cache TTL
+func (s *Server) getHealth() OverallHealth {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if s.cacheTTL <= 0 {
+		return s.manager.GetHealth()
+	}
+	if s.cachedAt.IsZero() || time.Since(s.cachedAt) >= s.cacheTTL {
+		s.cached = s.manager.GetHealth()
+		s.cachedAt = time.Now()
+	}
+	return s.cached
+}
+
+// SetStartup sets the startup steps reported by /health/startup. Without
+// them, the application is reported as started.
+func (s *Server) SetStartup(startup *Startup) {
+	s.startup = startup
+}
+
 // Stop gracefully stops the health check HTTP server
 func (s *Server) Stop(ctx context.Context) error {
 	if s.server == nil {
@@ -73,7 +161,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	health := s.manager.GetHealth()
+	health := s.getHealth()
 	
 	// Set status code based on health
 	statusCode := s.statusToHTTPCode(health.Status)
@@ -121,7 +209,7 @@ func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	health := s.manager.GetHealth()
+	health := s.getHealth()
 	
 	// Readiness check - verify the application can serve traffic
 	// We consider the app ready if it's not unhealthy
@@ -153,7 +241,7 @@ func (s *Server) handleDetailedHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	health := s.manager.GetHealth()
+	health := s.getHealth()
 	
 	statusCode := s.statusToHTTPCode(health.Status)
 	
@@ -165,6 +253,76 @@ func (s *Server) handleDetailedHealth(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleStartup provides a startup probe endpoint that succeeds once every
+// startup step has completed
+func (s *Server) handleStartup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := StartupStatus{Started: true, Completed: map[string]time.Time{}, Pending: []string{}}
+	if s.startup != nil {
+		status = s.startup.Status()
+	}
+
+	statusCode := http.StatusOK
+	if !status.Started {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		s.logger.Error("Failed to encode startup response", logger.String("error", err.Error()))
+	}
+}
+
+// handleHistory provides the recent results of each health check, or of the
+// check named by the "check" query parameter, so flapping checks stand out
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	history := s.manager.GetHistory()
+
+	var response interface{} = history
+	if name := r.URL.Query().Get("check"); name != "" {
+		checkHistory, exists := history[name]
+		if !exists {
+			http.Error(w, fmt.Sprintf("No history for check %s", name), http.StatusNotFound)
+			return
+		}
+		response = checkHistory
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.logger.Error("Failed to encode health history response", logger.String("error", err.Error()))
+	}
+}
+
+// handleMetrics serves the health check results in the Prometheus text
+// exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writePrometheusMetrics(w, s.getHealth()); err != nil {
+		s.logger.Error("Failed to write health metrics", logger.String("error", err.Error()))
+	}
+}
+
 // statusToHTTPCode converts health status to appropriate HTTP status code
 func (s *Server) statusToHTTPCode(status Status) int {
 	switch status {
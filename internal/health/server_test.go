@@ -254,6 +254,113 @@ func TestDetailedHealthEndpoint(t *testing.T) {
 	}
 }
 
+func TestServerHealthCache(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	server := NewServer(manager, 8080, log)
+	server.SetCacheTTL(time.Hour)
+
+	checker := newMockChecker("checker1", StatusHealthy, "All good")
+	manager.RegisterChecker(checker)
+	manager.RunChecks(context.Background())
+
+	if status := server.getHealth().Status; status != StatusHealthy {
+		t.Fatalf("Expected status healthy, got %s", status)
+	}
+
+	checker.result.Status = StatusUnhealthy
+	manager.RunChecks(context.Background())
+	if status := server.getHealth().Status; status != StatusHealthy {
+		t.Errorf("Expected the cached status healthy within the TTL, got %s", status)
+	}
+
+	server.SetCacheTTL(0)
+	if status := server.getHealth().Status; status != StatusUnhealthy {
+		t.Errorf("Expected status unhealthy without caching, got %s", status)
+	}
+}
+
+func TestStartupEndpoint(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	server := NewServer(NewManager("test-service", "1.0.0", log), 8080, log)
+
+	w := httptest.NewRecorder()
+	server.handleStartup(w, httptest.NewRequest(http.MethodGet, "/health/startup", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without startup steps, got %d", w.Code)
+	}
+
+	startup := NewStartup(StartupConfigLoaded, StartupAWSCredentials)
+	server.SetStartup(startup)
+	startup.Complete(StartupConfigLoaded)
+
+	w = httptest.NewRecorder()
+	server.handleStartup(w, httptest.NewRequest(http.MethodGet, "/health/startup", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while starting, got %d", w.Code)
+	}
+	var status StartupStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || len(status.Pending) != 1 {
+		t.Errorf("Expected 1 pending step, got %+v (%v)", status, err)
+	}
+
+	startup.Complete(StartupAWSCredentials)
+	w = httptest.NewRecorder()
+	server.handleStartup(w, httptest.NewRequest(http.MethodGet, "/health/startup", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once started, got %d", w.Code)
+	}
+}
+
+func TestHistoryEndpoint(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	manager := NewManager("test-service", "1.0.0", log)
+	server := NewServer(manager, 8080, log)
+
+	manager.RegisterChecker(newMockChecker("checker1", StatusHealthy, "All good"))
+	manager.RunChecks(context.Background())
+	manager.RunChecks(context.Background())
+
+	w := httptest.NewRecorder()
+	server.handleHistory(w, httptest.NewRequest(http.MethodGet, "/health/history", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response map[string]CheckHistory
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response["checker1"].Results) != 2 {
+		t.Errorf("Expected 2 results for checker1, got %d", len(response["checker1"].Results))
+	}
+
+	w = httptest.NewRecorder()
+	server.handleHistory(w, httptest.NewRequest(http.MethodGet, "/health/history?check=checker1", nil))
+	var checkHistory CheckHistory
+	if err := json.Unmarshal(w.Body.Bytes(), &checkHistory); err != nil || checkHistory.Name != "checker1" {
+		t.Errorf("Expected history of checker1, got %+v (%v)", checkHistory, err)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleHistory(w, httptest.NewRequest(http.MethodGet, "/health/history?check=missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown check, got %d", w.Code)
+	}
+}
+
 func TestStatusToHTTPCode(t *testing.T) {
 	loggerConfig := logger.Config{
 		Level:  "debug",
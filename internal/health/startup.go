@@ -0,0 +1,73 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// Startup steps tracked for the startup probe
+const (
+	// StartupConfigLoaded completes once the configuration has been loaded
+	StartupConfigLoaded = "config_loaded"
+	// StartupAWSCredentials completes once AWS credentials have been validated
+	StartupAWSCredentials = "aws_credentials"
+	// StartupExporterConnected completes once the first OTLP export succeeded
+	StartupExporterConnected = "exporter_connected"
+)
+
+// Startup tracks the steps that must complete before the application has
+// started. Unlike readiness it never reverts, so it suits a Kubernetes
+// startupProbe that holds off liveness checks during a slow start.
+type Startup struct {
+	mu        sync.RWMutex
+	steps     []string
+	completed map[string]time.Time
+}
+
+// StartupStatus reports the progress of startup
+type StartupStatus struct {
+	// Started is true once every step has completed
+	Started bool `json:"started"`
+	// Completed maps completed steps to when they completed
+	Completed map[string]time.Time `json:"completed"`
+	// Pending lists the steps not yet completed, in order
+	Pending []string `json:"pending"`
+}
+
+// NewStartup creates a tracker for the given steps
+func NewStartup(steps ...string) *Startup {
+	return &Startup{
+		steps:     steps,
+		completed: make(map[string]time.Time, len(steps)),
+	}
+}
+
+// Complete marks step as completed; completing a step again has no effect
+func (s *Startup) Complete(step string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, done := s.completed[step]; !done {
+		s.completed[step] = time.Now()
+	}
+}
+
+// Status returns the progress of startup
+func (s *Startup) Status() StartupStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := StartupStatus{
+		Completed: make(map[string]time.Time, len(s.completed)),
+		Pending:   []string{},
+	}
+	for _, step := range s.steps {
+		if completedAt, done := s.completed[step]; done {
+			status.Completed[step] = completedAt
+		} else {
+			status.Pending = append(status.Pending, step)
+		}
+	}
+	status.Started = len(status.Pending) == 0
+	return status
+}
@@ -0,0 +1,27 @@
+package health
+
+import "testing"
+
+func TestStartup(t *testing.T) {
+	startup := NewStartup(StartupConfigLoaded, StartupAWSCredentials)
+
+	startup.Complete(StartupConfigLoaded)
+	status := startup.Status()
+	if status.Started {
+		t.Error("Expected startup to be incomplete")
+	}
+	if len(status.Pending) != 1 || status.Pending[0] != StartupAWSCredentials {
+		t.Errorf("Expected aws_credentials to be pending, got %v", status.Pending)
+	}
+
+	completedAt := status.Completed[StartupConfigLoaded]
+	startup.Complete(StartupConfigLoaded)
+	if !startup.Status().Completed[StartupConfigLoaded].Equal(completedAt) {
+		t.Error("Expected completing a step twice to keep the first completion time")
+	}
+
+	startup.Complete(StartupAWSCredentials)
+	if status := startup.Status(); !status.Started || len(status.Pending) != 0 {
+		t.Errorf("Expected startup to be complete, got %+v", status)
+	}
+}
@@ -36,6 +36,9 @@ type CheckResult struct {
 	Error string `json:"error,omitempty"`
 	// Metadata contains additional check-specific information
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// Optional is set for non-critical checks, whose failure only degrades
+	// the service
+	Optional bool `json:"optional,omitempty"`
 }
 
 // Checker defines the interface for health check implementations
@@ -46,6 +49,24 @@ type Checker interface {
 	Name() string
 }
 
+// CriticalityChecker is implemented by checkers that declare whether they are
+// critical. An unhealthy critical check makes the overall status unhealthy,
+// while an unhealthy non-critical check only degrades it. Checkers that do not
+// implement it are critical.
+type CriticalityChecker interface {
+	Checker
+	// Critical reports whether the service is unhealthy when this check fails
+	Critical() bool
+}
+
+// IsCritical reports whether a checker is critical
+func IsCritical(checker Checker) bool {
+	if c, ok := checker.(CriticalityChecker); ok {
+		return c.Critical()
+	}
+	return true
+}
+
 // OverallHealth represents the overall health status of the application
 type OverallHealth struct {
 	// Status is the aggregated health status
@@ -64,6 +85,31 @@ type OverallHealth struct {
 	Summary string `json:"summary,omitempty"`
 }
 
+// HistoryEntry is a past result of a health check
+type HistoryEntry struct {
+	// Status is the health status reported by the check
+	Status Status `json:"status"`
+	// Message provides additional context about the status
+	Message string `json:"message,omitempty"`
+	// Error contains error details if the check failed
+	Error string `json:"error,omitempty"`
+	// Timestamp is when the check was performed
+	Timestamp time.Time `json:"timestamp"`
+	// Duration is how long the check took to complete
+	Duration time.Duration `json:"duration"`
+}
+
+// CheckHistory holds the most recent results of a health check
+type CheckHistory struct {
+	// Name is the identifier for the health check
+	Name string `json:"name"`
+	// Transitions is the number of status changes within Results; a high
+	// count means the check is flapping
+	Transitions int `json:"transitions"`
+	// Results are the most recent results, oldest first
+	Results []HistoryEntry `json:"results"`
+}
+
 // CheckerConfig defines configuration for health checkers
 type CheckerConfig struct {
 	// Enabled determines if this checker should be active
@@ -0,0 +1,86 @@
+//go:build integration
+
+package localstack
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+)
+
+func TestInventoryDescribesSeededInstances(t *testing.T) {
+	h := New(t)
+	id := h.RunInstance(t, types.InstanceTypeT3Micro, map[string]string{"team": "platform"})
+
+	inventory := aws.NewInventoryCache(h.Provider, time.Minute, h.Logger)
+	instances, err := inventory.GetInstances(context.Background(), Region)
+	if err != nil {
+		t.Fatalf("Expected no error describing instances, got: %v", err)
+	}
+	for _, instance := range instances {
+		if awssdk.ToString(instance.InstanceId) == id {
+			return
+		}
+	}
+	t.Errorf("Expected the seeded instance %s among %d instances", id, len(instances))
+}
+
+func TestExecCollectorEnrichedMetrics(t *testing.T) {
+	h := New(t)
+	id := h.RunInstance(t, types.InstanceTypeT3Micro, map[string]string{"team": "platform", "Cost-Center": "1234"})
+
+	output := fmt.Sprintf(`[
+  {"name": "queue_depth", "value": 42, "unit": "Count", "labels": {"instance_id": %q}},
+  {"name": "queue_depth", "value": 7, "unit": "Count", "labels": {"instance_id": "i-00000000000000000"}}
+]`, id)
+	plugin := config.PluginConfig{
+		Name:       "queue-depth",
+		Type:       collectors.ExecCollectorType,
+		Enabled:    true,
+		Settings:   map[string]string{"command": ExecCommand(t, output)},
+		Enrichment: config.EnrichmentConfig{Enabled: true, Tags: []string{"team", "Cost-Center"}},
+	}
+
+	metrics := h.Collect(t, plugin)
+	if len(metrics) != 2 {
+		t.Fatalf("Expected 2 metrics, got %d", len(metrics))
+	}
+	byInstance := make(map[string]collectors.MetricData)
+	for _, metric := range metrics {
+		byInstance[metric.Labels["instance_id"]] = metric
+	}
+
+	seeded, exists := byInstance[id]
+	if !exists || seeded.Value != 42 {
+		t.Fatalf("Expected the metric of the seeded instance, got %+v", metrics)
+	}
+	expected := map[string]string{
+		"collector":       "queue-depth",
+		"account_id":      AccountID,
+		"instance_type":   string(types.InstanceTypeT3Micro),
+		"resource_arn":    fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", Region, AccountID, id),
+		"tag_team":        "platform",
+		"tag_cost_center": "1234",
+	}
+	for k, v := range expected {
+		if seeded.Labels[k] != v {
+			t.Errorf("Expected label %s=%s, got %q", k, v, seeded.Labels[k])
+		}
+	}
+	if seeded.Labels["availability_zone"] == "" {
+		t.Error("Expected the availability zone of the seeded instance")
+	}
+
+	unknown := byInstance["i-00000000000000000"]
+	if _, enriched := unknown.Labels["instance_type"]; enriched {
+		t.Errorf("Expected the metric of an unknown instance not to be enriched, got %v", unknown.Labels)
+	}
+}
@@ -0,0 +1,11 @@
+// Package localstack runs collectors end to end against LocalStack, an
+// emulator of the AWS APIs, through the aws.endpoint_url override. Its tests
+// are built with the integration tag only, since they need LocalStack
+// running:
+//
+//	make localstack
+//	make test-integration
+//
+// LOCALSTACK_ENDPOINT points them at a LocalStack other than
+// http://localhost:4566.
+package localstack
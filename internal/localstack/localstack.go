@@ -0,0 +1,248 @@
+//go:build integration
+
+package localstack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+const (
+	// DefaultEndpoint is where LocalStack listens by default
+	DefaultEndpoint = "http://localhost:4566"
+	// EnvEndpoint overrides the LocalStack endpoint
+	EnvEndpoint = "LOCALSTACK_ENDPOINT"
+	// Region is the region resources are seeded and collected in
+	Region = "us-east-1"
+	// AccountID is the account LocalStack reports for its test credentials
+	AccountID = "000000000000"
+)
+
+// collectTimeout bounds a collection, including scheduling it
+const collectTimeout = time.Minute
+
+// Endpoint returns the LocalStack endpoint the tests use
+func Endpoint() string {
+	if endpoint := os.Getenv(EnvEndpoint); endpoint != "" {
+		return endpoint
+	}
+	return DefaultEndpoint
+}
+
+// Harness runs collectors against LocalStack and seeds the resources they
+// collect
+type Harness struct {
+	// Config is the configuration collectors run with, pointed at LocalStack
+	Config   *config.Config
+	Provider aws.ClientProvider
+	Logger   *logger.Logger
+
+	// ec2 seeds EC2 resources, which the provider's clients can only read
+	ec2 *ec2.Client
+}
+
+// New returns a harness for LocalStack, failing the test if it isn't running
+func New(t *testing.T) *Harness {
+	t.Helper()
+	endpoint := Endpoint()
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Get(endpoint + "/_localstack/health")
+	if err != nil {
+		t.Fatalf("LocalStack is not reachable at %s, start it with `make localstack`: %v", endpoint, err)
+	}
+	resp.Body.Close()
+
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+	cfg.AWS.AccessKeyID = "test"
+	cfg.AWS.SecretAccessKey = "test"
+	cfg.AWS.DefaultRegion = Region
+	cfg.AWS.EndpointURL = endpoint
+	cfg.AWS.MaxRetries = 1
+	cfg.EnabledRegions = []string{Region}
+
+	log, err := logger.NewLogger(logger.Config{Level: "info", Format: "json", OutputPath: "stderr"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	provider := aws.NewClientProvider(cfg, log)
+	t.Cleanup(func() { provider.Close() })
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(),
+		awsconfig.WithRegion(Region),
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("test", "test", "")),
+		awsconfig.WithBaseEndpoint(endpoint),
+	)
+	if err != nil {
+		t.Fatalf("Failed to load AWS config: %v", err)
+	}
+
+	return &Harness{
+		Config:   cfg,
+		Provider: provider,
+		Logger:   log,
+		ec2:      ec2.NewFromConfig(awsCfg),
+	}
+}
+
+// RunInstance seeds a running EC2 instance with the given tags, terminated
+// when the test ends, and returns its ID
+func (h *Harness) RunInstance(t *testing.T, instanceType types.InstanceType, tags map[string]string) string {
+	t.Helper()
+	ctx := context.Background()
+
+	var tagList []types.Tag
+	for key, value := range tags {
+		tagList = append(tagList, types.Tag{Key: awssdk.String(key), Value: awssdk.String(value)})
+	}
+	input := &ec2.RunInstancesInput{
+		// LocalStack doesn't require the image to exist
+		ImageId:      awssdk.String("ami-00000000000000000"),
+		InstanceType: instanceType,
+		MinCount:     awssdk.Int32(1),
+		MaxCount:     awssdk.Int32(1),
+	}
+	if len(tagList) > 0 {
+		input.TagSpecifications = []types.TagSpecification{{ResourceType: types.ResourceTypeInstance, Tags: tagList}}
+	}
+	out, err := h.ec2.RunInstances(ctx, input)
+	if err != nil {
+		t.Fatalf("Failed to run instance: %v", err)
+	}
+	id := awssdk.ToString(out.Instances[0].InstanceId)
+	t.Cleanup(func() {
+		if _, err := h.ec2.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: []string{id}}); err != nil {
+			t.Errorf("Failed to terminate instance %s: %v", id, err)
+		}
+	})
+	return id
+}
+
+// ExecCommand writes a script printing output, for exec collectors to run,
+// and returns its path
+func ExecCommand(t *testing.T, output string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "collect.sh")
+	script := fmt.Sprintf("#!/bin/sh\ncat <<'EOF'\n%s\nEOF\n", output)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write command: %v", err)
+	}
+	return path
+}
+
+// Collect creates the collector of a plugin configuration with the
+// dependencies aws-monitor gives it, schedules it in Region and returns the
+// metrics of its first collection, as they are handed to the exporter
+func (h *Harness) Collect(t *testing.T, plugin config.PluginConfig) []collectors.MetricData {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	account, err := h.resolveAccount(ctx)
+	if err != nil {
+		t.Fatalf("Failed to resolve account: %v", err)
+	}
+	collector, err := collectors.NewPluginCollector(plugin, collectors.CollectorDependencies{
+		Config:      h.Config,
+		AWSProvider: h.Provider,
+		Inventory:   aws.NewInventoryCache(h.Provider, time.Duration(h.Config.AWS.InventoryTTL), h.Logger),
+		Account:     account,
+		Logger:      h.Logger,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create collector: %v", err)
+	}
+	registry := collectors.NewCollectorRegistry(h.Logger)
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+
+	recorder := newRecorder()
+	schedulerConfig := scheduler.DefaultConfig()
+	schedulerConfig.TickInterval = 100 * time.Millisecond
+	schedulerConfig.JobTimeout = collectTimeout
+	metricScheduler := scheduler.NewMetricScheduler(schedulerConfig, registry, recorder, h.Logger)
+	if err := metricScheduler.ScheduleCollector(plugin.Name, []string{Region}, time.Hour); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	if err := metricScheduler.Start(ctx); err != nil {
+		t.Fatalf("Failed to start scheduler: %v", err)
+	}
+	defer metricScheduler.Stop(context.Background())
+
+	select {
+	case metrics := <-recorder.results:
+		return metrics
+	case err := <-recorder.errors:
+		t.Fatalf("Collection failed: %v", err)
+	case <-ctx.Done():
+		t.Fatalf("Timed out waiting for the collection")
+	}
+	return nil
+}
+
+// resolveAccount resolves the account LocalStack reports, as aws-monitor
+// does at startup
+func (h *Harness) resolveAccount(ctx context.Context) (aws.AccountInfo, error) {
+	stsClient, err := h.Provider.GetSTSClient(Region)
+	if err != nil {
+		return aws.AccountInfo{}, err
+	}
+	iamClient, err := h.Provider.GetIAMClient(Region)
+	if err != nil {
+		return aws.AccountInfo{}, err
+	}
+	return aws.ResolveAccountInfo(ctx, stsClient, iamClient, h.Logger)
+}
+
+// recorder is the job processor of the harness: it keeps the outcome of the
+// first collection
+type recorder struct {
+	results chan []collectors.MetricData
+	errors  chan *errors.Error
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		results: make(chan []collectors.MetricData, 1),
+		errors:  make(chan *errors.Error, 1),
+	}
+}
+
+// ProcessResult copies the metrics, which the scheduler reuses once they are
+// processed
+func (r *recorder) ProcessResult(_ context.Context, _ *scheduler.ScheduledJob, result *collectors.CollectionResult) error {
+	select {
+	case r.results <- collectors.CloneMetrics(result.Metrics):
+	default:
+	}
+	return nil
+}
+
+// ProcessError records the error of a failed collection
+func (r *recorder) ProcessError(_ context.Context, _ *scheduler.ScheduledJob, err *errors.Error) error {
+	select {
+	case r.errors <- err:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package memory watches the memory used by the process against its soft
+// limit, GOMEMLIMIT or the configured one, so aws-monitor can shed work when
+// it gets close instead of being OOM-killed.
+package memory
+
+import (
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+)
+
+// Pressure is how close the memory in use is to the soft limit
+type Pressure int
+
+const (
+	// PressureNone means there is plenty of memory left
+	PressureNone Pressure = iota
+	// PressureHigh means the memory in use passed the high ratio of the limit
+	PressureHigh
+	// PressureCritical means the memory in use passed the critical ratio of
+	// the limit
+	PressureCritical
+)
+
+// String returns the name of the pressure level
+func (p Pressure) String() string {
+	switch p {
+	case PressureHigh:
+		return "high"
+	case PressureCritical:
+		return "critical"
+	default:
+		return "none"
+	}
+}
+
+const (
+	// DefaultHighRatio is the share of the limit beyond which pressure is high
+	DefaultHighRatio = 0.8
+	// DefaultCriticalRatio is the share of the limit beyond which pressure is
+	// critical
+	DefaultCriticalRatio = 0.9
+)
+
+// Runtime metrics the memory in use is computed from, the way the runtime
+// accounts for GOMEMLIMIT
+const (
+	totalMetric    = "/memory/classes/total:bytes"
+	releasedMetric = "/memory/classes/heap/released:bytes"
+)
+
+// Config configures the memory monitor
+type Config struct {
+	// SoftLimit is the soft memory limit in bytes; 0 uses GOMEMLIMIT
+	SoftLimit int64
+	// HighRatio and CriticalRatio are the shares of the limit at which
+	// pressure becomes high and critical
+	HighRatio     float64
+	CriticalRatio float64
+}
+
+// Monitor reports the memory pressure of the process. A monitor without a
+// limit, when none is configured and GOMEMLIMIT is not set, never reports
+// pressure.
+type Monitor struct {
+	limit    int64
+	high     float64
+	critical float64
+	// usage returns the memory in use, in bytes
+	usage func() uint64
+}
+
+// NewMonitor creates a monitor for the configured soft limit, or for
+// GOMEMLIMIT if none is configured
+func NewMonitor(cfg Config) *Monitor {
+	m := &Monitor{
+		limit:    cfg.SoftLimit,
+		high:     cfg.HighRatio,
+		critical: cfg.CriticalRatio,
+		usage:    readUsage,
+	}
+	if m.high <= 0 {
+		m.high = DefaultHighRatio
+	}
+	if m.critical <= 0 {
+		m.critical = DefaultCriticalRatio
+	}
+	if m.limit <= 0 {
+		// A negative input only reads the current limit
+		if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+			m.limit = limit
+		}
+	}
+	return m
+}
+
+// ApplyRuntimeLimit makes the garbage collector work harder as the memory in
+// use approaches the configured limit, unless GOMEMLIMIT is already lower
+func (m *Monitor) ApplyRuntimeLimit() {
+	if m.limit > 0 && m.limit < debug.SetMemoryLimit(-1) {
+		debug.SetMemoryLimit(m.limit)
+	}
+}
+
+// Limit returns the soft memory limit in bytes; 0 if there is none
+func (m *Monitor) Limit() int64 {
+	return m.limit
+}
+
+// Usage returns the memory in use, in bytes
+func (m *Monitor) Usage() uint64 {
+	return m.usage()
+}
+
+// Pressure returns how close the memory in use is to the limit
+func (m *Monitor) Pressure() Pressure {
+	if m.limit <= 0 {
+		return PressureNone
+	}
+	ratio := float64(m.usage()) / float64(m.limit)
+	switch {
+	case ratio >= m.critical:
+		return PressureCritical
+	case ratio >= m.high:
+		return PressureHigh
+	default:
+		return PressureNone
+	}
+}
+
+// ScaleBatch shrinks a batch size under pressure: to half when pressure is
+// high and to a quarter when it is critical, never below 1
+func (m *Monitor) ScaleBatch(size int) int {
+	switch m.Pressure() {
+	case PressureHigh:
+		size /= 2
+	case PressureCritical:
+		size /= 4
+	}
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// readUsage returns the memory mapped by the runtime that wasn't returned to
+// the operating system
+func readUsage() uint64 {
+	samples := []metrics.Sample{{Name: totalMetric}, {Name: releasedMetric}}
+	metrics.Read(samples)
+
+	var usage uint64
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		usage = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		usage -= samples[1].Value.Uint64()
+	}
+	return usage
+}
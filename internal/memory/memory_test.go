@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestMonitorPressure(t *testing.T) {
+	m := NewMonitor(Config{SoftLimit: 1000})
+	tests := []struct {
+		usage     uint64
+		pressure  Pressure
+		batchSize int
+	}{
+		{usage: 500, pressure: PressureNone, batchSize: 100},
+		{usage: 800, pressure: PressureHigh, batchSize: 50},
+		{usage: 950, pressure: PressureCritical, batchSize: 25},
+	}
+
+	for _, tt := range tests {
+		usage := tt.usage
+		m.usage = func() uint64 { return usage }
+		if pressure := m.Pressure(); pressure != tt.pressure {
+			t.Errorf("Expected pressure %s at %d bytes, got %s", tt.pressure, tt.usage, pressure)
+		}
+		if size := m.ScaleBatch(100); size != tt.batchSize {
+			t.Errorf("Expected batch size %d at %d bytes, got %d", tt.batchSize, tt.usage, size)
+		}
+	}
+
+	if size := m.ScaleBatch(2); size != 1 {
+		t.Errorf("Expected batches of at least 1, got %d", size)
+	}
+}
+
+func TestMonitorWithoutLimit(t *testing.T) {
+	m := &Monitor{usage: func() uint64 { return 1 << 40 }}
+	if pressure := m.Pressure(); pressure != PressureNone {
+		t.Errorf("Expected no pressure without a limit, got %s", pressure)
+	}
+	if size := m.ScaleBatch(100); size != 100 {
+		t.Errorf("Expected the batch size unchanged, got %d", size)
+	}
+}
+
+func TestReadUsage(t *testing.T) {
+	if usage := NewMonitor(Config{}).Usage(); usage == 0 {
+		t.Error("Expected the memory in use to be reported")
+	}
+}
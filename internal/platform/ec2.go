@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Environment variables the AWS SDKs also read to reach or disable the
+// instance metadata service (IMDS)
+const (
+	EnvIMDSEndpoint = "AWS_EC2_METADATA_SERVICE_ENDPOINT"
+	EnvIMDSDisabled = "AWS_EC2_METADATA_DISABLED"
+)
+
+// EC2 is the name of the Amazon EC2 platform
+const EC2 = "aws_ec2"
+
+const (
+	defaultIMDSEndpoint = "http://169.254.169.254"
+	// dmiVendorFile names the maker of the machine on Linux: "Amazon EC2" on
+	// Nitro instances, "Xen" on older ones
+	dmiVendorFile = "/sys/class/dmi/id/sys_vendor"
+)
+
+// instanceIdentity is the part of the instance identity document used
+type instanceIdentity struct {
+	InstanceID       string `json:"instanceId"`
+	InstanceType     string `json:"instanceType"`
+	AvailabilityZone string `json:"availabilityZone"`
+	Region           string `json:"region"`
+	AccountID        string `json:"accountId"`
+}
+
+// detectEC2 reads the identity of the EC2 instance aws-monitor runs on from
+// IMDS, using a session token (IMDSv2) when the service issues one. Machines
+// whose vendor shows they aren't EC2 instances aren't probed, saving the
+// timeout.
+func detectEC2(ctx context.Context, env environment) (Metadata, bool) {
+	if strings.EqualFold(env.getenv(EnvIMDSDisabled), "true") {
+		return Metadata{}, false
+	}
+	if vendor, err := env.readFile(dmiVendorFile); err == nil &&
+		!strings.Contains(string(vendor), "Amazon") && !strings.Contains(string(vendor), "Xen") {
+		return Metadata{}, false
+	}
+	endpoint := strings.TrimSuffix(env.getenv(EnvIMDSEndpoint), "/")
+	if endpoint == "" {
+		endpoint = defaultIMDSEndpoint
+	}
+
+	header := make(http.Header)
+	token, err := imdsToken(ctx, env.client, endpoint)
+	if err != nil {
+		return Metadata{}, false
+	}
+	if token != "" {
+		header.Set("X-aws-ec2-metadata-token", token)
+	}
+	var identity instanceIdentity
+	if err := getJSON(ctx, env.client, endpoint+"/latest/dynamic/instance-identity/document", header, &identity); err != nil || identity.InstanceID == "" {
+		return Metadata{}, false
+	}
+
+	metadata := Metadata{Name: EC2}
+	metadata.setAttribute("cloud.provider", "aws")
+	metadata.setAttribute("cloud.platform", EC2)
+	metadata.setAttribute("cloud.region", identity.Region)
+	metadata.setAttribute("cloud.availability_zone", identity.AvailabilityZone)
+	metadata.setAttribute("cloud.account.id", identity.AccountID)
+	metadata.setAttribute("host.id", identity.InstanceID)
+	metadata.setAttribute("host.type", identity.InstanceType)
+	return metadata, true
+}
+
+// imdsToken requests an IMDSv2 session token. It returns no token, and no
+// error, when the service answers without one, as IMDSv1-only services do.
+func imdsToken(ctx context.Context, client *http.Client, endpoint string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
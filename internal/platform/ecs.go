@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// EnvECSMetadataURI is set by the ECS agent, on EC2 and Fargate, to the task
+// metadata endpoint of the container
+const EnvECSMetadataURI = "ECS_CONTAINER_METADATA_URI_V4"
+
+// ECS is the name of the Amazon ECS platform
+const ECS = "aws_ecs"
+
+// ecsTask is the part of the task metadata response used
+type ecsTask struct {
+	Cluster          string `json:"Cluster"`
+	TaskARN          string `json:"TaskARN"`
+	Family           string `json:"Family"`
+	Revision         string `json:"Revision"`
+	AvailabilityZone string `json:"AvailabilityZone"`
+	LaunchType       string `json:"LaunchType"`
+}
+
+// detectECS reads the metadata of the ECS task aws-monitor runs in, from the
+// task metadata endpoint
+func detectECS(ctx context.Context, env environment) (Metadata, bool) {
+	endpoint := env.getenv(EnvECSMetadataURI)
+	if endpoint == "" {
+		return Metadata{}, false
+	}
+
+	metadata := Metadata{Name: ECS}
+	metadata.setAttribute("cloud.provider", "aws")
+	metadata.setAttribute("cloud.platform", ECS)
+
+	var task ecsTask
+	if err := getJSON(ctx, env.client, endpoint+"/task", nil, &task); err != nil {
+		// The variable alone tells where aws-monitor runs
+		return metadata, true
+	}
+	metadata.setAttribute("cloud.availability_zone", task.AvailabilityZone)
+	metadata.setAttribute("aws.ecs.task.arn", task.TaskARN)
+	metadata.setAttribute("aws.ecs.task.family", task.Family)
+	metadata.setAttribute("aws.ecs.task.revision", task.Revision)
+	metadata.setAttribute("aws.ecs.launchtype", strings.ToLower(task.LaunchType))
+
+	// arn:aws:ecs:<region>:<account>:task/<cluster>/<id>
+	arn := strings.Split(task.TaskARN, ":")
+	if len(arn) == 6 {
+		metadata.setAttribute("cloud.region", arn[3])
+		metadata.setAttribute("cloud.account.id", arn[4])
+	}
+	cluster := task.Cluster
+	if !strings.HasPrefix(cluster, "arn:") && cluster != "" && len(arn) == 6 {
+		// Older agents report the cluster name only
+		cluster = strings.Join(arn[:5], ":") + ":cluster/" + cluster
+	}
+	metadata.setAttribute("aws.ecs.cluster.arn", cluster)
+	return metadata, true
+}
+
+// getJSON decodes the response to a GET request to url into v
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
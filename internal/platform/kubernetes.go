@@ -0,0 +1,63 @@
+package platform
+
+import (
+	"strings"
+)
+
+// Environment variables the Kubernetes metadata is read from. The pod, its
+// namespace and node are usually exposed through the downward API:
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	  - name: POD_NAMESPACE
+//	    valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	  - name: NODE_NAME
+//	    valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+const (
+	EnvKubernetesServiceHost = "KUBERNETES_SERVICE_HOST"
+	EnvPodName               = "POD_NAME"
+	EnvPodNamespace          = "POD_NAMESPACE"
+	EnvNodeName              = "NODE_NAME"
+	EnvClusterName           = "K8S_CLUSTER_NAME"
+)
+
+// Kubernetes is the name of the Kubernetes platform
+const Kubernetes = "kubernetes"
+
+// namespaceFile holds the namespace of the pod when its service account
+// token is mounted
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// detectKubernetes reads the metadata of the pod aws-monitor runs in. Every
+// pod has KUBERNETES_SERVICE_HOST set; without the downward API the namespace
+// comes from the service account and the pod name from the hostname.
+func detectKubernetes(opts Options, env environment) (Metadata, bool) {
+	if env.getenv(EnvKubernetesServiceHost) == "" {
+		return Metadata{}, false
+	}
+
+	namespace := env.getenv(EnvPodNamespace)
+	if namespace == "" {
+		if data, err := env.readFile(namespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+	pod := env.getenv(EnvPodName)
+	if pod == "" {
+		if hostname, err := env.hostname(); err == nil {
+			pod = hostname
+		}
+	}
+	cluster := opts.ClusterName
+	if cluster == "" {
+		cluster = env.getenv(EnvClusterName)
+	}
+
+	metadata := Metadata{Name: Kubernetes}
+	metadata.set("k8s.cluster.name", "k8s_cluster", cluster)
+	metadata.set("k8s.namespace.name", "k8s_namespace", namespace)
+	metadata.set("k8s.pod.name", "k8s_pod", pod)
+	metadata.set("k8s.node.name", "k8s_node", env.getenv(EnvNodeName))
+	return metadata, true
+}
@@ -0,0 +1,115 @@
+// Package platform detects the platform aws-monitor runs on, Kubernetes,
+// Amazon ECS or EC2, and the metadata identifying where it runs, so the telemetry of
+// a fleet of monitors can be told apart and correlated with the workloads
+// around them.
+package platform
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+)
+
+// detectTimeout bounds the requests to metadata endpoints, so a host that
+// isn't on the platform probed doesn't delay startup for long
+const detectTimeout = time.Second
+
+// Metadata identifies where aws-monitor runs
+type Metadata struct {
+	// Name is the platform detected, e.g. "kubernetes"; empty if none was
+	Name string
+	// Attributes are the OpenTelemetry resource attributes, named after the
+	// semantic conventions, e.g. k8s.pod.name
+	Attributes map[string]string
+	// Labels carry the metadata that identifies the workload as metric
+	// labels, e.g. k8s_pod
+	Labels map[string]string
+}
+
+// Options configures detection
+type Options struct {
+	// ClusterName names the Kubernetes cluster, which pods cannot discover
+	// by themselves; K8S_CLUSTER_NAME is used when empty
+	ClusterName string
+}
+
+// environment is what detection reads, replaced in tests
+type environment struct {
+	getenv   func(string) string
+	readFile func(string) ([]byte, error)
+	hostname func() (string, error)
+	// client requests the ECS task metadata endpoint and IMDS
+	client *http.Client
+}
+
+// Detect returns the metadata of the platform aws-monitor runs on, or empty
+// metadata if it runs on none it recognizes. Kubernetes and ECS are detected
+// from their environment variables; EC2 by asking IMDS, which takes up to a
+// second on other hosts.
+func Detect(opts Options) Metadata {
+	return detect(opts, environment{
+		getenv:   os.Getenv,
+		readFile: os.ReadFile,
+		hostname: os.Hostname,
+		client:   &http.Client{Timeout: detectTimeout},
+	})
+}
+
+func detect(opts Options, env environment) Metadata {
+	if metadata, ok := detectKubernetes(opts, env); ok {
+		return metadata
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+	if metadata, ok := detectECS(ctx, env); ok {
+		return metadata
+	}
+	if metadata, ok := detectEC2(ctx, env); ok {
+		return metadata
+	}
+	return Metadata{}
+}
+
+// MergeAttributes returns the configured resource attributes with the
+// detected ones added. Configured attributes take precedence, so they can
+// correct what was detected.
+func (m Metadata) MergeAttributes(configured map[string]string) map[string]string {
+	if len(m.Attributes) == 0 {
+		return configured
+	}
+	merged := make(map[string]string, len(m.Attributes)+len(configured))
+	for k, v := range m.Attributes {
+		merged[k] = v
+	}
+	for k, v := range configured {
+		merged[k] = v
+	}
+	return merged
+}
+
+// set records a piece of metadata as both a resource attribute and a label,
+// unless its value is unknown
+func (m *Metadata) set(attribute, label, value string) {
+	if value == "" {
+		return
+	}
+	m.setAttribute(attribute, value)
+	if m.Labels == nil {
+		m.Labels = make(map[string]string)
+	}
+	m.Labels[label] = value
+}
+
+// setAttribute records a piece of metadata as a resource attribute only,
+// unless its value is unknown
+func (m *Metadata) setAttribute(attribute, value string) {
+	if value == "" {
+		return
+	}
+	if m.Attributes == nil {
+		m.Attributes = make(map[string]string)
+	}
+	m.Attributes[attribute] = value
+}
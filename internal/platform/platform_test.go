@@ -0,0 +1,190 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// testEnvironment returns an environment with the given variables, a pod
+// hostname and, if namespace is set, a service account namespace file
+func testEnvironment(vars map[string]string, namespace string) environment {
+	return environment{
+		getenv: func(key string) string { return vars[key] },
+		readFile: func(path string) ([]byte, error) {
+			if path == namespaceFile && namespace != "" {
+				return []byte(namespace + "\n"), nil
+			}
+			return nil, os.ErrNotExist
+		},
+		hostname: func() (string, error) { return "aws-monitor-7d9f-abcde", nil },
+		client:   http.DefaultClient,
+	}
+}
+
+func TestDetectKubernetes(t *testing.T) {
+	env := testEnvironment(map[string]string{
+		EnvKubernetesServiceHost: "10.0.0.1",
+		EnvPodName:               "aws-monitor-0",
+		EnvPodNamespace:          "monitoring",
+		EnvNodeName:              "ip-10-0-1-12",
+		EnvClusterName:           "prod-eu",
+	}, "default")
+
+	metadata := detect(Options{}, env)
+	if metadata.Name != Kubernetes {
+		t.Fatalf("Expected the kubernetes platform, got %q", metadata.Name)
+	}
+	expected := map[string]string{
+		"k8s.cluster.name":   "prod-eu",
+		"k8s.namespace.name": "monitoring",
+		"k8s.pod.name":       "aws-monitor-0",
+		"k8s.node.name":      "ip-10-0-1-12",
+	}
+	for k, v := range expected {
+		if metadata.Attributes[k] != v {
+			t.Errorf("Expected attribute %s=%s, got %q", k, v, metadata.Attributes[k])
+		}
+	}
+	if metadata.Labels["k8s_namespace"] != "monitoring" || metadata.Labels["k8s_pod"] != "aws-monitor-0" {
+		t.Errorf("Expected the pod labels, got %v", metadata.Labels)
+	}
+
+	// The configured cluster name takes precedence over the environment
+	metadata = detect(Options{ClusterName: "prod"}, env)
+	if metadata.Labels["k8s_cluster"] != "prod" {
+		t.Errorf("Expected the configured cluster name, got %q", metadata.Labels["k8s_cluster"])
+	}
+}
+
+func TestDetectKubernetesWithoutDownwardAPI(t *testing.T) {
+	env := testEnvironment(map[string]string{EnvKubernetesServiceHost: "10.0.0.1"}, "monitoring")
+
+	metadata := detect(Options{}, env)
+	if metadata.Attributes["k8s.namespace.name"] != "monitoring" {
+		t.Errorf("Expected the service account namespace, got %q", metadata.Attributes["k8s.namespace.name"])
+	}
+	if metadata.Attributes["k8s.pod.name"] != "aws-monitor-7d9f-abcde" {
+		t.Errorf("Expected the hostname as pod name, got %q", metadata.Attributes["k8s.pod.name"])
+	}
+	if _, ok := metadata.Labels["k8s_node"]; ok {
+		t.Errorf("Expected no label for the unknown node, got %v", metadata.Labels)
+	}
+
+	env.hostname = func() (string, error) { return "", errors.New("no hostname") }
+	if metadata := detect(Options{}, env); metadata.Attributes["k8s.pod.name"] != "" {
+		t.Errorf("Expected no pod name, got %q", metadata.Attributes["k8s.pod.name"])
+	}
+}
+
+func TestDetectNoPlatform(t *testing.T) {
+	env := testEnvironment(map[string]string{EnvIMDSDisabled: "true"}, "")
+	metadata := detect(Options{ClusterName: "prod"}, env)
+	if metadata.Name != "" || metadata.Attributes != nil || metadata.Labels != nil {
+		t.Errorf("Expected no metadata outside a known platform, got %+v", metadata)
+	}
+}
+
+func TestMergeAttributes(t *testing.T) {
+	metadata := Metadata{Attributes: map[string]string{"k8s.pod.name": "aws-monitor-0", "k8s.cluster.name": "prod-eu"}}
+	configured := map[string]string{"k8s.cluster.name": "prod", "team": "platform"}
+
+	merged := metadata.MergeAttributes(configured)
+	if len(merged) != 3 || merged["k8s.cluster.name"] != "prod" || merged["k8s.pod.name"] != "aws-monitor-0" {
+		t.Errorf("Expected the configured attributes to take precedence, got %v", merged)
+	}
+	if len(configured) != 2 {
+		t.Errorf("Expected the configured attributes unchanged, got %v", configured)
+	}
+	if merged := (Metadata{}).MergeAttributes(configured); len(merged) != 2 {
+		t.Errorf("Expected the configured attributes without detected ones, got %v", merged)
+	}
+}
+
+func TestDetectECS(t *testing.T) {
+	cluster := "arn:aws:ecs:eu-west-1:123456789012:cluster/monitoring"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v4/task" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"Cluster": %q, "TaskARN": "arn:aws:ecs:eu-west-1:123456789012:task/monitoring/0f9a", `+
+			`"Family": "aws-monitor", "Revision": "12", "AvailabilityZone": "eu-west-1b", "LaunchType": "FARGATE"}`, cluster)
+	}))
+	defer server.Close()
+	env := testEnvironment(map[string]string{EnvECSMetadataURI: server.URL + "/v4"}, "")
+
+	metadata := detect(Options{}, env)
+	expected := map[string]string{
+		"cloud.platform":          ECS,
+		"cloud.region":            "eu-west-1",
+		"cloud.account.id":        "123456789012",
+		"cloud.availability_zone": "eu-west-1b",
+		"aws.ecs.cluster.arn":     cluster,
+		"aws.ecs.task.arn":        "arn:aws:ecs:eu-west-1:123456789012:task/monitoring/0f9a",
+		"aws.ecs.launchtype":      "fargate",
+	}
+	for k, v := range expected {
+		if metadata.Attributes[k] != v {
+			t.Errorf("Expected attribute %s=%s, got %q", k, v, metadata.Attributes[k])
+		}
+	}
+	if metadata.Name != ECS || metadata.Labels != nil {
+		t.Errorf("Expected ECS metadata as resource attributes only, got %+v", metadata)
+	}
+
+	// Older agents report the cluster name, from which the ARN is built
+	cluster = "monitoring"
+	if arn := detect(Options{}, env).Attributes["aws.ecs.cluster.arn"]; arn != "arn:aws:ecs:eu-west-1:123456789012:cluster/monitoring" {
+		t.Errorf("Expected the cluster ARN, got %q", arn)
+	}
+}
+
+func TestDetectEC2(t *testing.T) {
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			if token == "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, token)
+		case r.URL.Path == "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-aws-ec2-metadata-token") != token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"instanceId": "i-0123456789abcdef0", "instanceType": "m6i.large", `+
+				`"availabilityZone": "us-east-1a", "region": "us-east-1", "accountId": "123456789012"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	env := testEnvironment(map[string]string{EnvIMDSEndpoint: server.URL}, "")
+
+	for _, token = range []string{"session-token", ""} {
+		metadata := detect(Options{}, env)
+		if metadata.Name != EC2 || metadata.Attributes["host.id"] != "i-0123456789abcdef0" {
+			t.Fatalf("Expected the instance with token %q, got %+v", token, metadata)
+		}
+		if metadata.Attributes["cloud.availability_zone"] != "us-east-1a" || metadata.Attributes["host.type"] != "m6i.large" {
+			t.Errorf("Expected the instance identity, got %v", metadata.Attributes)
+		}
+	}
+
+	// Machines made by other vendors aren't probed
+	env.readFile = func(path string) ([]byte, error) {
+		if path == dmiVendorFile {
+			return []byte("LENOVO\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	if metadata := detect(Options{}, env); metadata.Name != "" {
+		t.Errorf("Expected no platform on another vendor's machine, got %q", metadata.Name)
+	}
+}
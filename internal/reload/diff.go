@@ -0,0 +1,173 @@
+package reload
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"aws-monitoring/internal/config"
+)
+
+// FieldChange is a setting that differs between two configurations. Old is
+// nil for an added setting and New is nil for a removed one.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// String formats the change as "path: old -> new"
+func (c FieldChange) String() string {
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("%s: added %s", c.Path, formatValue(c.New))
+	case c.New == nil:
+		return fmt.Sprintf("%s: removed %s", c.Path, formatValue(c.Old))
+	default:
+		return fmt.Sprintf("%s: %s -> %s", c.Path, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// formatValue formats a setting like it would be written in YAML flow style,
+// leaving strings unquoted
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err == nil && len(node.Content) > 0 {
+		node.Content[0].Style = yaml.FlowStyle
+		if flow, err := yaml.Marshal(node.Content[0]); err == nil {
+			data = flow
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ConfigDiff returns the settings that differ between two configurations,
+// sorted by path, with the values of sensitive settings redacted. Plugin
+// collectors are identified by name rather than position, e.g.
+// plugins[billing].regions.
+func ConfigDiff(previous, current *config.Config) ([]FieldChange, error) {
+	before, err := previous.Document()
+	if err != nil {
+		return nil, err
+	}
+	after, err := current.Document()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	diffValues("", before, after, false, current.SensitiveKeys(), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// diffValues appends the differences between two values at path to changes
+func diffValues(path string, before, after interface{}, redact bool, sensitive map[string]bool, changes *[]FieldChange) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		for _, key := range unionKeys(beforeMap, afterMap) {
+			diffValues(joinPath(path, key), beforeMap[key], afterMap[key], redact || sensitive[strings.ToLower(key)], sensitive, changes)
+		}
+		return
+	}
+
+	beforeNamed, beforeIsNamed := namedItems(before)
+	afterNamed, afterIsNamed := namedItems(after)
+	if beforeIsNamed && afterIsNamed {
+		for _, name := range unionKeys(beforeNamed, afterNamed) {
+			diffValues(fmt.Sprintf("%s[%s]", path, name), beforeNamed[name], afterNamed[name], redact, sensitive, changes)
+		}
+		return
+	}
+
+	if isEmpty(before) && isEmpty(after) {
+		return
+	}
+	change := FieldChange{Path: path}
+	if !isEmpty(before) {
+		change.Old = config.RedactValue(before, redact, sensitive)
+	}
+	if !isEmpty(after) {
+		change.New = config.RedactValue(after, redact, sensitive)
+	}
+	*changes = append(*changes, change)
+}
+
+// namedItems indexes a list of maps with unique name keys, such as plugin
+// collectors, by name
+func namedItems(value interface{}) (map[string]interface{}, bool) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, value == nil
+	}
+
+	items := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := fields["name"].(string)
+		if !ok || name == "" {
+			return nil, false
+		}
+		if _, duplicate := items[name]; duplicate {
+			return nil, false
+		}
+		items[name] = item
+	}
+	return items, true
+}
+
+// isEmpty reports whether a value is unset: nil, or an empty list or map
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, exists := a[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinPath appends a key to a dotted path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
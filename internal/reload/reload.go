@@ -0,0 +1,555 @@
+// Package reload applies changes to the configuration file to the running
+// application, without a restart.
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/health"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/logger"
+)
+
+// CheckerRegistry registers the health checkers of collectors added or
+// removed by a reload
+type CheckerRegistry interface {
+	RegisterChecker(checker health.Checker)
+	UnregisterChecker(name string)
+}
+
+// Changes describes the difference between two configurations
+type Changes struct {
+	// LogLevel is the new log level; empty when unchanged
+	LogLevel string `json:"log_level,omitempty"`
+	// EnabledRegions is set when the enabled regions changed
+	EnabledRegions bool `json:"enabled_regions,omitempty"`
+	// Added, Removed and Updated are the names of plugin collectors that were
+	// enabled, disabled or reconfigured
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	// RestartRequired lists the changed configuration sections that only
+	// take effect after a restart
+	RestartRequired []string `json:"restart_required,omitempty"`
+}
+
+// Empty reports whether there are no changes
+func (c Changes) Empty() bool {
+	return c.LogLevel == "" && !c.EnabledRegions && len(c.Added) == 0 && len(c.Removed) == 0 &&
+		len(c.Updated) == 0 && len(c.RestartRequired) == 0
+}
+
+// Diff compares two configurations. Collectors whose regions fall back to
+// the enabled regions count as updated when those change.
+func Diff(previous, current *config.Config) Changes {
+	var changes Changes
+	if previous.Global.LogLevel != current.Global.LogLevel {
+		changes.LogLevel = current.Global.LogLevel
+	}
+	changes.EnabledRegions = !reflect.DeepEqual(previous.EnabledRegions, current.EnabledRegions)
+
+	before := enabledPlugins(previous)
+	after := enabledPlugins(current)
+	for name, plugin := range after {
+		old, existed := before[name]
+		switch {
+		case !existed:
+			changes.Added = append(changes.Added, name)
+		case !reflect.DeepEqual(old, plugin) || (changes.EnabledRegions && len(plugin.Regions) == 0):
+			changes.Updated = append(changes.Updated, name)
+		}
+	}
+	for name := range before {
+		if _, exists := after[name]; !exists {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Updated)
+
+	changes.RestartRequired = restartRequired(previous, current)
+	return changes
+}
+
+// enabledPlugins returns the enabled plugin collectors by name
+func enabledPlugins(cfg *config.Config) map[string]config.PluginConfig {
+	plugins := make(map[string]config.PluginConfig)
+	for _, plugin := range cfg.Plugins {
+		if plugin.Enabled {
+			plugins[plugin.Name] = plugin
+		}
+	}
+	return plugins
+}
+
+// restartRequired returns the changed sections that cannot be applied at
+// runtime
+func restartRequired(previous, current *config.Config) []string {
+	var sections []string
+	if !reflect.DeepEqual(previous.AWS, current.AWS) {
+		sections = append(sections, "aws")
+	}
+	if !reflect.DeepEqual(previous.OTEL, current.OTEL) {
+		sections = append(sections, "otel")
+	}
+	if !reflect.DeepEqual(previous.Metrics, current.Metrics) {
+		sections = append(sections, "metrics")
+	}
+	if !reflect.DeepEqual(previous.Admin, current.Admin) {
+		sections = append(sections, "admin")
+	}
+	if !reflect.DeepEqual(previous.Alerting, current.Alerting) {
+		sections = append(sections, "alerting")
+	}
+	if !reflect.DeepEqual(previous.Alerts, current.Alerts) {
+		sections = append(sections, "alerts")
+	}
+	if !reflect.DeepEqual(previous.Anomalies, current.Anomalies) {
+		sections = append(sections, "anomalies")
+	}
+	if !reflect.DeepEqual(previous.Events, current.Events) {
+		sections = append(sections, "events")
+	}
+	if !reflect.DeepEqual(previous.EventTriggers, current.EventTriggers) {
+		sections = append(sections, "event_triggers")
+	}
+	if !reflect.DeepEqual(previous.Control, current.Control) {
+		sections = append(sections, "control")
+	}
+
+	// The log level is applied at runtime; the rest of global is not
+	previousGlobal, currentGlobal := previous.Global, current.Global
+	previousGlobal.LogLevel, currentGlobal.LogLevel = "", ""
+	if !reflect.DeepEqual(previousGlobal, currentGlobal) {
+		sections = append(sections, "global")
+	}
+	return sections
+}
+
+// Reloader reloads the configuration file and applies the changes to the log
+// level, plugin collectors and enabled regions. Other changes are logged as
+// requiring a restart.
+type Reloader struct {
+	path      string
+	registry  collectors.Registry
+	scheduler scheduler.Scheduler
+	deps      collectors.CollectorDependencies
+	logger    *logger.Logger
+
+	// checkers, if set, tracks a health checker per collector
+	checkers CheckerRegistry
+	// audit, if set, records the applied reloads
+	audit *audit.Log
+	// loadOptions are used to load the configuration file
+	loadOptions config.LoadOptions
+
+	mu      sync.Mutex
+	current *config.Config
+}
+
+// NewReloader creates a reloader for the configuration file at path, which
+// was last loaded as current
+func NewReloader(
+	path string,
+	current *config.Config,
+	registry collectors.Registry,
+	sched scheduler.Scheduler,
+	deps collectors.CollectorDependencies,
+	log *logger.Logger,
+) *Reloader {
+	return &Reloader{
+		path:      path,
+		registry:  registry,
+		scheduler: sched,
+		deps:      deps,
+		logger:    log.WithComponent("config-reload"),
+		current:   current,
+	}
+}
+
+// SetHealthManager registers a health checker for each collector added by a
+// reload and unregisters it when the collector is removed
+func (r *Reloader) SetHealthManager(registry CheckerRegistry) {
+	r.checkers = registry
+}
+
+// SetLoadOptions sets the options the configuration file is loaded with,
+// e.g. strict mode
+func (r *Reloader) SetLoadOptions(opts config.LoadOptions) {
+	r.loadOptions = opts
+}
+
+// Current returns the configuration last loaded successfully
+func (r *Reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// SetAuditLog records the reloads that changed the configuration to log
+func (r *Reloader) SetAuditLog(log *audit.Log) {
+	r.audit = log
+}
+
+// Reload loads and validates the configuration file and applies the changes.
+// An invalid file leaves the running configuration untouched.
+func (r *Reloader) Reload(ctx context.Context) (Changes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.LoadWithOptions(r.path, r.loadOptions)
+	if err != nil {
+		r.logger.Error("Configuration reload failed, keeping the current configuration",
+			logger.String("path", r.path),
+			logger.String("error", err.Error()))
+		return Changes{}, err
+	}
+	for _, deprecation := range next.Deprecations() {
+		r.logger.Warn("Deprecated configuration key", logger.String("deprecation", deprecation.String()))
+	}
+	for _, key := range next.UnknownKeys() {
+		r.logger.Warn("Unknown configuration key ignored", logger.String("key", key.String()))
+	}
+
+	r.logDiff(next)
+
+	changes := Diff(r.current, next)
+	if changes.Empty() {
+		r.logger.Info("Configuration reloaded without changes", logger.String("path", r.path))
+		return changes, nil
+	}
+
+	var failed []string
+	if changes.LogLevel != "" {
+		if err := r.logger.SetLevel(changes.LogLevel); err != nil {
+			failed = append(failed, fmt.Sprintf("log level: %v", err))
+		}
+	}
+	if changes.EnabledRegions {
+		r.scheduler.SetEnabledRegions(next.EnabledRegions)
+	}
+
+	// Collectors fall back to the new enabled regions and default interval
+	r.deps.Config = next
+	plugins := enabledPlugins(next)
+	for _, name := range append(changes.Removed, changes.Updated...) {
+		r.removeCollector(name)
+	}
+	for _, name := range append(changes.Added, changes.Updated...) {
+		if err := r.addCollector(ctx, plugins[name]); err != nil {
+			failed = append(failed, fmt.Sprintf("collector %s: %v", name, err))
+		}
+	}
+	r.current = next
+
+	r.logger.Info("Configuration reloaded",
+		logger.String("path", r.path),
+		logger.String("log_level", changes.LogLevel),
+		logger.Bool("enabled_regions_changed", changes.EnabledRegions),
+		logger.Strings("added", changes.Added),
+		logger.Strings("removed", changes.Removed),
+		logger.Strings("updated", changes.Updated))
+	if len(changes.RestartRequired) > 0 {
+		r.logger.Warn("Configuration changes require a restart to take effect",
+			logger.Strings("sections", changes.RestartRequired))
+	}
+	var applyErr error
+	if len(failed) > 0 {
+		applyErr = fmt.Errorf("failed to apply configuration changes: %v", failed)
+	}
+	r.record(ctx, changes, applyErr)
+	return changes, applyErr
+}
+
+// record adds an applied reload to the audit log, if any
+func (r *Reloader) record(ctx context.Context, changes Changes, applyErr error) {
+	if r.audit == nil {
+		return
+	}
+
+	details := map[string]string{}
+	if changes.LogLevel != "" {
+		details["log_level"] = changes.LogLevel
+	}
+	if changes.EnabledRegions {
+		details["enabled_regions"] = strings.Join(r.current.EnabledRegions, ",")
+	}
+	for key, names := range map[string][]string{
+		"added":            changes.Added,
+		"removed":          changes.Removed,
+		"updated":          changes.Updated,
+		"restart_required": changes.RestartRequired,
+	} {
+		if len(names) > 0 {
+			details[key] = strings.Join(names, ",")
+		}
+	}
+	if applyErr != nil {
+		details["error"] = applyErr.Error()
+	}
+
+	if err := r.audit.Record(ctx, audit.ActionConfigReload, r.path, details); err != nil {
+		r.logger.Error("Failed to record audit log entry", logger.String("error", err.Error()))
+	}
+}
+
+// logDiff logs the settings that differ between the running configuration
+// and next, so that operators can audit what a reload changed
+func (r *Reloader) logDiff(next *config.Config) {
+	diff, err := ConfigDiff(r.current, next)
+	if err != nil {
+		r.logger.Warn("Failed to compare configurations", logger.String("error", err.Error()))
+		return
+	}
+	if len(diff) == 0 {
+		return
+	}
+
+	lines := make([]string, len(diff))
+	for i, change := range diff {
+		lines[i] = change.String()
+	}
+	r.logger.Info("Configuration diff",
+		logger.String("path", r.path),
+		logger.Strings("changes", lines))
+}
+
+// removeCollector unschedules and unregisters a collector
+func (r *Reloader) removeCollector(name string) {
+	for _, job := range r.scheduler.GetScheduledJobs() {
+		if job.CollectorName != name {
+			continue
+		}
+		if err := r.scheduler.UnscheduleCollector(name, job.Region); err != nil {
+			r.logger.Warn("Failed to unschedule collector job",
+				logger.String("collector", name),
+				logger.String("region", job.Region),
+				logger.String("error", err.Error()))
+		}
+	}
+
+	// Collectors removed through the admin API are no longer registered
+	if _, exists := r.registry.Get(name); exists {
+		if err := r.registry.Unregister(name); err != nil {
+			r.logger.Warn("Failed to unregister collector",
+				logger.String("collector", name),
+				logger.String("error", err.Error()))
+		}
+	}
+
+	if r.checkers != nil {
+		r.checkers.UnregisterChecker(health.CollectorCheckerName(name))
+	}
+}
+
+// addCollector creates, registers, starts and schedules a plugin collector
+func (r *Reloader) addCollector(ctx context.Context, pluginCfg config.PluginConfig) error {
+	// A plugin registers its types once; loading it again would fail
+	if pluginCfg.Path != "" && !typeRegistered(pluginCfg.Type) {
+		if err := collectors.LoadPlugin(pluginCfg.Path); err != nil {
+			return err
+		}
+	}
+
+	collector, err := collectors.NewPluginCollector(pluginCfg, r.deps)
+	if err != nil {
+		return err
+	}
+	if err := r.registry.Register(collector); err != nil {
+		return err
+	}
+	if err := collector.Start(ctx); err != nil {
+		_ = r.registry.Unregister(pluginCfg.Name)
+		return fmt.Errorf("failed to start collector: %w", err)
+	}
+
+	info := collector.Info()
+	if err := scheduler.ScheduleCollectorInfo(r.scheduler, info); err != nil {
+		_ = r.registry.Unregister(pluginCfg.Name)
+		return fmt.Errorf("failed to schedule collector: %w", err)
+	}
+
+	if r.checkers != nil {
+		r.checkers.RegisterChecker(health.NewCollectorHealthChecker(collector))
+	}
+	return nil
+}
+
+// typeRegistered reports whether a collector type is registered
+func typeRegistered(typeName string) bool {
+	for _, registered := range collectors.RegisteredCollectorTypes() {
+		if registered == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// watchDebounce is how long Watch waits for file system notifications to
+// settle, so that a file written in several steps is reloaded once
+const watchDebounce = 250 * time.Millisecond
+
+// Watch reloads the configuration whenever the contents of the file or the
+// files it includes change, until ctx is done. Local files are watched for
+// file system notifications. A remote document, which has none, is fetched
+// every interval instead, as are local files if they cannot be watched.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if config.IsRemote(r.path) {
+		r.poll(ctx, interval)
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.logger.Warn("Failed to watch configuration files, checking them periodically instead",
+			logger.String("path", r.path),
+			logger.String("error", err.Error()))
+		r.poll(ctx, interval)
+		return
+	}
+	defer watcher.Close()
+
+	last, err := configDigest(r.path)
+	if err != nil {
+		r.logger.Warn("Failed to read configuration file for watching",
+			logger.String("path", r.path),
+			logger.String("error", err.Error()))
+	}
+	watched := make(map[string]bool)
+	r.watchDirs(watcher, watched)
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op != fsnotify.Chmod {
+				settled = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			r.logger.Warn("Configuration file watch error", logger.String("error", err.Error()))
+		case <-settled:
+			settled = nil
+			last = r.reloadChanged(ctx, last)
+			// Includes and conf.d may have been added
+			r.watchDirs(watcher, watched)
+		}
+	}
+}
+
+// poll reloads the configuration whenever its contents change, checking
+// every interval until ctx is done
+func (r *Reloader) poll(ctx context.Context, interval time.Duration) {
+	last, err := configDigest(r.path)
+	if err != nil {
+		r.logger.Warn("Failed to read configuration file for watching",
+			logger.String("path", r.path),
+			logger.String("error", err.Error()))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			last = r.reloadChanged(ctx, last)
+		}
+	}
+}
+
+// reloadChanged reloads the configuration if its digest differs from last,
+// and returns the current digest
+func (r *Reloader) reloadChanged(ctx context.Context, last [sha256.Size]byte) [sha256.Size]byte {
+	digest, err := configDigest(r.path)
+	if err != nil || digest == last {
+		// A missing file is usually being replaced; try again
+		return last
+	}
+	r.logger.Info("Configuration file changed", logger.String("path", r.path))
+	_, _ = r.Reload(audit.WithActor(ctx, "config-watch"))
+	return digest
+}
+
+// watchDirs adds the directories of the configuration files, and its conf.d
+// directory, to watcher. Directories rather than files are watched, so that
+// files replaced by a rename, as editors and Kubernetes ConfigMaps do, are
+// still noticed.
+func (r *Reloader) watchDirs(watcher *fsnotify.Watcher, watched map[string]bool) {
+	files := []string{r.path}
+	if sources, err := config.SourceFiles(r.path); err == nil {
+		files = sources
+	}
+
+	dirs := []string{filepath.Join(filepath.Dir(r.path), "conf.d")}
+	for _, file := range files {
+		dirs = append(dirs, filepath.Dir(file))
+	}
+	for _, dir := range dirs {
+		if watched[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			r.logger.Warn("Failed to watch configuration directory",
+				logger.String("dir", dir),
+				logger.String("error", err.Error()))
+			continue
+		}
+		watched[dir] = true
+	}
+}
+
+// configDigest returns a digest of the configuration file and the files it
+// includes, or of a remote configuration document. If the includes cannot be
+// resolved, e.g. because a file is being edited, only the configuration file
+// itself is digested, so that the change still triggers a reload that
+// reports the error.
+func configDigest(path string) ([sha256.Size]byte, error) {
+	files := []string{path}
+	if !config.IsRemote(path) {
+		if sources, err := config.SourceFiles(path); err == nil {
+			files = sources
+		}
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		data, err := config.ReadSource(file)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", file, len(data))
+		hash.Write(data)
+	}
+
+	var digest [sha256.Size]byte
+	copy(digest[:], hash.Sum(nil))
+	return digest, nil
+}
@@ -0,0 +1,357 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/audit"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/logger"
+)
+
+// stubCollector is a minimal collector that falls back to the enabled regions
+type stubCollector struct {
+	name    string
+	cfg     collectors.CollectorConfig
+	regions []string
+}
+
+func (c *stubCollector) Name() string                  { return c.name }
+func (c *stubCollector) Description() string           { return "stub collector" }
+func (c *stubCollector) Health() error                 { return nil }
+func (c *stubCollector) Start(_ context.Context) error { return nil }
+func (c *stubCollector) Stop(_ context.Context) error  { return nil }
+func (c *stubCollector) Collect(_ context.Context, region string) *collectors.CollectionResult {
+	return &collectors.CollectionResult{CollectorName: c.name, Region: region}
+}
+
+func (c *stubCollector) Info() collectors.CollectorInfo {
+	return collectors.CollectorInfo{Name: c.name, EnabledRegions: c.regions, Interval: c.cfg.Interval}
+}
+
+func init() {
+	err := collectors.RegisterCollectorType("reload-test-stub", func(name string, cfg collectors.CollectorConfig, deps collectors.CollectorDependencies) (collectors.MetricCollector, error) {
+		regions := cfg.EnabledRegions
+		if len(regions) == 0 {
+			regions = deps.Config.EnabledRegions
+		}
+		return &stubCollector{name: name, cfg: cfg, regions: regions}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// testConfig returns a configuration file with the given log level, enabled
+// regions and plugin collector entries
+func testConfig(logLevel, regions, plugins string) string {
+	return fmt.Sprintf(`
+enabled_regions: %s
+aws:
+  access_key_id: "test-key"
+  secret_access_key: "test-secret"
+  default_region: us-east-1
+otel:
+  collector_endpoint: "http://localhost:4317"
+  service_name: "aws-monitor"
+global:
+  log_level: %s
+plugins:
+%s
+`, regions, logLevel, plugins)
+}
+
+func writeConfig(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+// jobRegions returns the scheduled regions of each collector
+func jobRegions(sched scheduler.Scheduler) map[string][]string {
+	regions := make(map[string][]string)
+	for _, job := range sched.GetScheduledJobs() {
+		regions[job.CollectorName] = append(regions[job.CollectorName], job.Region)
+	}
+	return regions
+}
+
+func TestReloader(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, testConfig("info", "[us-east-1]", "  []"))
+	initial, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	registry := collectors.NewCollectorRegistry(log)
+	sched := scheduler.NewMetricScheduler(scheduler.DefaultConfig(), registry, nil, log)
+	deps := collectors.CollectorDependencies{Config: initial, Logger: log}
+	reloader := NewReloader(path, initial, registry, sched, deps, log)
+	auditLog, err := audit.Open("", 0)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	reloader.SetAuditLog(auditLog)
+
+	// Enable two collectors
+	writeConfig(t, path, testConfig("info", "[us-east-1]", `
+  - name: fast
+    type: reload-test-stub
+    enabled: true
+    collection_interval: 1m
+  - name: pinned
+    type: reload-test-stub
+    enabled: true
+    regions: [us-east-1]`))
+	changes, err := reloader.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(changes.Added) != 2 {
+		t.Errorf("Expected 2 added collectors, got %v", changes.Added)
+	}
+	if len(jobRegions(sched)["fast"]) != 1 || len(jobRegions(sched)["pinned"]) != 1 {
+		t.Errorf("Expected both collectors to be scheduled, got %v", jobRegions(sched))
+	}
+
+	// Change the log level and interval, add a region and disable a collector
+	writeConfig(t, path, testConfig("debug", "[us-east-1, us-west-2]", `
+  - name: fast
+    type: reload-test-stub
+    enabled: true
+    collection_interval: 2m
+  - name: pinned
+    type: reload-test-stub
+    enabled: false
+    regions: [us-east-1]`))
+	changes, err = reloader.Reload(audit.WithActor(context.Background(), "signal:SIGHUP"))
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if changes.LogLevel != "debug" || log.Level() != "debug" {
+		t.Errorf("Expected log level debug, got change %q and level %q", changes.LogLevel, log.Level())
+	}
+	if !changes.EnabledRegions {
+		t.Error("Expected enabled regions change")
+	}
+	if len(changes.Updated) != 1 || changes.Updated[0] != "fast" {
+		t.Errorf("Expected fast to be updated, got %v", changes.Updated)
+	}
+	if len(changes.Removed) != 1 || changes.Removed[0] != "pinned" {
+		t.Errorf("Expected pinned to be removed, got %v", changes.Removed)
+	}
+	if _, exists := registry.Get("pinned"); exists {
+		t.Error("Expected pinned to be unregistered")
+	}
+	if regions := jobRegions(sched); len(regions["fast"]) != 2 || len(regions["pinned"]) != 0 {
+		t.Errorf("Expected fast in both regions and pinned unscheduled, got %v", regions)
+	}
+	for _, job := range sched.GetScheduledJobs() {
+		if job.Interval != 2*time.Minute {
+			t.Errorf("Expected the new interval of 2m, got %s", job.Interval)
+		}
+	}
+
+	// Invalid configuration keeps the running one
+	writeConfig(t, path, testConfig("verbose", "[us-east-1]", "  []"))
+	if _, err := reloader.Reload(context.Background()); err == nil {
+		t.Error("Expected an invalid configuration to be rejected")
+	}
+	if _, exists := registry.Get("fast"); !exists {
+		t.Error("Expected collectors to be kept after a failed reload")
+	}
+
+	// Applied reloads are audited; the rejected one changed nothing
+	entries := auditLog.Entries(audit.Filter{Action: audit.ActionConfigReload})
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audited reloads, got %+v", entries)
+	}
+	latest := entries[0]
+	if latest.Actor != "signal:SIGHUP" || latest.Target != path {
+		t.Errorf("Expected the reload by SIGHUP of %s, got %+v", path, latest)
+	}
+	if latest.Details["log_level"] != "debug" || latest.Details["removed"] != "pinned" ||
+		latest.Details["updated"] != "fast" || latest.Details["enabled_regions"] != "us-east-1,us-west-2" {
+		t.Errorf("Expected the applied changes in the details, got %v", latest.Details)
+	}
+	if entries[1].Actor != "system" || entries[1].Details["added"] != "fast,pinned" {
+		t.Errorf("Expected the first reload, got %+v", entries[1])
+	}
+}
+
+func TestDiffRestartRequired(t *testing.T) {
+	previous := &config.Config{}
+	current := &config.Config{}
+	current.OTEL.CollectorEndpoint = "http://collector:4317"
+	current.Global.LogLevel = "debug"
+
+	changes := Diff(previous, current)
+	if len(changes.RestartRequired) != 1 || changes.RestartRequired[0] != "otel" {
+		t.Errorf("Expected only otel to require a restart, got %v", changes.RestartRequired)
+	}
+	if changes.LogLevel != "debug" {
+		t.Errorf("Expected log level change to debug, got %q", changes.LogLevel)
+	}
+	if !Diff(previous, previous).Empty() {
+		t.Error("Expected no changes between identical configurations")
+	}
+}
+
+// waitForLogLevel waits for the reloader to have loaded level
+func waitForLogLevel(t *testing.T, reloader *Reloader, level string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for reloader.Current().Global.LogLevel != level {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the configuration with log level %s to be loaded, got %s",
+				level, reloader.Current().Global.LogLevel)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWatchReloadsOnFileNotifications(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(t, path, testConfig("info", "[us-east-1]", "  []"))
+	initial, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	registry := collectors.NewCollectorRegistry(log)
+	sched := scheduler.NewMetricScheduler(scheduler.DefaultConfig(), registry, nil, log)
+	reloader := NewReloader(path, initial, registry, sched, collectors.CollectorDependencies{Config: initial, Logger: log}, log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The interval is never reached; only notifications trigger reloads
+		reloader.Watch(ctx, time.Hour)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	// Let the watcher start before changing the file
+	time.Sleep(100 * time.Millisecond)
+
+	writeConfig(t, path, testConfig("debug", "[us-east-1]", "  []"))
+	waitForLogLevel(t, reloader, "debug")
+
+	// Editors and ConfigMaps replace the file by a rename
+	replacement := filepath.Join(dir, "config.yaml.tmp")
+	writeConfig(t, replacement, testConfig("warn", "[us-east-1]", "  []"))
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatalf("Failed to replace config: %v", err)
+	}
+	waitForLogLevel(t, reloader, "warn")
+
+	// Overlays added to a new conf.d directory are watched too
+	if err := os.Mkdir(filepath.Join(dir, "conf.d"), 0755); err != nil {
+		t.Fatalf("Failed to create conf.d: %v", err)
+	}
+	writeConfig(t, filepath.Join(dir, "conf.d", "level.yaml"), "global:\n  log_level: error\n")
+	waitForLogLevel(t, reloader, "error")
+}
+
+func TestConfigDigestIncludesOverlays(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(t, path, testConfig("info", "[us-east-1]", ""))
+
+	before, err := configDigest(path)
+	if err != nil {
+		t.Fatalf("Failed to digest config: %v", err)
+	}
+
+	// Adding a conf.d overlay changes the digest without touching the file
+	if err := os.Mkdir(filepath.Join(dir, "conf.d"), 0755); err != nil {
+		t.Fatalf("Failed to create conf.d: %v", err)
+	}
+	writeConfig(t, filepath.Join(dir, "conf.d", "debug.yaml"), "global:\n  log_level: debug\n")
+
+	after, err := configDigest(path)
+	if err != nil {
+		t.Fatalf("Failed to digest config: %v", err)
+	}
+	if before == after {
+		t.Errorf("Expected digest to change when an overlay is added")
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	previous := &config.Config{EnabledRegions: []string{"us-east-1"}}
+	previous.AWS.SecretAccessKey = "old-secret"
+	previous.Global.LogLevel = "info"
+	previous.Plugins = []config.PluginConfig{
+		{Name: "billing", Type: "acme-billing", Enabled: true},
+		{Name: "queue", Type: "exec", Enabled: true},
+	}
+
+	current := &config.Config{EnabledRegions: []string{"us-east-1", "us-west-2"}}
+	current.AWS.SecretAccessKey = "new-secret"
+	current.Global.LogLevel = "debug"
+	current.OTEL.Headers = map[string]string{"Authorization": "Bearer abc"}
+	current.Plugins = []config.PluginConfig{
+		{Name: "queue", Type: "exec", Enabled: false},
+		{Name: "costs", Type: "acme-costs", Settings: map[string]string{"token": "xyz"}},
+	}
+
+	diff, err := ConfigDiff(previous, current)
+	if err != nil {
+		t.Fatalf("Failed to diff configurations: %v", err)
+	}
+
+	var lines []string
+	for _, change := range diff {
+		lines = append(lines, change.String())
+	}
+	expected := []string{
+		"aws.secret_access_key: [REDACTED] -> [REDACTED]",
+		"enabled_regions: [us-east-1] -> [us-east-1, us-west-2]",
+		"global.log_level: info -> debug",
+		"otel.headers.Authorization: added [REDACTED]",
+		"plugins[billing]: removed",
+		"plugins[costs]: added",
+		"plugins[queue].enabled: true -> false",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %v", len(expected), len(lines), lines)
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, expected[i]) {
+			t.Errorf("Expected change %q, got %q", expected[i], line)
+		}
+	}
+
+	// Secrets of an added plugin are masked too
+	if strings.Contains(lines[5], "xyz") || !strings.Contains(lines[5], "token: '[REDACTED]'") {
+		t.Errorf("Expected the plugin token to be redacted, got %q", lines[5])
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "secret") && !strings.HasPrefix(line, "aws.secret_access_key") {
+			t.Errorf("Expected secrets not to be logged, got %q", line)
+		}
+	}
+}
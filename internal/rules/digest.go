@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"context"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/pkg/logger"
+)
+
+// collect queues the alert of a digest rule for the next digest
+func (e *Evaluator) collect(alert alerting.Alert) {
+	e.mu.Lock()
+	e.digest = append(e.digest, alert)
+	e.mu.Unlock()
+
+	e.logger.Info("Alert rule collected for the digest",
+		logger.String("rule", alert.Rule),
+		logger.String("state", alert.State),
+		logger.String("series", alerting.SeriesKey(alert.Labels)),
+		logger.Float64("value", alert.Value))
+}
+
+// FlushDigest sends the alerts collected since the last digest as a single
+// alert. It does nothing if none were collected.
+func (e *Evaluator) FlushDigest() {
+	e.mu.Lock()
+	collected := e.digest
+	e.digest = nil
+	e.mu.Unlock()
+
+	if len(collected) == 0 {
+		return
+	}
+
+	notified := false
+	if e.notifier != nil {
+		notified = e.notifier.Notify(alerting.NewDigestAlert(collected))
+	}
+	e.logger.Info("Alert digest",
+		logger.Int("alerts", len(collected)),
+		logger.Bool("notified", notified))
+}
+
+// RunDigests sends the digest every interval until ctx is done. The alerts
+// collected since the last digest are sent by a final FlushDigest, such as on
+// shutdown.
+func (e *Evaluator) RunDigests(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.FlushDigest()
+		}
+	}
+}
+
+// HasDigestRules reports whether any rule collects its alerts into the digest
+func (e *Evaluator) HasDigestRules() bool {
+	for _, r := range e.rules {
+		if r.digest {
+			return true
+		}
+	}
+	return false
+}
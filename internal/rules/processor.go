@@ -0,0 +1,36 @@
+package rules
+
+import (
+	"context"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+)
+
+// Processor implements scheduler.JobProcessor by evaluating the alert rules
+// over the metrics of every successful collection run before passing it on
+// to the next processor. Failed runs leave the rules' state unchanged, so a
+// collector that fails does not resolve the alerts firing for its series.
+type Processor struct {
+	next      scheduler.JobProcessor
+	evaluator *Evaluator
+}
+
+// NewProcessor creates a processor evaluating the rules of evaluator
+func NewProcessor(next scheduler.JobProcessor, evaluator *Evaluator) *Processor {
+	return &Processor{next: next, evaluator: evaluator}
+}
+
+// ProcessResult evaluates the rules over the metrics of a successful run
+func (p *Processor) ProcessResult(ctx context.Context, job *scheduler.ScheduledJob, result *collectors.CollectionResult) error {
+	if result.Error == nil {
+		p.evaluator.Evaluate(job.CollectorName+"/"+job.Region, result.Metrics)
+	}
+	return p.next.ProcessResult(ctx, job, result)
+}
+
+// ProcessError passes the error on to the next processor
+func (p *Processor) ProcessError(ctx context.Context, job *scheduler.ScheduledJob, err *errors.Error) error {
+	return p.next.ProcessError(ctx, job, err)
+}
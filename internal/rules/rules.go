@@ -0,0 +1,371 @@
+// Package rules evaluates the threshold alert rules of the configuration over
+// the metrics collected by each run, and notifies when a rule starts firing
+// for a series and when it resolves.
+package rules
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// Notifier delivers alerts, e.g. *alerting.Notifier
+type Notifier interface {
+	Notify(alert alerting.Alert) bool
+}
+
+// rule is a parsed alert rule
+type rule struct {
+	name      string
+	metric    string
+	labels    map[string]string
+	operator  string
+	threshold float64
+	forPeriod time.Duration
+	severity  errors.Severity
+	// change is the period over which the percentage change of the value is
+	// compared instead of the value, if set
+	change time.Duration
+	// digest collects the rule's alerts into the digest instead of sending
+	// them
+	digest bool
+}
+
+// matches reports whether the metric is a series the rule applies to
+func (r *rule) matches(metric collectors.MetricData) bool {
+	if metric.Name != r.metric {
+		return false
+	}
+	for name, value := range r.labels {
+		if metric.Labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// breached reports whether value compares to the threshold with the rule's
+// operator
+func (r *rule) breached(value float64) bool {
+	switch r.operator {
+	case ">":
+		return value > r.threshold
+	case ">=":
+		return value >= r.threshold
+	case "<":
+		return value < r.threshold
+	case "<=":
+		return value <= r.threshold
+	case "==":
+		return value == r.threshold
+	case "!=":
+		return value != r.threshold
+	}
+	return false
+}
+
+// series is the state of a series breaching a rule
+type series struct {
+	rule   *rule
+	labels map[string]string
+	// source is the collector and region whose runs report the series
+	source string
+	since  time.Time
+	value  float64
+	firing bool
+	// firedAt is when the rule started firing for the series
+	firedAt time.Time
+}
+
+// sample is a value of a series at a time
+type sample struct {
+	time  time.Time
+	value float64
+}
+
+// history is the recent values of a series, for rules comparing the change of
+// the value
+type history struct {
+	source  string
+	samples []sample
+}
+
+// change records the value of a series at a time and returns its percentage
+// change from the latest value at least period earlier. A value for the same
+// time replaces the one recorded, as a day's spend does while the day goes on.
+// It reports false while there is no earlier value or it is zero.
+func (h *history) change(at time.Time, value float64, period time.Duration) (float64, bool) {
+	if n := len(h.samples); n > 0 && !at.After(h.samples[n-1].time) {
+		if at.Equal(h.samples[n-1].time) {
+			h.samples[n-1].value = value
+		} else {
+			// Out of order values can't be compared
+			return 0, false
+		}
+	} else {
+		h.samples = append(h.samples, sample{time: at, value: value})
+	}
+
+	// Keep the latest value at least period old as the baseline, and
+	// everything newer
+	baseline := -1
+	for i, s := range h.samples {
+		if !s.time.After(at.Add(-period)) {
+			baseline = i
+		}
+	}
+	if baseline < 0 {
+		return 0, false
+	}
+	h.samples = h.samples[baseline:]
+
+	previous := h.samples[0].value
+	if previous == 0 {
+		return 0, false
+	}
+	return (value - previous) / math.Abs(previous) * 100, true
+}
+
+// ruleAlert is an alert of a rule, sent or collected into the digest
+type ruleAlert struct {
+	alert  alerting.Alert
+	digest bool
+}
+
+// Evaluator tracks, per rule and series, how long the rule's threshold has
+// been breached. A rule fires for a series once the threshold has been
+// breached in every run for its for period, and resolves when a run reports
+// the series within the threshold or no longer reports it. Rules with a change
+// period compare the percentage change of the value over that period, such as
+// day-over-day spend. Alerts are logged, and sent if a notifier is set, or
+// collected into a periodic digest for digest rules.
+type Evaluator struct {
+	rules    []*rule
+	notifier Notifier
+	logger   *logger.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	series  map[string]*series
+	history map[string]*history
+	digest  []alerting.Alert
+}
+
+// NewEvaluator creates an evaluator for the configured rules
+func NewEvaluator(rules []config.AlertRuleConfig, log *logger.Logger) *Evaluator {
+	e := &Evaluator{
+		logger:  log.WithComponent("alert-rules"),
+		now:     time.Now,
+		series:  make(map[string]*series),
+		history: make(map[string]*history),
+	}
+	for _, cfg := range rules {
+		e.rules = append(e.rules, &rule{
+			name:      cfg.Name,
+			metric:    cfg.Metric,
+			labels:    cfg.Labels,
+			operator:  cfg.Operator,
+			threshold: cfg.Threshold,
+			forPeriod: time.Duration(cfg.For),
+			severity:  errors.Severity(cfg.Severity),
+			change:    time.Duration(cfg.Change),
+			digest:    cfg.Digest,
+		})
+	}
+	return e
+}
+
+// SetNotifier sets where alerts are sent
+func (e *Evaluator) SetNotifier(notifier Notifier) {
+	e.notifier = notifier
+}
+
+// Evaluate applies the rules to the metrics of a collection run. source
+// identifies the collector and region of the run, so that the series it no
+// longer reports are resolved.
+func (e *Evaluator) Evaluate(source string, metrics []collectors.MetricData) {
+	var alerts []ruleAlert
+
+	e.mu.Lock()
+	now := e.now()
+	seen := make(map[string]bool)
+	for _, metric := range metrics {
+		for _, r := range e.rules {
+			if !r.matches(metric) {
+				continue
+			}
+
+			key := r.name + "/" + alerting.SeriesKey(metric.Labels)
+			seen[key] = true
+			value, ok := e.value(r, key, source, metric, now)
+			if !ok {
+				continue
+			}
+
+			state := e.series[key]
+			if !r.breached(value) {
+				if state != nil {
+					delete(e.series, key)
+					if state.firing {
+						state.value = value
+						alerts = append(alerts, ruleAlert{resolvedAlert(state, true), r.digest})
+					}
+				}
+				continue
+			}
+
+			if state == nil {
+				// The metric's labels are reused once the collection is processed
+				state = &series{rule: r, labels: collectors.CloneLabels(metric.Labels), source: source, since: now}
+				e.series[key] = state
+			}
+			state.value = value
+			if !state.firing && now.Sub(state.since) >= r.forPeriod {
+				state.firing = true
+				state.firedAt = now
+				alerts = append(alerts, ruleAlert{firingAlert(state), r.digest})
+			}
+		}
+	}
+
+	var gone []string
+	for key, state := range e.series {
+		if state.source == source && !seen[key] {
+			gone = append(gone, key)
+		}
+	}
+	sort.Strings(gone)
+	for _, key := range gone {
+		if state := e.series[key]; state.firing {
+			alerts = append(alerts, ruleAlert{resolvedAlert(state, false), state.rule.digest})
+		}
+		delete(e.series, key)
+	}
+	for key, h := range e.history {
+		if h.source == source && !seen[key] {
+			delete(e.history, key)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, a := range alerts {
+		if a.digest {
+			e.collect(a.alert)
+		} else {
+			e.notify(a.alert)
+		}
+	}
+}
+
+// value returns the value of a metric the rule compares: the metric value, or
+// its percentage change for rules with a change period. It reports false
+// while the change can't be computed yet.
+func (e *Evaluator) value(r *rule, key, source string, metric collectors.MetricData, now time.Time) (float64, bool) {
+	if r.change == 0 {
+		return metric.Value, true
+	}
+
+	h := e.history[key]
+	if h == nil {
+		h = &history{source: source}
+		e.history[key] = h
+	}
+	at := metric.Timestamp
+	if at.IsZero() {
+		at = now
+	}
+	return h.change(at, metric.Value, r.change)
+}
+
+// Firing returns the number of series each rule is firing for
+func (e *Evaluator) Firing() map[string]int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	firing := make(map[string]int)
+	for _, state := range e.series {
+		if state.firing {
+			firing[state.rule.name]++
+		}
+	}
+	return firing
+}
+
+// FiringAlerts returns the alerts of the series the rules are firing for,
+// ordered by rule and series
+func (e *Evaluator) FiringAlerts() []alerting.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := make([]string, 0, len(e.series))
+	for key, state := range e.series {
+		if state.firing {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	alerts := make([]alerting.Alert, 0, len(keys))
+	for _, key := range keys {
+		alert := firingAlert(e.series[key])
+		alert.Time = e.series[key].firedAt
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// notify logs the alert and sends it
+func (e *Evaluator) notify(alert alerting.Alert) {
+	notified := false
+	if e.notifier != nil {
+		notified = e.notifier.Notify(alert)
+	}
+
+	fields := []logger.Field{
+		logger.String("rule", alert.Rule),
+		logger.String("series", alerting.SeriesKey(alert.Labels)),
+		logger.Float64("value", alert.Value),
+		logger.Bool("notified", notified),
+	}
+	if alert.State == alerting.RuleFiring {
+		e.logger.Warn("Alert rule firing", fields...)
+	} else {
+		e.logger.Info("Alert rule resolved", fields...)
+	}
+}
+
+// firingAlert returns the alert for a rule starting to fire for a series
+func firingAlert(state *series) alerting.Alert {
+	r := state.rule
+	message := fmt.Sprintf("%s{%s} is %g, %s %g", r.metric, alerting.SeriesKey(state.labels), state.value, r.operator, r.threshold)
+	if r.change > 0 {
+		message = fmt.Sprintf("%s{%s} changed by %+.1f%% over %s, %s %g%%", r.metric, alerting.SeriesKey(state.labels), state.value, r.change, r.operator, r.threshold)
+	}
+	if r.forPeriod > 0 {
+		message += " for " + r.forPeriod.String()
+	}
+	return alerting.NewRuleAlert(r.name, alerting.RuleFiring, r.severity, state.labels, state.value, message)
+}
+
+// resolvedAlert returns the alert for a rule no longer firing for a series,
+// either because it is within the threshold or because it is no longer
+// reported
+func resolvedAlert(state *series, reported bool) alerting.Alert {
+	r := state.rule
+	message := fmt.Sprintf("%s{%s} is %g, no longer %s %g", r.metric, alerting.SeriesKey(state.labels), state.value, r.operator, r.threshold)
+	if r.change > 0 {
+		message = fmt.Sprintf("%s{%s} changed by %+.1f%% over %s, no longer %s %g%%", r.metric, alerting.SeriesKey(state.labels), state.value, r.change, r.operator, r.threshold)
+	}
+	if !reported {
+		message = fmt.Sprintf("%s{%s} is no longer reported", r.metric, alerting.SeriesKey(state.labels))
+	}
+	return alerting.NewRuleAlert(r.name, alerting.RuleResolved, r.severity, state.labels, state.value, message)
+}
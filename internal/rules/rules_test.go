@@ -0,0 +1,303 @@
+package rules
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/alerting"
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// recordingNotifier records the alerts it is given
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []alerting.Alert
+}
+
+func (n *recordingNotifier) Notify(alert alerting.Alert) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return true
+}
+
+func newTestEvaluator(t *testing.T, rules ...config.AlertRuleConfig) (*Evaluator, *recordingNotifier, *time.Time) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	notifier := &recordingNotifier{}
+	evaluator := NewEvaluator(rules, log)
+	evaluator.SetNotifier(notifier)
+	evaluator.now = func() time.Time { return now }
+	return evaluator, notifier, &now
+}
+
+func queueDepth(queue string, value float64) collectors.MetricData {
+	return collectors.MetricData{
+		Name:   "aws_sqs_queue_depth",
+		Value:  value,
+		Labels: map[string]string{"collector": "sqs", "region": "us-east-1", "queue": queue},
+	}
+}
+
+func TestEvaluatorFiresAfterForPeriod(t *testing.T) {
+	evaluator, notifier, now := newTestEvaluator(t, config.AlertRuleConfig{
+		Name: "queue-backlog", Metric: "aws_sqs_queue_depth", Operator: ">", Threshold: 1000,
+		For: config.Duration(5 * time.Minute), Severity: "critical",
+	})
+
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 1200)})
+	*now = now.Add(3 * time.Minute)
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 1300)})
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("Expected no alert before the for period, got %d", len(notifier.alerts))
+	}
+
+	*now = now.Add(2 * time.Minute)
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 1400)})
+	*now = now.Add(time.Minute)
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 1500)})
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected 1 alert once the for period passed, got %d", len(notifier.alerts))
+	}
+	alert := notifier.alerts[0]
+	if alert.Rule != "queue-backlog" || alert.State != alerting.RuleFiring || alert.Severity != errors.SeverityCritical {
+		t.Errorf("Expected a critical firing alert for the rule, got %+v", alert)
+	}
+	if alert.Value != 1400 || !strings.Contains(alert.Message, "> 1000 for 5m0s") {
+		t.Errorf("Expected the breaching value and condition, got %g, %q", alert.Value, alert.Message)
+	}
+	if firing := evaluator.Firing(); firing["queue-backlog"] != 1 {
+		t.Errorf("Expected the rule to fire for 1 series, got %v", firing)
+	}
+	if alerts := evaluator.FiringAlerts(); len(alerts) != 1 || !alerts[0].Time.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Expected 1 firing alert since the rule fired, got %+v", alerts)
+	}
+
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 10)})
+	if len(notifier.alerts) != 2 || notifier.alerts[1].State != alerting.RuleResolved {
+		t.Fatalf("Expected a resolved alert, got %+v", notifier.alerts)
+	}
+	if firing := evaluator.Firing(); len(firing) != 0 {
+		t.Errorf("Expected no firing rules, got %v", firing)
+	}
+}
+
+func TestEvaluatorResetsPendingSeries(t *testing.T) {
+	evaluator, notifier, now := newTestEvaluator(t, config.AlertRuleConfig{
+		Name: "queue-backlog", Metric: "aws_sqs_queue_depth", Operator: ">=", Threshold: 1000,
+		For: config.Duration(5 * time.Minute), Severity: "high",
+	})
+
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 1000)})
+	*now = now.Add(3 * time.Minute)
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 999)})
+	*now = now.Add(3 * time.Minute)
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 1000)})
+	if len(notifier.alerts) != 0 {
+		t.Errorf("Expected a recovered series to start its for period over, got %d alerts", len(notifier.alerts))
+	}
+}
+
+func TestEvaluatorLabelSelector(t *testing.T) {
+	evaluator, notifier, _ := newTestEvaluator(t, config.AlertRuleConfig{
+		Name: "orders-backlog", Metric: "aws_sqs_queue_depth", Labels: map[string]string{"queue": "orders"},
+		Operator: ">", Threshold: 100, Severity: "high",
+	})
+
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{
+		queueDepth("orders", 500),
+		queueDepth("payments", 500),
+		{Name: "aws_sqs_oldest_message_age", Value: 500, Labels: map[string]string{"queue": "orders"}},
+	})
+	if len(notifier.alerts) != 1 || notifier.alerts[0].Labels["queue"] != "orders" {
+		t.Errorf("Expected 1 alert for the selected series, got %+v", notifier.alerts)
+	}
+}
+
+func TestEvaluatorResolvesSeriesNoLongerReported(t *testing.T) {
+	evaluator, notifier, _ := newTestEvaluator(t, config.AlertRuleConfig{
+		Name: "queue-backlog", Metric: "aws_sqs_queue_depth", Operator: ">", Threshold: 100, Severity: "high",
+	})
+
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 500), queueDepth("payments", 500)})
+	// Another collector or region doesn't report the series
+	evaluator.Evaluate("sqs/eu-west-1", nil)
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("Expected 2 firing alerts, got %d", len(notifier.alerts))
+	}
+
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 500)})
+	if len(notifier.alerts) != 3 {
+		t.Fatalf("Expected a resolved alert for the deleted queue, got %d alerts", len(notifier.alerts))
+	}
+	resolved := notifier.alerts[2]
+	if resolved.State != alerting.RuleResolved || resolved.Labels["queue"] != "payments" {
+		t.Errorf("Expected the payments queue to resolve, got %+v", resolved)
+	}
+	if !strings.Contains(resolved.Message, "no longer reported") {
+		t.Errorf("Expected the message to say the series is gone, got %q", resolved.Message)
+	}
+}
+
+func dailySpend(day time.Time, value float64) collectors.MetricData {
+	return collectors.MetricData{
+		Name:      "aws_cost_daily_amount",
+		Value:     value,
+		Timestamp: day,
+		Labels:    map[string]string{"collector": "billing", "region": "us-east-1", "service": "Amazon EC2"},
+	}
+}
+
+func TestEvaluatorChangeRule(t *testing.T) {
+	evaluator, notifier, _ := newTestEvaluator(t, config.AlertRuleConfig{
+		Name: "ec2-cost-spike", Metric: "aws_cost_daily_amount", Labels: map[string]string{"service": "Amazon EC2"},
+		Operator: ">", Threshold: 30, Change: config.Duration(24 * time.Hour), Severity: "high",
+	})
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	evaluator.Evaluate("billing/us-east-1", []collectors.MetricData{dailySpend(day, 100)})
+	// The day's spend grows as the day goes on, replacing its value
+	evaluator.Evaluate("billing/us-east-1", []collectors.MetricData{dailySpend(day.AddDate(0, 0, 1), 90)})
+	evaluator.Evaluate("billing/us-east-1", []collectors.MetricData{dailySpend(day.AddDate(0, 0, 1), 120)})
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("Expected no alert for a 20%% increase, got %+v", notifier.alerts)
+	}
+
+	evaluator.Evaluate("billing/us-east-1", []collectors.MetricData{dailySpend(day.AddDate(0, 0, 2), 180)})
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected an alert for a 50%% increase, got %d alerts", len(notifier.alerts))
+	}
+	alert := notifier.alerts[0]
+	if alert.Value != 50 || !strings.Contains(alert.Message, "changed by +50.0% over 24h0m0s") {
+		t.Errorf("Expected the percentage change, got %g, %q", alert.Value, alert.Message)
+	}
+
+	evaluator.Evaluate("billing/us-east-1", []collectors.MetricData{dailySpend(day.AddDate(0, 0, 3), 170)})
+	if len(notifier.alerts) != 2 || notifier.alerts[1].State != alerting.RuleResolved {
+		t.Errorf("Expected the spike to resolve when spend falls, got %+v", notifier.alerts)
+	}
+}
+
+func TestHistoryChange(t *testing.T) {
+	h := &history{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, ok := h.change(start, 0, time.Hour); ok {
+		t.Error("Expected no change without an earlier value")
+	}
+	if _, ok := h.change(start.Add(time.Hour), 10, time.Hour); ok {
+		t.Error("Expected no change from a zero value")
+	}
+	if change, ok := h.change(start.Add(2*time.Hour), 5, time.Hour); !ok || change != -50 {
+		t.Errorf("Expected a change of -50%%, got %g, %v", change, ok)
+	}
+	if _, ok := h.change(start, 5, time.Hour); ok {
+		t.Error("Expected an out of order value not to be compared")
+	}
+	if len(h.samples) != 2 {
+		t.Errorf("Expected values older than the baseline to be dropped, got %d", len(h.samples))
+	}
+}
+
+func TestEvaluatorDigest(t *testing.T) {
+	evaluator, notifier, _ := newTestEvaluator(t,
+		config.AlertRuleConfig{
+			Name: "queue-backlog", Metric: "aws_sqs_queue_depth", Operator: ">", Threshold: 100, Severity: "medium", Digest: true,
+		},
+		config.AlertRuleConfig{
+			Name: "orders-backlog", Metric: "aws_sqs_queue_depth", Labels: map[string]string{"queue": "orders"},
+			Operator: ">", Threshold: 1000, Severity: "critical",
+		})
+	if !evaluator.HasDigestRules() {
+		t.Fatal("Expected a digest rule")
+	}
+
+	evaluator.Evaluate("sqs/us-east-1", []collectors.MetricData{queueDepth("orders", 5000), queueDepth("payments", 500)})
+	if len(notifier.alerts) != 1 || notifier.alerts[0].Rule != "orders-backlog" {
+		t.Fatalf("Expected only the immediate rule's alert to be sent, got %+v", notifier.alerts)
+	}
+
+	evaluator.FlushDigest()
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("Expected the digest to be sent, got %d alerts", len(notifier.alerts))
+	}
+	digest := notifier.alerts[1]
+	if len(digest.Digest) != 2 || digest.Severity != errors.SeverityMedium {
+		t.Errorf("Expected a medium digest of 2 alerts, got %s with %d", digest.Severity, len(digest.Digest))
+	}
+	if title := digest.Title(); title != "[MEDIUM] aws-monitor alert digest: 2 alerts, 2 firing" {
+		t.Errorf("Expected digest title, got %q", title)
+	}
+
+	evaluator.FlushDigest()
+	if len(notifier.alerts) != 2 {
+		t.Errorf("Expected no digest without collected alerts, got %d alerts", len(notifier.alerts))
+	}
+}
+
+func TestRuleOperators(t *testing.T) {
+	tests := []struct {
+		operator string
+		value    float64
+		want     bool
+	}{
+		{">", 11, true}, {">", 10, false},
+		{">=", 10, true}, {">=", 9, false},
+		{"<", 9, true}, {"<", 10, false},
+		{"<=", 10, true}, {"<=", 11, false},
+		{"==", 10, true}, {"==", 11, false},
+		{"!=", 11, true}, {"!=", 10, false},
+	}
+	for _, tt := range tests {
+		r := &rule{operator: tt.operator, threshold: 10}
+		if got := r.breached(tt.value); got != tt.want {
+			t.Errorf("Expected %g %s 10 to be %v, got %v", tt.value, tt.operator, tt.want, got)
+		}
+	}
+}
+
+// countingProcessor counts the results passed on to it
+type countingProcessor struct {
+	results int
+}
+
+func (p *countingProcessor) ProcessResult(_ context.Context, _ *scheduler.ScheduledJob, _ *collectors.CollectionResult) error {
+	p.results++
+	return nil
+}
+
+func (p *countingProcessor) ProcessError(_ context.Context, _ *scheduler.ScheduledJob, _ *errors.Error) error {
+	return nil
+}
+
+func TestProcessor(t *testing.T) {
+	evaluator, notifier, _ := newTestEvaluator(t, config.AlertRuleConfig{
+		Name: "queue-backlog", Metric: "aws_sqs_queue_depth", Operator: ">", Threshold: 100, Severity: "high",
+	})
+	next := &countingProcessor{}
+	p := NewProcessor(next, evaluator)
+	ctx := context.Background()
+	job := &scheduler.ScheduledJob{CollectorName: "sqs", Region: "us-east-1"}
+
+	_ = p.ProcessResult(ctx, job, &collectors.CollectionResult{Metrics: []collectors.MetricData{queueDepth("orders", 500)}})
+	// A failed run doesn't resolve the series it didn't report
+	_ = p.ProcessResult(ctx, job, &collectors.CollectionResult{Error: errors.NewTimeoutError("collect", time.Second)})
+
+	if next.results != 2 {
+		t.Errorf("Expected 2 results passed on, got %d", next.results)
+	}
+	if len(notifier.alerts) != 1 || notifier.alerts[0].State != alerting.RuleFiring {
+		t.Errorf("Expected only the firing alert, got %+v", notifier.alerts)
+	}
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"aws-monitoring/internal/tracing"
+	"aws-monitoring/pkg/logger"
+)
+
+// batchRegions separates the due jobs that run in region batches from the
+// jobs that run alone. The batched jobs of each collector are returned
+// together, sorted by region.
+func (s *MetricScheduler) batchRegions(jobs []*ScheduledJob) ([]*ScheduledJob, [][]*ScheduledJob) {
+	alone := make([]*ScheduledJob, 0, len(jobs))
+	byBatch := make(map[string][]*ScheduledJob)
+	var keys []string
+	for _, job := range jobs {
+		if job.Batch == "" {
+			alone = append(alone, job)
+			continue
+		}
+		if _, seen := byBatch[job.Batch]; !seen {
+			keys = append(keys, job.Batch)
+		}
+		byBatch[job.Batch] = append(byBatch[job.Batch], job)
+	}
+
+	sort.Strings(keys)
+	batches := make([][]*ScheduledJob, 0, len(keys))
+	for _, key := range keys {
+		batch := byBatch[key]
+		sort.Slice(batch, func(i, j int) bool { return batch[i].Region < batch[j].Region })
+		batches = append(batches, batch)
+	}
+	return alone, batches
+}
+
+// startRegionBatches marks the jobs of a collector's regions active, so
+// later ticks don't start them again while they wait for their batch, and
+// runs them in the job slot acquired by the caller
+func (s *MetricScheduler) startRegionBatches(ctx context.Context, jobs []*ScheduledJob) {
+	batchCtx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	for _, job := range jobs {
+		s.activeJobs[job.ID] = cancel
+	}
+	s.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		s.executeRegionBatches(batchCtx, jobs)
+	}()
+}
+
+// executeRegionBatches runs the jobs of a collector's regions in batches of
+// RegionBatchSize regions collected in parallel. Each region is isolated: its
+// failure, even a collector panic, is reported for that region only and the
+// other regions and batches still run. A single summary reports the whole
+// run.
+func (s *MetricScheduler) executeRegionBatches(ctx context.Context, jobs []*ScheduledJob) {
+	defer s.inFlight.Done()
+	defer func() { <-s.jobSemaphore }() // Release semaphore
+
+	collectorName := jobs[0].CollectorName
+	batchSize := s.config.RegionBatchSize
+	if batchSize <= 0 {
+		batchSize = len(jobs)
+	}
+	start := time.Now()
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "region batches",
+		trace.WithAttributes(tracing.AttrCollector.String(collectorName)))
+	defer span.End()
+
+	for first := 0; first < len(jobs); first += batchSize {
+		if ctx.Err() != nil {
+			break
+		}
+		last := first + batchSize
+		if last > len(jobs) {
+			last = len(jobs)
+		}
+
+		var wg sync.WaitGroup
+		for _, job := range jobs[first:last] {
+			wg.Add(1)
+			go func(job *ScheduledJob) {
+				defer wg.Done()
+				s.runJob(ctx, job)
+			}(job)
+		}
+		wg.Wait()
+	}
+
+	// Jobs left unstarted because the run was cancelled are due again
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var metricCount int
+	var succeeded, failed, skipped []string
+	for _, job := range jobs {
+		if _, pending := s.activeJobs[job.ID]; pending {
+			delete(s.activeJobs, job.ID)
+			skipped = append(skipped, job.Region)
+			continue
+		}
+		metricCount += job.LastMetricCount
+		if job.LastResult == nil || job.LastResult.Error != nil {
+			failed = append(failed, job.Region)
+		} else {
+			succeeded = append(succeeded, job.Region)
+		}
+	}
+
+	fields := []logger.Field{
+		logger.String("collector", collectorName),
+		logger.Int("regions", len(jobs)),
+		logger.Int("batch_size", batchSize),
+		logger.Int("succeeded", len(succeeded)),
+		logger.Int("metric_count", metricCount),
+		logger.Duration("duration", time.Since(start)),
+	}
+	if len(failed) > 0 {
+		fields = append(fields, logger.Strings("failed_regions", failed))
+	}
+	if len(skipped) > 0 {
+		fields = append(fields, logger.Strings("skipped_regions", skipped))
+	}
+	if len(failed) > 0 || len(skipped) > 0 {
+		s.logger.Warn("Region batches completed with failures", fields...)
+	} else {
+		s.logger.Info("Region batches completed", fields...)
+	}
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+)
+
+func TestScheduleCollectorBatchesRegions(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	scheduler.config.RegionBatchThreshold = 3
+	scheduler.config.WarmupWindow = time.Minute
+
+	for _, name := range []string{"many-regions", "few-regions"} {
+		if err := registry.Register(&mockCollector{name: name}); err != nil {
+			t.Fatalf("Failed to register collector: %v", err)
+		}
+	}
+	regions := []string{"us-east-1", "us-west-2", "eu-west-1"}
+	if err := scheduler.ScheduleCollector("many-regions", regions, time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	if err := scheduler.ScheduleCollector("few-regions", regions[:2], time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+
+	first := scheduler.jobs["many-regions-us-east-1"]
+	if first.Batch == "" {
+		t.Fatal("Expected a collector scheduled in 3 regions to run in region batches")
+	}
+	for _, region := range regions {
+		job := scheduler.jobs["many-regions-"+region]
+		if job.Batch != first.Batch || job.NextRun.Sub(first.NextRun).Abs() > time.Second {
+			t.Errorf("Expected the batched jobs to start together, got %s at %v", job.Batch, job.NextRun)
+		}
+	}
+	if batch := scheduler.jobs["few-regions-us-east-1"].Batch; batch != "" {
+		t.Errorf("Expected a collector in 2 regions to run as separate jobs, got batch %q", batch)
+	}
+
+	var due []*ScheduledJob
+	for _, job := range scheduler.jobs {
+		due = append(due, job)
+	}
+	alone, batches := scheduler.batchRegions(due)
+	if len(alone) != 2 || len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("Expected 2 separate jobs and 1 batch of 3 regions, got %d and %v", len(alone), batches)
+	}
+	if batches[0][0].Region != "eu-west-1" {
+		t.Errorf("Expected the batch sorted by region, got %s first", batches[0][0].Region)
+	}
+}
+
+func TestExecuteRegionBatchesIsolatesRegions(t *testing.T) {
+	scheduler, registry, processor, _ := setupTest()
+	scheduler.config.RegionBatchThreshold = 3
+	scheduler.config.RegionBatchSize = 2
+
+	collector := &mockCollector{name: "many-regions"}
+	collector.collectFunc = func(_ context.Context, region string) *collectors.CollectionResult {
+		if region == "us-east-1" {
+			panic("collector bug")
+		}
+		return &collectors.CollectionResult{
+			CollectorName: "many-regions",
+			Region:        region,
+			Metrics:       []collectors.MetricData{{Name: "test_metric", Value: 1}},
+		}
+	}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	regions := []string{"eu-west-1", "us-east-1", "us-west-2"}
+	if err := scheduler.ScheduleCollector("many-regions", regions, time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+
+	var jobs []*ScheduledJob
+	for _, region := range regions {
+		jobs = append(jobs, scheduler.jobs["many-regions-"+region])
+	}
+	scheduler.jobSemaphore <- struct{}{}
+	scheduler.inFlight.Add(1)
+	scheduler.startRegionBatches(context.Background(), jobs)
+	scheduler.inFlight.Wait()
+
+	if len(processor.GetResults()) != 2 {
+		t.Errorf("Expected the results of the 2 healthy regions, got %d", len(processor.GetResults()))
+	}
+	if errs := processor.GetErrors(); len(errs) != 1 || errs[0].Job.Region != "us-east-1" {
+		t.Errorf("Expected the panic to fail only its region, got %+v", errs)
+	}
+	for _, job := range jobs {
+		if job.LastRun == nil {
+			t.Errorf("Expected region %s to run", job.Region)
+		}
+	}
+	if info := scheduler.GetInfo(); info.ActiveJobs != 0 || info.CompletedJobs != 2 || info.FailedJobs != 1 {
+		t.Errorf("Expected no active jobs, 2 completed and 1 failed, got %+v", info)
+	}
+}
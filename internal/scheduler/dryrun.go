@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// DryRunMetric is a line of dry run output: a collected metric with the job
+// that collected it
+type DryRunMetric struct {
+	Collector string            `json:"collector"`
+	Region    string            `json:"region"`
+	Name      string            `json:"name"`
+	Value     float64           `json:"value"`
+	Unit      string            `json:"unit"`
+	Timestamp time.Time         `json:"timestamp"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// DryRunSummary is the volume of metrics written by a DryRunProcessor
+type DryRunSummary struct {
+	// Results is the number of collection results processed
+	Results int `json:"results"`
+	// Metrics is the number of data points written
+	Metrics int `json:"metrics"`
+	// Series is the number of distinct metric name and label combinations,
+	// the cardinality a backend would store
+	Series int `json:"series"`
+}
+
+// DryRunProcessor implements JobProcessor by writing collected metrics to a
+// writer, one JSON object per line, instead of exporting them, so their shape
+// and volume can be checked before pointing aws-monitor at a backend
+type DryRunProcessor struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	errors  JobProcessor
+	logger  *logger.Logger
+	summary DryRunSummary
+	series  map[string]bool
+}
+
+// NewDryRunProcessor creates a dry run processor writing to w. Collection
+// errors are logged as by the default processor.
+func NewDryRunProcessor(w io.Writer, log *logger.Logger) *DryRunProcessor {
+	return &DryRunProcessor{
+		encoder: json.NewEncoder(w),
+		errors:  NewDefaultJobProcessor(log),
+		logger:  log.WithComponent("dry-run"),
+		series:  make(map[string]bool),
+	}
+}
+
+// ProcessResult writes the metrics of a collection result
+func (p *DryRunProcessor) ProcessResult(_ context.Context, job *ScheduledJob, result *collectors.CollectionResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.summary.Results++
+	for _, metric := range result.Metrics {
+		if err := p.encoder.Encode(DryRunMetric{
+			Collector: job.CollectorName,
+			Region:    job.Region,
+			Name:      metric.Name,
+			Value:     metric.Value,
+			Unit:      metric.Unit,
+			Timestamp: metric.Timestamp,
+			Labels:    metric.Labels,
+		}); err != nil {
+			return err
+		}
+		p.summary.Metrics++
+		p.series[seriesKey(metric)] = true
+	}
+	p.summary.Series = len(p.series)
+
+	p.logger.Debug("Dry run metrics written",
+		logger.String("collector", job.CollectorName),
+		logger.String("region", job.Region),
+		logger.Int("metric_count", len(result.Metrics)))
+	return nil
+}
+
+// ProcessError logs a collection error
+func (p *DryRunProcessor) ProcessError(ctx context.Context, job *ScheduledJob, err *errors.Error) error {
+	return p.errors.ProcessError(ctx, job, err)
+}
+
+// Summary returns the volume of metrics written so far
+func (p *DryRunProcessor) Summary() DryRunSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.summary
+}
+
+// seriesKey identifies the series of a metric by its name and sorted labels
+func seriesKey(metric collectors.MetricData) string {
+	labels := make([]string, 0, len(metric.Labels))
+	for key, value := range metric.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	return metric.Name + "{" + strings.Join(labels, ",") + "}"
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestDryRunProcessor(t *testing.T) {
+	log, _ := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+
+	var out strings.Builder
+	processor := NewDryRunProcessor(&out, log)
+
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	job := &ScheduledJob{CollectorName: "queue-depth", Region: "us-east-1"}
+	result := &collectors.CollectionResult{Metrics: []collectors.MetricData{
+		{Name: "queue_depth", Value: 42, Unit: "Count", Timestamp: timestamp, Labels: map[string]string{"queue": "jobs"}},
+		{Name: "queue_depth", Value: 7, Unit: "Count", Timestamp: timestamp, Labels: map[string]string{"queue": "emails"}},
+	}}
+	for i := 0; i < 2; i++ {
+		if err := processor.ProcessResult(context.Background(), job, result); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	var lines []DryRunMetric
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var metric DryRunMetric
+		if err := json.Unmarshal(scanner.Bytes(), &metric); err != nil {
+			t.Fatalf("Expected a JSON object per line, got %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, metric)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 metric lines, got %d", len(lines))
+	}
+	first := lines[0]
+	if first.Collector != "queue-depth" || first.Region != "us-east-1" || first.Name != "queue_depth" ||
+		first.Value != 42 || !first.Timestamp.Equal(timestamp) || first.Labels["queue"] != "jobs" {
+		t.Errorf("Unexpected metric line %+v", first)
+	}
+
+	summary := processor.Summary()
+	if summary.Results != 2 || summary.Metrics != 4 || summary.Series != 2 {
+		t.Errorf("Expected 2 results, 4 metrics and 2 series, got %+v", summary)
+	}
+}
@@ -32,7 +32,7 @@ func (e *DefaultJobExecutor) ExecuteJob(ctx context.Context, job *ScheduledJob)
 			Region:         job.Region,
 			CollectionTime: job.NextRun,
 			Metrics:        []collectors.MetricData{},
-			Error: errors.NewValidationError("COLLECTOR_NOT_FOUND", 
+			Error: errors.NewValidationError(errors.CodeCollectorNotFound, 
 				"collector not found in registry").
 				WithMetadata("collector", job.CollectorName),
 		}
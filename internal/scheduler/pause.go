@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"sort"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// PauseRegion stops running the jobs of a region until ResumeRegion is
+// called. Jobs already running finish; jobs scheduled in the region while it
+// is paused start paused.
+func (s *MetricScheduler) PauseRegion(region string) {
+	s.setRegionPaused(region, true)
+}
+
+// ResumeRegion runs the jobs of a paused region again. Jobs whose next run
+// passed while paused run at the next tick.
+func (s *MetricScheduler) ResumeRegion(region string) {
+	s.setRegionPaused(region, false)
+}
+
+func (s *MetricScheduler) setRegionPaused(region string, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if paused {
+		s.pausedRegions[region] = true
+	} else {
+		delete(s.pausedRegions, region)
+	}
+
+	jobs := 0
+	for _, job := range s.jobs {
+		if job.Region == region {
+			job.Enabled = !paused
+			jobs++
+		}
+	}
+
+	message := "Resumed region"
+	if paused {
+		message = "Paused region"
+	}
+	s.logger.Info(message,
+		logger.String("region", region),
+		logger.Int("jobs", jobs))
+}
+
+// pausedRegionList returns the paused regions, sorted; the caller holds the
+// lock
+func (s *MetricScheduler) pausedRegionList() []string {
+	if len(s.pausedRegions) == 0 {
+		return nil
+	}
+	regions := make([]string, 0, len(s.pausedRegions))
+	for region := range s.pausedRegions {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
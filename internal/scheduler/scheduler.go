@@ -3,14 +3,28 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"sort"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/memory"
+	"aws-monitoring/internal/tracing"
 	"aws-monitoring/pkg/errors"
 	"aws-monitoring/pkg/logger"
 )
 
+// tracerName is the instrumentation scope of the job spans
+const tracerName = "aws-monitoring/internal/scheduler"
+
+// stateBucket is the state store bucket of the last run of each job
+const stateBucket = "scheduler"
+
 // MetricScheduler implements the Scheduler interface
 type MetricScheduler struct {
 	// Configuration
@@ -31,8 +45,10 @@ type MetricScheduler struct {
 	// Job management
 	jobs          map[string]*ScheduledJob
 	activeJobs    map[string]context.CancelFunc
+	pausedRegions map[string]bool
 	completedJobs int64
 	failedJobs    int64
+	postponedJobs int64
 	
 	// Control channels
 	stopCh   chan struct{}
@@ -40,6 +56,7 @@ type MetricScheduler struct {
 	
 	// Job execution
 	jobSemaphore chan struct{}
+	inFlight     sync.WaitGroup
 }
 
 // NewMetricScheduler creates a new metric collection scheduler
@@ -62,6 +79,7 @@ func NewMetricScheduler(
 		status:       StatusStopped,
 		jobs:         make(map[string]*ScheduledJob),
 		activeJobs:   make(map[string]context.CancelFunc),
+		pausedRegions: make(map[string]bool),
 		stopCh:       make(chan struct{}),
 		doneCh:       make(chan struct{}),
 		jobSemaphore: make(chan struct{}, config.MaxConcurrentJobs),
@@ -102,7 +120,9 @@ func (s *MetricScheduler) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop gracefully shuts down the scheduler
+// Stop gracefully shuts down the scheduler: no new jobs are started and
+// in-flight jobs are given until ctx is done to finish, after which they are
+// cancelled
 func (s *MetricScheduler) Stop(ctx context.Context) error {
 	s.mu.Lock()
 	if s.status != StatusRunning {
@@ -113,6 +133,7 @@ func (s *MetricScheduler) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping metric scheduler")
 	s.status = StatusStopping
 	s.mu.Unlock()
+	start := time.Now()
 	
 	// Signal stop
 	close(s.stopCh)
@@ -127,17 +148,42 @@ func (s *MetricScheduler) Stop(ctx context.Context) error {
 			s.config.JobTimeout).WithMetadata("operation", "stop")
 	}
 	
+	// Drain in-flight jobs
+	s.mu.RLock()
+	inFlight := len(s.activeJobs)
+	s.mu.RUnlock()
+	if inFlight > 0 {
+		s.logger.Info("Waiting for in-flight jobs", logger.Int("jobs", inFlight))
+	}
+	drained := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(drained)
+	}()
+	
+	var drainErr error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		drainErr = errors.NewTimeoutError("scheduler-drain", 
+			time.Since(start)).WithMetadata("operation", "stop")
+	}
+	
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	// Cancel any active jobs
+	// Cancel the jobs that did not finish in time
+	if len(s.activeJobs) > 0 {
+		s.logger.Warn("Cancelling in-flight jobs that did not finish in time",
+			logger.Int("jobs", len(s.activeJobs)))
+	}
 	for jobID, cancel := range s.activeJobs {
 		s.logger.Debug("Cancelling active job", logger.String("job_id", jobID))
 		cancel()
 	}
 	
 	s.status = StatusStopped
-	return nil
+	return drainErr
 }
 
 // ScheduleCollector schedules a collector to run at specified intervals
@@ -146,8 +192,9 @@ func (s *MetricScheduler) ScheduleCollector(collectorName string, regions []stri
 	defer s.mu.Unlock()
 	
 	// Validate collector exists
-	if _, exists := s.registry.Get(collectorName); !exists {
-		return errors.NewValidationError("COLLECTOR_NOT_FOUND", 
+	collector, exists := s.registry.Get(collectorName)
+	if !exists {
+		return errors.NewValidationError(errors.CodeCollectorNotFound, 
 			fmt.Sprintf("collector %s not found in registry", collectorName))
 	}
 	
@@ -167,19 +214,34 @@ func (s *MetricScheduler) ScheduleCollector(collectorName string, regions []stri
 		regions = filteredRegions
 	}
 	
+	// A collector scheduled in many regions runs them in batches, so its
+	// jobs start together
+	batch := ""
+	if s.config.RegionBatchThreshold > 0 && len(regions) >= s.config.RegionBatchThreshold {
+		batch = fmt.Sprintf("%s@%s", collectorName, interval)
+	}
+	
 	// Create jobs for each region
 	for _, region := range regions {
 		jobID := fmt.Sprintf("%s-%s", collectorName, region)
+		warmupKey := jobID
+		if batch != "" {
+			warmupKey = batch
+		}
 		
 		job := &ScheduledJob{
 			ID:            jobID,
 			CollectorName: collectorName,
 			Region:        region,
 			Interval:      interval,
-			NextRun:       time.Now().Add(100 * time.Millisecond), // Start soon
-			Enabled:       true,
+			NextRun:       time.Now().Add(100*time.Millisecond + s.warmupOffset(warmupKey)), // Start soon
+			Enabled:       !s.pausedRegions[region],
+			Priority:      collector.Info().Priority,
+			Batch:         batch,
 		}
 		
+		s.restoreLastRun(job)
+		
 		s.jobs[jobID] = job
 		s.logger.Info("Scheduled collector job",
 			logger.String("job_id", jobID),
@@ -191,6 +253,88 @@ func (s *MetricScheduler) ScheduleCollector(collectorName string, regions []stri
 	return nil
 }
 
+// commitCheckpoints advances the checkpoints of a collector that resumes
+// from its last exported datapoints, once they were processed
+func (s *MetricScheduler) commitCheckpoints(job *ScheduledJob, result *collectors.CollectionResult, log *logger.Logger) {
+	collector, exists := s.registry.Get(job.CollectorName)
+	if !exists {
+		return
+	}
+	checkpointer, ok := collector.(collectors.Checkpointer)
+	if !ok {
+		return
+	}
+	if err := checkpointer.CommitCheckpoints(job.Region, result.Metrics); err != nil {
+		log.Warn("Failed to save collector checkpoints", logger.String("error", err.Error()))
+	}
+}
+
+// restoreLastRun delays the first run of a job that ran less than an
+// interval ago, before a restart, until its interval has passed
+func (s *MetricScheduler) restoreLastRun(job *ScheduledJob) {
+	if s.config.State == nil {
+		return
+	}
+	
+	var lastRun time.Time
+	found, err := s.config.State.Get(stateBucket, job.ID, &lastRun)
+	if err != nil {
+		s.logger.Warn("Failed to restore job last run",
+			logger.String("job_id", job.ID),
+			logger.String("error", err.Error()))
+		return
+	}
+	if !found {
+		return
+	}
+	
+	job.LastRun = &lastRun
+	if nextRun := lastRun.Add(job.Interval); nextRun.After(job.NextRun) {
+		job.NextRun = nextRun
+	}
+}
+
+// ScheduleCollectorInfo schedules a collector in its enabled regions, at the
+// interval it runs at in each region
+func ScheduleCollectorInfo(s Scheduler, info collectors.CollectorInfo) error {
+	var intervals []time.Duration
+	regionsByInterval := make(map[time.Duration][]string)
+	for _, region := range info.EnabledRegions {
+		interval := info.IntervalFor(region)
+		if _, seen := regionsByInterval[interval]; !seen {
+			intervals = append(intervals, interval)
+		}
+		regionsByInterval[interval] = append(regionsByInterval[interval], region)
+	}
+	
+	for _, interval := range intervals {
+		if err := s.ScheduleCollector(info.Name, regionsByInterval[interval], interval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetEnabledRegions changes the regions collectors may be scheduled in
+func (s *MetricScheduler) SetEnabledRegions(regions []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	s.config.EnabledRegions = regions
+}
+
+// warmupOffset returns a stable offset within the warm-up window for a job, so
+// first runs are spread out instead of all starting at once
+func (s *MetricScheduler) warmupOffset(jobID string) time.Duration {
+	if s.config.WarmupWindow <= 0 {
+		return 0
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(jobID))
+	return time.Duration(hash.Sum64() % uint64(s.config.WarmupWindow))
+}
+
 // UnscheduleCollector removes a collector from the schedule
 func (s *MetricScheduler) UnscheduleCollector(collectorName string, region string) error {
 	s.mu.Lock()
@@ -214,7 +358,7 @@ func (s *MetricScheduler) UnscheduleCollector(collectorName string, region strin
 		return nil
 	}
 	
-	return errors.NewValidationError("JOB_NOT_FOUND",
+	return errors.NewValidationError(errors.CodeJobNotFound,
 		fmt.Sprintf("job %s not found", jobID))
 }
 
@@ -243,7 +387,9 @@ func (s *MetricScheduler) GetInfo() Info {
 		ActiveJobs:    len(s.activeJobs),
 		CompletedJobs: s.completedJobs,
 		FailedJobs:    s.failedJobs,
+		PostponedJobs: s.postponedJobs,
 		LastTickTime:  s.lastTickTime,
+		PausedRegions: s.pausedRegionList(),
 	}
 }
 
@@ -256,18 +402,18 @@ func (s *MetricScheduler) Health() error {
 	case StatusRunning:
 		// Check if scheduler is ticking
 		if s.lastTickTime != nil && time.Since(*s.lastTickTime) > 2*s.config.TickInterval {
-			return errors.NewValidationError("SCHEDULER_NOT_TICKING",
+			return errors.NewValidationError(errors.CodeSchedulerNotTicking,
 				"scheduler has not ticked recently")
 		}
 		return nil
 	case StatusError:
-		return errors.NewValidationError("SCHEDULER_ERROR",
+		return errors.NewValidationError(errors.CodeSchedulerError,
 			"scheduler is in error state")
 	case StatusStopped:
-		return errors.NewValidationError("SCHEDULER_STOPPED",
+		return errors.NewValidationError(errors.CodeSchedulerStopped,
 			"scheduler is stopped")
 	default:
-		return errors.NewValidationError("SCHEDULER_NOT_READY",
+		return errors.NewValidationError(errors.CodeSchedulerNotReady,
 			"scheduler is not ready")
 	}
 }
@@ -275,17 +421,17 @@ func (s *MetricScheduler) Health() error {
 // validateConfig validates the scheduler configuration
 func (s *MetricScheduler) validateConfig() *errors.Error {
 	if s.config.TickInterval <= 0 {
-		return errors.NewConfigError("INVALID_TICK_INTERVAL",
+		return errors.NewConfigError(errors.CodeInvalidTickInterval,
 			"tick interval must be positive")
 	}
 	
 	if s.config.MaxConcurrentJobs <= 0 {
-		return errors.NewConfigError("INVALID_MAX_CONCURRENT_JOBS",
+		return errors.NewConfigError(errors.CodeInvalidMaxConcurrentJobs,
 			"max concurrent jobs must be positive")
 	}
 	
 	if s.config.JobTimeout <= 0 {
-		return errors.NewConfigError("INVALID_JOB_TIMEOUT",
+		return errors.NewConfigError(errors.CodeInvalidJobTimeout,
 			"job timeout must be positive")
 	}
 	
@@ -332,12 +478,31 @@ func (s *MetricScheduler) tick(ctx context.Context) {
 			}
 		}
 	}
+	activeJobs := len(s.activeJobs)
 	s.mu.Unlock()
 	
+	jobsToRun = s.throttle(jobsToRun, activeJobs)
+	jobsToRun, batches := s.batchRegions(jobsToRun)
+	
+	// Execute region batches, each in a single job slot
+	for _, batch := range batches {
+		select {
+		case s.jobSemaphore <- struct{}{}: // Acquire semaphore
+			s.inFlight.Add(1)
+			s.startRegionBatches(ctx, batch)
+		default:
+			s.logger.Warn("Skipping region batch execution, max concurrent jobs reached",
+				logger.String("collector", batch[0].CollectorName),
+				logger.Int("regions", len(batch)),
+				logger.Int("max_concurrent", s.config.MaxConcurrentJobs))
+		}
+	}
+	
 	// Execute jobs
 	for _, job := range jobsToRun {
 		select {
 		case s.jobSemaphore <- struct{}{}: // Acquire semaphore
+			s.inFlight.Add(1)
 			go s.executeJob(ctx, job)
 		default:
 			// No available slots, skip this job
@@ -348,14 +513,122 @@ func (s *MetricScheduler) tick(ctx context.Context) {
 	}
 }
 
+// throttle postpones the jobs there is no room for under memory pressure:
+// low priority jobs when pressure is high, all but high priority jobs when it
+// is critical. Fewer jobs run at once too: half the usual number when
+// pressure is high and one when it is critical. Postponed jobs keep their
+// next run, so they start once the pressure drops.
+func (s *MetricScheduler) throttle(jobs []*ScheduledJob, activeJobs int) []*ScheduledJob {
+	if s.config.Memory == nil || len(jobs) == 0 {
+		return jobs
+	}
+	pressure := s.config.Memory.Pressure()
+	maxActive := s.config.MaxConcurrentJobs
+	switch pressure {
+	case memory.PressureNone:
+		return jobs
+	case memory.PressureHigh:
+		maxActive /= 2
+	case memory.PressureCritical:
+		maxActive = 1
+	}
+	if maxActive < 1 {
+		maxActive = 1
+	}
+	
+	// Higher priority jobs take the slots left first
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return priorityRank(jobs[i].Priority) > priorityRank(jobs[j].Priority)
+	})
+	allowed := make([]*ScheduledJob, 0, len(jobs))
+	for _, job := range jobs {
+		if !runsUnderPressure(job.Priority, pressure) || activeJobs+len(allowed) >= maxActive {
+			continue
+		}
+		allowed = append(allowed, job)
+	}
+	
+	if postponed := len(jobs) - len(allowed); postponed > 0 {
+		s.mu.Lock()
+		s.postponedJobs += int64(postponed)
+		s.mu.Unlock()
+		s.logger.Warn("Postponing jobs under memory pressure",
+			logger.String("pressure", pressure.String()),
+			logger.Int("postponed", postponed),
+			logger.Int("max_concurrent", maxActive))
+	}
+	return allowed
+}
+
+// runsUnderPressure reports whether jobs of a priority run under pressure
+func runsUnderPressure(priority string, pressure memory.Pressure) bool {
+	switch pressure {
+	case memory.PressureHigh:
+		return priority != collectors.PriorityLow
+	case memory.PressureCritical:
+		return priority == collectors.PriorityHigh
+	default:
+		return true
+	}
+}
+
+// priorityRank orders priorities from low to high; empty means normal
+func priorityRank(priority string) int {
+	switch priority {
+	case collectors.PriorityLow:
+		return 0
+	case collectors.PriorityHigh:
+		return 2
+	default:
+		return 1
+	}
+}
+
 // executeJob runs a single job
 func (s *MetricScheduler) executeJob(ctx context.Context, job *ScheduledJob) {
+	defer s.inFlight.Done()
 	defer func() { <-s.jobSemaphore }() // Release semaphore
 	
+	s.runJob(ctx, job)
+}
+
+// execute runs the collection of a job. A collector that panics fails its
+// own job only, instead of the jobs running with it.
+func (s *MetricScheduler) execute(ctx context.Context, job *ScheduledJob) (result *collectors.CollectionResult) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = &collectors.CollectionResult{
+				CollectorName:  job.CollectorName,
+				Region:         job.Region,
+				CollectionTime: time.Now(),
+				Error: errors.WithRegion(errors.New(errors.ErrorTypeInternal, errors.CodeCollectionError,
+					fmt.Sprintf("collector panicked: %v", recovered)), job.Region),
+			}
+		}
+	}()
+	
+	return s.executor.ExecuteJob(ctx, job)
+}
+
+// runJob collects and processes the metrics of a job
+func (s *MetricScheduler) runJob(ctx context.Context, job *ScheduledJob) {
 	// Create job context with timeout
 	jobCtx, cancel := context.WithTimeout(ctx, s.config.JobTimeout)
 	defer cancel()
 	
+	// Trace the job end to end: the collection, its AWS API calls and the
+	// export of the results
+	jobCtx, span := otel.Tracer(tracerName).Start(jobCtx, "collection job",
+		trace.WithAttributes(
+			tracing.AttrCollector.String(job.CollectorName),
+			tracing.AttrRegion.String(job.Region),
+			tracing.AttrJobID.String(job.ID)))
+	defer span.End()
+	
+	// Entries logged while the job runs, including by the collector, carry its ID
+	jobCtx = logger.WithContext(jobCtx, logger.String("job_id", job.ID))
+	log := s.logger.ForContext(jobCtx)
+	
 	// Track active job
 	s.mu.Lock()
 	s.activeJobs[job.ID] = cancel
@@ -368,46 +641,85 @@ func (s *MetricScheduler) executeJob(ctx context.Context, job *ScheduledJob) {
 		s.mu.Unlock()
 	}()
 	
-	s.logger.Debug("Executing job", 
-		logger.String("job_id", job.ID),
+	log.Debug("Executing job", 
 		logger.String("collector", job.CollectorName),
 		logger.String("region", job.Region))
 	
 	// Execute the job
-	result := s.executor.ExecuteJob(jobCtx, job)
+	result := s.execute(jobCtx, job)
 	
-	// Update job state
-	s.mu.Lock()
+	// Update job state. Only the bookkeeping holds the lock; the result is
+	// processed after it is released, so slow exporters don't hold up the
+	// other jobs completing or the scheduler's tick.
 	now := time.Now()
+	metricCount := len(result.Metrics)
+	summary := *result
+	summary.Metrics = nil
+	s.mu.Lock()
 	job.LastRun = &now
 	job.NextRun = now.Add(job.Interval)
-	job.LastResult = result
-	
+	job.LastResult = &summary
+	job.LastMetricCount = metricCount
 	if result.Error != nil {
 		s.failedJobs++
-		s.logger.Warn("Job execution failed",
-			logger.String("job_id", job.ID),
+	} else {
+		s.completedJobs++
+	}
+	s.mu.Unlock()
+	
+	if s.config.State != nil {
+		if err := s.config.State.Put(stateBucket, job.ID, now); err != nil {
+			log.Warn("Failed to save job last run", logger.String("error", err.Error()))
+		}
+	}
+	
+	span.SetAttributes(tracing.AttrMetricCount.Int(metricCount))
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, result.Error.Error())
+	}
+	exportCtx, exportSpan := otel.Tracer(tracerName).Start(jobCtx, "export")
+	defer exportSpan.End()
+	
+	if result.Error != nil {
+		log.Warn("Job execution failed",
 			logger.String("error", result.Error.Error()))
 		
 		// Process error
-		if err := s.processor.ProcessError(jobCtx, job, result.Error); err != nil {
-			s.logger.Error("Failed to process job error",
-				logger.String("job_id", job.ID),
+		if err := s.processor.ProcessError(exportCtx, job, result.Error); err != nil {
+			exportSpan.RecordError(err)
+			exportSpan.SetStatus(codes.Error, err.Error())
+			log.Error("Failed to process job error",
 				logger.String("process_error", err.Error()))
 		}
+		
+		// Failed collections can still carry self-monitoring metrics such as
+		// the collector error counters
+		if metricCount > 0 {
+			if err := s.processor.ProcessResult(exportCtx, job, result); err != nil {
+				exportSpan.RecordError(err)
+				exportSpan.SetStatus(codes.Error, err.Error())
+				log.Error("Failed to process job result",
+					logger.String("process_error", err.Error()))
+			}
+		}
 	} else {
-		s.completedJobs++
-		s.logger.Debug("Job execution completed",
-			logger.String("job_id", job.ID),
-			logger.Int("metric_count", len(result.Metrics)),
+		log.Debug("Job execution completed",
+			logger.Int("metric_count", metricCount),
 			logger.Duration("duration", result.Duration))
 		
 		// Process result
-		if err := s.processor.ProcessResult(jobCtx, job, result); err != nil {
-			s.logger.Error("Failed to process job result",
-				logger.String("job_id", job.ID),
+		if err := s.processor.ProcessResult(exportCtx, job, result); err != nil {
+			exportSpan.RecordError(err)
+			exportSpan.SetStatus(codes.Error, err.Error())
+			log.Error("Failed to process job result",
 				logger.String("process_error", err.Error()))
+		} else {
+			s.commitCheckpoints(job, result, log)
 		}
 	}
-	s.mu.Unlock()
+	
+	// The metrics were processed; reuse their memory for later collections
+	collectors.ReleaseMetrics(result.Metrics)
+	result.Metrics = nil
 }
\ No newline at end of file
@@ -2,11 +2,19 @@ package scheduler
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
 	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/memory"
+	"aws-monitoring/internal/state"
 	"aws-monitoring/pkg/errors"
 	"aws-monitoring/pkg/logger"
 )
@@ -16,6 +24,7 @@ import (
 type mockCollector struct {
 	name        string
 	description string
+	priority    string
 	collectFunc func(ctx context.Context, region string) *collectors.CollectionResult
 }
 
@@ -29,6 +38,7 @@ func (m *mockCollector) Info() collectors.CollectorInfo {
 		Name:        m.name,
 		Description: m.description,
 		Status:      collectors.StatusRunning,
+		Priority:    m.priority,
 	}
 }
 
@@ -126,7 +136,10 @@ func newMockJobProcessor() *mockJobProcessor {
 func (p *mockJobProcessor) ProcessResult(_ context.Context, job *ScheduledJob, result *collectors.CollectionResult) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.results = append(p.results, ProcessedResult{Job: job, Result: result})
+	// The scheduler reuses the metrics once the result is processed
+	processed := *result
+	processed.Metrics = collectors.CloneMetrics(result.Metrics)
+	p.results = append(p.results, ProcessedResult{Job: job, Result: &processed})
 	return nil
 }
 
@@ -227,6 +240,81 @@ func TestSchedulerStartStop(t *testing.T) {
 	}
 }
 
+// startBlockingJob schedules a collector whose collection runs collect and
+// waits until a job is in flight
+func startBlockingJob(t *testing.T, scheduler *MetricScheduler, registry *mockRegistry,
+	collect func(ctx context.Context, region string) *collectors.CollectionResult) {
+	t.Helper()
+	
+	if err := registry.Register(&mockCollector{name: "slow-collector", collectFunc: collect}); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start scheduler: %v", err)
+	}
+	if err := scheduler.ScheduleCollector("slow-collector", []string{"us-east-1"}, 100*time.Millisecond); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	deadline := time.Now().Add(2 * time.Second)
+	for scheduler.GetInfo().ActiveJobs == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a job to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSchedulerStopDrainsJobs(t *testing.T) {
+	scheduler, registry, processor, _ := setupTest()
+	startBlockingJob(t, scheduler, registry, func(_ context.Context, region string) *collectors.CollectionResult {
+		time.Sleep(300 * time.Millisecond)
+		return &collectors.CollectionResult{CollectorName: "slow-collector", Region: region}
+	})
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := scheduler.Stop(ctx); err != nil {
+		t.Fatalf("Expected in-flight job to drain, got: %v", err)
+	}
+	
+	if len(processor.GetResults()) == 0 {
+		t.Error("Expected the in-flight job's result to be processed before Stop returned")
+	}
+	if info := scheduler.GetInfo(); info.ActiveJobs != 0 || info.Status != StatusStopped {
+		t.Errorf("Expected stopped scheduler without active jobs, got %+v", info)
+	}
+}
+
+func TestSchedulerStopDrainTimeout(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	cancelled := make(chan struct{})
+	startBlockingJob(t, scheduler, registry, func(ctx context.Context, region string) *collectors.CollectionResult {
+		<-ctx.Done()
+		close(cancelled)
+		return &collectors.CollectionResult{CollectorName: "slow-collector", Region: region}
+	})
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := scheduler.Stop(ctx)
+	if err == nil {
+		t.Fatal("Expected drain timeout error")
+	}
+	if appErr, ok := err.(*errors.Error); !ok || appErr.Type != errors.ErrorTypeTimeout {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the in-flight job to be cancelled")
+	}
+	if status := scheduler.GetInfo().Status; status != StatusStopped {
+		t.Errorf("Expected status stopped, got %s", status)
+	}
+}
+
 func TestScheduleCollector(t *testing.T) {
 	scheduler, registry, _, _ := setupTest()
 	
@@ -268,6 +356,304 @@ func TestScheduleCollector(t *testing.T) {
 	}
 }
 
+func TestScheduleCollectorWarmup(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	scheduler.config.WarmupWindow = time.Minute
+	
+	collector := &mockCollector{name: "test-collector", description: "Test collector"}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	
+	regions := []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2", "eu-west-1", "eu-central-1"}
+	before := time.Now()
+	if err := scheduler.ScheduleCollector("test-collector", regions, 5*time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	distinct := make(map[time.Duration]bool)
+	for _, job := range scheduler.GetScheduledJobs() {
+		offset := job.NextRun.Sub(before)
+		if offset < 0 || offset > time.Minute+time.Second {
+			t.Errorf("Expected first run within the warm-up window, got offset %v", offset)
+		}
+		distinct[offset.Truncate(time.Second)] = true
+	}
+	
+	if len(distinct) < 2 {
+		t.Error("Expected first runs to be spread over the warm-up window")
+	}
+	
+	// Offsets are stable for a job
+	if scheduler.warmupOffset("test-collector-us-east-1") != scheduler.warmupOffset("test-collector-us-east-1") {
+		t.Error("Expected warm-up offset to be deterministic")
+	}
+}
+
+func TestScheduleCollectorRestoresLastRun(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatalf("Failed to open state store: %v", err)
+	}
+	scheduler.config.State = store
+	
+	collector := &mockCollector{name: "test-collector", description: "Test collector"}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	
+	// us-east-1 ran a minute before the restart, us-west-2 never ran
+	lastRun := time.Now().Add(-time.Minute)
+	if err := store.Put(stateBucket, "test-collector-us-east-1", lastRun); err != nil {
+		t.Fatalf("Failed to save last run: %v", err)
+	}
+	if err := scheduler.ScheduleCollector("test-collector", []string{"us-east-1", "us-west-2"}, 5*time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	for _, job := range scheduler.GetScheduledJobs() {
+		switch job.Region {
+		case "us-east-1":
+			if !job.NextRun.Equal(lastRun.Add(5 * time.Minute)) {
+				t.Errorf("Expected next run a full interval after the last run, got %v", job.NextRun.Sub(lastRun))
+			}
+			if job.LastRun == nil || !job.LastRun.Equal(lastRun) {
+				t.Errorf("Expected the restored last run, got %v", job.LastRun)
+			}
+		case "us-west-2":
+			if time.Until(job.NextRun) > time.Second {
+				t.Errorf("Expected a job without a last run to start soon, got %v", time.Until(job.NextRun))
+			}
+		}
+	}
+	
+	// Running a job saves its last run
+	job := scheduler.jobs["test-collector-us-west-2"]
+	scheduler.jobSemaphore <- struct{}{}
+	scheduler.inFlight.Add(1)
+	scheduler.executeJob(context.Background(), job)
+	var saved time.Time
+	if found, err := store.Get(stateBucket, job.ID, &saved); !found || err != nil || !saved.Equal(*job.LastRun) {
+		t.Errorf("Expected the last run %v to be saved, got %v, found=%v, err=%v", job.LastRun, saved, found, err)
+	}
+}
+
+// checkpointingCollector records the checkpoints committed by the scheduler
+type checkpointingCollector struct {
+	mockCollector
+	committed []collectors.MetricData
+}
+
+func (c *checkpointingCollector) CommitCheckpoints(_ string, metrics []collectors.MetricData) error {
+	c.committed = append(c.committed, metrics...)
+	return nil
+}
+
+func TestJobExecutionCommitsCheckpoints(t *testing.T) {
+	scheduler, registry, processor, _ := setupTest()
+	
+	collector := &checkpointingCollector{mockCollector: mockCollector{name: "datapoints", description: "Test collector"}}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	if err := scheduler.ScheduleCollector("datapoints", []string{"us-east-1"}, time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	job := scheduler.jobs["datapoints-us-east-1"]
+	scheduler.jobSemaphore <- struct{}{}
+	scheduler.inFlight.Add(1)
+	scheduler.executeJob(context.Background(), job)
+	
+	if len(processor.GetResults()) != 1 {
+		t.Fatalf("Expected the result to be processed, got %d results", len(processor.GetResults()))
+	}
+	if len(collector.committed) != 1 || collector.committed[0].Name != "test_metric" {
+		t.Errorf("Expected the processed metrics to be committed, got %v", collector.committed)
+	}
+}
+
+func TestJobExecutionReleasesMetrics(t *testing.T) {
+	scheduler, registry, processor, _ := setupTest()
+	
+	collector := &mockCollector{name: "test-collector", description: "Test collector"}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	if err := scheduler.ScheduleCollector("test-collector", []string{"us-east-1"}, time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	job := scheduler.jobs["test-collector-us-east-1"]
+	scheduler.jobSemaphore <- struct{}{}
+	scheduler.inFlight.Add(1)
+	scheduler.executeJob(context.Background(), job)
+	
+	results := processor.GetResults()
+	if len(results) != 1 {
+		t.Fatalf("Expected the result to be processed, got %d results", len(results))
+	}
+	if len(results[0].Result.Metrics) != 1 || results[0].Result.Metrics[0].Name != "test_metric" {
+		t.Errorf("Expected the processor to copy the collected metrics, got %+v", results[0].Result.Metrics)
+	}
+	if job.LastMetricCount != 1 {
+		t.Errorf("Expected last metric count 1, got %d", job.LastMetricCount)
+	}
+	if job.LastResult == nil || job.LastResult.Metrics != nil {
+		t.Errorf("Expected the processed metrics to be released, got %+v", job.LastResult)
+	}
+}
+
+// fixedMemoryMonitor reports a fixed memory pressure
+type fixedMemoryMonitor struct {
+	pressure memory.Pressure
+}
+
+func (m *fixedMemoryMonitor) Pressure() memory.Pressure { return m.pressure }
+
+func TestThrottleUnderMemoryPressure(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	monitor := &fixedMemoryMonitor{}
+	scheduler.config.Memory = monitor
+	scheduler.config.MaxConcurrentJobs = 4
+	
+	priorities := map[string]string{"low": collectors.PriorityLow, "normal": "", "high": collectors.PriorityHigh}
+	for name, priority := range priorities {
+		if err := registry.Register(&mockCollector{name: name, priority: priority}); err != nil {
+			t.Fatalf("Failed to register collector: %v", err)
+		}
+		if err := scheduler.ScheduleCollector(name, []string{"us-east-1"}, time.Minute); err != nil {
+			t.Fatalf("Failed to schedule collector: %v", err)
+		}
+	}
+	if priority := scheduler.jobs["low-us-east-1"].Priority; priority != collectors.PriorityLow {
+		t.Errorf("Expected the job to take the collector priority, got %q", priority)
+	}
+	jobs := func() []*ScheduledJob {
+		return []*ScheduledJob{
+			scheduler.jobs["low-us-east-1"],
+			scheduler.jobs["normal-us-east-1"],
+			scheduler.jobs["high-us-east-1"],
+		}
+	}
+	
+	tests := []struct {
+		pressure   memory.Pressure
+		activeJobs int
+		expected   []string
+	}{
+		{pressure: memory.PressureNone, expected: []string{"low", "normal", "high"}},
+		{pressure: memory.PressureHigh, expected: []string{"high", "normal"}},
+		{pressure: memory.PressureHigh, activeJobs: 1, expected: []string{"high"}},
+		{pressure: memory.PressureCritical, expected: []string{"high"}},
+		{pressure: memory.PressureCritical, activeJobs: 1, expected: []string{}},
+	}
+	for _, tt := range tests {
+		monitor.pressure = tt.pressure
+		allowed := scheduler.throttle(jobs(), tt.activeJobs)
+		names := []string{}
+		for _, job := range allowed {
+			names = append(names, job.CollectorName)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("Expected %v to run under %s pressure with %d active jobs, got %v",
+				tt.expected, tt.pressure, tt.activeJobs, names)
+		}
+	}
+	
+	if postponed := scheduler.GetInfo().PostponedJobs; postponed != 1+2+2+3 {
+		t.Errorf("Expected 8 postponed job runs, got %d", postponed)
+	}
+}
+
+// blockingProcessor holds every result until released, like a slow exporter
+type blockingProcessor struct {
+	mockJobProcessor
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProcessor) ProcessResult(ctx context.Context, job *ScheduledJob, result *collectors.CollectionResult) error {
+	close(p.entered)
+	<-p.release
+	return p.mockJobProcessor.ProcessResult(ctx, job, result)
+}
+
+func TestJobProcessingOutsideLock(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	processor := &blockingProcessor{entered: make(chan struct{}), release: make(chan struct{})}
+	scheduler.processor = processor
+	
+	if err := registry.Register(&mockCollector{name: "test-collector"}); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	if err := scheduler.ScheduleCollector("test-collector", []string{"us-east-1"}, time.Minute); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	job := scheduler.jobs["test-collector-us-east-1"]
+	scheduler.jobSemaphore <- struct{}{}
+	scheduler.inFlight.Add(1)
+	go scheduler.executeJob(context.Background(), job)
+	<-processor.entered
+	
+	// The scheduler answers while the result is being processed
+	infoCh := make(chan Info, 1)
+	go func() { infoCh <- scheduler.GetInfo() }()
+	select {
+	case info := <-infoCh:
+		if info.CompletedJobs != 1 {
+			t.Errorf("Expected the job to be recorded as completed before processing, got %d", info.CompletedJobs)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected GetInfo not to wait for the result to be processed")
+	}
+	
+	close(processor.release)
+	scheduler.inFlight.Wait()
+	if len(processor.GetResults()) != 1 {
+		t.Errorf("Expected the result to be processed, got %d results", len(processor.GetResults()))
+	}
+}
+
+func TestScheduleCollectorInfoRegionIntervals(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	
+	collector := &mockCollector{name: "test-collector", description: "Test collector"}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	
+	info := collectors.CollectorInfo{
+		Name:           "test-collector",
+		EnabledRegions: []string{"us-east-1", "us-west-2", "ap-southeast-2"},
+		Interval:       5 * time.Minute,
+		RegionIntervals: map[string]time.Duration{
+			"us-east-1":      time.Minute,
+			"ap-southeast-2": 15 * time.Minute,
+		},
+	}
+	if err := ScheduleCollectorInfo(scheduler, info); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	expected := map[string]time.Duration{
+		"us-east-1":      time.Minute,
+		"us-west-2":      5 * time.Minute,
+		"ap-southeast-2": 15 * time.Minute,
+	}
+	jobs := scheduler.GetScheduledJobs()
+	if len(jobs) != len(expected) {
+		t.Fatalf("Expected %d jobs, got %d", len(expected), len(jobs))
+	}
+	for _, job := range jobs {
+		if job.Interval != expected[job.Region] {
+			t.Errorf("Expected interval %v for %s, got %v", expected[job.Region], job.Region, job.Interval)
+		}
+	}
+}
+
 func TestScheduleNonExistentCollector(t *testing.T) {
 	scheduler, _, _, _ := setupTest()
 	
@@ -314,6 +700,137 @@ func TestUnscheduleCollector(t *testing.T) {
 	}
 }
 
+func TestTriggerCollector(t *testing.T) {
+	scheduler, registry, processor, _ := setupTest()
+	
+	collector := &mockCollector{name: "test-collector", description: "Test collector"}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	
+	ctx := context.Background()
+	if err := scheduler.TriggerCollector(ctx, "test-collector", "us-east-1"); err == nil {
+		t.Error("Expected error when triggering a collector before the scheduler starts")
+	}
+	
+	if err := scheduler.Start(ctx); err != nil {
+		t.Fatalf("Failed to start scheduler: %v", err)
+	}
+	defer func() {
+		if stopErr := scheduler.Stop(ctx); stopErr != nil {
+			t.Errorf("Failed to stop scheduler: %v", stopErr)
+		}
+	}()
+	
+	if err := scheduler.ScheduleCollector("test-collector", []string{"us-east-1"}, time.Hour); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	// Wait for the first run; the next one is an hour away
+	waitForResults(t, processor, 1)
+	
+	if err := scheduler.TriggerCollector(ctx, "test-collector", "us-east-1"); err != nil {
+		t.Fatalf("Failed to trigger collector: %v", err)
+	}
+	waitForResults(t, processor, 2)
+	
+	err := scheduler.TriggerCollector(ctx, "test-collector", "eu-west-1")
+	if e, ok := err.(*errors.Error); !ok || e.Code != errors.CodeJobNotFound {
+		t.Errorf("Expected %s error for an unscheduled region, got %v", errors.CodeJobNotFound, err)
+	}
+}
+
+func TestTriggerCollectorWhenSlotsTaken(t *testing.T) {
+	scheduler, registry, processor, _ := setupTest()
+	
+	collector := &mockCollector{name: "test-collector", description: "Test collector"}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	if err := scheduler.ScheduleCollector("test-collector", []string{"us-east-1"}, time.Hour); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	// Take every job slot, so the triggered job has to wait for a tick
+	for i := 0; i < cap(scheduler.jobSemaphore); i++ {
+		scheduler.jobSemaphore <- struct{}{}
+	}
+	
+	scheduler.mu.Lock()
+	scheduler.status = StatusRunning
+	scheduler.mu.Unlock()
+	
+	if err := scheduler.TriggerCollector(context.Background(), "test-collector", "us-east-1"); err != nil {
+		t.Fatalf("Failed to trigger collector: %v", err)
+	}
+	
+	jobs := scheduler.GetScheduledJobs()
+	if len(jobs) != 1 || jobs[0].NextRun.After(time.Now()) {
+		t.Errorf("Expected the triggered job to be due, got %+v", jobs)
+	}
+	if results := processor.GetResults(); len(results) != 0 {
+		t.Errorf("Expected no results without a free slot, got %d", len(results))
+	}
+}
+
+func TestPauseRegion(t *testing.T) {
+	scheduler, registry, _, _ := setupTest()
+	
+	for _, name := range []string{"first", "second"} {
+		if err := registry.Register(&mockCollector{name: name, description: "Test collector"}); err != nil {
+			t.Fatalf("Failed to register collector: %v", err)
+		}
+	}
+	if err := scheduler.ScheduleCollector("first", []string{"us-east-1", "eu-west-1"}, time.Hour); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	
+	enabled := func() map[string]bool {
+		jobs := make(map[string]bool)
+		for _, job := range scheduler.GetScheduledJobs() {
+			jobs[job.ID] = job.Enabled
+		}
+		return jobs
+	}
+	
+	scheduler.PauseRegion("eu-west-1")
+	
+	// Jobs scheduled in a paused region start paused
+	if err := scheduler.ScheduleCollector("second", []string{"eu-west-1"}, time.Hour); err != nil {
+		t.Fatalf("Failed to schedule collector: %v", err)
+	}
+	jobs := enabled()
+	if !jobs["first-us-east-1"] || jobs["first-eu-west-1"] || jobs["second-eu-west-1"] {
+		t.Errorf("Expected only the eu-west-1 jobs to be paused, got %v", jobs)
+	}
+	if paused := scheduler.GetInfo().PausedRegions; len(paused) != 1 || paused[0] != "eu-west-1" {
+		t.Errorf("Expected eu-west-1 to be reported paused, got %v", paused)
+	}
+	
+	scheduler.ResumeRegion("eu-west-1")
+	
+	for id, jobEnabled := range enabled() {
+		if !jobEnabled {
+			t.Errorf("Expected job %s to be resumed", id)
+		}
+	}
+	if paused := scheduler.GetInfo().PausedRegions; len(paused) != 0 {
+		t.Errorf("Expected no paused regions, got %v", paused)
+	}
+}
+
+// waitForResults waits until the processor has at least n results
+func waitForResults(t *testing.T, processor *mockJobProcessor, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(processor.GetResults()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d results, got %d", n, len(processor.GetResults()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestJobExecution(t *testing.T) {
 	scheduler, registry, processor, _ := setupTest()
 	
@@ -433,6 +950,56 @@ func TestJobExecutionWithError(t *testing.T) {
 	}
 }
 
+func TestJobExecutionTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(previous)
+	
+	scheduler, registry, _, _ := setupTest()
+	collector := &mockCollector{
+		name: "error-collector",
+		collectFunc: func(_ context.Context, region string) *collectors.CollectionResult {
+			return &collectors.CollectionResult{
+				CollectorName: "error-collector",
+				Region:        region,
+				Error:         errors.NewNetworkError("CONNECTION_ERROR", "connection failed"),
+			}
+		},
+	}
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+	
+	// Run a job as tick does
+	job := &ScheduledJob{ID: "error-collector-us-east-1", CollectorName: "error-collector", Region: "us-east-1", Interval: time.Minute}
+	scheduler.jobSemaphore <- struct{}{}
+	scheduler.inFlight.Add(1)
+	scheduler.executeJob(context.Background(), job)
+	
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected a job and an export span, got %d spans", len(spans))
+	}
+	export, jobSpan := spans[0], spans[1]
+	if jobSpan.Name != "collection job" || export.Name != "export" {
+		t.Fatalf("Unexpected spans %q and %q", jobSpan.Name, export.Name)
+	}
+	if export.Parent.SpanID() != jobSpan.SpanContext.SpanID() {
+		t.Error("Expected the export span to be a child of the job span")
+	}
+	if jobSpan.Status.Code != codes.Error {
+		t.Errorf("Expected the failed job's span to have an error status, got %v", jobSpan.Status)
+	}
+	attributes := make(map[string]string)
+	for _, attr := range jobSpan.Attributes {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attributes["aws_monitor.collector"] != "error-collector" || attributes["aws_monitor.region"] != "us-east-1" {
+		t.Errorf("Unexpected job span attributes: %v", attributes)
+	}
+}
+
 func TestSchedulerHealth(t *testing.T) {
 	scheduler, _, _, _ := setupTest()
 	
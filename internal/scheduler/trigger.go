@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// TriggerCollector runs the job of a collector in a region now instead of at
+// its next run. A job that is already running is left alone, since the run
+// in progress picks up the change that triggered it, and so is a job of a
+// paused region. When all job slots are
+// taken, or memory pressure postpones the job, it runs at the next tick
+// instead.
+func (s *MetricScheduler) TriggerCollector(ctx context.Context, collectorName, region string) error {
+	jobID := fmt.Sprintf("%s-%s", collectorName, region)
+
+	s.mu.Lock()
+	if s.status != StatusRunning {
+		s.mu.Unlock()
+		return errors.NewValidationError(errors.CodeSchedulerNotReady,
+			"scheduler is not running")
+	}
+	job, exists := s.jobs[jobID]
+	if !exists {
+		s.mu.Unlock()
+		return errors.NewValidationError(errors.CodeJobNotFound,
+			fmt.Sprintf("job %s not found", jobID))
+	}
+	if _, running := s.activeJobs[jobID]; running {
+		s.mu.Unlock()
+		s.logger.Debug("Triggered job is already running", logger.String("job_id", jobID))
+		return nil
+	}
+	if !job.Enabled {
+		s.mu.Unlock()
+		s.logger.Debug("Triggered job is paused", logger.String("job_id", jobID))
+		return nil
+	}
+	// Make the job due, so the next tick runs it if it can't start now
+	job.NextRun = time.Now()
+	activeJobs := len(s.activeJobs)
+	s.mu.Unlock()
+
+	if len(s.throttle([]*ScheduledJob{job}, activeJobs)) == 0 {
+		return nil
+	}
+
+	select {
+	case s.jobSemaphore <- struct{}{}: // Acquire semaphore
+	default:
+		s.logger.Debug("Triggered job waits for the next tick, max concurrent jobs reached",
+			logger.String("job_id", jobID),
+			logger.Int("max_concurrent", s.config.MaxConcurrentJobs))
+		return nil
+	}
+
+	// Mark the job active under the lock again, so neither a tick nor another
+	// trigger starts it meanwhile, and so Stop waits for it
+	triggerCtx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	_, running := s.activeJobs[jobID]
+	if s.status != StatusRunning || running || s.jobs[jobID] != job {
+		s.mu.Unlock()
+		cancel()
+		<-s.jobSemaphore
+		return nil
+	}
+	s.activeJobs[jobID] = cancel
+	s.inFlight.Add(1)
+	s.mu.Unlock()
+
+	s.logger.Info("Triggered collector job",
+		logger.String("job_id", jobID),
+		logger.String("collector", collectorName),
+		logger.String("region", region))
+
+	go func() {
+		defer cancel()
+		s.executeJob(triggerCtx, job)
+	}()
+	return nil
+}
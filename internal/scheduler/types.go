@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/memory"
 	"aws-monitoring/pkg/errors"
 )
 
@@ -39,8 +40,16 @@ type ScheduledJob struct {
 	NextRun time.Time `json:"next_run"`
 	// LastRun is when this job last executed
 	LastRun *time.Time `json:"last_run,omitempty"`
-	// LastResult is the result of the last execution
+	// LastResult is the result of the last execution, without its metrics
 	LastResult *collectors.CollectionResult `json:"last_result,omitempty"`
+	// LastMetricCount is the number of metrics of the last execution
+	LastMetricCount int `json:"last_metric_count"`
+	// Priority is the priority of the collector, deciding which jobs are
+	// postponed first under memory pressure
+	Priority string `json:"priority,omitempty"`
+	// Batch identifies the jobs of a collector scheduled in many regions,
+	// which run together in region batches; empty if the job runs alone
+	Batch string `json:"batch,omitempty"`
 	// Enabled indicates if this job should run
 	Enabled bool `json:"enabled"`
 }
@@ -55,14 +64,36 @@ type Config struct {
 	JobTimeout time.Duration `json:"job_timeout"`
 	// EnabledRegions restricts scheduling to specific regions
 	EnabledRegions []string `json:"enabled_regions,omitempty"`
+	// WarmupWindow spreads the first run of newly scheduled jobs over this window
+	WarmupWindow time.Duration `json:"warmup_window,omitempty"`
+	// State persists the last run of each job, so jobs that ran shortly
+	// before a restart wait for the rest of their interval
+	State collectors.StateStore `json:"-"`
+	// Memory reports the memory pressure, under which fewer jobs run at once
+	// and lower priority jobs are postponed
+	Memory MemoryMonitor `json:"-"`
+	// RegionBatchThreshold is the number of regions from which a collector's
+	// regions run in batches, in a single job slot, instead of as separate
+	// jobs (0 = never)
+	RegionBatchThreshold int `json:"region_batch_threshold,omitempty"`
+	// RegionBatchSize is the number of regions of a batch collected in
+	// parallel
+	RegionBatchSize int `json:"region_batch_size,omitempty"`
+}
+
+// MemoryMonitor reports how close the memory in use is to the soft limit
+type MemoryMonitor interface {
+	Pressure() memory.Pressure
 }
 
 // DefaultConfig returns sensible defaults for scheduler configuration
 func DefaultConfig() Config {
 	return Config{
-		TickInterval:      30 * time.Second,
-		MaxConcurrentJobs: 10,
-		JobTimeout:        5 * time.Minute,
+		TickInterval:         30 * time.Second,
+		MaxConcurrentJobs:    10,
+		JobTimeout:           5 * time.Minute,
+		RegionBatchThreshold: 15,
+		RegionBatchSize:      5,
 	}
 }
 
@@ -80,8 +111,13 @@ type Info struct {
 	CompletedJobs int64 `json:"completed_jobs"`
 	// FailedJobs is the total number of failed jobs
 	FailedJobs int64 `json:"failed_jobs"`
+	// PostponedJobs is the total number of job runs postponed under memory
+	// pressure
+	PostponedJobs int64 `json:"postponed_jobs"`
 	// LastTickTime is when the scheduler last checked for jobs
 	LastTickTime *time.Time `json:"last_tick_time,omitempty"`
+	// PausedRegions are the regions whose jobs are paused
+	PausedRegions []string `json:"paused_regions,omitempty"`
 }
 
 // Scheduler defines the interface for metric collection scheduling
@@ -89,7 +125,8 @@ type Scheduler interface {
 	// Start begins the scheduler execution
 	Start(ctx context.Context) error
 	
-	// Stop gracefully shuts down the scheduler
+	// Stop gracefully shuts down the scheduler, waiting until ctx is done
+	// for in-flight jobs to finish
 	Stop(ctx context.Context) error
 	
 	// ScheduleCollector schedules a collector to run at specified intervals
@@ -98,6 +135,21 @@ type Scheduler interface {
 	// UnscheduleCollector removes a collector from the schedule
 	UnscheduleCollector(collectorName string, region string) error
 	
+	// TriggerCollector runs a scheduled collector in a region now instead of
+	// at its next run
+	TriggerCollector(ctx context.Context, collectorName, region string) error
+	
+	// PauseRegion stops running the jobs of a region until ResumeRegion is
+	// called, including jobs scheduled meanwhile
+	PauseRegion(region string)
+	
+	// ResumeRegion runs the jobs of a paused region again
+	ResumeRegion(region string)
+	
+	// SetEnabledRegions changes the regions collectors may be scheduled in;
+	// it applies to collectors scheduled afterwards
+	SetEnabledRegions(regions []string)
+	
 	// GetScheduledJobs returns all currently scheduled jobs
 	GetScheduledJobs() []ScheduledJob
 	
@@ -108,9 +160,13 @@ type Scheduler interface {
 	Health() error
 }
 
-// JobProcessor defines how to process collection results
+// JobProcessor defines how to process collection results. Jobs completing
+// at the same time call it concurrently.
 type JobProcessor interface {
-	// ProcessResult handles the result of a collection job
+	// ProcessResult handles the result of a collection job. The metrics of
+	// the result and their labels are reused for later collections once it
+	// returns, so processors that keep them must copy them, e.g. with
+	// collectors.CloneMetrics.
 	ProcessResult(ctx context.Context, job *ScheduledJob, result *collectors.CollectionResult) error
 	
 	// ProcessError handles errors that occur during collection
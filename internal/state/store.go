@@ -0,0 +1,254 @@
+// Package state provides a small persistent key-value store for the state
+// aws-monitor keeps across restarts, such as the last run of each collection
+// job, collector cursors and cumulative counters.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fileVersion is the version of the state file format
+const fileVersion = 1
+
+// file is the state file: the values of each bucket, by key, as JSON
+type file struct {
+	Version int                                   `json:"version"`
+	Buckets map[string]map[string]json.RawMessage `json:"buckets"`
+}
+
+// Store keeps values by bucket and key in memory and saves them to a JSON
+// file. Changes only mark the store dirty; they are saved by Flush, which
+// Run calls periodically and Close calls once more, so a crash loses at most
+// the changes since the last flush. A flush replaces the file atomically by a
+// synced temporary file, so the file always holds a complete flush. A store
+// without a path keeps its values in memory only.
+type Store struct {
+	path string
+
+	// flushMu serializes flushes, which write the file without holding mu
+	// so that changes aren't held up by disk I/O
+	flushMu sync.Mutex
+
+	mu      sync.Mutex
+	buckets map[string]map[string]json.RawMessage
+	// changes counts the changes made, and flushed those the file holds
+	changes uint64
+	flushed uint64
+}
+
+// Open opens the store saved at path, creating it on the first Flush if it
+// doesn't exist. An empty path opens a store kept in memory only.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, buckets: make(map[string]map[string]json.RawMessage)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var saved file
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if saved.Version != fileVersion {
+		return nil, fmt.Errorf("state file %s has unsupported version %d", path, saved.Version)
+	}
+	for name, bucket := range saved.Buckets {
+		s.buckets[name] = bucket
+	}
+	return s, nil
+}
+
+// Path returns the file the store is saved to; empty if it is kept in memory
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get decodes the value of key in bucket into value. It reports whether the
+// key exists.
+func (s *Store) Get(bucket, key string, value interface{}) (bool, error) {
+	s.mu.Lock()
+	raw, ok := s.buckets[bucket][key]
+	s.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return true, fmt.Errorf("failed to decode state %s/%s: %w", bucket, key, err)
+	}
+	return true, nil
+}
+
+// Put sets the value of key in bucket
+func (s *Store) Put(bucket, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode state %s/%s: %w", bucket, key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	s.buckets[bucket][key] = raw
+	s.changes++
+	return nil
+}
+
+// Delete removes key from bucket
+func (s *Store) Delete(bucket, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket][key]; ok {
+		delete(s.buckets[bucket], key)
+		s.changes++
+	}
+}
+
+// Keys returns the sorted keys of bucket
+func (s *Store) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Add adds delta to the counter key in bucket and returns its new value. A missing counter starts at zero.
+func (s *Store) Add(bucket, key string, delta float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var value float64
+	if raw, ok := s.buckets[bucket][key]; ok {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return 0, fmt.Errorf("failed to decode counter %s/%s: %w", bucket, key, err)
+		}
+	}
+	value += delta
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode counter %s/%s: %w", bucket, key, err)
+	}
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	s.buckets[bucket][key] = raw
+	s.changes++
+	return value, nil
+}
+
+// Flush saves the changes made since the last flush
+func (s *Store) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if s.path == "" || s.changes == s.flushed {
+		s.mu.Unlock()
+		return nil
+	}
+	changes := s.changes
+	data, err := json.Marshal(file{Version: fileVersion, Buckets: s.buckets})
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if err := writeFileSynced(s.path, data); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	s.mu.Lock()
+	s.flushed = changes
+	s.mu.Unlock()
+	return nil
+}
+
+// writeFileSynced replaces the file at path with data. The data is synced to
+// a temporary file that is then renamed over path, and the rename is synced,
+// so that after a crash the file holds either its previous or its new
+// contents.
+func writeFileSynced(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Not every platform can sync a directory; the rename is still atomic
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// Run flushes the store every interval until ctx is done, reporting flush
+// errors to onError
+func (s *Store) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Close saves the remaining changes
+func (s *Store) Close() error {
+	return s.Flush()
+}
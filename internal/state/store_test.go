@@ -0,0 +1,157 @@
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "state.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error opening a new store, got: %v", err)
+	}
+	lastRun := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.Put("scheduler", "ec2-us-east-1", lastRun); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := store.Add("counters", "collections", 2); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := store.Put("scheduler", "removed", lastRun); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	store.Delete("scheduler", "removed")
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error closing, got: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got: %v", err)
+	}
+	var restored time.Time
+	if found, err := reopened.Get("scheduler", "ec2-us-east-1", &restored); !found || err != nil {
+		t.Fatalf("Expected the last run to be restored, got found=%v, err=%v", found, err)
+	}
+	if !restored.Equal(lastRun) {
+		t.Errorf("Expected %v, got %v", lastRun, restored)
+	}
+	if keys := reopened.Keys("scheduler"); len(keys) != 1 || keys[0] != "ec2-us-east-1" {
+		t.Errorf("Expected only the remaining key, got %v", keys)
+	}
+	if total, err := reopened.Add("counters", "collections", 3); err != nil || total != 5 {
+		t.Errorf("Expected the counter to continue from 2 to 5, got %v, %v", total, err)
+	}
+	if found, _ := reopened.Get("scheduler", "missing", &restored); found {
+		t.Error("Expected a missing key not to be found")
+	}
+}
+
+func TestStoreInMemory(t *testing.T) {
+	store, err := Open("")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := store.Put("bucket", "key", "value"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var value string
+	if found, err := store.Get("bucket", "key", &value); !found || err != nil || value != "value" {
+		t.Errorf("Expected the stored value, got %q, found=%v, err=%v", value, found, err)
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Expected no error closing an in-memory store, got: %v", err)
+	}
+}
+
+func TestStoreRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Expected error for an invalid state file")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 99, "buckets": {}}`), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Expected error for an unsupported state file version")
+	}
+}
+
+func TestStoreRunFlushes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := store.Put("bucket", "key", 1); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		store.Run(ctx, 10*time.Millisecond, func(err error) { t.Errorf("Unexpected flush error: %v", err) })
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the state file to be written periodically, got: %v", err)
+	}
+}
+
+func TestStoreFlushRetriesFailedWrites(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "state")
+	path := filepath.Join(blocker, "state.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := store.Put("bucket", "key", 1); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// The state directory can't be created while a file has its name
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := store.Flush(); err == nil {
+		t.Fatal("Expected an error writing the state file")
+	}
+
+	if err := os.Remove(blocker); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Expected the changes to be flushed again, got: %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got: %v", err)
+	}
+	var value int
+	if found, _ := reopened.Get("bucket", "key", &value); !found || value != 1 {
+		t.Errorf("Expected the flushed change, got found=%v, value=%d", found, value)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(blocker, "*.tmp")); len(matches) != 0 {
+		t.Errorf("Expected no temporary files left, got %v", matches)
+	}
+}
@@ -0,0 +1,97 @@
+// Package systemd implements the parts of the systemd service notification
+// protocol aws-monitor uses: readiness, shutdown and watchdog notifications.
+package systemd
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// Notification states, see sd_notify(3)
+const (
+	// Ready reports that startup has completed
+	Ready = "READY=1"
+	// Stopping reports that shutdown has begun
+	Stopping = "STOPPING=1"
+	// Watchdog is the keep-alive heartbeat of the service watchdog
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends state to the service manager. It returns false, without an
+// error, when the process was not started by systemd with notifications
+// enabled (Type=notify).
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// A leading @ names a socket in the abstract namespace
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchdogTimeout returns the watchdog timeout set with WatchdogSec=, or
+// false if the watchdog is not enabled for this process
+func WatchdogTimeout() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// RunWatchdog sends a heartbeat every half timeout while healthy returns nil,
+// until ctx is done. No heartbeat is sent while healthy fails, or if it
+// hangs, so systemd restarts the process once the timeout passes.
+func RunWatchdog(ctx context.Context, timeout time.Duration, healthy func() error, log *logger.Logger) {
+	log = log.WithComponent("systemd")
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := healthy(); err != nil {
+			if !failing {
+				log.Warn("Withholding watchdog heartbeats; systemd restarts the service if this persists",
+					logger.Duration("timeout", timeout),
+					logger.String("error", err.Error()))
+			}
+			failing = true
+			continue
+		}
+		if failing {
+			log.Info("Watchdog heartbeats resumed")
+		}
+		failing = false
+
+		if _, err := Notify(Watchdog); err != nil {
+			log.Warn("Failed to send watchdog heartbeat", logger.String("error", err.Error()))
+		}
+	}
+}
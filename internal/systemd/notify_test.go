@@ -0,0 +1,108 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aws-monitoring/pkg/logger"
+)
+
+// listenNotifySocket listens on a notification socket and points
+// NOTIFY_SOCKET at it
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	// Socket paths are limited to about 100 bytes, shorter than t.TempDir()
+	dir, err := os.MkdirTemp("", "sd")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("Unix datagram sockets are not supported: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected a notification: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Errorf("Expected no notification outside systemd, got %t (%v)", sent, err)
+	}
+
+	conn := listenNotifySocket(t)
+	sent, err := Notify(Ready)
+	if !sent || err != nil {
+		t.Fatalf("Expected the notification to be sent, got %t (%v)", sent, err)
+	}
+	if state := readNotification(t, conn); state != Ready {
+		t.Errorf("Expected %q, got %q", Ready, state)
+	}
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	if _, ok := WatchdogTimeout(); ok {
+		t.Error("Expected the watchdog to be disabled without WATCHDOG_USEC")
+	}
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if timeout, ok := WatchdogTimeout(); !ok || timeout != 30*time.Second {
+		t.Errorf("Expected a 30s timeout, got %s (%t)", timeout, ok)
+	}
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if _, ok := WatchdogTimeout(); ok {
+		t.Error("Expected the watchdog of another process to be ignored")
+	}
+}
+
+func TestRunWatchdog(t *testing.T) {
+	conn := listenNotifySocket(t)
+	log, _ := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+
+	var unhealthy atomic.Bool
+	unhealthy.Store(true)
+	healthy := func() error {
+		if unhealthy.Load() {
+			return errors.New("scheduler has not ticked recently")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunWatchdog(ctx, 40*time.Millisecond, healthy, log)
+
+	// No heartbeat while unhealthy
+	_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 256)); err == nil {
+		t.Error("Expected no heartbeat while unhealthy")
+	}
+
+	unhealthy.Store(false)
+	if state := readNotification(t, conn); state != Watchdog {
+		t.Errorf("Expected %q, got %q", Watchdog, state)
+	}
+}
@@ -0,0 +1,113 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/config"
+	"aws-monitoring/internal/health"
+	"aws-monitoring/pkg/logger"
+)
+
+// CollectorName is the name of the self-telemetry collector
+const CollectorName = "self"
+
+// Collector reports the metrics of a registry as collection results. It only
+// runs in the default region, as the metrics are for the whole process.
+type Collector struct {
+	*collectors.BaseCollector
+	registry *Registry
+
+	// mu guards the collection count and time of the previous run, from
+	// which the collection rate is computed
+	mu        sync.Mutex
+	lastCount int64
+	lastTime  time.Time
+}
+
+// NewCollector creates the self-telemetry collector, collecting every
+// global.self_metrics.interval
+func NewCollector(cfg *config.Config, registry *Registry, log *logger.Logger) *Collector {
+	collectorConfig := collectors.DefaultCollectorConfig()
+	collectorConfig.Interval = time.Duration(cfg.Global.SelfMetrics.Interval)
+	collectorConfig.Timeout = 10 * time.Second
+	collectorConfig.Retries = 0
+	collectorConfig.EnabledRegions = []string{cfg.AWS.DefaultRegion}
+
+	return &Collector{
+		BaseCollector: collectors.NewBaseCollector(CollectorName, "Reports aws-monitor's own metrics",
+			cfg, collectorConfig, nil, log),
+		registry: registry,
+		// Counts restored from the state store are not new runs
+		lastCount: registry.CollectionCount(),
+		lastTime:  time.Now(),
+	}
+}
+
+// Collect reports the current value of every self-telemetry metric
+func (c *Collector) Collect(ctx context.Context, region string) *collectors.CollectionResult {
+	return c.CollectWithRetry(ctx, region, c.gather)
+}
+
+// DescribeMetrics returns the self-telemetry metrics, after those every
+// collector may produce
+func (c *Collector) DescribeMetrics() []collectors.MetricDescriptor {
+	own := []collectors.MetricDescriptor{
+		{Name: MetricCollectionsTotal, Kind: collectors.KindCounter, Unit: "Count",
+			Labels: []string{"collector", "region", "status"}},
+		{Name: MetricCollectionRate, Kind: collectors.KindGauge, Unit: "Count/Second"},
+		{Name: MetricCollectionDuration, Kind: collectors.KindHistogram, Unit: "Seconds",
+			Description: "Duration of collection runs", Labels: []string{"collector"}},
+		{Name: MetricExportRecordsTotal, Kind: collectors.KindCounter, Unit: "Count",
+			Labels: []string{"result"}},
+		{Name: MetricExportPending, Kind: collectors.KindGauge, Unit: "Count"},
+		{Name: MetricLogBufferEntries, Kind: collectors.KindGauge, Unit: "Count"},
+		{Name: MetricLogBufferCapacity, Kind: collectors.KindGauge, Unit: "Count"},
+		{Name: MetricLogDroppedTotal, Kind: collectors.KindCounter, Unit: "Count"},
+		{Name: MetricActiveJobs, Kind: collectors.KindGauge, Unit: "Count"},
+		{Name: MetricGoroutines, Kind: collectors.KindGauge, Unit: "Count"},
+		{Name: health.MetricStatus, Kind: collectors.KindGauge, Unit: "Count",
+			Labels: []string{"status"}},
+		{Name: health.MetricCheckStatus, Kind: collectors.KindGauge, Unit: "Count",
+			Labels: []string{"checker", "status"}},
+		{Name: health.MetricCheckDuration, Kind: collectors.KindGauge, Unit: "Seconds",
+			Labels: []string{"checker"}},
+	}
+	for i := range own {
+		if own[i].Description == "" {
+			own[i].Description = Sample{Name: own[i].Name}.Description()
+		}
+	}
+	return append(c.BaseCollector.DescribeMetrics(), own...)
+}
+
+func (c *Collector) gather(_ context.Context, _ string) ([]collectors.MetricData, error) {
+	samples := c.registry.Samples()
+	metrics := make([]collectors.MetricData, 0, len(samples)+1)
+	for _, sample := range samples {
+		metrics = append(metrics, c.CreateMetricWithDescription(sample.Name, sample.Value, sample.Unit,
+			sample.Description(), sample.Labels))
+	}
+	metrics = append(metrics, c.CreateMetricWithDescription(MetricCollectionRate, c.collectionRate(), "Count/Second",
+		metricDescriptions[MetricCollectionRate], nil))
+	return metrics, nil
+}
+
+// collectionRate returns the collection runs per second since the previous
+// call, or since the collector was created
+func (c *Collector) collectionRate() float64 {
+	count := c.registry.CollectionCount()
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elapsed := now.Sub(c.lastTime).Seconds()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(count-c.lastCount) / elapsed
+	}
+	c.lastCount, c.lastTime = count, now
+	return rate
+}
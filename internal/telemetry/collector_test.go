@@ -0,0 +1,60 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/logger"
+)
+
+func TestCollector(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	cfg := &config.Config{
+		EnabledRegions: []string{"us-east-1", "eu-west-1"},
+		AWS:            config.AWSConfig{DefaultRegion: "eu-west-1"},
+	}
+	cfg.Global.SelfMetrics.Interval = config.Duration(time.Minute)
+
+	registry := NewRegistry()
+	registry.RecordCollection("ec2", "us-east-1", time.Second, false)
+	c := NewCollector(cfg, registry, log)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start collector: %v", err)
+	}
+
+	info := c.Info()
+	if info.Name != CollectorName || info.Interval != time.Minute {
+		t.Errorf("Unexpected collector info: %+v", info)
+	}
+	if len(info.EnabledRegions) != 1 || info.EnabledRegions[0] != "eu-west-1" {
+		t.Errorf("Expected the collector to run in the default region only, got %v", info.EnabledRegions)
+	}
+
+	result := c.Collect(context.Background(), "eu-west-1")
+	if result.Error != nil {
+		t.Fatalf("Expected no error, got %v", result.Error)
+	}
+	names := make(map[string]bool)
+	for _, metric := range result.Metrics {
+		names[metric.Name] = true
+		if !strings.HasPrefix(metric.Name, "aws_monitor_") {
+			t.Errorf("Expected an aws_monitor_ prefix, got %s", metric.Name)
+		}
+	}
+	for _, name := range []string{MetricCollectionsTotal, MetricCollectionRate, MetricCollectionDuration + "_count", MetricGoroutines} {
+		if !names[name] {
+			t.Errorf("Expected a %s metric", name)
+		}
+	}
+	for _, metric := range result.Metrics {
+		if metric.Name == MetricCollectionsTotal && metric.Labels["collector"] != "ec2" {
+			t.Errorf("Expected the collections to keep the ec2 collector label, got %v", metric.Labels)
+		}
+	}
+}
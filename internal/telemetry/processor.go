@@ -0,0 +1,44 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+)
+
+// Processor implements scheduler.JobProcessor by recording every collection
+// run in a registry before passing it on to the next processor. Runs of the
+// self-telemetry collector are not recorded.
+type Processor struct {
+	next     scheduler.JobProcessor
+	registry *Registry
+}
+
+// NewProcessor creates a processor recording collection runs in registry
+func NewProcessor(next scheduler.JobProcessor, registry *Registry) *Processor {
+	return &Processor{next: next, registry: registry}
+}
+
+// ProcessResult records a successful collection run. Failed runs are
+// recorded by ProcessError, even when their result carries metrics.
+func (p *Processor) ProcessResult(ctx context.Context, job *scheduler.ScheduledJob, result *collectors.CollectionResult) error {
+	if result.Error == nil && job.CollectorName != CollectorName {
+		p.registry.RecordCollection(job.CollectorName, job.Region, result.Duration, false)
+	}
+	return p.next.ProcessResult(ctx, job, result)
+}
+
+// ProcessError records a failed collection run
+func (p *Processor) ProcessError(ctx context.Context, job *scheduler.ScheduledJob, err *errors.Error) error {
+	if job.CollectorName != CollectorName {
+		var duration time.Duration
+		if job.LastResult != nil {
+			duration = job.LastResult.Duration
+		}
+		p.registry.RecordCollection(job.CollectorName, job.Region, duration, true)
+	}
+	return p.next.ProcessError(ctx, job, err)
+}
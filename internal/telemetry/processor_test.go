@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/collectors"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/errors"
+)
+
+// countingProcessor counts the results and errors passed on to it
+type countingProcessor struct {
+	results, errors int
+}
+
+func (p *countingProcessor) ProcessResult(_ context.Context, _ *scheduler.ScheduledJob, _ *collectors.CollectionResult) error {
+	p.results++
+	return nil
+}
+
+func (p *countingProcessor) ProcessError(_ context.Context, _ *scheduler.ScheduledJob, _ *errors.Error) error {
+	p.errors++
+	return nil
+}
+
+func TestProcessor(t *testing.T) {
+	registry := NewRegistry()
+	next := &countingProcessor{}
+	p := NewProcessor(next, registry)
+	ctx := context.Background()
+
+	job := &scheduler.ScheduledJob{CollectorName: "ec2", Region: "us-east-1"}
+	_ = p.ProcessResult(ctx, job, &collectors.CollectionResult{Duration: time.Second})
+
+	// A failed run carrying metrics is passed to both methods but recorded once
+	failed := &collectors.CollectionResult{Duration: 2 * time.Second, Error: errors.NewTimeoutError("collect", 2*time.Second)}
+	job.LastResult = failed
+	_ = p.ProcessError(ctx, job, failed.Error)
+	_ = p.ProcessResult(ctx, job, failed)
+
+	self := &scheduler.ScheduledJob{CollectorName: CollectorName, Region: "us-east-1"}
+	_ = p.ProcessResult(ctx, self, &collectors.CollectionResult{})
+
+	if next.results != 3 || next.errors != 1 {
+		t.Errorf("Expected 3 results and 1 error passed on, got %d and %d", next.results, next.errors)
+	}
+	samples := registry.Samples()
+	if value, _ := findSample(samples, MetricCollectionsTotal, map[string]string{"status": StatusSuccess}); value != 1 {
+		t.Errorf("Expected 1 successful collection, got %g", value)
+	}
+	if value, _ := findSample(samples, MetricCollectionsTotal, map[string]string{"status": StatusFailure}); value != 1 {
+		t.Errorf("Expected 1 failed collection, got %g", value)
+	}
+	if sum, _ := findSample(samples, MetricCollectionDuration+"_sum", nil); sum != 3 {
+		t.Errorf("Expected a duration sum of 3s, got %g", sum)
+	}
+	if _, ok := findSample(samples, MetricCollectionsTotal, map[string]string{"collector": CollectorName}); ok {
+		t.Error("Expected the self-telemetry collector's runs not to be recorded")
+	}
+}
@@ -0,0 +1,322 @@
+// Package telemetry records aws-monitor's own metrics and reports them through
+// a collector, so they are exported through the same pipeline as the AWS
+// metrics.
+package telemetry
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"aws-monitoring/internal/health"
+	"aws-monitoring/internal/scheduler"
+	"aws-monitoring/pkg/logger"
+)
+
+// Self-telemetry metric names. The collection duration is a histogram
+// reported as _bucket, _sum and _count series.
+const (
+	MetricCollectionsTotal   = "aws_monitor_collections_total"
+	MetricCollectionRate     = "aws_monitor_collections_per_second"
+	MetricCollectionDuration = "aws_monitor_collection_duration_seconds"
+	MetricExportRecordsTotal = "aws_monitor_export_records_total"
+	MetricExportPending      = "aws_monitor_export_pending_records"
+	MetricLogBufferEntries   = "aws_monitor_log_buffer_entries"
+	MetricLogBufferCapacity  = "aws_monitor_log_buffer_capacity"
+	MetricLogDroppedTotal    = "aws_monitor_log_dropped_total"
+	MetricActiveJobs         = "aws_monitor_scheduler_active_jobs"
+	MetricGoroutines         = "aws_monitor_goroutines"
+)
+
+// Collection outcomes, the status label of MetricCollectionsTotal
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of the collection
+// duration histogram buckets
+var DurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// metricDescriptions describe the metrics, by name
+var metricDescriptions = map[string]string{
+	MetricCollectionsTotal:               "Total number of collection runs",
+	MetricCollectionRate:                 "Collection runs per second since the previous report",
+	MetricCollectionDuration + "_bucket": "Collection runs that took at most le seconds",
+	MetricCollectionDuration + "_sum":    "Total duration of collection runs",
+	MetricCollectionDuration + "_count":  "Number of timed collection runs",
+	MetricExportRecordsTotal:             "Total number of log records exported, by result",
+	MetricExportPending:                  "Log records waiting to be exported",
+	MetricLogBufferEntries:               "Log entries waiting in the asynchronous logging queue",
+	MetricLogBufferCapacity:              "Capacity of the asynchronous logging queue",
+	MetricLogDroppedTotal:                "Total number of log entries dropped because the queue was full",
+	MetricActiveJobs:                     "Collection jobs currently running",
+	MetricGoroutines:                     "Number of goroutines",
+}
+
+// LogStats reports the logging pipeline counters; *logger.Logger implements
+// it
+type LogStats interface {
+	ExportStats() (stats logger.ExportStats, ok bool)
+	AsyncStats() (stats logger.AsyncStats, ok bool)
+}
+
+// Sample is a single value of a self-telemetry metric
+type Sample struct {
+	Name   string
+	Value  float64
+	Unit   string
+	Labels map[string]string
+}
+
+// Description returns the description of the sample's metric
+func (s Sample) Description() string {
+	if description, ok := metricDescriptions[s.Name]; ok {
+		return description
+	}
+	return health.MetricDescription(s.Name)
+}
+
+// collectionKey identifies a collection counter series
+type collectionKey struct {
+	collector string
+	region    string
+	status    string
+}
+
+// stateBucket is the state store bucket of the collection counters
+const stateBucket = "telemetry"
+
+// HealthSource reports the latest health check results; *health.Manager
+// implements it
+type HealthSource interface {
+	GetHealth() health.OverallHealth
+}
+
+// CounterStore persists the collection counters across restarts;
+// *state.Store implements it
+type CounterStore interface {
+	Keys(bucket string) []string
+	Get(bucket, key string, value interface{}) (bool, error)
+	Put(bucket, key string, value interface{}) error
+}
+
+// savedCollections is a collection counter in the state store
+type savedCollections struct {
+	Collector string `json:"collector"`
+	Region    string `json:"region"`
+	Status    string `json:"status"`
+	Count     int64  `json:"count"`
+}
+
+// stateKey returns the state store key of a collection counter
+func (k collectionKey) stateKey() string {
+	return k.collector + "/" + k.region + "/" + k.status
+}
+
+// histogram counts observations per bucket of DurationBuckets; the last
+// count is for observations above every bound
+type histogram struct {
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+func (h *histogram) observe(value float64) {
+	i := sort.SearchFloat64s(DurationBuckets, value)
+	h.counts[i]++
+	h.count++
+	h.sum += value
+}
+
+// Registry records the collection runs and reads the logging, scheduler and
+// health check state when samples are taken
+type Registry struct {
+	mu          sync.Mutex
+	collections map[collectionKey]int64
+	durations   map[string]*histogram
+
+	logs      LogStats
+	scheduler scheduler.Scheduler
+	health    HealthSource
+	store     CounterStore
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		collections: make(map[collectionKey]int64),
+		durations:   make(map[string]*histogram),
+	}
+}
+
+// SetLogStats enables the export and log buffer metrics
+func (r *Registry) SetLogStats(logs LogStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = logs
+}
+
+// SetScheduler enables the active jobs metric
+func (r *Registry) SetScheduler(s scheduler.Scheduler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scheduler = s
+}
+
+// SetHealth enables the health check metrics
+func (r *Registry) SetHealth(source HealthSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.health = source
+}
+
+// SetStateStore persists the collection counters in store, continuing from
+// the counts saved there before a restart
+func (r *Registry) SetStateStore(store CounterStore) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range store.Keys(stateBucket) {
+		var saved savedCollections
+		if _, err := store.Get(stateBucket, key, &saved); err != nil {
+			return err
+		}
+		r.collections[collectionKey{collector: saved.Collector, region: saved.Region, status: saved.Status}] += saved.Count
+	}
+	r.store = store
+	return nil
+}
+
+// RecordCollection records a collection run of a collector in a region
+func (r *Registry) RecordCollection(collector, region string, duration time.Duration, failed bool) {
+	status := StatusSuccess
+	if failed {
+		status = StatusFailure
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := collectionKey{collector: collector, region: region, status: status}
+	r.collections[key]++
+	if r.store != nil {
+		// Counters are plain values, which always encode
+		_ = r.store.Put(stateBucket, key.stateKey(), savedCollections{
+			Collector: collector, Region: region, Status: status, Count: r.collections[key],
+		})
+	}
+	h, ok := r.durations[collector]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(DurationBuckets)+1)}
+		r.durations[collector] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// CollectionCount returns the number of collection runs recorded
+func (r *Registry) CollectionCount() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	for _, count := range r.collections {
+		total += count
+	}
+	return total
+}
+
+// Samples returns the current value of every metric, in a stable order
+func (r *Registry) Samples() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var samples []Sample
+	keys := make([]collectionKey, 0, len(r.collections))
+	for key := range r.collections {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.collector != b.collector {
+			return a.collector < b.collector
+		}
+		if a.region != b.region {
+			return a.region < b.region
+		}
+		return a.status < b.status
+	})
+	for _, key := range keys {
+		samples = append(samples, Sample{
+			Name:  MetricCollectionsTotal,
+			Value: float64(r.collections[key]),
+			Unit:  "Count",
+			Labels: map[string]string{
+				"collector": key.collector,
+				"region":    key.region,
+				"status":    key.status,
+			},
+		})
+	}
+
+	collectors := make([]string, 0, len(r.durations))
+	for collector := range r.durations {
+		collectors = append(collectors, collector)
+	}
+	sort.Strings(collectors)
+	for _, collector := range collectors {
+		samples = append(samples, r.durations[collector].samples(collector)...)
+	}
+
+	if r.logs != nil {
+		if stats, ok := r.logs.ExportStats(); ok {
+			samples = append(samples,
+				Sample{Name: MetricExportRecordsTotal, Value: float64(stats.Exported), Unit: "Count",
+					Labels: map[string]string{"result": StatusSuccess}},
+				Sample{Name: MetricExportRecordsTotal, Value: float64(stats.Failed), Unit: "Count",
+					Labels: map[string]string{"result": StatusFailure}},
+				Sample{Name: MetricExportPending, Value: float64(stats.Pending), Unit: "Count"})
+		}
+		if stats, ok := r.logs.AsyncStats(); ok {
+			samples = append(samples,
+				Sample{Name: MetricLogBufferEntries, Value: float64(stats.Queued), Unit: "Count"},
+				Sample{Name: MetricLogBufferCapacity, Value: float64(stats.QueueSize), Unit: "Count"},
+				Sample{Name: MetricLogDroppedTotal, Value: float64(stats.Dropped), Unit: "Count"})
+		}
+	}
+	if r.scheduler != nil {
+		samples = append(samples, Sample{Name: MetricActiveJobs, Value: float64(r.scheduler.GetInfo().ActiveJobs), Unit: "Count"})
+	}
+	if r.health != nil {
+		for _, sample := range health.MetricSamples(r.health.GetHealth()) {
+			samples = append(samples, Sample{Name: sample.Name, Value: sample.Value, Unit: sample.Unit, Labels: sample.Labels})
+		}
+	}
+	samples = append(samples, Sample{Name: MetricGoroutines, Value: float64(runtime.NumGoroutine()), Unit: "Count"})
+	return samples
+}
+
+// samples returns the cumulative bucket, sum and count series of the
+// histogram of a collector
+func (h *histogram) samples(collector string) []Sample {
+	samples := make([]Sample, 0, len(h.counts)+2)
+	var cumulative int64
+	for i, count := range h.counts {
+		cumulative += count
+		le := "+Inf"
+		if i < len(DurationBuckets) {
+			le = strconv.FormatFloat(DurationBuckets[i], 'g', -1, 64)
+		}
+		samples = append(samples, Sample{
+			Name:   MetricCollectionDuration + "_bucket",
+			Value:  float64(cumulative),
+			Unit:   "Count",
+			Labels: map[string]string{"collector": collector, "le": le},
+		})
+	}
+	return append(samples,
+		Sample{Name: MetricCollectionDuration + "_sum", Value: h.sum, Unit: "Seconds",
+			Labels: map[string]string{"collector": collector}},
+		Sample{Name: MetricCollectionDuration + "_count", Value: float64(h.count), Unit: "Count",
+			Labels: map[string]string{"collector": collector}})
+}
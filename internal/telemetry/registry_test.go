@@ -0,0 +1,142 @@
+package telemetry
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"aws-monitoring/internal/health"
+	"aws-monitoring/internal/state"
+	"aws-monitoring/pkg/logger"
+)
+
+// stubLogStats reports fixed logging counters
+type stubLogStats struct{}
+
+func (stubLogStats) ExportStats() (logger.ExportStats, bool) {
+	return logger.ExportStats{Exported: 10, Failed: 2, Pending: 3}, true
+}
+
+func (stubLogStats) AsyncStats() (logger.AsyncStats, bool) {
+	return logger.AsyncStats{}, false
+}
+
+// stubHealth reports fixed health check results
+type stubHealth struct{}
+
+func (stubHealth) GetHealth() health.OverallHealth {
+	return health.OverallHealth{
+		Status: health.StatusDegraded,
+		Checks: map[string]health.CheckResult{
+			"disk": {Status: health.StatusDegraded, Duration: 2 * time.Millisecond},
+		},
+	}
+}
+
+// findSample returns the value of the sample with name and the given labels
+func findSample(samples []Sample, name string, labels map[string]string) (float64, bool) {
+	for _, sample := range samples {
+		if sample.Name != name {
+			continue
+		}
+		matches := true
+		for key, value := range labels {
+			if sample.Labels[key] != value {
+				matches = false
+			}
+		}
+		if matches {
+			return sample.Value, true
+		}
+	}
+	return 0, false
+}
+
+func TestRegistrySamples(t *testing.T) {
+	r := NewRegistry()
+	r.RecordCollection("ec2", "us-east-1", 200*time.Millisecond, false)
+	r.RecordCollection("ec2", "us-east-1", 3*time.Second, false)
+	r.RecordCollection("ec2", "us-east-1", 400*time.Second, true)
+	r.SetLogStats(stubLogStats{})
+	r.SetHealth(stubHealth{})
+
+	if count := r.CollectionCount(); count != 3 {
+		t.Errorf("Expected 3 collections, got %d", count)
+	}
+
+	samples := r.Samples()
+	for _, tc := range []struct {
+		name   string
+		labels map[string]string
+		value  float64
+	}{
+		{MetricCollectionsTotal, map[string]string{"collector": "ec2", "status": StatusSuccess}, 2},
+		{MetricCollectionsTotal, map[string]string{"collector": "ec2", "status": StatusFailure}, 1},
+		{MetricCollectionDuration + "_bucket", map[string]string{"collector": "ec2", "le": "0.1"}, 0},
+		{MetricCollectionDuration + "_bucket", map[string]string{"collector": "ec2", "le": "0.25"}, 1},
+		{MetricCollectionDuration + "_bucket", map[string]string{"collector": "ec2", "le": "5"}, 2},
+		{MetricCollectionDuration + "_bucket", map[string]string{"collector": "ec2", "le": "300"}, 2},
+		{MetricCollectionDuration + "_bucket", map[string]string{"collector": "ec2", "le": "+Inf"}, 3},
+		{MetricCollectionDuration + "_count", map[string]string{"collector": "ec2"}, 3},
+		{MetricExportRecordsTotal, map[string]string{"result": StatusSuccess}, 10},
+		{MetricExportRecordsTotal, map[string]string{"result": StatusFailure}, 2},
+		{MetricExportPending, nil, 3},
+		{health.MetricStatus, map[string]string{"status": "degraded"}, 1},
+		{health.MetricCheckStatus, map[string]string{"checker": "disk", "status": "healthy"}, 0},
+		{health.MetricCheckDuration, map[string]string{"checker": "disk"}, 0.002},
+	} {
+		value, ok := findSample(samples, tc.name, tc.labels)
+		if !ok {
+			t.Errorf("Expected a %s sample with labels %v", tc.name, tc.labels)
+			continue
+		}
+		if value != tc.value {
+			t.Errorf("Expected %s%v to be %g, got %g", tc.name, tc.labels, tc.value, value)
+		}
+	}
+
+	if sum, _ := findSample(samples, MetricCollectionDuration+"_sum", nil); math.Abs(sum-403.2) > 1e-9 {
+		t.Errorf("Expected a duration sum of 403.2s, got %g", sum)
+	}
+	if _, ok := findSample(samples, MetricLogBufferEntries, nil); ok {
+		t.Error("Expected no log buffer metrics without asynchronous logging")
+	}
+	if goroutines, ok := findSample(samples, MetricGoroutines, nil); !ok || goroutines < 1 {
+		t.Errorf("Expected a goroutine count, got %g", goroutines)
+	}
+	for _, sample := range samples {
+		if sample.Description() == "" {
+			t.Errorf("Expected a description for %s", sample.Name)
+		}
+	}
+}
+
+func TestRegistryStateStore(t *testing.T) {
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatalf("Failed to open state store: %v", err)
+	}
+
+	before := NewRegistry()
+	if err := before.SetStateStore(store); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	before.RecordCollection("ec2", "us-east-1", time.Second, false)
+	before.RecordCollection("ec2", "us-east-1", time.Second, false)
+	before.RecordCollection("ec2", "us-east-1", time.Second, true)
+
+	// A registry created after a restart continues from the saved counts
+	after := NewRegistry()
+	if err := after.SetStateStore(store); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	after.RecordCollection("ec2", "us-east-1", time.Second, false)
+
+	samples := after.Samples()
+	if value, _ := findSample(samples, MetricCollectionsTotal, map[string]string{"region": "us-east-1", "status": StatusSuccess}); value != 3 {
+		t.Errorf("Expected 3 successful collections, got %v", value)
+	}
+	if value, _ := findSample(samples, MetricCollectionsTotal, map[string]string{"region": "us-east-1", "status": StatusFailure}); value != 1 {
+		t.Errorf("Expected 1 failed collection, got %v", value)
+	}
+}
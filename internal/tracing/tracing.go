@@ -0,0 +1,94 @@
+// Package tracing exports the spans of collection jobs to the OpenTelemetry
+// collector. Instrumented packages start spans with the global tracer
+// provider, which records nothing until NewProvider's provider is installed.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+
+	"aws-monitoring/internal/config"
+	"aws-monitoring/pkg/discovery"
+)
+
+// Span attributes shared by the instrumented packages
+const (
+	AttrCollector   = attribute.Key("aws_monitor.collector")
+	AttrRegion      = attribute.Key("aws_monitor.region")
+	AttrJobID       = attribute.Key("aws_monitor.job.id")
+	AttrMetricCount = attribute.Key("aws_monitor.metric_count")
+)
+
+// NewProvider creates a tracer provider exporting spans over OTLP/gRPC to the
+// configured collector, and installs it as the global tracer provider.
+// Shutting the provider down exports the remaining spans.
+func NewProvider(cfg config.OTELConfig, version string) (*sdktrace.TracerProvider, error) {
+	target, err := discovery.Resolve(cfg.CollectorEndpoint, time.Duration(cfg.SRVRefreshInterval))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(target.Address), otlptracegrpc.WithDialOption(target.DialOptions...)}
+	if cfg.Insecure || target.Plaintext {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+
+	exporter, err := otlptracegrpc.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	provider := newProvider(cfg, version, exporter)
+	otel.SetTracerProvider(provider)
+	return provider, nil
+}
+
+// newProvider creates a tracer provider batching spans to exporter
+func newProvider(cfg config.OTELConfig, version string, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	var batchOpts []sdktrace.BatchSpanProcessorOption
+	if cfg.BatchTimeout > 0 {
+		batchOpts = append(batchOpts, sdktrace.WithBatchTimeout(time.Duration(cfg.BatchTimeout)))
+	}
+	if cfg.BatchSize > 0 {
+		batchOpts = append(batchOpts, sdktrace.WithMaxExportBatchSize(cfg.BatchSize))
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(traceResource(cfg, version)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TraceSampleRatio))),
+		sdktrace.WithBatcher(exporter, batchOpts...),
+	)
+}
+
+// traceResource returns the resource describing the exporting service, as
+// for exported log records
+func traceResource(cfg config.OTELConfig, version string) *resource.Resource {
+	keys := make([]string, 0, len(cfg.ResourceAttributes))
+	for key := range cfg.ResourceAttributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute.KeyValue, 0, len(keys)+2)
+	for _, key := range keys {
+		attrs = append(attrs, attribute.String(key, cfg.ResourceAttributes[key]))
+	}
+	attrs = append(attrs, semconv.ServiceName(cfg.ServiceName))
+	if version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(version))
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
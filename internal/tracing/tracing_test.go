@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+
+	"aws-monitoring/internal/config"
+)
+
+func TestNewProvider(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	cfg := config.OTELConfig{
+		ServiceName:        "aws-monitor",
+		TraceSampleRatio:   1,
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+	}
+	provider := newProvider(cfg, "1.2.3", exporter)
+
+	_, span := provider.Tracer("test").Start(context.Background(), "collection job")
+	span.End()
+	// Shutting down would also reset the in-memory exporter
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush provider: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 exported span, got %d", len(spans))
+	}
+	attributes := make(map[string]string)
+	for _, attr := range spans[0].Resource.Attributes() {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attributes[string(semconv.ServiceNameKey)] != "aws-monitor" || attributes[string(semconv.ServiceVersionKey)] != "1.2.3" {
+		t.Errorf("Expected the service name and version in the resource, got %v", attributes)
+	}
+	if attributes["deployment.environment"] != "test" {
+		t.Errorf("Expected the configured resource attributes, got %v", attributes)
+	}
+}
+
+func TestNewProviderSampling(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := newProvider(config.OTELConfig{ServiceName: "aws-monitor", TraceSampleRatio: 0}, "", exporter)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "collection job")
+	_, child := provider.Tracer("test").Start(ctx, "collect")
+	child.End()
+	parent.End()
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush provider: %v", err)
+	}
+
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("Expected no spans with a sample ratio of 0, got %d", len(spans))
+	}
+}
@@ -0,0 +1,103 @@
+package triggers
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"aws-monitoring/internal/aws"
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// Trigger runs the collection of a collector in a region now
+type Trigger interface {
+	TriggerCollector(ctx context.Context, collectorName, region string) error
+}
+
+// Listener consumes an SQS queue of EventBridge events and triggers the
+// collectors their rules select in the event's region. Messages that are not
+// valid events are discarded.
+type Listener struct {
+	consumer *aws.QueueConsumer
+	queueURL string
+	rules    []Rule
+	trigger  Trigger
+	logger   *logger.Logger
+}
+
+// NewListener creates a listener for the queue at queueURL. Start starts it.
+func NewListener(client aws.SQSClient, queueURL string, rules []Rule, trigger Trigger, log *logger.Logger) *Listener {
+	l := &Listener{
+		queueURL: queueURL,
+		rules:    rules,
+		trigger:  trigger,
+		logger:   log.WithComponent("triggers"),
+	}
+	l.consumer = aws.NewQueueConsumer(client, queueURL, l.handle, l.logger)
+	return l
+}
+
+// SetWaitTime sets how long a receive waits for messages, up to 20s
+func (l *Listener) SetWaitTime(waitTime time.Duration) {
+	l.consumer.SetWaitTime(waitTime)
+}
+
+// Start consumes the queue in the background until Stop is called or ctx is
+// done
+func (l *Listener) Start(ctx context.Context) {
+	if l.consumer.Start(ctx) {
+		l.logger.Info("Event trigger listener started",
+			logger.String("queue_url", l.queueURL),
+			logger.Int("rules", len(l.rules)))
+	}
+}
+
+// Stop stops consuming the queue and waits until ctx is done for the receive
+// in progress to return
+func (l *Listener) Stop(ctx context.Context) error {
+	return l.consumer.Stop(ctx)
+}
+
+// handle triggers the collectors selected by the event in a message. The
+// collections outlive the listener; the scheduler cancels them on shutdown.
+func (l *Listener) handle(ctx context.Context, messageID, body string) {
+	event, err := ParseEvent(body)
+	if err != nil {
+		l.logger.Warn("Discarding event trigger message",
+			logger.String("message_id", messageID),
+			logger.String("error", err.Error()))
+		return
+	}
+
+	collectorNames := Collectors(l.rules, event)
+	if len(collectorNames) == 0 {
+		l.logger.Debug("No rule matches event",
+			logger.String("source", event.Source),
+			logger.String("detail_type", event.DetailType))
+		return
+	}
+
+	for _, name := range collectorNames {
+		err := l.trigger.TriggerCollector(ctx, name, event.Region)
+		switch {
+		case err == nil:
+			l.logger.Info("Event triggered collection",
+				logger.String("collector", name),
+				logger.String("region", event.Region),
+				logger.String("source", event.Source),
+				logger.String("detail_type", event.DetailType),
+				logger.String("event_id", event.ID))
+		case stderrors.Is(err, &errors.Error{Type: errors.ErrorTypeValidation, Code: errors.CodeJobNotFound}):
+			// The collector is disabled or not collected in the event's region
+			l.logger.Debug("Event matches a collector not scheduled in its region",
+				logger.String("collector", name),
+				logger.String("region", event.Region))
+		default:
+			l.logger.Warn("Failed to trigger collection",
+				logger.String("collector", name),
+				logger.String("region", event.Region),
+				logger.String("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package triggers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"aws-monitoring/pkg/errors"
+	"aws-monitoring/pkg/logger"
+)
+
+// mockSQSClient returns the queued messages on the first receive, then waits
+// for the context like an empty long poll
+type mockSQSClient struct {
+	mu       sync.Mutex
+	messages []types.Message
+	deleted  []string
+	err      error
+}
+
+func (m *mockSQSClient) ReceiveMessage(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	m.mu.Lock()
+	messages, err := m.messages, m.err
+	m.messages = nil
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+func (m *mockSQSClient) DeleteMessage(_ context.Context, params *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, awssdk.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (m *mockSQSClient) Deleted() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.deleted...)
+}
+
+// mockTrigger records the triggered jobs; only the jobs in scheduled exist
+type mockTrigger struct {
+	mu        sync.Mutex
+	scheduled map[string]bool
+	triggered []string
+}
+
+func (m *mockTrigger) TriggerCollector(_ context.Context, collectorName, region string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobID := fmt.Sprintf("%s-%s", collectorName, region)
+	if !m.scheduled[jobID] {
+		return errors.NewValidationError(errors.CodeJobNotFound, fmt.Sprintf("job %s not found", jobID))
+	}
+	m.triggered = append(m.triggered, jobID)
+	return nil
+}
+
+func (m *mockTrigger) Triggered() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.triggered...)
+}
+
+func testLogger() *logger.Logger {
+	log, _ := logger.NewLogger(logger.Config{Level: "error", Format: "json"})
+	return log
+}
+
+func message(id, body string) types.Message {
+	return types.Message{
+		MessageId:     awssdk.String(id),
+		ReceiptHandle: awssdk.String("receipt-" + id),
+		Body:          awssdk.String(body),
+	}
+}
+
+func TestListener(t *testing.T) {
+	client := &mockSQSClient{messages: []types.Message{
+		message("launched", instanceLaunched),
+		message("failover", `{"source": "aws.rds", "detail-type": "RDS DB Cluster Event", "region": "us-east-1"}`),
+		message("invalid", "not an event"),
+	}}
+	trigger := &mockTrigger{scheduled: map[string]bool{"ec2-eu-west-1": true}}
+
+	listener := NewListener(client, "https://sqs.us-east-1.amazonaws.com/123456789012/events", DefaultRules(), trigger, testLogger())
+	listener.Start(context.Background())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.Deleted()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := listener.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop listener: %v", err)
+	}
+
+	// The RDS collector is not scheduled in us-east-1, so only the EC2
+	// collector runs
+	if triggered := trigger.Triggered(); len(triggered) != 1 || triggered[0] != "ec2-eu-west-1" {
+		t.Errorf("Expected ec2-eu-west-1 to be triggered, got %v", triggered)
+	}
+
+	// Every message is deleted, including the one that is not an event
+	if deleted := client.Deleted(); len(deleted) != 3 {
+		t.Errorf("Expected 3 deleted messages, got %v", deleted)
+	}
+}
+
+func TestListenerStopWithoutStart(t *testing.T) {
+	listener := NewListener(&mockSQSClient{}, "queue", nil, &mockTrigger{}, testLogger())
+	if err := listener.Stop(context.Background()); err != nil {
+		t.Errorf("Expected no error stopping a listener that never started, got %v", err)
+	}
+}
+
+func TestListenerReceiveError(t *testing.T) {
+	client := &mockSQSClient{err: fmt.Errorf("access denied")}
+	listener := NewListener(client, "queue", DefaultRules(), &mockTrigger{}, testLogger())
+	listener.Start(context.Background())
+
+	// The listener waits before retrying and stops during the wait
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := listener.Stop(ctx); err != nil {
+		t.Errorf("Expected the listener to stop while waiting to retry, got %v", err)
+	}
+}
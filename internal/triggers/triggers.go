@@ -0,0 +1,107 @@
+// Package triggers runs collections as soon as AWS reports a relevant
+// change, instead of at the next collection interval. EventBridge rules
+// deliver the events to an SQS queue that a Listener consumes; each event
+// matching a Rule triggers the rule's collectors in the event's region.
+package triggers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Event is the envelope of an EventBridge event
+type Event struct {
+	ID         string          `json:"id"`
+	Source     string          `json:"source"`
+	DetailType string          `json:"detail-type"`
+	Account    string          `json:"account"`
+	Region     string          `json:"region"`
+	Resources  []string        `json:"resources"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// Rule selects the collectors triggered by events of a source and, when
+// DetailTypes is set, of one of those detail types
+type Rule struct {
+	Source      string
+	DetailTypes []string
+	Collectors  []string
+}
+
+// DefaultRules trigger the EC2 collector when an instance changes state, e.g.
+// is launched or terminated, and the RDS collector on database instance and
+// cluster events such as a failover
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Source:      "aws.ec2",
+			DetailTypes: []string{"EC2 Instance State-change Notification"},
+			Collectors:  []string{"ec2"},
+		},
+		{
+			Source:      "aws.rds",
+			DetailTypes: []string{"RDS DB Instance Event", "RDS DB Cluster Event"},
+			Collectors:  []string{"rds"},
+		},
+	}
+}
+
+// Matches reports whether the rule selects the event
+func (r Rule) Matches(event Event) bool {
+	if r.Source != event.Source {
+		return false
+	}
+	if len(r.DetailTypes) == 0 {
+		return true
+	}
+	for _, detailType := range r.DetailTypes {
+		if detailType == event.DetailType {
+			return true
+		}
+	}
+	return false
+}
+
+// Collectors returns the collectors the rules trigger for an event, each once
+func Collectors(rules []Rule, event Event) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if !rule.Matches(event) {
+			continue
+		}
+		for _, name := range rule.Collectors {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// snsNotification is the envelope of a message delivered to the queue through
+// an SNS topic without raw message delivery
+type snsNotification struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+// ParseEvent parses the body of a queue message. The event is either the
+// whole body, when the queue is the rule's target, or the message of an SNS
+// notification, when the rule publishes to a topic the queue subscribes to.
+func ParseEvent(body string) (Event, error) {
+	var notification snsNotification
+	if err := json.Unmarshal([]byte(body), &notification); err == nil && notification.Type == "Notification" {
+		body = notification.Message
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		return Event{}, fmt.Errorf("invalid event: %w", err)
+	}
+	if event.Source == "" || event.DetailType == "" {
+		return Event{}, fmt.Errorf("invalid event: missing source or detail-type")
+	}
+	return event, nil
+}
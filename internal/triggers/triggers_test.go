@@ -0,0 +1,95 @@
+package triggers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const instanceLaunched = `{
+  "version": "0",
+  "id": "7bf73129-1428-4cd3-a780-95db273d1602",
+  "detail-type": "EC2 Instance State-change Notification",
+  "source": "aws.ec2",
+  "account": "123456789012",
+  "time": "2026-10-16T12:00:00Z",
+  "region": "eu-west-1",
+  "resources": ["arn:aws:ec2:eu-west-1:123456789012:instance/i-0123456789abcdef0"],
+  "detail": {"instance-id": "i-0123456789abcdef0", "state": "running"}
+}`
+
+func TestParseEvent(t *testing.T) {
+	event, err := ParseEvent(instanceLaunched)
+	if err != nil {
+		t.Fatalf("Failed to parse event: %v", err)
+	}
+	if event.Source != "aws.ec2" || event.DetailType != "EC2 Instance State-change Notification" {
+		t.Errorf("Expected an EC2 state change, got %s %q", event.Source, event.DetailType)
+	}
+	if event.Region != "eu-west-1" || event.Account != "123456789012" {
+		t.Errorf("Expected region eu-west-1 of account 123456789012, got %s %s", event.Region, event.Account)
+	}
+	if len(event.Resources) != 1 {
+		t.Errorf("Expected 1 resource, got %d", len(event.Resources))
+	}
+
+	// Events published to a topic the queue subscribes to arrive wrapped
+	notification, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": instanceLaunched})
+	wrapped, err := ParseEvent(string(notification))
+	if err != nil {
+		t.Fatalf("Failed to parse SNS notification: %v", err)
+	}
+	if wrapped.ID != event.ID {
+		t.Errorf("Expected event %s from the notification, got %s", event.ID, wrapped.ID)
+	}
+
+	for _, body := range []string{"", "not json", `{"source": "aws.ec2"}`} {
+		if _, err := ParseEvent(body); err == nil {
+			t.Errorf("Expected error parsing %q", body)
+		}
+	}
+}
+
+func TestCollectors(t *testing.T) {
+	rules := append(DefaultRules(),
+		Rule{Source: "aws.ec2", Collectors: []string{"ec2", "ebs"}},
+		Rule{Source: "aws.health", Collectors: []string{"health"}})
+
+	tests := []struct {
+		name     string
+		event    Event
+		expected []string
+	}{
+		{
+			name:     "instance state change",
+			event:    Event{Source: "aws.ec2", DetailType: "EC2 Instance State-change Notification"},
+			expected: []string{"ec2", "ebs"},
+		},
+		{
+			name:     "source without detail types",
+			event:    Event{Source: "aws.ec2", DetailType: "EBS Volume Notification"},
+			expected: []string{"ec2", "ebs"},
+		},
+		{
+			name:     "rds failover",
+			event:    Event{Source: "aws.rds", DetailType: "RDS DB Cluster Event"},
+			expected: []string{"rds"},
+		},
+		{
+			name:  "other rds detail type",
+			event: Event{Source: "aws.rds", DetailType: "RDS DB Snapshot Event"},
+		},
+		{
+			name:  "unknown source",
+			event: Event{Source: "aws.s3", DetailType: "Object Created"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Collectors(rules, tt.event); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected collectors %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
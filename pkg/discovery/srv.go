@@ -0,0 +1,278 @@
+// Package discovery finds the gRPC endpoints of a service through DNS SRV
+// records. An srv:// endpoint, such as srv://_otlp._tcp.collector.internal,
+// connects to every target of the record with the lowest priority, balancing
+// calls across them round robin, and the record is resolved again
+// periodically so targets can be added and removed without a restart.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the URL scheme of endpoints discovered through SRV records
+const Scheme = "srv"
+
+// DefaultRefreshInterval is how often the SRV record is resolved again when
+// no interval is given
+const DefaultRefreshInterval = 30 * time.Second
+
+// minResolveInterval limits how often gRPC can ask for the record to be
+// resolved again, e.g. when connections to targets keep failing
+const minResolveInterval = 5 * time.Second
+
+// roundRobin is the service config balancing calls across the targets
+const roundRobin = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// lookupFunc looks up the SRV records of a name
+type lookupFunc func(ctx context.Context, name string) ([]*net.SRV, error)
+
+// IsSRV reports whether endpoint is discovered through SRV records
+func IsSRV(endpoint string) bool {
+	return strings.HasPrefix(endpoint, Scheme+"://")
+}
+
+// Target is how a gRPC client reaches the collectors of an endpoint
+type Target struct {
+	// Address is the gRPC target: the host:port of an http:// or https://
+	// endpoint, or srv:///<record> for an srv:// endpoint
+	Address string
+	// Plaintext is set for http:// endpoints, which have no TLS
+	Plaintext bool
+	// DialOptions resolve and balance the targets of an srv:// endpoint
+	DialOptions []grpc.DialOption
+}
+
+// Resolve returns how to reach the collectors of an endpoint URL. An srv://
+// endpoint connects to every target of its SRV record, resolved again every
+// refresh, balancing calls across them round robin.
+func Resolve(endpoint string, refresh time.Duration) (Target, error) {
+	if IsSRV(endpoint) {
+		address, opts, err := dial(endpoint, refresh)
+		if err != nil {
+			return Target{}, err
+		}
+		return Target{Address: address, DialOptions: opts}, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return Target{}, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Host == "" {
+		return Target{}, fmt.Errorf("endpoint %q has no host", endpoint)
+	}
+	return Target{Address: u.Host, Plaintext: u.Scheme == "http"}, nil
+}
+
+// dial returns the gRPC target and dial options connecting to the targets of
+// the SRV record named by an srv:// endpoint, resolved again every refresh.
+// TLS certificates are verified against the domain of the record, the name
+// without its _service._proto labels, e.g. collector.internal.
+func dial(endpoint string, refresh time.Duration) (string, []grpc.DialOption, error) {
+	name, err := RecordName(endpoint)
+	if err != nil {
+		return "", nil, err
+	}
+	if refresh <= 0 {
+		refresh = DefaultRefreshInterval
+	}
+
+	builder := &srvBuilder{refresh: refresh, lookup: lookupSRV}
+	opts := []grpc.DialOption{
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(roundRobin),
+		grpc.WithAuthority(recordDomain(name)),
+	}
+	return Scheme + ":///" + name, opts, nil
+}
+
+// RecordName returns the SRV record name of an srv:// endpoint
+func RecordName(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != Scheme {
+		return "", fmt.Errorf("endpoint %q is not an %s:// endpoint", endpoint, Scheme)
+	}
+	if u.Host == "" || u.Port() != "" || (u.Path != "" && u.Path != "/") {
+		return "", fmt.Errorf("endpoint %q must be %s:// followed by a record name only, e.g. %s://_otlp._tcp.collector.internal", endpoint, Scheme, Scheme)
+	}
+	return u.Host, nil
+}
+
+// recordDomain returns the domain of an SRV record name, without its leading
+// _service and _proto labels
+func recordDomain(name string) string {
+	labels := strings.Split(name, ".")
+	for len(labels) > 1 && strings.HasPrefix(labels[0], "_") {
+		labels = labels[1:]
+	}
+	return strings.Join(labels, ".")
+}
+
+// Lookup resolves the SRV record of an srv:// endpoint and returns the
+// host:port addresses gRPC connects to
+func Lookup(ctx context.Context, endpoint string) ([]string, error) {
+	name, err := RecordName(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	records, err := lookupSRV(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	addrs := addresses(records)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("SRV record %s has no targets", name)
+	}
+	targets := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		targets = append(targets, addr.Addr)
+	}
+	return targets, nil
+}
+
+func lookupSRV(ctx context.Context, name string) ([]*net.SRV, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	return records, err
+}
+
+// addresses returns the addresses of the targets with the lowest priority;
+// targets with a higher priority are fallbacks, used only once the record no
+// longer lists the lower ones
+func addresses(records []*net.SRV) []resolver.Address {
+	if len(records) == 0 {
+		return nil
+	}
+	sorted := append([]*net.SRV(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority < sorted[j].Priority })
+
+	var addrs []resolver.Address
+	for _, record := range sorted {
+		if record.Priority != sorted[0].Priority {
+			break
+		}
+		host := strings.TrimSuffix(record.Target, ".")
+		// A target of "." means the service is not available at this name
+		if host == "" {
+			continue
+		}
+		addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(host, strconv.Itoa(int(record.Port)))})
+	}
+	return addrs
+}
+
+// srvBuilder builds the resolvers of srv:/// targets
+type srvBuilder struct {
+	refresh time.Duration
+	lookup  lookupFunc
+}
+
+// Scheme returns the scheme of the targets the builder resolves
+func (b *srvBuilder) Scheme() string {
+	return Scheme
+}
+
+// Build starts resolving the SRV record of target for cc
+func (b *srvBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &srvResolver{
+		name:       target.Endpoint(),
+		cc:         cc,
+		lookup:     b.lookup,
+		refresh:    b.refresh,
+		resolveNow: make(chan struct{}, 1),
+		cancel:     cancel,
+	}
+	r.wg.Add(1)
+	go r.watch(ctx)
+	return r, nil
+}
+
+// srvResolver resolves an SRV record every refresh interval and when gRPC
+// asks, keeping the last targets found when a lookup fails
+type srvResolver struct {
+	name       string
+	cc         resolver.ClientConn
+	lookup     lookupFunc
+	refresh    time.Duration
+	resolveNow chan struct{}
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// ResolveNow asks for the record to be resolved again
+func (r *srvResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops resolving the record
+func (r *srvResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *srvResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+
+	resolved := false
+	for {
+		lookupCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		records, err := r.lookup(lookupCtx, r.name)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		last := time.Now()
+
+		addrs := addresses(records)
+		switch {
+		case err == nil && len(addrs) > 0:
+			_ = r.cc.UpdateState(resolver.State{Addresses: addrs})
+			resolved = true
+		case !resolved:
+			// Without targets yet, report the failure so calls fail fast
+			// instead of waiting for the next resolution
+			if err == nil {
+				err = fmt.Errorf("SRV record %s has no targets", r.name)
+			}
+			r.cc.ReportError(fmt.Errorf("failed to resolve %s: %w", r.name, err))
+		}
+
+		next := time.NewTimer(r.refresh)
+		select {
+		case <-ctx.Done():
+			next.Stop()
+			return
+		case <-next.C:
+		case <-r.resolveNow:
+			next.Stop()
+		}
+
+		// Resolve again on request no more often than minResolveInterval
+		if wait := min(minResolveInterval, r.refresh) - time.Since(last); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
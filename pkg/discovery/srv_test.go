@@ -0,0 +1,250 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestDial(t *testing.T) {
+	target, opts, err := dial("srv://_otlp._tcp.collector.internal", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if target != "srv:///_otlp._tcp.collector.internal" {
+		t.Errorf("Expected target srv:///_otlp._tcp.collector.internal, got %s", target)
+	}
+	if len(opts) != 3 {
+		t.Errorf("Expected resolver, balancer and authority options, got %d", len(opts))
+	}
+
+	for _, endpoint := range []string{
+		"http://collector:4317",
+		"srv://",
+		"srv://_otlp._tcp.collector.internal:4317",
+		"srv://_otlp._tcp.collector.internal/v1/traces",
+	} {
+		if _, _, err := dial(endpoint, time.Minute); err == nil {
+			t.Errorf("Expected error for endpoint %s", endpoint)
+		}
+	}
+
+	if !IsSRV("srv://_otlp._tcp.collector.internal") || IsSRV("http://collector:4317") {
+		t.Error("Expected only srv:// endpoints to be SRV endpoints")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		endpoint  string
+		address   string
+		plaintext bool
+		dialOpts  bool
+	}{
+		{endpoint: "http://collector:4317", address: "collector:4317", plaintext: true},
+		{endpoint: "https://collector.example.com:4317", address: "collector.example.com:4317"},
+		{endpoint: "srv://_otlp._tcp.collector.internal", address: "srv:///_otlp._tcp.collector.internal", dialOpts: true},
+	}
+	for _, tt := range tests {
+		target, err := Resolve(tt.endpoint, time.Minute)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", tt.endpoint, err)
+			continue
+		}
+		if target.Address != tt.address || target.Plaintext != tt.plaintext || (len(target.DialOptions) > 0) != tt.dialOpts {
+			t.Errorf("Expected %s to resolve to %s (plaintext %v), got %+v", tt.endpoint, tt.address, tt.plaintext, target)
+		}
+	}
+
+	for _, endpoint := range []string{"collector:4317", "srv://_otlp._tcp.collector.internal:4317"} {
+		if _, err := Resolve(endpoint, time.Minute); err == nil {
+			t.Errorf("Expected error for endpoint %s", endpoint)
+		}
+	}
+}
+
+func TestRecordDomain(t *testing.T) {
+	tests := map[string]string{
+		"_otlp._tcp.collector.internal": "collector.internal",
+		"collector.internal":            "collector.internal",
+		"_otlp":                         "_otlp",
+	}
+	for name, expected := range tests {
+		if domain := recordDomain(name); domain != expected {
+			t.Errorf("Expected domain %s of %s, got %s", expected, name, domain)
+		}
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	addrs := addresses([]*net.SRV{
+		{Target: "backup.collector.internal.", Port: 4317, Priority: 20},
+		{Target: "a.collector.internal.", Port: 4317, Priority: 10},
+		{Target: "b.collector.internal.", Port: 4318, Priority: 10},
+	})
+	if len(addrs) != 2 || addrs[0].Addr != "a.collector.internal:4317" || addrs[1].Addr != "b.collector.internal:4318" {
+		t.Errorf("Expected the two targets with the lowest priority, got %v", addrs)
+	}
+
+	if addrs := addresses([]*net.SRV{{Target: ".", Port: 0}}); len(addrs) != 0 {
+		t.Errorf("Expected no addresses for an unavailable service, got %v", addrs)
+	}
+}
+
+// fakeClientConn records the states and errors reported by a resolver
+type fakeClientConn struct {
+	resolver.ClientConn
+
+	mu     sync.Mutex
+	states []resolver.State
+	errs   []error
+}
+
+func (c *fakeClientConn) UpdateState(state resolver.State) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states = append(c.states, state)
+	return nil
+}
+
+func (c *fakeClientConn) ReportError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+func (c *fakeClientConn) lastAddrs() ([]resolver.Address, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.states) == 0 {
+		return nil, len(c.errs)
+	}
+	return c.states[len(c.states)-1].Addresses, len(c.errs)
+}
+
+func TestResolverRefresh(t *testing.T) {
+	var mu sync.Mutex
+	var records []*net.SRV
+	var lookupErr error = fmt.Errorf("no such host")
+	setRecords := func(srvs []*net.SRV, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		records, lookupErr = srvs, err
+	}
+
+	builder := &srvBuilder{
+		refresh: 10 * time.Millisecond,
+		lookup: func(_ context.Context, name string) ([]*net.SRV, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if name != "_otlp._tcp.collector.internal" {
+				return nil, fmt.Errorf("unexpected name %s", name)
+			}
+			return records, lookupErr
+		},
+	}
+	cc := &fakeClientConn{}
+	target := resolver.Target{}
+	target.URL.Scheme = Scheme
+	target.URL.Path = "/_otlp._tcp.collector.internal"
+	r, err := builder.Build(target, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Failed to build resolver: %v", err)
+	}
+	defer r.Close()
+
+	waitFor := func(description string, done func(addrs []resolver.Address, errs int) bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if done(cc.lastAddrs()) {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		addrs, errs := cc.lastAddrs()
+		t.Fatalf("Expected %s, got addresses %v and %d errors", description, addrs, errs)
+	}
+
+	// Failures are reported until targets are found
+	waitFor("an error", func(_ []resolver.Address, errs int) bool { return errs > 0 })
+
+	setRecords([]*net.SRV{{Target: "a.collector.internal.", Port: 4317}}, nil)
+	waitFor("target a", func(addrs []resolver.Address, _ int) bool {
+		return len(addrs) == 1 && addrs[0].Addr == "a.collector.internal:4317"
+	})
+
+	// A new target is picked up at the next refresh
+	setRecords([]*net.SRV{{Target: "a.collector.internal.", Port: 4317}, {Target: "b.collector.internal.", Port: 4317}}, nil)
+	waitFor("targets a and b", func(addrs []resolver.Address, _ int) bool { return len(addrs) == 2 })
+
+	// Failed lookups keep the last targets without reporting errors
+	_, errs := cc.lastAddrs()
+	setRecords(nil, fmt.Errorf("timeout"))
+	time.Sleep(50 * time.Millisecond)
+	if addrs, after := cc.lastAddrs(); len(addrs) != 2 || after != errs {
+		t.Errorf("Expected the last targets to be kept silently, got %v and %d new errors", addrs, after-errs)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	var calls [2]atomic.Int64
+	var records []*net.SRV
+	for i := range calls {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		counter := &calls[i]
+		server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			counter.Add(1)
+			return handler(ctx, req)
+		}))
+		healthpb.RegisterHealthServer(server, grpchealth.NewServer())
+		go func() { _ = server.Serve(listener) }()
+		defer server.Stop()
+
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		portNumber, _ := strconv.Atoi(port)
+		records = append(records, &net.SRV{Target: "127.0.0.1.", Port: uint16(portNumber)})
+	}
+
+	builder := &srvBuilder{
+		refresh: time.Minute,
+		lookup: func(context.Context, string) ([]*net.SRV, error) {
+			return records, nil
+		},
+	}
+	conn, err := grpc.NewClient("srv:///_otlp._tcp.collector.internal",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(roundRobin))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := healthpb.NewHealthClient(conn)
+	for i := 0; i < 10; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+			t.Fatalf("Health check failed: %v", err)
+		}
+	}
+
+	if calls[0].Load() == 0 || calls[1].Load() == 0 {
+		t.Errorf("Expected calls on both targets, got %d and %d", calls[0].Load(), calls[1].Load())
+	}
+}
@@ -0,0 +1,215 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// AWS error codes grouped by how they should be handled
+var (
+	throttlingCodes = map[string]bool{
+		"Throttling":                             true,
+		"ThrottlingException":                    true,
+		"ThrottledException":                     true,
+		"RequestThrottled":                       true,
+		"RequestThrottledException":              true,
+		"TooManyRequestsException":               true,
+		"RequestLimitExceeded":                   true,
+		"ProvisionedThroughputExceededException": true,
+		"BandwidthLimitExceeded":                 true,
+		"SlowDown":                               true,
+		"EC2ThrottledException":                  true,
+		"PriorRequestNotComplete":                true,
+	}
+
+	permissionCodes = map[string]bool{
+		"AccessDenied":                true,
+		"AccessDeniedException":       true,
+		"UnauthorizedOperation":       true,
+		"UnauthorizedAccess":          true,
+		"UnauthorizedException":       true,
+		"AuthFailure":                 true,
+		"Forbidden":                   true,
+		"InvalidClientTokenId":        true,
+		"UnrecognizedClientException": true,
+		"SignatureDoesNotMatch":       true,
+		"ExpiredToken":                true,
+		"ExpiredTokenException":       true,
+		"OptInRequired":               true,
+	}
+
+	serverCodes = map[string]bool{
+		"InternalError":               true,
+		"InternalFailure":             true,
+		"InternalServerError":         true,
+		"InternalServiceError":        true,
+		"ServiceUnavailable":          true,
+		"ServiceUnavailableException": true,
+		"Unavailable":                 true,
+		"RequestTimeout":              true,
+		"RequestTimeoutException":     true,
+	}
+)
+
+// ClassifyAWSError converts an error returned by the AWS SDK into a typed
+// Error by inspecting the smithy API error code, the HTTP status code and the
+// underlying network error, rather than matching on error messages:
+//
+//   - throttling codes and HTTP 429 become ErrorTypeRateLimit
+//   - access denied and credential codes and HTTP 401/403 become ErrorTypePermission
+//   - other client faults and HTTP 4xx become ErrorTypeValidation
+//   - server faults and HTTP 5xx become retryable ErrorTypeAWS
+//   - connection failures become ErrorTypeNetwork and deadlines ErrorTypeTimeout
+//
+// The service, operation, request ID and HTTP status are recorded on the
+// result, and RetryAfter is taken from the Retry-After header when the service
+// sends one (e.g. with throttling or 503 Slow Down responses). Errors that are
+// already an *Error are returned unchanged; nil is returned when err carries
+// no AWS or network error information.
+func ClassifyAWSError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var existing *Error
+	if errors.As(err, &existing) {
+		return existing
+	}
+
+	var classified *Error
+	var apiErr smithy.APIError
+	statusCode := httpStatusCode(err)
+
+	switch {
+	case errors.As(err, &apiErr):
+		classified = classifyAPIError(err, apiErr, statusCode)
+	case statusCode != 0:
+		classified = classifyHTTPStatus(err, statusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		classified = WithRetryable(Wrap(err, ErrorTypeTimeout, CodeTimeout, err.Error()), true)
+	case errors.Is(err, context.Canceled):
+		classified = Wrap(err, ErrorTypeInternal, CodeContextCancelled, err.Error())
+	case isNetworkError(err):
+		classified = WithRetryable(Wrap(err, ErrorTypeNetwork, CodeNetworkError, err.Error()), true)
+	default:
+		return nil
+	}
+
+	var opErr *smithy.OperationError
+	if errors.As(err, &opErr) {
+		classified = WithOperation(WithService(classified, opErr.Service()), opErr.Operation())
+	}
+
+	var requestIDErr interface{ ServiceRequestID() string }
+	if errors.As(err, &requestIDErr) && requestIDErr.ServiceRequestID() != "" {
+		classified.WithMetadata("request_id", requestIDErr.ServiceRequestID())
+	}
+	if statusCode != 0 {
+		classified.WithMetadata("http_status", statusCode)
+	}
+
+	return classified
+}
+
+// classifyAPIError classifies a modeled AWS API error by its error code,
+// falling back to the HTTP status code and the error fault
+func classifyAPIError(err error, apiErr smithy.APIError, statusCode int) *Error {
+	code := apiErr.ErrorCode()
+	message := apiErr.ErrorMessage()
+	if message == "" {
+		message = err.Error()
+	}
+
+	switch {
+	case throttlingCodes[code]:
+		return newThrottlingError(err, code, message)
+	case permissionCodes[code]:
+		return newAWSPermissionError(err, code, message)
+	case serverCodes[code]:
+		return WithRetryable(Wrap(err, ErrorTypeAWS, code, message), true)
+	case statusCode != 0:
+		classified := classifyHTTPStatus(err, statusCode)
+		classified.Code = code
+		classified.Message = message
+		return classified
+	case apiErr.ErrorFault() == smithy.FaultClient:
+		return Wrap(err, ErrorTypeValidation, code, message)
+	case apiErr.ErrorFault() == smithy.FaultServer:
+		return WithRetryable(Wrap(err, ErrorTypeAWS, code, message), true)
+	default:
+		return Wrap(err, ErrorTypeAWS, code, message)
+	}
+}
+
+// classifyHTTPStatus classifies an error by the HTTP status code of the response
+func classifyHTTPStatus(err error, statusCode int) *Error {
+	code := fmt.Sprintf("HTTP_%d", statusCode)
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return newThrottlingError(err, code, err.Error())
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return newAWSPermissionError(err, code, err.Error())
+	case statusCode >= 400 && statusCode < 500:
+		return Wrap(err, ErrorTypeValidation, code, err.Error())
+	default:
+		return WithRetryable(Wrap(err, ErrorTypeAWS, code, err.Error()), statusCode >= 500)
+	}
+}
+
+// newThrottlingError creates a retryable rate limit error
+func newThrottlingError(err error, code, message string) *Error {
+	return WithRetryable(Wrap(err, ErrorTypeRateLimit, code, message), true)
+}
+
+// newAWSPermissionError creates a high severity permission error
+func newAWSPermissionError(err error, code, message string) *Error {
+	return WithSeverity(Wrap(err, ErrorTypePermission, code, message), SeverityHigh)
+}
+
+// httpStatusCode returns the HTTP status code of the response that caused err, or 0
+func httpStatusCode(err error) int {
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatusCode()
+	}
+	return 0
+}
+
+// retryAfterHeader returns the delay from the Retry-After header of the
+// response that caused err, in either delay-seconds or HTTP-date form
+func retryAfterHeader(err error) (time.Duration, bool) {
+	var respErr *smithyhttp.ResponseError
+	if !errors.As(err, &respErr) || respErr.Response == nil || respErr.Response.Response == nil {
+		return 0, false
+	}
+
+	value := respErr.Response.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// isNetworkError reports whether err was caused by a failed network connection
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,169 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// sdkError builds an error shaped like the ones returned by AWS SDK operations
+func sdkError(statusCode int, header http.Header, apiErr error) error {
+	return &smithy.OperationError{
+		ServiceID:     "EC2",
+		OperationName: "DescribeInstances",
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: statusCode, Header: header}},
+				Err:      apiErr,
+			},
+			RequestID: "req-123",
+		},
+	}
+}
+
+func TestClassifyAWSError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		errType   ErrorType
+		code      string
+		retryable bool
+	}{
+		{
+			name:      "throttling code",
+			err:       sdkError(400, nil, &smithy.GenericAPIError{Code: "RequestLimitExceeded", Message: "Request limit exceeded."}),
+			errType:   ErrorTypeRateLimit,
+			code:      "RequestLimitExceeded",
+			retryable: true,
+		},
+		{
+			name:    "permission code",
+			err:     sdkError(403, nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "You are not authorized"}),
+			errType: ErrorTypePermission,
+			code:    "UnauthorizedOperation",
+		},
+		{
+			name:    "client fault by status",
+			err:     sdkError(400, nil, &smithy.GenericAPIError{Code: "InvalidParameterValue", Message: "bad filter"}),
+			errType: ErrorTypeValidation,
+			code:    "InvalidParameterValue",
+		},
+		{
+			name:      "server fault by status",
+			err:       sdkError(503, nil, &smithy.GenericAPIError{Code: "SomethingWentWrong", Message: "oops"}),
+			errType:   ErrorTypeAWS,
+			code:      "SomethingWentWrong",
+			retryable: true,
+		},
+		{
+			name:      "HTTP 429 without API error",
+			err:       sdkError(429, nil, fmt.Errorf("too many requests")),
+			errType:   ErrorTypeRateLimit,
+			code:      "HTTP_429",
+			retryable: true,
+		},
+		{
+			name:      "network error",
+			err:       &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")},
+			errType:   ErrorTypeNetwork,
+			code:      "NETWORK_ERROR",
+			retryable: true,
+		},
+		{
+			name:      "deadline exceeded",
+			err:       fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			errType:   ErrorTypeTimeout,
+			code:      "TIMEOUT",
+			retryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classified := ClassifyAWSError(tt.err)
+			if classified == nil {
+				t.Fatal("Expected error to be classified")
+			}
+			if classified.Type != tt.errType {
+				t.Errorf("Expected type %s, got %s", tt.errType, classified.Type)
+			}
+			if classified.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, classified.Code)
+			}
+			if classified.Retryable != tt.retryable {
+				t.Errorf("Expected retryable %v, got %v", tt.retryable, classified.Retryable)
+			}
+		})
+	}
+}
+
+func TestClassifyAWSErrorContext(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"7"}}
+	classified := ClassifyAWSError(sdkError(400, header, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}))
+
+	if classified.RetryAfter == nil || *classified.RetryAfter != 7*time.Second {
+		t.Errorf("Expected RetryAfter of 7s, got %v", classified.RetryAfter)
+	}
+	if classified.Service != "EC2" || classified.Operation != "DescribeInstances" {
+		t.Errorf("Expected service and operation from the SDK error, got %s/%s", classified.Service, classified.Operation)
+	}
+	if classified.Metadata["request_id"] != "req-123" || classified.Metadata["http_status"] != 400 {
+		t.Errorf("Unexpected metadata: %v", classified.Metadata)
+	}
+	if classified.Message != "Rate exceeded" {
+		t.Errorf("Expected API error message, got %q", classified.Message)
+	}
+
+	permission := ClassifyAWSError(sdkError(403, nil, &smithy.GenericAPIError{Code: "AccessDeniedException"}))
+	if permission.Severity != SeverityHigh {
+		t.Errorf("Expected high severity for permission errors, got %s", permission.Severity)
+	}
+}
+
+func TestRetryAfterFromResponse(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"3"}}
+
+	// Server errors such as S3 Slow Down carry Retry-After too
+	classified := ClassifyAWSError(sdkError(503, header, &smithy.GenericAPIError{Code: "ServiceUnavailable"}))
+	if classified.RetryAfter == nil || *classified.RetryAfter != 3*time.Second {
+		t.Errorf("Expected RetryAfter of 3s for a 503 response, got %v", classified.RetryAfter)
+	}
+
+	// Collectors that wrap SDK errors themselves keep the suggested delay
+	wrapped := Wrap(sdkError(429, header, nil), ErrorTypeAWS, "DESCRIBE_FAILED", "describe failed")
+	if wrapped.RetryAfter == nil || *wrapped.RetryAfter != 3*time.Second {
+		t.Errorf("Expected RetryAfter of 3s on wrapped SDK error, got %v", wrapped.RetryAfter)
+	}
+
+	date := http.Header{"Retry-After": []string{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}
+	dated := ClassifyAWSError(sdkError(429, date, nil))
+	if dated.RetryAfter == nil || *dated.RetryAfter <= 58*time.Second || *dated.RetryAfter > time.Minute {
+		t.Errorf("Expected RetryAfter of about 1m from an HTTP date, got %v", dated.RetryAfter)
+	}
+
+	if plain := Wrap(sdkError(429, nil, nil), ErrorTypeAWS, "DESCRIBE_FAILED", "describe failed"); plain.RetryAfter != nil {
+		t.Errorf("Expected no RetryAfter without the header, got %v", plain.RetryAfter)
+	}
+}
+
+func TestClassifyAWSErrorPassthrough(t *testing.T) {
+	if ClassifyAWSError(nil) != nil {
+		t.Error("Expected nil for nil error")
+	}
+
+	if ClassifyAWSError(fmt.Errorf("plain error")) != nil {
+		t.Error("Expected nil for an error without AWS information")
+	}
+
+	existing := NewValidationError("INVALID", "already classified")
+	if ClassifyAWSError(fmt.Errorf("wrapped: %w", existing)) != existing {
+		t.Error("Expected an existing *Error to be returned unchanged")
+	}
+}
@@ -0,0 +1,312 @@
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Error codes used by aws-monitor. Errors classified from AWS SDK responses
+// by ClassifyAWSError carry the AWS API error code (e.g. "AccessDenied") or
+// "HTTP_<status>" instead and are not part of the catalog.
+const (
+	// General errors
+	CodeAccessDenied     = "ACCESS_DENIED"
+	CodeRateLimit        = "RATE_LIMIT"
+	CodeTimeout          = "TIMEOUT"
+	CodeContextCancelled = "CONTEXT_CANCELLED"
+	CodeNetworkError     = "NETWORK_ERROR"
+	CodeTemporaryFailure = "TEMPORARY_FAILURE"
+	CodeWrapped          = "WRAPPED"
+
+	// Collector errors
+	CodeCollectionError    = "COLLECTION_ERROR"
+	CodeAllRegionsFailed   = "ALL_REGIONS_FAILED"
+	CodeBudgetPhaseTimeout = "BUDGET_PHASE_TIMEOUT"
+	CodeCircuitOpen        = "CIRCUIT_OPEN"
+	CodeCollectorNotFound  = "COLLECTOR_NOT_FOUND"
+	CodeCollectorNotReady  = "COLLECTOR_NOT_READY"
+	CodeCollectorStopped   = "COLLECTOR_STOPPED"
+	CodeHighErrorRate      = "HIGH_ERROR_RATE"
+	CodeSLOBurnRateHigh    = "SLO_BURN_RATE_HIGH"
+	CodeEnrichmentFailed   = "ENRICHMENT_FAILED"
+	CodeExecFailed         = "EXEC_FAILED"
+	CodeExecOutputInvalid  = "EXEC_OUTPUT_INVALID"
+
+	// Collector configuration errors
+	CodeInvalidInterval = "INVALID_INTERVAL"
+	CodeInvalidTimeout  = "INVALID_TIMEOUT"
+	CodeInvalidRetries  = "INVALID_RETRIES"
+	CodeNoRegions       = "NO_REGIONS"
+
+	// Scheduler errors
+	CodeInvalidTickInterval      = "INVALID_TICK_INTERVAL"
+	CodeInvalidMaxConcurrentJobs = "INVALID_MAX_CONCURRENT_JOBS"
+	CodeInvalidJobTimeout        = "INVALID_JOB_TIMEOUT"
+	CodeJobNotFound              = "JOB_NOT_FOUND"
+	CodeSchedulerError           = "SCHEDULER_ERROR"
+	CodeSchedulerStopped         = "SCHEDULER_STOPPED"
+	CodeSchedulerNotReady        = "SCHEDULER_NOT_READY"
+	CodeSchedulerNotTicking      = "SCHEDULER_NOT_TICKING"
+)
+
+// CodeInfo describes an error code so operators can look up what it means and
+// how to fix it
+type CodeInfo struct {
+	// Code is the error code
+	Code string `json:"code"`
+	// Type is the category errors with this code usually have
+	Type ErrorType `json:"type"`
+	// Retryable indicates errors with this code are usually retried
+	Retryable bool `json:"retryable"`
+	// Description explains what the error means
+	Description string `json:"description"`
+	// Remediation suggests how to resolve the error
+	Remediation string `json:"remediation"`
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = make(map[string]CodeInfo)
+)
+
+func init() {
+	for _, info := range builtinCodes {
+		catalog[info.Code] = info
+	}
+}
+
+// builtinCodes describes the error codes used by aws-monitor itself
+var builtinCodes = []CodeInfo{
+	{
+		Code:        CodeAccessDenied,
+		Type:        ErrorTypePermission,
+		Description: "The AWS credentials are not allowed to perform an operation the collector needs.",
+		Remediation: "Grant the IAM permission named in the error to the monitoring role, e.g. ec2:DescribeInstances.",
+	},
+	{
+		Code:        CodeRateLimit,
+		Type:        ErrorTypeRateLimit,
+		Retryable:   true,
+		Description: "An AWS API throttled the request.",
+		Remediation: "Retries back off automatically; if it persists, increase collection intervals, lower max_concurrent_regions or request a service quota increase.",
+	},
+	{
+		Code:        CodeTimeout,
+		Type:        ErrorTypeTimeout,
+		Retryable:   true,
+		Description: "An operation did not complete before its deadline.",
+		Remediation: "Check network connectivity to the AWS endpoints and raise the collector timeout if the account has many resources.",
+	},
+	{
+		Code:        CodeContextCancelled,
+		Type:        ErrorTypeInternal,
+		Description: "An operation was cancelled, usually because the application is shutting down.",
+		Remediation: "No action is needed during shutdown; otherwise check for a parent timeout that is shorter than the collection timeout.",
+	},
+	{
+		Code:        CodeNetworkError,
+		Type:        ErrorTypeNetwork,
+		Retryable:   true,
+		Description: "A connection to an AWS endpoint failed.",
+		Remediation: "Check DNS, proxies, VPC endpoints and security groups between aws-monitor and the AWS APIs.",
+	},
+	{
+		Code:        CodeTemporaryFailure,
+		Type:        ErrorTypeInternal,
+		Retryable:   true,
+		Description: "A transient failure occurred that is expected to succeed on retry.",
+		Remediation: "Retries happen automatically; investigate only if the error keeps recurring.",
+	},
+	{
+		Code:        CodeWrapped,
+		Type:        ErrorTypeInternal,
+		Description: "A plain error was added to a multi-error without more specific context.",
+		Remediation: "Inspect the cause of the error for details.",
+	},
+	{
+		Code:        CodeCollectionError,
+		Type:        ErrorTypeInternal,
+		Description: "A collector failed with an error that could not be classified.",
+		Remediation: "Inspect the cause in the logs; it usually names the AWS operation that failed.",
+	},
+	{
+		Code:        CodeAllRegionsFailed,
+		Type:        ErrorTypeInternal,
+		Description: "A collector failed in every enabled region.",
+		Remediation: "Look at the per-region errors; a common cause is missing permissions or credentials that are not valid in any region.",
+	},
+	{
+		Code:        CodeBudgetPhaseTimeout,
+		Type:        ErrorTypeTimeout,
+		Description: "A collection phase ran out of its share of the timeout and partial results were returned.",
+		Remediation: "Raise the collector timeout or reduce the number of resources collected, e.g. with max_resources.",
+	},
+	{
+		Code:        CodeCircuitOpen,
+		Type:        ErrorTypeInternal,
+		Description: "Collection was skipped because the collector's circuit breaker for the region is open after repeated failures.",
+		Remediation: "Fix the underlying errors; the breaker closes again after its timeout once collections succeed.",
+	},
+	{
+		Code:        CodeCollectorNotFound,
+		Type:        ErrorTypeValidation,
+		Description: "A job or request referred to a collector that is not registered.",
+		Remediation: "Check the collector name against GET /admin/collectors and the plugins section of the configuration.",
+	},
+	{
+		Code:        CodeCollectorNotReady,
+		Type:        ErrorTypeValidation,
+		Description: "The collector has not been started yet.",
+		Remediation: "Wait for startup to complete; if it persists, check the logs for start-up errors.",
+	},
+	{
+		Code:        CodeCollectorStopped,
+		Type:        ErrorTypeValidation,
+		Description: "The collector has been stopped.",
+		Remediation: "Restart aws-monitor or re-add the collector through the admin API.",
+	},
+	{
+		Code:        CodeHighErrorRate,
+		Type:        ErrorTypeValidation,
+		Description: "More than half of the collector's recent collections failed.",
+		Remediation: "Check the collector's recent errors and fix the most frequent one.",
+	},
+	{
+		Code:        CodeSLOBurnRateHigh,
+		Type:        ErrorTypeValidation,
+		Description: "The collector is using up its SLO error budget faster than the configured burn rate threshold.",
+		Remediation: "Check the collector's recent errors; the budget recovers as collections succeed.",
+	},
+	{
+		Code:        CodeEnrichmentFailed,
+		Type:        ErrorTypeAWS,
+		Description: "Metrics could not be enriched with resource metadata and were sent without it.",
+		Remediation: "Grant ec2:DescribeInstances and check the inventory cache errors in the logs.",
+	},
+	{
+		Code:        CodeExecFailed,
+		Type:        ErrorTypeInternal,
+		Description: "An exec collector command failed or exited with a non-zero status.",
+		Remediation: "Run the command by hand as the aws-monitor user and check its stderr in the logs.",
+	},
+	{
+		Code:        CodeExecOutputInvalid,
+		Type:        ErrorTypeValidation,
+		Description: "An exec collector command printed output that could not be parsed.",
+		Remediation: "Make the command print metrics in the configured format (json or prometheus).",
+	},
+	{
+		Code:        CodeInvalidInterval,
+		Type:        ErrorTypeConfig,
+		Description: "A collector's interval is not positive.",
+		Remediation: "Set collection_interval to a positive duration such as 5m.",
+	},
+	{
+		Code:        CodeInvalidTimeout,
+		Type:        ErrorTypeConfig,
+		Description: "A collector's timeout is not positive.",
+		Remediation: "Set the collector timeout to a positive duration.",
+	},
+	{
+		Code:        CodeInvalidRetries,
+		Type:        ErrorTypeConfig,
+		Description: "A collector's retry count is negative.",
+		Remediation: "Set retries to zero or more.",
+	},
+	{
+		Code:        CodeNoRegions,
+		Type:        ErrorTypeConfig,
+		Description: "A collector has no regions to collect from.",
+		Remediation: "Add regions to enabled_regions or to the collector's regions list.",
+	},
+	{
+		Code:        CodeInvalidTickInterval,
+		Type:        ErrorTypeConfig,
+		Description: "The scheduler tick interval is not positive.",
+		Remediation: "Set the scheduler tick interval to a positive duration.",
+	},
+	{
+		Code:        CodeInvalidMaxConcurrentJobs,
+		Type:        ErrorTypeConfig,
+		Description: "The scheduler's maximum number of concurrent jobs is not positive.",
+		Remediation: "Set max_concurrent_workers to at least 1.",
+	},
+	{
+		Code:        CodeInvalidJobTimeout,
+		Type:        ErrorTypeConfig,
+		Description: "The scheduler job timeout is not positive.",
+		Remediation: "Set worker_timeout to a positive duration.",
+	},
+	{
+		Code:        CodeJobNotFound,
+		Type:        ErrorTypeValidation,
+		Description: "A request referred to a scheduled job that does not exist.",
+		Remediation: "List scheduled jobs to find the collector and region combination that is scheduled.",
+	},
+	{
+		Code:        CodeSchedulerError,
+		Type:        ErrorTypeValidation,
+		Description: "The scheduler is in an error state.",
+		Remediation: "Check the logs for the scheduler error and restart aws-monitor.",
+	},
+	{
+		Code:        CodeSchedulerStopped,
+		Type:        ErrorTypeValidation,
+		Description: "The scheduler has been stopped and no collections are running.",
+		Remediation: "Restart aws-monitor.",
+	},
+	{
+		Code:        CodeSchedulerNotReady,
+		Type:        ErrorTypeValidation,
+		Description: "The scheduler has not started yet.",
+		Remediation: "Wait for startup to complete; if it persists, check the logs for start-up errors.",
+	},
+	{
+		Code:        CodeSchedulerNotTicking,
+		Type:        ErrorTypeValidation,
+		Description: "The scheduler has not run its scheduling loop recently.",
+		Remediation: "Check for a blocked or overloaded process and restart aws-monitor if it does not recover.",
+	},
+}
+
+// RegisterCode adds an error code to the catalog, e.g. for a collector
+// plugin. It returns an error if the code is already registered.
+func RegisterCode(info CodeInfo) error {
+	if info.Code == "" {
+		return fmt.Errorf("error code must not be empty")
+	}
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	if _, exists := catalog[info.Code]; exists {
+		return fmt.Errorf("error code %s is already registered", info.Code)
+	}
+	catalog[info.Code] = info
+	return nil
+}
+
+// LookupCode returns the catalog entry for an error code
+func LookupCode(code string) (CodeInfo, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	info, exists := catalog[code]
+	return info, exists
+}
+
+// Catalog returns every registered error code, sorted by code
+func Catalog() []CodeInfo {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	codes := make([]CodeInfo, 0, len(catalog))
+	for _, info := range catalog {
+		codes = append(codes, info)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+	return codes
+}
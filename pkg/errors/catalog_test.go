@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	codes := Catalog()
+	if len(codes) != len(builtinCodes) {
+		t.Errorf("Expected %d codes, got %d", len(builtinCodes), len(codes))
+	}
+
+	for i, info := range codes {
+		if info.Type == "" || info.Description == "" || info.Remediation == "" {
+			t.Errorf("Expected %s to have a type, description and remediation", info.Code)
+		}
+		if i > 0 && codes[i-1].Code >= info.Code {
+			t.Errorf("Expected catalog sorted by code, got %s before %s", codes[i-1].Code, info.Code)
+		}
+	}
+
+	info, exists := LookupCode(CodeRateLimit)
+	if !exists || info.Type != ErrorTypeRateLimit || !info.Retryable {
+		t.Errorf("Unexpected catalog entry for %s: %+v", CodeRateLimit, info)
+	}
+
+	if _, exists := LookupCode("AccessDenied"); exists {
+		t.Error("Expected AWS API error codes not to be in the catalog")
+	}
+}
+
+func TestRegisterCode(t *testing.T) {
+	info := CodeInfo{Code: "TEST_PLUGIN_FAILED", Type: ErrorTypeInternal, Description: "test", Remediation: "test"}
+	if err := RegisterCode(info); err != nil {
+		t.Fatalf("Expected no error registering a new code, got: %v", err)
+	}
+	defer func() {
+		catalogMu.Lock()
+		delete(catalog, info.Code)
+		catalogMu.Unlock()
+	}()
+
+	if _, exists := LookupCode(info.Code); !exists {
+		t.Error("Expected registered code to be found")
+	}
+	if err := RegisterCode(info); err == nil {
+		t.Error("Expected error registering a duplicate code")
+	}
+	if err := RegisterCode(CodeInfo{}); err == nil {
+		t.Error("Expected error registering an empty code")
+	}
+}
@@ -108,12 +108,16 @@ func (e *Error) Unwrap() error {
 	return e.Cause
 }
 
-// Is checks if this error matches the target error
+// Is checks if this error matches the target error. An *Error target matches
+// on type and code; an empty code in the target matches any code, so
+// errors.Is(err, &Error{Type: ErrorTypeRateLimit}) finds any rate limit error
+// in a chain. Other targets are matched against the cause chain by the
+// standard library through Unwrap.
 func (e *Error) Is(target error) bool {
 	if t, ok := target.(*Error); ok {
-		return e.Type == t.Type && e.Code == t.Code
+		return e.Type == t.Type && (t.Code == "" || e.Code == t.Code)
 	}
-	return errors.Is(e.Cause, target)
+	return false
 }
 
 // WithMetadata adds metadata to the error
@@ -165,7 +169,7 @@ func Wrap(err error, errorType ErrorType, code, message string) *Error {
 		return &enhanced
 	}
 	
-	return &Error{
+	wrapped := &Error{
 		Type:       errorType,
 		Code:       code,
 		Message:    message,
@@ -175,6 +179,13 @@ func Wrap(err error, errorType ErrorType, code, message string) *Error {
 		Retryable:  false,
 		StackTrace: captureStackTrace(),
 	}
+	
+	// Keep the delay suggested by the service when wrapping an SDK error
+	if retryAfter, ok := retryAfterHeader(err); ok {
+		wrapped.RetryAfter = &retryAfter
+	}
+	
+	return wrapped
 }
 
 // WithSeverity sets the severity level of the error
@@ -243,7 +254,7 @@ func NewValidationError(code, message string) *Error {
 func NewTimeoutError(operation string, timeout time.Duration) *Error {
 	return WithRetryable(
 		WithOperation(
-			New(ErrorTypeTimeout, "TIMEOUT", 
+			New(ErrorTypeTimeout, CodeTimeout, 
 				fmt.Sprintf("operation timed out after %v", timeout)),
 			operation),
 		true)
@@ -252,7 +263,7 @@ func NewTimeoutError(operation string, timeout time.Duration) *Error {
 // NewRateLimitError creates a new rate limit error
 func NewRateLimitError(retryAfter time.Duration) *Error {
 	return WithRetryable(
-		New(ErrorTypeRateLimit, "RATE_LIMIT", "rate limit exceeded").
+		New(ErrorTypeRateLimit, CodeRateLimit, "rate limit exceeded").
 			WithRetryAfter(retryAfter),
 		true)
 }
@@ -261,36 +272,58 @@ func NewRateLimitError(retryAfter time.Duration) *Error {
 func NewPermissionError(operation, resource string) *Error {
 	return WithSeverity(
 		WithOperation(
-			New(ErrorTypePermission, "ACCESS_DENIED", 
+			New(ErrorTypePermission, CodeAccessDenied, 
 				fmt.Sprintf("insufficient permissions for resource: %s", resource)),
 			operation),
 		SeverityHigh)
 }
 
-// IsRetryable checks if an error is retryable
+// IsRetryable checks if the first *Error in err's chain is retryable
 func IsRetryable(err error) bool {
-	if e, ok := err.(*Error); ok {
+	var e *Error
+	if errors.As(err, &e) {
 		return e.Retryable
 	}
 	return false
 }
 
-// IsType checks if an error is of a specific type
+// IsType checks if any *Error in err's chain is of a specific type
 func IsType(err error, errorType ErrorType) bool {
-	if e, ok := err.(*Error); ok {
-		return e.Type == errorType
-	}
-	return false
+	return errors.Is(err, &Error{Type: errorType})
 }
 
-// GetRetryAfter returns the retry delay if the error is a rate limit error
+// GetRetryAfter returns the retry delay of the first *Error in err's chain
+// that has one
 func GetRetryAfter(err error) *time.Duration {
-	if e, ok := err.(*Error); ok && e.RetryAfter != nil {
+	if e := find(err, func(e *Error) bool { return e.RetryAfter != nil }); e != nil {
 		return e.RetryAfter
 	}
 	return nil
 }
 
+// find walks err's chain depth-first, like errors.As, and returns the first
+// *Error that satisfies match
+func find(err error, match func(*Error) bool) *Error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*Error); ok && match(e) {
+		return e
+	}
+
+	switch unwrapper := err.(type) {
+	case interface{ Unwrap() error }:
+		return find(unwrapper.Unwrap(), match)
+	case interface{ Unwrap() []error }:
+		for _, inner := range unwrapper.Unwrap() {
+			if e := find(inner, match); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
 // captureStackTrace captures the current stack trace
 func captureStackTrace() []string {
 	const maxDepth = 10
@@ -338,16 +371,34 @@ func (m *MultiError) Error() string {
 	return fmt.Sprintf("multiple errors occurred: %s", strings.Join(messages, "; "))
 }
 
-// Add adds an error to the MultiError
+// Unwrap returns the collected errors so errors.Is and errors.As match
+// against each of them
+func (m *MultiError) Unwrap() []error {
+	errs := make([]error, len(m.Errors))
+	for i, err := range m.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Add adds an error to the MultiError. Multi-errors, including those from the
+// standard library's errors.Join, are flattened into their individual errors.
 func (m *MultiError) Add(err error) {
 	if err == nil {
 		return
 	}
 	
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range multi.Unwrap() {
+			m.Add(e)
+		}
+		return
+	}
+	
 	if e, ok := err.(*Error); ok {
 		m.Errors = append(m.Errors, e)
 	} else {
-		m.Errors = append(m.Errors, Wrap(err, ErrorTypeInternal, "WRAPPED", "wrapped error"))
+		m.Errors = append(m.Errors, Wrap(err, ErrorTypeInternal, CodeWrapped, "wrapped error"))
 	}
 }
 
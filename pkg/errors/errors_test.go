@@ -2,6 +2,7 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -314,4 +315,64 @@ func TestErrorIs(t *testing.T) {
 	if err1.Is(err3) {
 		t.Error("Expected errors with different codes to not match")
 	}
-}
\ No newline at end of file
+}
+func TestMultiErrorUnwrap(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	rateLimit := NewRateLimitError(5 * time.Second)
+
+	multiErr := NewMultiError()
+	multiErr.Add(New(ErrorTypeAWS, "ERR1", "first error"))
+	multiErr.Add(Wrap(sentinel, ErrorTypeNetwork, "ERR2", "second error"))
+	multiErr.Add(rateLimit)
+
+	if !errors.Is(multiErr, sentinel) {
+		t.Error("Expected errors.Is to find a cause inside the MultiError")
+	}
+
+	if !errors.Is(multiErr, &Error{Type: ErrorTypeRateLimit}) {
+		t.Error("Expected errors.Is to match an *Error by type")
+	}
+
+	var target *Error
+	if !errors.As(multiErr, &target) || target.Code != "ERR1" {
+		t.Errorf("Expected errors.As to find the first *Error, got %v", target)
+	}
+
+	if !IsType(multiErr, ErrorTypeNetwork) {
+		t.Error("Expected IsType to search every error in the MultiError")
+	}
+
+	if retryAfter := GetRetryAfter(fmt.Errorf("collect: %w", multiErr)); retryAfter == nil || *retryAfter != 5*time.Second {
+		t.Errorf("Expected retry after from the wrapped rate limit error, got %v", retryAfter)
+	}
+}
+
+func TestMultiErrorAddJoined(t *testing.T) {
+	multiErr := NewMultiError()
+	multiErr.Add(errors.Join(New(ErrorTypeAWS, "ERR1", "first"), errors.New("second")))
+
+	nested := NewMultiError()
+	nested.Add(New(ErrorTypeTimeout, "ERR3", "third"))
+	multiErr.Add(nested)
+
+	if len(multiErr.Errors) != 3 {
+		t.Errorf("Expected joined and nested errors to be flattened into 3 errors, got %d", len(multiErr.Errors))
+	}
+}
+
+func TestErrorIsThroughWrapping(t *testing.T) {
+	inner := New(ErrorTypePermission, "ACCESS_DENIED", "denied")
+	wrapped := fmt.Errorf("collect ec2: %w", Wrap(fmt.Errorf("describe: %w", inner), ErrorTypeAWS, "COLLECTION_ERROR", "failed"))
+
+	if !errors.Is(wrapped, inner) {
+		t.Error("Expected errors.Is to find an *Error through mixed wrapping")
+	}
+
+	if !IsType(wrapped, ErrorTypePermission) {
+		t.Error("Expected IsType to find the permission error in the chain")
+	}
+
+	if errors.Is(wrapped, &Error{Type: ErrorTypeRateLimit}) {
+		t.Error("Expected no rate limit error in the chain")
+	}
+}
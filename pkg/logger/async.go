@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultAsyncQueueSize is the number of entries queued when none is configured
+const defaultAsyncQueueSize = 8192
+
+// AsyncConfig configures asynchronous writing of log entries. Entries below
+// error level are queued and written by a background goroutine, so collection
+// bursts don't wait on slow disks; when the queue is full they are dropped and
+// counted. Errors are always written synchronously and never dropped.
+type AsyncConfig struct {
+	// Enabled turns on asynchronous writing; applies to stdout, stderr and
+	// file outputs
+	Enabled bool `yaml:"enabled"`
+	// QueueSize is the maximum number of queued entries (default 8192)
+	QueueSize int `yaml:"queue_size" validate:"min=0"`
+}
+
+// AsyncStats reports the state of asynchronous logging
+type AsyncStats struct {
+	// Written is the number of queued entries written
+	Written uint64 `json:"written"`
+	// Dropped is the number of entries dropped because the queue was full
+	Dropped uint64 `json:"dropped"`
+	// Queued is the number of entries waiting to be written
+	Queued int `json:"queued"`
+	// QueueSize is the capacity of the queue
+	QueueSize int `json:"queue_size"`
+}
+
+// asyncWriter is a zapcore.WriteSyncer that queues encoded entries and writes
+// them to out from a background goroutine
+type asyncWriter struct {
+	out   zapcore.WriteSyncer
+	queue chan []byte
+	flush chan chan struct{}
+	done  chan struct{}
+
+	// mu guards closing the queue against concurrent writes
+	mu      sync.RWMutex
+	stopped bool
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// newAsyncWriter starts writing queued entries to out
+func newAsyncWriter(out zapcore.WriteSyncer, queueSize int) *asyncWriter {
+	if queueSize <= 0 {
+		queueSize = defaultAsyncQueueSize
+	}
+
+	w := &asyncWriter{
+		out:   out,
+		queue: make(chan []byte, queueSize),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p, dropping it if the queue is full. After Stop,
+// entries are written synchronously.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.stopped {
+		return w.out.Write(p)
+	}
+
+	// zap reuses p once Write returns
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	select {
+	case w.queue <- entry:
+	default:
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Sync writes the entries queued so far and syncs the underlying writer
+func (w *asyncWriter) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case w.flush <- ack:
+		<-ack
+	case <-w.done:
+	}
+	return w.out.Sync()
+}
+
+// Stop writes the remaining queued entries and stops the background
+// goroutine, giving up when ctx is done
+func (w *asyncWriter) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return w.out.Sync()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the write and drop counters
+func (w *asyncWriter) Stats() AsyncStats {
+	return AsyncStats{
+		Written:   w.written.Load(),
+		Dropped:   w.dropped.Load(),
+		Queued:    len(w.queue),
+		QueueSize: cap(w.queue),
+	}
+}
+
+// run writes queued entries until the queue is closed
+func (w *asyncWriter) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				return
+			}
+			w.write(entry)
+		case ack := <-w.flush:
+			for pending := len(w.queue); pending > 0; pending-- {
+				entry, ok := <-w.queue
+				if !ok {
+					break
+				}
+				w.write(entry)
+			}
+			close(ack)
+		}
+	}
+}
+
+// write writes a single entry to the underlying writer
+func (w *asyncWriter) write(entry []byte) {
+	// Write errors can't be reported to the caller, which has moved on
+	_, _ = w.out.Write(entry)
+	w.written.Add(1)
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gatedWriter blocks writes until its gate is opened
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) Sync() error { return nil }
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriterDropsWhenFull(t *testing.T) {
+	out := &gatedWriter{gate: make(chan struct{})}
+	w := newAsyncWriter(out, 2)
+
+	for i := 0; i < 5; i++ {
+		if n, err := w.Write([]byte("entry\n")); err != nil || n != 6 {
+			t.Fatalf("Expected queued write to succeed, got %d, %v", n, err)
+		}
+	}
+
+	// At most one entry is being written and two are queued
+	if stats := w.Stats(); stats.Dropped < 2 || stats.QueueSize != 2 {
+		t.Errorf("Expected at least 2 dropped entries with queue size 2, got %+v", stats)
+	}
+
+	close(out.gate)
+	if err := w.Stop(context.Background()); err != nil {
+		t.Fatalf("Unexpected stop error: %v", err)
+	}
+
+	stats := w.Stats()
+	if stats.Written+stats.Dropped != 5 || stats.Queued != 0 {
+		t.Errorf("Expected every entry to be written or dropped, got %+v", stats)
+	}
+	if got := strings.Count(out.String(), "entry"); uint64(got) != stats.Written {
+		t.Errorf("Expected %d entries in output, got %d", stats.Written, got)
+	}
+
+	// Entries written after Stop go straight to the output
+	_, _ = w.Write([]byte("late\n"))
+	if !strings.Contains(out.String(), "late") {
+		t.Error("Expected entry written after stop to be written synchronously")
+	}
+}
+
+func TestLoggerAsync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	logger, err := NewLogger(Config{
+		Level:      "info",
+		Format:     "json",
+		OutputPath: path,
+		ErrorPath:  path,
+		Async:      AsyncConfig{Enabled: true, QueueSize: 100},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		logger.WithComponent("test").Info("queued entry")
+	}
+	logger.Error("synchronous error")
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("Unexpected sync error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if strings.Count(string(data), "queued entry") != 10 || !strings.Contains(string(data), "synchronous error") {
+		t.Errorf("Expected all entries after sync, got %s", data)
+	}
+
+	stats, ok := logger.AsyncStats()
+	if !ok || stats.Written != 10 || stats.Dropped != 0 {
+		t.Errorf("Expected 10 written entries, got %+v (enabled %v)", stats, ok)
+	}
+
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Errorf("Unexpected shutdown error: %v", err)
+	}
+}
+
+func TestLoggerAsyncDisabled(t *testing.T) {
+	logger, err := NewLogger(Config{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	if _, ok := logger.AsyncStats(); ok {
+		t.Error("Expected async stats to be unavailable when disabled")
+	}
+}
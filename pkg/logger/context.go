@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextKey is the context key for fields carried by a context
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying fields, such as the ID of the job
+// being executed. Loggers obtained with FromContext or Logger.ForContext add
+// them to every entry. Fields accumulate across calls.
+func WithContext(ctx context.Context, fields ...Field) context.Context {
+	existing, _ := ctx.Value(contextKey{}).([]Field)
+
+	combined := make([]Field, 0, len(existing)+len(fields))
+	combined = append(combined, existing...)
+	combined = append(combined, fields...)
+	return context.WithValue(ctx, contextKey{}, combined)
+}
+
+// FromContext returns the global logger with the fields carried by ctx
+func FromContext(ctx context.Context) *Logger {
+	return GetGlobal().ForContext(ctx)
+}
+
+// ForContext returns a logger that adds the fields carried by ctx and, when
+// ctx holds a valid trace span, its trace_id and span_id so log entries can be
+// correlated with traces
+func (l *Logger) ForContext(ctx context.Context) *Logger {
+	fields := contextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.WithFields(fields...)
+}
+
+// contextFields returns the fields carried by ctx followed by its trace IDs
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(contextKey{}).([]Field)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		// Copy so the fields shared through ctx are never appended to
+		fields = append(fields[:len(fields):len(fields)],
+			String("trace_id", spanContext.TraceID().String()),
+			String("span_id", spanContext.SpanID().String()))
+	}
+	return fields
+}
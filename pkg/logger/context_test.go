@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerForContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &Logger{Logger: zap.New(core), level: zap.NewAtomicLevel()}
+
+	ctx := WithContext(context.Background(), String("job_id", "ec2-us-east-1"))
+	ctx = WithContext(ctx, String("attempt", "1"))
+
+	log.ForContext(ctx).Info("collecting")
+	log.ForContext(context.Background()).Info("no context fields")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["job_id"] != "ec2-us-east-1" || fields["attempt"] != "1" {
+		t.Errorf("Expected job_id and attempt fields, got %v", fields)
+	}
+	if _, exists := fields["trace_id"]; exists {
+		t.Error("Expected no trace_id without a span")
+	}
+	if len(entries[1].Context) != 0 {
+		t.Errorf("Expected no fields, got %v", entries[1].ContextMap())
+	}
+}
+
+func TestLoggerForContextTrace(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &Logger{Logger: zap.New(core), level: zap.NewAtomicLevel()}
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	ctx = WithContext(ctx, String("job_id", "rds-eu-west-1"))
+
+	log.ForContext(ctx).Warn("slow collection")
+
+	fields := logs.All()[0].ContextMap()
+	if fields["trace_id"] != spanContext.TraceID().String() {
+		t.Errorf("Expected trace_id %s, got %v", spanContext.TraceID(), fields["trace_id"])
+	}
+	if fields["span_id"] != spanContext.SpanID().String() {
+		t.Errorf("Expected span_id %s, got %v", spanContext.SpanID(), fields["span_id"])
+	}
+	if fields["job_id"] != "rds-eu-west-1" {
+		t.Errorf("Expected job_id rds-eu-west-1, got %v", fields["job_id"])
+	}
+}
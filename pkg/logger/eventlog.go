@@ -0,0 +1,21 @@
+package logger
+
+// eventLogPath selects the Windows Event Log as a log output
+const eventLogPath = "eventlog"
+
+// eventLogSource is the event source entries are reported under
+const eventLogSource = "aws-monitor"
+
+// Event IDs reported to the Event Log, by severity. EventCreate-style sources
+// accept IDs from 1 to 1000.
+const (
+	eventIDInfo     uint32 = 1
+	eventIDWarning  uint32 = 2
+	eventIDError    uint32 = 3
+	eventIDCritical uint32 = 4
+)
+
+// isEventLogPath reports whether path selects the Windows Event Log
+func isEventLogPath(path string) bool {
+	return path == eventLogPath
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package logger
+
+import (
+	"fmt"
+	"runtime"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newEventLogCore reports that the Event Log is only available on Windows
+func newEventLogCore(_ zapcore.Encoder, _ zapcore.LevelEnabler) (zapcore.Core, error) {
+	return nil, fmt.Errorf("eventlog output is only supported on windows, not %s", runtime.GOOS)
+}
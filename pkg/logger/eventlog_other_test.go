@@ -0,0 +1,11 @@
+//go:build !windows
+
+package logger
+
+import "testing"
+
+func TestLoggerEventLogUnsupported(t *testing.T) {
+	if _, err := NewLogger(Config{Level: "info", Format: "json", OutputPath: "eventlog"}); err == nil {
+		t.Error("Expected error for eventlog output outside Windows")
+	}
+}
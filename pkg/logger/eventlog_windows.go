@@ -0,0 +1,78 @@
+//go:build windows
+
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventLogCore writes entries to the Windows Event Log, mapping each entry's
+// level to an information, warning or error event
+type eventLogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	log     *eventlog.Log
+}
+
+// newEventLogCore creates a core writing entries enabled by enabler to the
+// Event Log. The event source is registered on first use, which requires
+// administrator rights; when running as a service it normally is.
+func newEventLogCore(encoder zapcore.Encoder, enabler zapcore.LevelEnabler) (zapcore.Core, error) {
+	// Registration fails if the source already exists; entries are still
+	// logged if it can't be registered, only without a message description
+	_ = eventlog.InstallAsEventCreate(eventLogSource, eventlog.Info|eventlog.Warning|eventlog.Error)
+
+	log, err := eventlog.Open(eventLogSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+
+	return &eventLogCore{LevelEnabler: enabler, encoder: encoder.Clone(), log: log}, nil
+}
+
+// With adds structured context to the core
+func (c *eventLogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &eventLogCore{LevelEnabler: c.LevelEnabler, encoder: c.encoder.Clone(), log: c.log}
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
+	return clone
+}
+
+// Check adds the core to the checked entry if the entry's level is enabled
+func (c *eventLogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write reports the encoded entry as an event of the matching type
+func (c *eventLogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	message := strings.TrimSuffix(buf.String(), "\n")
+	switch {
+	case ent.Level < zapcore.WarnLevel:
+		return c.log.Info(eventIDInfo, message)
+	case ent.Level == zapcore.WarnLevel:
+		return c.log.Warning(eventIDWarning, message)
+	case ent.Level == zapcore.ErrorLevel:
+		return c.log.Error(eventIDError, message)
+	default:
+		return c.log.Error(eventIDCritical, message)
+	}
+}
+
+// Sync is a no-op; events are reported as they are written
+func (c *eventLogCore) Sync() error {
+	return nil
+}
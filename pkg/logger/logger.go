@@ -2,6 +2,8 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -15,14 +17,53 @@ import (
 type Logger struct {
 	*zap.Logger
 	config Config
+	// level is shared by all loggers derived from the same root so the level
+	// can be changed at runtime
+	level zap.AtomicLevel
+	// shutdown stops asynchronous writing and log export
+	shutdown func(context.Context) error
+	// flush exports the pending records when log export is enabled
+	flush func(context.Context) error
+	// async queues entries when asynchronous logging is enabled
+	async *asyncWriter
+	// export tracks log export when it is enabled
+	export *exportTracker
 }
 
 // Config holds logger configuration
 type Config struct {
-	Level      string `yaml:"level" validate:"oneof=debug info warn error"`
-	Format     string `yaml:"format" validate:"oneof=json text"`
+	Level  string `yaml:"level" validate:"oneof=debug info warn error"`
+	Format string `yaml:"format" validate:"oneof=json text"`
+	// OutputPath and ErrorPath are stdout, stderr, a file path, a syslog
+	// URL such as syslog://host:514?facility=local0 (syslog+tcp:// for TCP)
+	// or "eventlog" for the Windows Event Log
 	OutputPath string `yaml:"output_path"`
 	ErrorPath  string `yaml:"error_path"`
+
+	// MaxSize is the size in megabytes at which a log file is rotated;
+	// 0 disables rotation. Rotation only applies to file paths.
+	MaxSize int `yaml:"max_size" validate:"min=0"`
+	// MaxAge is the number of days rotated log files are kept; 0 keeps them
+	// regardless of age
+	MaxAge int `yaml:"max_age" validate:"min=0"`
+	// MaxBackups is the number of rotated log files kept; 0 keeps them all
+	MaxBackups int `yaml:"max_backups" validate:"min=0"`
+	// Compress gzips rotated log files
+	Compress bool `yaml:"compress"`
+
+	// Sampling limits how often identical entries are logged; disabled by default
+	Sampling SamplingConfig `yaml:"sampling"`
+
+	// Async queues entries and writes them in the background; disabled by default
+	Async AsyncConfig `yaml:"async"`
+
+	// OTLP exports log records to an OpenTelemetry collector; disabled by default
+	OTLP OTLPConfig `yaml:"otlp"`
+
+	// RedactFields are additional field names whose values are masked.
+	// AWS credentials, authorization headers, tokens and passwords are
+	// always masked.
+	RedactFields []string `yaml:"redact_fields"`
 }
 
 // Field represents a structured log field
@@ -84,10 +125,11 @@ func Time(key string, val time.Time) Field {
 // NewLogger creates a new logger instance
 func NewLogger(config Config) (*Logger, error) {
 	// Parse log level
-	level, err := parseLogLevel(config.Level)
+	parsedLevel, err := parseLogLevel(config.Level)
 	if err != nil {
 		return nil, fmt.Errorf("invalid log level %s: %w", config.Level, err)
 	}
+	level := zap.NewAtomicLevelAt(parsedLevel)
 
 	// Create encoder config
 	encoderConfig := getEncoderConfig(config.Format)
@@ -114,28 +156,92 @@ func NewLogger(config Config) (*Logger, error) {
 		errorPath = "stderr"
 	}
 
-	// Create core
-	writeSyncer := getWriteSyncer(outputPath)
-	errorWriteSyncer := getWriteSyncer(errorPath)
-
 	// Create separate cores for different levels
-	infoCore := zapcore.NewCore(
-		encoder,
-		writeSyncer,
-		zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-			return lvl >= level && lvl < zapcore.ErrorLevel
-		}),
-	)
+	infoEnabler := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return level.Enabled(lvl) && lvl < zapcore.ErrorLevel
+	})
+	errorEnabler := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zapcore.ErrorLevel
+	})
+
+	var infoCore, errorCore zapcore.Core
+	var writeSyncer zapcore.WriteSyncer
+	var async *asyncWriter
+	if isTargetPath(outputPath) {
+		if infoCore, err = newTargetCore(outputPath, encoder, infoEnabler); err != nil {
+			return nil, err
+		}
+	} else {
+		writeSyncer = getWriteSyncer(outputPath, config)
+		infoWriter := writeSyncer
+		if config.Async.Enabled {
+			async = newAsyncWriter(writeSyncer, config.Async.QueueSize)
+			infoWriter = async
+		}
+		infoCore = zapcore.NewCore(encoder, infoWriter, infoEnabler)
+	}
 
-	errorCore := zapcore.NewCore(
-		encoder,
-		errorWriteSyncer,
-		zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-			return lvl >= zapcore.ErrorLevel
-		}),
-	)
+	// shutdown stops the async writer and log export, whichever are enabled
+	var closers []func(context.Context) error
+	if async != nil {
+		closers = append(closers, async.Stop)
+	}
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+		for _, closer := range closers {
+			if err := closer(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	switch {
+	case isTargetPath(errorPath):
+		if errorCore, err = newTargetCore(errorPath, encoder, errorEnabler); err != nil {
+			_ = shutdown(context.Background())
+			return nil, err
+		}
+	case errorPath == outputPath:
+		// Errors bypass the async queue so they are never dropped
+		// Separate writers for the same file would rotate it independently
+		errorCore = zapcore.NewCore(encoder, writeSyncer, errorEnabler)
+	default:
+		errorWriter := getWriteSyncer(errorPath, config)
+		closers = appendFileCloser(closers, errorWriter)
+		errorCore = zapcore.NewCore(encoder, errorWriter, errorEnabler)
+	}
+	// Once the queued entries are written, the rotated files are compressed
+	// and removed before shutdown completes
+	closers = appendFileCloser(closers, writeSyncer)
+
+	// Secrets are masked before entries are encoded or exported
+	redactor := newRedactor(config.RedactFields)
+	cores := []zapcore.Core{
+		&redactingCore{Core: infoCore, redactor: redactor},
+		&redactingCore{Core: errorCore, redactor: redactor},
+	}
 
-	core := zapcore.NewTee(infoCore, errorCore)
+	var export *exportTracker
+	var flush func(context.Context) error
+	if config.OTLP.Enabled {
+		export = newExportTracker(config.OTLP.BatchSize)
+		otlpCore, provider, err := newOTLPCore(config.OTLP, level, export)
+		if err != nil {
+			_ = shutdown(context.Background())
+			return nil, err
+		}
+		cores = append(cores, &redactingCore{Core: otlpCore, redactor: redactor})
+		closers = append(closers, provider.Shutdown)
+		flush = provider.ForceFlush
+	}
+	core := zapcore.NewTee(cores...)
+
+	core, err = newSampledCore(core, config.Sampling)
+	if err != nil {
+		_ = shutdown(context.Background())
+		return nil, err
+	}
 
 	// Create logger with options
 	zapLogger := zap.New(core,
@@ -145,8 +251,13 @@ func NewLogger(config Config) (*Logger, error) {
 	)
 
 	logger := &Logger{
-		Logger: zapLogger,
-		config: config,
+		Logger:   zapLogger,
+		config:   config,
+		level:    level,
+		shutdown: shutdown,
+		flush:    flush,
+		async:    async,
+		export:   export,
 	}
 
 	return logger, nil
@@ -182,12 +293,102 @@ func (l *Logger) Sync() error {
 	return l.Logger.Sync()
 }
 
+// Shutdown flushes buffered entries, writes any queued entries, waits for
+// rotated log files to be compressed and removed and, when log export is
+// enabled, exports the remaining records and stops the exporter.
+// Entries logged afterwards are written synchronously and no longer exported.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	_ = l.Logger.Sync()
+	if l.shutdown == nil {
+		return nil
+	}
+	return l.shutdown(ctx)
+}
+
+// Flush writes buffered and queued entries and, when log export is enabled,
+// exports the pending records. Unlike Shutdown, logging and export continue
+// afterwards.
+func (l *Logger) Flush(ctx context.Context) error {
+	_ = l.Logger.Sync()
+	if l.flush == nil {
+		return nil
+	}
+	return l.flush(ctx)
+}
+
+// AsyncStats returns the asynchronous logging counters; ok is false when
+// asynchronous logging is disabled
+func (l *Logger) AsyncStats() (stats AsyncStats, ok bool) {
+	if l.async == nil {
+		return AsyncStats{}, false
+	}
+	return l.async.Stats(), true
+}
+
+// ExportStats returns the log export counters; ok is false when log export is
+// disabled
+func (l *Logger) ExportStats() (stats ExportStats, ok bool) {
+	if l.export == nil {
+		return ExportStats{}, false
+	}
+	return l.export.Stats(), true
+}
+
 // WithFields creates a logger with structured fields
 func (l *Logger) WithFields(fields ...Field) *Logger {
 	return &Logger{
-		Logger: l.With(fields...),
-		config: l.config,
+		Logger:   l.With(fields...),
+		config:   l.config,
+		level:    l.level,
+		shutdown: l.shutdown,
+		flush:    l.flush,
+		async:    l.async,
+		export:   l.export,
+	}
+}
+
+// Level returns the current log level
+func (l *Logger) Level() string {
+	return l.level.Level().String()
+}
+
+// SetLevel changes the log level of this logger and every logger derived
+// from the same root at runtime. Errors are always logged.
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		return err
 	}
+	if parsed > zapcore.ErrorLevel {
+		return fmt.Errorf("log level %s would hide errors", level)
+	}
+
+	previous := l.level.Level()
+	l.level.SetLevel(parsed)
+	if previous != parsed {
+		l.Warn("Log level changed",
+			String("previous_level", previous.String()),
+			String("level", parsed.String()))
+	}
+	return nil
+}
+
+// IncreaseVerbosity lowers the log level one step towards debug and returns
+// the new level
+func (l *Logger) IncreaseVerbosity() string {
+	if current := l.level.Level(); current > zapcore.DebugLevel {
+		_ = l.SetLevel((current - 1).String())
+	}
+	return l.Level()
+}
+
+// DecreaseVerbosity raises the log level one step towards error and returns
+// the new level
+func (l *Logger) DecreaseVerbosity() string {
+	if current := l.level.Level(); current < zapcore.ErrorLevel {
+		_ = l.SetLevel((current + 1).String())
+	}
+	return l.Level()
 }
 
 // WithComponent creates a logger for a specific component
@@ -331,6 +532,11 @@ func Sync() error {
 	return GetGlobal().Sync()
 }
 
+// Shutdown flushes the global logger and stops log export
+func Shutdown(ctx context.Context) error {
+	return GetGlobal().Shutdown(ctx)
+}
+
 // WithFields creates a logger with fields from global logger
 func WithFields(fields ...Field) *Logger {
 	return GetGlobal().WithFields(fields...)
@@ -381,13 +587,54 @@ func getEncoderConfig(format string) zapcore.EncoderConfig {
 	return config
 }
 
-func getWriteSyncer(path string) zapcore.WriteSyncer {
+// isTargetPath reports whether path is a log target with its own core, such
+// as syslog, rather than a stream or file
+func isTargetPath(path string) bool {
+	return isSyslogPath(path) || isEventLogPath(path)
+}
+
+// IsFilePath reports whether path, an OutputPath or ErrorPath, is written to a
+// file rather than a stream, syslog or the Event Log
+func IsFilePath(path string) bool {
+	switch path {
+	case "", "stdout", "stderr":
+		return false
+	}
+	return !isTargetPath(path)
+}
+
+// newTargetCore creates the core for a syslog or Event Log target
+func newTargetCore(path string, encoder zapcore.Encoder, enabler zapcore.LevelEnabler) (zapcore.Core, error) {
+	if isEventLogPath(path) {
+		return newEventLogCore(encoder, enabler)
+	}
+	return newSyslogCore(path, encoder, enabler)
+}
+
+// appendFileCloser adds closing w to closers if it is a rotating log file
+func appendFileCloser(closers []func(context.Context) error, w zapcore.WriteSyncer) []func(context.Context) error {
+	if rotating, ok := w.(*rotatingFile); ok {
+		closers = append(closers, func(context.Context) error { return rotating.Close() })
+	}
+	return closers
+}
+
+func getWriteSyncer(path string, config Config) zapcore.WriteSyncer {
 	switch path {
 	case "stdout", "":
 		return zapcore.AddSync(os.Stdout)
 	case "stderr":
 		return zapcore.AddSync(os.Stderr)
 	default:
+		if config.MaxSize > 0 {
+			rotating, err := newRotatingFile(path, config)
+			if err != nil {
+				// Fallback to stdout if file cannot be opened
+				return zapcore.AddSync(os.Stdout)
+			}
+			return rotating
+		}
+
 		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		if err != nil {
 			// Fallback to stdout if file cannot be opened
@@ -388,3 +388,62 @@ func BenchmarkGlobalLogger(b *testing.B) {
 		)
 	}
 }
+
+func TestLoggerSetLevel(t *testing.T) {
+	logger, err := NewLogger(Config{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	component := logger.WithComponent("test")
+
+	if component.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("Expected debug to be disabled at info level")
+	}
+
+	if err := logger.SetLevel("debug"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !component.Core().Enabled(zapcore.DebugLevel) || component.Level() != "debug" {
+		t.Error("Expected level change to apply to derived loggers")
+	}
+
+	if err := logger.SetLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+	if err := logger.SetLevel("fatal"); err == nil {
+		t.Error("Expected error for a level that hides errors")
+	}
+
+	if level := logger.IncreaseVerbosity(); level != "debug" {
+		t.Errorf("Expected verbosity to stay at debug, got %s", level)
+	}
+	if level := logger.DecreaseVerbosity(); level != "info" {
+		t.Errorf("Expected info after decreasing verbosity, got %s", level)
+	}
+	logger.DecreaseVerbosity()
+	logger.DecreaseVerbosity()
+	if level := logger.DecreaseVerbosity(); level != "error" {
+		t.Errorf("Expected verbosity to stop at error, got %s", level)
+	}
+	if !component.Core().Enabled(zapcore.ErrorLevel) {
+		t.Error("Expected errors to always be logged")
+	}
+}
+
+func TestIsFilePath(t *testing.T) {
+	tests := map[string]bool{
+		"":                         false,
+		"stdout":                   false,
+		"stderr":                   false,
+		"syslog://localhost":       false,
+		"eventlog":                 false,
+		"/var/log/aws-monitor.log": true,
+		"logs/aws-monitor.log":     true,
+	}
+
+	for path, expected := range tests {
+		if got := IsFilePath(path); got != expected {
+			t.Errorf("Expected IsFilePath(%q) to be %v, got %v", path, expected, got)
+		}
+	}
+}
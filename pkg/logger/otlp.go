@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.uber.org/zap/zapcore"
+
+	"aws-monitoring/pkg/discovery"
+)
+
+// otlpScopeName is the instrumentation scope of exported log records
+const otlpScopeName = "aws-monitoring/pkg/logger"
+
+// OTLPConfig configures exporting log records over OTLP/gRPC, so logs reach
+// the same OpenTelemetry collector and backend as the metrics
+type OTLPConfig struct {
+	// Enabled turns on log export
+	Enabled bool `yaml:"enabled"`
+	// Endpoint is the collector URL, e.g. http://otel-collector:4317, or an
+	// srv:// URL naming a DNS SRV record listing the collectors
+	Endpoint string `yaml:"endpoint"`
+	// SRVRefreshInterval is how often the SRV record of an srv:// endpoint
+	// is resolved again
+	SRVRefreshInterval time.Duration `yaml:"srv_refresh_interval"`
+	// Insecure disables TLS
+	Insecure bool `yaml:"insecure"`
+	// Headers are sent with every export request
+	Headers map[string]string `yaml:"headers"`
+	// ServiceName and ServiceVersion identify the exporting service
+	ServiceName    string `yaml:"service_name"`
+	ServiceVersion string `yaml:"service_version"`
+	// ResourceAttributes are added to the resource of every exported record
+	ResourceAttributes map[string]string `yaml:"resource_attributes"`
+	// BatchTimeout is the maximum time records wait before being exported
+	BatchTimeout time.Duration `yaml:"batch_timeout"`
+	// BatchSize is the maximum number of records per export request
+	BatchSize int `yaml:"batch_size" validate:"min=0"`
+}
+
+// newOTLPCore creates a core exporting entries to the configured collector,
+// reporting exports to tracker. The returned provider flushes and stops the
+// exporter.
+func newOTLPCore(config OTLPConfig, level zapcore.LevelEnabler, tracker *exportTracker) (zapcore.Core, *sdklog.LoggerProvider, error) {
+	opts, err := OTLPExporterOptions(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The gRPC connection is established lazily, so an unreachable collector
+	// doesn't prevent startup
+	exporter, err := otlploggrpc.New(context.Background(), opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create OTLP log exporter: %w", err)
+	}
+
+	return newExportCore(config, level, exporter, tracker)
+}
+
+// OTLPExporterOptions returns the options connecting an OTLP/gRPC log
+// exporter to the collector of config: its endpoint, TLS setting and headers.
+// Other exporters of log records, such as the events sink, share them.
+func OTLPExporterOptions(config OTLPConfig) ([]otlploggrpc.Option, error) {
+	target, err := discovery.Resolve(config.Endpoint, config.SRVRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(target.Address), otlploggrpc.WithDialOption(target.DialOptions...)}
+	if config.Insecure || target.Plaintext {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	if len(config.Headers) > 0 {
+		opts = append(opts, otlploggrpc.WithHeaders(config.Headers))
+	}
+	return opts, nil
+}
+
+// newExportCore creates a core that batches entries and hands them to exporter
+func newExportCore(config OTLPConfig, level zapcore.LevelEnabler, exporter sdklog.Exporter, tracker *exportTracker) (zapcore.Core, *sdklog.LoggerProvider, error) {
+	var batchOpts []sdklog.BatchProcessorOption
+	if config.BatchTimeout > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportInterval(config.BatchTimeout))
+	}
+	if config.BatchSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportMaxBatchSize(config.BatchSize))
+	}
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(otlpResource(config)),
+		sdklog.WithProcessor(&trackingProcessor{
+			Processor: sdklog.NewBatchProcessor(&trackingExporter{Exporter: exporter, tracker: tracker}, batchOpts...),
+			tracker:   tracker,
+		}),
+	)
+
+	// The bridge logs every level; restrict it to the logger's (runtime
+	// adjustable) level
+	core, err := zapcore.NewIncreaseLevelCore(otelzap.NewCore(otlpScopeName, otelzap.WithLoggerProvider(provider)), level)
+	if err != nil {
+		_ = provider.Shutdown(context.Background())
+		return nil, nil, fmt.Errorf("failed to create OTLP log core: %w", err)
+	}
+
+	return core, provider, nil
+}
+
+// otlpResource returns the resource describing the exporting service
+func otlpResource(config OTLPConfig) *resource.Resource {
+	keys := make([]string, 0, len(config.ResourceAttributes))
+	for key := range config.ResourceAttributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute.KeyValue, 0, len(keys)+2)
+	for _, key := range keys {
+		attrs = append(attrs, attribute.String(key, config.ResourceAttributes[key]))
+	}
+	if config.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceName(config.ServiceName))
+	}
+	if config.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(config.ServiceVersion))
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// defaultOTLPBatchSize is the batch processor's batch size when none is configured
+const defaultOTLPBatchSize = 512
+
+// ExportStats reports the state of log export
+type ExportStats struct {
+	// Exported is the number of records exported successfully
+	Exported uint64 `json:"exported"`
+	// Failed is the number of records whose export failed
+	Failed uint64 `json:"failed"`
+	// Pending is the approximate number of records waiting to be exported
+	Pending int64 `json:"pending"`
+	// LastSuccess is when records were last exported successfully
+	LastSuccess time.Time `json:"last_success"`
+	// FailingSince is when the current run of failed exports started; zero
+	// when the last export succeeded
+	FailingSince time.Time `json:"failing_since"`
+	// LastError is the error of the most recent failed export
+	LastError string `json:"last_error,omitempty"`
+}
+
+// exportTracker counts the records handed to and exported by a log exporter
+type exportTracker struct {
+	batchSize int
+
+	exported atomic.Uint64
+	failed   atomic.Uint64
+	pending  atomic.Int64
+
+	mu           sync.Mutex
+	lastSuccess  time.Time
+	failingSince time.Time
+	lastError    string
+}
+
+// newExportTracker creates a tracker for a batch processor exporting up to
+// batchSize records at a time
+func newExportTracker(batchSize int) *exportTracker {
+	if batchSize <= 0 {
+		batchSize = defaultOTLPBatchSize
+	}
+	return &exportTracker{batchSize: batchSize}
+}
+
+// Stats returns the export counters
+func (t *exportTracker) Stats() ExportStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return ExportStats{
+		Exported:     t.exported.Load(),
+		Failed:       t.failed.Load(),
+		Pending:      t.pending.Load(),
+		LastSuccess:  t.lastSuccess,
+		FailingSince: t.failingSince,
+		LastError:    t.lastError,
+	}
+}
+
+// emitted records that a record was queued for export
+func (t *exportTracker) emitted() {
+	t.pending.Add(1)
+}
+
+// exportDone records the outcome of exporting records
+func (t *exportTracker) exportDone(records int, err error) {
+	if records < t.batchSize {
+		// A partial batch drains the batch queue, which also clears records
+		// the batch processor dropped because its queue was full
+		t.pending.Store(0)
+	} else {
+		t.pending.Add(-int64(records))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		t.failed.Add(uint64(records))
+		t.lastError = err.Error()
+		if t.failingSince.IsZero() {
+			t.failingSince = time.Now()
+		}
+		return
+	}
+
+	t.exported.Add(uint64(records))
+	t.lastSuccess = time.Now()
+	t.failingSince = time.Time{}
+}
+
+// trackingExporter reports the outcome of every export to a tracker
+type trackingExporter struct {
+	sdklog.Exporter
+	tracker *exportTracker
+}
+
+// Export exports records and records the outcome
+func (e *trackingExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	err := e.Exporter.Export(ctx, records)
+	e.tracker.exportDone(len(records), err)
+	return err
+}
+
+// trackingProcessor counts the records emitted to the batch processor
+type trackingProcessor struct {
+	sdklog.Processor
+	tracker *exportTracker
+}
+
+// OnEmit counts the record and hands it to the batch processor
+func (p *trackingProcessor) OnEmit(ctx context.Context, record *sdklog.Record) error {
+	p.tracker.emitted()
+	return p.Processor.OnEmit(ctx, record)
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// memoryExporter keeps exported log records in memory
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, record := range records {
+		e.records = append(e.records, record.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func TestExportCore(t *testing.T) {
+	exporter := &memoryExporter{}
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	core, provider, err := newExportCore(OTLPConfig{
+		ServiceName:        "aws-monitor",
+		ServiceVersion:     "1.2.3",
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+	}, level, exporter, newExportTracker(0))
+	if err != nil {
+		t.Fatalf("Failed to create export core: %v", err)
+	}
+
+	log := zap.New(core)
+	log.Debug("below level")
+	log.Info("metrics collected", zap.String("region", "us-east-1"))
+	level.SetLevel(zapcore.WarnLevel)
+	log.Info("below raised level")
+	log.Error("collection failed")
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected shutdown error: %v", err)
+	}
+
+	if len(exporter.records) != 2 {
+		t.Fatalf("Expected 2 exported records, got %d", len(exporter.records))
+	}
+
+	info := exporter.records[0]
+	if info.Body().AsString() != "metrics collected" || info.Severity() != otellog.SeverityInfo {
+		t.Errorf("Expected info record 'metrics collected', got %q (%v)", info.Body().AsString(), info.Severity())
+	}
+	region := ""
+	info.WalkAttributes(func(kv otellog.KeyValue) bool {
+		if kv.Key == "region" {
+			region = kv.Value.AsString()
+		}
+		return true
+	})
+	if region != "us-east-1" {
+		t.Errorf("Expected region attribute us-east-1, got %q", region)
+	}
+
+	if exporter.records[1].Severity() != otellog.SeverityError {
+		t.Errorf("Expected error severity, got %v", exporter.records[1].Severity())
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range info.Resource().Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	for key, want := range map[string]string{
+		"service.name":           "aws-monitor",
+		"service.version":        "1.2.3",
+		"deployment.environment": "test",
+	} {
+		if attrs[key] != want {
+			t.Errorf("Expected resource attribute %s=%s, got %q", key, want, attrs[key])
+		}
+	}
+}
+
+// failingExporter fails every export
+type failingExporter struct{ memoryExporter }
+
+func (e *failingExporter) Export(context.Context, []sdklog.Record) error {
+	return errors.New("connection refused")
+}
+
+func TestExportTracking(t *testing.T) {
+	exporter := &failingExporter{}
+	tracker := newExportTracker(0)
+
+	core, provider, err := newExportCore(OTLPConfig{}, zapcore.InfoLevel, exporter, tracker)
+	if err != nil {
+		t.Fatalf("Failed to create export core: %v", err)
+	}
+
+	log := zap.New(core)
+	log.Info("first")
+	log.Info("second")
+	if stats := tracker.Stats(); stats.Pending != 2 {
+		t.Errorf("Expected 2 pending records, got %d", stats.Pending)
+	}
+
+	_ = provider.Shutdown(context.Background())
+
+	stats := tracker.Stats()
+	if stats.Failed != 2 || stats.Exported != 0 || stats.Pending != 0 {
+		t.Errorf("Expected 2 failed records and none pending, got %+v", stats)
+	}
+	if stats.FailingSince.IsZero() || stats.LastError != "connection refused" {
+		t.Errorf("Expected export to be failing with the exporter's error, got %+v", stats)
+	}
+
+	tracker.exportDone(1, nil)
+	stats = tracker.Stats()
+	if stats.Exported != 1 || !stats.FailingSince.IsZero() || stats.LastSuccess.IsZero() {
+		t.Errorf("Expected a successful export to clear the failure, got %+v", stats)
+	}
+}
+
+func TestLoggerFlush(t *testing.T) {
+	exporter := &memoryExporter{}
+	core, provider, err := newExportCore(OTLPConfig{}, zapcore.InfoLevel, exporter, newExportTracker(0))
+	if err != nil {
+		t.Fatalf("Failed to create export core: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	logger := &Logger{Logger: zap.New(core), flush: provider.ForceFlush}
+	logger.Info("pending")
+	if err := logger.WithComponent("test").Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected flush error: %v", err)
+	}
+
+	exporter.mu.Lock()
+	exported := len(exporter.records)
+	exporter.mu.Unlock()
+	if exported != 1 {
+		t.Errorf("Expected 1 record exported by the flush, got %d", exported)
+	}
+
+	// Export continues after a flush
+	logger.Info("after flush")
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected shutdown error: %v", err)
+	}
+	if len(exporter.records) != 2 {
+		t.Errorf("Expected 2 exported records, got %d", len(exporter.records))
+	}
+}
+
+func TestLoggerShutdownWithoutExport(t *testing.T) {
+	logger, err := NewLogger(Config{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	if _, ok := logger.ExportStats(); ok {
+		t.Error("Expected no export stats when export is disabled")
+	}
+
+	if err := logger.WithComponent("test").Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue replaces masked secrets
+const redactedValue = "[REDACTED]"
+
+// defaultSensitiveFields are field names whose values are always masked.
+// Names are compared case-insensitively, ignoring "_" and "-", so
+// "secret_access_key" also matches "SecretAccessKey".
+var defaultSensitiveFields = []string{
+	"access_key_id",
+	"secret_access_key",
+	"session_token",
+	"authorization",
+	"password",
+	"token",
+	"api_key",
+	"dsn",
+}
+
+// secretPatterns find secrets embedded in free text such as log messages and
+// error strings
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// AWS access key IDs (long-term and temporary)
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`), redactedValue},
+	// AWS secret access keys next to their name, e.g. aws_secret_access_key=...
+	{regexp.MustCompile(`(?i)(secret[_-]?access[_-]?key["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`), "${1}" + redactedValue},
+	// Authorization headers, e.g. the OTEL collector bearer token
+	{regexp.MustCompile(`(?i)(authorization["']?\s*[:=]\s*["']?)(?:(?:bearer|basic)\s+)?[^\s"',}]+`), "${1}" + redactedValue},
+}
+
+// redactor masks secrets in log entries
+type redactor struct {
+	sensitive map[string]bool
+}
+
+// newRedactor creates a redactor masking the default sensitive fields and fields
+func newRedactor(fields []string) *redactor {
+	r := &redactor{sensitive: make(map[string]bool, len(defaultSensitiveFields)+len(fields))}
+	for _, name := range defaultSensitiveFields {
+		r.sensitive[normalizeFieldName(name)] = true
+	}
+	for _, name := range fields {
+		r.sensitive[normalizeFieldName(name)] = true
+	}
+	return r
+}
+
+// normalizeFieldName lowercases name and strips separators
+func normalizeFieldName(name string) string {
+	return strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(name))
+}
+
+// isSensitive reports whether values of the named field are masked
+func (r *redactor) isSensitive(name string) bool {
+	return r.sensitive[normalizeFieldName(name)]
+}
+
+// redactString masks secrets embedded in s
+func (r *redactor) redactString(s string) string {
+	for _, p := range secretPatterns {
+		s = p.pattern.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// redactFields returns fields with sensitive values masked. Structured values
+// such as config dumps are converted to their JSON form so nested sensitive
+// fields can be masked too.
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = r.redactField(field)
+	}
+	return redacted
+}
+
+// redactField masks a single field
+func (r *redactor) redactField(field zapcore.Field) zapcore.Field {
+	if r.isSensitive(field.Key) && field.Type != zapcore.SkipType {
+		return zap.String(field.Key, redactedValue)
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		return zap.String(field.Key, r.redactString(field.String))
+	case zapcore.ByteStringType:
+		if b, ok := field.Interface.([]byte); ok {
+			return zap.ByteString(field.Key, []byte(r.redactString(string(b))))
+		}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return zap.String(field.Key, r.redactString(err.Error()))
+		}
+	case zapcore.StringerType:
+		if s, ok := field.Interface.(fmt.Stringer); ok {
+			return zap.String(field.Key, r.redactString(s.String()))
+		}
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		if value, ok := r.structuredValue(field); ok {
+			return zap.Any(field.Key, value)
+		}
+	}
+	return field
+}
+
+// structuredValue converts a structured field to plain maps and slices with
+// sensitive values masked
+func (r *redactor) structuredValue(field zapcore.Field) (interface{}, bool) {
+	var value interface{}
+
+	if field.Type == zapcore.ReflectType {
+		data, err := json.Marshal(field.Interface)
+		if err != nil {
+			return nil, false
+		}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, false
+		}
+	} else {
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		value = enc.Fields[field.Key]
+	}
+
+	return r.redactValue(value), true
+}
+
+// redactValue masks sensitive keys and embedded secrets in a decoded value
+func (r *redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if r.isSensitive(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = r.redactValue(nested)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = r.redactValue(nested)
+		}
+		return v
+	case string:
+		return r.redactString(v)
+	default:
+		return v
+	}
+}
+
+// redactingCore masks secrets in entries before they reach the wrapped core,
+// so they are never encoded or exported. It wraps individual output cores
+// rather than a tee, since a tee writes to all of its cores regardless of
+// their levels.
+type redactingCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+// With adds structured context, masking sensitive values
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactor.redactFields(fields)), redactor: c.redactor}
+}
+
+// Check adds the core to the checked entry if the wrapped core logs it
+func (c *redactingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write masks secrets in the message and fields and writes the entry
+func (c *redactingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.redactString(ent.Message)
+	return c.Core.Write(ent, c.redactor.redactFields(fields))
+}
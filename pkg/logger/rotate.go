@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp added to rotated log file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile is a zapcore.WriteSyncer that writes to a log file and rotates
+// it once it grows past a maximum size, in the style of lumberjack. Rotated
+// files are renamed to <name>-<timestamp><ext>, optionally gzip compressed,
+// and removed once there are more than maxBackups of them or they are older
+// than maxAge. Compression and removal run in a background goroutine, so that
+// writes aren't held up while a large file is compressed.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+	now        func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// mill receives the time of each rotation for the goroutine compressing
+	// and removing backups, which millWG tracks; nil until the first rotation
+	mill   chan time.Time
+	millWG sync.WaitGroup
+}
+
+// backupFile is a rotated log file found on disk
+type backupFile struct {
+	path      string
+	timestamp time.Time
+}
+
+// newRotatingFile opens path for appending, rotating it according to config
+func newRotatingFile(path string, config Config) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(config.MaxSize) * 1024 * 1024,
+		maxAge:     time.Duration(config.MaxAge) * 24 * time.Hour,
+		maxBackups: config.MaxBackups,
+		compress:   config.Compress,
+		now:        time.Now,
+	}
+
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write appends p to the log file, rotating it first if p would take it past
+// the maximum size
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the log file to disk
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close closes the log file and waits for rotated files to be compressed and
+// removed
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	if r.mill != nil {
+		close(r.mill)
+		r.mill = nil
+	}
+	r.mu.Unlock()
+
+	r.millWG.Wait()
+	return err
+}
+
+// open opens the log file for appending and records its current size
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate moves the current log file aside and opens a new one, leaving the
+// backups to be compressed and removed in the background
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	now := r.now()
+	if err := os.Rename(r.path, r.backupName(now)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	if r.mill == nil {
+		r.mill = make(chan time.Time, 1)
+		r.millWG.Add(1)
+		go r.runMill(r.mill)
+	}
+	// A pending run also covers this rotation
+	select {
+	case r.mill <- now:
+	default:
+	}
+	return nil
+}
+
+// runMill compresses and removes backups after each rotation until mill is
+// closed
+func (r *rotatingFile) runMill(mill <-chan time.Time) {
+	defer r.millWG.Done()
+
+	for now := range mill {
+		if r.compress {
+			r.compressBackups()
+		}
+		r.removeOldBackups(now)
+	}
+}
+
+// compressBackups gzips the backups that aren't compressed yet, including
+// those left uncompressed by an earlier run
+func (r *rotatingFile) compressBackups() {
+	for _, backup := range r.backups() {
+		if strings.HasSuffix(backup.path, ".gz") {
+			continue
+		}
+		if err := compressFile(backup.path); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to compress rotated log file %s: %v\n", backup.path, err)
+		}
+	}
+}
+
+// backupName returns the name of a log file rotated at t
+func (r *rotatingFile) backupName(t time.Time) string {
+	dir := filepath.Dir(r.path)
+	name := filepath.Base(r.path)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext)
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, t.UTC().Format(backupTimeFormat), ext))
+}
+
+// backups returns the rotated log files on disk, newest first
+func (r *rotatingFile) backups() []backupFile {
+	dir := filepath.Dir(r.path)
+	name := filepath.Base(r.path)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(entry.Name(), ".gz")
+		if !strings.HasPrefix(stamp, prefix) || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimPrefix(stamp, prefix), ext)
+
+		timestamp, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, entry.Name()), timestamp: timestamp})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+	return backups
+}
+
+// removeOldBackups deletes backups beyond maxBackups or older than maxAge at
+// now
+func (r *rotatingFile) removeOldBackups(now time.Time) {
+	if r.maxBackups <= 0 && r.maxAge <= 0 {
+		return
+	}
+
+	cutoff := now.Add(-r.maxAge)
+	for i, backup := range r.backups() {
+		tooMany := r.maxBackups > 0 && i >= r.maxBackups
+		tooOld := r.maxAge > 0 && backup.timestamp.Before(cutoff)
+		if tooMany || tooOld {
+			_ = os.Remove(backup.path)
+		}
+	}
+}
+
+// compressFile gzips path to path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
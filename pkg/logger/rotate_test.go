@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	r, err := newRotatingFile(path, Config{MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Failed to open rotating file: %v", err)
+	}
+	defer r.Close()
+
+	r.maxSize = 10
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		if _, err := r.Write([]byte("12345678\n")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		now = now.Add(time.Minute)
+	}
+	// Close waits for the backups to be removed
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if string(data) != "12345678\n" {
+		t.Errorf("Expected only the last write in the active file, got %q", string(data))
+	}
+
+	backups := r.backups()
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups to be kept, got %d", len(backups))
+	}
+	if want := filepath.Join(dir, "app-2024-01-01T00-03-00.000.log"); backups[0].path != want {
+		t.Errorf("Expected newest backup %s, got %s", want, backups[0].path)
+	}
+}
+
+func TestRotatingFileMaxAgeAndCompress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	// A backup left by an earlier run that is past the maximum age
+	old := filepath.Join(dir, "app-2023-12-01T00-00-00.000.log")
+	if err := os.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("Failed to create old backup: %v", err)
+	}
+
+	r, err := newRotatingFile(path, Config{MaxAge: 7, Compress: true})
+	if err != nil {
+		t.Fatalf("Failed to open rotating file: %v", err)
+	}
+	defer r.Close()
+
+	r.maxSize = 10
+	r.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Write([]byte("12345678\n")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	// Close waits for the backups to be compressed and removed
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected backup older than max age to be removed")
+	}
+
+	backups := r.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0].path, ".log.gz") {
+		t.Fatalf("Expected a single compressed backup, got %+v", backups)
+	}
+
+	file, err := os.Open(backups[0].path)
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to read compressed backup: %v", err)
+	}
+	data, _ := io.ReadAll(gz)
+	if string(data) != "12345678\n" {
+		t.Errorf("Unexpected compressed backup contents %q", string(data))
+	}
+}
+
+func TestLoggerWithRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	logger, err := NewLogger(Config{Level: "info", Format: "json", OutputPath: path, ErrorPath: path, MaxSize: 1})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Info("info message")
+	logger.Error("error message")
+	_ = logger.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(data), "info message") || !strings.Contains(string(data), "error message") {
+		t.Errorf("Expected both messages in the shared log file, got %q", string(data))
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultSamplingTick is the sampling interval used when none is configured
+const defaultSamplingTick = time.Second
+
+// SamplingConfig limits how often identical log entries are written. Within
+// each tick, the first Initial entries with the same level and message are
+// logged and after that only every Thereafter-th one, so a collector logging
+// the same warning thousands of times a minute can't flood the log pipeline.
+// Sampling is disabled for a level when both Initial and Thereafter are 0.
+type SamplingConfig struct {
+	// Initial is the number of identical entries logged per tick
+	Initial int `yaml:"initial" validate:"min=0"`
+	// Thereafter logs every Thereafter-th identical entry once Initial is
+	// reached; 0 drops them all
+	Thereafter int `yaml:"thereafter" validate:"min=0"`
+	// Tick is the sampling interval (default 1s)
+	Tick time.Duration `yaml:"tick"`
+	// Levels overrides Initial and Thereafter for individual levels, keyed by
+	// level name (debug, info, warn, error)
+	Levels map[string]LevelSamplingConfig `yaml:"levels"`
+}
+
+// LevelSamplingConfig holds the sampling limits for a single level
+type LevelSamplingConfig struct {
+	Initial    int `yaml:"initial" validate:"min=0"`
+	Thereafter int `yaml:"thereafter" validate:"min=0"`
+}
+
+// sampledLevels are the levels sampling applies to; fatal entries are always logged
+var sampledLevels = []zapcore.Level{
+	zapcore.DebugLevel,
+	zapcore.InfoLevel,
+	zapcore.WarnLevel,
+	zapcore.ErrorLevel,
+}
+
+// newSampledCore wraps core so each level is sampled with its own limits
+func newSampledCore(core zapcore.Core, config SamplingConfig) (zapcore.Core, error) {
+	limits := make(map[zapcore.Level]LevelSamplingConfig, len(sampledLevels))
+	for _, lvl := range sampledLevels {
+		limits[lvl] = LevelSamplingConfig{Initial: config.Initial, Thereafter: config.Thereafter}
+	}
+	for name, levelConfig := range config.Levels {
+		lvl, err := parseLogLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sampling level %s: %w", name, err)
+		}
+		if _, sampled := limits[lvl]; !sampled {
+			return nil, fmt.Errorf("sampling is not supported for level %s", name)
+		}
+		limits[lvl] = levelConfig
+	}
+
+	tick := config.Tick
+	if tick <= 0 {
+		tick = defaultSamplingTick
+	}
+
+	cores := make([]zapcore.Core, 0, len(sampledLevels)+1)
+	sampling := false
+	for _, lvl := range sampledLevels {
+		levelCore := zapcore.Core(&levelFilterCore{Core: core, level: lvl})
+		if limit := limits[lvl]; limit.Initial > 0 || limit.Thereafter > 0 {
+			levelCore = zapcore.NewSamplerWithOptions(levelCore, tick, limit.Initial, limit.Thereafter)
+			sampling = true
+		}
+		cores = append(cores, levelCore)
+	}
+	if !sampling {
+		return core, nil
+	}
+
+	// Levels above error are never sampled
+	cores = append(cores, &levelFilterCore{Core: core, level: zapcore.DPanicLevel, orAbove: true})
+	return zapcore.NewTee(cores...), nil
+}
+
+// levelFilterCore passes through only entries of a single level, or of that
+// level and above when orAbove is set
+type levelFilterCore struct {
+	zapcore.Core
+	level   zapcore.Level
+	orAbove bool
+}
+
+// Enabled reports whether the wrapped core logs lvl and lvl passes the filter
+func (c *levelFilterCore) Enabled(lvl zapcore.Level) bool {
+	if lvl != c.level && !(c.orAbove && lvl > c.level) {
+		return false
+	}
+	return c.Core.Enabled(lvl)
+}
+
+// With adds structured context to the wrapped core
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), level: c.level, orAbove: c.orAbove}
+}
+
+// Check adds the wrapped core to the checked entry if the entry passes the filter
+func (c *levelFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
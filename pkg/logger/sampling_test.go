@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerSampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	logger, err := NewLogger(Config{
+		Level:      "debug",
+		Format:     "json",
+		OutputPath: path,
+		ErrorPath:  path,
+		Sampling: SamplingConfig{
+			Initial:    2,
+			Thereafter: 0,
+			Tick:       time.Minute,
+			Levels: map[string]LevelSamplingConfig{
+				"error": {Initial: 3, Thereafter: 5},
+				"info":  {},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		logger.Warn("repeated warning")
+		logger.Info("repeated info")
+		logger.Error("repeated error")
+	}
+	logger.Warn("different warning")
+	_ = logger.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	output := string(data)
+
+	counts := map[string]int{
+		"repeated warning":  2,  // initial 2, rest dropped
+		"repeated info":     10, // sampling disabled for info
+		"repeated error":    4,  // initial 3, then the 5th after that (8th overall)
+		"different warning": 1,  // sampled per message
+	}
+	for message, want := range counts {
+		if got := strings.Count(output, `"message":"`+message+`"`); got != want {
+			t.Errorf("Expected %d %q entries, got %d", want, message, got)
+		}
+	}
+}
+
+func TestLoggerSamplingInvalidLevel(t *testing.T) {
+	_, err := NewLogger(Config{
+		Level:    "info",
+		Format:   "json",
+		Sampling: SamplingConfig{Levels: map[string]LevelSamplingConfig{"verbose": {Initial: 1}}},
+	})
+	if err == nil {
+		t.Error("Expected error for unknown sampling level")
+	}
+
+	_, err = NewLogger(Config{
+		Level:    "info",
+		Format:   "json",
+		Sampling: SamplingConfig{Levels: map[string]LevelSamplingConfig{"fatal": {Initial: 1}}},
+	})
+	if err == nil {
+		t.Error("Expected error for sampling fatal entries")
+	}
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	// defaultSyslogPort is used when a syslog URL has no port
+	defaultSyslogPort = "514"
+	// defaultSyslogFacility is used when a syslog URL has no facility
+	defaultSyslogFacility = "local0"
+	// defaultSyslogTag identifies the monitor's messages
+	defaultSyslogTag = "aws-monitor"
+)
+
+// syslogFacilities are the facility names accepted in syslog URLs
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogTarget is a parsed syslog output path
+type syslogTarget struct {
+	network  string
+	address  string
+	facility string
+	tag      string
+}
+
+// isSyslogPath reports whether path is a syslog URL rather than a file
+func isSyslogPath(path string) bool {
+	return strings.HasPrefix(path, "syslog://") || strings.HasPrefix(path, "syslog+tcp://")
+}
+
+// parseSyslogURL parses syslog://host[:port][?facility=local0&tag=name].
+// syslog:// sends over UDP and syslog+tcp:// over TCP.
+func parseSyslogURL(path string) (syslogTarget, error) {
+	parsed, err := url.Parse(path)
+	if err != nil {
+		return syslogTarget{}, fmt.Errorf("invalid syslog URL %s: %w", path, err)
+	}
+
+	target := syslogTarget{
+		network:  "udp",
+		facility: defaultSyslogFacility,
+		tag:      defaultSyslogTag,
+	}
+	switch parsed.Scheme {
+	case "syslog":
+	case "syslog+tcp":
+		target.network = "tcp"
+	default:
+		return syslogTarget{}, fmt.Errorf("invalid syslog URL %s: unsupported scheme %s", path, parsed.Scheme)
+	}
+
+	if parsed.Hostname() == "" {
+		return syslogTarget{}, fmt.Errorf("invalid syslog URL %s: missing host", path)
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = defaultSyslogPort
+	}
+	target.address = net.JoinHostPort(parsed.Hostname(), port)
+
+	query := parsed.Query()
+	if facility := query.Get("facility"); facility != "" {
+		target.facility = strings.ToLower(facility)
+		if !isSyslogFacility(target.facility) {
+			return syslogTarget{}, fmt.Errorf("invalid syslog URL %s: unknown facility %s", path, facility)
+		}
+	}
+	if tag := query.Get("tag"); tag != "" {
+		target.tag = tag
+	}
+
+	return target, nil
+}
+
+// isSyslogFacility reports whether name is a known syslog facility
+func isSyslogFacility(name string) bool {
+	for _, facility := range syslogFacilities {
+		if facility == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"fmt"
+	"runtime"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newSyslogCore reports that syslog output is not available on this platform
+func newSyslogCore(path string, _ zapcore.Encoder, _ zapcore.LevelEnabler) (zapcore.Core, error) {
+	if _, err := parseSyslogURL(path); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("syslog output is not supported on %s", runtime.GOOS)
+}
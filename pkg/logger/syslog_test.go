@@ -0,0 +1,49 @@
+package logger
+
+import "testing"
+
+func TestParseSyslogURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    syslogTarget
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			path: "syslog://logs.example.com",
+			want: syslogTarget{network: "udp", address: "logs.example.com:514", facility: "local0", tag: "aws-monitor"},
+		},
+		{
+			name: "facility and tag",
+			path: "syslog://10.0.0.5:1514?facility=LOCAL3&tag=monitor",
+			want: syslogTarget{network: "udp", address: "10.0.0.5:1514", facility: "local3", tag: "monitor"},
+		},
+		{
+			name: "tcp",
+			path: "syslog+tcp://logs.example.com:6514?facility=daemon",
+			want: syslogTarget{network: "tcp", address: "logs.example.com:6514", facility: "daemon", tag: "aws-monitor"},
+		},
+		{name: "missing host", path: "syslog://:514", wantErr: true},
+		{name: "unknown facility", path: "syslog://logs.example.com?facility=local9", wantErr: true},
+		{name: "unsupported scheme", path: "syslog+tls://logs.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSyslogURL(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %s", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
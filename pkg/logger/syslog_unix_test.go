@@ -0,0 +1,54 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerSyslogOutput(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP listener not available: %v", err)
+	}
+	defer conn.Close()
+
+	path := "syslog://" + conn.LocalAddr().String() + "?facility=local0&tag=aws-monitor-test"
+	logger, err := NewLogger(Config{Level: "info", Format: "json", OutputPath: path, ErrorPath: path})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Info("collection complete")
+	logger.Error("collection failed")
+
+	// local0 is facility 16: info is <134>, err is <131>
+	for _, want := range []struct{ priority, message string }{
+		{"<134>", "collection complete"},
+		{"<131>", "collection failed"},
+	} {
+		buf := make([]byte, 4096)
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("Failed to read syslog message: %v", err)
+		}
+
+		packet := string(buf[:n])
+		if !strings.HasPrefix(packet, want.priority) {
+			t.Errorf("Expected priority %s, got %q", want.priority, packet)
+		}
+		if !strings.Contains(packet, "aws-monitor-test") || !strings.Contains(packet, want.message) {
+			t.Errorf("Expected tagged message %q, got %q", want.message, packet)
+		}
+	}
+}
+
+func TestLoggerSyslogInvalidURL(t *testing.T) {
+	if _, err := NewLogger(Config{Level: "info", Format: "json", OutputPath: "syslog://logs.example.com?facility=bogus"}); err == nil {
+		t.Error("Expected error for an invalid syslog URL")
+	}
+}